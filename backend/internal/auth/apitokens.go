@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Verbs an API token scope may grant
+const (
+	VerbRead  = "read"
+	VerbWrite = "write"
+)
+
+// TokenScope restricts what an API token may do. Buckets holds bucket name patterns in
+// path.Match syntax (e.g. "artifacts-*") and Verbs the verbs allowed on them.
+type TokenScope struct {
+	Buckets []string `json:"buckets"`
+	Verbs   []string `json:"verbs"`
+}
+
+// Validate checks that the scope names at least one bucket pattern and one known verb
+func (s *TokenScope) Validate() error {
+	if len(s.Buckets) == 0 {
+		return errors.New("scope must list at least one bucket pattern")
+	}
+	for _, pattern := range s.Buckets {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid bucket pattern %q", pattern)
+		}
+	}
+
+	if len(s.Verbs) == 0 {
+		return errors.New("scope must list at least one verb")
+	}
+	for _, verb := range s.Verbs {
+		if verb != VerbRead && verb != VerbWrite {
+			return fmt.Errorf("invalid verb %q, expected %q or %q", verb, VerbRead, VerbWrite)
+		}
+	}
+
+	return nil
+}
+
+// AllowsBucket reports whether the bucket matches one of the scope's patterns
+func (s *TokenScope) AllowsBucket(bucketName string) bool {
+	for _, pattern := range s.Buckets {
+		if ok, _ := path.Match(pattern, bucketName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsVerb reports whether the scope grants the verb
+func (s *TokenScope) AllowsVerb(verb string) bool {
+	return slices.Contains(s.Verbs, verb)
+}
+
+// APIToken describes an issued API token. The token itself is a signed JWT carrying
+// the ID and is never stored, so a lost token cannot be recovered, only revoked.
+type APIToken struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Owner     string      `json:"owner"`
+	Scope     *TokenScope `json:"scope,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// ErrTokenNotFound is returned when an API token does not exist or belongs to someone else
+var ErrTokenNotFound = errors.New("api token not found")
+
+// ErrTokenLimit is returned when a user already holds the maximum number of API tokens
+var ErrTokenLimit = errors.New("api token limit reached")
+
+// APITokenStore keeps the issued API tokens. A token is only accepted while it is in the
+// store, which is how tokens are revoked. When a path is configured the store is persisted
+// as JSON so tokens survive restarts; otherwise it lives in memory only.
+type APITokenStore struct {
+	mu         sync.RWMutex
+	path       string
+	maxPerUser int
+	tokens     map[string]APIToken
+}
+
+// apiTokenFile is the on-disk representation of the API token store
+type apiTokenFile struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// NewAPITokenStore creates an API token store, loading previously issued tokens from path if set
+func NewAPITokenStore(path string, maxPerUser int) (*APITokenStore, error) {
+	store := &APITokenStore{
+		path:       path,
+		maxPerUser: maxPerUser,
+		tokens:     make(map[string]APIToken),
+	}
+
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api token file: %w", err)
+	}
+
+	var file apiTokenFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse api token file: %w", err)
+	}
+	for _, token := range file.Tokens {
+		store.tokens[token.ID] = token
+	}
+
+	return store, nil
+}
+
+// List returns the unexpired tokens of owner, oldest first
+func (s *APITokenStore) List(owner string, now time.Time) []APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]APIToken, 0)
+	for _, token := range s.tokens {
+		if token.Owner == owner && now.Before(token.ExpiresAt) {
+			result = append(result, token)
+		}
+	}
+	slices.SortFunc(result, func(a, b APIToken) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return result
+}
+
+// Active reports whether the token with the given ID exists and has not expired
+func (s *APITokenStore) Active(id string, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.tokens[id]
+	return ok && now.Before(token.ExpiresAt)
+}
+
+// Add stores a new token, dropping the owner's expired tokens first. It returns
+// ErrTokenLimit when the owner already holds maxPerUser tokens.
+func (s *APITokenStore) Add(token APIToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, existing := range s.tokens {
+		if existing.Owner != token.Owner {
+			continue
+		}
+		if !token.CreatedAt.Before(existing.ExpiresAt) {
+			delete(s.tokens, id)
+			continue
+		}
+		count++
+	}
+	if count >= s.maxPerUser {
+		return ErrTokenLimit
+	}
+
+	s.tokens[token.ID] = token
+	if err := s.save(); err != nil {
+		delete(s.tokens, token.ID)
+		return err
+	}
+	return nil
+}
+
+// Delete revokes a token of owner
+func (s *APITokenStore) Delete(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[id]
+	if !ok || token.Owner != owner {
+		return ErrTokenNotFound
+	}
+
+	delete(s.tokens, id)
+	if err := s.save(); err != nil {
+		s.tokens[id] = token
+		return err
+	}
+	return nil
+}
+
+// save writes the tokens to disk atomically; callers must hold the write lock
+func (s *APITokenStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := apiTokenFile{Tokens: make([]APIToken, 0, len(s.tokens))}
+	for _, token := range s.tokens {
+		file.Tokens = append(file.Tokens, token)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode api tokens: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create api token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write api token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write api token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace api token file: %w", err)
+	}
+
+	return nil
+}
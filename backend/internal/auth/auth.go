@@ -2,12 +2,19 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"golang.org/x/oauth2"
@@ -17,23 +24,65 @@ import (
 type Service struct {
 	authConfig   *config.AuthConfig
 	serverConfig *config.ServerConfig
-	oidcProvider *oidc.Provider
-	oidcVerifier *oidc.IDTokenVerifier
-	oauth2Config *oauth2.Config
 	jwtService   *JWTService
+	apiTokens    *APITokenStore // nil when API tokens are disabled
+	clock        clock.Clock
+
+	// oidc is set once discovery succeeds; until then OIDC logins are refused while the
+	// discovery is retried in the background
+	oidc        atomic.Pointer[oidcClient]
+	oidcStarted atomic.Bool // Whether a discovery attempt has completed
 }
 
+// oidcClient holds what OIDC discovery provides
+type oidcClient struct {
+	provider     *oidc.Provider
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config *oauth2.Config
+}
+
+// States of the OIDC provider reported in the auth configuration
+const (
+	OIDCStatusInitializing = "initializing" // First discovery attempt still running
+	OIDCStatusDegraded     = "degraded"     // Discovery failed and is being retried
+	OIDCStatusReady        = "ready"
+)
+
+// Bounds of the OIDC discovery retries
+const (
+	oidcDiscoveryTimeout    = 10 * time.Second
+	oidcDiscoveryBackoff    = 2 * time.Second // Doubled after each failed attempt
+	oidcDiscoveryMaxBackoff = time.Minute
+)
+
+// ErrOIDCUnavailable is returned by OIDC operations until discovery succeeds
+var ErrOIDCUnavailable = errors.New("OIDC provider is not available yet")
+
+// Authentication methods a session can originate from
+const (
+	AuthMethodNone  = "none"
+	AuthMethodAdmin = "admin"
+	AuthMethodOIDC  = "oidc"
+)
+
 // UserInfo represents authenticated user information
 type UserInfo struct {
-	Username string
-	Email    string
-	Name     string
-	Roles    []string
+	Username   string
+	Email      string
+	Name       string
+	Roles      []string
+	AuthMethod string
+
+	// TokenID and Scope are set when the user authenticated with an API token;
+	// Scope stays nil for tokens that are not restricted
+	TokenID string
+	Scope   *TokenScope
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(authCfg *config.AuthConfig, serverCfg *config.ServerConfig) (*Service, error) {
-	jwtService, err := NewJWTServiceWithKey(authCfg.JWTPrivKey)
+// NewAuthService creates a new authentication service, measuring session, login state and
+// API token expiry against clk
+func NewAuthService(authCfg *config.AuthConfig, serverCfg *config.ServerConfig, clk clock.Clock) (*Service, error) {
+	jwtService, err := NewJWTServiceWithKey(authCfg.JWTPrivKey, authCfg.OIDC.MaxPendingLogins, clk)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize JWT service: %w", err)
 	}
@@ -42,21 +91,71 @@ func NewAuthService(authCfg *config.AuthConfig, serverCfg *config.ServerConfig)
 		authConfig:   authCfg,
 		serverConfig: serverCfg,
 		jwtService:   jwtService,
+		clock:        clk,
 	}
 
-	// Initialize OIDC if enabled
-	if authCfg.OIDC.Enabled {
-		if err := service.initOIDC(); err != nil {
-			return nil, fmt.Errorf("failed to initialize OIDC: %w", err)
+	if authCfg.APITokens.Enabled {
+		service.apiTokens, err = NewAPITokenStore(authCfg.APITokens.Path, authCfg.APITokens.MaxPerUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize API tokens: %w", err)
 		}
 	}
 
 	return service, nil
 }
 
-// initOIDC initializes the OIDC provider and configuration
-func (a *Service) initOIDC() error {
-	ctx := context.Background()
+// RunOIDCDiscovery discovers the OIDC provider, retrying with backoff until it succeeds or
+// ctx is done, so that an unreachable provider at startup only delays OIDC logins instead
+// of failing the whole server. It returns at once when OIDC is disabled.
+func (a *Service) RunOIDCDiscovery(ctx context.Context) {
+	if !a.authConfig.OIDC.Enabled {
+		return
+	}
+
+	backoff := oidcDiscoveryBackoff
+	for attempt := 1; ; attempt++ {
+		err := a.initOIDC(ctx)
+		a.oidcStarted.Store(true)
+		if err == nil {
+			logger.Info().
+				Str("issuer", a.authConfig.OIDC.IssuerURL).
+				Int("attempts", attempt).
+				Msg("OIDC provider available, OIDC logins enabled")
+			return
+		}
+
+		logger.Warn().
+			Err(err).
+			Str("issuer", a.authConfig.OIDC.IssuerURL).
+			Int("attempt", attempt).
+			Dur("retry_in", backoff).
+			Msg("OIDC discovery failed, OIDC logins unavailable until it succeeds")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, oidcDiscoveryMaxBackoff)
+	}
+}
+
+// OIDCStatus reports whether the OIDC provider can be used yet
+func (a *Service) OIDCStatus() string {
+	switch {
+	case a.oidc.Load() != nil:
+		return OIDCStatusReady
+	case a.oidcStarted.Load():
+		return OIDCStatusDegraded
+	default:
+		return OIDCStatusInitializing
+	}
+}
+
+// initOIDC discovers the OIDC provider and builds the verifier and OAuth2 configuration
+func (a *Service) initOIDC(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, oidcDiscoveryTimeout)
+	defer cancel()
 
 	// Create OIDC provider
 	provider, err := oidc.NewProvider(ctx, a.authConfig.OIDC.IssuerURL)
@@ -64,28 +163,28 @@ func (a *Service) initOIDC() error {
 		return fmt.Errorf("failed to create OIDC provider: %w", err)
 	}
 
-	a.oidcProvider = provider
-
 	// Create ID token verifier
 	verifierConfig := &oidc.Config{
 		ClientID:        a.authConfig.OIDC.ClientID,
 		SkipIssuerCheck: a.authConfig.OIDC.SkipIssuerCheck,
 		SkipExpiryCheck: a.authConfig.OIDC.SkipExpiryCheck,
 	}
-	a.oidcVerifier = provider.Verifier(verifierConfig)
 
 	// Construct redirect URL from server config
 	// Use root_url if set, otherwise construct from protocol/domain
 	redirectURL := a.serverConfig.RootURL + "/auth/oidc/callback"
 
-	// Create OAuth2 config
-	a.oauth2Config = &oauth2.Config{
-		ClientID:     a.authConfig.OIDC.ClientID,
-		ClientSecret: a.authConfig.OIDC.ClientSecret,
-		RedirectURL:  redirectURL,
-		Endpoint:     provider.Endpoint(),
-		Scopes:       a.authConfig.OIDC.Scopes,
-	}
+	a.oidc.Store(&oidcClient{
+		provider: provider,
+		verifier: provider.Verifier(verifierConfig),
+		oauth2Config: &oauth2.Config{
+			ClientID:     a.authConfig.OIDC.ClientID,
+			ClientSecret: a.authConfig.OIDC.ClientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       a.authConfig.OIDC.Scopes,
+		},
+	})
 
 	return nil
 }
@@ -137,20 +236,22 @@ func ParseBasicAuth(authHeader string) (username, password string, ok bool) {
 
 // GetAuthorizationURL returns the OIDC authorization URL for login
 func (a *Service) GetAuthorizationURL(state string) (string, error) {
-	if a.oauth2Config == nil {
-		return "", fmt.Errorf("OIDC not initialized")
+	client := a.oidc.Load()
+	if client == nil {
+		return "", ErrOIDCUnavailable
 	}
 
-	return a.oauth2Config.AuthCodeURL(state), nil
+	return client.oauth2Config.AuthCodeURL(state), nil
 }
 
 // ExchangeCode exchanges an authorization code for tokens
 func (a *Service) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	if a.oauth2Config == nil {
-		return nil, fmt.Errorf("OIDC not initialized")
+	client := a.oidc.Load()
+	if client == nil {
+		return nil, ErrOIDCUnavailable
 	}
 
-	token, err := a.oauth2Config.Exchange(ctx, code)
+	token, err := client.oauth2Config.Exchange(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -160,12 +261,13 @@ func (a *Service) ExchangeCode(ctx context.Context, code string) (*oauth2.Token,
 
 // VerifyIDToken verifies an OIDC ID token and extracts user info
 func (a *Service) VerifyIDToken(ctx context.Context, rawIDToken string) (*UserInfo, error) {
-	if a.oidcVerifier == nil {
-		return nil, fmt.Errorf("OIDC not initialized")
+	client := a.oidc.Load()
+	if client == nil {
+		return nil, ErrOIDCUnavailable
 	}
 
 	// Verify the ID token
-	idToken, err := a.oidcVerifier.Verify(ctx, rawIDToken)
+	idToken, err := client.verifier.Verify(ctx, rawIDToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify ID token: %w", err)
 	}
@@ -178,9 +280,10 @@ func (a *Service) VerifyIDToken(ctx context.Context, rawIDToken string) (*UserIn
 
 	// Extract user information using configured attributes
 	userInfo := &UserInfo{
-		Username: extractClaim(claims, a.authConfig.OIDC.UsernameAttribute),
-		Email:    extractClaim(claims, a.authConfig.OIDC.EmailAttribute),
-		Name:     extractClaim(claims, a.authConfig.OIDC.NameAttribute),
+		Username:   extractClaim(claims, a.authConfig.OIDC.UsernameAttribute),
+		Email:      extractClaim(claims, a.authConfig.OIDC.EmailAttribute),
+		Name:       extractClaim(claims, a.authConfig.OIDC.NameAttribute),
+		AuthMethod: AuthMethodOIDC,
 	}
 
 	// Extract roles if configured
@@ -193,15 +296,16 @@ func (a *Service) VerifyIDToken(ctx context.Context, rawIDToken string) (*UserIn
 
 // GetUserInfo retrieves user information from the OIDC provider
 func (a *Service) GetUserInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error) {
-	if a.oidcProvider == nil {
-		return nil, fmt.Errorf("OIDC not initialized")
+	client := a.oidc.Load()
+	if client == nil {
+		return nil, ErrOIDCUnavailable
 	}
 
 	// Create OAuth2 token source
-	tokenSource := a.oauth2Config.TokenSource(ctx, token)
+	tokenSource := client.oauth2Config.TokenSource(ctx, token)
 
 	// Get user info from the provider
-	userInfoEndpoint, err := a.oidcProvider.UserInfo(ctx, tokenSource)
+	userInfoEndpoint, err := client.provider.UserInfo(ctx, tokenSource)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -214,9 +318,10 @@ func (a *Service) GetUserInfo(ctx context.Context, token *oauth2.Token) (*UserIn
 
 	// Build user info
 	userInfo := &UserInfo{
-		Username: extractClaim(claims, a.authConfig.OIDC.UsernameAttribute),
-		Email:    extractClaim(claims, a.authConfig.OIDC.EmailAttribute),
-		Name:     extractClaim(claims, a.authConfig.OIDC.NameAttribute),
+		Username:   extractClaim(claims, a.authConfig.OIDC.UsernameAttribute),
+		Email:      extractClaim(claims, a.authConfig.OIDC.EmailAttribute),
+		Name:       extractClaim(claims, a.authConfig.OIDC.NameAttribute),
+		AuthMethod: AuthMethodOIDC,
 	}
 
 	// Extract roles if configured
@@ -242,6 +347,15 @@ func (a *Service) IsAdmin(userInfo *UserInfo) bool {
 	return false
 }
 
+// IsAdminUser checks if the user has administrative privileges: either they logged in
+// with the configured admin credentials, or their OIDC roles include the admin role
+func (a *Service) IsAdminUser(userInfo *UserInfo) bool {
+	if userInfo == nil {
+		return false
+	}
+	return userInfo.AuthMethod == AuthMethodAdmin || a.IsAdmin(userInfo)
+}
+
 // Helper functions
 
 // extractClaim extracts a string claim from the claims map
@@ -320,6 +434,11 @@ func (a *Service) GenerateStateToken() (string, error) {
 	return a.jwtService.GenerateStateToken()
 }
 
+// RunStateJanitor removes expired CSRF state tokens until ctx is canceled
+func (a *Service) RunStateJanitor(ctx context.Context) {
+	a.jwtService.RunStateJanitor(ctx)
+}
+
 // ValidateAndConsumeState validates and consumes a CSRF state token
 func (a *Service) ValidateAndConsumeState(token string) bool {
 	return a.jwtService.ValidateAndConsumeState(token)
@@ -337,10 +456,72 @@ func (a *Service) ValidateSessionToken(tokenString string) (*UserInfo, error) {
 		return nil, err
 	}
 
+	// API tokens are only honored while they have not been revoked
+	if claims.ID != "" && (a.apiTokens == nil || !a.apiTokens.Active(claims.ID, a.clock.Now())) {
+		return nil, ErrTokenNotFound
+	}
+
 	return &UserInfo{
-		Username: claims.Username,
-		Email:    claims.Email,
-		Name:     claims.Name,
-		Roles:    claims.Roles,
+		Username:   claims.Username,
+		Email:      claims.Email,
+		Name:       claims.Name,
+		Roles:      claims.Roles,
+		AuthMethod: claims.AuthMethod,
+		TokenID:    claims.ID,
+		Scope:      claims.Scope,
 	}, nil
 }
+
+// APITokensEnabled reports whether users may issue API tokens
+func (a *Service) APITokensEnabled() bool {
+	return a.apiTokens != nil
+}
+
+// IssueAPIToken creates an API token acting as userInfo for ttl, optionally restricted
+// to scope. The signed token is returned once and cannot be retrieved again.
+func (a *Service) IssueAPIToken(userInfo *UserInfo, name string, scope *TokenScope, ttl time.Duration) (string, *APIToken, error) {
+	if a.apiTokens == nil {
+		return "", nil, fmt.Errorf("api tokens are disabled")
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	now := a.clock.Now().UTC()
+	token := APIToken{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Owner:     userInfo.Username,
+		Scope:     scope,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	signed, err := a.jwtService.GenerateAPIToken(userInfo, token.ID, scope, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := a.apiTokens.Add(token); err != nil {
+		return "", nil, err
+	}
+
+	return signed, &token, nil
+}
+
+// ListAPITokens returns the unexpired API tokens of owner
+func (a *Service) ListAPITokens(owner string) []APIToken {
+	if a.apiTokens == nil {
+		return []APIToken{}
+	}
+	return a.apiTokens.List(owner, a.clock.Now())
+}
+
+// RevokeAPIToken revokes an API token of owner
+func (a *Service) RevokeAPIToken(owner, id string) error {
+	if a.apiTokens == nil {
+		return ErrTokenNotFound
+	}
+	return a.apiTokens.Delete(owner, id)
+}
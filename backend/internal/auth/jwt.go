@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"container/list"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/x509"
@@ -10,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"Noooste/garage-ui/pkg/clock"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -17,12 +21,17 @@ type JWTService struct {
 	privateKey ed25519.PrivateKey
 	publicKey  ed25519.PublicKey
 	stateStore *StateStore
+	clock      clock.Clock // Issues and checks token and state expiry
 	mu         sync.RWMutex
 }
 
+// StateStore holds the OAuth state tokens of logins in progress. Every state lives for
+// stateTTL, so insertion order is also expiry order and the oldest state is at the front.
 type StateStore struct {
-	mu     sync.RWMutex
-	states map[string]StateData
+	mu         sync.Mutex
+	states     map[string]*list.Element // Values are *pendingState
+	order      *list.List
+	maxEntries int
 }
 
 type StateData struct {
@@ -30,19 +39,99 @@ type StateData struct {
 	ExpiresAt time.Time
 }
 
+// pendingState is a state token with its data, as kept in StateStore.order
+type pendingState struct {
+	token string
+	StateData
+}
+
+const (
+	// stateTTL is how long a login may take between redirect and callback
+	stateTTL = 10 * time.Minute
+
+	// stateCleanupInterval is how often expired states are removed
+	stateCleanupInterval = time.Minute
+
+	// defaultMaxStates bounds the state store when no limit is configured
+	defaultMaxStates = 10000
+)
+
+// newStateStore creates a state store keeping at most maxEntries states
+func newStateStore(maxEntries int) *StateStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxStates
+	}
+	return &StateStore{
+		states:     make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// add stores a new state, evicting the oldest ones when the store is full
+func (s *StateStore) add(token string, data StateData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.order.Len() >= s.maxEntries {
+		s.remove(s.order.Front())
+	}
+	s.states[token] = s.order.PushBack(&pendingState{token: token, StateData: data})
+}
+
+// consume removes a state and reports whether it existed and had not expired
+func (s *StateStore) consume(token string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, exists := s.states[token]
+	if !exists {
+		return false
+	}
+	s.remove(element)
+
+	return !clock.Expired(now, element.Value.(*pendingState).ExpiresAt)
+}
+
+// removeExpired drops the states expired at now
+func (s *StateStore) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if !clock.Expired(now, element.Value.(*pendingState).ExpiresAt) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove drops one state; the caller holds s.mu
+func (s *StateStore) remove(element *list.Element) {
+	delete(s.states, element.Value.(*pendingState).token)
+	s.order.Remove(element)
+}
+
 type SessionClaims struct {
-	Username string   `json:"username"`
-	Email    string   `json:"email"`
-	Name     string   `json:"name"`
-	Roles    []string `json:"roles"`
+	Username   string   `json:"username"`
+	Email      string   `json:"email"`
+	Name       string   `json:"name"`
+	Roles      []string `json:"roles"`
+	AuthMethod string   `json:"auth_method,omitempty"`
+
+	// Scope is set on API tokens restricted to some buckets and verbs
+	Scope *TokenScope `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func NewJWTService() (*JWTService, error) {
-	return NewJWTServiceWithKey("")
+	return NewJWTServiceWithKey("", defaultMaxStates, clock.Real)
 }
 
-func NewJWTServiceWithKey(privateKeyPEM string) (*JWTService, error) {
+// NewJWTServiceWithKey creates a JWT service signing with the given key, or with a fresh
+// one when privateKeyPEM is empty, that keeps at most maxStates login states. Expiry is
+// measured against clk.
+func NewJWTServiceWithKey(privateKeyPEM string, maxStates int, clk clock.Clock) (*JWTService, error) {
 	var privateKey ed25519.PrivateKey
 	var publicKey ed25519.PublicKey
 	var err error
@@ -65,9 +154,8 @@ func NewJWTServiceWithKey(privateKeyPEM string) (*JWTService, error) {
 	return &JWTService{
 		privateKey: privateKey,
 		publicKey:  publicKey,
-		stateStore: &StateStore{
-			states: make(map[string]StateData),
-		},
+		stateStore: newStateStore(maxStates),
+		clock:      clk,
 	}, nil
 }
 
@@ -104,51 +192,47 @@ func (j *JWTService) GenerateStateToken() (string, error) {
 
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
 
-	j.stateStore.mu.Lock()
-	defer j.stateStore.mu.Unlock()
-
-	now := time.Now()
-	j.stateStore.states[token] = StateData{
+	now := j.clock.Now()
+	j.stateStore.add(token, StateData{
 		Created:   now,
-		ExpiresAt: now.Add(10 * time.Minute),
-	}
-
-	go j.cleanupExpiredStates()
+		ExpiresAt: now.Add(stateTTL),
+	})
 
 	return token, nil
 }
 
 func (j *JWTService) ValidateAndConsumeState(token string) bool {
-	j.stateStore.mu.Lock()
-	defer j.stateStore.mu.Unlock()
-
-	state, exists := j.stateStore.states[token]
-	if !exists {
-		return false
-	}
-
-	if time.Now().After(state.ExpiresAt) {
-		delete(j.stateStore.states, token)
-		return false
-	}
-
-	delete(j.stateStore.states, token)
-	return true
+	return j.stateStore.consume(token, j.clock.Now())
 }
 
-func (j *JWTService) cleanupExpiredStates() {
-	j.stateStore.mu.Lock()
-	defer j.stateStore.mu.Unlock()
-
-	now := time.Now()
-	for token, state := range j.stateStore.states {
-		if now.After(state.ExpiresAt) {
-			delete(j.stateStore.states, token)
+// RunStateJanitor removes expired login states every stateCleanupInterval until ctx is
+// canceled. States that are never consumed would otherwise stay until evicted.
+func (j *JWTService) RunStateJanitor(ctx context.Context) {
+	ticker := j.clock.NewTicker(stateCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			j.stateStore.removeExpired(j.clock.Now())
 		}
 	}
 }
 
 func (j *JWTService) GenerateToken(userInfo *UserInfo, sessionMaxAge int) (string, error) {
+	now := j.clock.Now()
+	return j.signClaims(userInfo, "", nil, now, now.Add(time.Duration(sessionMaxAge)*time.Second))
+}
+
+// GenerateAPIToken signs an API token with the given ID, optional scope and lifetime
+func (j *JWTService) GenerateAPIToken(userInfo *UserInfo, id string, scope *TokenScope, issuedAt, expiresAt time.Time) (string, error) {
+	return j.signClaims(userInfo, id, scope, issuedAt, expiresAt)
+}
+
+// signClaims signs the session claims of userInfo; id and scope are only set on API tokens
+func (j *JWTService) signClaims(userInfo *UserInfo, id string, scope *TokenScope, now, expiresAt time.Time) (string, error) {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
 
@@ -156,15 +240,15 @@ func (j *JWTService) GenerateToken(userInfo *UserInfo, sessionMaxAge int) (strin
 		return "", fmt.Errorf("private key not initialized")
 	}
 
-	now := time.Now()
-	expiresAt := now.Add(time.Duration(sessionMaxAge) * time.Second)
-
 	claims := SessionClaims{
-		Username: userInfo.Username,
-		Email:    userInfo.Email,
-		Name:     userInfo.Name,
-		Roles:    userInfo.Roles,
+		Username:   userInfo.Username,
+		Email:      userInfo.Email,
+		Name:       userInfo.Name,
+		Roles:      userInfo.Roles,
+		AuthMethod: userInfo.AuthMethod,
+		Scope:      scope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(now),
@@ -193,7 +277,7 @@ func (j *JWTService) ValidateToken(tokenString string) (*SessionClaims, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return j.publicKey, nil
-	})
+	}, jwt.WithTimeFunc(j.clock.Now))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/pkg/clock"
+)
+
+func TestGenerateStateTokenStartsNoGoroutines(t *testing.T) {
+	jwtService, err := NewJWTService()
+	if err != nil {
+		t.Fatalf("NewJWTService failed: %v", err)
+	}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 1000; i++ {
+		if _, err := jwtService.GenerateStateToken(); err != nil {
+			t.Fatalf("GenerateStateToken failed: %v", err)
+		}
+	}
+	runtime.Gosched()
+
+	// A little slack for goroutines of the runtime and the test framework
+	if after := runtime.NumGoroutine(); after > before+2 {
+		t.Errorf("goroutines grew from %d to %d over 1000 logins", before, after)
+	}
+}
+
+func TestStateStoreEvictsOldest(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newStateStore(3)
+	for i := 0; i < 5; i++ {
+		store.add(fmt.Sprintf("state-%d", i), StateData{Created: now, ExpiresAt: now.Add(stateTTL)})
+	}
+
+	if got := len(store.states); got != 3 {
+		t.Errorf("store holds %d states, want 3", got)
+	}
+	if got := store.order.Len(); got != 3 {
+		t.Errorf("store orders %d states, want 3", got)
+	}
+
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{token: "state-0", want: false},
+		{token: "state-1", want: false},
+		{token: "state-2", want: true},
+		{token: "state-3", want: true},
+		{token: "state-4", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			if got := store.consume(tt.token, now); got != tt.want {
+				t.Errorf("consume = %v, want %v", got, tt.want)
+			}
+			if store.consume(tt.token, now) {
+				t.Error("state was accepted twice")
+			}
+		})
+	}
+}
+
+func TestStateStoreDefaultLimit(t *testing.T) {
+	for _, maxEntries := range []int{0, -1} {
+		if got := newStateStore(maxEntries).maxEntries; got != defaultMaxStates {
+			t.Errorf("newStateStore(%d) keeps %d states, want %d", maxEntries, got, defaultMaxStates)
+		}
+	}
+}
+
+func TestSessionExpiresAtMaxAge(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService, err := NewJWTServiceWithKey("", 0, clk)
+	if err != nil {
+		t.Fatalf("NewJWTServiceWithKey failed: %v", err)
+	}
+
+	token, err := jwtService.GenerateToken(&UserInfo{Username: "alice"}, 3600)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	clk.Advance(time.Hour - time.Second)
+	if _, err := jwtService.ValidateToken(token); err != nil {
+		t.Errorf("session rejected a second before it expires: %v", err)
+	}
+	clk.Advance(time.Second)
+	if _, err := jwtService.ValidateToken(token); err == nil {
+		t.Error("session accepted once it expired")
+	}
+}
+
+func TestStateTokenExpiresAtTTL(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	jwtService, err := NewJWTServiceWithKey("", 0, clk)
+	if err != nil {
+		t.Fatalf("NewJWTServiceWithKey failed: %v", err)
+	}
+
+	fresh, err := jwtService.GenerateStateToken()
+	if err != nil {
+		t.Fatalf("GenerateStateToken failed: %v", err)
+	}
+	stale, err := jwtService.GenerateStateToken()
+	if err != nil {
+		t.Fatalf("GenerateStateToken failed: %v", err)
+	}
+
+	clk.Advance(stateTTL - time.Nanosecond)
+	if !jwtService.ValidateAndConsumeState(fresh) {
+		t.Error("state rejected just before it expires")
+	}
+	clk.Advance(time.Nanosecond)
+	if jwtService.ValidateAndConsumeState(stale) {
+		t.Error("state accepted once it expired")
+	}
+}
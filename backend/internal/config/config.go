@@ -2,9 +2,17 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -15,6 +23,13 @@ type Config struct {
 	Auth    AuthConfig    `mapstructure:"auth"`
 	CORS    CORSConfig    `mapstructure:"cors"`
 	Logging LoggingConfig `mapstructure:"logging"`
+	Trash   TrashConfig   `mapstructure:"trash"`
+
+	SelfService SelfServiceConfig `mapstructure:"self_service"`
+	Monitoring  MonitoringConfig  `mapstructure:"monitoring"`
+	Public      PublicConfig      `mapstructure:"public"`
+	Thumbnails  ThumbnailsConfig  `mapstructure:"thumbnails"`
+	Lifecycle   LifecycleConfig   `mapstructure:"lifecycle"`
 }
 
 // ServerConfig contains server-related configuration
@@ -28,27 +43,172 @@ type ServerConfig struct {
 	RootURL         string `mapstructure:"root_url"`          // Full external URL for redirects (e.g., https://garage-ui.example.com)
 	MaxBodySize     int64  `mapstructure:"max_body_size"`     // Maximum request body size in bytes (default: 300MB)
 	MaxHeaderSize   int    `mapstructure:"max_header_size"`   // Maximum request header size in bytes (default: 1MB)
-	ReadBufferSize  int    `mapstructure:"read_buffer_size"`  // Read buffer size in bytes (default: 4KB)
+	ReadBufferSize  int    `mapstructure:"read_buffer_size"`  // Read buffer size in bytes, which bounds the request line and headers (default and minimum: 16KB)
 	WriteBufferSize int    `mapstructure:"write_buffer_size"` // Write buffer size in bytes (default: 4KB)
+
+	// InlineContentTypes lists the content types objects may be rendered inline with.
+	// Entries are exact media types or "type/*" wildcards; everything else is served
+	// as an attachment so untrusted uploads (HTML, SVG, scripts) cannot run in the UI origin.
+	InlineContentTypes []string `mapstructure:"inline_content_types"`
+
+	// Pagination sets the page size of the object, bucket, user and audit listings
+	Pagination PaginationConfig `mapstructure:"pagination"`
+
+	// Timeouts sets the time budget of each group of API routes
+	Timeouts TimeoutsConfig `mapstructure:"timeouts"`
+
+	// TrustedProxies lists proxy IPs or CIDR ranges whose ProxyHeader is trusted for the
+	// client IP. When empty, the client IP is always the remote address of the connection.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	ProxyHeader    string   `mapstructure:"proxy_header"` // Header carrying the client IP (default: X-Forwarded-For)
+
+	SettingsPath string `mapstructure:"settings_path"` // JSON file persisting UI settings (empty: in-memory only)
+
+	// DeleteConfirmThreshold is the number of objects above which a prefix deletion must be
+	// confirmed with a server-issued token. Force-deleting or emptying a bucket that holds
+	// any object always needs confirmation.
+	DeleteConfirmThreshold int `mapstructure:"delete_confirm_threshold"`
+
+	// BlockWritesWhenDegraded makes uploads and deletions fail early with 503 while some
+	// partitions lack write quorum, judging from the cluster health cached for up to 15s
+	BlockWritesWhenDegraded bool `mapstructure:"block_writes_when_degraded"`
+
+	// ZipManifestTTL is how long a prepared resumable ZIP download can be fetched (default: 24h)
+	ZipManifestTTL time.Duration `mapstructure:"zip_manifest_ttl"`
+
+	// LegacyFieldNames emits the snake_case names some response fields had before all of
+	// them became camelCase, for clients that have not been updated yet. Deprecated.
+	LegacyFieldNames bool `mapstructure:"legacy_field_names"`
+}
+
+// DefaultInlineContentTypes is the inline rendering safelist used when none is configured
+var DefaultInlineContentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"image/avif",
+	"image/bmp",
+	"application/pdf",
+	"text/plain",
+	"audio/*",
+	"video/*",
 }
 
 // GarageConfig contains Garage S3 connection settings
+//
+// ForcePathStyle selects how buckets are addressed on the S3 API (default: true).
+// Path-style (http://endpoint/bucket/key) works with every Garage version and needs no
+// DNS setup. Virtual-hosted-style (http://bucket.endpoint/key) additionally requires
+// root_domain to be set in Garage's [s3_api] section and a wildcard DNS record pointing
+// *.root_domain at the gateway; the endpoint must then be that root domain.
+// Presigned URLs use the same addressing mode.
 type GarageConfig struct {
 	Endpoint       string `mapstructure:"endpoint"`
+	PublicEndpoint string `mapstructure:"public_endpoint"` // Externally reachable S3 endpoint used to sign presigned URLs (optional)
 	Region         string `mapstructure:"region"`
 	UseSSL         bool   `mapstructure:"use_ssl"`
 	ForcePathStyle bool   `mapstructure:"force_path_style"`
 	AdminEndpoint  string `mapstructure:"admin_endpoint"`
 	AdminToken     string `mapstructure:"admin_token"`
+
+	// WebsiteRootDomain is Garage's [s3_web] root_domain (e.g. ".web.garage.example.com").
+	// When set, objects in buckets with website access get a public URL of the form
+	// <website_scheme>://<bucket><root_domain>/<key>.
+	WebsiteRootDomain string `mapstructure:"website_root_domain"`
+	WebsiteScheme     string `mapstructure:"website_scheme"` // Scheme of public website URLs (default: https)
+
+	PresignDefaultTTL time.Duration `mapstructure:"presign_default_ttl"` // Presigned URL expiry when none is requested (default: 1h)
+	PresignMaxTTL     time.Duration `mapstructure:"presign_max_ttl"`     // Longest presigned URL expiry allowed (default and upper bound: 168h)
+	PresignStrict     bool          `mapstructure:"presign_strict"`      // Reject expiries above the maximum instead of clamping them (default: true)
+
+	AdminListLimit int `mapstructure:"admin_list_limit"` // Safety cap on keys/buckets read from one Admin API list call (default: 10000)
+
+	// AdminMaxConcurrentRequests caps the Admin API calls in flight at once; further calls
+	// wait for a slot until their context ends (default: 8)
+	AdminMaxConcurrentRequests int `mapstructure:"admin_max_concurrent_requests"`
+
+	// DefaultAccessKey and DefaultSecretKey optionally name an S3 key used to list buckets
+	// when the Admin API is unavailable. Only the buckets this key can access are listed.
+	DefaultAccessKey string `mapstructure:"default_access_key"`
+	DefaultSecretKey string `mapstructure:"default_secret_key"`
+
+	// ReadOnlyBuckets lists glob patterns (path.Match syntax, e.g. "archive-*") of buckets
+	// whose objects the UI refuses to change, on top of the per-bucket read-only setting
+	ReadOnlyBuckets []string `mapstructure:"read_only_buckets"`
+}
+
+// MaxPresignTTL is the longest expiry S3 signature V4 allows for presigned URLs
+const MaxPresignTTL = 7 * 24 * time.Hour
+
+// DefaultMaxBodySize is the request body limit applied when max_body_size is not set
+const DefaultMaxBodySize int64 = 300 * 1024 * 1024
+
+// MaxObjectKeyLength is the longest object key S3 accepts, in bytes
+const MaxObjectKeyLength = 1024
+
+// MinReadBufferSize is the smallest read buffer the server runs with. The request line and
+// headers must fit in it: a 1024-byte key takes up to 3KB once percent-encoded, on top of
+// the route prefix, an action suffix and the session cookie or bearer token.
+const MinReadBufferSize = 16 * 1024
+
+// ReadBufferLimit returns the effective read buffer size in bytes, raised to
+// MinReadBufferSize so that requests for the longest keys are not rejected with 431
+func (c *ServerConfig) ReadBufferLimit() int {
+	return max(c.ReadBufferSize, MinReadBufferSize)
+}
+
+// BodyLimit returns the effective maximum request body size in bytes
+func (c *ServerConfig) BodyLimit() int64 {
+	if c.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return c.MaxBodySize
+}
+
+// BucketsForRoles returns the sorted bucket names mapped to any of the given roles, "*"
+// included as is
+func (c *SelfServiceConfig) BucketsForRoles(roles []string) []string {
+	names := make([]string, 0)
+	for _, role := range roles {
+		names = append(names, c.RoleBuckets[strings.ToLower(role)]...)
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// WebsiteURL returns the public website URL of an object, or "" if no website root domain is configured
+func (c *GarageConfig) WebsiteURL(bucketName, key string) string {
+	if c.WebsiteRootDomain == "" {
+		return ""
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	host := bucketName + "." + strings.TrimPrefix(c.WebsiteRootDomain, ".")
+	return c.WebsiteScheme + "://" + host + "/" + strings.Join(segments, "/")
 }
 
 // AuthConfig contains authentication configuration
 type AuthConfig struct {
 	Admin      AdminAuthConfig `mapstructure:"admin"`
 	OIDC       OIDCConfig      `mapstructure:"oidc"`
+	APITokens  APITokenConfig  `mapstructure:"api_tokens"`
 	JWTPrivKey string          `mapstructure:"jwt_private_key"` // Ed25519 private key in PEM format for JWT signing (64 bytes)
 }
 
+// APITokenConfig contains settings for personal API tokens used by application integrations
+type APITokenConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Path       string        `mapstructure:"path"`         // JSON file persisting issued tokens (empty: in-memory only)
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`      // Longest lifetime a token may be issued for
+	MaxPerUser int           `mapstructure:"max_per_user"` // Tokens a single user may hold at once
+}
+
 // AdminAuthConfig contains admin authentication settings
 type AdminAuthConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
@@ -80,6 +240,7 @@ type OIDCConfig struct {
 	CookieSecure      bool     `mapstructure:"cookie_secure"`
 	CookieHTTPOnly    bool     `mapstructure:"cookie_http_only"`
 	CookieSameSite    string   `mapstructure:"cookie_same_site"`
+	MaxPendingLogins  int      `mapstructure:"max_pending_logins"` // Login states kept at once; the oldest are evicted beyond this (default: 10000)
 }
 
 // CORSConfig contains CORS settings for frontend communication
@@ -88,17 +249,153 @@ type CORSConfig struct {
 	AllowedOrigins   []string `mapstructure:"allowed_origins"`
 	AllowedMethods   []string `mapstructure:"allowed_methods"`
 	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"` // Response headers browser code may read (default: ETag, Content-Range, X-Request-ID, Retry-After and the X-Manifest-* headers)
 	AllowCredentials bool     `mapstructure:"allow_credentials"`
 	MaxAge           int      `mapstructure:"max_age"`
 }
 
+// PaginationConfig contains the page size settings shared by all list endpoints
+type PaginationConfig struct {
+	DefaultPageSize int `mapstructure:"default_page_size"` // Page size when the request does not set one (default: 100)
+	MaxPageSize     int `mapstructure:"max_page_size"`     // Larger requested page sizes are clamped to this (default: 1000)
+}
+
+// Route groups with their own time budget
+const (
+	TimeoutGroupDefault  = "default"
+	TimeoutGroupListing  = "listing"
+	TimeoutGroupPresign  = "presign"
+	TimeoutGroupTransfer = "transfer"
+)
+
+// TimeoutsConfig contains the time budget of each group of API routes. A request running
+// past its budget has its context canceled and is answered with 504. A budget of 0 means
+// unlimited; transfers are unlimited by default and only bounded by TransferIdle.
+type TimeoutsConfig struct {
+	Default      time.Duration `mapstructure:"default"`       // Routes not in another group (default: 30s)
+	Listing      time.Duration `mapstructure:"listing"`       // Bucket, object and user listings (default: 10s)
+	Presign      time.Duration `mapstructure:"presign"`       // Presigned URLs and download manifests (default: 5s)
+	Transfer     time.Duration `mapstructure:"transfer"`      // Uploads, downloads, streamed listings and ZIP exports (default: 0)
+	TransferIdle time.Duration `mapstructure:"transfer_idle"` // Abort a ZIP export making no progress for this long (default: 60s)
+}
+
+// Budget returns the time budget of a route group, 0 meaning unlimited
+func (t TimeoutsConfig) Budget(group string) time.Duration {
+	switch group {
+	case TimeoutGroupListing:
+		return t.Listing
+	case TimeoutGroupPresign:
+		return t.Presign
+	case TimeoutGroupTransfer:
+		return t.Transfer
+	default:
+		return t.Default
+	}
+}
+
+// TrashConfig contains settings for the per-bucket trash (soft-delete) feature.
+// Trash is enabled per bucket through the bucket settings.
+type TrashConfig struct {
+	Prefix        string        `mapstructure:"prefix"`         // Key prefix trashed objects are moved under (default: .trash/)
+	Retention     time.Duration `mapstructure:"retention"`      // How long trashed objects are kept (default: 720h)
+	SweepInterval time.Duration `mapstructure:"sweep_interval"` // How often expired trash is purged (default: 1h)
+}
+
+// LifecycleConfig contains settings for enforcing the per-bucket lifecycle rules. Garage has
+// no lifecycle policies, so expired objects are listed and deleted by garage-ui itself.
+type LifecycleConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`  // Enforce the rules periodically (default: true)
+	Interval time.Duration `mapstructure:"interval"` // How often the rules of every bucket are enforced (default: 6h, min: 1m)
+}
+
+// PublicConfig contains settings for anonymous read-only browsing under /public.
+// A bucket is only served when publicBrowsing is set in its settings and it has
+// website access enabled in Garage.
+type PublicConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	RateLimit int  `mapstructure:"rate_limit"` // Requests per minute per client IP (default: 120)
+}
+
+// ThumbnailsConfig contains settings for the image previews of the file browser.
+// Generated thumbnails are cached on local disk, keyed by the ETag of their source object.
+type ThumbnailsConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	CacheDir      string        `mapstructure:"cache_dir"`       // Directory thumbnails are cached in (default: garage-ui-thumbnails in the system temp directory)
+	CacheTTL      time.Duration `mapstructure:"cache_ttl"`       // Cached thumbnails unused for this long are removed (default: 168h)
+	MaxSourceSize int64         `mapstructure:"max_source_size"` // Largest object in bytes thumbnails are generated from (default: 20MB)
+	MaxDimension  int           `mapstructure:"max_dimension"`   // Largest width or height a thumbnail may be requested with (default: 1024)
+}
+
+// MonitoringConfig contains settings for the statistics shown on the dashboard
+type MonitoringConfig struct {
+	WarmCache       bool          `mapstructure:"warm_cache"`       // Pre-populate and keep refreshing the bucket statistics cache
+	WarmDelay       time.Duration `mapstructure:"warm_delay"`       // Wait after startup before warming (default: 5s)
+	WarmConcurrency int           `mapstructure:"warm_concurrency"` // Bucket info requests in flight while warming (default: 4)
+
+	// KeyActivityInterval is how often Garage's metrics are read to attribute requests to
+	// access keys (default: 5m, 0 disables)
+	KeyActivityInterval time.Duration `mapstructure:"key_activity_interval"`
+
+	// SelfTestBucket is the bucket the admin self-test works in, created when missing
+	// (default: garage-ui-selftest)
+	SelfTestBucket string `mapstructure:"selftest_bucket"`
+}
+
+// SelfServiceConfig contains settings for self-service S3 key issuance by OIDC users.
+// RoleBuckets maps OIDC roles to the bucket names their members get read/write keys for;
+// "*" grants every bucket. Role names are matched case-insensitively.
+type SelfServiceConfig struct {
+	Enabled        bool                `mapstructure:"enabled"`
+	KeyNamePrefix  string              `mapstructure:"key_name_prefix"`   // Issued keys are named <prefix><username> (default: self-service:)
+	MaxKeysPerUser int                 `mapstructure:"max_keys_per_user"` // Maximum keys a user may hold at once (default: 3)
+	DefaultTTL     time.Duration       `mapstructure:"default_ttl"`       // Key expiry when the user does not request one (default: 720h)
+	MaxTTL         time.Duration       `mapstructure:"max_ttl"`           // Longest key expiry a user may request (default: 2160h)
+	RoleBuckets    map[string][]string `mapstructure:"role_buckets"`
+}
+
 // LoggingConfig contains logging configuration
 type LoggingConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level     string `mapstructure:"level"`
+	Format    string `mapstructure:"format"`
+	AccessLog bool   `mapstructure:"access_log"` // Emit a structured log line per request (default: false)
+
+	// Access log volume controls. Requests under an excluded path prefix are not logged, and
+	// only this fraction of the other requests is (default: 1). Responses with a status of
+	// 400 or more are always logged.
+	AccessLogExclude    []string `mapstructure:"access_log_exclude"`
+	AccessLogSampleRate float64  `mapstructure:"access_log_sample_rate"`
+
+	// LogAdminBodies logs every Admin API call with its request and response bodies, secrets
+	// removed, when level is debug (default: false)
+	LogAdminBodies bool `mapstructure:"log_admin_bodies"`
+
+	// SlowRequestThreshold is how long a request must take to be kept in the slow-request
+	// list of support bundles; 0 disables the list (default: 2s)
+	SlowRequestThreshold time.Duration `mapstructure:"slow_request_threshold"`
+}
+
+// stringToSliceHook splits comma-separated strings into string slices, which is how
+// list settings such as GARAGE_UI_CORS_ALLOWED_ORIGINS are given through the environment.
+// Items are trimmed and empty ones dropped, so "a, b," yields [a b].
+func stringToSliceHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Slice || to.Elem().Kind() != reflect.String {
+			return data, nil
+		}
+
+		items := make([]string, 0)
+		for _, item := range strings.Split(data.(string), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
 }
 
-// Load reads the configuration from the specified file
+// Load reads the configuration from the specified file. The file is optional: every
+// setting can be given through GARAGE_UI_* environment variables, which take precedence,
+// and only garage.endpoint, garage.admin_endpoint and garage.admin_token have no default.
 func Load(configPath string) (*Config, error) {
 	// Set default config file name if not specified
 	if configPath == "" {
@@ -118,6 +415,74 @@ func Load(configPath string) (*Config, error) {
 	// Env vars override config file values
 	bindEnvVars()
 
+	viper.SetDefault("server.host", "0.0.0.0")
+	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.environment", "production")
+	viper.SetDefault("server.protocol", "http")
+
+	// Garage's own default region
+	viper.SetDefault("garage.region", "garage")
+
+	// Path-style addressing is the historical behavior and works without DNS setup
+	viper.SetDefault("garage.force_path_style", true)
+	viper.SetDefault("garage.website_scheme", "https")
+	viper.SetDefault("garage.presign_default_ttl", "1h")
+	viper.SetDefault("garage.presign_max_ttl", "168h")
+	viper.SetDefault("garage.presign_strict", true)
+	viper.SetDefault("server.inline_content_types", DefaultInlineContentTypes)
+	viper.SetDefault("server.pagination.default_page_size", 100)
+	viper.SetDefault("server.pagination.max_page_size", 1000)
+	viper.SetDefault("server.timeouts.default", "30s")
+	viper.SetDefault("server.timeouts.listing", "10s")
+	viper.SetDefault("server.timeouts.presign", "5s")
+	viper.SetDefault("server.timeouts.transfer", "0s")
+	viper.SetDefault("server.timeouts.transfer_idle", "60s")
+	viper.SetDefault("server.delete_confirm_threshold", 1000)
+	viper.SetDefault("server.block_writes_when_degraded", false)
+	viper.SetDefault("server.zip_manifest_ttl", "24h")
+	viper.SetDefault("server.legacy_field_names", false)
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("cors.allowed_headers", []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"})
+	viper.SetDefault("cors.exposed_headers", []string{"ETag", "Content-Range", "X-Request-ID", "Retry-After", "X-Manifest-Count", "X-Manifest-Total-Size", "X-Manifest-Skipped", "X-Manifest-Truncated"})
+	viper.SetDefault("cors.max_age", 3600)
+	viper.SetDefault("auth.oidc.provider_name", "OIDC")
+	viper.SetDefault("auth.oidc.scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("auth.oidc.email_attribute", "email")
+	viper.SetDefault("auth.oidc.username_attribute", "preferred_username")
+	viper.SetDefault("auth.oidc.name_attribute", "name")
+	viper.SetDefault("auth.oidc.session_max_age", 86400)
+	viper.SetDefault("auth.oidc.cookie_name", "garage_session")
+	viper.SetDefault("auth.oidc.cookie_http_only", true)
+	viper.SetDefault("auth.oidc.cookie_same_site", "lax")
+	viper.SetDefault("auth.oidc.max_pending_logins", 10000)
+	viper.SetDefault("auth.api_tokens.max_ttl", "2160h")
+	viper.SetDefault("auth.api_tokens.max_per_user", 20)
+	viper.SetDefault("public.enabled", false)
+	viper.SetDefault("public.rate_limit", 120)
+	viper.SetDefault("thumbnails.enabled", true)
+	viper.SetDefault("thumbnails.cache_dir", "")
+	viper.SetDefault("thumbnails.cache_ttl", "168h")
+	viper.SetDefault("thumbnails.max_source_size", 20*1024*1024)
+	viper.SetDefault("thumbnails.max_dimension", 1024)
+	viper.SetDefault("trash.prefix", ".trash/")
+	viper.SetDefault("trash.retention", "720h")
+	viper.SetDefault("trash.sweep_interval", "1h")
+	viper.SetDefault("lifecycle.enabled", true)
+	viper.SetDefault("lifecycle.interval", "6h")
+	viper.SetDefault("monitoring.warm_cache", false)
+	viper.SetDefault("monitoring.warm_delay", "5s")
+	viper.SetDefault("monitoring.warm_concurrency", 4)
+	viper.SetDefault("monitoring.key_activity_interval", "5m")
+	viper.SetDefault("monitoring.selftest_bucket", "garage-ui-selftest")
+	viper.SetDefault("self_service.key_name_prefix", "self-service:")
+	viper.SetDefault("self_service.max_keys_per_user", 3)
+	viper.SetDefault("self_service.default_ttl", "720h")
+	viper.SetDefault("self_service.max_ttl", "2160h")
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("logging.slow_request_threshold", "2s")
+	viper.SetDefault("logging.access_log_sample_rate", 1)
+
 	// Read the config file (optional - will use defaults and env vars if not found)
 	if _, err := os.Stat(configPath); err == nil {
 		if err := viper.ReadInConfig(); err != nil {
@@ -125,9 +490,14 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
-	// Unmarshal the config into the Config struct
+	// Unmarshal the config into the Config struct. Environment variables always arrive
+	// as strings, so durations and lists are decoded from their string forms.
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToSliceHook(),
+	))
+	if err := viper.Unmarshal(&cfg, decodeHook); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
@@ -153,20 +523,50 @@ func bindEnvVars() {
 	viper.BindEnv("server.max_header_size", "GARAGE_UI_SERVER_MAX_HEADER_SIZE")
 	viper.BindEnv("server.read_buffer_size", "GARAGE_UI_SERVER_READ_BUFFER_SIZE")
 	viper.BindEnv("server.write_buffer_size", "GARAGE_UI_SERVER_WRITE_BUFFER_SIZE")
+	viper.BindEnv("server.inline_content_types", "GARAGE_UI_SERVER_INLINE_CONTENT_TYPES")
+	viper.BindEnv("server.trusted_proxies", "GARAGE_UI_SERVER_TRUSTED_PROXIES")
+	viper.BindEnv("server.proxy_header", "GARAGE_UI_SERVER_PROXY_HEADER")
+	viper.BindEnv("server.settings_path", "GARAGE_UI_SERVER_SETTINGS_PATH")
+	viper.BindEnv("server.delete_confirm_threshold", "GARAGE_UI_SERVER_DELETE_CONFIRM_THRESHOLD")
+	viper.BindEnv("server.block_writes_when_degraded", "GARAGE_UI_SERVER_BLOCK_WRITES_WHEN_DEGRADED")
+	viper.BindEnv("server.zip_manifest_ttl", "GARAGE_UI_SERVER_ZIP_MANIFEST_TTL")
+	viper.BindEnv("server.legacy_field_names", "GARAGE_UI_SERVER_LEGACY_FIELD_NAMES")
+	viper.BindEnv("server.pagination.default_page_size", "GARAGE_UI_SERVER_PAGINATION_DEFAULT_PAGE_SIZE")
+	viper.BindEnv("server.pagination.max_page_size", "GARAGE_UI_SERVER_PAGINATION_MAX_PAGE_SIZE")
+	viper.BindEnv("server.timeouts.default", "GARAGE_UI_SERVER_TIMEOUTS_DEFAULT")
+	viper.BindEnv("server.timeouts.listing", "GARAGE_UI_SERVER_TIMEOUTS_LISTING")
+	viper.BindEnv("server.timeouts.presign", "GARAGE_UI_SERVER_TIMEOUTS_PRESIGN")
+	viper.BindEnv("server.timeouts.transfer", "GARAGE_UI_SERVER_TIMEOUTS_TRANSFER")
+	viper.BindEnv("server.timeouts.transfer_idle", "GARAGE_UI_SERVER_TIMEOUTS_TRANSFER_IDLE")
 
 	// Garage config
 	viper.BindEnv("garage.endpoint", "GARAGE_UI_GARAGE_ENDPOINT")
+	viper.BindEnv("garage.public_endpoint", "GARAGE_UI_GARAGE_PUBLIC_ENDPOINT")
 	viper.BindEnv("garage.region", "GARAGE_UI_GARAGE_REGION")
 	viper.BindEnv("garage.use_ssl", "GARAGE_UI_GARAGE_USE_SSL")
 	viper.BindEnv("garage.force_path_style", "GARAGE_UI_GARAGE_FORCE_PATH_STYLE")
 	viper.BindEnv("garage.admin_endpoint", "GARAGE_UI_GARAGE_ADMIN_ENDPOINT")
 	viper.BindEnv("garage.admin_token", "GARAGE_UI_GARAGE_ADMIN_TOKEN")
+	viper.BindEnv("garage.website_root_domain", "GARAGE_UI_GARAGE_WEBSITE_ROOT_DOMAIN")
+	viper.BindEnv("garage.website_scheme", "GARAGE_UI_GARAGE_WEBSITE_SCHEME")
+	viper.BindEnv("garage.presign_default_ttl", "GARAGE_UI_GARAGE_PRESIGN_DEFAULT_TTL")
+	viper.BindEnv("garage.presign_max_ttl", "GARAGE_UI_GARAGE_PRESIGN_MAX_TTL")
+	viper.BindEnv("garage.presign_strict", "GARAGE_UI_GARAGE_PRESIGN_STRICT")
+	viper.BindEnv("garage.admin_list_limit", "GARAGE_UI_GARAGE_ADMIN_LIST_LIMIT")
+	viper.BindEnv("garage.admin_max_concurrent_requests", "GARAGE_UI_GARAGE_ADMIN_MAX_CONCURRENT_REQUESTS")
+	viper.BindEnv("garage.read_only_buckets", "GARAGE_UI_GARAGE_READ_ONLY_BUCKETS")
+	viper.BindEnv("garage.default_access_key", "GARAGE_UI_GARAGE_DEFAULT_ACCESS_KEY")
+	viper.BindEnv("garage.default_secret_key", "GARAGE_UI_GARAGE_DEFAULT_SECRET_KEY")
 
 	// Auth config
 	viper.BindEnv("auth.admin.enabled", "GARAGE_UI_AUTH_ADMIN_ENABLED")
 	viper.BindEnv("auth.admin.username", "GARAGE_UI_AUTH_ADMIN_USERNAME")
 	viper.BindEnv("auth.admin.password", "GARAGE_UI_AUTH_ADMIN_PASSWORD")
 	viper.BindEnv("auth.jwt_private_key", "GARAGE_UI_AUTH_JWT_PRIVATE_KEY")
+	viper.BindEnv("auth.api_tokens.enabled", "GARAGE_UI_AUTH_API_TOKENS_ENABLED")
+	viper.BindEnv("auth.api_tokens.path", "GARAGE_UI_AUTH_API_TOKENS_PATH")
+	viper.BindEnv("auth.api_tokens.max_ttl", "GARAGE_UI_AUTH_API_TOKENS_MAX_TTL")
+	viper.BindEnv("auth.api_tokens.max_per_user", "GARAGE_UI_AUTH_API_TOKENS_MAX_PER_USER")
 
 	// OIDC config
 	viper.BindEnv("auth.oidc.enabled", "GARAGE_UI_AUTH_OIDC_ENABLED")
@@ -191,18 +591,83 @@ func bindEnvVars() {
 	viper.BindEnv("auth.oidc.cookie_secure", "GARAGE_UI_AUTH_OIDC_COOKIE_SECURE")
 	viper.BindEnv("auth.oidc.cookie_http_only", "GARAGE_UI_AUTH_OIDC_COOKIE_HTTP_ONLY")
 	viper.BindEnv("auth.oidc.cookie_same_site", "GARAGE_UI_AUTH_OIDC_COOKIE_SAME_SITE")
+	viper.BindEnv("auth.oidc.max_pending_logins", "GARAGE_UI_AUTH_OIDC_MAX_PENDING_LOGINS")
 
 	// CORS config
 	viper.BindEnv("cors.enabled", "GARAGE_UI_CORS_ENABLED")
 	viper.BindEnv("cors.allowed_origins", "GARAGE_UI_CORS_ALLOWED_ORIGINS")
 	viper.BindEnv("cors.allowed_methods", "GARAGE_UI_CORS_ALLOWED_METHODS")
 	viper.BindEnv("cors.allowed_headers", "GARAGE_UI_CORS_ALLOWED_HEADERS")
+	viper.BindEnv("cors.exposed_headers", "GARAGE_UI_CORS_EXPOSED_HEADERS")
 	viper.BindEnv("cors.allow_credentials", "GARAGE_UI_CORS_ALLOW_CREDENTIALS")
 	viper.BindEnv("cors.max_age", "GARAGE_UI_CORS_MAX_AGE")
 
 	// Logging config
 	viper.BindEnv("logging.level", "GARAGE_UI_LOGGING_LEVEL")
 	viper.BindEnv("logging.format", "GARAGE_UI_LOGGING_FORMAT")
+	viper.BindEnv("logging.access_log", "GARAGE_UI_LOGGING_ACCESS_LOG")
+	viper.BindEnv("logging.access_log_exclude", "GARAGE_UI_LOGGING_ACCESS_LOG_EXCLUDE")
+	viper.BindEnv("logging.access_log_sample_rate", "GARAGE_UI_LOGGING_ACCESS_LOG_SAMPLE_RATE")
+	viper.BindEnv("logging.log_admin_bodies", "GARAGE_UI_LOGGING_LOG_ADMIN_BODIES")
+	viper.BindEnv("logging.slow_request_threshold", "GARAGE_UI_LOGGING_SLOW_REQUEST_THRESHOLD")
+
+	// Trash config
+	viper.BindEnv("trash.prefix", "GARAGE_UI_TRASH_PREFIX")
+	viper.BindEnv("trash.retention", "GARAGE_UI_TRASH_RETENTION")
+	viper.BindEnv("trash.sweep_interval", "GARAGE_UI_TRASH_SWEEP_INTERVAL")
+
+	// Lifecycle config
+	viper.BindEnv("lifecycle.enabled", "GARAGE_UI_LIFECYCLE_ENABLED")
+	viper.BindEnv("lifecycle.interval", "GARAGE_UI_LIFECYCLE_INTERVAL")
+
+	// Monitoring config
+	viper.BindEnv("monitoring.warm_cache", "GARAGE_UI_MONITORING_WARM_CACHE")
+	viper.BindEnv("monitoring.warm_delay", "GARAGE_UI_MONITORING_WARM_DELAY")
+	viper.BindEnv("monitoring.warm_concurrency", "GARAGE_UI_MONITORING_WARM_CONCURRENCY")
+	viper.BindEnv("monitoring.key_activity_interval", "GARAGE_UI_MONITORING_KEY_ACTIVITY_INTERVAL")
+	viper.BindEnv("monitoring.selftest_bucket", "GARAGE_UI_MONITORING_SELFTEST_BUCKET")
+
+	// Self-service key config
+	viper.BindEnv("self_service.enabled", "GARAGE_UI_SELF_SERVICE_ENABLED")
+	viper.BindEnv("self_service.key_name_prefix", "GARAGE_UI_SELF_SERVICE_KEY_NAME_PREFIX")
+	viper.BindEnv("self_service.max_keys_per_user", "GARAGE_UI_SELF_SERVICE_MAX_KEYS_PER_USER")
+	viper.BindEnv("self_service.default_ttl", "GARAGE_UI_SELF_SERVICE_DEFAULT_TTL")
+	viper.BindEnv("self_service.max_ttl", "GARAGE_UI_SELF_SERVICE_MAX_TTL")
+
+	// Public browsing config
+	viper.BindEnv("public.enabled", "GARAGE_UI_PUBLIC_ENABLED")
+	viper.BindEnv("public.rate_limit", "GARAGE_UI_PUBLIC_RATE_LIMIT")
+
+	// Thumbnails config
+	viper.BindEnv("thumbnails.enabled", "GARAGE_UI_THUMBNAILS_ENABLED")
+	viper.BindEnv("thumbnails.cache_dir", "GARAGE_UI_THUMBNAILS_CACHE_DIR")
+	viper.BindEnv("thumbnails.cache_ttl", "GARAGE_UI_THUMBNAILS_CACHE_TTL")
+	viper.BindEnv("thumbnails.max_source_size", "GARAGE_UI_THUMBNAILS_MAX_SOURCE_SIZE")
+	viper.BindEnv("thumbnails.max_dimension", "GARAGE_UI_THUMBNAILS_MAX_DIMENSION")
+}
+
+// validateS3Endpoint checks that the S3 endpoint is a host with an optional port, optionally
+// prefixed with http:// or https://, which is all the MinIO client accepts
+func validateS3Endpoint(endpoint string) error {
+	raw := endpoint
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	endpointURL, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid garage endpoint %q: %w", endpoint, err)
+	}
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		return fmt.Errorf("garage endpoint must use http or https, got %q", endpoint)
+	}
+	if endpointURL.Hostname() == "" {
+		return fmt.Errorf("garage endpoint must include a host, got %q", endpoint)
+	}
+	if endpointURL.Path != "" || endpointURL.RawQuery != "" || endpointURL.User != nil {
+		return fmt.Errorf("garage endpoint must be host[:port] without a path, query or credentials, got %q", endpoint)
+	}
+	return nil
 }
 
 // Validate checks if the configuration is valid
@@ -212,16 +677,126 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if c.Server.Pagination.DefaultPageSize <= 0 || c.Server.Pagination.DefaultPageSize > c.Server.Pagination.MaxPageSize {
+		return fmt.Errorf("server pagination default_page_size must be between 1 and max_page_size")
+	}
+
+	timeouts := c.Server.Timeouts
+	if timeouts.Default < 0 || timeouts.Listing < 0 || timeouts.Presign < 0 || timeouts.Transfer < 0 || timeouts.TransferIdle < 0 {
+		return fmt.Errorf("server timeouts must not be negative")
+	}
+
+	if c.Server.DeleteConfirmThreshold < 0 {
+		return fmt.Errorf("server delete_confirm_threshold must not be negative")
+	}
+
+	if c.Server.ZipManifestTTL <= 0 {
+		return fmt.Errorf("server zip_manifest_ttl must be positive")
+	}
+
+	if c.Logging.AccessLogSampleRate < 0 || c.Logging.AccessLogSampleRate > 1 {
+		return fmt.Errorf("logging access_log_sample_rate must be between 0 and 1")
+	}
+
 	// Validate Garage config
 	if c.Garage.Endpoint == "" {
 		return fmt.Errorf("garage endpoint is required")
 	}
+	if err := validateS3Endpoint(c.Garage.Endpoint); err != nil {
+		return err
+	}
 	if c.Garage.AdminEndpoint == "" {
 		return fmt.Errorf("garage admin_endpoint is required")
 	}
 	if c.Garage.AdminToken == "" {
 		return fmt.Errorf("garage admin_token is required")
 	}
+	if c.Garage.PublicEndpoint != "" {
+		publicURL, err := url.Parse(c.Garage.PublicEndpoint)
+		if err != nil {
+			return fmt.Errorf("invalid garage public_endpoint: %w", err)
+		}
+		if publicURL.Scheme != "http" && publicURL.Scheme != "https" {
+			return fmt.Errorf("garage public_endpoint must use http or https, got %q", c.Garage.PublicEndpoint)
+		}
+		if publicURL.Host == "" {
+			return fmt.Errorf("garage public_endpoint must include a host, got %q", c.Garage.PublicEndpoint)
+		}
+		if publicURL.Path != "" && publicURL.Path != "/" {
+			return fmt.Errorf("garage public_endpoint must not include a path, got %q", c.Garage.PublicEndpoint)
+		}
+	}
+
+	for _, pattern := range c.Garage.ReadOnlyBuckets {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid garage read_only_buckets pattern %q: %w", pattern, err)
+		}
+	}
+
+	if c.Garage.WebsiteScheme != "http" && c.Garage.WebsiteScheme != "https" {
+		return fmt.Errorf("garage website_scheme must be http or https, got %q", c.Garage.WebsiteScheme)
+	}
+
+	if c.Garage.PresignMaxTTL < time.Second || c.Garage.PresignMaxTTL > MaxPresignTTL {
+		return fmt.Errorf("garage presign_max_ttl must be between 1s and %s, got %s", MaxPresignTTL, c.Garage.PresignMaxTTL)
+	}
+	if c.Garage.PresignDefaultTTL < time.Second || c.Garage.PresignDefaultTTL > c.Garage.PresignMaxTTL {
+		return fmt.Errorf("garage presign_default_ttl must be between 1s and presign_max_ttl, got %s", c.Garage.PresignDefaultTTL)
+	}
+
+	// Validate trash config; the prefix must be a folder so trashed keys never mix with live ones
+	if c.Trash.Prefix == "" || !strings.HasSuffix(c.Trash.Prefix, "/") || strings.HasPrefix(c.Trash.Prefix, "/") {
+		return fmt.Errorf("trash prefix must be a relative folder ending with '/', got %q", c.Trash.Prefix)
+	}
+	if c.Trash.Retention <= 0 || c.Trash.SweepInterval <= 0 {
+		return fmt.Errorf("trash retention and sweep_interval must be positive")
+	}
+
+	if c.Lifecycle.Enabled && c.Lifecycle.Interval < time.Minute {
+		return fmt.Errorf("lifecycle interval must be at least 1m, got %s", c.Lifecycle.Interval)
+	}
+
+	// Validate the cache warm-up if enabled
+	if c.Monitoring.WarmCache && (c.Monitoring.WarmConcurrency <= 0 || c.Monitoring.WarmDelay < 0) {
+		return fmt.Errorf("monitoring warm_concurrency must be positive and warm_delay must not be negative")
+	}
+
+	if c.Monitoring.KeyActivityInterval < 0 {
+		return fmt.Errorf("monitoring key_activity_interval must not be negative")
+	}
+
+	if err := utils.ValidateBucketName(c.Monitoring.SelfTestBucket); err != nil {
+		return fmt.Errorf("monitoring selftest_bucket: %w", err)
+	}
+
+	if c.Public.Enabled && c.Public.RateLimit <= 0 {
+		return fmt.Errorf("public rate_limit must be positive")
+	}
+
+	if c.Thumbnails.Enabled {
+		if c.Thumbnails.CacheTTL <= 0 || c.Thumbnails.MaxSourceSize <= 0 {
+			return fmt.Errorf("thumbnails cache_ttl and max_source_size must be positive")
+		}
+		if c.Thumbnails.MaxDimension < 16 || c.Thumbnails.MaxDimension > 4096 {
+			return fmt.Errorf("thumbnails max_dimension must be between 16 and 4096, got %d", c.Thumbnails.MaxDimension)
+		}
+	}
+
+	// Validate self-service keys if enabled; keys are only issued to OIDC users
+	if c.SelfService.Enabled {
+		if !c.Auth.OIDC.Enabled {
+			return fmt.Errorf("self_service requires OIDC auth to be enabled")
+		}
+		if c.SelfService.KeyNamePrefix == "" {
+			return fmt.Errorf("self_service key_name_prefix is required")
+		}
+		if c.SelfService.MaxKeysPerUser <= 0 {
+			return fmt.Errorf("self_service max_keys_per_user must be positive")
+		}
+		if c.SelfService.MaxTTL <= 0 || c.SelfService.DefaultTTL <= 0 || c.SelfService.DefaultTTL > c.SelfService.MaxTTL {
+			return fmt.Errorf("self_service default_ttl must be positive and at most max_ttl")
+		}
+	}
 
 	// Validate admin auth if enabled
 	if c.Auth.Admin.Enabled {
@@ -230,6 +805,16 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate API tokens if enabled; they are issued to users who logged in another way
+	if c.Auth.APITokens.Enabled {
+		if !c.Auth.Admin.Enabled && !c.Auth.OIDC.Enabled {
+			return fmt.Errorf("auth api_tokens require admin or OIDC auth to be enabled")
+		}
+		if c.Auth.APITokens.MaxTTL <= 0 || c.Auth.APITokens.MaxPerUser <= 0 {
+			return fmt.Errorf("auth api_tokens max_ttl and max_per_user must be positive")
+		}
+	}
+
 	// Validate OIDC config if enabled
 	if c.Auth.OIDC.Enabled {
 		if c.Auth.OIDC.ClientID == "" {
@@ -238,17 +823,48 @@ func (c *Config) Validate() error {
 		if c.Auth.OIDC.IssuerURL == "" {
 			return fmt.Errorf("oidc issuer_url is required when oidc is enabled")
 		}
+		// Discovery is retried in the background, so a malformed issuer must be caught here
+		if issuerURL, err := url.Parse(c.Auth.OIDC.IssuerURL); err != nil || (issuerURL.Scheme != "http" && issuerURL.Scheme != "https") || issuerURL.Host == "" {
+			return fmt.Errorf("oidc issuer_url must be an absolute http or https URL")
+		}
 		if c.Server.RootURL == "" {
 			return fmt.Errorf("server.root_url is required when oidc is enabled")
 		}
 		if len(c.Auth.OIDC.Scopes) == 0 {
 			return fmt.Errorf("oidc scopes are required when oidc is enabled")
 		}
+		if c.Auth.OIDC.MaxPendingLogins <= 0 {
+			return fmt.Errorf("oidc max_pending_logins must be positive")
+		}
 	}
 
 	return nil
 }
 
+// redactedValue replaces secrets in the redacted configuration dump
+const redactedValue = "[REDACTED]"
+
+// redact masks a secret value while keeping track of whether it was set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// Redacted returns a copy of the configuration with all secrets masked, suitable for logging
+func (c *Config) Redacted() Config {
+	redacted := *c
+
+	redacted.Garage.AdminToken = redact(c.Garage.AdminToken)
+	redacted.Garage.DefaultSecretKey = redact(c.Garage.DefaultSecretKey)
+	redacted.Auth.JWTPrivKey = redact(c.Auth.JWTPrivKey)
+	redacted.Auth.Admin.Password = redact(c.Auth.Admin.Password)
+	redacted.Auth.OIDC.ClientSecret = redact(c.Auth.OIDC.ClientSecret)
+
+	return redacted
+}
+
 // GetAddress returns the full server address (host:port)
 func (c *Config) GetAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
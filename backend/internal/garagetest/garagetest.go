@@ -0,0 +1,637 @@
+// Package garagetest runs an in-memory Garage cluster for tests: an Admin API granting keys
+// on buckets and an S3 API serving their objects. Buckets are addressed by their global alias
+// on both APIs.
+//
+// Only the calls the services make are implemented, and requests are not authenticated
+// beyond checking that the access key they are signed with exists.
+package garagetest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+)
+
+// Server is a fake Garage cluster
+type Server struct {
+	// AdminURL and S3URL are the base URLs of the Admin and S3 APIs
+	AdminURL string
+	S3URL    string
+
+	clock clock.Clock
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	keys    map[string]*key
+
+	// s3Keys records the access key of every S3 request, in order
+	s3Keys []string
+
+	// reversePrefixes makes listings return common prefixes in reverse order
+	reversePrefixes bool
+
+	// grantLag is how many bucket lookups miss a grant made through the Admin API
+	grantLag int
+
+	// failingAdminCalls are the Admin API calls answered with 500, by name
+	failingAdminCalls []string
+}
+
+type bucket struct {
+	id          string
+	created     time.Time
+	keys        []models.BucketKeyInfo
+	objects     map[string]object
+	deleteFails map[string]bool // Keys DeleteObjects reports as not deleted
+
+	pendingKeys    []models.BucketKeyInfo // Grants not yet visible to lookups
+	pendingLookups int                    // Lookups left before pendingKeys become visible
+	lookups        int                    // Lookups of the bucket through the Admin API
+}
+
+type key struct {
+	secret     string
+	created    time.Time
+	expiration *time.Time
+	denied     bool // Rejected by the S3 API while the Admin API still lists it
+}
+
+type object struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// New starts a fake cluster whose objects are stamped by clk. It is stopped when the test ends.
+func New(t testing.TB, clk clock.Clock) *Server {
+	t.Helper()
+
+	g := &Server{
+		clock:   clk,
+		buckets: make(map[string]*bucket),
+		keys:    make(map[string]*key),
+	}
+
+	adminServer := httptest.NewServer(http.HandlerFunc(g.serveAdmin))
+	t.Cleanup(adminServer.Close)
+	s3Server := httptest.NewServer(http.HandlerFunc(g.serveS3))
+	t.Cleanup(s3Server.Close)
+
+	g.AdminURL = adminServer.URL
+	g.S3URL = s3Server.URL
+	return g
+}
+
+// AddBucket creates a bucket
+func (g *Server) AddBucket(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.buckets[name] = &bucket{
+		id:          "id-" + name,
+		created:     g.clock.Now(),
+		objects:     make(map[string]object),
+		deleteFails: make(map[string]bool),
+	}
+}
+
+// AddKey creates a key without access to any bucket
+func (g *Server) AddKey(accessKeyID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.keys[accessKeyID] = &key{secret: "secret-" + accessKeyID, created: g.clock.Now()}
+}
+
+// GrantKey creates a key with read and write access to a bucket, or grants an existing one
+func (g *Server) GrantKey(bucketName, accessKeyID string, owner bool, expiration *time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.keys[accessKeyID]; !ok {
+		g.keys[accessKeyID] = &key{secret: "secret-" + accessKeyID, created: g.clock.Now(), expiration: expiration}
+	}
+	b := g.buckets[bucketName]
+	b.keys = append(b.keys, models.BucketKeyInfo{
+		AccessKeyID: accessKeyID,
+		Permissions: models.BucketKeyPermission{Read: true, Write: true, Owner: owner},
+	})
+}
+
+// LagGrants makes grants made through the Admin API invisible to the next lookups of their
+// bucket, as while they propagate through the cluster
+func (g *Server) LagGrants(lookups int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.grantLag = lookups
+}
+
+// FailAdmin makes the given Admin API calls, such as "ListBuckets", fail with 500 as during an
+// outage. Calling it again replaces the failing calls; with none, every call succeeds again.
+func (g *Server) FailAdmin(calls ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failingAdminCalls = calls
+}
+
+// BucketLookups returns how many times a bucket was looked up through the Admin API
+func (g *Server) BucketLookups(bucketName string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok := g.buckets[bucketName]; ok {
+		return b.lookups
+	}
+	return 0
+}
+
+// DeleteKey deletes a key, as an administrator revoking it would
+func (g *Server) DeleteKey(accessKeyID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.keys, accessKeyID)
+	for _, b := range g.buckets {
+		b.keys = slices.DeleteFunc(b.keys, func(key models.BucketKeyInfo) bool {
+			return key.AccessKeyID == accessKeyID
+		})
+	}
+}
+
+// DenyKey makes the S3 API reject a key the Admin API still lists, as while the revocation
+// of its permissions propagates
+func (g *Server) DenyKey(accessKeyID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.keys[accessKeyID].denied = true
+}
+
+// PutObject stores an object
+func (g *Server) PutObject(bucketName, key, contentType string, data []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.buckets[bucketName].objects[key] = object{data: data, contentType: contentType, modified: g.clock.Now()}
+}
+
+// Object returns the data of an object, if it exists
+func (g *Server) Object(bucketName, key string) ([]byte, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	obj, ok := g.buckets[bucketName].objects[key]
+	return obj.data, ok
+}
+
+// ObjectKeys returns the keys of the objects of a bucket, sorted
+func (g *Server) ObjectKeys(bucketName string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keys := make([]string, 0, len(g.buckets[bucketName].objects))
+	for key := range g.buckets[bucketName].objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FailDelete makes DeleteObjects report an object as not deleted
+func (g *Server) FailDelete(bucketName, key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.buckets[bucketName].deleteFails[key] = true
+}
+
+// ReversePrefixes makes listings return common prefixes in reverse order, as an S3
+// implementation free to order them would
+func (g *Server) ReversePrefixes() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reversePrefixes = true
+}
+
+// S3KeysUsed returns the access keys of the S3 requests made so far
+func (g *Server) S3KeysUsed() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.s3Keys)
+}
+
+func (g *Server) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if slices.Contains(g.failingAdminCalls, strings.TrimPrefix(r.URL.Path, "/v2/")) {
+		http.Error(w, `{"code":"InternalError","message":"unavailable"}`, http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	switch r.URL.Path {
+	case "/v2/ListBuckets":
+		items := make([]models.ListBucketsResponseItem, 0, len(g.buckets))
+		for name, b := range g.buckets {
+			items = append(items, models.ListBucketsResponseItem{ID: b.id, Created: b.created, GlobalAliases: []string{name}})
+		}
+		slices.SortFunc(items, func(a, b models.ListBucketsResponseItem) int { return strings.Compare(a.ID, b.ID) })
+		_ = json.NewEncoder(w).Encode(items)
+		return
+	case "/v2/ListKeys":
+		items := make([]models.ListKeysResponseItem, 0, len(g.keys))
+		for id, k := range g.keys {
+			items = append(items, models.ListKeysResponseItem{ID: id, Name: id, Created: &k.created, Expiration: k.expiration})
+		}
+		slices.SortFunc(items, func(a, b models.ListKeysResponseItem) int { return strings.Compare(a.ID, b.ID) })
+		_ = json.NewEncoder(w).Encode(items)
+		return
+	case "/v2/CreateBucket":
+		var req models.CreateBucketAdminRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GlobalAlias == nil {
+			http.Error(w, `{"code":"InvalidRequest","message":"globalAlias is required"}`, http.StatusBadRequest)
+			return
+		}
+		if _, ok := g.buckets[*req.GlobalAlias]; ok {
+			http.Error(w, `{"code":"BucketAlreadyExists","message":"bucket exists"}`, http.StatusConflict)
+			return
+		}
+		b := &bucket{id: "id-" + *req.GlobalAlias, created: g.clock.Now(), objects: make(map[string]object), deleteFails: make(map[string]bool)}
+		g.buckets[*req.GlobalAlias] = b
+		_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{ID: b.id, Created: b.created, GlobalAliases: []string{*req.GlobalAlias}})
+		return
+	case "/v2/AllowBucketKey":
+		var req models.BucketKeyPermRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"code":"InvalidRequest","message":"invalid body"}`, http.StatusBadRequest)
+			return
+		}
+		for name, b := range g.buckets {
+			if b.id == req.BucketID {
+				b.pendingKeys = append(b.pendingKeys, models.BucketKeyInfo{AccessKeyID: req.AccessKeyID, Permissions: req.Permissions})
+				b.pendingLookups = g.grantLag
+				_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{ID: b.id, Created: b.created, GlobalAliases: []string{name}, Keys: b.keys})
+				return
+			}
+		}
+	case "/v2/GetBucketInfo":
+		for name, b := range g.buckets {
+			if name == query.Get("globalAlias") || b.id == query.Get("id") {
+				b.lookups++
+				if b.pendingLookups > 0 {
+					b.pendingLookups--
+				} else {
+					b.keys = append(b.keys, b.pendingKeys...)
+					b.pendingKeys = nil
+				}
+				var size int64
+				for _, obj := range b.objects {
+					size += int64(len(obj.data))
+				}
+				_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{
+					ID:            b.id,
+					Created:       b.created,
+					GlobalAliases: []string{name},
+					Keys:          b.keys,
+					Objects:       int64(len(b.objects)),
+					Bytes:         size,
+				})
+				return
+			}
+		}
+	case "/v2/UpdateKey":
+		k, ok := g.keys[query.Get("id")]
+		if !ok {
+			break
+		}
+		var req models.UpdateKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"code":"InvalidRequest","message":"invalid body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.NeverExpires {
+			k.expiration = nil
+		} else if req.Expiration != nil {
+			k.expiration = req.Expiration
+		}
+		_ = json.NewEncoder(w).Encode(models.GarageKeyInfo{AccessKeyID: query.Get("id"), Created: &k.created, Expiration: k.expiration})
+		return
+	case "/v2/GetKeyInfo":
+		if k, ok := g.keys[query.Get("id")]; ok {
+			info := models.GarageKeyInfo{AccessKeyID: query.Get("id"), Created: &k.created, Expiration: k.expiration}
+			if query.Get("showSecretKey") == "true" {
+				info.SecretAccessKey = &k.secret
+			}
+			_ = json.NewEncoder(w).Encode(info)
+			return
+		}
+	}
+	http.Error(w, `{"code":"NoSuchBucket","message":"not found"}`, http.StatusNotFound)
+}
+
+// s3AccessKey returns the access key a request is signed with, from its Authorization
+// header or, for presigned URLs, its query
+func s3AccessKey(r *http.Request) string {
+	credential := r.URL.Query().Get("X-Amz-Credential")
+	if _, after, ok := strings.Cut(r.Header.Get("Authorization"), "Credential="); ok {
+		credential = after
+	}
+	accessKey, _, _ := strings.Cut(credential, "/")
+	return accessKey
+}
+
+func (g *Server) serveS3(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	accessKey := s3AccessKey(r)
+	g.s3Keys = append(g.s3Keys, accessKey)
+	if k, ok := g.keys[accessKey]; !ok {
+		writeS3Error(w, r, http.StatusForbidden, "InvalidAccessKeyId", "The access key does not exist")
+		return
+	} else if k.denied {
+		writeS3Error(w, r, http.StatusForbidden, "AccessDenied", "Access denied")
+		return
+	}
+
+	bucketName, objectKey, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	b, ok := g.buckets[bucketName]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", "The bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case objectKey == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		g.listObjects(w, r, bucketName, b)
+	case objectKey == "" && r.Method == http.MethodPost && query.Has("delete"):
+		deleteObjects(w, r, b)
+	case objectKey != "" && r.Method == http.MethodGet && query.Has("tagging"):
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = io.WriteString(w, `<Tagging><TagSet></TagSet></Tagging>`)
+	case objectKey != "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		serveObject(w, r, b, objectKey)
+	case objectKey != "" && r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		g.copyObject(w, r, b, objectKey)
+	case objectKey != "" && r.Method == http.MethodPut && len(query) == 0:
+		g.storeObject(w, r, b, objectKey)
+	case objectKey != "" && r.Method == http.MethodDelete && len(query) == 0:
+		delete(b.objects, objectKey)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", r.Method+" "+r.URL.String())
+	}
+}
+
+// listObjects answers ListObjectsV2. Continuation tokens are the last key or prefix returned.
+func (g *Server) listObjects(w http.ResponseWriter, r *http.Request, bucketName string, b *bucket) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys, err := strconv.Atoi(query.Get("max-keys"))
+	if err != nil || maxKeys <= 0 {
+		maxKeys = 1000
+	}
+	after := max(query.Get("continuation-token"), query.Get("start-after"))
+
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		Prefix                string
+		KeyCount              int
+		MaxKeys               int
+		Delimiter             string `xml:",omitempty"`
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+		Contents              []content
+		CommonPrefixes        []commonPrefix
+	}{Name: bucketName, Prefix: prefix, MaxKeys: maxKeys, Delimiter: delimiter}
+
+	last := ""
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		entry := key
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if entry <= after || entry == last {
+			continue
+		}
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = last
+			break
+		}
+
+		if entry == key {
+			obj := b.objects[key]
+			result.Contents = append(result.Contents, content{
+				Key:          key,
+				LastModified: obj.modified.Format(time.RFC3339),
+				ETag:         etag(obj),
+				Size:         len(obj.data),
+				StorageClass: "STANDARD",
+			})
+		} else {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: entry})
+		}
+		result.KeyCount++
+		last = entry
+	}
+	if g.reversePrefixes {
+		slices.Reverse(result.CommonPrefixes)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// deleteObjects answers DeleteObjects, failing the keys listed in b.deleteFails
+func deleteObjects(w http.ResponseWriter, r *http.Request, b *bucket) {
+	var request struct {
+		Objects []struct {
+			Key string
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	type deleteError struct {
+		Key     string
+		Code    string
+		Message string
+	}
+	result := struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Errors  []deleteError `xml:"Error"`
+	}{}
+	for _, obj := range request.Objects {
+		if b.deleteFails[obj.Key] {
+			result.Errors = append(result.Errors, deleteError{Key: obj.Key, Code: "AccessDenied", Message: "Access denied"})
+			continue
+		}
+		delete(b.objects, obj.Key)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// storeObject answers PutObject, decoding chunked uploads
+func (g *Server) storeObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = newChunkReader(r.Body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	obj := object{data: data, contentType: r.Header.Get("Content-Type"), modified: g.clock.Now()}
+	b.objects[key] = obj
+	w.Header().Set("ETag", etag(obj))
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyObject answers CopyObject
+func (g *Server) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	source, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	srcBucketName, srcKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	srcBucket, ok := g.buckets[srcBucketName]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", "The source bucket does not exist")
+		return
+	}
+	obj, ok := srcBucket.objects[srcKey]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", "The source key does not exist")
+		return
+	}
+
+	obj.modified = g.clock.Now()
+	b.objects[key] = obj
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = fmt.Fprintf(w, `<CopyObjectResult><LastModified>%s</LastModified><ETag>%s</ETag></CopyObjectResult>`,
+		obj.modified.Format(time.RFC3339), etag(obj))
+}
+
+// newChunkReader decodes an aws-chunked body: hexadecimal chunk sizes, optionally followed
+// by a chunk signature, each on a line before its data, up to a chunk of size zero
+func newChunkReader(body io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			sizeField, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+			size, err := strconv.ParseInt(sizeField, 16, 64)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if size == 0 {
+				pw.Close()
+				return
+			}
+			if _, err := io.CopyN(pw, reader, size); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := reader.Discard(2); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// serveObject answers GetObject and HeadObject, honouring a single byte range
+func serveObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	key, _ = url.PathUnescape(key)
+	obj, ok := b.objects[key]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", "The key does not exist")
+		return
+	}
+
+	w.Header().Set("Content-Type", obj.contentType)
+	w.Header().Set("ETag", etag(obj))
+	w.Header().Set("Last-Modified", obj.modified.Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	data := obj.data
+	status := http.StatusOK
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		first, last, _ := strings.Cut(spec, "-")
+		start, _ := strconv.Atoi(first)
+		end := len(data) - 1
+		if last != "" {
+			end, _ = strconv.Atoi(last)
+		}
+		end = min(end, len(data)-1)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = io.Copy(w, bytes.NewReader(data))
+	}
+}
+
+// etag returns the quoted ETag of an object, the MD5 of its data as for single-part uploads
+func etag(obj object) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum(obj.data))
+}
+
+// writeS3Error answers an S3 error; HEAD responses carry no body
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = fmt.Fprintf(w,
+		`<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message><Resource>%s</Resource><RequestId>fake</RequestId></Error>`,
+		code, message, r.URL.Path,
+	)
+}
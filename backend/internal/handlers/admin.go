@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// AdminHandler handles administrator-only overview operations
+type AdminHandler struct {
+	adminService  *services.GarageAdminService
+	settingsStore *services.SettingsStore
+	auditLog      *services.AuditLog
+	notifier      *services.Notifier
+	supportBundle *services.SupportBundle
+	selfTest      *services.SelfTest
+	pagination    *config.PaginationConfig
+	clock         clock.Clock // Judges maintenance end times and dates reports
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService *services.GarageAdminService, settingsStore *services.SettingsStore, auditLog *services.AuditLog, notifier *services.Notifier, supportBundle *services.SupportBundle, selfTest *services.SelfTest, pagination *config.PaginationConfig, clk clock.Clock) *AdminHandler {
+	return &AdminHandler{
+		adminService:  adminService,
+		settingsStore: settingsStore,
+		auditLog:      auditLog,
+		notifier:      notifier,
+		supportBundle: supportBundle,
+		selfTest:      selfTest,
+		pagination:    pagination,
+		clock:         clk,
+	}
+}
+
+// ListAuditEvents returns recent audit events
+//
+//	@Summary		List audit events
+//	@Description	Returns one page of the audit events kept in memory, newest first. Admin only.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			limit	query		int													false	"Page size (default: server.pagination.default_page_size, capped at max_page_size)"
+//	@Param			offset	query		int													false	"Index of the first event (default: 0)"
+//	@Success		200		{object}	models.APIResponse{data=models.AuditListResponse}	"Audit events"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid paging parameters"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}			"Administrator privileges required"
+//	@Router			/api/v1/admin/audit [get]
+func (h *AdminHandler) ListAuditEvents(c fiber.Ctx) error {
+	params, err := parsePageParams(c, h.pagination, "limit", 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
+		)
+	}
+
+	events, pagination := paginate(h.auditLog.Recent(0), params)
+
+	return c.JSON(models.SuccessResponse(models.AuditListResponse{
+		Events:     events,
+		Count:      len(events),
+		Pagination: pagination,
+	}))
+}
+
+// ListNotifications returns the recent key notification deliveries
+//
+//	@Summary		List key notification deliveries
+//	@Description	Returns one page of the key notification deliveries kept in memory, newest first, with their status (delivered, failed or dropped), attempts and last error. Admin only.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			limit	query		int															false	"Page size (default: server.pagination.default_page_size, capped at max_page_size)"
+//	@Param			offset	query		int															false	"Index of the first delivery (default: 0)"
+//	@Success		200		{object}	models.APIResponse{data=models.NotificationListResponse}	"Notification deliveries"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}					"Invalid paging parameters"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}					"Administrator privileges required"
+//	@Router			/api/v1/admin/notifications [get]
+func (h *AdminHandler) ListNotifications(c fiber.Ctx) error {
+	params, err := parsePageParams(c, h.pagination, "limit", 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
+		)
+	}
+
+	deliveries, pagination := paginate(h.notifier.Recent(0), params)
+
+	return c.JSON(models.SuccessResponse(models.NotificationListResponse{
+		Deliveries: deliveries,
+		Count:      len(deliveries),
+		Pagination: pagination,
+	}))
+}
+
+// RawAdminRequest proxies a read-only Admin API call for debugging
+//
+//	@Summary		Raw Admin API request
+//	@Description	Calls one of a fixed set of read-only Admin API endpoints (GetClusterHealth, GetClusterStatus, GetBucketInfo, GetKeyInfo, ListWorkers) and returns its raw JSON answer with timing. Secret keys are removed from the answer. Every call is recorded in the audit log. Admin only.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.AdminRawRequest								true	"Endpoint and query parameters"
+//	@Success		200		{object}	models.APIResponse{data=models.AdminRawResponse}	"Raw Admin API answer"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid request body or parameter"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}			"Endpoint not allowed, or administrator privileges required"
+//	@Failure		502		{object}	models.APIResponse{error=models.APIError}			"Admin API unreachable"
+//	@Router			/api/v1/admin/raw [post]
+func (h *AdminHandler) RawAdminRequest(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	var req models.AdminRawRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	event := newAuditEvent(c, "admin.raw", req.Endpoint)
+	event.Details = req.Params
+	response, err := h.adminService.RawRequest(ctx, req.Endpoint, req.Params)
+	event.Success = err == nil
+	h.auditLog.Record(event)
+
+	switch {
+	case errors.Is(err, services.ErrRawEndpointNotAllowed):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Endpoint not allowed; available: "+strings.Join(services.RawEndpoints(), ", ")),
+		)
+	case errors.Is(err, services.ErrRawParamNotAllowed):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid parameter: "+err.Error()),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusBadGateway).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Admin API request failed: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// SetMaintenance enables or ends the maintenance mode
+//
+//	@Summary		Set maintenance mode
+//	@Description	Enables or ends the maintenance mode. While it is in effect every API call that would change something is rejected with 503 and the message, browsing keeps working, and /health and /auth/config report it so the frontend can show a banner. Maintenance ends on its own at until, if set. It survives restarts when server.settings_path is set. Admin only.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MaintenanceRequest					true	"Maintenance state"
+//	@Success		200		{object}	models.APIResponse{data=models.Maintenance}	"Maintenance state saved"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid request body, or until is in the past"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}	"Administrator privileges required"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}	"Failed to save the maintenance state"
+//	@Router			/api/v1/admin/maintenance [post]
+func (h *AdminHandler) SetMaintenance(c fiber.Ctx) error {
+	var req models.MaintenanceRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	// Ending maintenance forgets its message and end time
+	maintenance := models.Maintenance{Enabled: req.Enabled}
+	if req.Enabled {
+		if req.Until != nil && !req.Until.After(h.clock.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "until must be in the future"),
+			)
+		}
+		maintenance.Message = strings.TrimSpace(req.Message)
+		if req.Until != nil {
+			until := req.Until.UTC()
+			maintenance.Until = &until
+		}
+	}
+
+	event := newAuditEvent(c, "admin.maintenance", "")
+	event.Details = map[string]string{
+		"enabled": strconv.FormatBool(maintenance.Enabled),
+	}
+	if maintenance.Message != "" {
+		event.Details["message"] = maintenance.Message
+	}
+	if maintenance.Until != nil {
+		event.Details["until"] = maintenance.Until.Format(time.RFC3339)
+	}
+	err := h.settingsStore.SetMaintenance(maintenance)
+	event.Success = err == nil
+	h.auditLog.Record(event)
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to save the maintenance state: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(maintenance))
+}
+
+// RunSelfTest exercises the deployment end to end
+//
+//	@Summary		Run a self-test
+//	@Description	Exercises the deployment end to end in the bucket set by monitoring.selftest_bucket, creating it if missing: a temporary key is created and granted read/write on the bucket, a small object is uploaded with it, inspected, downloaded through a presigned URL and deleted, then the key is revoked and deleted. With delete_bucket the bucket is deleted too. Steps after a failed one are skipped, but what the run created is always removed, and leftovers of an interrupted run are removed first, so the test can be run any number of times. Each step is reported with its timing and, on failure, a hint. The run is audited. Admin only.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.SelfTestRequest							false	"Self-test options"
+//	@Success		200		{object}	models.APIResponse{data=models.SelfTestReport}	"Self-test report, passed or not"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}		"Invalid request body"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}		"Administrator privileges required"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}		"A self-test is already running"
+//	@Router			/api/v1/admin/selftest [post]
+func (h *AdminHandler) RunSelfTest(c fiber.Ctx) error {
+	var req models.SelfTestRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+			)
+		}
+	}
+
+	report, err := h.selfTest.Run(c.Context(), req.DeleteBucket)
+	if errors.Is(err, services.ErrSelfTestRunning) {
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, err.Error()),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to run the self-test: "+err.Error()),
+		)
+	}
+
+	event := newAuditEvent(c, "admin.selftest", report.Bucket)
+	event.Success = report.Success
+	event.Details = map[string]string{
+		"delete_bucket": strconv.FormatBool(req.DeleteBucket),
+		"duration_ms":   strconv.FormatInt(report.DurationMs, 10),
+	}
+	var failed []string
+	for _, step := range report.Steps {
+		if step.Status == models.DiagnosticStatusFailed {
+			failed = append(failed, step.Name)
+		}
+	}
+	if len(failed) > 0 {
+		event.Details["failed_steps"] = strings.Join(failed, ",")
+	}
+	h.auditLog.Record(event)
+
+	return c.JSON(models.SuccessResponse(report))
+}
+
+// GetPermissionMatrix returns which keys can access which buckets
+//
+//	@Summary		Get bucket permission matrix
+//	@Description	Returns a matrix of keys (rows) by buckets (columns) with r/w/o permission flags, for one page of buckets. Admin only.
+//	@Tags			Admin
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			offset			query		int													false	"Index of the first bucket column (default: 0)"
+//	@Param			limit			query		int													false	"Number of bucket columns (default: 100, max: 500)"
+//	@Param			owner_threshold	query		int													false	"Count keys with owner on more than this many buckets (default: 5)"
+//	@Param			format			query		string												false	"Response format: json (default) or csv"
+//	@Success		200				{object}	models.APIResponse{data=models.PermissionMatrix}	"Permission matrix"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}			"Invalid query parameters"
+//	@Failure		403				{object}	models.APIResponse{error=models.APIError}			"Administrator privileges required"
+//	@Failure		500				{object}	models.APIResponse{error=models.APIError}			"Failed to build permission matrix"
+//	@Router			/api/v1/admin/permission-matrix [get]
+func (h *AdminHandler) GetPermissionMatrix(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid offset parameter"),
+		)
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "100"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid limit parameter (must be between 1 and 500)"),
+		)
+	}
+
+	ownerThreshold, err := strconv.Atoi(c.Query("owner_threshold", "5"))
+	if err != nil || ownerThreshold < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid owner_threshold parameter"),
+		)
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid format parameter (must be json or csv)"),
+		)
+	}
+
+	matrix, err := h.adminService.BuildPermissionMatrix(ctx, offset, limit, ownerThreshold)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to build permission matrix: "+err.Error()),
+		)
+	}
+
+	if format == "json" {
+		return c.JSON(models.SuccessResponse(matrix))
+	}
+
+	// CSV export: one row per key, one column per bucket
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"access_key_id", "name"}
+	for _, bucket := range matrix.Buckets {
+		header = append(header, csvSafe(bucket.Name))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range matrix.Keys {
+		record := append([]string{row.AccessKeyID, csvSafe(row.Name)}, row.Cells...)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", "attachment; filename=\"permission-matrix.csv\"")
+	return c.Send(buf.Bytes())
+}
+
+// csvSafe neutralizes values spreadsheet applications would otherwise evaluate as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// DownloadSupportBundle streams a zip of the information needed for bug reports
+//
+//	@Summary		Download support bundle
+//	@Description	Streams a zip archive holding the effective configuration, version and build information, recent log lines, the last diagnostics run, cluster health and status snapshots and the slow-request list, all with secrets removed. Files are cut so the bundle stays bounded in size. Every download is recorded in the audit log. Admin only.
+//	@Tags			Admin
+//	@Produce		application/zip
+//	@Success		200	{file}		file										"Support bundle"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}	"Administrator privileges required"
+//	@Router			/api/v1/admin/support-bundle [get]
+func (h *AdminHandler) DownloadSupportBundle(c fiber.Ctx) error {
+	generatedAt := h.clock.Now().UTC()
+	files := h.supportBundle.Collect(c.Context())
+
+	size := 0
+	var truncated []string
+	for _, file := range files {
+		size += len(file.Data)
+		if file.Truncated {
+			truncated = append(truncated, file.Name)
+		}
+	}
+
+	event := newAuditEvent(c, "admin.support_bundle", "")
+	event.Success = true
+	event.Details = map[string]string{
+		"files": strconv.Itoa(len(files)),
+		"bytes": strconv.Itoa(size),
+	}
+	if len(truncated) > 0 {
+		event.Details["truncated"] = strings.Join(truncated, ",")
+	}
+	h.auditLog.Record(event)
+
+	filename := "garage-ui-support-" + generatedAt.Format("20060102-150405") + ".zip"
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Set("Cache-Control", "no-store")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		if err := services.WriteSupportBundle(w, files, generatedAt); err != nil {
+			logger.Warn().Err(err).Msg("Failed to write support bundle")
+			return
+		}
+		w.Flush()
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// newAuditEvent creates an audit event attributed to the user making the request
+func newAuditEvent(c fiber.Ctx, action, target string) models.AuditEvent {
+	event := models.AuditEvent{
+		Action: action,
+		Target: target,
+		IP:     c.IP(),
+	}
+
+	if username, ok := c.Locals("username").(string); ok {
+		event.Actor = username
+	}
+	if authMethod, ok := c.Locals("authMethod").(string); ok {
+		event.AuthMethod = authMethod
+	}
+
+	return event
+}
+
+// transferUser returns the user that object transfers are attributed to. The name is
+// cloned because streamed transfers are recorded after the request context is released.
+func transferUser(c fiber.Ctx) string {
+	if username, ok := c.Locals("username").(string); ok && username != "" {
+		return strings.Clone(username)
+	}
+	return "anonymous"
+}
+
+// actorName returns the name of the authenticated user making the request
+func actorName(c fiber.Ctx) string {
+	username, _ := c.Locals("username").(string)
+	return username
+}
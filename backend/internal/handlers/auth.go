@@ -1,55 +1,61 @@
 package handlers
 
 import (
+	"slices"
+
 	"Noooste/garage-ui/internal/auth"
 	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	cfg         *config.Config
-	authService *auth.Service
+	cfg           *config.Config
+	authService   *auth.Service
+	settingsStore *services.SettingsStore
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(cfg *config.Config, authService *auth.Service) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, authService *auth.Service, settingsStore *services.SettingsStore) *AuthHandler {
 	return &AuthHandler{
-		cfg:         cfg,
-		authService: authService,
+		cfg:           cfg,
+		authService:   authService,
+		settingsStore: settingsStore,
 	}
 }
 
 // GetAuthConfig returns the current authentication configuration
 //
 //	@Summary		Get authentication configuration
-//	@Description	Returns the current auth configuration (admin and/or OIDC)
+//	@Description	Returns the current auth configuration (admin and/or OIDC), and the maintenance mode while it is in effect. OIDC carries the state of the provider: initializing or degraded while its discovery has not succeeded, during which OIDC logins are refused, then ready.
 //	@Tags			auth
 //	@Produce		json
-//	@Success		200	{object}	object{admin=object,oidc=object}	"Auth config"
+//	@Success		200	{object}	models.APIResponse{data=models.AuthConfigResponse}	"Auth config"
 //	@Router			/auth/config [get]
 func (h *AuthHandler) GetAuthConfig(c fiber.Ctx) error {
-	response := fiber.Map{
-		"admin": fiber.Map{
-			"enabled": h.cfg.Auth.Admin.Enabled,
-		},
-		"oidc": fiber.Map{
-			"enabled": h.cfg.Auth.OIDC.Enabled,
-		},
+	response := models.AuthConfigResponse{
+		Admin: models.AuthMethodConfig{Enabled: h.cfg.Auth.Admin.Enabled},
+		OIDC:  models.AuthMethodConfig{Enabled: h.cfg.Auth.OIDC.Enabled},
 	}
 
 	// Add provider name if OIDC is enabled
 	if h.cfg.Auth.OIDC.Enabled {
-		provider := h.cfg.Auth.OIDC.ProviderName
-		if provider == "" {
-			provider = "OIDC Provider"
+		response.OIDC.Provider = h.cfg.Auth.OIDC.ProviderName
+		if response.OIDC.Provider == "" {
+			response.OIDC.Provider = "OIDC Provider"
 		}
-		response["oidc"].(fiber.Map)["provider"] = provider
+		response.OIDC.Status = h.authService.OIDCStatus()
+	}
+
+	// The login page shows the maintenance banner too
+	if maintenance, active := h.settingsStore.Maintenance(); active {
+		response.Maintenance = &maintenance
 	}
 
-	return c.JSON(response)
+	return c.JSON(models.SuccessResponse(response))
 }
 
 // LoginBasicRequest represents the basic auth login request
@@ -66,9 +72,9 @@ type LoginBasicRequest struct {
 //	@Accept			json
 //	@Produce		json
 //	@Param			credentials	body		LoginBasicRequest								true	"Login credentials"
-//	@Success		200			{object}	object{success=bool,token=string,user=object}	"Login successful"
-//	@Failure		400			{object}	models.APIResponse								"Invalid request"
-//	@Failure		401			{object}	models.APIResponse								"Invalid credentials"
+//	@Success		200			{object}	models.APIResponse{data=models.LoginResponse}	"Login successful"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}		"Invalid request"
+//	@Failure		401			{object}	models.APIResponse{error=models.APIError}		"Invalid credentials"
 //	@Router			/auth/login [post]
 func (h *AuthHandler) LoginAdmin(c fiber.Ctx) error {
 	// Parse request body
@@ -88,7 +94,8 @@ func (h *AuthHandler) LoginAdmin(c fiber.Ctx) error {
 
 	// Create user info object
 	userInfo := &auth.UserInfo{
-		Username: req.Username,
+		Username:   req.Username,
+		AuthMethod: auth.AuthMethodAdmin,
 	}
 
 	// Generate JWT session token
@@ -99,24 +106,21 @@ func (h *AuthHandler) LoginAdmin(c fiber.Ctx) error {
 		)
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"token":   sessionToken,
-		"user": fiber.Map{
-			"username": userInfo.Username,
-		},
-	})
+	return c.JSON(models.SuccessResponse(models.LoginResponse{
+		Token: sessionToken,
+		User:  models.AuthUser{Username: userInfo.Username},
+	}))
 }
 
 // GetMe returns the current authenticated user's information
 //
 //	@Summary		Get current user
-//	@Description	Returns information about the currently authenticated user
+//	@Description	Returns information about the currently authenticated user, with their role and a summary of the buckets they can access
 //	@Tags			auth
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Success		200	{object}	object{success=bool,user=object}	"User information"
-//	@Failure		401	{object}	models.APIResponse					"Not authenticated"
+//	@Success		200	{object}	models.APIResponse{data=models.CurrentUserResponse}	"User information"
+//	@Failure		401	{object}	models.APIResponse{error=models.APIError}			"Not authenticated"
 //	@Router			/auth/me [get]
 func (h *AuthHandler) GetMe(c fiber.Ctx) error {
 	// Try to get user info from OIDC context
@@ -124,14 +128,14 @@ func (h *AuthHandler) GetMe(c fiber.Ctx) error {
 	if userInfoInterface != nil {
 		userInfo, ok := userInfoInterface.(*auth.UserInfo)
 		if ok {
-			return c.JSON(fiber.Map{
-				"success": true,
-				"user": fiber.Map{
-					"username": userInfo.Username,
-					"email":    userInfo.Email,
-					"name":     userInfo.Name,
+			return c.JSON(models.SuccessResponse(models.CurrentUserResponse{
+				User: models.AuthUser{
+					Username: userInfo.Username,
+					Email:    userInfo.Email,
+					Name:     userInfo.Name,
 				},
-			})
+				Summary: h.userSummary(c),
+			}))
 		}
 	}
 
@@ -140,12 +144,10 @@ func (h *AuthHandler) GetMe(c fiber.Ctx) error {
 	if usernameInterface != nil {
 		username, ok := usernameInterface.(string)
 		if ok {
-			return c.JSON(fiber.Map{
-				"success": true,
-				"user": fiber.Map{
-					"username": username,
-				},
-			})
+			return c.JSON(models.SuccessResponse(models.CurrentUserResponse{
+				User:    models.AuthUser{Username: username},
+				Summary: h.userSummary(c),
+			}))
 		}
 	}
 
@@ -153,3 +155,186 @@ func (h *AuthHandler) GetMe(c fiber.Ctx) error {
 		models.ErrorResponse(models.ErrCodeUnauthorized, "Not authenticated"),
 	)
 }
+
+// OIDCLogin starts an OIDC login by redirecting to the provider
+//
+//	@Summary		Start OIDC login
+//	@Description	Redirects to the OIDC provider's authorization endpoint. Until the provider has been discovered, 503 is returned with a Retry-After header.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		302	"Redirect to the OIDC provider"
+//	@Failure		500	{object}	models.APIResponse{error=models.APIError}	"Failed to start login"
+//	@Failure		503	{object}	models.APIResponse{error=models.APIError}	"OIDC provider not available yet"
+//	@Router			/auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(c fiber.Ctx) error {
+	if h.authService.OIDCStatus() != auth.OIDCStatusReady {
+		c.Set(fiber.HeaderRetryAfter, "10")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(
+			models.ErrorResponse(models.ErrCodeUpstream, "The OIDC provider is not available yet, please retry shortly or sign in as admin"),
+		)
+	}
+
+	state, err := h.authService.GenerateStateToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to generate state token"),
+		)
+	}
+
+	authURL, err := h.authService.GetAuthorizationURL(state)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to generate login URL"),
+		)
+	}
+	return c.Redirect().To(authURL)
+}
+
+// OIDCCallback completes an OIDC login, sets the session cookie and redirects to the frontend
+//
+//	@Summary		OIDC login callback
+//	@Description	Exchanges the authorization code, sets the session cookie and redirects to the frontend
+//	@Tags			auth
+//	@Produce		json
+//	@Param			state	query		string										true	"State token issued by /auth/oidc/login"
+//	@Param			code	query		string										true	"Authorization code"
+//	@Success		302		"Redirect to the frontend"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid state or missing code"
+//	@Failure		401		{object}	models.APIResponse{error=models.APIError}	"Code exchange or ID token verification failed"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}	"Failed to create session"
+//	@Router			/auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(c fiber.Ctx) error {
+	// Get and validate state token
+	state := c.Query("state")
+	if !h.authService.ValidateAndConsumeState(state) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid or expired state token"),
+		)
+	}
+
+	// Get authorization code from query
+	code := c.Query("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Authorization code is required"),
+		)
+	}
+
+	// Exchange code for tokens
+	ctx := c.Context()
+	token, err := h.authService.ExchangeCode(ctx, code)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(
+			models.ErrorResponse(models.ErrCodeUnauthorized, "Failed to exchange authorization code"),
+		)
+	}
+
+	// Extract ID token from OAuth2 token
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(
+			models.ErrorResponse(models.ErrCodeUnauthorized, "No ID token in response"),
+		)
+	}
+
+	// Verify ID token and get user info
+	userInfo, err := h.authService.VerifyIDToken(ctx, rawIDToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(
+			models.ErrorResponse(models.ErrCodeUnauthorized, "Invalid ID token"),
+		)
+	}
+
+	// Generate JWT session token
+	sessionToken, err := h.authService.GenerateSessionToken(userInfo)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to create session"),
+		)
+	}
+
+	// Set JWT session token as secure cookie
+	c.Cookie(&fiber.Cookie{
+		Name:     h.cfg.Auth.OIDC.CookieName,
+		Value:    sessionToken,
+		MaxAge:   h.cfg.Auth.OIDC.SessionMaxAge,
+		Secure:   h.cfg.Auth.OIDC.CookieSecure,
+		HTTPOnly: h.cfg.Auth.OIDC.CookieHTTPOnly,
+		SameSite: h.cfg.Auth.OIDC.CookieSameSite,
+	})
+
+	// Redirect to frontend with success indicator
+	return c.Redirect().To("/login?login=success")
+}
+
+// OIDCLogout clears the OIDC session cookie
+//
+//	@Summary		OIDC logout
+//	@Description	Clears the session cookie set by the OIDC callback
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse	"Logged out"
+//	@Router			/auth/oidc/logout [post]
+func (h *AuthHandler) OIDCLogout(c fiber.Ctx) error {
+	// Clear session cookie
+	c.Cookie(&fiber.Cookie{
+		Name:   h.cfg.Auth.OIDC.CookieName,
+		Value:  "",
+		MaxAge: -1,
+	})
+
+	response := map[string]interface{}{
+		"message": "Logged out successfully",
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// userSummary builds the current user's summary from the locals set by the auth middleware
+// and the cached bucket stats, without calling the Admin API. Only the buckets the user can
+// read count towards the total size.
+func (h *AuthHandler) userSummary(c fiber.Ctx) models.UserSummary {
+	summary := models.UserSummary{Role: "user"}
+	if isAdmin, ok := c.Locals("isAdmin").(bool); ok && isAdmin {
+		summary.Role = "admin"
+	}
+	if authMethod, ok := c.Locals("authMethod").(string); ok {
+		summary.AuthMethod = authMethod
+	}
+
+	var scope *auth.TokenScope
+	if userInfo, ok := c.Locals("userInfo").(*auth.UserInfo); ok {
+		scope = userInfo.Scope
+	}
+
+	buckets := services.CachedBuckets()
+	for _, info := range buckets {
+		readable, writable := h.bucketAccess(scope, info)
+		if readable {
+			summary.ReadableBuckets++
+			summary.TotalBytes += info.Bytes
+		}
+		if writable {
+			summary.WritableBuckets++
+		}
+	}
+	summary.StatsAvailable = len(buckets) > 0
+
+	return summary
+}
+
+// bucketAccess reports whether the user can read and write the bucket. Every user can read
+// and write all buckets through the UI, unless they authenticated with a scoped API token,
+// which is limited to its bucket patterns and verbs; read-only buckets refuse writes to all.
+func (h *AuthHandler) bucketAccess(scope *auth.TokenScope, info *models.GarageBucketInfo) (readable, writable bool) {
+	readable, writable = true, true
+	if scope != nil {
+		inScope := slices.ContainsFunc(info.GlobalAliases, scope.AllowsBucket)
+		readable = inScope && scope.AllowsVerb(auth.VerbRead)
+		writable = inScope && scope.AllowsVerb(auth.VerbWrite)
+	}
+	if slices.ContainsFunc(info.GlobalAliases, h.settingsStore.IsReadOnly) {
+		writable = false
+	}
+	return readable, writable
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// newAuthTestApp serves the auth routes with admin and OIDC logins enabled. OIDC discovery
+// is never run, so the provider stays unavailable.
+func newAuthTestApp(t *testing.T) (*fiber.App, *auth.Service) {
+	t.Helper()
+
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			Admin: config.AdminAuthConfig{Enabled: true, Username: "admin", Password: "secret"},
+			OIDC: config.OIDCConfig{
+				Enabled:          true,
+				ProviderName:     "Test IdP",
+				CookieName:       "garage_session",
+				SessionMaxAge:    3600,
+				MaxPendingLogins: 100,
+			},
+		},
+	}
+	authService, err := auth.NewAuthService(&cfg.Auth, &cfg.Server, clock.Real)
+	if err != nil {
+		t.Fatalf("NewAuthService failed: %v", err)
+	}
+	settings, err := services.NewSettingsStore("", nil, clock.Real)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+
+	handler := NewAuthHandler(cfg, authService, settings)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(false)})
+	app.Get("/auth/config", handler.GetAuthConfig)
+	app.Post("/auth/login", handler.LoginAdmin)
+	app.Get("/auth/me", func(c fiber.Ctx) error {
+		if username := c.Get(testUserHeader); username != "" {
+			c.Locals("username", username)
+		}
+		return c.Next()
+	}, handler.GetMe)
+	app.Get("/auth/oidc/login", handler.OIDCLogin)
+	app.Get("/auth/oidc/callback", handler.OIDCCallback)
+	app.Post("/auth/oidc/logout", handler.OIDCLogout)
+
+	return app, authService
+}
+
+func TestAuthHandlersReturnEnvelope(t *testing.T) {
+	app, authService := newAuthTestApp(t)
+
+	state, err := authService.GenerateStateToken()
+	if err != nil {
+		t.Fatalf("GenerateStateToken failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		headers    []string
+		wantStatus int
+		wantCode   string // Empty for successful responses
+	}{
+		{name: "config", method: http.MethodGet, target: "/auth/config", wantStatus: http.StatusOK},
+		{name: "login", method: http.MethodPost, target: "/auth/login", body: `{"username":"admin","password":"secret"}`, wantStatus: http.StatusOK},
+		{name: "login with bad body", method: http.MethodPost, target: "/auth/login", body: `{"username":`, wantStatus: http.StatusBadRequest, wantCode: models.ErrCodeBadRequest},
+		{name: "login with wrong password", method: http.MethodPost, target: "/auth/login", body: `{"username":"admin","password":"wrong"}`, wantStatus: http.StatusUnauthorized, wantCode: models.ErrCodeUnauthorized},
+		{name: "me", method: http.MethodGet, target: "/auth/me", headers: []string{testUserHeader, "alice"}, wantStatus: http.StatusOK},
+		{name: "me unauthenticated", method: http.MethodGet, target: "/auth/me", wantStatus: http.StatusUnauthorized, wantCode: models.ErrCodeUnauthorized},
+		{name: "oidc login before discovery", method: http.MethodGet, target: "/auth/oidc/login", wantStatus: http.StatusServiceUnavailable, wantCode: models.ErrCodeUpstream},
+		{name: "oidc callback with unknown state", method: http.MethodGet, target: "/auth/oidc/callback?state=unknown&code=abc", wantStatus: http.StatusBadRequest, wantCode: models.ErrCodeBadRequest},
+		{name: "oidc callback without code", method: http.MethodGet, target: "/auth/oidc/callback?state=" + state, wantStatus: http.StatusBadRequest, wantCode: models.ErrCodeBadRequest},
+		{name: "oidc logout", method: http.MethodPost, target: "/auth/oidc/logout", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			for i := 0; i+1 < len(tt.headers); i += 2 {
+				req.Header.Set(tt.headers[i], tt.headers[i+1])
+			}
+			resp, err := app.Test(req, fiber.TestConfig{Timeout: 10 * time.Second})
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			response := decodeAPIResponse(t, resp, nil)
+			if response.Success != (tt.wantCode == "") {
+				t.Errorf("success = %v, want %v", response.Success, tt.wantCode == "")
+			}
+			switch {
+			case tt.wantCode == "" && response.Error != nil:
+				t.Errorf("error = %+v, want none", response.Error)
+			case tt.wantCode != "" && (response.Error == nil || response.Error.Code != tt.wantCode):
+				t.Errorf("error = %+v, want code %s", response.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginBeforeDiscoveryAsksToRetry(t *testing.T) {
+	app, _ := newAuthTestApp(t)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Error("no Retry-After header")
+	}
+	if location := resp.Header.Get(fiber.HeaderLocation); location != "" {
+		t.Errorf("redirected to %q", location)
+	}
+}
+
+func TestOIDCCallbackConsumesState(t *testing.T) {
+	app, authService := newAuthTestApp(t)
+
+	state, err := authService.GenerateStateToken()
+	if err != nil {
+		t.Fatalf("GenerateStateToken failed: %v", err)
+	}
+
+	// The first callback uses the state up, even though the provider is unavailable
+	for i, want := range []int{http.StatusUnauthorized, http.StatusBadRequest} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=abc&state="+state, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("callback %d: status = %d, want %d", i+1, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestMeSummaryCountsAccessibleBuckets(t *testing.T) {
+	// The summary covers every bucket info in the process-wide cache
+	utils.GlobalCache.Clear()
+	t.Cleanup(utils.GlobalCache.Clear)
+
+	env := newTestEnv(t)
+	sizes := map[string]int{"docs": 5, "photos": 100, "archive": 1000}
+	for name, size := range sizes {
+		env.addBucket(name)
+		env.PutObject(name, "data", "application/octet-stream", make([]byte, size))
+		if _, err := env.admin.GetCachedBucketInfoByAlias(context.Background(), name); err != nil {
+			t.Fatalf("GetCachedBucketInfoByAlias(%s) failed: %v", name, err)
+		}
+	}
+	if err := env.settings.SetBucketSettings("archive", models.BucketSettings{ReadOnly: true}); err != nil {
+		t.Fatalf("SetBucketSettings failed: %v", err)
+	}
+	handler := NewAuthHandler(env.cfg, nil, env.settings)
+
+	tests := []struct {
+		name         string
+		scope        *auth.TokenScope
+		wantReadable int
+		wantWritable int
+		wantBytes    int64
+	}{
+		{name: "unrestricted", wantReadable: 3, wantWritable: 2, wantBytes: 1105},
+		{name: "scoped to some buckets", scope: &auth.TokenScope{Buckets: []string{"docs", "arch*"}, Verbs: []string{auth.VerbRead, auth.VerbWrite}}, wantReadable: 2, wantWritable: 1, wantBytes: 1005},
+		{name: "read only", scope: &auth.TokenScope{Buckets: []string{"*"}, Verbs: []string{auth.VerbRead}}, wantReadable: 3, wantWritable: 0, wantBytes: 1105},
+		{name: "write only", scope: &auth.TokenScope{Buckets: []string{"photos"}, Verbs: []string{auth.VerbWrite}}, wantReadable: 0, wantWritable: 1, wantBytes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/auth/me", func(c fiber.Ctx) error {
+				c.Locals("userInfo", &auth.UserInfo{Username: "ci", AuthMethod: "token", Scope: tt.scope})
+				return c.Next()
+			}, handler.GetMe)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/me", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var me models.CurrentUserResponse
+			decodeAPIResponse(t, resp, &me)
+			summary := me.Summary
+			if summary.ReadableBuckets != tt.wantReadable || summary.WritableBuckets != tt.wantWritable || summary.TotalBytes != tt.wantBytes {
+				t.Errorf("summary = %d readable, %d writable, %d bytes; want %d, %d, %d",
+					summary.ReadableBuckets, summary.WritableBuckets, summary.TotalBytes, tt.wantReadable, tt.wantWritable, tt.wantBytes)
+			}
+			if !summary.StatsAvailable {
+				t.Error("stats not available")
+			}
+		})
+	}
+}
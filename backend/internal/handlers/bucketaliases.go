@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"slices"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// AddBucketAlias adds a global alias to a bucket
+//
+//	@Summary		Add a bucket alias
+//	@Description	Makes a bucket reachable under one more global alias. The alias follows the bucket naming rules and must not already name another bucket. Adding an alias the bucket already has succeeds without changes.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Param			request	body		models.BucketAliasRequest								true	"Alias to add"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketAliasesResponse}	"The bucket already had the alias"
+//	@Success		201		{object}	models.APIResponse{data=models.BucketAliasesResponse}	"Alias added"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Invalid request body or alias"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Bucket not found"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}				"The alias already names another bucket"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to add the alias"
+//	@Router			/api/v1/buckets/{name}/aliases [post]
+func (h *BucketHandler) AddBucketAlias(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := c.Params("name")
+
+	var req models.BucketAliasRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if req.Alias == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "alias is required"),
+		)
+	}
+	if err := utils.ValidateBucketName(req.Alias); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidBucketName, "Invalid alias: "+err.Error()),
+		)
+	}
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	if services.IsAdminNotFound(err) || (err == nil && bucketInfo == nil) {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get bucket info: "+err.Error()),
+		)
+	}
+
+	if slices.Contains(bucketInfo.GlobalAliases, req.Alias) {
+		return c.JSON(models.SuccessResponse(models.BucketAliasesResponse{
+			Bucket:        bucketInfo.ID,
+			GlobalAliases: bucketInfo.GlobalAliases,
+		}))
+	}
+
+	// Garage would refuse the alias too, but without saying which bucket holds it
+	existing, err := h.adminService.GetBucketInfoByAlias(ctx, req.Alias)
+	switch {
+	case err == nil && existing != nil:
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeBucketExists, "Alias "+req.Alias+" already names bucket "+existing.ID),
+		)
+	case err != nil && !services.IsAdminNotFound(err):
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to check the alias: "+err.Error()),
+		)
+	}
+
+	updated, err := h.adminService.AddBucketAlias(ctx, models.AddBucketAliasRequest{
+		BucketID:    bucketInfo.ID,
+		GlobalAlias: &req.Alias,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to add the alias: "+err.Error()),
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(models.BucketAliasesResponse{
+		Bucket:        updated.ID,
+		GlobalAliases: updated.GlobalAliases,
+	}))
+}
+
+// RemoveBucketAlias removes a global alias from a bucket
+//
+//	@Summary		Remove a bucket alias
+//	@Description	Removes one of the global aliases of a bucket; the bucket stays reachable under the others. The last global alias cannot be removed, as the bucket could no longer be reached by name.
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Param			alias	query		string													true	"Alias to remove"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketAliasesResponse}	"Alias removed"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Alias is required"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Bucket not found, or the alias does not name it"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}				"The alias is the last one of the bucket"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to remove the alias"
+//	@Router			/api/v1/buckets/{name}/aliases [delete]
+func (h *BucketHandler) RemoveBucketAlias(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := c.Params("name")
+
+	alias := c.Query("alias")
+	if alias == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "alias is required"),
+		)
+	}
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	if services.IsAdminNotFound(err) || (err == nil && bucketInfo == nil) {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get bucket info: "+err.Error()),
+		)
+	}
+
+	if !slices.Contains(bucketInfo.GlobalAliases, alias) {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "Bucket has no alias "+alias),
+		)
+	}
+	if len(bucketInfo.GlobalAliases) == 1 {
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, "Cannot remove the last global alias of a bucket"),
+		)
+	}
+
+	updated, err := h.adminService.RemoveBucketAlias(ctx, models.RemoveBucketAliasRequest{
+		BucketID:    bucketInfo.ID,
+		GlobalAlias: &alias,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to remove the alias: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(models.BucketAliasesResponse{
+		Bucket:        updated.ID,
+		GlobalAliases: updated.GlobalAliases,
+	}))
+}
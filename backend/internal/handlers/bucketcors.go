@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetBucketCORS returns the CORS rules of a bucket
+//
+//	@Summary		Get bucket CORS rules
+//	@Description	Returns the S3 CORS rules of a bucket, which let browsers on other origins call the S3 API for it; empty when the bucket has none. Answers 501 when Garage does not implement CORS.
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string												true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketCORSResponse}	"CORS rules"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}			"Garage denied access to the bucket"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Bucket not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to get the CORS rules"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}			"Garage does not support CORS"
+//	@Router			/api/v1/buckets/{name}/cors [get]
+func (h *BucketHandler) GetBucketCORS(c fiber.Ctx) error {
+	bucketName := c.Params("name")
+
+	rules, err := h.s3Service.GetBucketCORS(c.Context(), bucketName)
+	if err != nil {
+		return corsError(c, err, bucketName, "Failed to get CORS rules: ")
+	}
+
+	return c.JSON(models.SuccessResponse(models.BucketCORSResponse{
+		Bucket: bucketName,
+		Rules:  rules,
+	}))
+}
+
+// PutBucketCORS replaces the CORS rules of a bucket
+//
+//	@Summary		Set bucket CORS rules
+//	@Description	Replaces every S3 CORS rule of a bucket with the given ones; an empty list removes the CORS configuration. Each rule needs at least one origin and one method among GET, PUT, POST, DELETE and HEAD; origins and allowed headers may hold one * wildcard. S3 allows at most 100 rules. Answers 501 when Garage does not implement CORS.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string												true	"Name of the bucket"
+//	@Param			request	body		models.BucketCORSRequest							true	"New CORS rules"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketCORSResponse}	"CORS rules saved"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid request body or rules"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}			"Garage denied access to the bucket"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Bucket not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to set the CORS rules"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}			"Garage does not support CORS"
+//	@Router			/api/v1/buckets/{name}/cors [put]
+func (h *BucketHandler) PutBucketCORS(c fiber.Ctx) error {
+	bucketName := c.Params("name")
+
+	var req models.BucketCORSRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if req.Rules == nil {
+		req.Rules = []models.CORSRule{}
+	}
+
+	if err := h.s3Service.PutBucketCORS(c.Context(), bucketName, req.Rules); err != nil {
+		return corsError(c, err, bucketName, "Failed to set CORS rules: ")
+	}
+
+	return c.JSON(models.SuccessResponse(models.BucketCORSResponse{
+		Bucket: bucketName,
+		Rules:  req.Rules,
+	}))
+}
+
+// corsError answers a failed CORS call with the status matching its cause
+func corsError(c fiber.Ctx, err error, bucketName, message string) error {
+	var invalid *services.InvalidCORSError
+	switch {
+	case errors.As(err, &invalid):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, invalid.Error()),
+		)
+	case services.IsNotImplemented(err):
+		return c.Status(fiber.StatusNotImplemented).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, "Garage does not support CORS"),
+		)
+	case services.IsBucketNotFound(err):
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist or no key gives access to it: "+bucketName),
+		)
+	case services.IsAccessDenied(err):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Access to bucket denied: "+bucketName),
+		)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(
+		models.ErrorResponse(models.ErrCodeInternalError, message+err.Error()),
+	)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Fields bucket listings can be sorted by
+const (
+	bucketSortName    = "name"
+	bucketSortCreated = "created"
+)
+
+// maxBucketSearchLength bounds bucket searches; bucket names are at most 63 characters
+const maxBucketSearchLength = 63
+
+// bucketStatsWorkers is how many bucket statistics a listing page fetches at once
+const bucketStatsWorkers = 8
+
+// bucketListQuery holds the search and sort parameters of a bucket listing
+type bucketListQuery struct {
+	Search     string // Lowercased substring of a global alias
+	SortBy     string // One of the bucketSort constants
+	Descending bool
+}
+
+// parseBucketListQuery reads the search and sort query parameters. sort is a field,
+// prefixed with - for descending order, e.g. -created.
+func parseBucketListQuery(c fiber.Ctx) (bucketListQuery, error) {
+	query := bucketListQuery{SortBy: bucketSortName}
+
+	query.Search = strings.ToLower(strings.TrimSpace(c.Query("search")))
+	if len(query.Search) > maxBucketSearchLength {
+		return query, fmt.Errorf("search must be at most %d characters", maxBucketSearchLength)
+	}
+
+	if value := c.Query("sort"); value != "" {
+		field, descending := strings.CutPrefix(value, "-")
+		switch field {
+		case bucketSortName, bucketSortCreated:
+			query.SortBy, query.Descending = field, descending
+		default:
+			return query, fmt.Errorf("sort must be name or created, optionally prefixed with -, got %q", value)
+		}
+	}
+
+	return query, nil
+}
+
+// matches reports whether one of the aliases of a bucket contains the search
+func (q bucketListQuery) matches(aliases ...string) bool {
+	if q.Search == "" {
+		return true
+	}
+	for _, alias := range aliases {
+		if strings.Contains(strings.ToLower(alias), q.Search) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare orders two buckets by the sort field, then by name so pages are stable
+func (q bucketListQuery) compare(nameA string, createdA time.Time, nameB string, createdB time.Time) int {
+	result := 0
+	if q.SortBy == bucketSortCreated {
+		result = createdA.Compare(createdB)
+	}
+	if result == 0 {
+		result = strings.Compare(nameA, nameB)
+	}
+	if q.Descending {
+		return -result
+	}
+	return result
+}
+
+// applyPageNumber lets page, counted from 1, stand in for offset, in pages of params.Limit
+func applyPageNumber(c fiber.Ctx, params pageParams) (pageParams, error) {
+	value := c.Query("page")
+	if value == "" {
+		return params, nil
+	}
+	if c.Query("offset") != "" {
+		return params, errors.New("page and offset cannot be combined")
+	}
+
+	page, err := strconv.Atoi(value)
+	if err != nil || page < 1 {
+		return params, errors.New("page must be a positive integer")
+	}
+	params.Offset = (page - 1) * params.Limit
+	return params, nil
+}
@@ -1,102 +1,254 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/logger"
+	"Noooste/garage-ui/pkg/utils"
 
 	"github.com/gofiber/fiber/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 // BucketHandler handles bucket-related operations
 type BucketHandler struct {
-	adminService *services.GarageAdminService
-	s3Service    *services.S3Service
+	adminService  *services.GarageAdminService
+	s3Service     *services.S3Service
+	settingsStore *services.SettingsStore
+	auditLog      *services.AuditLog
+	notifier      *services.Notifier
+	pagination    *config.PaginationConfig
+
+	// lastDegradedLog is when the degraded bucket listing was last logged, in Unix nanoseconds
+	lastDegradedLog atomic.Int64
 }
 
+// Bounds of the wait for the grants of a new bucket to become visible
+const (
+	grantPropagationAttempts = 5
+	grantPropagationInterval = 200 * time.Millisecond
+)
+
+// degradedLogInterval limits how often serving the degraded bucket listing is logged
+const degradedLogInterval = time.Minute
+
 // NewBucketHandler creates a new bucket handler
-func NewBucketHandler(adminService *services.GarageAdminService, s3Service *services.S3Service) *BucketHandler {
+func NewBucketHandler(adminService *services.GarageAdminService, s3Service *services.S3Service, settingsStore *services.SettingsStore, auditLog *services.AuditLog, notifier *services.Notifier, pagination *config.PaginationConfig) *BucketHandler {
 	return &BucketHandler{
-		adminService: adminService,
-		s3Service:    s3Service,
+		adminService:  adminService,
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
+		auditLog:      auditLog,
+		notifier:      notifier,
+		pagination:    pagination,
 	}
 }
 
 // ListBuckets lists all buckets
 //
 //	@Summary		List all buckets
-//	@Description	Retrieves a list of all buckets in the Garage storage system with object count and size
+//	@Description	Retrieves one page of the buckets in the Garage storage system, ordered by name unless sort says otherwise, with object count, size, number of keys, website access and whether a quota is set. These come from one Admin API lookup per bucket of the page, made 8 at a time, which skip_stats=true avoids on very large clusters; when a lookup fails, the last statistics fetched are returned flagged as stale. search keeps the buckets one of whose global aliases contains it, ignoring case, and total counts the matching buckets. When the Admin API is unavailable and garage.default_access_key is set, the buckets visible to that key are listed instead, without statistics, and the response is flagged as degraded. Time budget: server.timeouts.listing (default: 10s).
 //	@Tags			Buckets
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	models.APIResponse{data=models.BucketListResponse}	"Successfully retrieved list of buckets"
-//	@Failure		500	{object}	models.APIResponse{error=models.APIError}			"Failed to list buckets"
+//	@Param			limit		query		int													false	"Page size (default: server.pagination.default_page_size, capped at max_page_size)"
+//	@Param			per_page	query		int													false	"Same as limit"
+//	@Param			offset		query		int													false	"Index of the first bucket (default: 0)"
+//	@Param			page		query		int													false	"Page number counted from 1, instead of offset"
+//	@Param			search		query		string												false	"Only return buckets with a global alias containing this, ignoring case"
+//	@Param			sort		query		string												false	"name (default) or created, prefixed with - for descending order, e.g. -created"
+//	@Param			skip_stats	query		bool												false	"Only return names and creation dates"
+//	@Param			fields		query		string												false	"Comma-separated bucket fields to return, e.g. name,size; statistics are skipped when none is selected"
+//	@Success		200			{object}	models.APIResponse{data=models.BucketListResponse}	"Successfully retrieved list of buckets"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}			"Invalid paging, search or sort parameters, or unknown field"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}			"Failed to list buckets"
 //	@Router			/api/v1/buckets [get]
 func (h *BucketHandler) ListBuckets(c fiber.Ctx) error {
 	ctx := c.Context()
 
+	limitParam := "limit"
+	if c.Query("per_page") != "" {
+		limitParam = "per_page"
+	}
+	params, err := parsePageParams(c, h.pagination, limitParam, 0)
+	if err == nil {
+		params, err = applyPageNumber(c, params)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
+		)
+	}
+
+	query, err := parseBucketListQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid bucket query: "+err.Error()),
+		)
+	}
+
+	fields, err := parseFields[models.BucketInfo](c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid fields parameter: "+err.Error()),
+		)
+	}
+
 	// List all buckets from Garage Admin API
-	adminBuckets, err := h.adminService.ListBuckets(ctx)
+	adminBuckets, truncated, err := h.adminService.ListBuckets(ctx)
 	if err != nil {
+		if h.s3Service.HasDefaultCredentials() {
+			return h.listBucketsDegraded(c, params, query, fields, err)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeListFailed, "Failed to list buckets: "+err.Error()),
 		)
 	}
 
-	// Convert admin bucket response to BucketInfo
-	buckets := make([]models.BucketInfo, 0, len(adminBuckets))
+	// Skip buckets without global aliases or not matching the search, and order the rest
+	named := make([]models.ListBucketsResponseItem, 0, len(adminBuckets))
 	for _, adminBucket := range adminBuckets {
-		// Get the bucket name from global aliases
-		var bucketName string
-		if len(adminBucket.GlobalAliases) > 0 {
-			bucketName = adminBucket.GlobalAliases[0]
-		} else {
-			// Skip buckets without global aliases
-			continue
+		if len(adminBucket.GlobalAliases) > 0 && query.matches(adminBucket.GlobalAliases...) {
+			named = append(named, adminBucket)
 		}
+	}
+	slices.SortFunc(named, func(a, b models.ListBucketsResponseItem) int {
+		return query.compare(a.GlobalAliases[0], a.Created, b.GlobalAliases[0], b.Created)
+	})
+	page, pagination := paginate(named, params)
+	// Statistics cost an Admin API lookup per bucket, so skip them when no field needs them
+	skipStats := c.Query("skip_stats") == "true" ||
+		(len(fields) > 0 && !fields["objectCount"] && !fields["size"] && !fields["keyCount"] && !fields["websiteAccess"] && !fields["hasQuota"])
+
+	// Convert admin bucket response to BucketInfo, fetching stats for this page only. Each
+	// bucket has its own slot, so workers never share state.
+	buckets := make([]models.BucketInfo, len(page))
+	var group errgroup.Group
+	group.SetLimit(bucketStatsWorkers)
+	for i, adminBucket := range page {
+		group.Go(func() error {
+			buckets[i] = h.bucketListEntry(ctx, adminBucket, skipStats)
+			return nil
+		})
+	}
+	_ = group.Wait()
 
-		// Get detailed bucket info from Admin API to retrieve object count and size
-		detailedInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
-		if err != nil {
-			// If we can't get detailed info, return basic info without stats
-			buckets = append(buckets, models.BucketInfo{
-				Name:         bucketName,
-				CreationDate: adminBucket.Created,
-				Region:       "",
-			})
-			continue
-		}
+	response := models.BucketListResponse{
+		Buckets:    buckets,
+		Count:      len(buckets),
+		Truncated:  truncated,
+		Pagination: pagination,
+	}
 
-		bucketInfo := models.BucketInfo{
+	return sendListing(c, response, "buckets", fields)
+}
+
+// bucketListEntry describes a bucket of the listing, with its statistics unless skipStats
+// is set or they cannot be fetched
+func (h *BucketHandler) bucketListEntry(ctx context.Context, adminBucket models.ListBucketsResponseItem, skipStats bool) models.BucketInfo {
+	bucketName := adminBucket.GlobalAliases[0]
+	readOnly := h.settingsStore.IsReadOnly(bucketName)
+
+	// Get detailed bucket info from Admin API to retrieve object count and size
+	var detailedInfo *models.GarageBucketInfo
+	var staleSince *time.Time
+	var err error
+	if !skipStats {
+		detailedInfo, staleSince, err = h.adminService.GetBucketInfoOrStale(ctx, bucketName)
+	}
+	if skipStats || err != nil {
+		// Without detailed info, skipped or unavailable, return basic info without stats
+		return models.BucketInfo{
 			Name:         bucketName,
-			CreationDate: adminBucket.Created,
-			Region:       "", // Garage doesn't have regions
-			ObjectCount:  &detailedInfo.Objects,
-			Size:         &detailedInfo.Bytes,
+			CreationDate: utils.UTC(adminBucket.Created),
+			Region:       "",
+			ReadOnly:     readOnly,
 		}
+	}
 
-		buckets = append(buckets, bucketInfo)
+	bucketInfo := models.BucketInfo{
+		Name:         bucketName,
+		CreationDate: utils.UTC(adminBucket.Created),
+		Region:       "", // Garage doesn't have regions
+		ObjectCount:  &detailedInfo.Objects,
+		Size:         &detailedInfo.Bytes,
+		ReadOnly:     readOnly,
+		Stale:        staleSince != nil,
+		GeneratedAt:  staleSince,
 	}
 
-	response := models.BucketListResponse{
-		Buckets: buckets,
-		Count:   len(buckets),
+	keyCount := len(detailedInfo.Keys)
+	hasQuota := detailedInfo.Quotas != nil && (detailedInfo.Quotas.MaxSize != nil || detailedInfo.Quotas.MaxObjects != nil)
+	bucketInfo.KeyCount = &keyCount
+	bucketInfo.WebsiteAccess = &detailedInfo.WebsiteAccess
+	bucketInfo.HasQuota = &hasQuota
+
+	return bucketInfo
+}
+
+// listBucketsDegraded serves the bucket listing through S3 with the default key while the
+// Admin API is unavailable. Statistics come from the Admin API, so none are returned.
+func (h *BucketHandler) listBucketsDegraded(c fiber.Ctx, params pageParams, query bucketListQuery, fields models.FieldSelection, adminErr error) error {
+	listing, err := h.s3Service.ListBuckets(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeListFailed, "Failed to list buckets: "+adminErr.Error()),
+		)
 	}
 
-	return c.JSON(models.SuccessResponse(response))
+	// Log once per interval rather than on every request while the outage lasts
+	now := time.Now().UnixNano()
+	last := h.lastDegradedLog.Load()
+	if now-last >= int64(degradedLogInterval) && h.lastDegradedLog.CompareAndSwap(last, now) {
+		logger.Warn().Err(adminErr).Msg("Admin API unavailable, serving degraded bucket listing through S3")
+	}
+
+	buckets := make([]models.BucketInfo, 0, len(listing.Buckets))
+	for _, bucket := range listing.Buckets {
+		if query.matches(bucket.Name) {
+			bucket.ReadOnly = h.settingsStore.IsReadOnly(bucket.Name)
+			buckets = append(buckets, bucket)
+		}
+	}
+	slices.SortFunc(buckets, func(a, b models.BucketInfo) int {
+		return query.compare(a.Name, a.CreationDate, b.Name, b.CreationDate)
+	})
+	page, pagination := paginate(buckets, params)
+
+	return sendListing(c, models.BucketListResponse{
+		Buckets:    page,
+		Count:      len(page),
+		Degraded:   true,
+		Pagination: pagination,
+	}, "buckets", fields)
 }
 
 // CreateBucket creates a new bucket
 //
 //	@Summary		Create a new bucket
-//	@Description	Creates a new bucket in the Garage storage system
+//	@Description	Creates a new bucket in the Garage storage system, optionally granting keys access to it. When grants are given, the response is held until they are visible through the Admin API (at most 5 polls, 200ms apart) and the bucket credentials are cached, so the bucket can be listed right away.
 //	@Tags			Buckets
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		models.CreateBucketRequest										true	"Bucket creation payload"
+//	@Param			no_wait	query		bool															false	"Respond without waiting for the grants to propagate"
 //	@Success		201		{object}	models.APIResponse{data=object{bucket=string,message=string}}	"Bucket created successfully"
 //	@Failure		400		{object}	models.APIResponse{error=models.APIError}						"Invalid request body or bucket name is required"
 //	@Failure		409		{object}	models.APIResponse{error=models.APIError}						"Bucket already exists"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}						"Failed to create bucket"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}						"Failed to create bucket or grant access"
+//	@Failure		502		{object}	models.APIResponse{error=models.APIError}						"Garage did not answer and the bucket may have been created"
 //	@Router			/api/v1/buckets [post]
 func (h *BucketHandler) CreateBucket(c fiber.Ctx) error {
 	ctx := c.Context()
@@ -115,39 +267,130 @@ func (h *BucketHandler) CreateBucket(c fiber.Ctx) error {
 			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
 		)
 	}
+	for _, grant := range req.Grants {
+		if grant.AccessKeyID == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Every grant requires an accessKeyId"),
+			)
+		}
+	}
 
 	// Create the bucket
 	createBucketReq := models.CreateBucketAdminRequest{
 		GlobalAlias: &req.Name,
 	}
 
-	if _, err := h.adminService.CreateBucket(ctx, createBucketReq); err != nil {
+	bucketInfo, err := h.adminService.CreateBucket(ctx, createBucketReq)
+	if errors.Is(err, services.ErrCreateOutcomeUnknown) {
+		return c.Status(fiber.StatusBadGateway).JSON(
+			models.ErrorResponse(models.ErrCodeUpstream, "Garage did not answer, the bucket may or may not have been created: check before retrying"),
+		)
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeInternalError, "Failed to create bucket: "+err.Error()),
 		)
 	}
 
+	// Grant the requested keys access to the new bucket
+	for _, grant := range req.Grants {
+		_, err := h.adminService.AllowBucketKey(ctx, models.BucketKeyPermRequest{
+			BucketID:    bucketInfo.ID,
+			AccessKeyID: grant.AccessKeyID,
+			Permissions: grant.Permissions,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Bucket created, but granting key "+grant.AccessKeyID+" failed: "+err.Error()),
+			)
+		}
+	}
+
 	// Return success response
 	response := map[string]interface{}{
 		"bucket":  req.Name,
 		"message": "Bucket created successfully",
 	}
 
+	if len(req.Grants) > 0 && c.Query("no_wait") != "true" {
+		response["grants_visible"] = h.waitForGrants(c, req.Name, req.Grants)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(response))
 }
 
+// waitForGrants polls the bucket until every grant is visible through the Admin API, then
+// pre-warms the bucket credentials. It reports whether the grants became visible in time;
+// giving up is not an error since the grants will propagate eventually.
+func (h *BucketHandler) waitForGrants(c fiber.Ctx, bucketName string, grants []models.GrantBucketPermissionRequest) bool {
+	ctx := c.Context()
+
+	for attempt := 0; attempt < grantPropagationAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(grantPropagationInterval):
+			}
+		}
+
+		info, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+		if err != nil || !grantsVisible(info, grants) {
+			continue
+		}
+
+		if err := h.s3Service.WarmBucketCredentials(ctx, bucketName); err != nil {
+			logger.Warn().Err(err).Str("bucket", bucketName).Msg("Failed to pre-warm credentials of new bucket")
+		}
+		return true
+	}
+
+	logger.Warn().Str("bucket", bucketName).Msg("Grants of new bucket are not visible yet")
+	return false
+}
+
+// grantsVisible reports whether the bucket lists every grant with at least the requested permissions
+func grantsVisible(info *models.GarageBucketInfo, grants []models.GrantBucketPermissionRequest) bool {
+	if info == nil {
+		return false
+	}
+
+	for _, grant := range grants {
+		visible := false
+		for _, key := range info.Keys {
+			if key.AccessKeyID != grant.AccessKeyID {
+				continue
+			}
+			visible = (key.Permissions.Read || !grant.Permissions.Read) &&
+				(key.Permissions.Write || !grant.Permissions.Write) &&
+				(key.Permissions.Owner || !grant.Permissions.Owner)
+			break
+		}
+		if !visible {
+			return false
+		}
+	}
+
+	return true
+}
+
 // DeleteBucket deletes a bucket
 //
 //	@Summary		Delete a bucket
-//	@Description	Deletes an existing bucket from the Garage storage system. The bucket must be empty before deletion.
+//	@Description	Deletes an existing bucket from the Garage storage system. The bucket must be empty before deletion unless force is set.
+//	@Description	Force-deleting a bucket that still holds objects first answers 428 with a confirmation token; repeating the request with confirm_token deletes every object and incomplete multipart upload, revokes every key grant, removes every alias and then deletes the bucket. The response then sums up what was removed.
 //	@Tags			Buckets
 //	@Accept			json
 //	@Produce		json
-//	@Param			name	path		string															true	"Name of the bucket to delete"
-//	@Success		200		{object}	models.APIResponse{data=object{bucket=string,message=string}}	"Bucket deleted successfully"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}						"Bucket name is required"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}						"Bucket does not exist"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}						"Failed to delete bucket"
+//	@Param			name			path		string												true	"Name of the bucket to delete"
+//	@Param			force			query		bool												false	"Admin only: delete the objects, uploads, key grants and aliases of the bucket first"
+//	@Param			confirm_token	query		string												false	"Confirmation token returned by a previous 428 response"
+//	@Success		200				{object}	models.APIResponse{data=models.BucketDeleteSummary}	"Bucket deleted successfully"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}			"Bucket name is required"
+//	@Failure		403				{object}	models.APIResponse{error=models.APIError}			"Force deletion requested by a non-admin"
+//	@Failure		404				{object}	models.APIResponse{error=models.APIError}			"Bucket does not exist"
+//	@Failure		428				{object}	models.APIResponse{data=models.DeleteConfirmation}	"Confirmation required"
+//	@Failure		500				{object}	models.APIResponse{error=models.APIError}			"Failed to delete bucket"
 //	@Router			/api/v1/buckets/{name} [delete]
 func (h *BucketHandler) DeleteBucket(c fiber.Ctx) error {
 	ctx := c.Context()
@@ -174,6 +417,41 @@ func (h *BucketHandler) DeleteBucket(c fiber.Ctx) error {
 		)
 	}
 
+	summary := models.BucketDeleteSummary{Bucket: bucketName, Message: "Bucket deleted successfully"}
+
+	// Deleting the objects along with the bucket is reserved for admins and must be confirmed
+	if c.Query("force") == "true" {
+		if isAdmin, _ := c.Locals("isAdmin").(bool); !isAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(
+				models.ErrorResponse(models.ErrCodeForbidden, "Force deletion is restricted to administrators"),
+			)
+		}
+
+		if bucketInfo.Objects > 0 || bucketInfo.Bytes > 0 {
+			if confirmed, err := confirmDestructive(c, services.ConfirmForceDeleteBucket, bucketName, bucketInfo.Objects, bucketInfo.Bytes); !confirmed {
+				return err
+			}
+		}
+
+		deleted, aborted, err := h.s3Service.EmptyBucket(ctx, bucketName, nil)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete bucket objects: "+err.Error()),
+			)
+		}
+		summary.DeletedObjects, summary.AbortedUploads = deleted, aborted
+
+		// The bucket credentials are no longer needed once the objects are gone
+		removedAliases, revokedKeys, err := h.adminService.DetachBucket(ctx, bucketInfo, bucketName)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeDeleteFailed, "Bucket emptied, but detaching it failed: "+err.Error()),
+			)
+		}
+		summary.RemovedAliases = append(removedAliases, bucketName)
+		summary.RevokedKeys = revokedKeys
+	}
+
 	// Delete the bucket
 	if err := h.adminService.DeleteBucket(ctx, bucketInfo.ID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
@@ -181,27 +459,117 @@ func (h *BucketHandler) DeleteBucket(c fiber.Ctx) error {
 		)
 	}
 
-	// Return success response
-	response := map[string]interface{}{
-		"bucket":  bucketName,
-		"message": "Bucket deleted successfully",
+	// Drop UI settings so a future bucket with the same name starts fresh
+	if err := h.settingsStore.DeleteBucketSettings(bucketName); err != nil {
+		logger.Warn().Err(err).Str("bucket", bucketName).Msg("Failed to delete bucket settings")
 	}
 
-	return c.JSON(models.SuccessResponse(response))
+	return c.JSON(models.SuccessResponse(summary))
+}
+
+// EmptyBucket deletes every object of a bucket and keeps the bucket
+//
+//	@Summary		Empty a bucket
+//	@Description	Permanently deletes every object of a bucket, bypassing the trash, then aborts its incomplete multipart uploads, so that the bucket can be deleted afterwards. When the bucket holds objects, the first call answers 428 with a confirmation token; repeating the request with confirm_token empties the bucket. With progress=true the response is newline-delimited JSON: a "progress" line after each deleted page of up to 1000 objects, then a "summary" line, whose error is set if emptying failed part way; emptying stops if the client goes away. Time budget: server.timeouts.default, or unlimited with progress=true.
+//	@Tags			Buckets
+//	@Produce		json,application/x-ndjson
+//	@Param			name			path		string																			true	"Name of the bucket to empty"
+//	@Param			confirm_token	query		string																			false	"Confirmation token returned by a previous 428 response"
+//	@Param			progress		query		bool																			false	"Stream the progress as models.EmptyBucketProgress lines"
+//	@Success		200				{object}	models.APIResponse{data=object{bucket=string,deleted=int,abortedUploads=int}}	"Bucket emptied"
+//	@Failure		404				{object}	models.APIResponse{error=models.APIError}										"Bucket does not exist"
+//	@Failure		428				{object}	models.APIResponse{data=models.DeleteConfirmation}								"Confirmation required"
+//	@Failure		500				{object}	models.APIResponse{error=models.APIError}										"Failed to empty bucket"
+//	@Router			/api/v1/buckets/{name}/empty [post]
+func (h *BucketHandler) EmptyBucket(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := c.Params("name")
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to check bucket existence: "+err.Error()),
+		)
+	}
+	if bucketInfo == nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+
+	if bucketInfo.Objects > 0 || bucketInfo.Bytes > 0 {
+		if confirmed, err := confirmDestructive(c, services.ConfirmEmptyBucket, bucketName, bucketInfo.Objects, bucketInfo.Bytes); !confirmed {
+			return err
+		}
+	}
+
+	if c.Query("progress") == "true" {
+		return h.streamEmptyBucket(c, bucketInfo.Objects)
+	}
+
+	deleted, aborted, err := h.s3Service.EmptyBucket(ctx, bucketName, nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeDeleteFailed, fmt.Sprintf("Failed to empty bucket after deleting %d objects: %s", deleted, err.Error())),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(map[string]interface{}{
+		"bucket":         bucketName,
+		"deleted":        deleted,
+		"abortedUploads": aborted,
+	}))
+}
+
+// streamEmptyBucket empties a bucket while streaming its progress as NDJSON
+func (h *BucketHandler) streamEmptyBucket(c fiber.Ctx, total int64) error {
+	// The stream writer runs after this handler returns and the request is released,
+	// so copy everything it needs out of the request first
+	bucketName := strings.Clone(c.Params("name"))
+
+	c.Set("Content-Type", "application/x-ndjson")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	// Lines use the app's encoder so that server.legacy_field_names applies to them too
+	marshal := c.App().Config().JSONEncoder
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		send := func(line models.EmptyBucketProgress) error {
+			data, err := marshal(line)
+			if err != nil {
+				return err
+			}
+			w.Write(data)
+			w.WriteByte('\n')
+			// Flushing fails once the client has gone away, which stops the emptying
+			return w.Flush()
+		}
+
+		deleted, aborted, err := h.s3Service.EmptyBucket(context.Background(), bucketName, func(deleted int) error {
+			return send(models.EmptyBucketProgress{Type: "progress", Bucket: bucketName, Deleted: deleted, Total: total})
+		})
+
+		summary := models.EmptyBucketProgress{Type: "summary", Bucket: bucketName, Deleted: deleted, Total: total, AbortedUploads: aborted}
+		if err != nil {
+			summary.Error = err.Error()
+		}
+		send(summary)
+	})
 }
 
 // GetBucketInfo returns information about a specific bucket
 //
 //	@Summary		Get bucket information
-//	@Description	Retrieves detailed information about a specific bucket including creation date and region
+//	@Description	Retrieves detailed information about a specific bucket as known to Garage, and whether it is read-only through the UI
 //	@Tags			Buckets
 //	@Accept			json
 //	@Produce		json
-//	@Param			name	path		string										true	"Name of the bucket to retrieve information for"
-//	@Success		200		{object}	models.APIResponse{data=models.BucketInfo}	"Successfully retrieved bucket information"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Bucket name is required"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}	"Bucket does not exist"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}	"Failed to retrieve bucket information"
+//	@Param			name	path		string											true	"Name of the bucket to retrieve information for"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketDetails}	"Successfully retrieved bucket information"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}		"Bucket name is required"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}		"Bucket does not exist"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}		"Failed to retrieve bucket information"
 //	@Router			/api/v1/buckets/{name} [get]
 func (h *BucketHandler) GetBucketInfo(c fiber.Ctx) error {
 	ctx := c.Context()
@@ -228,13 +596,18 @@ func (h *BucketHandler) GetBucketInfo(c fiber.Ctx) error {
 		)
 	}
 
-	return c.JSON(models.SuccessResponse(bucketInfo))
+	details := models.BucketDetails{
+		GarageBucketInfo: *bucketInfo,
+		ReadOnly:         h.settingsStore.IsReadOnly(bucketName),
+	}
+	details.Created = utils.UTC(details.Created)
+	return c.JSON(models.SuccessResponse(details))
 }
 
 // GrantBucketPermission grants permissions for an access key on a bucket
 //
 //	@Summary		Grant bucket permissions
-//	@Description	Grants read/write/owner permissions for an access key on a specific bucket
+//	@Description	Grants read/write/owner permissions for an access key on a specific bucket. Write and owner grants are refused on read-only buckets.
 //	@Tags			Buckets
 //	@Accept			json
 //	@Produce		json
@@ -242,6 +615,7 @@ func (h *BucketHandler) GetBucketInfo(c fiber.Ctx) error {
 //	@Param			request	body		models.GrantBucketPermissionRequest					true	"Permission grant request"
 //	@Success		200		{object}	models.APIResponse{data=models.GarageBucketInfo}	"Permissions granted successfully"
 //	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid request"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}			"Write grant on a read-only bucket"
 //	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Bucket not found"
 //	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to grant permissions"
 //	@Router			/api/v1/buckets/{name}/permissions [post]
@@ -271,6 +645,12 @@ func (h *BucketHandler) GrantBucketPermission(c fiber.Ctx) error {
 		)
 	}
 
+	if (req.Permissions.Write || req.Permissions.Owner) && h.settingsStore.IsReadOnly(bucketName) {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeBucketReadOnly, "Bucket "+bucketName+" is read-only; only read access can be granted"),
+		)
+	}
+
 	// Get bucket info to retrieve bucket ID
 	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
 	if err != nil {
@@ -304,5 +684,338 @@ func (h *BucketHandler) GrantBucketPermission(c fiber.Ctx) error {
 		)
 	}
 
+	h.notifier.Notify(models.KeyNotification{
+		Event:       models.KeyEventPermissionGrant,
+		AccessKeyID: req.AccessKeyID,
+		Bucket:      bucketName,
+		Permissions: formatPermissions(permRequest.Permissions),
+		Actor:       actorName(c),
+	})
+
+	result.Created = utils.UTC(result.Created)
 	return c.JSON(models.SuccessResponse(result))
 }
+
+// GetBucketSettings returns the UI settings stored for a bucket
+//
+//	@Summary		Get bucket UI settings
+//	@Description	Retrieves UI-only preferences for a bucket (default page size, sort and view mode). Settings are not stored in Garage.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string											true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketSettings}	"Successfully retrieved bucket settings"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}		"Bucket name is required"
+//	@Router			/api/v1/buckets/{name}/settings [get]
+func (h *BucketHandler) GetBucketSettings(c fiber.Ctx) error {
+	// Get bucket name from URL parameter
+	bucketName := c.Params("name")
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+
+	// Buckets without stored settings get the zero value (server defaults)
+	settings, _ := h.settingsStore.GetBucketSettings(bucketName)
+
+	c.Set(fiber.HeaderETag, services.BucketSettingsETag(settings))
+	return c.JSON(models.SuccessResponse(settings))
+}
+
+// UpdateBucketSettings replaces the UI settings stored for a bucket
+//
+//	@Summary		Update bucket UI settings
+//	@Description	Replaces UI-only preferences for a bucket. ListObjects uses max_keys as its default page size. Only admins may change readOnly, and the change is audited. allowedContentTypes and blockedExtensions are glob lists enforced on uploads. dedupeHints (after or before) points uploads at objects recently uploaded with the same content. Settings are not stored in Garage. PATCH on the bucket is the same operation and also replaces every setting.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name		path		string											true	"Name of the bucket"
+//	@Param			payload		body		models.BucketSettings							true	"Bucket settings"
+//	@Param			If-Match	header		string											false	"ETag of the settings as loaded; the update is refused if they changed since"
+//	@Success		200			{object}	models.APIResponse{data=models.BucketSettings}	"Bucket settings updated successfully"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}		"Invalid request body or settings"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}		"readOnly changed by a non-admin"
+//	@Failure		409			{object}	models.APIResponse{data=models.EditConflict}	"The settings changed since they were loaded"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}		"Failed to save settings"
+//	@Router			/api/v1/buckets/{name}/settings [put]
+//	@Router			/api/v1/buckets/{name} [patch]
+func (h *BucketHandler) UpdateBucketSettings(c fiber.Ctx) error {
+	// Get bucket name from URL parameter
+	bucketName := c.Params("name")
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+
+	// Parse request body
+	var settings models.BucketSettings
+	if err := c.Bind().JSON(&settings); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	// Validate settings (S3 caps a listing page at 1000 keys)
+	if settings.MaxKeys < 0 || settings.MaxKeys > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "max_keys must be between 1 and 1000"),
+		)
+	}
+
+	switch settings.SortBy {
+	case "", "name", "size", "last_modified":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "sort_by must be one of name, size, last_modified"),
+		)
+	}
+
+	switch settings.SortOrder {
+	case "", "asc", "desc":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "sort_order must be asc or desc"),
+		)
+	}
+
+	switch settings.DedupeHints {
+	case "", services.DedupeHintsAfter, services.DedupeHintsBefore:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "dedupeHints must be after or before"),
+		)
+	}
+
+	for _, pattern := range slices.Concat(settings.AllowedContentTypes, settings.BlockedExtensions) {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("Invalid file type pattern %q", pattern)),
+			)
+		}
+	}
+
+	// The read-only flag guards against writes, so only admins may flip it. With If-Match,
+	// only replace the settings the editor loaded.
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	current, readOnlyChanged, err := h.settingsStore.UpdateBucketSettings(bucketName, settings, ifMatch, isAdmin)
+	switch {
+	case errors.Is(err, services.ErrReadOnlyChangeForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Only admins can change whether a bucket is read-only"),
+		)
+	case errors.Is(err, services.ErrSettingsConflict):
+		return c.Status(fiber.StatusConflict).JSON(models.ConflictResponse(
+			"The bucket settings were modified by someone else; reload them and apply your changes again",
+			models.EditConflict{ProvidedETag: ifMatch, CurrentETag: services.BucketSettingsETag(current), Current: current, Requested: settings},
+		))
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to save bucket settings: "+err.Error()),
+		)
+	}
+
+	if readOnlyChanged {
+		event := newAuditEvent(c, "bucket.read_only", bucketName)
+		event.Details = map[string]string{"readOnly": strconv.FormatBool(settings.ReadOnly)}
+		h.auditLog.Record(event)
+	}
+
+	c.Set(fiber.HeaderETag, services.BucketSettingsETag(settings))
+	return c.JSON(models.SuccessResponse(settings))
+}
+
+// UpdateBucketWebsite configures website hosting for a bucket
+//
+//	@Summary		Configure bucket website hosting
+//	@Description	Enables or disables website hosting for a bucket. Unless validate=false, enabling it first checks with the bucket's credentials that the index and error documents exist, and refuses with 422 listing the missing ones; force=true proceeds anyway. The response carries the public URL of the website when garage.website_root_domain is set.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name		path		string													true	"Name of the bucket"
+//	@Param			request		body		models.UpdateBucketWebsiteRequest						true	"Website configuration"
+//	@Param			validate	query		bool													false	"Check that the documents exist (default: true)"
+//	@Param			force		query		bool													false	"Apply the configuration even if documents are missing"
+//	@Success		200			{object}	models.APIResponse{data=models.BucketWebsiteResponse}	"Website configuration updated"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}				"Invalid request"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}				"Bucket not found"
+//	@Failure		422			{object}	models.APIResponse{data=models.MissingWebsiteDocuments}	"The index or error document does not exist"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}				"Failed to update website configuration"
+//	@Router			/api/v1/buckets/{name}/website [put]
+func (h *BucketHandler) UpdateBucketWebsite(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	// Get bucket name from URL parameter
+	bucketName := c.Params("name")
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+
+	// Parse request body
+	var req models.UpdateBucketWebsiteRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	if req.Enabled && req.IndexDocument == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "index_document is required to enable website access"),
+		)
+	}
+
+	validate := c.Query("validate") != "false"
+	force := c.Query("force") == "true"
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get bucket info: "+err.Error()),
+		)
+	}
+
+	if bucketInfo == nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+
+	publicURL := h.s3Service.WebsiteURL(bucketName, "")
+
+	// A missing index document serves a blank site, so check the documents exist first
+	if req.Enabled && validate {
+		var missing []string
+		for _, key := range []string{req.IndexDocument, req.ErrorDocument} {
+			if key == "" {
+				continue
+			}
+			exists, err := h.s3Service.ObjectExists(ctx, bucketName, key)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(
+					models.ErrorResponse(models.ErrCodeInternalError, "Failed to check website document "+key+": "+err.Error()),
+				)
+			}
+			if !exists {
+				missing = append(missing, key)
+			}
+		}
+
+		if len(missing) > 0 && !force {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.WebsiteDocumentsMissingResponse(
+				"Website documents do not exist in bucket "+bucketName+"; upload them or retry with force=true",
+				models.MissingWebsiteDocuments{Missing: missing, PublicURL: publicURL},
+			))
+		}
+	}
+
+	update := models.UpdateBucketRequest{
+		WebsiteAccess: &models.UpdateBucketWebsiteAccess{Enabled: req.Enabled},
+	}
+	if req.Enabled {
+		update.WebsiteAccess.IndexDocument = &req.IndexDocument
+		if req.ErrorDocument != "" {
+			update.WebsiteAccess.ErrorDocument = &req.ErrorDocument
+		}
+	}
+
+	if _, err := h.adminService.UpdateBucket(ctx, bucketInfo.ID, update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to update website configuration: "+err.Error()),
+		)
+	}
+
+	response := models.BucketWebsiteResponse{Bucket: bucketName, Enabled: req.Enabled}
+	if req.Enabled {
+		response.IndexDocument = req.IndexDocument
+		response.ErrorDocument = req.ErrorDocument
+		response.PublicURL = publicURL
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// GetBucketWebsite returns the website hosting configuration of a bucket
+//
+//	@Summary		Get bucket website hosting
+//	@Description	Returns whether website hosting is enabled for a bucket, its index and error documents, and the public URL of the website when garage.website_root_domain is set
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketWebsiteResponse}	"Website configuration"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Bucket not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to get bucket info"
+//	@Router			/api/v1/buckets/{name}/website [get]
+func (h *BucketHandler) GetBucketWebsite(c fiber.Ctx) error {
+	bucketName := c.Params("name")
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(c.Context(), bucketName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get bucket info: "+err.Error()),
+		)
+	}
+	if bucketInfo == nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+
+	response := models.BucketWebsiteResponse{Bucket: bucketName, Enabled: bucketInfo.WebsiteAccess}
+	if bucketInfo.WebsiteAccess {
+		if bucketInfo.WebsiteConfig != nil {
+			response.IndexDocument = bucketInfo.WebsiteConfig.IndexDocument
+			if bucketInfo.WebsiteConfig.ErrorDocument != nil {
+				response.ErrorDocument = *bucketInfo.WebsiteConfig.ErrorDocument
+			}
+		}
+		response.PublicURL = h.s3Service.WebsiteURL(bucketName, "")
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// DeleteBucketWebsite disables website hosting for a bucket
+//
+//	@Summary		Disable bucket website hosting
+//	@Description	Disables website hosting for a bucket, which also stops public browsing of it under /public. Disabling it when it is not enabled succeeds.
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketWebsiteResponse}	"Website hosting disabled"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Bucket not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to update website configuration"
+//	@Router			/api/v1/buckets/{name}/website [delete]
+func (h *BucketHandler) DeleteBucketWebsite(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := c.Params("name")
+
+	bucketInfo, err := h.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get bucket info: "+err.Error()),
+		)
+	}
+	if bucketInfo == nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist"),
+		)
+	}
+
+	if bucketInfo.WebsiteAccess {
+		update := models.UpdateBucketRequest{
+			WebsiteAccess: &models.UpdateBucketWebsiteAccess{Enabled: false},
+		}
+		if _, err := h.adminService.UpdateBucket(ctx, bucketInfo.ID, update); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to update website configuration: "+err.Error()),
+			)
+		}
+	}
+
+	return c.JSON(models.SuccessResponse(models.BucketWebsiteResponse{Bucket: bucketName, Enabled: false}))
+}
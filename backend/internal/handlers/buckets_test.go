@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestListBucketsSurfacesTruncation(t *testing.T) {
+	for _, tt := range []struct {
+		limit         string
+		wantCount     int
+		wantTruncated bool
+	}{
+		{limit: "2", wantCount: 2, wantTruncated: true},
+		{limit: "3", wantCount: 3},
+	} {
+		t.Run("limit "+tt.limit, func(t *testing.T) {
+			t.Setenv("GARAGE_UI_GARAGE_ADMIN_LIST_LIMIT", tt.limit)
+			env := newTestEnv(t)
+			for _, name := range []string{"a", "b", "c"} {
+				env.addBucket(name)
+			}
+
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/", nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+
+			var list models.BucketListResponse
+			decodeAPIResponse(t, resp, &list)
+			if list.Count != tt.wantCount || list.Truncated != tt.wantTruncated {
+				t.Errorf("got %d buckets, truncated %v; want %d, %v", list.Count, list.Truncated, tt.wantCount, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestCreateBucketWaitsForGrants(t *testing.T) {
+	tests := []struct {
+		name        string
+		lag         int
+		query       string
+		wantVisible interface{}
+		wantLookups int
+	}{
+		{name: "visible after two polls", lag: 2, wantVisible: true},
+		{name: "never visible", lag: 10, wantVisible: false, wantLookups: grantPropagationAttempts},
+		{name: "no_wait", lag: 2, query: "?no_wait=true", wantLookups: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.AddKey("GK-app")
+			env.LagGrants(tt.lag)
+
+			resp := env.requestJSON(t, http.MethodPost, "/api/v1/buckets/"+tt.query, models.CreateBucketRequest{
+				Name: "fresh",
+				Grants: []models.GrantBucketPermissionRequest{
+					{AccessKeyID: "GK-app", Permissions: models.BucketKeyPermission{Read: true, Write: true, Owner: true}},
+				},
+			})
+			if resp.StatusCode != http.StatusCreated {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusCreated)
+			}
+
+			var created map[string]interface{}
+			decodeAPIResponse(t, resp, &created)
+			if got := created["grants_visible"]; got != tt.wantVisible {
+				t.Errorf("grants_visible = %v, want %v", got, tt.wantVisible)
+			}
+
+			lookups := env.BucketLookups("fresh")
+			if tt.wantVisible == true {
+				// Polled until the third lookup saw the grant; the bucket lists right away
+				if lookups < tt.lag+1 {
+					t.Errorf("bucket looked up %d times, want at least %d", lookups, tt.lag+1)
+				}
+				list := env.request(t, http.MethodGet, "/api/v1/buckets/fresh/objects/", nil)
+				if list.StatusCode != http.StatusOK {
+					t.Errorf("listing the new bucket: status = %d, want %d", list.StatusCode, http.StatusOK)
+				}
+			} else if lookups != tt.wantLookups {
+				t.Errorf("bucket looked up %d times, want %d", lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestUpdateBucketSettingsIfMatch(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		target string
+	}{
+		{method: http.MethodPut, target: "/api/v1/buckets/docs/settings"},
+		{method: http.MethodPatch, target: "/api/v1/buckets/docs"},
+	} {
+		t.Run(tt.method, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.addBucket("docs")
+
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/docs/settings", nil)
+			loaded := resp.Header.Get("ETag")
+			if resp.StatusCode != http.StatusOK || loaded == "" {
+				t.Fatalf("GetBucketSettings status = %d, ETag = %q", resp.StatusCode, loaded)
+			}
+
+			// Another admin edits the settings first
+			first := env.requestJSON(t, tt.method, tt.target, models.BucketSettings{MaxKeys: 50}, "If-Match", loaded)
+			if first.StatusCode != http.StatusOK {
+				t.Fatalf("first update status = %d, want %d", first.StatusCode, http.StatusOK)
+			}
+			current := first.Header.Get("ETag")
+
+			// The stale edit is refused with both versions, and the settings are kept
+			stale := env.requestJSON(t, tt.method, tt.target, models.BucketSettings{MaxKeys: 200}, "If-Match", loaded)
+			if stale.StatusCode != http.StatusConflict {
+				t.Fatalf("stale update status = %d, want %d", stale.StatusCode, http.StatusConflict)
+			}
+			var conflict struct {
+				ProvidedETag string                `json:"providedEtag"`
+				CurrentETag  string                `json:"currentEtag"`
+				Current      models.BucketSettings `json:"current"`
+				Requested    models.BucketSettings `json:"requested"`
+			}
+			response := decodeAPIResponse(t, stale, &conflict)
+			if response.Error == nil || response.Error.Code != models.ErrCodeConflict {
+				t.Errorf("error = %+v, want %s", response.Error, models.ErrCodeConflict)
+			}
+			if conflict.ProvidedETag != loaded || conflict.CurrentETag != current {
+				t.Errorf("etags = %q, %q; want %q, %q", conflict.ProvidedETag, conflict.CurrentETag, loaded, current)
+			}
+			if conflict.Current.MaxKeys != 50 || conflict.Requested.MaxKeys != 200 {
+				t.Errorf("current max keys = %d, requested = %d; want 50, 200", conflict.Current.MaxKeys, conflict.Requested.MaxKeys)
+			}
+			if settings, _ := env.settings.GetBucketSettings("docs"); settings.MaxKeys != 50 {
+				t.Errorf("stored max keys = %d, want 50", settings.MaxKeys)
+			}
+
+			// Without If-Match the settings are replaced unconditionally
+			forced := env.requestJSON(t, tt.method, tt.target, models.BucketSettings{MaxKeys: 200})
+			if forced.StatusCode != http.StatusOK {
+				t.Errorf("unconditional update status = %d, want %d", forced.StatusCode, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestReadOnlyBucketRejectsChanges(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	env.addBucket("archive")
+	env.AddKey("GK-app")
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+	env.PutObject("archive", "b.txt", "text/plain", []byte("b"))
+	if err := env.settings.SetBucketSettings("archive", models.BucketSettings{ReadOnly: true}); err != nil {
+		t.Fatalf("SetBucketSettings failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		target string
+		body   interface{}
+	}{
+		{name: "upload", method: http.MethodPut, target: "/api/v1/buckets/archive/objects/c.txt"},
+		{name: "delete", method: http.MethodDelete, target: "/api/v1/buckets/archive/objects/b.txt"},
+		{name: "rename", method: http.MethodPost, target: "/api/v1/buckets/archive/objects/b.txt/move", body: models.ObjectMoveRequest{TargetKey: "c.txt"}},
+		{name: "copy into", method: http.MethodPost, target: "/api/v1/buckets/archive/objects/copy", body: models.ObjectCopyRequest{SourceBucket: "docs", SourceKey: "a.txt", Key: "a.txt"}},
+		{name: "grant", method: http.MethodPost, target: "/api/v1/buckets/archive/permissions", body: models.GrantBucketPermissionRequest{
+			AccessKeyID: "GK-app",
+			Permissions: models.BucketKeyPermission{Read: true, Write: true},
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.requestJSON(t, tt.method, tt.target, tt.body)
+			if resp.StatusCode != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+			}
+			if code := errorCode(t, resp); code != models.ErrCodeBucketReadOnly {
+				t.Errorf("error code = %q, want %q", code, models.ErrCodeBucketReadOnly)
+			}
+		})
+	}
+
+	if keys := env.ObjectKeys("archive"); len(keys) != 1 || keys[0] != "b.txt" {
+		t.Errorf("archive holds %v, want [b.txt]", keys)
+	}
+}
+
+func TestUpdateBucketSettingsReadOnlyIsAdminOnly(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+
+	resp := env.requestJSON(t, http.MethodPut, "/api/v1/buckets/docs/settings", models.BucketSettings{ReadOnly: true}, testUserHeader, "alice")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if code := errorCode(t, resp); code != models.ErrCodeForbidden {
+		t.Errorf("error code = %q, want %q", code, models.ErrCodeForbidden)
+	}
+	if env.settings.IsReadOnly("docs") {
+		t.Fatal("non-admin made the bucket read-only")
+	}
+
+	// Other settings may still be changed by anyone who keeps readOnly as it is
+	if resp := env.requestJSON(t, http.MethodPut, "/api/v1/buckets/docs/settings", models.BucketSettings{MaxKeys: 50}, testUserHeader, "alice"); resp.StatusCode != http.StatusOK {
+		t.Errorf("non-admin update status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if resp := env.requestJSON(t, http.MethodPut, "/api/v1/buckets/docs/settings", models.BucketSettings{ReadOnly: true}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("admin status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if !env.settings.IsReadOnly("docs") {
+		t.Error("admin could not make the bucket read-only")
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/logger"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -22,7 +23,7 @@ func NewClusterHandler(adminService *services.GarageAdminService) *ClusterHandle
 // GetHealth returns the health status of the cluster
 //
 //	@Summary		Get cluster health
-//	@Description	Retrieves the overall health status of the Garage storage cluster
+//	@Description	Retrieves the overall health status of the Garage storage cluster. The answer may be up to 15 seconds old.
 //	@Tags			Cluster
 //	@Accept			json
 //	@Produce		json
@@ -32,7 +33,7 @@ func NewClusterHandler(adminService *services.GarageAdminService) *ClusterHandle
 func (h *ClusterHandler) GetHealth(c fiber.Ctx) error {
 	ctx := c.Context()
 
-	health, err := h.adminService.GetClusterHealth(ctx)
+	health, err := h.adminService.GetCachedClusterHealth(ctx)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get cluster health: "+err.Error()),
@@ -66,7 +67,15 @@ func (h *ClusterHandler) GetStatus(c fiber.Ctx) error {
 }
 
 // GetStatistics returns global cluster statistics
-// GET /api/v1/cluster/statistics
+//
+//	@Summary		Get cluster statistics
+//	@Description	Retrieves Garage's human-readable statistics report as freeformText, along with the disk capacity of the nodes as raw byte counts and unrounded percentages
+//	@Tags			Cluster
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.ClusterStatisticsResponse}	"Successfully retrieved cluster statistics"
+//	@Failure		500	{object}	models.APIResponse{error=models.APIError}					"Failed to get cluster statistics"
+//	@Router			/api/v1/cluster/statistics [get]
 func (h *ClusterHandler) GetStatistics(c fiber.Ctx) error {
 	ctx := c.Context()
 
@@ -77,13 +86,53 @@ func (h *ClusterHandler) GetStatistics(c fiber.Ctx) error {
 		)
 	}
 
-	return c.JSON(models.SuccessResponse(stats))
+	response := models.ClusterStatisticsResponse{FreeformText: stats.Freeform}
+
+	// The report is still useful without the capacity
+	if status, err := h.adminService.GetClusterStatus(ctx); err != nil {
+		logger.Debug().Err(err).Msg("Failed to get cluster status for the capacity summary")
+	} else {
+		response.Capacity = summarizeCapacity(status.Nodes)
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// summarizeCapacity sums the partitions of the nodes that report them
+func summarizeCapacity(nodes []models.NodeInfo) *models.CapacitySummary {
+	summary := &models.CapacitySummary{}
+	for _, node := range nodes {
+		if node.DataPartition == nil && node.MetadataPartition == nil {
+			continue
+		}
+		summary.Nodes++
+		if node.DataPartition != nil {
+			summary.DataAvailable += node.DataPartition.Available
+			summary.DataTotal += node.DataPartition.Total
+		}
+		if node.MetadataPartition != nil {
+			summary.MetadataAvailable += node.MetadataPartition.Available
+			summary.MetadataTotal += node.MetadataPartition.Total
+		}
+	}
+
+	summary.DataUsedPercent = usedPercent(summary.DataAvailable, summary.DataTotal)
+	summary.MetadataUsedPercent = usedPercent(summary.MetadataAvailable, summary.MetadataTotal)
+	return summary
+}
+
+// usedPercent returns the used share of a partition, or 0 for an empty total
+func usedPercent(available, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(total-available) / float64(total) * 100
 }
 
 // GetNodeInfo returns information about a specific node
 //
 //	@Summary		Get node information
-//	@Description	Retrieves detailed information about a specific node in the Garage storage cluster
+//	@Description	Retrieves detailed information about a specific node in the Garage storage cluster, or about every node with "*". With "*", nodes are queried separately and those not answering within 5 seconds are listed in the error map as "timeout", so the others are still returned.
 //	@Tags			Cluster
 //	@Accept			json
 //	@Produce		json
@@ -115,7 +164,7 @@ func (h *ClusterHandler) GetNodeInfo(c fiber.Ctx) error {
 // GetNodeStatistics returns statistics for a specific node
 //
 //	@Summary		Get node statistics
-//	@Description	Retrieves performance statistics and metrics for a specific node in the Garage storage cluster
+//	@Description	Retrieves performance statistics and metrics for a specific node in the Garage storage cluster, or for every node with "*". With "*", nodes are queried separately and those not answering within 5 seconds are listed in the error map as "timeout", so the others are still returned.
 //	@Tags			Cluster
 //	@Accept			json
 //	@Produce		json
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestSummarizeCapacity(t *testing.T) {
+	summary := summarizeCapacity([]models.NodeInfo{
+		{
+			DataPartition:     &models.FreeSpaceInfo{Available: 1000, Total: 3000},
+			MetadataPartition: &models.FreeSpaceInfo{Available: 100, Total: 300},
+		},
+		{DataPartition: &models.FreeSpaceInfo{Available: 1000, Total: 3000}},
+		{}, // Nodes that report no partition are left out
+	})
+
+	want := models.CapacitySummary{
+		Nodes:               2,
+		DataAvailable:       2000,
+		DataTotal:           6000,
+		DataUsedPercent:     float64(4000) / 6000 * 100,
+		MetadataAvailable:   100,
+		MetadataTotal:       300,
+		MetadataUsedPercent: float64(200) / 300 * 100,
+	}
+	if *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+
+	if empty := summarizeCapacity(nil); empty.DataUsedPercent != 0 || empty.MetadataUsedPercent != 0 {
+		t.Errorf("percentages without partitions = %v, %v; want 0", empty.DataUsedPercent, empty.MetadataUsedPercent)
+	}
+}
+
+// TestStatisticsSerializeRawNumbers pins sizes to integer byte counts and percentages to
+// unrounded numbers, leaving formatted text to fields named ...Text
+func TestStatisticsSerializeRawNumbers(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		response interface{}
+		want     map[string]interface{}
+	}{
+		{
+			name: "cluster statistics",
+			response: models.ClusterStatisticsResponse{
+				FreeformText: "Storage nodes: 3",
+				Capacity: &models.CapacitySummary{
+					Nodes:           3,
+					DataAvailable:   1 << 40,
+					DataTotal:       3 << 40,
+					DataUsedPercent: 200.0 / 3,
+				},
+			},
+			want: map[string]interface{}{
+				"freeformText":             "Storage nodes: 3",
+				"capacity.nodes":           float64(3),
+				"capacity.dataAvailable":   float64(1 << 40),
+				"capacity.dataTotal":       float64(3 << 40),
+				"capacity.dataUsedPercent": 200.0 / 3,
+			},
+		},
+		{
+			name: "dashboard",
+			response: models.DashboardMetrics{
+				TotalSize:     3 << 30,
+				ObjectCount:   7,
+				UsageByBucket: []models.BucketUsage{{BucketName: "docs", Size: 1 << 30, ObjectCount: 7, Percentage: 100.0 / 3}},
+			},
+			want: map[string]interface{}{
+				"totalSize":                  float64(3 << 30),
+				"objectCount":                float64(7),
+				"usageByBucket.0.size":       float64(1 << 30),
+				"usageByBucket.0.percentage": 100.0 / 3,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var decoded interface{}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			fields := make(map[string]interface{})
+			flattenJSON("", decoded, fields)
+			for name, want := range tt.want {
+				if got, ok := fields[name]; !ok || got != want {
+					t.Errorf("%s = %#v, want %#v", name, got, want)
+				}
+			}
+			if _, ok := fields["freeform"]; ok {
+				t.Error("the report is still sent as freeform")
+			}
+		})
+	}
+}
+
+// flattenJSON records the leaves of a decoded JSON value by their dotted path
+func flattenJSON(path string, value interface{}, fields map[string]interface{}) {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			flattenJSON(join(name), field, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenJSON(join(strconv.Itoa(i)), item, fields)
+		}
+	default:
+		fields[path] = v
+	}
+}
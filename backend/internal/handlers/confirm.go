@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// confirmDestructive checks the confirm_token query parameter of a destructive request.
+// When it does not confirm action on target, a 428 response carrying a fresh token and the
+// objects and bytes at stake is sent, and confirmed is false.
+func confirmDestructive(c fiber.Ctx, action, target string, objects, bytes int64) (confirmed bool, err error) {
+	username, _ := c.Locals("username").(string)
+
+	token := c.Query("confirm_token")
+	if services.ConsumeConfirmation(token, action, target, username) {
+		return true, nil
+	}
+
+	confirmation, err := services.IssueConfirmation(action, target, username, objects, bytes)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to issue confirmation token: "+err.Error()),
+		)
+	}
+
+	message := fmt.Sprintf("This deletes %d objects (%d bytes); repeat the request with confirm_token to proceed", objects, bytes)
+	if token != "" {
+		message = "Confirmation token is invalid or expired; " + message
+	}
+	return false, c.Status(fiber.StatusPreconditionRequired).JSON(
+		models.ConfirmationRequiredResponse(message, confirmation),
+	)
+}
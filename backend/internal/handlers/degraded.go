@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// rejectIfDegraded refuses a write with 503 when server.block_writes_when_degraded is set
+// and the cached cluster health shows partitions without write quorum, so clients get a
+// clear error instead of a write failing halfway. If the health cannot be read the write
+// goes ahead: it is the Admin API that is unreachable, not necessarily the cluster.
+func (h *ObjectHandler) rejectIfDegraded(c fiber.Ctx) (rejected bool, err error) {
+	if !h.blockWritesWhenDegraded {
+		return false, nil
+	}
+
+	health, err := h.adminService.GetCachedClusterHealth(c.Context())
+	if err != nil {
+		logger.Warn().Err(err).Msg("Cannot read cluster health, allowing write")
+		return false, nil
+	}
+	if !health.LacksWriteQuorum() {
+		return false, nil
+	}
+
+	message := fmt.Sprintf(
+		"Cluster degraded: only %d of %d partitions have write quorum (%d of %d storage nodes up); retry once the cluster has recovered",
+		health.PartitionsQuorum, health.Partitions, health.StorageNodesUp, health.StorageNodes,
+	)
+	return true, c.Status(fiber.StatusServiceUnavailable).JSON(
+		models.ClusterDegradedResponse(message, health),
+	)
+}
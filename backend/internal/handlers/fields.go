@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"reflect"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// parseFields reads the ?fields= selection of a listing whose items are of type T
+func parseFields[T any](c fiber.Ctx) (models.FieldSelection, error) {
+	return models.ParseFieldSelection(c.Query("fields"), reflect.TypeFor[T]())
+}
+
+// sendListing sends a listing, keeping only the selected fields of the items under
+// listField. It encodes with the app's encoder so server.legacy_field_names still applies.
+func sendListing(c fiber.Ctx, listing interface{}, listField string, fields models.FieldSelection) error {
+	if len(fields) == 0 {
+		return c.JSON(models.SuccessResponse(listing))
+	}
+
+	body, err := c.App().Config().JSONEncoder(models.SuccessResponse(listing))
+	if err != nil {
+		return err
+	}
+	body, err = fields.Project(body, listField)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
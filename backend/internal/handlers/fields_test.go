@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestListingsSelectFields(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+
+	for _, tt := range []struct {
+		name      string
+		target    string
+		listField string
+		want      []string
+	}{
+		{name: "objects", target: "/api/v1/buckets/docs/objects/?fields=key,size,lastModified", listField: "objects", want: []string{"key", "lastModified", "size"}},
+		{name: "objects by legacy name", target: "/api/v1/buckets/docs/objects/?fields=key,last_modified", listField: "objects", want: []string{"key", "lastModified"}},
+		{name: "buckets", target: "/api/v1/buckets/?fields=name,%20readOnly", listField: "buckets", want: []string{"name", "readOnly"}},
+		{name: "users", target: "/api/v1/users/?fields=accessKeyId", listField: "users", want: []string{"accessKeyId"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.request(t, http.MethodGet, tt.target, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+
+			var listing map[string]json.RawMessage
+			decodeAPIResponse(t, resp, &listing)
+			var items []map[string]json.RawMessage
+			if err := json.Unmarshal(listing[tt.listField], &items); err != nil {
+				t.Fatalf("decoding %s: %v", tt.listField, err)
+			}
+			if len(items) == 0 {
+				t.Fatalf("no %s listed", tt.listField)
+			}
+			// Unselected fields are absent, not null
+			for _, item := range items {
+				if got := slices.Sorted(maps.Keys(item)); !slices.Equal(got, tt.want) {
+					t.Errorf("item fields = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/v1/buckets/docs/objects/?fields=key,owner",
+			"/api/v1/buckets/?fields=storageClass",
+			"/api/v1/users/?fields=secret",
+		} {
+			resp := env.request(t, http.MethodGet, target, nil)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want %d", target, resp.StatusCode, http.StatusBadRequest)
+				continue
+			}
+			if code := errorCode(t, resp); code != models.ErrCodeBadRequest {
+				t.Errorf("%s: error code = %q, want %q", target, code, models.ErrCodeBadRequest)
+			}
+		}
+	})
+
+	t.Run("every field without selection", func(t *testing.T) {
+		resp := env.request(t, http.MethodGet, "/api/v1/buckets/docs/objects/", nil)
+		var listing models.ObjectListResponse
+		decodeAPIResponse(t, resp, &listing)
+		if len(listing.Objects) != 1 || listing.Objects[0].ETag == "" {
+			t.Errorf("objects = %+v, want a.txt with its etag", listing.Objects)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/garagetest"
+	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/objectroute"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// testEnv serves the handlers under test in front of a fake Garage cluster
+type testEnv struct {
+	*garagetest.Server
+
+	app      *fiber.App
+	cfg      *config.Config
+	admin    *services.GarageAdminService
+	s3       *services.S3Service
+	settings *services.SettingsStore
+	clock    *clock.Manual
+}
+
+// testUserHeader names the non-admin user a test request is made as; requests without it
+// are made by an administrator
+const testUserHeader = "X-Test-User"
+
+// newTestEnv loads the default configuration, as a deployment configured only with the
+// required environment variables would, and serves the bucket, object, user and dashboard
+// routes the way routes.SetupRoutes does, behind a stand-in for the auth middleware
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	clk := clock.NewManual(time.Now().UTC().Truncate(time.Second))
+	env := &testEnv{Server: garagetest.New(t, clk), clock: clk}
+
+	t.Setenv("GARAGE_UI_GARAGE_ENDPOINT", env.S3URL)
+	t.Setenv("GARAGE_UI_GARAGE_ADMIN_ENDPOINT", env.AdminURL)
+	t.Setenv("GARAGE_UI_GARAGE_ADMIN_TOKEN", "test")
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("config.Load failed: %v", err)
+	}
+	env.cfg = cfg
+
+	metrics := services.NewBackendMetrics()
+	env.admin = services.NewGarageAdminService(&env.cfg.Garage, &env.cfg.Logging, metrics)
+	env.s3, err = services.NewS3Service(&env.cfg.Garage, env.admin, metrics, clk)
+	if err != nil {
+		t.Fatalf("NewS3Service failed: %v", err)
+	}
+	env.settings, err = services.NewSettingsStore("", cfg.Garage.ReadOnlyBuckets, clk)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+	auditLog := services.NewAuditLog()
+	notifier := services.NewNotifier(env.settings)
+	trash := services.NewTrashService(env.s3, env.settings, &cfg.Trash, clk)
+	objectHandler := NewObjectHandler(env.s3, env.admin, env.settings, trash, services.NewTransferStats(), auditLog, nil, cfg, clk)
+	bucketHandler := NewBucketHandler(env.admin, env.s3, env.settings, auditLog, notifier, &cfg.Server.Pagination)
+	userHandler := NewUserHandler(env.admin, env.s3, env.settings, auditLog, notifier, services.NewKeyActivity(env.admin, time.Hour), &cfg.Server.Pagination)
+
+	env.app = fiber.New(fiber.Config{
+		ErrorHandler:   middleware.ErrorHandler(false),
+		ReadBufferSize: cfg.Server.ReadBufferLimit(),
+	})
+	env.app.Get("/health", NewHealthHandler("test", env.admin, env.s3, env.settings).Check)
+	api := env.app.Group("/api/v1", func(c fiber.Ctx) error {
+		username := c.Get(testUserHeader)
+		c.Locals("isAdmin", username == "")
+		if username == "" {
+			username = "admin"
+		}
+		c.Locals("username", username)
+		return c.Next()
+	})
+
+	readOnly := middleware.RejectReadOnlyBuckets(env.settings)
+
+	buckets := api.Group("/buckets")
+	buckets.Get("/", bucketHandler.ListBuckets)
+	buckets.Post("/", bucketHandler.CreateBucket)
+	buckets.Get("/:name", bucketHandler.GetBucketInfo)
+	buckets.Delete("/:name", readOnly, bucketHandler.DeleteBucket)
+	buckets.Post("/:name/permissions", bucketHandler.GrantBucketPermission)
+	buckets.Get("/:name/settings", bucketHandler.GetBucketSettings)
+	buckets.Patch("/:name", bucketHandler.UpdateBucketSettings)
+	buckets.Put("/:name/settings", bucketHandler.UpdateBucketSettings)
+
+	objects := api.Group("/buckets/:bucket/objects", middleware.DecodeBucketParam(), readOnly)
+	objects.Get("/", objectHandler.ListObjects)
+	objects.Post("/", objectHandler.UploadObject)
+	objects.Post("/delete-multiple", objectHandler.DeleteMultipleObjects)
+	objects.Post("/delete-prefix", objectHandler.DeletePrefix)
+	objects.Post("/copy", objectHandler.CopyObject)
+	objects.Head("/*", objectroute.Handler(objectHandler.GetObjectMetadata, nil))
+	objects.Get("/*", objectroute.Handler(objectHandler.GetObject, map[string]fiber.Handler{
+		objectroute.ActionMetadata: objectHandler.GetObjectMetadata,
+		objectroute.ActionPresign:  objectHandler.GetPresignedURL,
+	}))
+	objects.Post("/*", objectroute.Handler(objectHandler.UnknownObjectAction, map[string]fiber.Handler{
+		objectroute.ActionMove: objectHandler.MoveObject,
+	}))
+	objects.Put("/*", objectroute.Handler(objectHandler.UploadObjectStream, nil))
+	objects.Delete("/*", objectroute.Handler(objectHandler.DeleteObject, nil))
+	api.Get("/buckets/:bucket/search", middleware.DecodeBucketParam(), objectHandler.SearchObjects)
+
+	users := api.Group("/users")
+	users.Get("/", userHandler.ListUsers)
+	users.Get("/:access_key", userHandler.GetUser)
+	users.Patch("/:access_key", userHandler.UpdateUserPermissions)
+
+	monitoringHandler := NewMonitoringHandler(env.admin, env.s3, nil, nil, nil, metrics, nil)
+	api.Get("/monitoring/dashboard", monitoringHandler.GetDashboardMetrics)
+
+	return env
+}
+
+// addBucket creates a bucket with a key the proxy can use, like a bucket created in the UI
+func (env *testEnv) addBucket(name string) {
+	env.AddBucket(name)
+	env.GrantKey(name, "GK-"+name, true, nil)
+}
+
+// request sends a request to the handlers. headers are given as name, value pairs.
+func (env *testEnv) request(t *testing.T, method, target string, body io.Reader, headers ...string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, body)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := env.app.Test(req, fiber.TestConfig{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, target, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// requestJSON sends a request with a JSON body
+func (env *testEnv) requestJSON(t *testing.T, method, target string, body interface{}, headers ...string) *http.Response {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("encoding request body: %v", err)
+	}
+	headers = append(headers, fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return env.request(t, method, target, strings.NewReader(string(data)), headers...)
+}
+
+// decodeAPIResponse decodes the standard envelope of a response, its data into data when
+// not nil
+func decodeAPIResponse(t *testing.T, resp *http.Response, data interface{}) models.APIResponse {
+	t.Helper()
+
+	var envelope struct {
+		models.APIResponse
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if data != nil && envelope.Data != nil {
+		if err := json.Unmarshal(envelope.Data, data); err != nil {
+			t.Fatalf("decoding response data: %v", err)
+		}
+	}
+	return envelope.APIResponse
+}
+
+// errorCode returns the error code of a response, or "" when it succeeded
+func errorCode(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	response := decodeAPIResponse(t, resp, nil)
+	if response.Error == nil {
+		return ""
+	}
+	return response.Error.Code
+}
+
+// assertUTCTimestamps fails the test for every RFC3339 timestamp in a JSON response that is
+// not in UTC with a "Z" suffix, and returns how many timestamps it found
+func assertUTCTimestamps(t *testing.T, resp *http.Response) int {
+	t.Helper()
+
+	var body interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	found := 0
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for name, field := range v {
+				walk(path+"."+name, field)
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		case string:
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return
+			}
+			found++
+			if !strings.HasSuffix(v, "Z") {
+				t.Errorf("%s = %q is not in UTC", path, v)
+			}
+		}
+	}
+	walk("", body)
+	return found
+}
+
+func TestResponseTimestampsAreUTC(t *testing.T) {
+	env := newTestEnv(t)
+	// Garage reports times in the zone of the node that answers
+	env.clock.Set(env.clock.Now().In(time.FixedZone("CEST", 2*60*60)))
+	expiration := time.Date(2030, 1, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	env.addBucket("docs")
+	env.GrantKey("docs", "GK-app", false, &expiration)
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+
+	for _, target := range []string{
+		"/health",
+		"/api/v1/buckets/",
+		"/api/v1/buckets/docs",
+		"/api/v1/buckets/docs/objects/",
+		"/api/v1/buckets/docs/objects/a.txt/metadata",
+		"/api/v1/users/",
+		"/api/v1/users/GK-app",
+	} {
+		t.Run(target, func(t *testing.T) {
+			resp := env.request(t, http.MethodGet, target, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if assertUTCTimestamps(t, resp) == 0 {
+				t.Error("response holds no timestamp")
+			}
+		})
+	}
+
+	t.Run("permission grant", func(t *testing.T) {
+		resp := env.requestJSON(t, http.MethodPost, "/api/v1/buckets/docs/permissions", models.GrantBucketPermissionRequest{
+			AccessKeyID: "GK-app",
+			Permissions: models.BucketKeyPermission{Read: true},
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if assertUTCTimestamps(t, resp) == 0 {
+			t.Error("response holds no timestamp")
+		}
+	})
+
+	t.Run("offset input", func(t *testing.T) {
+		resp := env.requestJSON(t, http.MethodPatch, "/api/v1/users/GK-app", map[string]string{"expiration": "2031-06-01T14:30:00+02:00"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var user models.UserInfo
+		decodeAPIResponse(t, resp, &user)
+		if want := time.Date(2031, 6, 1, 12, 30, 0, 0, time.UTC); user.Expiration == nil || !user.Expiration.Equal(want) || user.Expiration.Location() != time.UTC {
+			t.Errorf("expiration = %v, want %v", user.Expiration, want)
+		}
+	})
+}
@@ -4,36 +4,52 @@ import (
 	"time"
 
 	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	version string
+	version       string
+	adminService  *services.GarageAdminService
+	s3Service     *services.S3Service
+	settingsStore *services.SettingsStore
 }
 
 // NewHealthHandler creates a new health check handler
-func NewHealthHandler(version string) *HealthHandler {
+func NewHealthHandler(version string, adminService *services.GarageAdminService, s3Service *services.S3Service, settingsStore *services.SettingsStore) *HealthHandler {
 	return &HealthHandler{
-		version: version,
+		version:       version,
+		adminService:  adminService,
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
 	}
 }
 
 // Check returns the health status of the service
 //
 //	@Summary		Health check
-//	@Description	Returns the health status of the API service along with version information
+//	@Description	Returns the health status of the API service along with version information and the last observed contact with the Garage S3 and Admin APIs, and the maintenance mode while it is in effect. Checking health never calls Garage.
 //	@Tags			Health
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	models.APIResponse{data=models.HealthResponse}	"Service is healthy"
 //	@Router			/api/v1/health [get]
 func (h *HealthHandler) Check(c fiber.Ctx) error {
+	now := time.Now().UTC()
 	response := models.HealthResponse{
-		Status:    "healthy",
-		Timestamp: time.Now(),
-		Version:   h.version,
+		Status:     "healthy",
+		Timestamp:  now,
+		ServerTime: now,
+		Version:    h.version,
+		Upstream: models.Upstream{
+			S3:    h.s3Service.ContactStatus(),
+			Admin: h.adminService.ContactStatus(),
+		},
+	}
+	if maintenance, active := h.settingsStore.Maintenance(); active {
+		response.Maintenance = &maintenance
 	}
 
 	return c.JSON(models.SuccessResponse(response))
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// LifecycleHandler handles the lifecycle rules garage-ui enforces on buckets
+type LifecycleHandler struct {
+	lifecycleService *services.LifecycleService
+}
+
+// NewLifecycleHandler creates a new lifecycle handler
+func NewLifecycleHandler(lifecycleService *services.LifecycleService) *LifecycleHandler {
+	return &LifecycleHandler{
+		lifecycleService: lifecycleService,
+	}
+}
+
+// GetLifecycle returns the lifecycle rules of a bucket
+//
+//	@Summary		Get bucket lifecycle rules
+//	@Description	Returns the lifecycle rules of a bucket, whether they are enforced periodically (lifecycle.enabled) and the outcome of their last enforcement since garage-ui started. Garage has no lifecycle policies, so garage-ui enforces these rules itself.
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketLifecycleResponse}	"Lifecycle rules"
+//	@Router			/api/v1/buckets/{name}/lifecycle [get]
+func (h *LifecycleHandler) GetLifecycle(c fiber.Ctx) error {
+	return c.JSON(models.SuccessResponse(h.lifecycleResponse(c.Params("name"))))
+}
+
+// PutLifecycle replaces the lifecycle rules of a bucket
+//
+//	@Summary		Set bucket lifecycle rules
+//	@Description	Replaces every lifecycle rule of a bucket; an empty list removes them. Each rule needs a unique id and expirationDays, abortIncompleteUploadDays or both: objects under its prefix last modified more than expirationDays ago are deleted permanently, bypassing the trash, and multipart uploads started more than abortIncompleteUploadDays ago are aborted. A bucket may have at most 100 rules. Rules of read-only buckets are kept but not enforced.
+//	@Tags			Buckets
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Param			request	body		models.BucketLifecycleRequest							true	"New lifecycle rules"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketLifecycleResponse}	"Lifecycle rules saved"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Invalid request body or rules"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}				"Not an admin, or the bucket is read-only"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to save the rules"
+//	@Router			/api/v1/buckets/{name}/lifecycle [put]
+func (h *LifecycleHandler) PutLifecycle(c fiber.Ctx) error {
+	bucketName := c.Params("name")
+
+	var req models.BucketLifecycleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	err := h.lifecycleService.SetRules(bucketName, req.Rules)
+	var invalid *services.InvalidLifecycleError
+	switch {
+	case errors.As(err, &invalid):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, invalid.Error()),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to save lifecycle rules: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(h.lifecycleResponse(bucketName)))
+}
+
+// DeleteLifecycle removes every lifecycle rule of a bucket
+//
+//	@Summary		Delete bucket lifecycle rules
+//	@Description	Removes every lifecycle rule of a bucket. An enforcement in progress finishes the rules it started with.
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.BucketLifecycleResponse}	"Lifecycle rules removed"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}				"Not an admin, or the bucket is read-only"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to remove the rules"
+//	@Router			/api/v1/buckets/{name}/lifecycle [delete]
+func (h *LifecycleHandler) DeleteLifecycle(c fiber.Ctx) error {
+	bucketName := c.Params("name")
+
+	if err := h.lifecycleService.SetRules(bucketName, nil); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to remove lifecycle rules: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(h.lifecycleResponse(bucketName)))
+}
+
+// RunLifecycle enforces the lifecycle rules of a bucket now
+//
+//	@Summary		Enforce bucket lifecycle rules now
+//	@Description	Enforces the enabled lifecycle rules of a bucket right away, whether or not lifecycle.enabled is set, and returns the outcome. A run stopped by an error or by the time budget answers 500 saying what it removed; it also becomes the lastRun of the bucket. Time budget: server.timeouts.transfer (default: 5m).
+//	@Tags			Buckets
+//	@Produce		json
+//	@Param			name	path		string											true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.LifecycleRun}	"Rules enforced"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}		"Not an admin, or the bucket is read-only"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}		"The rules of the bucket are already being enforced"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}		"Enforcement stopped early"
+//	@Router			/api/v1/buckets/{name}/lifecycle/run [post]
+func (h *LifecycleHandler) RunLifecycle(c fiber.Ctx) error {
+	run, err := h.lifecycleService.Apply(c.Context(), c.Params("name"))
+	switch {
+	case errors.Is(err, services.ErrLifecycleRunning):
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, err.Error()),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, fmt.Sprintf(
+				"Lifecycle run stopped after expiring %d objects and aborting %d uploads: %s", run.ExpiredObjects, run.AbortedUploads, err.Error(),
+			)),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(run))
+}
+
+// lifecycleResponse describes the lifecycle rules of a bucket as they are stored
+func (h *LifecycleHandler) lifecycleResponse(bucketName string) models.BucketLifecycleResponse {
+	response := models.BucketLifecycleResponse{
+		Bucket:   bucketName,
+		Rules:    h.lifecycleService.Rules(bucketName),
+		Enforced: h.lifecycleService.Enforced(),
+	}
+	if run, ok := h.lifecycleService.LastRun(bucketName); ok {
+		response.LastRun = &run
+	}
+	return response
+}
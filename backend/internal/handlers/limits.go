@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// LimitsHandler reports the effective request limits to the frontend
+type LimitsHandler struct {
+	cfg *config.Config
+}
+
+// NewLimitsHandler creates a new limits handler
+func NewLimitsHandler(cfg *config.Config) *LimitsHandler {
+	return &LimitsHandler{
+		cfg: cfg,
+	}
+}
+
+// GetLimits returns the limits that apply to the current user. Everything comes from the
+// configuration, so this never calls Garage.
+//
+//	@Summary		Get effective limits
+//	@Description	Returns the body, object, key length, request header, presign, pagination, time budget and role-dependent limits that apply to the current user. Time budgets are given per route group in seconds (0: unlimited) so clients can set matching timeouts: listing covers the bucket, object and user listings, presign the presigned URLs and download manifests, transfer the uploads, downloads, streamed listings and ZIP exports, and default everything else.
+//	@Tags			Limits
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.LimitsResponse}	"Effective limits"
+//	@Failure		401	{object}	models.APIResponse{error=models.APIError}		"Unauthorized"
+//	@Security		BearerAuth
+//	@Router			/api/v1/limits [get]
+func (h *LimitsHandler) GetLimits(c fiber.Ctx) error {
+	bodyLimit := h.cfg.Server.BodyLimit()
+
+	response := models.LimitsResponse{
+		MaxBodySize:           bodyLimit,
+		MaxObjectSize:         bodyLimit,
+		MaxStreamedObjectSize: services.MaxStreamedObjectSize,
+		PresignDefaultTTL:     int64(h.cfg.Garage.PresignDefaultTTL / time.Second),
+		PresignMaxTTL:         int64(h.cfg.Garage.PresignMaxTTL / time.Second),
+		DefaultPageSize:       h.cfg.Server.Pagination.DefaultPageSize,
+		MaxPageSize:           h.cfg.Server.Pagination.MaxPageSize,
+		MaxKeyLength:          config.MaxObjectKeyLength,
+		MaxRequestHeader:      h.cfg.Server.ReadBufferLimit(),
+		Timeouts: models.TimeoutLimits{
+			Default:      int64(h.cfg.Server.Timeouts.Default / time.Second),
+			Listing:      int64(h.cfg.Server.Timeouts.Listing / time.Second),
+			Presign:      int64(h.cfg.Server.Timeouts.Presign / time.Second),
+			Transfer:     int64(h.cfg.Server.Timeouts.Transfer / time.Second),
+			TransferIdle: int64(h.cfg.Server.Timeouts.TransferIdle / time.Second),
+		},
+	}
+
+	if userInfo, ok := oidcUser(c); ok && h.cfg.SelfService.Enabled {
+		response.SelfService = &models.SelfServiceLimit{
+			MaxKeys:    h.cfg.SelfService.MaxKeysPerUser,
+			DefaultTTL: int64(h.cfg.SelfService.DefaultTTL / time.Second),
+			MaxTTL:     int64(h.cfg.SelfService.MaxTTL / time.Second),
+			Buckets:    h.cfg.SelfService.BucketsForRoles(userInfo.Roles),
+		}
+	}
+
+	if isAdmin, _ := c.Locals("isAdmin").(bool); isAdmin {
+		response.Bulk = &models.BulkLimits{
+			MaxUsers:   maxBulkUserDelete,
+			MaxBuckets: maxBulkBuckets,
+		}
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
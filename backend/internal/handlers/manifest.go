@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxManifestURLs bounds the number of URLs in a download manifest
+const maxManifestURLs = 1000
+
+// Download manifest formats
+const (
+	manifestFormatAria2 = "aria2" // aria2 input file: each URL followed by an indented out= option
+	manifestFormatURLs  = "urls"  // One URL per line, for rclone, wget -i and the like
+)
+
+// GetDownloadManifest returns presigned URLs of several objects for external download managers
+//
+//	@Summary		Get a download manifest
+//	@Description	Returns presigned GET URLs for the given keys, or for every object under a prefix, as an aria2 input file (each URL followed by an out= option holding the key) or as a plain list with one URL per line. All URLs are signed with the same bucket key and expiry. Manifests hold at most 1000 URLs: more keys are rejected, and a longer prefix listing is cut. The X-Manifest-Count, X-Manifest-Total-Size (bytes), X-Manifest-Skipped (keys not found) and X-Manifest-Truncated headers describe the manifest. Time budget: server.timeouts.presign (default: 5s).
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		text/plain
+//	@Param			bucket		path		string										true	"Name of the bucket"
+//	@Param			request		body		models.DownloadManifestRequest				true	"Keys, or a prefix"
+//	@Param			format		query		string										false	"Manifest format: aria2 (default) or urls"
+//	@Param			expires_in	query		int											false	"Expiration time in seconds of the URLs (default: garage.presign_default_ttl, max: garage.presign_max_ttl)"
+//	@Success		200			{string}	string										"Manifest"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}	"Invalid request body or parameters"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}	"Failed to build the manifest"
+//	@Router			/api/v1/buckets/{bucket}/objects/manifest [post]
+func (h *ObjectHandler) GetDownloadManifest(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	format := c.Query("format", manifestFormatAria2)
+	if format != manifestFormatAria2 && format != manifestFormatURLs {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid format (must be aria2 or urls)"),
+		)
+	}
+
+	expiresIn, _, err := h.presignExpiry(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+
+	var req models.DownloadManifestRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	var objects []models.ObjectInfo
+	skipped, truncated := 0, false
+	switch {
+	case len(req.Keys) > 0 && req.Prefix != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Either keys or a prefix must be given, not both"),
+		)
+
+	case len(req.Keys) > 0:
+		if len(req.Keys) > maxManifestURLs {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("A manifest holds at most %d keys", maxManifestURLs)),
+			)
+		}
+		if slices.Contains(req.Keys, "") {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Object keys must not be empty"),
+			)
+		}
+
+		// The sizes come from the metadata; keys that cannot be found are left out
+		results, err := h.s3Service.GetObjectsMetadata(ctx, bucketName, req.Keys)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to get metadata: "+err.Error()),
+			)
+		}
+		for _, result := range results {
+			if result.Object == nil {
+				skipped++
+				continue
+			}
+			objects = append(objects, *result.Object)
+		}
+
+	case req.Prefix != nil:
+		objects, truncated, err = h.s3Service.ListObjectsRecursive(ctx, bucketName, *req.Prefix, maxManifestURLs)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeListFailed, "Failed to list objects: "+err.Error()),
+			)
+		}
+
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Keys or a prefix are required"),
+		)
+	}
+
+	keys := make([]string, len(objects))
+	var totalSize int64
+	for i, object := range objects {
+		keys[i] = object.Key
+		totalSize += object.Size
+	}
+
+	urls, err := h.s3Service.GetPresignedURLs(ctx, bucketName, keys, expiresIn)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to generate pre-signed URLs: "+err.Error()),
+		)
+	}
+
+	var b strings.Builder
+	for i, url := range urls {
+		b.WriteString(url)
+		b.WriteByte('\n')
+		if format == manifestFormatAria2 {
+			// aria2 options apply to the URL line above them and must be indented
+			fmt.Fprintf(&b, "  out=%s\n", keys[i])
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store") // The URLs grant access until they expire
+	c.Set("X-Manifest-Count", strconv.Itoa(len(urls)))
+	c.Set("X-Manifest-Total-Size", strconv.FormatInt(totalSize, 10))
+	c.Set("X-Manifest-Skipped", strconv.Itoa(skipped))
+	c.Set("X-Manifest-Truncated", strconv.FormatBool(truncated))
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.SendString(b.String())
+}
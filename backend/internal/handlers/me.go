@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MeHandler handles self-service operations of the current user
+type MeHandler struct {
+	selfService *services.SelfServiceKeys
+	config      *config.SelfServiceConfig
+	auditLog    *services.AuditLog
+}
+
+// NewMeHandler creates a new self-service handler
+func NewMeHandler(selfService *services.SelfServiceKeys, cfg *config.SelfServiceConfig, auditLog *services.AuditLog) *MeHandler {
+	return &MeHandler{
+		selfService: selfService,
+		config:      cfg,
+		auditLog:    auditLog,
+	}
+}
+
+// oidcUser returns the current user when they authenticated through OIDC
+func oidcUser(c fiber.Ctx) (*auth.UserInfo, bool) {
+	userInfo, ok := c.Locals("userInfo").(*auth.UserInfo)
+	if !ok || userInfo.AuthMethod != auth.AuthMethodOIDC {
+		return nil, false
+	}
+	return userInfo, true
+}
+
+// keyInfoToUserInfo converts a Garage key to the frontend UserInfo format
+func keyInfoToUserInfo(keyInfo *models.GarageKeyInfo) models.UserInfo {
+	status := "active"
+	if keyInfo.Expired {
+		status = "inactive"
+	}
+
+	return models.UserInfo{
+		AccessKeyID:       keyInfo.AccessKeyID,
+		SecretKey:         keyInfo.SecretAccessKey,
+		Name:              keyInfo.Name,
+		CreatedAt:         utils.UTCPtr(keyInfo.Created),
+		Status:            status,
+		BucketPermissions: convertBucketPermissionsToBucketPermissions(keyInfo.Buckets),
+		Expiration:        utils.UTCPtr(keyInfo.Expiration),
+		Expired:           keyInfo.Expired,
+	}
+}
+
+// ListMyKeys lists the S3 keys issued to the current user
+//
+//	@Summary		List my keys
+//	@Description	Lists the S3 keys issued to the current OIDC user through self-service
+//	@Tags			Users
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.UserListResponse}	"Successfully retrieved keys"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}			"Only available to OIDC users"
+//	@Failure		500	{object}	models.APIResponse{error=models.APIError}			"Failed to list keys"
+//	@Router			/api/v1/me/keys [get]
+func (h *MeHandler) ListMyKeys(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	userInfo, ok := oidcUser(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Self-service keys are only available to OIDC users"),
+		)
+	}
+
+	keys, err := h.selfService.ListKeys(ctx, userInfo.Username)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to list keys: "+err.Error()),
+		)
+	}
+
+	users := make([]models.UserInfo, 0, len(keys))
+	for _, key := range keys {
+		users = append(users, keyInfoToUserInfo(&models.GarageKeyInfo{
+			AccessKeyID: key.ID,
+			Name:        key.Name,
+			Expired:     key.Expired,
+			Created:     key.Created,
+			Expiration:  key.Expiration,
+		}))
+	}
+
+	// Users hold at most max_keys_per_user keys, so the list is never paged
+	return c.JSON(models.SuccessResponse(models.UserListResponse{
+		Users:      users,
+		Count:      len(users),
+		Pagination: models.Pagination{Limit: h.config.MaxKeysPerUser, Total: len(users)},
+	}))
+}
+
+// CreateMyKey issues an S3 key to the current user
+//
+//	@Summary		Create my key
+//	@Description	Issues an S3 key to the current OIDC user with read/write access to the buckets their roles are mapped to. The secret key is only returned once.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateSelfServiceKeyRequest			false	"Optional key expiry"
+//	@Success		201		{object}	models.APIResponse{data=models.UserInfo}	"Key created"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid request body or expiry"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}	"Not an OIDC user, or no buckets are mapped to the user's roles"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}	"Key limit reached"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}	"Failed to create key"
+//	@Router			/api/v1/me/keys [post]
+func (h *MeHandler) CreateMyKey(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	userInfo, ok := oidcUser(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Self-service keys are only available to OIDC users"),
+		)
+	}
+
+	// The body is optional
+	var req models.CreateSelfServiceKeyRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+			)
+		}
+	}
+
+	ttl := h.config.DefaultTTL
+	if req.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid expires_in: must be a positive duration such as 720h"),
+			)
+		}
+		if parsed > h.config.MaxTTL {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "expires_in must not exceed "+h.config.MaxTTL.String()),
+			)
+		}
+		ttl = parsed
+	}
+
+	event := newAuditEvent(c, "key.self_service.create", userInfo.Username)
+	key, err := h.selfService.CreateKey(ctx, userInfo.Username, userInfo.Roles, ttl)
+	if err == nil {
+		event.Target = key.AccessKeyID
+		event.Success = true
+	}
+	h.auditLog.Record(event)
+
+	switch {
+	case errors.Is(err, services.ErrSelfServiceKeyLimit):
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, "Key limit reached: delete an existing key first"),
+		)
+	case errors.Is(err, services.ErrNoEntitledBuckets):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "No buckets are available to your roles"),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to create key: "+err.Error()),
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(keyInfoToUserInfo(key)))
+}
+
+// DeleteMyKey deletes an S3 key issued to the current user
+//
+//	@Summary		Delete my key
+//	@Description	Deletes an S3 key previously issued to the current OIDC user
+//	@Tags			Users
+//	@Produce		json
+//	@Param			id	path		string											true	"Access key ID"
+//	@Success		200	{object}	models.APIResponse{data=map[string]interface{}}	"Key deleted"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}		"Only available to OIDC users"
+//	@Failure		404	{object}	models.APIResponse{error=models.APIError}		"Key not found"
+//	@Failure		500	{object}	models.APIResponse{error=models.APIError}		"Failed to delete key"
+//	@Router			/api/v1/me/keys/{id} [delete]
+func (h *MeHandler) DeleteMyKey(c fiber.Ctx) error {
+	ctx := c.Context()
+	keyID := c.Params("id")
+
+	userInfo, ok := oidcUser(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Self-service keys are only available to OIDC users"),
+		)
+	}
+
+	event := newAuditEvent(c, "key.self_service.delete", keyID)
+	err := h.selfService.DeleteKey(ctx, userInfo.Username, keyID)
+	event.Success = err == nil
+	h.auditLog.Record(event)
+
+	// Keys of other users are reported as missing rather than forbidden
+	if errors.Is(err, services.ErrKeyNotOwned) {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "Key not found"),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to delete key: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(map[string]interface{}{
+		"access_key": keyID,
+		"deleted":    true,
+	}))
+}
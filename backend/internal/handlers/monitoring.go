@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"sync/atomic"
+	"time"
+
 	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/services"
 
@@ -9,22 +12,74 @@ import (
 
 // MonitoringHandler handles monitoring operations
 type MonitoringHandler struct {
-	adminService *services.GarageAdminService
-	s3Service    *services.S3Service
+	adminService       *services.GarageAdminService
+	s3Service          *services.S3Service
+	diagnosticsService *services.DiagnosticsService
+	transferStats      *services.TransferStats
+	throttleStats      *services.ThrottleStats
+	backendMetrics     *services.BackendMetrics
+	cacheWarmer        *services.CacheWarmer
+
+	// lastDashboard holds the last dashboard metrics computed without errors, served while
+	// the Admin API fails
+	lastDashboard atomic.Pointer[models.DashboardMetrics]
 }
 
 // NewMonitoringHandler creates a new monitoring handler
-func NewMonitoringHandler(adminService *services.GarageAdminService, s3Service *services.S3Service) *MonitoringHandler {
+func NewMonitoringHandler(adminService *services.GarageAdminService, s3Service *services.S3Service, diagnosticsService *services.DiagnosticsService, transferStats *services.TransferStats, throttleStats *services.ThrottleStats, backendMetrics *services.BackendMetrics, cacheWarmer *services.CacheWarmer) *MonitoringHandler {
 	return &MonitoringHandler{
-		adminService: adminService,
-		s3Service:    s3Service,
+		adminService:       adminService,
+		s3Service:          s3Service,
+		diagnosticsService: diagnosticsService,
+		transferStats:      transferStats,
+		throttleStats:      throttleStats,
+		backendMetrics:     backendMetrics,
+		cacheWarmer:        cacheWarmer,
+	}
+}
+
+// GetDiagnostics returns the last connection diagnostic report
+//
+//	@Summary		Get connection diagnostics
+//	@Description	Returns the report of the last connection self-test (DNS, TCP, TLS, admin token and bucket listing against the configured endpoints), along with the cache warm-up progress when monitoring.warm_cache is enabled
+//	@Tags			Monitoring
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.DiagnosticReport}	"Last diagnostic report"
+//	@Failure		404	{object}	models.APIResponse{error=models.APIError}			"No diagnostic run has completed yet"
+//	@Router			/api/v1/monitoring/diagnostics [get]
+func (h *MonitoringHandler) GetDiagnostics(c fiber.Ctx) error {
+	report := h.diagnosticsService.LastReport()
+	if report == nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "No diagnostic run has completed yet"),
+		)
 	}
+
+	// The stored report is shared, so the warm-up status goes on a copy
+	withWarmup := *report
+	withWarmup.CacheWarmup = h.cacheWarmer.Status()
+
+	return c.JSON(models.SuccessResponse(withWarmup))
+}
+
+// RunDiagnostics re-runs the connection diagnostics
+//
+//	@Summary		Run connection diagnostics
+//	@Description	Runs the connection self-test now and returns its report. Admin only.
+//	@Tags			Monitoring
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.DiagnosticReport}	"Diagnostic report"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}			"Administrator privileges required"
+//	@Router			/api/v1/monitoring/diagnostics/run [post]
+func (h *MonitoringHandler) RunDiagnostics(c fiber.Ctx) error {
+	report := h.diagnosticsService.Run(c.Context())
+	return c.JSON(models.SuccessResponse(report))
 }
 
 // GetMetrics retrieves system metrics from the Admin API
 //
 //	@Summary		Get system metrics
-//	@Description	Retrieves system metrics from the Garage Admin API for monitoring purposes
+//	@Description	Retrieves system metrics from the Garage Admin API for monitoring purposes, followed by the Garage UI transfer and throttling counters, bucket credential cache lookups, Admin API calls by operation and status, retries and S3 operation latencies
 //	@Tags			Monitoring
 //	@Accept			json
 //	@Produce		text/plain
@@ -41,41 +96,96 @@ func (h *MonitoringHandler) GetMetrics(c fiber.Ctx) error {
 		)
 	}
 
+	// Append the proxy's own counters to the Garage metrics
+	if metrics != "" && metrics[len(metrics)-1] != '\n' {
+		metrics += "\n"
+	}
+	metrics += h.transferStats.PrometheusMetrics()
+	metrics += h.throttleStats.PrometheusMetrics()
+	metrics += h.backendMetrics.PrometheusMetrics()
+
 	// Return metrics as plain text
 	c.Set("Content-Type", "text/plain; charset=utf-8")
 	return c.SendString(metrics)
 }
 
+// GetTransferStats returns the bytes each user uploaded and downloaded through the proxy
+//
+//	@Summary		Get transfer statistics
+//	@Description	Returns per-user object upload and download byte totals over a time window, at hourly granularity. Counters are kept in memory for 7 days. Transfers through presigned URLs bypass the proxy and are not counted. Admin only.
+//	@Tags			Monitoring
+//	@Produce		json
+//	@Param			window	query		string													false	"Time window as a duration (default: 24h, max: 168h)"
+//	@Success		200		{object}	models.APIResponse{data=models.TransferStatsResponse}	"Transfer totals"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Invalid window"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}				"Administrator privileges required"
+//	@Router			/api/v1/monitoring/transfer [get]
+func (h *MonitoringHandler) GetTransferStats(c fiber.Ctx) error {
+	window := 24 * time.Hour
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > services.TransferRetention {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid window: must be a positive duration no longer than "+services.TransferRetention.String()),
+			)
+		}
+		window = parsed
+	}
+
+	users := h.transferStats.Totals(window)
+
+	response := models.TransferStatsResponse{
+		Window: window.String(),
+		Since:  time.Now().Add(-window).UTC(),
+		Users:  users,
+		Note:   "Transfers through presigned URLs go directly to Garage and are not counted",
+	}
+	for _, user := range users {
+		response.TotalUploadBytes += user.UploadBytes
+		response.TotalDownloadBytes += user.DownloadBytes
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
 // CheckAdminHealth checks if the Admin API is reachable
 //
 //	@Summary		Check Admin API health
-//	@Description	Performs a health check on the Garage Admin API to verify connectivity and availability
+//	@Description	Performs a health check on the Garage Admin API to verify connectivity and availability. Both outcomes include the last observed contact with the S3 and Admin APIs, so clients can tell since when Garage is unreachable.
 //	@Tags			Monitoring
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	models.APIResponse{data=object{status=string,message=string}}	"Admin API is healthy"
-//	@Failure		503	{object}	models.APIResponse{error=models.APIError}						"Admin API health check failed"
+//	@Success		200	{object}	models.APIResponse{data=object{status=string,message=string,upstream=models.Upstream}}	"Admin API is healthy"
+//	@Failure		503	{object}	models.APIResponse{data=models.Upstream,error=models.APIError}							"Admin API health check failed"
 //	@Router			/api/v1/monitoring/admin-health [get]
 func (h *MonitoringHandler) CheckAdminHealth(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	err := h.adminService.HealthCheck(ctx)
+
+	// Read after the check so that it is included
+	upstream := models.Upstream{
+		S3:    h.s3Service.ContactStatus(),
+		Admin: h.adminService.ContactStatus(),
+	}
+
 	if err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(
-			models.ErrorResponse(models.ErrCodeInternalError, "Admin API health check failed: "+err.Error()),
-		)
+		response := models.ErrorResponse(models.ErrCodeInternalError, "Admin API health check failed: "+err.Error())
+		response.Data = upstream
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
 	}
 
 	return c.JSON(models.SuccessResponse(map[string]interface{}{
-		"status":  "healthy",
-		"message": "Admin API is reachable",
+		"status":   "healthy",
+		"message":  "Admin API is reachable",
+		"upstream": upstream,
 	}))
 }
 
 // GetDashboardMetrics retrieves aggregated dashboard metrics
 //
 //	@Summary		Get dashboard metrics
-//	@Description	Retrieves aggregated metrics for the dashboard including storage, buckets, and request metrics
+//	@Description	Retrieves aggregated metrics for the dashboard including storage, buckets, and request metrics. When the Admin API fails, the last metrics computed are returned with stale set and their original generatedAt; an error is only returned when none were ever computed.
 //	@Tags			Monitoring
 //	@Accept			json
 //	@Produce		json
@@ -86,8 +196,11 @@ func (h *MonitoringHandler) GetDashboardMetrics(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket list
-	buckets, err := h.adminService.ListBuckets(ctx)
+	buckets, truncated, err := h.adminService.ListBuckets(ctx)
 	if err != nil {
+		if last := h.lastDashboard.Load(); last != nil {
+			return c.JSON(models.SuccessResponse(staleDashboard(last)))
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get buckets: "+err.Error()),
 		)
@@ -97,11 +210,13 @@ func (h *MonitoringHandler) GetDashboardMetrics(c fiber.Ctx) error {
 	var totalSize int64
 	var totalObjects int64
 	usageByBucket := make([]models.BucketUsage, 0)
+	complete := true
 
 	for _, bucket := range buckets {
 		// Get bucket info to calculate size and object count
 		bucketInfo, err := h.adminService.GetBucketInfo(ctx, bucket.ID)
 		if err != nil {
+			complete = false
 			continue // Skip buckets we can't access
 		}
 
@@ -139,7 +254,24 @@ func (h *MonitoringHandler) GetDashboardMetrics(c fiber.Ctx) error {
 		ObjectCount:   totalObjects,
 		BucketCount:   len(buckets),
 		UsageByBucket: usageByBucket,
+		Truncated:     truncated,
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	// Totals missing some buckets would look like a drop in usage; the last complete ones
+	// are closer to the truth
+	if complete {
+		h.lastDashboard.Store(&dashboardMetrics)
+	} else if last := h.lastDashboard.Load(); last != nil {
+		return c.JSON(models.SuccessResponse(staleDashboard(last)))
 	}
 
 	return c.JSON(models.SuccessResponse(dashboardMetrics))
 }
+
+// staleDashboard returns a copy of the last dashboard metrics flagged as stale
+func staleDashboard(last *models.DashboardMetrics) models.DashboardMetrics {
+	stale := *last
+	stale.Stale = true
+	return stale
+}
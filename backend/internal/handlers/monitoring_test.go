@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestDashboardMetricsServeStaleWhileAdminFails(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+
+	getDashboard := func(t *testing.T) (*http.Response, models.DashboardMetrics) {
+		t.Helper()
+		resp := env.request(t, http.MethodGet, "/api/v1/monitoring/dashboard", nil)
+		var metrics models.DashboardMetrics
+		if resp.StatusCode == http.StatusOK {
+			decodeAPIResponse(t, resp, &metrics)
+		}
+		return resp, metrics
+	}
+
+	// Nothing was ever computed, so there is nothing to fall back on
+	env.FailAdmin("ListBuckets")
+	if resp, _ := getDashboard(t); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("never computed: status = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	env.FailAdmin()
+	resp, fresh := getDashboard(t)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("fresh: status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if fresh.Stale || fresh.GeneratedAt.IsZero() || fresh.ObjectCount != 1 {
+		t.Fatalf("fresh: stale = %v, generated at %v, objects = %d; want fresh metrics of 1 object", fresh.Stale, fresh.GeneratedAt, fresh.ObjectCount)
+	}
+
+	// The next lookups reach the Admin API instead of the bucket info cache
+	info, err := env.admin.GetBucketInfoByAlias(context.Background(), "docs")
+	if err != nil {
+		t.Fatalf("GetBucketInfoByAlias failed: %v", err)
+	}
+	env.PutObject("docs", "b.txt", "text/plain", []byte("b"))
+	env.admin.InvalidateBucketInfo(info.ID)
+
+	for _, call := range []string{"ListBuckets", "GetBucketInfo"} {
+		t.Run(call+" failing", func(t *testing.T) {
+			env.FailAdmin(call)
+			resp, stale := getDashboard(t)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if !stale.Stale || !stale.GeneratedAt.Equal(fresh.GeneratedAt) || stale.ObjectCount != 1 {
+				t.Errorf("stale = %v, generated at %v, objects = %d; want the metrics of %v flagged stale",
+					stale.Stale, stale.GeneratedAt, stale.ObjectCount, fresh.GeneratedAt)
+			}
+		})
+	}
+
+	env.FailAdmin()
+	if _, recovered := getDashboard(t); recovered.Stale || recovered.ObjectCount != 2 {
+		t.Errorf("recovered: stale = %v, objects = %d; want fresh metrics of 2 objects", recovered.Stale, recovered.ObjectCount)
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// InitMultipartUpload starts a multipart upload
+//
+//	@Summary		Start a multipart upload
+//	@Description	Starts a multipart upload of key, for files too large to be sent in one request. The parts are then sent one per request with PUT /multipart/{uploadId}/parts/{part}, in any order and in parallel, and assembled with POST /multipart/{uploadId}/complete; the object only appears then. Every part but the last must be at least minPartSize bytes, and no part may exceed maxPartSize (server.max_body_size). An upload that will not be completed should be aborted, since Garage keeps its parts until then. The bucket's file type rules are applied to the key and content_type; the content is not sniffed.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string																true	"Name of the bucket"
+//	@Param			request	body		models.MultipartInitRequest											true	"Object key and content type"
+//	@Success		201		{object}	models.APIResponse{data=models.MultipartUploadResponse}				"Upload started"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}							"Invalid request body or object key"
+//	@Failure		415		{object}	models.APIResponse{error=models.APIError}							"File type refused by the bucket's allowedContentTypes or blockedExtensions"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}							"Failed to start the upload"
+//	@Failure		503		{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/multipart/init [post]
+func (h *ObjectHandler) InitMultipartUpload(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+
+	var req models.MultipartInitRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if err := validateObjectKey(req.Key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	// The parts are not seen together before completion, so only the declared type is checked
+	var blocked *services.UploadBlockedError
+	if errors.As(h.settingsStore.CheckUpload(bucketName, req.Key, req.ContentType, ""), &blocked) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(
+			models.ErrorResponse(models.ErrCodeFileTypeBlocked, "Upload of "+req.Key+" "+blocked.Error()),
+		)
+	}
+
+	uploadID, err := h.s3Service.InitMultipartUpload(c.Context(), bucketName, req.Key, req.ContentType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to start multipart upload: "+err.Error()),
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(models.MultipartUploadResponse{
+		Bucket:      bucketName,
+		Key:         req.Key,
+		UploadID:    uploadID,
+		MinPartSize: services.MultipartMinPartSize,
+		MaxPartSize: h.maxPartSize,
+		MaxParts:    services.MultipartMaxParts,
+	}))
+}
+
+// UploadMultipartPart uploads one part of a multipart upload
+//
+//	@Summary		Upload a part of a multipart upload
+//	@Description	Uploads part number part (from 1 to 10000) of a multipart upload, sent as the raw request body. Sending a part number again replaces the part, so a failed part can simply be sent again. The returned etag must be passed back with the part number on completion. Time budget: server.timeouts.transfer (default: unlimited).
+//	@Tags			Objects
+//	@Accept			application/octet-stream
+//	@Produce		json
+//	@Param			bucket		path		string																true	"Name of the bucket"
+//	@Param			upload_id	path		string																true	"Upload ID returned when the upload was started"
+//	@Param			part		path		int																	true	"Part number, from 1 to 10000"
+//	@Param			key			query		string																true	"Object key of the upload"
+//	@Success		200			{object}	models.APIResponse{data=models.MultipartPart}						"Part uploaded"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}							"Invalid part number, object key or empty body"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}							"Unknown upload"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}							"Failed to upload the part"
+//	@Failure		503			{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/multipart/{upload_id}/parts/{part} [put]
+func (h *ObjectHandler) UploadMultipartPart(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key := c.Query("key")
+	if err := validateObjectKey(key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+
+	partNumber, err := strconv.Atoi(c.Params("part"))
+	if err != nil || partNumber < 1 || partNumber > services.MultipartMaxParts {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("Part number must be between 1 and %d", services.MultipartMaxParts)),
+		)
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Part body is empty"),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	part, err := h.s3Service.UploadPart(c.Context(), bucketName, key, c.Params("upload_id"), partNumber, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		if services.IsNoSuchUpload(err) {
+			return c.Status(fiber.StatusNotFound).JSON(
+				models.ErrorResponse(models.ErrCodeNotFound, "Multipart upload not found; it may have been completed or aborted"),
+			)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to upload part: "+err.Error()),
+		)
+	}
+
+	h.transferStats.Add(transferUser(c), services.TransferUpload, part.Size)
+
+	return c.JSON(models.SuccessResponse(part))
+}
+
+// CompleteMultipartUpload assembles the parts of a multipart upload into the object
+//
+//	@Summary		Complete a multipart upload
+//	@Description	Assembles the given parts, identified by their number and the etag returned when they were uploaded, into the object, in part number order. Parts uploaded but not listed are discarded. The object replaces any object with the same key.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket		path		string																true	"Name of the bucket"
+//	@Param			upload_id	path		string																true	"Upload ID returned when the upload was started"
+//	@Param			request		body		models.MultipartCompleteRequest										true	"Object key and parts"
+//	@Success		201			{object}	models.APIResponse{data=models.ObjectUploadResponse}				"Object assembled"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}							"Invalid request body, or parts Garage refused (too small, missing or with a wrong etag)"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}							"Unknown upload"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}							"Failed to complete the upload"
+//	@Failure		503			{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/multipart/{upload_id}/complete [post]
+func (h *ObjectHandler) CompleteMultipartUpload(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+
+	var req models.MultipartCompleteRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if err := validateObjectKey(req.Key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+	if len(req.Parts) == 0 || len(req.Parts) > services.MultipartMaxParts {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("Between 1 and %d parts are required", services.MultipartMaxParts)),
+		)
+	}
+	seen := make(map[int]bool, len(req.Parts))
+	for _, part := range req.Parts {
+		if part.PartNumber < 1 || part.PartNumber > services.MultipartMaxParts || part.ETag == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Every part needs a part_number between 1 and 10000 and an etag"),
+			)
+		}
+		if seen[part.PartNumber] {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("Part %d is listed more than once", part.PartNumber)),
+			)
+		}
+		seen[part.PartNumber] = true
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	result, err := h.s3Service.CompleteMultipartUpload(c.Context(), bucketName, req.Key, c.Params("upload_id"), req.Parts)
+	if err != nil {
+		switch {
+		case services.IsNoSuchUpload(err):
+			return c.Status(fiber.StatusNotFound).JSON(
+				models.ErrorResponse(models.ErrCodeNotFound, "Multipart upload not found; it may have been completed or aborted"),
+			)
+		case services.IsInvalidPart(err):
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Garage refused the parts: "+err.Error()),
+			)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to complete multipart upload: "+err.Error()),
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(result))
+}
+
+// AbortMultipartUpload cancels a multipart upload
+//
+//	@Summary		Abort a multipart upload
+//	@Description	Cancels a multipart upload and frees the parts uploaded so far. Aborting an upload that no longer exists succeeds.
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			bucket		path		string											true	"Name of the bucket"
+//	@Param			upload_id	path		string											true	"Upload ID returned when the upload was started"
+//	@Param			key			query		string											true	"Object key of the upload"
+//	@Success		200			{object}	models.APIResponse{data=map[string]interface{}}	"Upload aborted"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}		"Invalid object key"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}		"Failed to abort the upload"
+//	@Router			/api/v1/buckets/{bucket}/objects/multipart/{upload_id}/abort [delete]
+func (h *ObjectHandler) AbortMultipartUpload(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key := c.Query("key")
+	if err := validateObjectKey(key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+
+	if err := h.s3Service.AbortMultipartUpload(c.Context(), bucketName, key, c.Params("upload_id")); err != nil && !services.IsNoSuchUpload(err) {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to abort multipart upload: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(map[string]interface{}{
+		"uploadId": c.Params("upload_id"),
+		"aborted":  true,
+	}))
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// CopyObject copies an object into the bucket of the route
+//
+//	@Summary		Copy an object
+//	@Description	Copies source_key of source_bucket (default: this bucket) to key in this bucket, server-side, keeping its content type, encoding and metadata. Copies between buckets whose keys cannot read each other are streamed through the server instead of Garage. An existing object at key is only replaced with overwrite=true. Scoped API tokens need write access to this bucket and read access to the source bucket.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string																true	"Name of the destination bucket"
+//	@Param			request	body		models.ObjectCopyRequest											true	"Source and destination of the copy"
+//	@Success		201		{object}	models.APIResponse{data=models.ObjectCopyResponse}					"Object copied"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}							"Invalid request body or object key, or the object would be copied onto itself"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}							"Token scope does not allow reading the source bucket"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}							"Source object not found"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}							"An object already exists at key"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}							"Failed to copy the object"
+//	@Failure		503		{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/copy [post]
+func (h *ObjectHandler) CopyObject(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	var req models.ObjectCopyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if req.SourceBucket == "" {
+		req.SourceBucket = bucketName
+	}
+	if req.SourceKey == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "source_key is required"),
+		)
+	}
+	if err := validateObjectKey(req.Key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+	if req.SourceBucket == bucketName && req.SourceKey == req.Key {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "An object cannot be copied onto itself"),
+		)
+	}
+
+	// The route middleware only checked the destination bucket
+	if !tokenScopeAllows(c, req.SourceBucket, auth.VerbRead) {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Token scope does not allow read access to bucket "+req.SourceBucket),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	source, err := h.s3Service.GetObjectMetadata(ctx, req.SourceBucket, req.SourceKey)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeObjectNotFound, "Source object not found: "+err.Error()),
+		)
+	}
+
+	if !req.Overwrite {
+		exists, err := h.s3Service.ObjectExists(ctx, bucketName, req.Key)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to check the destination: "+err.Error()),
+			)
+		}
+		if exists {
+			return c.Status(fiber.StatusConflict).JSON(
+				models.ErrorResponse(models.ErrCodeConflict, "An object already exists at "+req.Key+"; retry with overwrite to replace it"),
+			)
+		}
+	}
+
+	if err := h.s3Service.CopyObject(ctx, req.SourceBucket, req.SourceKey, bucketName, req.Key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to copy object: "+err.Error()),
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(models.ObjectCopyResponse{
+		SourceBucket: req.SourceBucket,
+		SourceKey:    req.SourceKey,
+		Bucket:       bucketName,
+		Key:          req.Key,
+		Size:         source.Size,
+	}))
+}
+
+// tokenScopeAllows reports whether the API token of the request, if it is scoped, allows
+// verb on a bucket other than the one of the route
+func tokenScopeAllows(c fiber.Ctx, bucketName, verb string) bool {
+	userInfo, ok := c.Locals("userInfo").(*auth.UserInfo)
+	if !ok || userInfo.Scope == nil {
+		return true
+	}
+	return userInfo.Scope.AllowsBucket(bucketName) && userInfo.Scope.AllowsVerb(verb)
+}
+
+// MoveObject moves or renames an object, or a folder when the key ends with /
+//
+//	@Summary		Move or rename an object or folder
+//	@Description	Moves the object at key to target_key in the same bucket, with a server-side copy followed by a deletion of the original. When key ends with / every object under it is moved under target_key, which must then end with / too and must not lie under key; the objects are moved one listing page at a time, so a move interrupted midway (for instance by the time budget) leaves some objects at each place and can be resumed by repeating the request with overwrite=true. Existing objects at the target are only replaced with overwrite=true. Moves bypass the trash.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string																true	"Name of the bucket"
+//	@Param			key		path		string																true	"Key of the object, or folder prefix ending with /"
+//	@Param			request	body		models.ObjectMoveRequest											true	"Target of the move"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectMoveResponse}					"Object or folder moved"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}							"Invalid request body or target key"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}							"Object not found, or nothing under the folder"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}							"Objects already exist at the target"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}							"Failed to move"
+//	@Failure		503		{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/move [post]
+func (h *ObjectHandler) MoveObject(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	var req models.ObjectMoveRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if err := validateObjectKey(req.TargetKey); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid target key: "+err.Error()),
+		)
+	}
+
+	folder := strings.HasSuffix(key, "/")
+	switch {
+	case req.TargetKey == key:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "The target key is the current key"),
+		)
+	case folder && !strings.HasSuffix(req.TargetKey, "/"):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "The target of a folder must end with /"),
+		)
+	case folder && strings.HasPrefix(req.TargetKey, key):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "A folder cannot be moved into itself"),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	response := models.ObjectMoveResponse{
+		Bucket:    bucketName,
+		Key:       key,
+		TargetKey: req.TargetKey,
+		Folder:    folder,
+	}
+
+	if folder {
+		if !req.Overwrite {
+			existing, _, err := h.s3Service.PrefixUsage(ctx, bucketName, req.TargetKey)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(
+					models.ErrorResponse(models.ErrCodeListFailed, "Failed to check the target: "+err.Error()),
+				)
+			}
+			if existing > 0 {
+				return c.Status(fiber.StatusConflict).JSON(
+					models.ErrorResponse(models.ErrCodeConflict, fmt.Sprintf("%d objects already exist under %s; retry with overwrite to replace them", existing, req.TargetKey)),
+				)
+			}
+		}
+
+		moved, err := h.s3Service.MovePrefix(ctx, bucketName, key, req.TargetKey)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, fmt.Sprintf("Failed to move folder after %d objects: %s", moved, err.Error())),
+			)
+		}
+		if moved == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(
+				models.ErrorResponse(models.ErrCodeObjectNotFound, "No objects found under "+key),
+			)
+		}
+		response.Moved = moved
+		return c.JSON(models.SuccessResponse(response))
+	}
+
+	if _, err := h.s3Service.GetObjectMetadata(ctx, bucketName, key); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found: "+err.Error()),
+		)
+	}
+
+	if !req.Overwrite {
+		exists, err := h.s3Service.ObjectExists(ctx, bucketName, req.TargetKey)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to check the target: "+err.Error()),
+			)
+		}
+		if exists {
+			return c.Status(fiber.StatusConflict).JSON(
+				models.ErrorResponse(models.ErrCodeConflict, "An object already exists at "+req.TargetKey+"; retry with overwrite to replace it"),
+			)
+		}
+	}
+
+	if err := h.s3Service.MoveObject(ctx, bucketName, key, req.TargetKey); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to move object: "+err.Error()),
+		)
+	}
+
+	response.Moved = 1
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// UnknownObjectAction answers object routes whose trailing action is missing or unknown
+func (h *ObjectHandler) UnknownObjectAction(c fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(
+		models.ErrorResponse(models.ErrCodeNotFound, "Unknown object action"),
+	)
+}
@@ -1,49 +1,231 @@
 package handlers
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/middleware"
 	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/utils"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 // ObjectHandler handles object-related operations
 type ObjectHandler struct {
-	s3Service *services.S3Service
+	s3Service          *services.S3Service
+	adminService       *services.GarageAdminService
+	settingsStore      *services.SettingsStore
+	trashService       *services.TrashService
+	transferStats      *services.TransferStats
+	auditLog           *services.AuditLog
+	dedupe             *services.DedupeIndex
+	uploadProgress     *services.UploadProgress
+	thumbnails         *services.ThumbnailService // Nil when thumbnails are disabled
+	garageConfig       *config.GarageConfig
+	pagination         *config.PaginationConfig
+	inlineContentTypes []string
+
+	// deleteConfirmThreshold is the object count above which prefix deletions must be confirmed
+	deleteConfirmThreshold int
+
+	// blockWritesWhenDegraded rejects uploads and deletions while partitions lack write quorum
+	blockWritesWhenDegraded bool
+
+	// zipManifestTTL is how long a prepared ZIP download can be fetched
+	zipManifestTTL time.Duration
+
+	// transferIdle aborts ZIP exports making no progress for this long; 0 disables it
+	transferIdle time.Duration
+
+	// maxPartSize is the largest multipart upload part a request may carry
+	maxPartSize int64
+
+	// thumbnailMaxDimension is the largest width or height a thumbnail may be requested with
+	thumbnailMaxDimension int
+
+	// clock dates presigned URLs and retains the progress of finished uploads
+	clock clock.Clock
 }
 
 // NewObjectHandler creates a new object handler
-func NewObjectHandler(s3Service *services.S3Service) *ObjectHandler {
+func NewObjectHandler(s3Service *services.S3Service, adminService *services.GarageAdminService, settingsStore *services.SettingsStore, trashService *services.TrashService, transferStats *services.TransferStats, auditLog *services.AuditLog, thumbnails *services.ThumbnailService, cfg *config.Config, clk clock.Clock) *ObjectHandler {
+	inlineContentTypes := cfg.Server.InlineContentTypes
+	if len(inlineContentTypes) == 0 {
+		inlineContentTypes = config.DefaultInlineContentTypes
+	}
+
 	return &ObjectHandler{
-		s3Service: s3Service,
+		s3Service:          s3Service,
+		adminService:       adminService,
+		settingsStore:      settingsStore,
+		trashService:       trashService,
+		transferStats:      transferStats,
+		auditLog:           auditLog,
+		dedupe:             services.NewDedupeIndex(),
+		uploadProgress:     services.NewUploadProgress(clk),
+		thumbnails:         thumbnails,
+		garageConfig:       &cfg.Garage,
+		pagination:         &cfg.Server.Pagination,
+		inlineContentTypes: inlineContentTypes,
+
+		deleteConfirmThreshold:  cfg.Server.DeleteConfirmThreshold,
+		blockWritesWhenDegraded: cfg.Server.BlockWritesWhenDegraded,
+		zipManifestTTL:          cfg.Server.ZipManifestTTL,
+		transferIdle:            cfg.Server.Timeouts.TransferIdle,
+		maxPartSize:             cfg.Server.BodyLimit(),
+		thumbnailMaxDimension:   cfg.Thumbnails.MaxDimension,
+		clock:                   clk,
+	}
+}
+
+// bucketParam returns the bucket name decoded by the route middleware, falling back to the
+// raw path parameter
+func bucketParam(c fiber.Ctx) string {
+	if bucketName, ok := c.Locals("bucketName").(string); ok && bucketName != "" {
+		return bucketName
+	}
+	return c.Params("bucket")
+}
+
+// validateObjectKey rejects keys that cannot be stored safely. Keys containing control
+// characters such as CR or LF would otherwise end up in response headers on download.
+func validateObjectKey(key string) error {
+	if key == "" {
+		return errors.New("object key is required")
+	}
+	if len(key) > config.MaxObjectKeyLength {
+		return fmt.Errorf("object key must not exceed %d bytes", config.MaxObjectKeyLength)
+	}
+	if utils.ContainsControlChars(key) {
+		return errors.New("object key must not contain control characters")
+	}
+	return nil
+}
+
+// parseObjectSort reads the sort_by and order query parameters of object listings
+func parseObjectSort(c fiber.Ctx) (services.ObjectSort, error) {
+	var order services.ObjectSort
+
+	switch sortBy := c.Query("sort_by", services.SortByName); sortBy {
+	case services.SortByName, services.SortBySize, services.SortByLastModified:
+		order.By = sortBy
+	default:
+		return order, fmt.Errorf("invalid sort_by %q (must be name, size or last_modified)", sortBy)
+	}
+
+	switch direction := c.Query("order", "asc"); direction {
+	case "asc":
+	case "desc":
+		order.Descending = true
+	default:
+		return order, fmt.Errorf("invalid order %q (must be asc or desc)", direction)
+	}
+	return order, nil
+}
+
+// parseObjectFilter reads the min_size, max_size, modified_after and modified_before
+// query parameters of a listing
+func parseObjectFilter(c fiber.Ctx) (services.ObjectFilter, error) {
+	var filter services.ObjectFilter
+
+	for param, target := range map[string]**int64{"min_size": &filter.MinSize, "max_size": &filter.MaxSize} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size < 0 {
+			return filter, fmt.Errorf("%s must be a non-negative integer", param)
+		}
+		*target = &size
 	}
+
+	for param, target := range map[string]**time.Time{"modified_after": &filter.ModifiedAfter, "modified_before": &filter.ModifiedBefore} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := utils.ParseTimestamp(value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+		}
+		*target = &t
+	}
+
+	if filter.MinSize != nil && filter.MaxSize != nil && *filter.MinSize > *filter.MaxSize {
+		return filter, errors.New("min_size must not exceed max_size")
+	}
+	if filter.ModifiedAfter != nil && filter.ModifiedBefore != nil && filter.ModifiedAfter.After(*filter.ModifiedBefore) {
+		return filter, errors.New("modified_after must not be later than modified_before")
+	}
+
+	return filter, nil
+}
+
+// canRenderInline reports whether the content type is on the inline rendering safelist
+func (h *ObjectHandler) canRenderInline(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range h.inlineContentTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // ListObjects lists objects in a bucket with optional filtering and pagination
 //
 //	@Summary		List objects in a bucket
-//	@Description	Retrieves a list of objects and prefixes (folders) stored in the specified bucket, with optional filtering by prefix, pagination support, and max keys
+//	@Description	Retrieves a list of objects and prefixes (folders) stored in the specified bucket, with optional filtering by prefix, pagination support, and max keys. With a size or date filter, pages hold only matching objects; each page scans at most 10000 keys, so a page may hold fewer matches than max_keys, or none, while isTruncated is still true. Continuation tokens of filtered listings only work with filtered listings. Garage lists keys by ascending name only, so for any other sort_by or order the first 10000 keys of the folder are read and sorted on each request, folders first, and pages are cut from them by offset; sortWindowExceeded is set when the folder holds more keys, which are then left out. Continuation tokens of sorted listings only work with the same sort. An empty bucket has empty objects and prefixes arrays, while a missing bucket answers 404. Time budget: server.timeouts.listing (default: 10s).
 //	@Tags			Objects
 //	@Accept			json
 //	@Produce		json
 //	@Param			bucket				path		string												true	"Name of the bucket to list objects from"
 //	@Param			prefix				query		string												false	"Filter objects by prefix"
-//	@Param			max_keys			query		int													false	"Maximum number of objects to return (default: 100)"
+//	@Param			recursive			query		bool												false	"List all keys under the prefix instead of one folder level; no prefixes are returned"
+//	@Param			max_keys			query		int													false	"Maximum number of objects to return (default: bucket setting, or server.pagination.default_page_size; capped at server.pagination.max_page_size and 1000)"
 //	@Param			continuation_token	query		string												false	"Token for pagination to retrieve next page of results"
+//	@Param			min_size			query		int													false	"Only return objects of at least this many bytes"
+//	@Param			max_size			query		int													false	"Only return objects of at most this many bytes"
+//	@Param			modified_after		query		string												false	"Only return objects modified at or after this RFC3339 time"
+//	@Param			modified_before		query		string												false	"Only return objects modified at or before this RFC3339 time"
+//	@Param			sort_by				query		string												false	"Sort objects by name (default), size or last_modified"
+//	@Param			order				query		string												false	"Sort order: asc (default) or desc"
+//	@Param			fields				query		string												false	"Comma-separated object fields to return, e.g. key,size,lastModified (snake_case names are accepted too)"
 //	@Success		200					{object}	models.APIResponse{data=models.ObjectListResponse}	"Successfully retrieved list of objects and prefixes"
 //	@Failure		400					{object}	models.APIResponse{error=models.APIError}			"Invalid request parameters"
-//	@Failure		404					{object}	models.APIResponse{error=models.APIError}			"Bucket not found"
+//	@Failure		403					{object}	models.APIResponse{error=models.APIError}			"Garage denied access to the bucket"
+//	@Failure		404					{object}	models.APIResponse{error=models.APIError}			"Bucket does not exist, or no key gives access to it"
 //	@Failure		500					{object}	models.APIResponse{error=models.APIError}			"Failed to list objects"
 //	@Router			/api/v1/buckets/{bucket}/objects [get]
 func (h *ObjectHandler) ListObjects(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameter
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 	if bucketName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(
 			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
@@ -53,52 +235,259 @@ func (h *ObjectHandler) ListObjects(c fiber.Ctx) error {
 	// Get query parameters for filtering and pagination
 	prefix := c.Query("prefix", "")
 	continuationToken := c.Query("continuation_token", "")
+	recursive := c.Query("recursive") == "true"
 
-	maxKeysStr := c.Query("max_keys", "100")
-	maxKeys, err := strconv.Atoi(maxKeysStr)
-	if err != nil || maxKeys <= 0 {
+	// Fall back to the bucket's stored page size when max_keys is absent
+	defaultMaxKeys := 0
+	if settings, ok := h.settingsStore.GetBucketSettings(bucketName); ok && settings.MaxKeys > 0 {
+		defaultMaxKeys = settings.MaxKeys
+	}
+
+	params, err := parsePageParams(c, h.pagination, "max_keys", defaultMaxKeys)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(
-			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid max_keys parameter"),
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
 		)
 	}
 
-	// List objects in the bucket
-	objects, err := h.s3Service.ListObjects(ctx, bucketName, prefix, maxKeys, continuationToken)
+	filter, err := parseObjectFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid filter: "+err.Error()),
+		)
+	}
+
+	fields, err := parseFields[models.ObjectInfo](c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid fields parameter: "+err.Error()),
+		)
+	}
+
+	order, err := parseObjectSort(c)
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid sort: "+err.Error()),
+		)
+	}
+
+	// List objects in the bucket
+	var objects *models.ObjectListResponse
+	if !order.Native() {
+		objects, err = h.s3Service.ListObjectsSorted(ctx, bucketName, prefix, recursive, params.Limit, continuationToken, order, filter)
+	} else if filter.Active() {
+		objects, err = h.s3Service.ListObjectsFiltered(ctx, bucketName, prefix, recursive, params.Limit, continuationToken, filter)
+	} else {
+		objects, err = h.s3Service.ListObjects(ctx, bucketName, prefix, recursive, params.Limit, continuationToken)
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidFilterToken), errors.Is(err, services.ErrInvalidSortToken):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid continuation token: "+err.Error()),
+		)
+	case services.IsBucketNotFound(err):
+		// A missing bucket must not look like an empty one
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist or no key gives access to it: "+bucketName),
+		)
+	case services.IsAccessDenied(err):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Access to bucket denied: "+bucketName),
+		)
+	case err != nil:
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeListFailed, "Failed to list objects: "+err.Error()),
 		)
 	}
 
-	return c.JSON(models.SuccessResponse(objects))
+	// An empty bucket lists as empty arrays, never null
+	return sendListing(c, objects, "objects", fields)
+}
+
+// maxStreamedObjects caps the number of objects a single streamed listing returns
+const maxStreamedObjects = 1_000_000
+
+// errStreamLimit stops a streamed listing once the object limit is reached
+var errStreamLimit = errors.New("stream object limit reached")
+
+// StreamObjects streams a listing progressively as NDJSON
+//
+//	@Summary		Stream objects in a bucket
+//	@Description	Streams a listing as newline-delimited JSON: one "batch" line per Garage page (up to 1000 objects) as it arrives, then a "summary" line with totals and truncation status. Results are in key order; sorting is not supported. Content types are not included. With a size or date filter, batches hold only matching objects and the listing stops after scanning 1000000 keys. Time budget: server.timeouts.transfer (default: unlimited).
+//	@Tags			Objects
+//	@Produce		application/x-ndjson
+//	@Param			bucket			path		string										true	"Name of the bucket to list objects from"
+//	@Param			prefix			query		string										false	"Filter objects by prefix"
+//	@Param			recursive		query		bool										false	"List all keys under the prefix instead of one folder level"
+//	@Param			limit			query		int											false	"Maximum number of objects to stream (default and max: 1000000)"
+//	@Param			min_size		query		int											false	"Only stream objects of at least this many bytes"
+//	@Param			max_size		query		int											false	"Only stream objects of at most this many bytes"
+//	@Param			modified_after	query		string										false	"Only stream objects modified at or after this RFC3339 time"
+//	@Param			modified_before	query		string										false	"Only stream objects modified at or before this RFC3339 time"
+//	@Success		200				{object}	models.ObjectStreamBatch					"Batches followed by a models.ObjectStreamSummary line"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}	"Invalid request parameters"
+//	@Router			/api/v1/buckets/{bucket}/objects/stream [get]
+func (h *ObjectHandler) StreamObjects(c fiber.Ctx) error {
+	// The stream writer runs after this handler returns and the request is released,
+	// so copy everything it needs out of the request first
+	bucketName := strings.Clone(bucketParam(c))
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+	prefix := strings.Clone(c.Query("prefix", ""))
+	recursive := c.Query("recursive") == "true"
+
+	limit := maxStreamedObjects
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid limit parameter"),
+			)
+		}
+		limit = min(parsed, maxStreamedObjects)
+	}
+
+	filter, err := parseObjectFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid filter: "+err.Error()),
+		)
+	}
+
+	c.Set("Content-Type", "application/x-ndjson")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	// Lines use the app's encoder so that server.legacy_field_names applies to them too
+	marshal := c.App().Config().JSONEncoder
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		encode := func(v interface{}) error {
+			line, err := marshal(v)
+			if err != nil {
+				return err
+			}
+			w.Write(line)
+			return w.WriteByte('\n')
+		}
+		summary := models.ObjectStreamSummary{
+			Type:   "summary",
+			Bucket: bucketName,
+			Prefix: prefix,
+		}
+
+		err := h.s3Service.ListObjectsPages(ctx, bucketName, prefix, recursive, func(objects []models.ObjectInfo, prefixes []string) error {
+			if filter.Active() {
+				summary.Scanned += len(objects)
+				objects = slices.DeleteFunc(objects, func(obj models.ObjectInfo) bool {
+					return !filter.Matches(obj)
+				})
+				// Stop scanning once the budget is used up, even without a single match
+				if summary.Scanned >= maxStreamedObjects {
+					summary.IsTruncated = true
+				}
+			}
+
+			if remaining := limit - summary.Count; len(objects) > remaining {
+				objects = objects[:remaining]
+				summary.IsTruncated = true
+			}
+			summary.Count += len(objects)
+			summary.PrefixCount += len(prefixes)
+
+			if len(objects) > 0 || len(prefixes) > 0 {
+				batch := models.ObjectStreamBatch{Type: "batch", Objects: objects, Prefixes: prefixes}
+				if err := encode(batch); err != nil {
+					return err
+				}
+				// Flushing fails once the client has gone away, which ends the listing
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+
+			if summary.IsTruncated {
+				return errStreamLimit
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStreamLimit) {
+			summary.Error = err.Error()
+		}
+
+		if err := encode(summary); err == nil {
+			w.Flush()
+		}
+	})
 }
 
 // UploadObject uploads an object to a bucket
 //
 //	@Summary		Upload object to bucket
-//	@Description	Uploads an object to the specified bucket using multipart/form-data
+//	@Description	Uploads an object to the specified bucket using multipart/form-data. With compress=gzip the object is stored compressed, under the same key, with Content-Encoding: gzip; size is then the size of the file and storedSize that of the object. When the bucket has dedupe hints enabled, duplicateOf names another key recently uploaded with the same content; in "before" mode such an upload is refused with 409 unless allow_duplicate=true. Storage is not deduplicated. Time budget: server.timeouts.transfer (default: unlimited).
 //	@Tags			Objects
 //	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			bucket	path		string													true	"Name of the bucket to upload the object to"
-//	@Param			file	formData	file													true	"File to upload"
-//	@Param			key		formData	string													false	"Object key (path in bucket). If not provided, the filename will be used"
-//	@Success		201		{object}	models.APIResponse{data=models.ObjectUploadResponse}	"Object uploaded successfully"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Invalid request parameters"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Bucket not found"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to upload object"
+//	@Param			bucket			path		string																true	"Name of the bucket to upload the object to"
+//	@Param			file			formData	file																true	"File to upload"
+//	@Param			key				formData	string																false	"Object key (path in bucket). If not provided, the filename will be used"
+//	@Param			compress		query		string																false	"Store the object compressed: gzip"
+//	@Param			force			query		bool																false	"Admin only: upload even if the bucket's file type rules refuse the file (audited)"
+//	@Param			allow_duplicate	query		bool																false	"Upload even if the bucket's dedupe hints find the content under another key"
+//	@Param			upload_id		query		string																false	"ID chosen by the client to follow the upload on /uploads/{upload_id}/progress (also accepted as a form field)"
+//	@Success		201				{object}	models.APIResponse{data=models.ObjectUploadResponse}				"Object uploaded successfully"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}							"Invalid request parameters"
+//	@Failure		403				{object}	models.APIResponse{error=models.APIError}							"force requested by a non-admin"
+//	@Failure		404				{object}	models.APIResponse{error=models.APIError}							"Bucket not found"
+//	@Failure		409				{object}	models.APIResponse{data=models.DuplicateUpload}						"Content recently uploaded under another key (dedupeHints before)"
+//	@Failure		415				{object}	models.APIResponse{error=models.APIError}							"File type refused by the bucket's allowedContentTypes or blockedExtensions"
+//	@Failure		500				{object}	models.APIResponse{error=models.APIError}							"Failed to upload object"
+//	@Failure		503				{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
 //	@Router			/api/v1/buckets/{bucket}/objects [post]
 func (h *ObjectHandler) UploadObject(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameter
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 	if bucketName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(
 			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
 		)
 	}
 
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	compress, err := parseCompress(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+
+	// File type rules may only be overridden by admins
+	force := c.Query("force") == "true"
+	if isAdmin, _ := c.Locals("isAdmin").(bool); force && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Forcing uploads past file type rules is restricted to administrators"),
+		)
+	}
+
+	// The client may follow the upload on /uploads/:upload_id/progress
+	uploadID := c.Query("upload_id", c.FormValue("upload_id"))
+	if uploadID != "" && !uploadIDPattern.MatchString(uploadID) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "upload_id must be 1 to 64 letters, digits, dashes or underscores"),
+		)
+	}
+
 	// Get file from multipart form
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -113,6 +502,11 @@ func (h *ObjectHandler) UploadObject(c fiber.Ctx) error {
 		// Use filename as key if not provided
 		key = file.Filename
 	}
+	if err := validateObjectKey(key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
 
 	// Open the uploaded file
 	fileHandle, err := file.Open()
@@ -126,36 +520,118 @@ func (h *ObjectHandler) UploadObject(c fiber.Ctx) error {
 	// Get content type
 	contentType := file.Header.Get("Content-Type")
 
+	if rejected, err := h.rejectBlockedFileType(c, bucketName, key, contentType, fileHandle, force); rejected {
+		return err
+	}
+
+	dedupeMode, checksum, duplicateOf, err := h.findDuplicate(ctx, bucketName, key, fileHandle)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to read uploaded file: "+err.Error()),
+		)
+	}
+	if duplicateOf != "" && dedupeMode == services.DedupeHintsBefore && c.Query("allow_duplicate") != "true" {
+		return c.Status(fiber.StatusConflict).JSON(models.DuplicateUploadResponse(
+			"The content of "+key+" was recently uploaded as "+duplicateOf+"; use that object or retry with allow_duplicate=true",
+			models.DuplicateUpload{Bucket: bucketName, Key: key, DuplicateOf: duplicateOf, MD5: checksum},
+		))
+	}
+
+	// Report the progress of the relay to Garage when the client asked to follow it
+	var body io.ReadSeeker = fileHandle
+	if uploadID != "" {
+		username, _ := c.Locals("username").(string)
+		body = h.uploadProgress.Track(uploadID, username, bucketName, key, file.Size, fileHandle)
+	}
+
 	// Upload to Garage
-	uploadResult, err := h.s3Service.UploadObject(ctx, bucketName, key, fileHandle, contentType)
+	uploadResult, err := h.s3Service.UploadObject(ctx, bucketName, key, body, contentType, compress)
+	if uploadID != "" {
+		h.uploadProgress.Finish(uploadID, err)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to upload object: "+err.Error()),
 		)
 	}
 
+	if checksum != "" {
+		h.dedupe.Remember(bucketName, checksum, key)
+		uploadResult.DuplicateOf = duplicateOf
+	}
+
+	h.transferStats.Add(transferUser(c), services.TransferUpload, uploadResult.Size)
+
 	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(uploadResult))
 }
 
+// findDuplicate checksums an upload when its bucket has dedupe hints enabled, and returns
+// the bucket's hint mode, the checksum and the other key recently uploaded with the same
+// content, provided that object still exists. The file is rewound.
+func (h *ObjectHandler) findDuplicate(ctx context.Context, bucketName, key string, file multipart.File) (mode, checksum, duplicateOf string, err error) {
+	settings, _ := h.settingsStore.GetBucketSettings(bucketName)
+	if settings.DedupeHints == "" {
+		return "", "", "", nil
+	}
+
+	checksum, err = services.ChecksumUpload(file)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	existing, ok := h.dedupe.Lookup(bucketName, checksum)
+	if !ok || existing == key {
+		return settings.DedupeHints, checksum, "", nil
+	}
+
+	// A hint is not worth failing the upload over, so lookup errors just drop it
+	exists, err := h.s3Service.ObjectExists(ctx, bucketName, existing)
+	if err != nil {
+		return settings.DedupeHints, checksum, "", nil
+	}
+	if !exists {
+		h.dedupe.Forget(bucketName, checksum)
+		return settings.DedupeHints, checksum, "", nil
+	}
+	return settings.DedupeHints, checksum, existing, nil
+}
+
+// parseCompress reads the compress query parameter of uploads
+func parseCompress(c fiber.Ctx) (bool, error) {
+	switch c.Query("compress") {
+	case "":
+		return false, nil
+	case services.ContentEncodingGzip:
+		return true, nil
+	default:
+		return false, errors.New("invalid compress value (must be gzip)")
+	}
+}
+
 // GetObject retrieves an object from a bucket
 //
 //	@Summary		Get object from bucket
-//	@Description	Retrieves an object stored in the specified bucket
+//	@Description	Retrieves an object stored in the specified bucket. Objects stored compressed are sent as is with Content-Encoding: gzip to clients accepting gzip, and decompressed otherwise; Range is ignored for them. Errors are JSON when the request has no Accept header or accepts application/json, and a plain status text otherwise (e.g. for <img> tags); they are never cached. Time budget: server.timeouts.transfer (default: unlimited).
 //	@Tags			Objects
 //	@Accept			json
 //	@Produce		application/octet-stream
-//	@Param			bucket		path		string										true	"Name of the bucket containing the object"
-//	@Param			key			path		string										true	"Key (path) of the object"
-//	@Param			download	query		bool										false	"Set to true to download the object as an attachment"
-//	@Success		200			{file}		binary										"Successfully retrieved the object"
-//	@Failure		400			{object}	models.APIResponse{error=models.APIError}	"Bucket name and object key are required"
-//	@Failure		404			{object}	models.APIResponse{error=models.APIError}	"Object not found"
+//	@Param			bucket			path		string										true	"Name of the bucket containing the object"
+//	@Param			key				path		string										true	"Key (path) of the object"
+//	@Param			download		query		bool										false	"Set to true to download the object as an attachment"
+//	@Param			inline_unsafe	query		bool										false	"Admin only: render content types outside the inline safelist inline"
+//	@Param			Range			header		string										false	"Single byte range to retrieve (e.g. bytes=0-1023)"
+//	@Success		200				{file}		binary										"Successfully retrieved the object"
+//	@Success		206				{file}		binary										"Successfully retrieved the requested range"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}	"Bucket name and object key are required"
+//	@Failure		403				{object}	models.APIResponse{error=models.APIError}	"inline_unsafe requested by a non-admin"
+//	@Failure		404				{object}	models.APIResponse{error=models.APIError}	"Object not found"
+//	@Failure		416				{object}	models.APIResponse{error=models.APIError}	"Requested range not satisfiable"
 //	@Router			/api/v1/buckets/{bucket}/objects/{key} [get]
 func (h *ObjectHandler) GetObject(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameters
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 
 	// Get object key from locals (set by route handler) or from params
 	key, ok := c.Locals("objectKey").(string)
@@ -164,53 +640,181 @@ func (h *ObjectHandler) GetObject(c fiber.Ctx) error {
 	}
 
 	if bucketName == "" || key == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name and object key are required"),
-		)
+		return downloadError(c, fiber.StatusBadRequest, models.ErrCodeBadRequest, "Bucket name and object key are required")
+	}
+
+	// Rendering content outside the safelist inline is reserved for admins
+	inlineUnsafe := c.Query("inline_unsafe") == "true"
+	if isAdmin, _ := c.Locals("isAdmin").(bool); inlineUnsafe && !isAdmin {
+		return downloadError(c, fiber.StatusForbidden, models.ErrCodeForbidden, "inline_unsafe is restricted to administrators")
+	}
+
+	var (
+		body       io.ReadCloser
+		objectInfo *models.ObjectInfo
+		byteRange  *byteRange
+		err        error
+	)
+
+	// Ranged requests need the object size before the range can be resolved
+	if rangeHeader := c.Get(fiber.HeaderRange); rangeHeader != "" {
+		objectInfo, err = h.s3Service.GetObjectMetadata(ctx, bucketName, key)
+		if err != nil {
+			return downloadError(c, fiber.StatusNotFound, models.ErrCodeObjectNotFound, "Object not found: "+err.Error())
+		}
+
+		// Ranges of compressed objects would cut the gzip stream, so those are sent whole
+		if objectInfo.ContentEncoding == "" {
+			var satisfiable bool
+			byteRange, satisfiable = parseByteRange(rangeHeader, objectInfo.Size)
+			if !satisfiable {
+				c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(objectInfo.Size, 10))
+				return downloadError(c, fiber.StatusRequestedRangeNotSatisfiable, models.ErrCodeBadRequest, "Requested range not satisfiable")
+			}
+		}
 	}
 
 	// Get object from Garage
-	body, objectInfo, err := h.s3Service.GetObject(ctx, bucketName, key)
+	if byteRange != nil {
+		body, err = h.s3Service.GetObjectRange(ctx, bucketName, key, byteRange.start, byteRange.length())
+	} else {
+		body, objectInfo, err = h.s3Service.GetObject(ctx, bucketName, key)
+	}
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(
-			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found: "+err.Error()),
-		)
+		return downloadError(c, fiber.StatusNotFound, models.ErrCodeObjectNotFound, "Object not found: "+err.Error())
 	}
 
 	// Set response headers
-	c.Set("Content-Type", objectInfo.ContentType)
-	c.Set("Content-Length", string(rune(objectInfo.Size)))
-	c.Set("ETag", objectInfo.ETag)
+	contentType := resolveContentType(key, objectInfo.ContentType)
+	c.Set("Content-Type", contentType)
+	c.Set("ETag", utils.SanitizeHeaderValue(objectInfo.ETag))
 	c.Set("Last-Modified", objectInfo.LastModified.Format(time.RFC1123))
 
-	// Check if client wants to download or view inline
-	if c.Query("download") == "true" {
-		c.Set("Content-Disposition", "attachment; filename=\""+key+"\"")
+	// Compressed objects are passed through to clients that accept gzip, and decompressed
+	// for the others, whose size is then unknown
+	size := int(objectInfo.Size)
+	if objectInfo.ContentEncoding == services.ContentEncodingGzip {
+		c.Set(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+		c.Set("Accept-Ranges", "none")
+		if acceptsGzip(c) {
+			c.Set(fiber.HeaderContentEncoding, services.ContentEncodingGzip)
+		} else {
+			decompressed, err := gzip.NewReader(body)
+			if err != nil {
+				body.Close()
+				return downloadError(c, fiber.StatusInternalServerError, models.ErrCodeInternalError, "Failed to decompress object: "+err.Error())
+			}
+			body = readCloser{Reader: decompressed, Closer: body}
+			size = -1
+		}
+	} else {
+		c.Set("Accept-Ranges", "bytes")
+	}
+
+	// Never let the browser guess a more dangerous type than the one we declare
+	c.Set("X-Content-Type-Options", "nosniff")
+
+	// Only safelisted content types may render inline; everything else is forced to download
+	inline := inlineUnsafe || h.canRenderInline(contentType)
+	if c.Query("download") == "true" || !inline {
+		c.Set("Content-Disposition", utils.ContentDisposition("attachment", key))
+	}
+
+	// Downloaded bytes are attributed to the user once the stream is closed
+	counted := h.transferStats.CountReader(transferUser(c), services.TransferDownload, body)
+
+	// Stream only the requested range with a partial content status
+	if byteRange != nil {
+		c.Set(fiber.HeaderContentRange, byteRange.contentRange(objectInfo.Size))
+		c.Status(fiber.StatusPartialContent)
+		return c.SendStream(middleware.CountResponseBody(c, counted), int(byteRange.length()))
 	}
 
 	// Stream the object body to the client
-	return c.SendStream(body)
+	return c.SendStream(middleware.CountResponseBody(c, counted), size)
+}
+
+// rejectBlockedFileType applies the bucket's file type rules to an uploaded file, sniffing
+// its type from its first bytes. Uploads forced by an admin go through and are audited.
+// It returns whether the upload was refused, along with the response.
+func (h *ObjectHandler) rejectBlockedFileType(c fiber.Ctx, bucketName, key, declaredType string, file multipart.File, force bool) (bool, error) {
+	head := make([]byte, services.SniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to read uploaded file: "+err.Error()),
+		)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return true, c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to rewind uploaded file: "+err.Error()),
+		)
+	}
+
+	return h.rejectBlockedContent(c, bucketName, key, declaredType, head[:n], force)
+}
+
+// rejectBlockedContent is rejectBlockedFileType for uploads whose first bytes were already
+// read, such as streamed ones
+func (h *ObjectHandler) rejectBlockedContent(c fiber.Ctx, bucketName, key, declaredType string, head []byte, force bool) (bool, error) {
+	sniffedType := services.SniffContentType(head)
+
+	var blocked *services.UploadBlockedError
+	if !errors.As(h.settingsStore.CheckUpload(bucketName, key, declaredType, sniffedType), &blocked) {
+		return false, nil
+	}
+
+	if force {
+		event := newAuditEvent(c, "object.upload_forced", bucketName+"/"+key)
+		event.Details = map[string]string{"rule": blocked.Rule, "sniffedType": sniffedType}
+		h.auditLog.Record(event)
+		return false, nil
+	}
+
+	return true, c.Status(fiber.StatusUnsupportedMediaType).JSON(
+		models.ErrorResponse(models.ErrCodeFileTypeBlocked, "Upload of "+key+" "+blocked.Error()),
+	)
+}
+
+// readCloser reads from a wrapping reader and closes the underlying body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// acceptsGzip reports whether the client accepts gzip encoded responses
+func acceptsGzip(c fiber.Ctx) bool {
+	for _, encoding := range strings.Split(c.Get(fiber.HeaderAcceptEncoding), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
 }
 
 // DeleteObject deletes an object from a bucket
 //
 //	@Summary		Delete object from bucket
-//	@Description	Deletes an object stored in the specified bucket
+//	@Description	Deletes an object stored in the specified bucket, or moves it to the trash when the bucket has trash enabled
 //	@Tags			Objects
 //	@Accept			json
 //	@Produce		json
-//	@Param			bucket	path		string													true	"Name of the bucket containing the object"
-//	@Param			key		path		string													true	"Key (path) of the object"
-//	@Success		200		{object}	models.APIResponse{data=models.ObjectDeleteResponse}	"Successfully deleted the object"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Bucket name and object key are required"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}				"Object not found"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to delete object"
+//	@Param			bucket		path		string																true	"Name of the bucket containing the object"
+//	@Param			key			path		string																true	"Key (path) of the object"
+//	@Param			permanent	query		bool																false	"Admin only: delete permanently even if trash is enabled"
+//	@Success		200			{object}	models.APIResponse{data=models.ObjectDeleteResponse}				"Successfully deleted the object"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}							"Bucket name and object key are required"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}							"Permanent deletion or deletion of trashed objects requested by a non-admin"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}							"Object not found"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}							"Failed to delete object"
+//	@Failure		503			{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
 //	@Router			/api/v1/buckets/{bucket}/objects/{key} [delete]
 func (h *ObjectHandler) DeleteObject(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameters
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 
 	// Get object key from locals (set by route handler) or from params
 	key, ok := c.Locals("objectKey").(string)
@@ -224,6 +828,25 @@ func (h *ObjectHandler) DeleteObject(c fiber.Ctx) error {
 		)
 	}
 
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	// Bypassing the trash is reserved for admins, as is deleting trashed objects, which
+	// are deleted permanently
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	permanent := c.Query("permanent") == "true"
+	if permanent && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Permanent deletion is restricted to administrators"),
+		)
+	}
+	if !isAdmin && h.trashService.IsTrashKey(key) {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Deleting trashed objects is restricted to administrators"),
+		)
+	}
+
 	// Check if object exists
 	exists, err := h.s3Service.ObjectExists(ctx, bucketName, key)
 	if err != nil {
@@ -238,20 +861,27 @@ func (h *ObjectHandler) DeleteObject(c fiber.Ctx) error {
 		)
 	}
 
-	// Delete the object
-	if err := h.s3Service.DeleteObject(ctx, bucketName, key); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete object: "+err.Error()),
-		)
-	}
-
-	// Return success response
 	response := models.ObjectDeleteResponse{
 		Bucket:  bucketName,
 		Key:     key,
 		Deleted: true,
 	}
 
+	// Move the object to the trash when the bucket has it enabled, otherwise delete it
+	if !permanent && h.trashService.Enabled(bucketName) {
+		trashKey, err := h.trashService.MoveToTrash(ctx, bucketName, key)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete object: "+err.Error()),
+			)
+		}
+		response.TrashKey = trashKey
+	} else if err := h.s3Service.DeleteObject(ctx, bucketName, key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete object: "+err.Error()),
+		)
+	}
+
 	return c.JSON(models.SuccessResponse(response))
 }
 
@@ -272,7 +902,7 @@ func (h *ObjectHandler) GetObjectMetadata(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameters
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 
 	// Get object key from locals (set by route handler) or from params
 	key, ok := c.Locals("objectKey").(string)
@@ -297,26 +927,107 @@ func (h *ObjectHandler) GetObjectMetadata(c fiber.Ctx) error {
 	return c.JSON(models.SuccessResponse(metadata))
 }
 
+// maxMetadataBatchKeys bounds the number of keys accepted by GetObjectsMetadata
+const maxMetadataBatchKeys = 200
+
+// GetObjectsMetadata returns the metadata of several objects in one request
+//
+//	@Summary		Get metadata of several objects
+//	@Description	Retrieves the metadata of up to 200 objects, one result per key in request order. A key that cannot be read only fails its own result.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string														true	"Name of the bucket containing the objects"
+//	@Param			request	body		object{keys=[]string}										true	"Keys of the objects"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectMetadataBatchResponse}	"Metadata of every object"
+//	@Success		207		{object}	models.APIResponse{data=models.ObjectMetadataBatchResponse}	"Some keys failed"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}					"Invalid request parameters"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}					"Failed to get metadata"
+//	@Router			/api/v1/buckets/{bucket}/objects/metadata-batch [post]
+func (h *ObjectHandler) GetObjectsMetadata(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	var req struct {
+		Keys []string `json:"keys"`
+	}
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	if len(req.Keys) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "At least one key is required"),
+		)
+	}
+	if len(req.Keys) > maxMetadataBatchKeys {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("At most %d keys can be looked up at once", maxMetadataBatchKeys)),
+		)
+	}
+	if slices.Contains(req.Keys, "") {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Object keys must not be empty"),
+		)
+	}
+
+	results, err := h.s3Service.GetObjectsMetadata(ctx, bucketName, req.Keys)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get metadata: "+err.Error()),
+		)
+	}
+
+	response := models.ObjectMetadataBatchResponse{
+		Bucket:  bucketName,
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Error == "" {
+			response.SuccessCount++
+		} else {
+			response.FailureCount++
+		}
+	}
+	response.Partial = response.FailureCount > 0 && response.SuccessCount > 0
+
+	// Return 200 if all succeeded, 207 (Multi-Status) if partial success, 500 if all failed
+	statusCode := fiber.StatusOK
+	if response.Partial {
+		statusCode = fiber.StatusMultiStatus // 207
+	} else if response.FailureCount > 0 {
+		statusCode = fiber.StatusInternalServerError
+	}
+
+	return c.Status(statusCode).JSON(models.SuccessResponse(response))
+}
+
 // GetPresignedURL generates a pre-signed URL for accessing an object
 //
 //	@Summary		Get pre-signed URL for object
-//	@Description	Generates a pre-signed URL that allows temporary access to the specified object
+//	@Description	Generates a pre-signed URL that allows temporary access to the specified object: with method=get (default) to download it, with method=put to upload it and with method=delete to delete it, so external tools can reach Garage directly. Upload and delete URLs are refused for read-only buckets, during maintenance and to API tokens without write scope, and are audited; the requests made with them bypass the bucket's file type rules and trash. The object must exist, except for upload URLs. Time budget: server.timeouts.presign (default: 5s).
 //	@Tags			Objects
 //	@Accept			json
 //	@Produce		json
 //	@Param			bucket		path		string													true	"Name of the bucket containing the object"
 //	@Param			key			path		string													true	"Key (path) of the object"
-//	@Param			expires_in	query		int														false	"Expiration time in seconds for the pre-signed URL (default: 3600 seconds)"
+//	@Param			expires_in	query		int														false	"Expiration time in seconds for the pre-signed URL (default: garage.presign_default_ttl, max: garage.presign_max_ttl)"
+//	@Param			method		query		string													false	"Operation the URL allows: get (default), put or delete"
 //	@Success		200			{object}	models.APIResponse{data=models.PresignedURLResponse}	"Successfully generated pre-signed URL"
 //	@Failure		400			{object}	models.APIResponse{error=models.APIError}				"Invalid request parameters"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}				"Upload or delete URL for a read-only bucket, or beyond the token scope"
 //	@Failure		404			{object}	models.APIResponse{error=models.APIError}				"Object not found"
 //	@Failure		500			{object}	models.APIResponse{error=models.APIError}				"Failed to generate pre-signed URL"
+//	@Failure		503			{object}	models.APIResponse{error=models.APIError}				"Upload or delete URL during maintenance"
 //	@Router			/api/v1/buckets/{bucket}/objects/{key}/presigned-url [get]
 func (h *ObjectHandler) GetPresignedURL(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameters
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 
 	// Get object key from locals (set by route handler) or from params
 	key, ok := c.Locals("objectKey").(string)
@@ -330,79 +1041,152 @@ func (h *ObjectHandler) GetPresignedURL(c fiber.Ctx) error {
 		)
 	}
 
-	// Get expiration time from query parameter (default: 1 hour)
-	expiresInStr := c.Query("expires_in", "3600")
-	expiresIn, err := strconv.ParseInt(expiresInStr, 10, 64)
+	// Get expiration time from query parameter, bounded by the configured maximum
+	expiresIn, clamped, err := h.presignExpiry(c)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(
-			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid expiration time: "+err.Error()),
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
 		)
 	}
 
-	// Validate expiration time (1 second to 7 days)
-	if expiresIn <= 0 || expiresIn > 604800 { // Max 7 days
+	method := strings.ToUpper(c.Query("method", services.PresignMethodGet))
+	switch method {
+	case services.PresignMethodGet:
+	case services.PresignMethodPut, services.PresignMethodDelete:
+		// The route is a read, so the checks the middlewares apply to changes are made here
+		if rejected, err := h.rejectPresignedWrite(c, bucketName, method); rejected {
+			return err
+		}
+	default:
 		return c.Status(fiber.StatusBadRequest).JSON(
-			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid expiration time (must be between 1 and 604800 seconds)"),
+			models.ErrorResponse(models.ErrCodeBadRequest, "method must be get, put or delete"),
 		)
 	}
 
-	// Check if object exists
-	exists, err := h.s3Service.ObjectExists(ctx, bucketName, key)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			models.ErrorResponse(models.ErrCodeInternalError, "Failed to check object existence: "+err.Error()),
-		)
-	}
+	// Check if object exists; an upload URL may create it
+	if method != services.PresignMethodPut {
+		exists, err := h.s3Service.ObjectExists(ctx, bucketName, key)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to check object existence: "+err.Error()),
+			)
+		}
 
-	if !exists {
-		return c.Status(fiber.StatusNotFound).JSON(
-			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found"),
-		)
+		if !exists {
+			return c.Status(fiber.StatusNotFound).JSON(
+				models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found"),
+			)
+		}
 	}
 
 	// Generate pre-signed URL
-	url, err := h.s3Service.GetPresignedURL(ctx, bucketName, key, time.Duration(expiresIn)*time.Second)
+	url, err := h.s3Service.GetPresignedURL(ctx, bucketName, key, method, expiresIn)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeInternalError, "Failed to generate pre-signed URL: "+err.Error()),
 		)
 	}
 
+	if method != services.PresignMethodGet {
+		event := newAuditEvent(c, "object.presign_"+strings.ToLower(method), bucketName+"/"+key)
+		event.Details = map[string]string{"expiresIn": strconv.FormatInt(int64(expiresIn/time.Second), 10)}
+		h.auditLog.Record(event)
+	}
+
 	response := models.PresignedURLResponse{
-		URL:       url,
-		ExpiresIn: expiresIn,
-		Bucket:    bucketName,
-		Key:       key,
+		URL:          url,
+		Method:       method,
+		ExpiresIn:    int64(expiresIn / time.Second),
+		ExpiresAt:    h.clock.Now().Add(expiresIn).UTC(),
+		MaxExpiresIn: int64(h.garageConfig.PresignMaxTTL / time.Second),
+		Clamped:      clamped,
+		Bucket:       bucketName,
+		Key:          key,
 	}
 
 	return c.JSON(models.SuccessResponse(response))
 }
 
+// rejectPresignedWrite refuses upload and delete URLs where the change they allow would be
+// refused: read-only buckets, maintenance and API tokens without write scope. It returns
+// whether the URL was refused, along with the response.
+func (h *ObjectHandler) rejectPresignedWrite(c fiber.Ctx, bucketName, method string) (bool, error) {
+	if !tokenScopeAllows(c, bucketName, auth.VerbWrite) {
+		return true, c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Token scope does not allow write access to bucket "+bucketName),
+		)
+	}
+	if h.settingsStore.IsReadOnly(bucketName) {
+		return true, c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeBucketReadOnly, "Bucket "+bucketName+" is read-only"),
+		)
+	}
+	if _, active := h.settingsStore.Maintenance(); active {
+		return true, c.Status(fiber.StatusServiceUnavailable).JSON(
+			models.ErrorResponse(models.ErrCodeMaintenance, "Presigned "+strings.ToLower(method)+" URLs cannot be generated during maintenance"),
+		)
+	}
+	return false, nil
+}
+
+// presignExpiry reads the expires_in query parameter, applying the configured default and
+// maximum. Above the maximum the expiry is rejected in strict mode and clamped otherwise.
+func (h *ObjectHandler) presignExpiry(c fiber.Ctx) (time.Duration, bool, error) {
+	maxTTL := h.garageConfig.PresignMaxTTL
+	maxSeconds := int64(maxTTL / time.Second)
+
+	expiresInStr := c.Query("expires_in")
+	if expiresInStr == "" {
+		return h.garageConfig.PresignDefaultTTL, false, nil
+	}
+
+	expiresIn, err := strconv.ParseInt(expiresInStr, 10, 64)
+	if err != nil || expiresIn <= 0 {
+		return 0, false, fmt.Errorf("invalid expiration time (must be between 1 and %d seconds)", maxSeconds)
+	}
+
+	if expiresIn > maxSeconds {
+		if h.garageConfig.PresignStrict {
+			return 0, false, fmt.Errorf("invalid expiration time (must be between 1 and %d seconds)", maxSeconds)
+		}
+		return maxTTL, true, nil
+	}
+
+	return time.Duration(expiresIn) * time.Second, false, nil
+}
+
 // DeleteMultipleObjects deletes multiple objects from a bucket
 //
 //	@Summary		Delete multiple objects from bucket
-//	@Description	Deletes multiple objects stored in the specified bucket
+//	@Description	Deletes multiple objects stored in the specified bucket, or moves them to the trash when the bucket has trash enabled
 //	@Tags			Objects
 //	@Accept			json
 //	@Produce		json
-//	@Param			bucket	path		string															true	"Name of the bucket containing the objects"
-//	@Param			request	body		object{keys=[]string,prefix=string}								true	"List of object keys to delete and optional prefix for path context"
-//	@Success		200		{object}	models.APIResponse{data=models.ObjectDeleteMultipleResponse}	"Successfully deleted the objects"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}						"Invalid request parameters"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}						"Bucket not found"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}						"Failed to delete objects"
+//	@Param			bucket		path		string																true	"Name of the bucket containing the objects"
+//	@Param			request		body		object{keys=[]string,prefix=string}									true	"List of object keys to delete and optional prefix for path context"
+//	@Param			permanent	query		bool																false	"Admin only: delete permanently even if trash is enabled"
+//	@Success		200			{object}	models.APIResponse{data=models.ObjectDeleteMultipleResponse}		"Successfully deleted the objects"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}							"Invalid request parameters"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}							"Permanent deletion or deletion of trashed objects requested by a non-admin"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}							"Bucket not found"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}							"Failed to delete objects"
+//	@Failure		503			{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
 //	@Router			/api/v1/buckets/{bucket}/objects/delete-multiple [post]
 func (h *ObjectHandler) DeleteMultipleObjects(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameter
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 	if bucketName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(
 			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
 		)
 	}
 
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
 	// Parse request body to get keys and optional prefix
 	var req struct {
 		Keys   []string `json:"keys"`
@@ -420,10 +1204,18 @@ func (h *ObjectHandler) DeleteMultipleObjects(c fiber.Ctx) error {
 		)
 	}
 
-	// Delete multiple objects
-	if err := h.s3Service.DeleteMultipleObjects(ctx, bucketName, req.Keys); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete objects: "+err.Error()),
+	// Bypassing the trash is reserved for admins, as is deleting trashed objects, which
+	// are deleted permanently
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	permanent := c.Query("permanent") == "true"
+	if permanent && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Permanent deletion is restricted to administrators"),
+		)
+	}
+	if !isAdmin && slices.ContainsFunc(req.Keys, h.trashService.IsTrashKey) {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Deleting trashed objects is restricted to administrators"),
 		)
 	}
 
@@ -433,34 +1225,174 @@ func (h *ObjectHandler) DeleteMultipleObjects(c fiber.Ctx) error {
 		Keys:    req.Keys,
 	}
 
+	if !permanent && h.trashService.Enabled(bucketName) {
+		// Move the objects to the trash one by one; there is no batch server-side copy
+		for _, key := range req.Keys {
+			if _, err := h.trashService.MoveToTrash(ctx, bucketName, key); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(
+					models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete object "+key+": "+err.Error()),
+				)
+			}
+		}
+		response.Trashed = true
+	} else if err := h.s3Service.DeleteMultipleObjects(ctx, bucketName, req.Keys); err != nil {
+		// Delete multiple objects
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete objects: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// DeletePrefix deletes every object under a prefix
+//
+//	@Summary		Delete all objects under a prefix
+//	@Description	Deletes every object whose key starts with the prefix, or moves them to the trash when the bucket has trash enabled. When more objects than server.delete_confirm_threshold would be deleted, the first call answers 428 with a confirmation token; repeating the request with confirm_token executes it.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket			path		string																true	"Name of the bucket containing the objects"
+//	@Param			request			body		object{prefix=string}												true	"Prefix of the keys to delete, usually a folder ending with /"
+//	@Param			permanent		query		bool																false	"Admin only: delete permanently even if trash is enabled"
+//	@Param			confirm_token	query		string																false	"Confirmation token returned by a previous 428 response"
+//	@Success		200				{object}	models.APIResponse{data=models.ObjectDeletePrefixResponse}			"Successfully deleted the objects"
+//	@Failure		400				{object}	models.APIResponse{error=models.APIError}							"Invalid request parameters"
+//	@Failure		403				{object}	models.APIResponse{error=models.APIError}							"Permanent deletion or deletion of trashed objects requested by a non-admin"
+//	@Failure		428				{object}	models.APIResponse{data=models.DeleteConfirmation}					"Confirmation required"
+//	@Failure		500				{object}	models.APIResponse{error=models.APIError}							"Failed to delete objects"
+//	@Failure		503				{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/delete-prefix [post]
+func (h *ObjectHandler) DeletePrefix(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	var req struct {
+		Prefix string `json:"prefix"`
+	}
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	// Emptying a whole bucket has its own endpoint
+	if req.Prefix == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "A prefix is required"),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	// Bypassing the trash is reserved for admins, as is deleting trashed objects, which
+	// are deleted permanently
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	permanent := c.Query("permanent") == "true"
+	if permanent && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Permanent deletion is restricted to administrators"),
+		)
+	}
+	if !isAdmin && h.trashService.CoversTrash(req.Prefix) {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Deleting trashed objects is restricted to administrators"),
+		)
+	}
+
+	objects, bytes, err := h.s3Service.PrefixUsage(ctx, bucketName, req.Prefix)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeListFailed, "Failed to count objects: "+err.Error()),
+		)
+	}
+
+	if objects > int64(h.deleteConfirmThreshold) {
+		if confirmed, err := confirmDestructive(c, services.ConfirmDeletePrefix, bucketName+"/"+req.Prefix, objects, bytes); !confirmed {
+			return err
+		}
+	}
+
+	response := models.ObjectDeletePrefixResponse{
+		Bucket: bucketName,
+		Prefix: req.Prefix,
+	}
+
+	if !permanent && h.trashService.Enabled(bucketName) {
+		// Move the objects to the trash one by one; there is no batch server-side copy
+		err = h.s3Service.ListObjectsPages(ctx, bucketName, req.Prefix, true, func(page []models.ObjectInfo, _ []string) error {
+			for _, obj := range page {
+				if _, err := h.trashService.MoveToTrash(ctx, bucketName, obj.Key); err != nil {
+					return fmt.Errorf("failed to delete object %s: %w", obj.Key, err)
+				}
+				response.Deleted++
+			}
+			return nil
+		})
+		response.Trashed = true
+	} else {
+		response.Deleted, err = h.s3Service.DeletePrefix(ctx, bucketName, req.Prefix)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeDeleteFailed, "Failed to delete objects: "+err.Error()),
+		)
+	}
+
 	return c.JSON(models.SuccessResponse(response))
 }
 
 // UploadMultipleObjects uploads multiple objects to a bucket
 //
 //	@Summary		Upload multiple objects to bucket
-//	@Description	Uploads multiple objects to the specified bucket using multipart/form-data. Accepts unlimited number of files and handles them in a loop.
+//	@Description	Uploads multiple objects to the specified bucket using multipart/form-data. Accepts unlimited number of files and handles them in a loop. With compress=gzip the objects are stored compressed, as for single uploads. Time budget: server.timeouts.transfer (default: unlimited).
 //	@Tags			Objects
 //	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			bucket	path		string															true	"Name of the bucket to upload the objects to"
-//	@Param			files	formData	file															true	"Files to upload (can be multiple)"
-//	@Success		201		{object}	models.APIResponse{data=models.ObjectUploadMultipleResponse}	"Objects uploaded successfully (including partial failures)"
-//	@Failure		400		{object}	models.APIResponse{error=models.APIError}						"Invalid request parameters"
-//	@Failure		404		{object}	models.APIResponse{error=models.APIError}						"Bucket not found"
-//	@Failure		500		{object}	models.APIResponse{error=models.APIError}						"Failed to upload objects"
+//	@Param			bucket		path		string																true	"Name of the bucket to upload the objects to"
+//	@Param			files		formData	file																true	"Files to upload (can be multiple)"
+//	@Param			compress	query		string																false	"Store the objects compressed: gzip"
+//	@Param			force		query		bool																false	"Admin only: upload even if the bucket's file type rules refuse a file (audited)"
+//	@Success		201			{object}	models.APIResponse{data=models.ObjectUploadMultipleResponse}		"Objects uploaded successfully (including partial failures)"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}							"Invalid request parameters"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}							"force requested by a non-admin"
+//	@Failure		404			{object}	models.APIResponse{error=models.APIError}							"Bucket not found"
+//	@Failure		415			{object}	models.APIResponse{error=models.APIError}							"File type refused by the bucket's allowedContentTypes or blockedExtensions"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}							"Failed to upload objects"
+//	@Failure		503			{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
 //	@Router			/api/v1/buckets/{bucket}/objects/upload-multiple [post]
 func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 	ctx := c.Context()
 
 	// Get bucket name from URL parameter
-	bucketName := c.Params("bucket")
+	bucketName := bucketParam(c)
 	if bucketName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(
 			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
 		)
 	}
 
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	compress, err := parseCompress(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+
+	// File type rules may only be overridden by admins
+	force := c.Query("force") == "true"
+	if isAdmin, _ := c.Locals("isAdmin").(bool); force && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Forcing uploads past file type rules is restricted to administrators"),
+		)
+	}
+
 	// Parse multipart form to get all files
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -483,6 +1415,15 @@ func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 		ContentType string
 	}, len(files))
 
+	// Reject invalid keys before anything is uploaded
+	for _, fileHeader := range files {
+		if err := validateObjectKey(fileHeader.Filename); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+			)
+		}
+	}
+
 	// Open all files and prepare for upload
 	for i, fileHeader := range files {
 		file, err := fileHeader.Open()
@@ -500,6 +1441,10 @@ func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 			contentType = "application/octet-stream"
 		}
 
+		if rejected, err := h.rejectBlockedFileType(c, bucketName, key, contentType, file, force); rejected {
+			return err
+		}
+
 		uploadFiles[i] = struct {
 			Key         string
 			Body        io.Reader
@@ -512,7 +1457,7 @@ func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 	}
 
 	// Upload all files using the service method
-	results := h.s3Service.UploadMultipleObjects(ctx, bucketName, uploadFiles)
+	results := h.s3Service.UploadMultipleObjects(ctx, bucketName, uploadFiles, compress)
 
 	// Process results and categorize successes and failures
 	var successFiles []models.ObjectUploadResult
@@ -520,13 +1465,16 @@ func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 	successCount := 0
 	failureCount := 0
 
+	var uploadedBytes int64
 	for _, result := range results {
 		if result.Success {
 			successCount++
+			uploadedBytes += result.Size
 			successFiles = append(successFiles, models.ObjectUploadResult{
 				Key:         result.Key,
 				ETag:        result.ETag,
 				Size:        result.Size,
+				StoredSize:  result.StoredSize,
 				ContentType: result.ContentType,
 			})
 		} else {
@@ -539,6 +1487,8 @@ func (h *ObjectHandler) UploadMultipleObjects(c fiber.Ctx) error {
 		}
 	}
 
+	h.transferStats.Add(transferUser(c), services.TransferUpload, uploadedBytes)
+
 	response := models.ObjectUploadMultipleResponse{
 		Bucket:       bucketName,
 		TotalFiles:   len(files),
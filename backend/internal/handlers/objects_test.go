@@ -0,0 +1,531 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestGetObjectInlineSafelist(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("photos")
+	env.PutObject("photos", "page.html", "text/html", []byte("<script>alert(1)</script>"))
+	env.PutObject("photos", "drawing.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+	env.PutObject("photos", "app.js", "application/javascript", []byte("alert(1)"))
+	env.PutObject("photos", "cat.png", "image/png", []byte("\x89PNG\r\n\x1a\n"))
+
+	tests := []struct {
+		name           string
+		target         string
+		user           string
+		wantStatus     int
+		wantAttachment bool
+	}{
+		{name: "html is downloaded", target: "page.html", wantStatus: http.StatusOK, wantAttachment: true},
+		{name: "svg is downloaded", target: "drawing.svg", wantStatus: http.StatusOK, wantAttachment: true},
+		{name: "javascript is downloaded", target: "app.js", wantStatus: http.StatusOK, wantAttachment: true},
+		{name: "png renders inline", target: "cat.png", wantStatus: http.StatusOK},
+		{name: "download forces attachment", target: "cat.png?download=true", wantStatus: http.StatusOK, wantAttachment: true},
+		{name: "inline_unsafe renders html inline for admins", target: "page.html?inline_unsafe=true", wantStatus: http.StatusOK},
+		{name: "inline_unsafe renders svg inline for admins", target: "drawing.svg?inline_unsafe=true", wantStatus: http.StatusOK},
+		{name: "inline_unsafe is forbidden to users", target: "page.html?inline_unsafe=true", user: "alice", wantStatus: http.StatusForbidden},
+		{name: "users still download html", target: "page.html", user: "alice", wantStatus: http.StatusOK, wantAttachment: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.user != "" {
+				headers = append(headers, testUserHeader, tt.user)
+			}
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/photos/objects/"+tt.target, nil, headers...)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if code := errorCode(t, resp); code != models.ErrCodeForbidden {
+					t.Errorf("error code = %q, want %q", code, models.ErrCodeForbidden)
+				}
+				return
+			}
+
+			if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			disposition := resp.Header.Get("Content-Disposition")
+			if attachment := strings.HasPrefix(disposition, "attachment"); attachment != tt.wantAttachment {
+				t.Errorf("Content-Disposition = %q, want attachment %v", disposition, tt.wantAttachment)
+			}
+		})
+	}
+}
+
+func TestGetObjectRanges(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("media")
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	env.PutObject("media", "movie.mkv", "application/octet-stream", data)
+
+	tests := []struct {
+		rangeHeader      string
+		wantContentRange string
+		wantStart        int
+		wantEnd          int
+	}{
+		{rangeHeader: "bytes=0-99", wantContentRange: "bytes 0-99/1000", wantStart: 0, wantEnd: 99},
+		{rangeHeader: "bytes=900-", wantContentRange: "bytes 900-999/1000", wantStart: 900, wantEnd: 999},
+		{rangeHeader: "bytes=-10", wantContentRange: "bytes 990-999/1000", wantStart: 990, wantEnd: 999},
+		{rangeHeader: "bytes=500-5000", wantContentRange: "bytes 500-999/1000", wantStart: 500, wantEnd: 999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rangeHeader, func(t *testing.T) {
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/media/objects/movie.mkv", nil, "Range", tt.rangeHeader)
+
+			if resp.StatusCode != http.StatusPartialContent {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusPartialContent)
+			}
+			if got := resp.Header.Get("Content-Range"); got != tt.wantContentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantContentRange)
+			}
+			if got := resp.Header.Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", got)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "video/x-matroska" {
+				t.Errorf("Content-Type = %q, want video/x-matroska", got)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			want := data[tt.wantStart : tt.wantEnd+1]
+			if resp.ContentLength != int64(len(want)) {
+				t.Errorf("Content-Length = %d, want %d", resp.ContentLength, len(want))
+			}
+			if !bytes.Equal(body, want) {
+				t.Errorf("body is %d bytes not matching bytes %d-%d of the object", len(body), tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	t.Run("unsatisfiable", func(t *testing.T) {
+		resp := env.request(t, http.MethodGet, "/api/v1/buckets/media/objects/movie.mkv", nil, "Range", "bytes=1000-")
+
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusRequestedRangeNotSatisfiable)
+		}
+		if got := resp.Header.Get("Content-Range"); got != "bytes */1000" {
+			t.Errorf("Content-Range = %q, want %q", got, "bytes */1000")
+		}
+	})
+
+	t.Run("whole object", func(t *testing.T) {
+		resp := env.request(t, http.MethodGet, "/api/v1/buckets/media/objects/movie.mkv", nil)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if got := resp.Header.Get("Accept-Ranges"); got != "bytes" {
+			t.Errorf("Accept-Ranges = %q, want bytes", got)
+		}
+		if resp.ContentLength != int64(len(data)) {
+			t.Errorf("Content-Length = %d, want %d", resp.ContentLength, len(data))
+		}
+	})
+}
+
+func TestDeleteTrashedObjectsRequiresAdmin(t *testing.T) {
+	const trashed = ".trash/20260101T000000.000000000Z/old.txt"
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   interface{}
+		user   string
+		want   int
+		gone   []string
+	}{
+		{name: "user deletes a trashed object", method: http.MethodDelete, target: "objects/" + trashed, user: "alice", want: http.StatusForbidden},
+		{name: "user deletes trashed objects among others", method: http.MethodPost, target: "objects/delete-multiple", body: map[string]interface{}{"keys": []string{"report.txt", trashed}}, user: "alice", want: http.StatusForbidden},
+		{name: "user deletes the trash prefix", method: http.MethodPost, target: "objects/delete-prefix", body: map[string]string{"prefix": ".trash/"}, user: "alice", want: http.StatusForbidden},
+		{name: "user deletes a prefix covering the trash", method: http.MethodPost, target: "objects/delete-prefix", body: map[string]string{"prefix": ".tr"}, user: "alice", want: http.StatusForbidden},
+		{name: "user deletes permanently", method: http.MethodDelete, target: "objects/report.txt?permanent=true", user: "alice", want: http.StatusForbidden},
+		{name: "user trashes a live object", method: http.MethodDelete, target: "objects/report.txt", user: "alice", want: http.StatusOK, gone: []string{"report.txt"}},
+		{name: "admin deletes a trashed object", method: http.MethodDelete, target: "objects/" + trashed, want: http.StatusOK, gone: []string{trashed}},
+		{name: "admin deletes the trash prefix", method: http.MethodPost, target: "objects/delete-prefix", body: map[string]string{"prefix": ".trash/"}, want: http.StatusOK, gone: []string{trashed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.addBucket("docs")
+			if err := env.settings.SetBucketSettings("docs", models.BucketSettings{TrashEnabled: true}); err != nil {
+				t.Fatalf("SetBucketSettings failed: %v", err)
+			}
+			env.PutObject("docs", "report.txt", "text/plain", []byte("report"))
+			env.PutObject("docs", trashed, "text/plain", []byte("old"))
+
+			var headers []string
+			if tt.user != "" {
+				headers = append(headers, testUserHeader, tt.user)
+			}
+			target := "/api/v1/buckets/docs/" + tt.target
+			var resp *http.Response
+			if tt.body != nil {
+				resp = env.requestJSON(t, tt.method, target, tt.body, headers...)
+			} else {
+				resp = env.request(t, tt.method, target, nil, headers...)
+			}
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusForbidden {
+				if code := errorCode(t, resp); code != models.ErrCodeForbidden {
+					t.Errorf("error code = %q, want %q", code, models.ErrCodeForbidden)
+				}
+			}
+			for _, key := range []string{"report.txt", trashed} {
+				_, exists := env.Object("docs", key)
+				if wantGone := slices.Contains(tt.gone, key); exists == wantGone {
+					t.Errorf("%s exists = %v, want %v", key, exists, !wantGone)
+				}
+			}
+		})
+	}
+}
+
+func TestCRLFKeysCannotSplitResponses(t *testing.T) {
+	const key = "evil\r\nSet-Cookie: session=stolen\r\n\r\n.txt"
+	const escaped = "evil%0D%0ASet-Cookie:%20session=stolen%0D%0A%0D%0A.txt"
+
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	// Stored directly in Garage, as the proxy refuses such keys
+	env.PutObject("docs", key, "text/plain", []byte("data"))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		t.Run(method, func(t *testing.T) {
+			resp := env.request(t, method, "/api/v1/buckets/docs/objects/"+escaped+"?download=true", nil)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if got := resp.Header.Values("Set-Cookie"); len(got) > 0 {
+				t.Errorf("response carries injected Set-Cookie %q", got)
+			}
+			for name, values := range resp.Header {
+				for _, value := range values {
+					if strings.ContainsAny(value, "\r\n") {
+						t.Errorf("header %s = %q contains CR or LF", name, value)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("upload", func(t *testing.T) {
+		resp := env.request(t, http.MethodPut, "/api/v1/buckets/docs/objects/new%0D%0AX-Injected:%201.txt", strings.NewReader("data"))
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+		if code := errorCode(t, resp); code != models.ErrCodeInvalidObjectKey {
+			t.Errorf("error code = %q, want %q", code, models.ErrCodeInvalidObjectKey)
+		}
+		if keys := env.ObjectKeys("docs"); len(keys) != 1 {
+			t.Errorf("bucket holds %q, want only the pre-existing object", keys)
+		}
+	})
+}
+
+func TestListObjectsPrefixesBeforeObjects(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+	env.PutObject("docs", "folder/b.txt", "text/plain", []byte("b"))
+
+	resp := env.request(t, http.MethodGet, "/api/v1/buckets/docs/objects/", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	prefixes, objects := bytes.Index(body, []byte(`"prefixes"`)), bytes.Index(body, []byte(`"objects"`))
+	if prefixes < 0 || objects < 0 || prefixes > objects {
+		t.Errorf("prefixes are not listed before objects in %s", body)
+	}
+}
+
+func TestDownloadErrorsFollowAccept(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("photos")
+	env.PutObject("photos", "cat.png", "image/png", []byte("\x89PNG\r\n\x1a\n"))
+
+	tests := []struct {
+		name       string
+		target     string
+		accept     string
+		wantStatus int
+		wantJSON   bool
+	}{
+		{name: "no accept header", target: "missing.png", wantStatus: http.StatusNotFound, wantJSON: true},
+		{name: "api client", target: "missing.png", accept: "application/json", wantStatus: http.StatusNotFound, wantJSON: true},
+		{name: "api client among others", target: "missing.png", accept: "text/html, application/json;q=0.9", wantStatus: http.StatusNotFound, wantJSON: true},
+		{name: "img tag", target: "missing.png", accept: "image/avif,image/webp,image/*,*/*;q=0.8", wantStatus: http.StatusNotFound},
+		{name: "browser navigation", target: "missing.png", accept: "text/html,application/xhtml+xml,*/*;q=0.8", wantStatus: http.StatusNotFound},
+		{name: "unsatisfiable range for img tag", target: "cat.png", accept: "image/*", wantStatus: http.StatusRequestedRangeNotSatisfiable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := []string{fiber.HeaderRange, "bytes=1000-"}
+			if tt.accept != "" {
+				headers = append(headers, fiber.HeaderAccept, tt.accept)
+			}
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/photos/objects/"+tt.target, nil, headers...)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+
+			if tt.wantJSON {
+				if got := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(got, fiber.MIMEApplicationJSON) {
+					t.Errorf("Content-Type = %q, want JSON", got)
+				}
+				if code := errorCode(t, resp); code != models.ErrCodeObjectNotFound {
+					t.Errorf("error code = %q, want %q", code, models.ErrCodeObjectNotFound)
+				}
+				return
+			}
+
+			if got := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(got, fiber.MIMETextPlain) {
+				t.Errorf("Content-Type = %q, want plain text", got)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if want := fmt.Sprintf("%d %s", tt.wantStatus, http.StatusText(tt.wantStatus)); string(body) != want {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestLongKeys(t *testing.T) {
+	// 248 folders deep, 1000 bytes long; escaped, the multi-byte variant is 3 times as long
+	asciiKey := strings.Repeat("dir/", 248) + "file.txt"
+	unicodeKey := strings.Repeat("é/", 248) + "file.txt"
+	unicodeKey = strings.Repeat("é", (1000-len(unicodeKey))/2) + unicodeKey
+
+	for name, key := range map[string]string{"ascii": asciiKey, "multi-byte": unicodeKey} {
+		t.Run(name, func(t *testing.T) {
+			if len(key) > config.MaxObjectKeyLength || len(key) < 999 {
+				t.Fatalf("key is %d bytes", len(key))
+			}
+
+			env := newTestEnv(t)
+			env.addBucket("docs")
+			env.PutObject("docs", key, "text/plain", []byte("deep"))
+
+			base := "/api/v1/buckets/docs/objects/"
+			escaped := escapeKey(key)
+			parent := key[:strings.LastIndex(key, "/")+1]
+
+			t.Run("list", func(t *testing.T) {
+				resp := env.request(t, http.MethodGet, base+"?prefix="+url.QueryEscape(parent), nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+				var list models.ObjectListResponse
+				decodeAPIResponse(t, resp, &list)
+				if len(list.Objects) != 1 || list.Objects[0].Key != key {
+					t.Errorf("listed %+v, want the long key", list.Objects)
+				}
+			})
+
+			t.Run("download", func(t *testing.T) {
+				resp := env.request(t, http.MethodGet, base+escaped, nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+				if body, _ := io.ReadAll(resp.Body); string(body) != "deep" {
+					t.Errorf("body = %q, want %q", body, "deep")
+				}
+			})
+
+			t.Run("metadata", func(t *testing.T) {
+				resp := env.request(t, http.MethodGet, base+escaped+"/metadata", nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+				var info models.ObjectInfo
+				decodeAPIResponse(t, resp, &info)
+				if info.Key != key || info.Size != 4 {
+					t.Errorf("metadata = %+v, want the long key with 4 bytes", info)
+				}
+			})
+
+			t.Run("presign", func(t *testing.T) {
+				resp := env.request(t, http.MethodGet, base+escaped+"/presign", nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+				var presigned models.PresignedURLResponse
+				decodeAPIResponse(t, resp, &presigned)
+				parsed, err := url.Parse(presigned.URL)
+				if err != nil {
+					t.Fatalf("presigned URL %q does not parse: %v", presigned.URL, err)
+				}
+				if !strings.HasSuffix(parsed.Path, "/docs/"+key) {
+					t.Errorf("presigned URL path %q does not end with the long key", parsed.Path)
+				}
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				resp := env.request(t, http.MethodDelete, base+escaped, nil)
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+				if _, exists := env.Object("docs", key); exists {
+					t.Error("long key was not deleted")
+				}
+			})
+		})
+	}
+}
+
+// escapeKey escapes every segment of an object key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestListObjectsEmptyMissingAndDeniedBuckets(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("empty")
+	env.AddBucket("keyless")
+	env.addBucket("denied")
+	env.DenyKey("GK-denied")
+
+	tests := []struct {
+		name       string
+		bucket     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "empty bucket", bucket: "empty", wantStatus: http.StatusOK},
+		{name: "missing bucket", bucket: "typo", wantStatus: http.StatusNotFound, wantCode: models.ErrCodeBucketNotFound},
+		{name: "bucket without a usable key", bucket: "keyless", wantStatus: http.StatusNotFound, wantCode: models.ErrCodeBucketNotFound},
+		{name: "bucket denying access", bucket: "denied", wantStatus: http.StatusForbidden, wantCode: models.ErrCodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/"+tt.bucket+"/objects/", nil)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, resp); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+
+			// Empty arrays, not null, so clients can tell an empty bucket from a failure
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			for _, field := range []string{`"objects":[]`, `"prefixes":[]`} {
+				if !bytes.Contains(body, []byte(field)) {
+					t.Errorf("response lacks %s: %s", field, body)
+				}
+			}
+		})
+	}
+}
+
+// TestPresignedURLExpiresByClock dates the expiry of a shared link from the clock it was
+// signed at
+func TestPresignedURLExpiresByClock(t *testing.T) {
+	env := newTestEnv(t)
+	env.clock.Set(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC))
+	env.addBucket("docs")
+	env.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+
+	maxTTL := env.cfg.Garage.PresignMaxTTL
+	for _, tt := range []struct {
+		query string
+		want  time.Duration
+	}{
+		{query: "", want: env.cfg.Garage.PresignDefaultTTL},
+		{query: "?expires_in=60", want: time.Minute},
+		{query: fmt.Sprintf("?expires_in=%d", int64(maxTTL/time.Second)), want: maxTTL},
+	} {
+		t.Run("expires_in"+tt.query, func(t *testing.T) {
+			resp := env.request(t, http.MethodGet, "/api/v1/buckets/docs/objects/a.txt/presign"+tt.query, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			var presigned models.PresignedURLResponse
+			decodeAPIResponse(t, resp, &presigned)
+			if want := env.clock.Now().Add(tt.want); !presigned.ExpiresAt.Equal(want) {
+				t.Errorf("expiresAt = %v, want %v", presigned.ExpiresAt, want)
+			}
+		})
+	}
+}
+
+func TestSearchDoesNotShadowObjects(t *testing.T) {
+	env := newTestEnv(t)
+	env.addBucket("docs")
+	env.PutObject("docs", "search", "text/plain", []byte("an object named search"))
+	env.PutObject("docs", "dir/search-notes.txt", "text/plain", []byte("notes"))
+
+	resp := env.request(t, http.MethodGet, "/api/v1/buckets/docs/objects/search", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "an object named search" {
+		t.Errorf("downloaded %q, want the object named search", body)
+	}
+
+	resp = env.request(t, http.MethodGet, "/api/v1/buckets/docs/search?q=notes", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("search status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var list models.ObjectListResponse
+	decodeAPIResponse(t, resp, &list)
+	if len(list.Objects) != 1 || list.Objects[0].Key != "dir/search-notes.txt" {
+		t.Errorf("found %+v, want dir/search-notes.txt", list.Objects)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetObjectTags returns the tags of an object
+//
+//	@Summary		Get object tags
+//	@Description	Returns the S3 tags of an object, empty when it has none. Tags are also included in the object metadata. Answers 501 when Garage does not implement object tagging.
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			bucket	path		string												true	"Name of the bucket containing the object"
+//	@Param			key		path		string												true	"Key (path) of the object"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectTagsResponse}	"Object tags"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Object not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to get the tags"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}			"Garage does not support object tagging"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/tags [get]
+func (h *ObjectHandler) GetObjectTags(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	objectTags, err := h.s3Service.GetObjectTags(c.Context(), bucketName, key)
+	if err != nil {
+		return tagsError(c, err, "Failed to get object tags: ")
+	}
+
+	return c.JSON(models.SuccessResponse(models.ObjectTagsResponse{
+		Bucket: bucketName,
+		Key:    key,
+		Tags:   objectTags,
+	}))
+}
+
+// PutObjectTags replaces the tags of an object
+//
+//	@Summary		Set object tags
+//	@Description	Replaces every S3 tag of an object with the given ones. S3 allows at most 10 tags per object, with keys of up to 128 characters and values of up to 256. Answers 501 when Garage does not implement object tagging.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string												true	"Name of the bucket containing the object"
+//	@Param			key		path		string												true	"Key (path) of the object"
+//	@Param			request	body		models.ObjectTagsRequest							true	"New tags"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectTagsResponse}	"Tags saved"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid request body or tags"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Object not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to set the tags"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}			"Garage does not support object tagging"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/tags [put]
+func (h *ObjectHandler) PutObjectTags(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	var req models.ObjectTagsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if req.Tags == nil {
+		req.Tags = map[string]string{}
+	}
+
+	if err := h.s3Service.PutObjectTags(c.Context(), bucketName, key, req.Tags); err != nil {
+		return tagsError(c, err, "Failed to set object tags: ")
+	}
+
+	return c.JSON(models.SuccessResponse(models.ObjectTagsResponse{
+		Bucket: bucketName,
+		Key:    key,
+		Tags:   req.Tags,
+	}))
+}
+
+// DeleteObjectTags removes every tag of an object
+//
+//	@Summary		Delete object tags
+//	@Description	Removes every S3 tag of an object. Answers 501 when Garage does not implement object tagging.
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			bucket	path		string												true	"Name of the bucket containing the object"
+//	@Param			key		path		string												true	"Key (path) of the object"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectTagsResponse}	"Tags removed"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}			"Object not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to delete the tags"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}			"Garage does not support object tagging"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/tags [delete]
+func (h *ObjectHandler) DeleteObjectTags(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	if err := h.s3Service.DeleteObjectTags(c.Context(), bucketName, key); err != nil {
+		return tagsError(c, err, "Failed to delete object tags: ")
+	}
+
+	return c.JSON(models.SuccessResponse(models.ObjectTagsResponse{
+		Bucket: bucketName,
+		Key:    key,
+		Tags:   map[string]string{},
+	}))
+}
+
+// tagsError answers a failed tagging call with the status matching its cause
+func tagsError(c fiber.Ctx, err error, message string) error {
+	var invalid *services.InvalidTagsError
+	switch {
+	case errors.As(err, &invalid):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, invalid.Error()),
+		)
+	case services.IsNotImplemented(err):
+		return c.Status(fiber.StatusNotImplemented).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, "Garage does not support object tagging"),
+		)
+	case services.IsObjectNotFound(err):
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found"),
+		)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(
+		models.ErrorResponse(models.ErrCodeInternalError, message+err.Error()),
+	)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// pageParams holds the parsed paging parameters of a list request
+type pageParams struct {
+	Limit  int
+	Offset int
+}
+
+// parsePageParams reads the page size from limitParam and the offset from "offset".
+// A missing page size falls back to defaultLimit, or to the configured default when that
+// is zero; page sizes above the configured maximum are clamped to it.
+func parsePageParams(c fiber.Ctx, cfg *config.PaginationConfig, limitParam string, defaultLimit int) (pageParams, error) {
+	params := pageParams{Limit: defaultLimit}
+	if params.Limit <= 0 {
+		params.Limit = cfg.DefaultPageSize
+	}
+
+	if value := c.Query(limitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return params, fmt.Errorf("%s must be a positive integer", limitParam)
+		}
+		params.Limit = limit
+	}
+	params.Limit = min(params.Limit, cfg.MaxPageSize)
+
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return params, errors.New("offset must be a non-negative integer")
+		}
+		params.Offset = offset
+	}
+
+	return params, nil
+}
+
+// paginate returns one page of items along with its paging metadata
+func paginate[T any](items []T, params pageParams) ([]T, models.Pagination) {
+	page := models.Pagination{
+		Limit:  params.Limit,
+		Offset: params.Offset,
+		Total:  len(items),
+	}
+
+	start := min(params.Offset, len(items))
+	end := min(start+params.Limit, len(items))
+	if end < len(items) {
+		page.HasMore = true
+		page.NextOffset = &end
+	}
+
+	return items[start:end], page
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Bytes of text objects returned by previews
+const (
+	defaultPreviewLimit = 64 << 10
+	maxPreviewLimit     = 1 << 20
+)
+
+// textContentTypes lists the non-text/* media types that are previewed as text
+var textContentTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"application/x-javascript",
+	"application/ecmascript",
+	"application/yaml",
+	"application/x-yaml",
+	"application/toml",
+	"application/x-sh",
+	"application/sql",
+	"application/csv",
+	"application/x-ndjson",
+}
+
+// GetObjectPreview returns a bounded preview of an object
+//
+//	@Summary		Get an object preview
+//	@Description	Returns what the preview pane shows of an object without downloading it whole. Text objects (text/*, JSON, XML, YAML and similar, and objects of unknown type whose start is valid UTF-8) get their first limit bytes, cut at a character boundary; truncated tells whether there is more. PDFs get their version, page count and document information, read from their first and last bytes. Other objects get kind "none". The content type is resolved from the key's extension when Garage only knows it as a generic binary type.
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			bucket	path		string											true	"Name of the bucket containing the object"
+//	@Param			key		path		string											true	"Key (path) of the object"
+//	@Param			limit	query		int												false	"Bytes of text to return, up to 1048576 (default: 65536)"
+//	@Success		200		{object}	models.APIResponse{data=models.ObjectPreview}	"Preview of the object"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}		"Invalid limit or object key"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}		"Object not found"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}		"Failed to read the object"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/preview [get]
+func (h *ObjectHandler) GetObjectPreview(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultPreviewLimit)))
+	if err != nil || limit < 1 || limit > maxPreviewLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPreviewLimit)),
+		)
+	}
+
+	info, err := h.s3Service.GetObjectMetadata(ctx, bucketName, key)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found: "+err.Error()),
+		)
+	}
+
+	contentType := resolveContentType(key, info.ContentType)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	preview := models.ObjectPreview{
+		Bucket:      bucketName,
+		Key:         key,
+		Size:        info.Size,
+		ContentType: contentType,
+		Kind:        models.PreviewKindNone,
+	}
+
+	switch {
+	case mediaType == "application/pdf":
+		pdf, err := h.s3Service.GetPDFInfo(ctx, bucketName, info)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to read PDF: "+err.Error()),
+			)
+		}
+		preview.Kind = models.PreviewKindPDF
+		preview.PDF = pdf
+
+	case isTextMediaType(mediaType), mediaType == "application/octet-stream":
+		data, truncated, err := h.s3Service.ReadObjectHead(ctx, bucketName, info, int64(limit))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to read object: "+err.Error()),
+			)
+		}
+		if truncated {
+			data = trimPartialRune(data)
+		}
+
+		// Objects of unknown type are only shown when they look like text
+		if mediaType == "application/octet-stream" && (bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)) {
+			break
+		}
+		preview.Kind = models.PreviewKindText
+		preview.Text = strings.ToValidUTF8(string(data), "\uFFFD")
+		preview.Truncated = truncated
+	}
+
+	return c.JSON(models.SuccessResponse(preview))
+}
+
+// isTextMediaType reports whether objects of a media type are previewed as text
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") ||
+		slices.Contains(textContentTypes, mediaType)
+}
+
+// trimPartialRune drops the bytes of a UTF-8 character cut off at the end of data
+func trimPartialRune(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
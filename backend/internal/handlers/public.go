@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"html/template"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/objectroute"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// PublicHandler serves buckets read-only and without authentication under /public. A
+// bucket is only served when publicBrowsing is set in its settings and it has website
+// access enabled in Garage; everything else is reported as missing.
+type PublicHandler struct {
+	adminService  *services.GarageAdminService
+	s3Service     *services.S3Service
+	settingsStore *services.SettingsStore
+	trashService  *services.TrashService
+	objects       *ObjectHandler
+}
+
+// NewPublicHandler creates a new public browsing handler. Downloads are served by the
+// object handler, without the admin-only options since no user is authenticated.
+func NewPublicHandler(adminService *services.GarageAdminService, s3Service *services.S3Service, settingsStore *services.SettingsStore, trashService *services.TrashService, objects *ObjectHandler) *PublicHandler {
+	return &PublicHandler{
+		adminService:  adminService,
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
+		trashService:  trashService,
+		objects:       objects,
+	}
+}
+
+// publicListingTemplate renders a directory-style listing
+var publicListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of /{{.Bucket}}/{{.Prefix}}</title></head>
+<body>
+<h1>Index of /{{.Bucket}}/{{.Prefix}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Last modified</th></tr>
+{{if .Prefix}}<tr><td><a href="{{.Parent}}">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Folders}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td>-</td><td></td></tr>
+{{end}}{{range .Objects}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.LastModified}}</td></tr>
+{{end}}</table>
+{{if .Next}}<p><a href="?continuation_token={{.Next}}">Next page</a></p>{{end}}
+</body>
+</html>
+`))
+
+// publicListingEntry is one row of an HTML listing
+type publicListingEntry struct {
+	Name         string
+	Href         string
+	Size         int64
+	LastModified string
+}
+
+// publicURL returns the /public URL of a key, escaping each path segment
+func publicURL(bucketName, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/public/" + url.PathEscape(bucketName) + "/" + strings.Join(segments, "/")
+}
+
+// Browse lists a folder of a public bucket, or downloads an object
+//
+//	@Summary		Browse a public bucket
+//	@Description	Read-only, unauthenticated access to buckets with public browsing and website access enabled. Paths ending with / list a folder as HTML, or as JSON when requested with Accept: application/json; other paths download the object. Rate limited per client IP.
+//	@Tags			Public
+//	@Produce		html
+//	@Produce		json
+//	@Produce		octet-stream
+//	@Param			bucket				path		string												true	"Name of the bucket"
+//	@Param			path				path		string												false	"Folder (ending with /) or object key"
+//	@Param			continuation_token	query		string												false	"Token from a previous listing page"
+//	@Success		200					{object}	models.APIResponse{data=models.ObjectListResponse}	"Folder listing (JSON) or object content"
+//	@Failure		404					{object}	models.APIResponse{error=models.APIError}			"Bucket is not public or object not found"
+//	@Failure		429					{object}	models.APIResponse{error=models.APIError}			"Rate limit exceeded"
+//	@Router			/public/{bucket}/{path} [get]
+func (h *PublicHandler) Browse(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	key, _, err := objectroute.Parse(c.Params("*"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+
+	// Unknown buckets and buckets that are not public look the same, and trashed objects
+	// are never exposed
+	notFound := func() error {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "Not found"),
+		)
+	}
+	if settings, ok := h.settingsStore.GetBucketSettings(bucketName); !ok || !settings.PublicBrowsing {
+		return notFound()
+	}
+	if bucketInfo, err := h.adminService.GetCachedBucketInfoByAlias(ctx, bucketName); err != nil || !bucketInfo.WebsiteAccess {
+		return notFound()
+	}
+	if h.trashService.IsTrashKey(key) {
+		return notFound()
+	}
+
+	if key != "" && !strings.HasSuffix(key, "/") {
+		c.Locals("objectKey", key)
+		return h.objects.GetObject(c)
+	}
+
+	listing, err := h.s3Service.ListObjects(ctx, bucketName, key, false, 0, c.Query("continuation_token"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeListFailed, "Failed to list objects"),
+		)
+	}
+
+	// Hide the trash folder from the bucket root
+	listing.Prefixes = slices.DeleteFunc(listing.Prefixes, h.trashService.IsTrashKey)
+
+	if c.Accepts(fiber.MIMETextHTML, fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
+		return c.JSON(models.SuccessResponse(listing))
+	}
+
+	data := struct {
+		Bucket  string
+		Prefix  string
+		Parent  string
+		Folders []publicListingEntry
+		Objects []publicListingEntry
+		Next    string
+	}{
+		Bucket: bucketName,
+		Prefix: key,
+		Next:   listing.NextContinuationToken,
+	}
+	if parent := path.Dir(strings.TrimSuffix(key, "/")); parent != "." {
+		data.Parent = publicURL(bucketName, parent+"/")
+	} else {
+		data.Parent = publicURL(bucketName, "")
+	}
+	for _, prefix := range listing.Prefixes {
+		data.Folders = append(data.Folders, publicListingEntry{
+			Name: strings.TrimPrefix(prefix, key),
+			Href: publicURL(bucketName, prefix),
+		})
+	}
+	for _, obj := range listing.Objects {
+		data.Objects = append(data.Objects, publicListingEntry{
+			Name:         strings.TrimPrefix(obj.Key, key),
+			Href:         publicURL(bucketName, obj.Key),
+			Size:         obj.Size,
+			LastModified: obj.LastModified.Format("2006-01-02 15:04:05 UTC"),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return publicListingTemplate.Execute(c, data)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxSearchQueryLength bounds search queries, regular expressions included
+const maxSearchQueryLength = 256
+
+// Ways a search query is matched against keys
+const (
+	searchModeSubstring = "substring"
+	searchModeGlob      = "glob"
+	searchModeRegex     = "regex"
+)
+
+// SearchObjects searches a bucket for objects by key
+//
+//	@Summary		Search objects in a bucket
+//	@Description	Walks every key under prefix, at any depth, and returns the objects whose key matches q. With mode=substring (default) q may appear anywhere in the key; with mode=glob q is a shell pattern (*, ?, [...]) matched against the file name, or against the whole key when q contains a /; with mode=regex q is a regular expression searched for in the key. Matching ignores case unless case_sensitive=true. The size and date filters of the object listing apply too. Each page scans at most 10000 keys, so a page may hold fewer matches than max_keys, or none, while isTruncated is still true; pass nextContinuationToken to go on. Scoped API tokens need read access to the bucket. Time budget: server.timeouts.listing (default: 10s).
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			bucket				path		string												true	"Name of the bucket to search"
+//	@Param			q					query		string												true	"Search query, up to 256 characters"
+//	@Param			mode				query		string												false	"How q is matched: substring (default), glob or regex"
+//	@Param			case_sensitive		query		bool												false	"Match case exactly"
+//	@Param			prefix				query		string												false	"Only search under this prefix"
+//	@Param			max_keys			query		int													false	"Maximum number of matches to return (default: bucket setting, or server.pagination.default_page_size; capped at server.pagination.max_page_size and 1000)"
+//	@Param			continuation_token	query		string												false	"Token from a previous page of the same search"
+//	@Param			min_size			query		int													false	"Only return objects of at least this many bytes"
+//	@Param			max_size			query		int													false	"Only return objects of at most this many bytes"
+//	@Param			modified_after		query		string												false	"Only return objects modified at or after this RFC3339 time"
+//	@Param			modified_before		query		string												false	"Only return objects modified at or before this RFC3339 time"
+//	@Param			fields				query		string												false	"Comma-separated object fields to return, e.g. key,size,lastModified"
+//	@Success		200					{object}	models.APIResponse{data=models.ObjectListResponse}	"Matching objects"
+//	@Failure		400					{object}	models.APIResponse{error=models.APIError}			"Invalid query or parameters"
+//	@Failure		403					{object}	models.APIResponse{error=models.APIError}			"Garage denied access to the bucket"
+//	@Failure		404					{object}	models.APIResponse{error=models.APIError}			"Bucket does not exist, or no key gives access to it"
+//	@Failure		500					{object}	models.APIResponse{error=models.APIError}			"Failed to search objects"
+//	@Router			/api/v1/buckets/{bucket}/search [get]
+func (h *ObjectHandler) SearchObjects(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+
+	match, err := parseSearchQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid search: "+err.Error()),
+		)
+	}
+
+	// Fall back to the bucket's stored page size when max_keys is absent
+	defaultMaxKeys := 0
+	if settings, ok := h.settingsStore.GetBucketSettings(bucketName); ok && settings.MaxKeys > 0 {
+		defaultMaxKeys = settings.MaxKeys
+	}
+
+	params, err := parsePageParams(c, h.pagination, "max_keys", defaultMaxKeys)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
+		)
+	}
+
+	filter, err := parseObjectFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid filter: "+err.Error()),
+		)
+	}
+
+	fields, err := parseFields[models.ObjectInfo](c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid fields parameter: "+err.Error()),
+		)
+	}
+
+	results, err := h.s3Service.SearchObjects(c.Context(), bucketName, c.Query("prefix"), params.Limit, c.Query("continuation_token"), match, filter)
+	switch {
+	case errors.Is(err, services.ErrInvalidFilterToken):
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid continuation token: "+err.Error()),
+		)
+	case services.IsBucketNotFound(err):
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeBucketNotFound, "Bucket does not exist or no key gives access to it: "+bucketName),
+		)
+	case services.IsAccessDenied(err):
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Access to bucket denied: "+bucketName),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeListFailed, "Failed to search objects: "+err.Error()),
+		)
+	}
+
+	return sendListing(c, results, "objects", fields)
+}
+
+// parseSearchQuery reads the q, mode and case_sensitive query parameters into a key matcher
+func parseSearchQuery(c fiber.Ctx) (func(key string) bool, error) {
+	query := c.Query("q")
+	if query == "" {
+		return nil, errors.New("q is required")
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, fmt.Errorf("q must be at most %d characters", maxSearchQueryLength)
+	}
+	caseSensitive := c.Query("case_sensitive") == "true"
+
+	switch mode := c.Query("mode", searchModeSubstring); mode {
+	case searchModeSubstring:
+		if !caseSensitive {
+			query = strings.ToLower(query)
+			return func(key string) bool { return strings.Contains(strings.ToLower(key), query) }, nil
+		}
+		return func(key string) bool { return strings.Contains(key, query) }, nil
+
+	case searchModeGlob:
+		if !caseSensitive {
+			query = strings.ToLower(query)
+		}
+		if _, err := path.Match(query, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern: %w", err)
+		}
+		wholeKey := strings.Contains(query, "/")
+		return func(key string) bool {
+			if !caseSensitive {
+				key = strings.ToLower(key)
+			}
+			if !wholeKey {
+				key = path.Base(key)
+			}
+			matched, _ := path.Match(query, key)
+			return matched
+		}, nil
+
+	case searchModeRegex:
+		if !caseSensitive {
+			query = "(?i)" + query
+		}
+		pattern, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return pattern.MatchString, nil
+
+	default:
+		return nil, fmt.Errorf("invalid mode %q (must be substring, glob or regex)", mode)
+	}
+}
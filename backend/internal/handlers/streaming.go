@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// mediaContentTypes maps media extensions Go's mime table does not know (or gets wrong
+// on some systems) to the types browsers expect for playback
+var mediaContentTypes = map[string]string{
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".ogv":  "video/ogg",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".aac":  "audio/aac",
+}
+
+// resolveContentType replaces the generic binary content type Garage reports for objects
+// uploaded without one by a type derived from the key's extension
+func resolveContentType(key, contentType string) string {
+	switch contentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+	default:
+		return contentType
+	}
+
+	ext := strings.ToLower(path.Ext(key))
+	if mediaType, ok := mediaContentTypes[ext]; ok {
+		return mediaType
+	}
+	if mediaType := mime.TypeByExtension(ext); mediaType != "" {
+		return mediaType
+	}
+
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// byteRange is a resolved, inclusive byte range within an object
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// length returns the number of bytes in the range
+func (r *byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// contentRange formats the Content-Range header value for the range
+func (r *byteRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(r.start, 10) + "-" + strconv.FormatInt(r.end, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// parseByteRange resolves a Range header against an object size. It returns a nil range
+// when the header should be ignored and the whole object served (other units, multiple
+// ranges or malformed values, as RFC 9110 allows), and false when the range cannot be
+// satisfied.
+func parseByteRange(header string, size int64) (*byteRange, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, true
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, true
+	}
+
+	// Suffix range: the last N bytes
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix < 0 {
+			return nil, true
+		}
+		if suffix == 0 || size == 0 {
+			return nil, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return &byteRange{start: size - suffix, end: size - 1}, true
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return nil, true
+	}
+	if start >= size {
+		return nil, false
+	}
+
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return nil, true
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return &byteRange{start: start, end: end}, true
+}
+
+// acceptsJSONErrors reports whether a download client should get errors as JSON: API
+// clients send no Accept header or name JSON explicitly, while <img>, <video> and plain
+// browser navigation only accept media types or */*
+func acceptsJSONErrors(c fiber.Ctx) bool {
+	accept := c.Get(fiber.HeaderAccept)
+	return accept == "" || strings.Contains(accept, fiber.MIMEApplicationJSON)
+}
+
+// downloadError answers a failed download. Clients that accept JSON get the standard
+// error response; others get a bare status text so no JSON ends up rendered as content.
+// Neither may be cached, since the object can appear at any time.
+func downloadError(c fiber.Ctx, status int, code, message string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if acceptsJSONErrors(c) {
+		return c.Status(status).JSON(models.ErrorResponse(code, message))
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.Status(status).SendString(strconv.Itoa(status) + " " + http.StatusText(status))
+}
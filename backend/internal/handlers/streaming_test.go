@@ -0,0 +1,24 @@
+package handlers
+
+import "testing"
+
+func TestResolveContentType(t *testing.T) {
+	tests := []struct {
+		key         string
+		contentType string
+		want        string
+	}{
+		{key: "movie.mkv", contentType: "application/octet-stream", want: "video/x-matroska"},
+		{key: "song.M4A", contentType: "binary/octet-stream", want: "audio/mp4"},
+		{key: "clip.webm", contentType: "", want: "video/webm"},
+		{key: "clip.webm", contentType: "video/mp4", want: "video/mp4"},
+		{key: "data.bin", contentType: "application/octet-stream", want: "application/octet-stream"},
+		{key: "noext", contentType: "", want: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		if got := resolveContentType(tt.key, tt.contentType); got != tt.want {
+			t.Errorf("resolveContentType(%q, %q) = %q, want %q", tt.key, tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// UploadObjectStream uploads the raw request body as an object
+//
+//	@Summary		Upload an object from the raw request body
+//	@Description	Stores the request body as the object at key, replacing any existing one. Unlike the form upload the body is relayed to Garage as it arrives, in parts of 16MB, so server.max_body_size does not apply and objects of up to maxStreamedObjectSize (see /limits) can be uploaded; chunked bodies are accepted. The Content-Type header of the request becomes that of the object. The bucket's file type rules apply. Compression, dedupe hints and upload progress are only available with the form upload. Time budget: server.timeouts.transfer (default: unlimited).
+//	@Tags			Objects
+//	@Accept			application/octet-stream
+//	@Produce		json
+//	@Param			bucket	path		string																true	"Name of the bucket to upload the object to"
+//	@Param			key		path		string																true	"Key (path) of the object"
+//	@Param			force	query		bool																false	"Admin only: upload even if the bucket's file type rules refuse the file (audited)"
+//	@Param			body	body		string																true	"Content of the object"
+//	@Success		201		{object}	models.APIResponse{data=models.ObjectUploadResponse}				"Object uploaded successfully"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}							"Invalid object key, or the body exceeds maxStreamedObjectSize"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}							"force requested by a non-admin"
+//	@Failure		415		{object}	models.APIResponse{error=models.APIError}							"File type refused by the bucket's allowedContentTypes or blockedExtensions"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}							"Failed to upload object"
+//	@Failure		503		{object}	models.APIResponse{data=models.ClusterHealth,error=models.APIError}	"Cluster lacks write quorum (server.block_writes_when_degraded)"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key} [put]
+func (h *ObjectHandler) UploadObjectStream(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if err := validateObjectKey(key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+		)
+	}
+
+	// File type rules may only be overridden by admins
+	force := c.Query("force") == "true"
+	if isAdmin, _ := c.Locals("isAdmin").(bool); force && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "Forcing uploads past file type rules is restricted to administrators"),
+		)
+	}
+
+	size := int64(c.Request().Header.ContentLength())
+	if size > services.MaxStreamedObjectSize {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "The body exceeds the largest object a streamed upload can create"),
+		)
+	}
+
+	if rejected, err := h.rejectIfDegraded(c); rejected {
+		return err
+	}
+
+	// Bodies within the server's body limit have been received already; larger ones are
+	// read from the connection as the upload goes
+	var (
+		body io.Reader
+		head []byte
+	)
+	if c.Request().IsBodyStream() {
+		buffered := bufio.NewReaderSize(c.Request().BodyStream(), services.SniffLength)
+		var err error
+		head, err = buffered.Peek(services.SniffLength)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to read the request body: "+err.Error()),
+			)
+		}
+		body = buffered
+	} else {
+		received := c.Body()
+		head = received[:min(len(received), services.SniffLength)]
+		body = bytes.NewReader(received)
+		size = int64(len(received))
+	}
+
+	contentType := c.Get(fiber.HeaderContentType)
+	if rejected, err := h.rejectBlockedContent(c, bucketName, key, contentType, head, force); rejected {
+		return err
+	}
+
+	uploadResult, err := h.s3Service.UploadObjectStream(c.Context(), bucketName, key, body, size, contentType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeUploadFailed, "Failed to upload object: "+err.Error()),
+		)
+	}
+
+	h.transferStats.Add(transferUser(c), services.TransferUpload, uploadResult.Size)
+
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(uploadResult))
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// defaultThumbnailSize is the width and height of thumbnails requested without either
+const defaultThumbnailSize = 256
+
+// GetObjectThumbnail returns a downscaled preview of an image object
+//
+//	@Summary		Get an image thumbnail
+//	@Description	Returns a preview of a JPEG, PNG or GIF object fitting within w x h pixels, keeping its aspect ratio; images are never enlarged. When only one of w and h is given the other is not bounded, and when neither is the thumbnail fits within 256 x 256. Thumbnails of JPEG images are JPEGs and the others PNGs. Generated thumbnails are cached until the object changes, and carry an ETag for conditional requests.
+//	@Tags			Objects
+//	@Produce		image/jpeg,image/png
+//	@Param			bucket	path		string										true	"Name of the bucket containing the object"
+//	@Param			key		path		string										true	"Key (path) of the object"
+//	@Param			w		query		int											false	"Largest width of the thumbnail, up to thumbnails.max_dimension"
+//	@Param			h		query		int											false	"Largest height of the thumbnail, up to thumbnails.max_dimension"
+//	@Success		200		{file}		binary										"Thumbnail"
+//	@Success		304		{string}	string										"Thumbnail unchanged since the ETag given in If-None-Match"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid size or object key"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}	"Object not found"
+//	@Failure		415		{object}	models.APIResponse{error=models.APIError}	"Object is not a supported image, or too large"
+//	@Failure		501		{object}	models.APIResponse{error=models.APIError}	"Thumbnails are disabled"
+//	@Router			/api/v1/buckets/{bucket}/objects/{key}/thumbnail [get]
+func (h *ObjectHandler) GetObjectThumbnail(c fiber.Ctx) error {
+	if h.thumbnails == nil {
+		return c.Status(fiber.StatusNotImplemented).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, "Thumbnails are disabled"),
+		)
+	}
+
+	bucketName := bucketParam(c)
+	key, _ := c.Locals("objectKey").(string)
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Object key is required"),
+		)
+	}
+
+	width, height, err := h.thumbnailSize(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+
+	thumbnail, err := h.thumbnails.Get(c.Context(), bucketName, key, width, height)
+	switch {
+	case errors.Is(err, services.ErrThumbnailUnsupported), errors.Is(err, services.ErrThumbnailTooLarge):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, err.Error()),
+		)
+	case services.IsObjectNotFound(err):
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeObjectNotFound, "Object not found: "+err.Error()),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to generate thumbnail: "+err.Error()),
+		)
+	}
+
+	c.Set("ETag", thumbnail.ETag)
+	c.Set("Cache-Control", "private, max-age=86400")
+	c.Set("X-Content-Type-Options", "nosniff")
+	if c.Get(fiber.HeaderIfNoneMatch) == thumbnail.ETag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set("Content-Type", thumbnail.ContentType)
+	return c.Send(thumbnail.Data)
+}
+
+// thumbnailSize reads the w and h query parameters. A missing side is bounded by the
+// largest allowed dimension, and both default to defaultThumbnailSize.
+func (h *ObjectHandler) thumbnailSize(c fiber.Ctx) (int, int, error) {
+	maxDimension := h.thumbnailMaxDimension
+	if c.Query("w") == "" && c.Query("h") == "" {
+		size := min(defaultThumbnailSize, maxDimension)
+		return size, size, nil
+	}
+
+	parse := func(name string) (int, error) {
+		raw := c.Query(name)
+		if raw == "" {
+			return maxDimension, nil
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxDimension {
+			return 0, errors.New(name + " must be between 1 and " + strconv.Itoa(maxDimension))
+		}
+		return value, nil
+	}
+
+	width, err := parse("w")
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := parse("h")
+	if err != nil {
+		return 0, 0, err
+	}
+	return width, height, nil
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// TokenHandler handles the personal API tokens of the current user
+type TokenHandler struct {
+	authService *auth.Service
+	config      *config.APITokenConfig
+	auditLog    *services.AuditLog
+}
+
+// NewTokenHandler creates a new API token handler
+func NewTokenHandler(authService *auth.Service, cfg *config.APITokenConfig, auditLog *services.AuditLog) *TokenHandler {
+	return &TokenHandler{
+		authService: authService,
+		config:      cfg,
+		auditLog:    auditLog,
+	}
+}
+
+// tokenOwner returns the current user when they logged in themselves; API tokens cannot
+// manage API tokens
+func tokenOwner(c fiber.Ctx) (*auth.UserInfo, bool) {
+	userInfo, ok := c.Locals("userInfo").(*auth.UserInfo)
+	if !ok || userInfo.TokenID != "" {
+		return nil, false
+	}
+	return userInfo, true
+}
+
+// apiTokenToInfo converts a stored API token to its API representation
+func apiTokenToInfo(token *auth.APIToken) models.APITokenInfo {
+	info := models.APITokenInfo{
+		ID:        token.ID,
+		Name:      token.Name,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}
+	if token.Scope != nil {
+		info.Scoped = true
+		info.Buckets = token.Scope.Buckets
+		info.Verbs = token.Scope.Verbs
+	}
+	return info
+}
+
+// ListTokens lists the API tokens of the current user with their scopes
+//
+//	@Summary		List my API tokens
+//	@Description	Lists the unexpired personal API tokens of the current user with their bucket and verb scopes
+//	@Tags			Tokens
+//	@Produce		json
+//	@Success		200	{object}	models.APIResponse{data=models.APITokenListResponse}	"Successfully retrieved tokens"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}				"Not available to API tokens"
+//	@Security		BearerAuth
+//	@Router			/api/v1/me/tokens [get]
+func (h *TokenHandler) ListTokens(c fiber.Ctx) error {
+	userInfo, ok := tokenOwner(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "API tokens can only be managed after logging in"),
+		)
+	}
+
+	tokens := h.authService.ListAPITokens(userInfo.Username)
+	response := models.APITokenListResponse{
+		Tokens: make([]models.APITokenInfo, 0, len(tokens)),
+		Count:  len(tokens),
+	}
+	for i := range tokens {
+		response.Tokens = append(response.Tokens, apiTokenToInfo(&tokens[i]))
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
+// CreateToken issues an API token to the current user
+//
+//	@Summary		Create my API token
+//	@Description	Issues a personal API token acting as the current user, optionally scoped to bucket name patterns and to the read and/or write verbs. Scoped tokens can only access the object routes of matching buckets. The token is only returned once.
+//	@Tags			Tokens
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateAPITokenRequest					true	"Token name, scope and expiry"
+//	@Success		201		{object}	models.APIResponse{data=models.APITokenInfo}	"Token created"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}		"Invalid request body, scope or expiry"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}		"Not available to API tokens"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}		"Token limit reached"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}		"Failed to create token"
+//	@Security		BearerAuth
+//	@Router			/api/v1/me/tokens [post]
+func (h *TokenHandler) CreateToken(c fiber.Ctx) error {
+	userInfo, ok := tokenOwner(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "API tokens can only be managed after logging in"),
+		)
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Token name is required"),
+		)
+	}
+
+	var scope *auth.TokenScope
+	if len(req.Buckets) > 0 || len(req.Verbs) > 0 {
+		scope = &auth.TokenScope{Buckets: req.Buckets, Verbs: req.Verbs}
+		if err := scope.Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid token scope: "+err.Error()),
+			)
+		}
+	}
+
+	ttl := h.config.MaxTTL
+	if req.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid expires_in: must be a positive duration such as 720h"),
+			)
+		}
+		if parsed > h.config.MaxTTL {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "expires_in must not exceed "+h.config.MaxTTL.String()),
+			)
+		}
+		ttl = parsed
+	}
+
+	event := newAuditEvent(c, "api_token.create", req.Name)
+	signed, token, err := h.authService.IssueAPIToken(userInfo, req.Name, scope, ttl)
+	if err == nil {
+		event.Target = token.ID
+		event.Success = true
+	}
+	h.auditLog.Record(event)
+
+	switch {
+	case errors.Is(err, auth.ErrTokenLimit):
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, "Token limit reached: delete an existing token first"),
+		)
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to create token: "+err.Error()),
+		)
+	}
+
+	info := apiTokenToInfo(token)
+	info.Token = signed
+	return c.Status(fiber.StatusCreated).JSON(models.SuccessResponse(info))
+}
+
+// DeleteToken revokes an API token of the current user
+//
+//	@Summary		Delete my API token
+//	@Description	Revokes a personal API token of the current user; it is rejected from then on
+//	@Tags			Tokens
+//	@Produce		json
+//	@Param			id	path		string											true	"Token ID"
+//	@Success		200	{object}	models.APIResponse{data=map[string]interface{}}	"Token deleted"
+//	@Failure		403	{object}	models.APIResponse{error=models.APIError}		"Not available to API tokens"
+//	@Failure		404	{object}	models.APIResponse{error=models.APIError}		"Token not found"
+//	@Failure		500	{object}	models.APIResponse{error=models.APIError}		"Failed to delete token"
+//	@Security		BearerAuth
+//	@Router			/api/v1/me/tokens/{id} [delete]
+func (h *TokenHandler) DeleteToken(c fiber.Ctx) error {
+	tokenID := c.Params("id")
+
+	userInfo, ok := tokenOwner(c)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(
+			models.ErrorResponse(models.ErrCodeForbidden, "API tokens can only be managed after logging in"),
+		)
+	}
+
+	event := newAuditEvent(c, "api_token.delete", tokenID)
+	err := h.authService.RevokeAPIToken(userInfo.Username, tokenID)
+	event.Success = err == nil
+	h.auditLog.Record(event)
+
+	// Tokens of other users are reported as missing rather than forbidden
+	if errors.Is(err, auth.ErrTokenNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "Token not found"),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to delete token: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(map[string]interface{}{
+		"id":      tokenID,
+		"deleted": true,
+	}))
+}
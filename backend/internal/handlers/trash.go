@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// TrashHandler handles listing and restoring soft-deleted objects
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(trashService *services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// ListTrash lists the objects in a bucket's trash
+//
+//	@Summary		List trashed objects
+//	@Description	Lists objects moved to the bucket's trash, oldest first, with their original key and deletion time. Time budget: server.timeouts.listing (default: 10s).
+//	@Tags			Objects
+//	@Produce		json
+//	@Param			name	path		string												true	"Name of the bucket"
+//	@Success		200		{object}	models.APIResponse{data=models.TrashListResponse}	"Successfully retrieved trashed objects"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Bucket name is required"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to list trash"
+//	@Router			/api/v1/buckets/{name}/trash [get]
+func (h *TrashHandler) ListTrash(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	// Get bucket name from URL parameter
+	bucketName := c.Params("name")
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+
+	trash, err := h.trashService.ListTrash(ctx, bucketName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeListFailed, "Failed to list trash: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(trash))
+}
+
+// RestoreFromTrash moves a trashed object back to its original key
+//
+//	@Summary		Restore trashed object
+//	@Description	Moves an object from the bucket's trash back to its original key
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string													true	"Name of the bucket"
+//	@Param			request	body		models.RestoreTrashRequest								true	"Trash key to restore"
+//	@Success		200		{object}	models.APIResponse{data=models.TrashRestoreResponse}	"Object restored successfully"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}				"Invalid request body or trash key"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}				"An object already exists at the original key"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}				"Failed to restore object"
+//	@Router			/api/v1/buckets/{name}/trash/restore [post]
+func (h *TrashHandler) RestoreFromTrash(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	// Get bucket name from URL parameter
+	bucketName := c.Params("name")
+	if bucketName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Bucket name is required"),
+		)
+	}
+
+	// Parse request body
+	var req models.RestoreTrashRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	if !h.trashService.IsTrashKey(req.TrashKey) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Key is not in the trash"),
+		)
+	}
+
+	key, err := h.trashService.Restore(ctx, bucketName, req.TrashKey, req.Overwrite)
+	if errors.Is(err, services.ErrRestoreConflict) {
+		return c.Status(fiber.StatusConflict).JSON(
+			models.ErrorResponse(models.ErrCodeConflict, "An object already exists at the original key"),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to restore object: "+err.Error()),
+		)
+	}
+
+	response := models.TrashRestoreResponse{
+		Bucket:   bucketName,
+		TrashKey: req.TrashKey,
+		Key:      key,
+	}
+
+	return c.JSON(models.SuccessResponse(response))
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Pace of the upload progress stream
+const (
+	uploadProgressInterval  = 250 * time.Millisecond
+	uploadProgressHeartbeat = 15 * time.Second
+
+	// uploadProgressWait is how long the stream waits for an upload that has not started
+	// yet, since the stream is usually opened just before the upload is sent
+	uploadProgressWait = 30 * time.Second
+)
+
+// uploadIDPattern restricts the IDs clients may choose for their uploads
+var uploadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// StreamUploadProgress streams the progress of an upload as server-sent events
+//
+//	@Summary		Stream upload progress
+//	@Description	Streams the progress of an upload sent to POST /buckets/{bucket}/objects with the same upload_id, as server-sent events: a "progress" event with a models.UploadProgress whenever more bytes were relayed to Garage, then a "done" or "error" event when the upload ends, after which the stream closes. Only the relay to Garage is measured, since the upload body is received in full before it starts; browsers can measure the sending themselves. The stream may be opened before the upload is sent and waits up to 30 seconds for it to start. Progress can only be watched by the user who uploads, and is kept for a minute after the upload ends.
+//	@Tags			Objects
+//	@Produce		text/event-stream
+//	@Param			upload_id	path		string										true	"ID chosen by the client for the upload"
+//	@Success		200			{object}	models.UploadProgress						"Stream of progress events"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}	"Invalid upload ID"
+//	@Router			/api/v1/uploads/{upload_id}/progress [get]
+func (h *ObjectHandler) StreamUploadProgress(c fiber.Ctx) error {
+	// The stream writer runs after this handler returns and the request is released,
+	// so copy everything it needs out of the request first
+	uploadID := strings.Clone(c.Params("upload_id"))
+	if !uploadIDPattern.MatchString(uploadID) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "upload_id must be 1 to 64 letters, digits, dashes or underscores"),
+		)
+	}
+	username, _ := c.Locals("username").(string)
+	username = strings.Clone(username)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	// Events use the app's encoder so that server.legacy_field_names applies to them too
+	marshal := c.App().Config().JSONEncoder
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		send := func(event string, v interface{}) error {
+			data, err := marshal(v)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+			// Flushing fails once the client has gone away, which ends the stream
+			return w.Flush()
+		}
+
+		ticker := time.NewTicker(uploadProgressInterval)
+		defer ticker.Stop()
+
+		opened := time.Now()
+		lastSent := opened
+		var last *models.UploadProgress
+		for range ticker.C {
+			progress, ok := h.uploadProgress.Get(uploadID, username)
+			if !ok {
+				if time.Since(opened) < uploadProgressWait {
+					continue
+				}
+				send("error", models.UploadProgress{UploadID: uploadID, Error: "no upload with this ID was started"})
+				return
+			}
+
+			if progress.Done {
+				event := "done"
+				if progress.Error != "" {
+					event = "error"
+				}
+				send(event, progress)
+				return
+			}
+
+			switch {
+			case last == nil || progress.Transferred != last.Transferred:
+				if send("progress", progress) != nil {
+					return
+				}
+				last, lastSent = &progress, time.Now()
+			case time.Since(lastSent) >= uploadProgressHeartbeat:
+				// Comments keep idle connections from being closed by proxies
+				w.WriteString(": keep-alive\n\n")
+				if w.Flush() != nil {
+					return
+				}
+				lastSent = time.Now()
+			}
+		}
+	})
+}
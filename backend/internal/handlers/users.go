@@ -1,48 +1,98 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/utils"
 
 	"github.com/gofiber/fiber/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 // UserHandler handles user/key management operations using Garage Admin API
 type UserHandler struct {
-	adminService *services.GarageAdminService
+	adminService  *services.GarageAdminService
+	s3Service     *services.S3Service
+	settingsStore *services.SettingsStore
+	auditLog      *services.AuditLog
+	notifier      *services.Notifier
+	keyActivity   *services.KeyActivity
+	pagination    *config.PaginationConfig
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(adminService *services.GarageAdminService) *UserHandler {
+func NewUserHandler(adminService *services.GarageAdminService, s3Service *services.S3Service, settingsStore *services.SettingsStore, auditLog *services.AuditLog, notifier *services.Notifier, keyActivity *services.KeyActivity, pagination *config.PaginationConfig) *UserHandler {
 	return &UserHandler{
-		adminService: adminService,
+		adminService:  adminService,
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
+		auditLog:      auditLog,
+		notifier:      notifier,
+		keyActivity:   keyActivity,
+		pagination:    pagination,
 	}
 }
 
 // ListUsers lists all users/access keys
 //
 //	@Summary		List all users
-//	@Description	Retrieves a list of all users/access keys
+//	@Description	Retrieves one page of the users/access keys, ordered by name. When Garage exposes per-key request metrics, lastSeenActivity tells when each key was last seen making requests. Time budget: server.timeouts.listing (default: 10s).
 //	@Tags			Users
 //	@Produce		json
-//	@Success		200	{object}	models.APIResponse{data=models.UserListResponse}	"List of users retrieved successfully"
-//	@Failure		500	{object}	models.APIResponse{error=models.APIError}			"Failed to list users"
+//	@Param			limit	query		int													false	"Page size (default: server.pagination.default_page_size, capped at max_page_size)"
+//	@Param			offset	query		int													false	"Index of the first user (default: 0)"
+//	@Param			fields	query		string												false	"Comma-separated user fields to return, e.g. accessKeyId,name"
+//	@Success		200		{object}	models.APIResponse{data=models.UserListResponse}	"List of users retrieved successfully"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid paging parameters or unknown field"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to list users"
 //	@Router			/api/v1/users [get]
 func (h *UserHandler) ListUsers(c fiber.Ctx) error {
 	ctx := c.Context()
 
-	keys, err := h.adminService.ListKeys(ctx)
+	params, err := parsePageParams(c, h.pagination, "limit", 0)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid paging parameters: "+err.Error()),
+		)
+	}
+
+	fields, err := parseFields[models.UserInfo](c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid fields parameter: "+err.Error()),
+		)
+	}
+
+	keys, truncated, err := h.adminService.ListKeys(ctx)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(
 			models.ErrorResponse(models.ErrCodeInternalError, "Failed to list users: "+err.Error()),
 		)
 	}
 
+	// Order keys so pages are stable, and only fetch details for the requested page
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	page, pagination := paginate(keys, params)
+
+	// Activity hints are only given when the metrics can attribute requests to keys
+	activitySupported := h.keyActivity.Supported()
+
 	// Convert to UserInfo format
-	users := make([]models.UserInfo, 0, len(keys))
-	for _, key := range keys {
+	users := make([]models.UserInfo, 0, len(page))
+	for _, key := range page {
 		// Get full key info to retrieve bucket permissions
 		keyInfo, err := h.adminService.GetKeyInfo(ctx, key.ID, false)
 		if err != nil {
@@ -59,21 +109,29 @@ func (h *UserHandler) ListUsers(c fiber.Ctx) error {
 			status = "inactive"
 		}
 
-		users = append(users, models.UserInfo{
+		user := models.UserInfo{
 			AccessKeyID:       keyInfo.AccessKeyID,
 			Name:              keyInfo.Name,
-			CreatedAt:         keyInfo.Created,
+			CreatedAt:         utils.UTCPtr(keyInfo.Created),
 			Status:            status,
 			BucketPermissions: bucketPermissions,
-			Expiration:        keyInfo.Expiration,
+			Expiration:        utils.UTCPtr(keyInfo.Expiration),
 			Expired:           keyInfo.Expired,
-		})
+		}
+		if activitySupported {
+			if seen, ok := h.keyActivity.LastSeen(keyInfo.AccessKeyID); ok {
+				user.LastSeenActivity = &seen
+			}
+		}
+		users = append(users, user)
 	}
 
-	return c.JSON(models.SuccessResponse(models.UserListResponse{
-		Users: users,
-		Count: len(users),
-	}))
+	return sendListing(c, models.UserListResponse{
+		Users:      users,
+		Count:      len(users),
+		Truncated:  truncated,
+		Pagination: pagination,
+	}, "users", fields)
 }
 
 // convertBucketPermissionsToBucketPermissions converts Garage bucket permissions to frontend BucketPermission format
@@ -154,10 +212,10 @@ func (h *UserHandler) CreateUser(c fiber.Ctx) error {
 		AccessKeyID:       keyInfo.AccessKeyID,
 		SecretKey:         keyInfo.SecretAccessKey,
 		Name:              keyInfo.Name,
-		CreatedAt:         keyInfo.Created,
+		CreatedAt:         utils.UTCPtr(keyInfo.Created),
 		Status:            status,
 		BucketPermissions: bucketPermissions,
-		Expiration:        keyInfo.Expiration,
+		Expiration:        utils.UTCPtr(keyInfo.Expiration),
 		Expired:           keyInfo.Expired,
 	}
 
@@ -193,12 +251,338 @@ func (h *UserHandler) DeleteUser(c fiber.Ctx) error {
 		)
 	}
 
+	h.notifier.Notify(models.KeyNotification{Event: models.KeyEventKeyDelete, AccessKeyID: accessKey, Actor: actorName(c)})
+
 	return c.JSON(models.SuccessResponse(map[string]interface{}{
 		"access_key": accessKey,
 		"deleted":    true,
 	}))
 }
 
+// maxBulkUserDelete bounds the number of keys accepted by DeleteMultipleUsers
+const maxBulkUserDelete = 100
+
+// DeleteMultipleUsers deletes several users/access keys
+//
+//	@Summary		Delete multiple users
+//	@Description	Deletes several users/access keys. A key that is the only owner of a bucket is not deleted and is reported as a per-key error. Admin only.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.DeleteMultipleUsersRequest							true	"Access keys to delete"
+//	@Success		200		{object}	models.APIResponse{data=models.UserDeleteMultipleResponse}	"All users deleted"
+//	@Success		207		{object}	models.APIResponse{data=models.UserDeleteMultipleResponse}	"Some users could not be deleted"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}					"Invalid request body"
+//	@Failure		403		{object}	models.APIResponse{error=models.APIError}					"Administrator privileges required"
+//	@Failure		500		{object}	models.APIResponse{data=models.UserDeleteMultipleResponse}	"No user could be deleted"
+//	@Router			/api/v1/users/delete-multiple [post]
+func (h *UserHandler) DeleteMultipleUsers(c fiber.Ctx) error {
+	var req models.DeleteMultipleUsersRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	// Drop empty entries and duplicates, keeping the request order
+	accessKeys := make([]string, 0, len(req.AccessKeys))
+	seen := make(map[string]bool, len(req.AccessKeys))
+	for _, accessKey := range req.AccessKeys {
+		if accessKey == "" || seen[accessKey] {
+			continue
+		}
+		seen[accessKey] = true
+		accessKeys = append(accessKeys, accessKey)
+	}
+
+	if len(accessKeys) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "At least one access key is required"),
+		)
+	}
+	if len(accessKeys) > maxBulkUserDelete {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("At most %d access keys can be deleted at once", maxBulkUserDelete)),
+		)
+	}
+
+	response := models.UserDeleteMultipleResponse{
+		Total:   len(accessKeys),
+		Results: make([]models.UserDeleteResult, 0, len(accessKeys)),
+	}
+
+	// Keys are deleted one at a time so the ownership check sees earlier deletions
+	for _, accessKey := range accessKeys {
+		result := h.deleteUserChecked(c, accessKey)
+		if result.Deleted {
+			response.SuccessCount++
+		} else {
+			response.FailureCount++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	// Return 200 if all succeeded, 207 (Multi-Status) if partial success, 500 if all failed
+	statusCode := fiber.StatusOK
+	if response.FailureCount > 0 && response.SuccessCount > 0 {
+		statusCode = fiber.StatusMultiStatus // 207
+	} else if response.FailureCount > 0 && response.SuccessCount == 0 {
+		statusCode = fiber.StatusInternalServerError
+	}
+
+	return c.Status(statusCode).JSON(models.SuccessResponse(response))
+}
+
+// deleteUserChecked deletes one key unless it is the only owner of a bucket, and drops the
+// cached S3 credentials of the buckets it had access to
+func (h *UserHandler) deleteUserChecked(c fiber.Ctx, accessKey string) models.UserDeleteResult {
+	ctx := c.Context()
+	result := models.UserDeleteResult{AccessKey: accessKey}
+
+	event := newAuditEvent(c, "key.delete", accessKey)
+	defer func() {
+		event.Success = result.Deleted
+		h.auditLog.Record(event)
+	}()
+
+	keyInfo, err := h.adminService.GetKeyInfo(ctx, accessKey, false)
+	if err != nil {
+		result.Error = "Failed to get key info: " + err.Error()
+		return result
+	}
+
+	soleOwned, err := h.adminService.SoleOwnedBuckets(ctx, keyInfo)
+	if err != nil {
+		result.Error = "Failed to check bucket ownership: " + err.Error()
+		return result
+	}
+	if len(soleOwned) > 0 {
+		result.Error = "Key is the only owner of: " + strings.Join(soleOwned, ", ")
+		result.SoleOwnerOf = soleOwned
+		return result
+	}
+
+	if err := h.adminService.DeleteKey(ctx, accessKey); err != nil {
+		result.Error = "Failed to delete user: " + err.Error()
+		return result
+	}
+
+	// The deleted key may be the one cached for these buckets
+	for _, bucket := range keyInfo.Buckets {
+		for _, alias := range bucket.GlobalAliases {
+			h.s3Service.InvalidateBucketCredentials(alias)
+		}
+	}
+
+	h.notifier.Notify(models.KeyNotification{Event: models.KeyEventKeyDelete, AccessKeyID: accessKey, Actor: event.Actor})
+	result.Deleted = true
+	return result
+}
+
+// Bounds of UpdateBucketPermissionsBulk
+const (
+	maxBulkBuckets        = 500
+	bulkPermissionWorkers = 8
+)
+
+// UpdateBucketPermissionsBulk grants or revokes one key's permissions on several buckets
+//
+//	@Summary		Bulk grant or revoke bucket permissions
+//	@Description	Grants or revokes the given permissions of a key on several buckets, resolved by global alias. buckets ["*"] applies to every bucket and requires confirm_all. Admin only.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			access_key	path		string														true	"Access key to grant or revoke"
+//	@Param			request		body		models.BulkBucketPermissionRequest							true	"Buckets, permissions and action"
+//	@Success		200			{object}	models.APIResponse{data=models.BulkBucketPermissionResponse}	"Applied to every bucket"
+//	@Success		207			{object}	models.APIResponse{data=models.BulkBucketPermissionResponse}	"Applied to some buckets only"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}					"Invalid request body"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}					"Administrator privileges required"
+//	@Failure		500			{object}	models.APIResponse{data=models.BulkBucketPermissionResponse}	"Not applied to any bucket"
+//	@Router			/api/v1/users/{access_key}/buckets/bulk [post]
+func (h *UserHandler) UpdateBucketPermissionsBulk(c fiber.Ctx) error {
+	ctx := c.Context()
+	accessKey := c.Params("access_key")
+
+	var req models.BulkBucketPermissionRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	if req.Action != "grant" && req.Action != "revoke" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "action must be grant or revoke"),
+		)
+	}
+	if !req.Permissions.Read && !req.Permissions.Write && !req.Permissions.Owner {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "At least one permission is required"),
+		)
+	}
+
+	// Drop empty entries and duplicates, keeping the request order
+	buckets := make([]string, 0, len(req.Buckets))
+	seen := make(map[string]bool, len(req.Buckets))
+	for _, bucket := range req.Buckets {
+		if bucket == "" || seen[bucket] {
+			continue
+		}
+		seen[bucket] = true
+		buckets = append(buckets, bucket)
+	}
+
+	if seen["*"] {
+		if len(buckets) > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "\"*\" cannot be combined with bucket names"),
+			)
+		}
+		if !req.ConfirmAll {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "confirm_all must be set to apply to every bucket"),
+			)
+		}
+
+		all, _, err := h.adminService.ListBuckets(ctx)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to list buckets: "+err.Error()),
+			)
+		}
+		buckets = buckets[:0]
+		for _, bucket := range all {
+			if len(bucket.GlobalAliases) > 0 {
+				buckets = append(buckets, bucket.GlobalAliases[0])
+			}
+		}
+		sort.Strings(buckets)
+	}
+
+	if len(buckets) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "At least one bucket is required"),
+		)
+	}
+	if len(buckets) > maxBulkBuckets && !seen["*"] {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("At most %d buckets can be updated at once", maxBulkBuckets)),
+		)
+	}
+
+	// Each result has its own slot, so workers never share state
+	results := make([]models.BucketPermissionResult, len(buckets))
+	var group errgroup.Group
+	group.SetLimit(bulkPermissionWorkers)
+	for i, bucket := range buckets {
+		group.Go(func() error {
+			results[i] = h.applyBucketPermission(ctx, accessKey, bucket, req.Action, req.Permissions)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	response := models.BulkBucketPermissionResponse{
+		AccessKey: accessKey,
+		Action:    req.Action,
+		Total:     len(results),
+		Results:   results,
+	}
+	event := newAuditEvent(c, "key.bucket."+req.Action, accessKey)
+
+	notificationEvent := models.KeyEventPermissionGrant
+	if req.Action == "revoke" {
+		notificationEvent = models.KeyEventPermissionRevoke
+	}
+	for _, result := range results {
+		if !result.Success {
+			response.FailureCount++
+			continue
+		}
+		response.SuccessCount++
+		h.notifier.Notify(models.KeyNotification{
+			Event:       notificationEvent,
+			AccessKeyID: accessKey,
+			Bucket:      result.Bucket,
+			Permissions: formatPermissions(req.Permissions),
+			Actor:       event.Actor,
+		})
+	}
+
+	event.Success = response.FailureCount == 0
+	event.Details = map[string]string{
+		"buckets":     strings.Join(buckets, ","),
+		"permissions": formatPermissions(req.Permissions),
+		"failed":      fmt.Sprint(response.FailureCount),
+	}
+	h.auditLog.Record(event)
+
+	// Return 200 if all succeeded, 207 (Multi-Status) if partial success, 500 if all failed
+	statusCode := fiber.StatusOK
+	if response.FailureCount > 0 && response.SuccessCount > 0 {
+		statusCode = fiber.StatusMultiStatus // 207
+	} else if response.FailureCount > 0 && response.SuccessCount == 0 {
+		statusCode = fiber.StatusInternalServerError
+	}
+
+	return c.Status(statusCode).JSON(models.SuccessResponse(response))
+}
+
+// applyBucketPermission grants or revokes a key's permissions on one bucket resolved by alias
+func (h *UserHandler) applyBucketPermission(ctx context.Context, accessKey, bucket, action string, permissions models.BucketKeyPermission) models.BucketPermissionResult {
+	result := models.BucketPermissionResult{Bucket: bucket}
+
+	if action == "grant" && (permissions.Write || permissions.Owner) && h.settingsStore.IsReadOnly(bucket) {
+		result.Error = "Bucket is read-only; only read access can be granted"
+		return result
+	}
+
+	bucketInfo, err := h.adminService.GetCachedBucketInfoByAlias(ctx, bucket)
+	if err != nil {
+		result.Error = "Failed to get bucket info: " + err.Error()
+		return result
+	}
+
+	permRequest := models.BucketKeyPermRequest{
+		BucketID:    bucketInfo.ID,
+		AccessKeyID: accessKey,
+		Permissions: permissions,
+	}
+	if action == "grant" {
+		_, err = h.adminService.AllowBucketKey(ctx, permRequest)
+	} else {
+		_, err = h.adminService.DenyBucketKey(ctx, permRequest)
+	}
+	if err != nil {
+		result.Error = "Failed to " + action + " permissions: " + err.Error()
+		return result
+	}
+
+	// A revoked key may be the one cached for this bucket
+	if action == "revoke" {
+		h.s3Service.InvalidateBucketCredentials(bucket)
+	}
+
+	result.Success = true
+	return result
+}
+
+// formatPermissions renders permissions as a compact list such as "read,write"
+func formatPermissions(permissions models.BucketKeyPermission) string {
+	var names []string
+	if permissions.Read {
+		names = append(names, "read")
+	}
+	if permissions.Write {
+		names = append(names, "write")
+	}
+	if permissions.Owner {
+		names = append(names, "owner")
+	}
+	return strings.Join(names, ",")
+}
+
 // GetUser retrieves information about a specific user/access key
 //
 //	@Summary		Get user information
@@ -241,13 +625,15 @@ func (h *UserHandler) GetUser(c fiber.Ctx) error {
 	userInfo := models.UserInfo{
 		AccessKeyID:       keyInfo.AccessKeyID,
 		Name:              keyInfo.Name,
-		CreatedAt:         keyInfo.Created,
+		CreatedAt:         utils.UTCPtr(keyInfo.Created),
 		Status:            status,
 		BucketPermissions: bucketPermissions,
-		Expiration:        keyInfo.Expiration,
+		Expiration:        utils.UTCPtr(keyInfo.Expiration),
 		Expired:           keyInfo.Expired,
+		ETag:              utils.StateETag(keyInfo),
 	}
 
+	c.Set(fiber.HeaderETag, userInfo.ETag)
 	return c.JSON(models.SuccessResponse(userInfo))
 }
 
@@ -286,6 +672,81 @@ func (h *UserHandler) GetUserSecretKey(c fiber.Ctx) error {
 	}))
 }
 
+// TestUserKey verifies a key's S3 connectivity end-to-end
+//
+//	@Summary		Test user key connectivity
+//	@Description	Runs ListBuckets, and HeadBucket when a bucket is supplied, against the S3 API using the key's own credentials. Admin only.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			access_key	path		string											true	"Access key of the user to test"
+//	@Param			request		body		models.TestUserKeyRequest						false	"Optional bucket to check"
+//	@Success		200			{object}	models.APIResponse{data=models.KeyTestResponse}	"Checks ran (see per-check results)"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}		"Access key is required or invalid request body"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}		"Administrator privileges required"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}		"Failed to get key info"
+//	@Router			/api/v1/users/{access_key}/test [post]
+func (h *UserHandler) TestUserKey(c fiber.Ctx) error {
+	ctx := c.Context()
+	accessKey := c.Params("access_key")
+
+	if accessKey == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Access key is required"),
+		)
+	}
+
+	// The body is optional
+	var req models.TestUserKeyRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+			)
+		}
+	}
+
+	event := newAuditEvent(c, "key.test", accessKey)
+	if req.Bucket != "" {
+		event.Details = map[string]string{"bucket": req.Bucket}
+	}
+
+	// Get key information WITH secret key
+	keyInfo, err := h.adminService.GetKeyInfo(ctx, accessKey, true)
+	if err != nil || keyInfo.SecretAccessKey == nil {
+		h.auditLog.Record(event)
+		if err == nil {
+			err = fmt.Errorf("secret key not returned by Garage")
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to get key info: "+err.Error()),
+		)
+	}
+
+	checks, err := h.s3Service.TestKeyConnectivity(ctx, accessKey, *keyInfo.SecretAccessKey, req.Bucket)
+	if err != nil {
+		h.auditLog.Record(event)
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to test key: "+err.Error()),
+		)
+	}
+
+	response := models.KeyTestResponse{
+		AccessKeyID: accessKey,
+		Bucket:      req.Bucket,
+		Success:     true,
+		Checks:      checks,
+	}
+	for _, check := range checks {
+		response.Success = response.Success && check.Success
+	}
+
+	event.Success = response.Success
+	h.auditLog.Record(event)
+
+	return c.JSON(models.SuccessResponse(response))
+}
+
 // UpdateUserPermissions updates user permissions
 //
 //	@Summary		Update user permissions
@@ -295,8 +756,10 @@ func (h *UserHandler) GetUserSecretKey(c fiber.Ctx) error {
 //	@Produce		json
 //	@Param			access_key	path		string										true	"Access key of the user to update"
 //	@Param			request		body		models.UpdateUserRequest					true	"User update request with new permissions"
+//	@Param			If-Match	header		string										false	"etag of the key as loaded; the update is refused if the key changed since"
 //	@Success		200			{object}	models.APIResponse{data=models.UserInfo}	"User updated successfully"
 //	@Failure		400			{object}	models.APIResponse{error=models.APIError}	"Access key is required or invalid request body"
+//	@Failure		409			{object}	models.APIResponse{data=models.EditConflict}	"The key changed since it was loaded"
 //	@Failure		500			{object}	models.APIResponse{error=models.APIError}	"Failed to update user"
 //	@Router			/api/v1/users/{access_key} [patch]
 func (h *UserHandler) UpdateUserPermissions(c fiber.Ctx) error {
@@ -334,7 +797,7 @@ func (h *UserHandler) UpdateUserPermissions(c fiber.Ctx) error {
 
 	// Handle explicit expiration date setting
 	if req.Expiration != nil && *req.Expiration != "" {
-		expirationTime, err := time.Parse(time.RFC3339, *req.Expiration)
+		expirationTime, err := utils.ParseTimestamp(*req.Expiration)
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(
 				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid expiration date format: "+err.Error()),
@@ -344,6 +807,26 @@ func (h *UserHandler) UpdateUserPermissions(c fiber.Ctx) error {
 		updateReq.NeverExpires = false
 	}
 
+	// Refuse the update when the key changed since the editor loaded it. Garage has no
+	// conditional update, so this narrows the race to the two Admin API calls below.
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		current, err := h.adminService.GetKeyInfo(ctx, accessKey, false)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to get user info: "+err.Error()),
+			)
+		}
+
+		if etag := utils.StateETag(current); !utils.ETagMatches(ifMatch, etag) {
+			currentInfo := keyInfoToUserInfo(current)
+			currentInfo.ETag = etag
+			return c.Status(fiber.StatusConflict).JSON(models.ConflictResponse(
+				"The key was modified by someone else; reload it and apply your changes again",
+				models.EditConflict{ProvidedETag: ifMatch, CurrentETag: etag, Current: currentInfo, Requested: req},
+			))
+		}
+	}
+
 	// Update the key
 	keyInfo, err := h.adminService.UpdateKey(ctx, accessKey, updateReq)
 	if err != nil {
@@ -365,12 +848,124 @@ func (h *UserHandler) UpdateUserPermissions(c fiber.Ctx) error {
 	userInfo := models.UserInfo{
 		AccessKeyID:       keyInfo.AccessKeyID,
 		Name:              keyInfo.Name,
-		CreatedAt:         keyInfo.Created,
+		CreatedAt:         utils.UTCPtr(keyInfo.Created),
 		Status:            status,
 		BucketPermissions: bucketPermissions,
-		Expiration:        keyInfo.Expiration,
+		Expiration:        utils.UTCPtr(keyInfo.Expiration),
 		Expired:           keyInfo.Expired,
+		ETag:              utils.StateETag(keyInfo),
 	}
 
+	c.Set(fiber.HeaderETag, userInfo.ETag)
 	return c.JSON(models.SuccessResponse(userInfo))
 }
+
+// GetUserActivity returns the requests a key made recently, by bucket and verb
+//
+//	@Summary		Get key activity
+//	@Description	Returns how many S3 requests the key made over a time window, by bucket and API endpoint, counted from Garage's request metrics sampled every monitoring.key_activity_interval. Counts are kept in memory for 24 hours at hourly granularity, and requests made before the server started are not counted. Garage builds that do not label their request metrics with the access key cannot attribute requests to keys: 501 UNSUPPORTED is returned then, rather than counts of zero. Admin only.
+//	@Tags			Users
+//	@Produce		json
+//	@Param			access_key	path		string												true	"Access key"
+//	@Param			window		query		string												false	"Time window as a duration (default: 24h, max: 24h)"
+//	@Success		200			{object}	models.APIResponse{data=models.KeyActivityResponse}	"Key activity"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}			"Invalid window"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}			"Administrator privileges required"
+//	@Failure		501			{object}	models.APIResponse{error=models.APIError}			"Garage does not expose per-key metrics, or sampling is disabled"
+//	@Failure		503			{object}	models.APIResponse{error=models.APIError}			"The metrics have not been sampled yet"
+//	@Router			/api/v1/users/{access_key}/activity [get]
+func (h *UserHandler) GetUserActivity(c fiber.Ctx) error {
+	window := services.KeyActivityRetention
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > services.KeyActivityRetention {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "Invalid window: must be a positive duration no longer than "+services.KeyActivityRetention.String()),
+			)
+		}
+		window = parsed
+	}
+
+	switch {
+	case !h.keyActivity.Enabled():
+		return c.Status(fiber.StatusNotImplemented).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, "Key activity is disabled (monitoring.key_activity_interval is 0)"),
+		)
+	case h.keyActivity.SampledAt().IsZero():
+		return c.Status(fiber.StatusServiceUnavailable).JSON(
+			models.ErrorResponse(models.ErrCodeUpstream, "Garage metrics have not been sampled yet"),
+		)
+	case !h.keyActivity.Supported():
+		return c.Status(fiber.StatusNotImplemented).JSON(
+			models.ErrorResponse(models.ErrCodeUnsupported, "Garage does not expose per-key request metrics"),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(h.keyActivity.Activity(c.Params("access_key"), window)))
+}
+
+// GetUserNotifications returns where changes to a key's access are announced
+//
+//	@Summary		Get key notification target
+//	@Description	Returns the webhook notified when the key's bucket permissions are granted or revoked or the key is deleted, and the contact passed along with each notification. Admin only.
+//	@Tags			Users
+//	@Produce		json
+//	@Param			access_key	path		string													true	"Access key"
+//	@Success		200			{object}	models.APIResponse{data=models.KeyNotificationTarget}	"Notification target, empty when none is set"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}				"Administrator privileges required"
+//	@Router			/api/v1/users/{access_key}/notifications [get]
+func (h *UserHandler) GetUserNotifications(c fiber.Ctx) error {
+	target, _ := h.settingsStore.KeyNotificationTarget(c.Params("access_key"))
+	return c.JSON(models.SuccessResponse(target))
+}
+
+// UpdateUserNotifications replaces where changes to a key's access are announced
+//
+//	@Summary		Set key notification target
+//	@Description	Sets the webhook receiving a JSON POST whenever the key's bucket permissions are granted or revoked or the key is deleted, and an email-style contact included in each notification. An empty body removes the target. Deliveries are retried in the background and listed under /api/v1/admin/notifications. Admin only.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			access_key	path		string													true	"Access key"
+//	@Param			request		body		models.KeyNotificationTarget							true	"Notification target"
+//	@Success		200			{object}	models.APIResponse{data=models.KeyNotificationTarget}	"Notification target updated"
+//	@Failure		400			{object}	models.APIResponse{error=models.APIError}				"Invalid request body or webhook URL"
+//	@Failure		403			{object}	models.APIResponse{error=models.APIError}				"Administrator privileges required"
+//	@Failure		500			{object}	models.APIResponse{error=models.APIError}				"Failed to save the target"
+//	@Router			/api/v1/users/{access_key}/notifications [put]
+func (h *UserHandler) UpdateUserNotifications(c fiber.Ctx) error {
+	accessKey := c.Params("access_key")
+	if accessKey == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Access key is required"),
+		)
+	}
+
+	var target models.KeyNotificationTarget
+	if err := c.Bind().JSON(&target); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	if target.WebhookURL != "" {
+		webhook, err := url.Parse(target.WebhookURL)
+		if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, "webhookUrl must be an http or https URL"),
+			)
+		}
+	}
+
+	if err := h.settingsStore.SetKeyNotificationTarget(accessKey, target); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to save notification target: "+err.Error()),
+		)
+	}
+
+	event := newAuditEvent(c, "key.notifications", accessKey)
+	event.Success = true
+	h.auditLog.Record(event)
+
+	return c.JSON(models.SuccessResponse(target))
+}
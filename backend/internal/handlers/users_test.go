@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestListUsersSurfacesTruncation(t *testing.T) {
+	for _, tt := range []struct {
+		limit         string
+		wantCount     int
+		wantTruncated bool
+	}{
+		{limit: "2", wantCount: 2, wantTruncated: true},
+		{limit: "3", wantCount: 3},
+	} {
+		t.Run("limit "+tt.limit, func(t *testing.T) {
+			t.Setenv("GARAGE_UI_GARAGE_ADMIN_LIST_LIMIT", tt.limit)
+			env := newTestEnv(t)
+			for _, name := range []string{"a", "b", "c"} {
+				env.addBucket(name)
+			}
+
+			resp := env.request(t, http.MethodGet, "/api/v1/users/", nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+
+			var list models.UserListResponse
+			decodeAPIResponse(t, resp, &list)
+			if len(list.Users) != tt.wantCount || list.Truncated != tt.wantTruncated {
+				t.Errorf("got %d users, truncated %v; want %d, %v", len(list.Users), list.Truncated, tt.wantCount, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestUpdateUserIfMatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.AddKey("GK-app")
+
+	resp := env.request(t, http.MethodGet, "/api/v1/users/GK-app", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GetUser status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var loaded models.UserInfo
+	decodeAPIResponse(t, resp, &loaded)
+	if loaded.ETag == "" || resp.Header.Get("ETag") != loaded.ETag {
+		t.Fatalf("GetUser etag = %q, ETag header = %q; want both set and equal", loaded.ETag, resp.Header.Get("ETag"))
+	}
+
+	// Another admin edits the key first
+	first := env.requestJSON(t, http.MethodPatch, "/api/v1/users/GK-app", map[string]string{"expiration": "2030-01-01T00:00:00Z"}, "If-Match", loaded.ETag)
+	if first.StatusCode != http.StatusOK {
+		t.Fatalf("first update status = %d, want %d", first.StatusCode, http.StatusOK)
+	}
+	var updated models.UserInfo
+	decodeAPIResponse(t, first, &updated)
+	if updated.ETag == loaded.ETag {
+		t.Fatal("etag did not change with the expiration")
+	}
+
+	// The stale edit is refused with both versions
+	stale := env.requestJSON(t, http.MethodPatch, "/api/v1/users/GK-app", map[string]string{"expiration": "2031-01-01T00:00:00Z"}, "If-Match", loaded.ETag)
+	if stale.StatusCode != http.StatusConflict {
+		t.Fatalf("stale update status = %d, want %d", stale.StatusCode, http.StatusConflict)
+	}
+	var conflict struct {
+		ProvidedETag string            `json:"providedEtag"`
+		CurrentETag  string            `json:"currentEtag"`
+		Current      models.UserInfo   `json:"current"`
+		Requested    map[string]string `json:"requested"`
+	}
+	response := decodeAPIResponse(t, stale, &conflict)
+	if response.Error == nil || response.Error.Code != models.ErrCodeConflict {
+		t.Errorf("error = %+v, want %s", response.Error, models.ErrCodeConflict)
+	}
+	if conflict.ProvidedETag != loaded.ETag || conflict.CurrentETag != updated.ETag {
+		t.Errorf("etags = %q, %q; want %q, %q", conflict.ProvidedETag, conflict.CurrentETag, loaded.ETag, updated.ETag)
+	}
+	if conflict.Current.Expiration == nil || conflict.Current.Expiration.Year() != 2030 {
+		t.Errorf("current expiration = %v, want 2030", conflict.Current.Expiration)
+	}
+	if conflict.Requested["expiration"] != "2031-01-01T00:00:00Z" {
+		t.Errorf("requested = %v, want the rejected expiration", conflict.Requested)
+	}
+
+	// Reloading and retrying with the current etag succeeds
+	retry := env.requestJSON(t, http.MethodPatch, "/api/v1/users/GK-app", map[string]string{"expiration": "2031-01-01T00:00:00Z"}, "If-Match", conflict.CurrentETag)
+	if retry.StatusCode != http.StatusOK {
+		t.Errorf("retried update status = %d, want %d", retry.StatusCode, http.StatusOK)
+	}
+}
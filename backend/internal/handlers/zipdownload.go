@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// PrepareZipDownload lays out a ZIP archive of several objects to be downloaded in parts
+//
+//	@Summary		Prepare a ZIP download
+//	@Description	Lays out a ZIP archive of the given keys, or of every object under a prefix, and returns its manifest: the archived keys with their sizes, the archive size and the number of parts, under a token valid for server.zip_manifest_ttl. Each part can then be fetched, and fetched again after a failure, on its own; concatenating the parts in order gives the archive. Parts can be regenerated independently because the archive is deterministic: entries are stored uncompressed, in key order, with the modification time of the object, and no ZIP64 records are written, so an archive holds at most 65535 entries and 4 GiB. Each object is stored under its key cleaned into a relative path, without a leading / or .. segments; keys longer than 255 bytes, and keys whose cleaned path an earlier entry already has (such as a//b after a/b), are stored under their SHA-256, keeping the extension, and the name of every entry is in the manifest. Folder markers (keys ending in /) are left out and keys that cannot be found are skipped.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		json
+//	@Param			bucket	path		string												true	"Name of the bucket"
+//	@Param			request	body		models.ZipDownloadRequest							true	"Keys, or a prefix"
+//	@Success		200		{object}	models.APIResponse{data=models.ZipDownloadManifest}	"Archive prepared"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}			"Invalid request body, or the archive would be too large"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}			"Failed to prepare the archive"
+//	@Router			/api/v1/buckets/{bucket}/objects/download-zip/prepare [post]
+func (h *ObjectHandler) PrepareZipDownload(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	var req models.ZipDownloadRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	partSize := req.PartSize
+	if partSize == 0 {
+		partSize = services.ZipDefaultPartSize
+	}
+	if partSize < services.ZipMinPartSize || partSize > services.ZipMaxPartSize {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("part_size must be between %d and %d bytes", services.ZipMinPartSize, services.ZipMaxPartSize)),
+		)
+	}
+
+	var objects []models.ObjectInfo
+	switch {
+	case len(req.Keys) > 0 && req.Prefix != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Either keys or a prefix must be given, not both"),
+		)
+
+	case len(req.Keys) > 0:
+		if len(req.Keys) > services.ZipMaxEntries {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("An archive holds at most %d keys", services.ZipMaxEntries)),
+			)
+		}
+		if slices.Contains(req.Keys, "") {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Object keys must not be empty"),
+			)
+		}
+
+		// Sizes, ETags and times come from the metadata; keys that cannot be found are left out
+		results, err := h.s3Service.GetObjectsMetadata(ctx, bucketName, slices.Compact(slices.Sorted(slices.Values(req.Keys))))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeInternalError, "Failed to get metadata: "+err.Error()),
+			)
+		}
+		for _, result := range results {
+			if result.Object != nil {
+				objects = append(objects, *result.Object)
+			}
+		}
+
+	case req.Prefix != nil:
+		var truncated bool
+		var err error
+		objects, truncated, err = h.s3Service.ListObjectsRecursive(ctx, bucketName, *req.Prefix, services.ZipMaxEntries)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(
+				models.ErrorResponse(models.ErrCodeListFailed, "Failed to list objects: "+err.Error()),
+			)
+		}
+		if truncated {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, services.ErrZipArchiveTooLarge.Error()),
+			)
+		}
+
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Keys or a prefix are required"),
+		)
+	}
+
+	username, _ := c.Locals("username").(string)
+	token, archive, err := services.PrepareZipArchive(bucketName, username, objects, partSize, h.zipManifestTTL)
+	if errors.Is(err, services.ErrZipArchiveTooLarge) {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to prepare the archive: "+err.Error()),
+		)
+	}
+
+	return c.JSON(models.SuccessResponse(archive.Manifest(token)))
+}
+
+// errTransferIdle aborts a transfer that made no progress for its idle budget
+var errTransferIdle = errors.New("transfer made no progress within its idle time budget")
+
+// idleWriter postpones the idle timer of a transfer every time bytes are written
+type idleWriter struct {
+	io.Writer
+	timer *time.Timer
+	idle  time.Duration
+}
+
+// Write implements io.Writer
+func (w idleWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.timer.Reset(w.idle)
+	return n, err
+}
+
+// DownloadZipPart streams one part of a prepared ZIP download
+//
+//	@Summary		Download a part of a ZIP download
+//	@Description	Streams part N (counted from 0) of an archive prepared with /download-zip/prepare: its bytes N*partSize to (N+1)*partSize, the last part being shorter. A part is regenerated from the objects each time it is fetched, so a failed part can simply be fetched again. Parts have no time budget (server.timeouts.transfer) but are aborted after server.timeouts.transfer_idle without progress. Before streaming, the objects the part is built from are checked against the manifest; if one was modified or removed since the archive was prepared, 409 is returned and the download must be prepared again. Only the user who prepared the archive can fetch it.
+//	@Tags			Objects
+//	@Produce		application/zip
+//	@Param			bucket	path		string										true	"Name of the bucket"
+//	@Param			token	path		string										true	"Token returned by /download-zip/prepare"
+//	@Param			part	query		int											true	"Part number, from 0"
+//	@Success		200		{file}		file										"Archive bytes of the part"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid part number"
+//	@Failure		404		{object}	models.APIResponse{error=models.APIError}	"Unknown or expired token"
+//	@Failure		409		{object}	models.APIResponse{error=models.APIError}	"An object changed since the archive was prepared"
+//	@Failure		500		{object}	models.APIResponse{error=models.APIError}	"Failed to build the part"
+//	@Router			/api/v1/buckets/{bucket}/objects/download-zip/{token} [get]
+func (h *ObjectHandler) DownloadZipPart(c fiber.Ctx) error {
+	ctx := c.Context()
+	bucketName := bucketParam(c)
+
+	username, _ := c.Locals("username").(string)
+	archive, ok := services.LookupZipArchive(c.Params("token"), username)
+	if !ok || archive.Bucket != bucketName {
+		return c.Status(fiber.StatusNotFound).JSON(
+			models.ErrorResponse(models.ErrCodeNotFound, "ZIP download not found or expired"),
+		)
+	}
+
+	part, err := strconv.Atoi(c.Query("part"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid part parameter"),
+		)
+	}
+	start, end, err := archive.PartRange(part)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, err.Error()),
+		)
+	}
+
+	if err := archive.PreparePart(ctx, h.s3Service, part); err != nil {
+		if errors.Is(err, services.ErrZipObjectChanged) {
+			return c.Status(fiber.StatusConflict).JSON(
+				models.ErrorResponse(models.ErrCodeConflict, err.Error()),
+			)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(
+			models.ErrorResponse(models.ErrCodeInternalError, "Failed to build the part: "+err.Error()),
+		)
+	}
+
+	// The part is written as it is read; an error midway cuts the body short, so the
+	// client sees fewer bytes than announced and fetches the part again. Parts have no time
+	// budget, but one making no progress for server.timeouts.transfer_idle is aborted.
+	reader, writer := io.Pipe()
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out io.Writer = writer
+		if h.transferIdle > 0 {
+			idle := time.AfterFunc(h.transferIdle, func() {
+				cancel()
+				writer.CloseWithError(errTransferIdle)
+			})
+			defer idle.Stop()
+			out = idleWriter{Writer: writer, timer: idle, idle: h.transferIdle}
+		}
+		writer.CloseWithError(archive.WritePart(ctx, h.s3Service, out, part))
+	}()
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, utils.ContentDisposition("attachment", fmt.Sprintf("%s.zip.%03d", bucketName, part)))
+	c.Set("X-Zip-Part-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, archive.Size))
+
+	counted := h.transferStats.CountReader(transferUser(c), services.TransferDownload, reader)
+	return c.SendStream(middleware.CountResponseBody(c, counted), int(end-start))
+}
+
+// DownloadArchive streams several objects as one ZIP archive
+//
+//	@Summary		Download objects as a ZIP archive
+//	@Description	Streams a ZIP archive of the given keys, or of every object under a prefix, in the response as the objects are read. Unlike /download-zip/prepare there are no size or entry limits, since ZIP64 records are written when needed, but the download cannot be resumed: an error midway cuts the archive short and it must be downloaded again. Entries are stored uncompressed under their key cleaned into a relative path, without a leading / or .. segments, or under its SHA-256, keeping the extension, when longer than 255 bytes or when an earlier entry already has the cleaned path; folder markers (keys ending in /) and keys that cannot be found are left out. The stream has no time budget (server.timeouts.transfer) but is aborted after server.timeouts.transfer_idle without progress.
+//	@Tags			Objects
+//	@Accept			json
+//	@Produce		application/zip
+//	@Param			bucket	path		string										true	"Name of the bucket"
+//	@Param			request	body		models.ZipStreamRequest						true	"Keys, or a prefix"
+//	@Success		200		{file}		file										"ZIP archive"
+//	@Failure		400		{object}	models.APIResponse{error=models.APIError}	"Invalid request body"
+//	@Router			/api/v1/buckets/{bucket}/objects/download-archive [post]
+func (h *ObjectHandler) DownloadArchive(c fiber.Ctx) error {
+	bucketName := bucketParam(c)
+
+	var req models.ZipStreamRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Invalid request body: "+err.Error()),
+		)
+	}
+
+	name := bucketName
+	switch {
+	case len(req.Keys) > 0 && req.Prefix != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Either keys or a prefix must be given, not both"),
+		)
+
+	case len(req.Keys) > 0:
+		if len(req.Keys) > services.ZipMaxEntries {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("At most %d keys can be listed; use a prefix for more", services.ZipMaxEntries)),
+			)
+		}
+		if slices.Contains(req.Keys, "") {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Object keys must not be empty"),
+			)
+		}
+
+	case req.Prefix != nil:
+		// A folder is named after its last segment
+		if folder := path.Base(strings.TrimSuffix(*req.Prefix, "/")); folder != "." && folder != "/" {
+			name = folder
+		}
+
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(
+			models.ErrorResponse(models.ErrCodeBadRequest, "Keys or a prefix are required"),
+		)
+	}
+
+	// The archive is written as it is read; an error midway cuts the body short. The stream
+	// has no time budget, but one making no progress for server.timeouts.transfer_idle is aborted.
+	reader, writer := io.Pipe()
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out io.Writer = writer
+		if h.transferIdle > 0 {
+			idle := time.AfterFunc(h.transferIdle, func() {
+				cancel()
+				writer.CloseWithError(errTransferIdle)
+			})
+			defer idle.Stop()
+			out = idleWriter{Writer: writer, timer: idle, idle: h.transferIdle}
+		}
+		_, err := h.s3Service.WriteZipStream(ctx, out, bucketName, req.Keys, req.Prefix)
+		writer.CloseWithError(err)
+	}()
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, utils.ContentDisposition("attachment", name+".zip"))
+
+	counted := h.transferStats.CountReader(transferUser(c), services.TransferDownload, reader)
+	return c.SendStream(middleware.CountResponseBody(c, counted))
+}
@@ -0,0 +1,90 @@
+// Package lifecycle coordinates the background components of the server, so that shutdown
+// stops all of them instead of leaving goroutines running until the process exits.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// Manager runs background components with a context that is canceled on shutdown, and
+// waits for them to return
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int // Running components by name
+}
+
+// NewManager creates a manager with no components
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]int),
+	}
+}
+
+// Context returns the context canceled on shutdown, for work that is not a component
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in its own goroutine as the named component. fn must return once ctx is
+// canceled. Components started after Shutdown return at once.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			logger.Debug().Str("component", name).Msg("Background component stopped")
+		}()
+
+		logger.Debug().Str("component", name).Msg("Background component started")
+		fn(m.ctx)
+	}()
+}
+
+// Shutdown cancels every component and waits up to timeout for them to return. The error
+// names the components still running when the timeout expired.
+func (m *Manager) Shutdown(timeout time.Duration) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return fmt.Errorf("background components still running after %s: %v", timeout, names)
+}
@@ -0,0 +1,91 @@
+package lifecycle
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// waitForGoroutines waits for the number of goroutines to drop to at most want, and returns
+// the last count seen
+func waitForGoroutines(want int) int {
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := runtime.NumGoroutine()
+		if got <= want || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShutdownStopsComponents(t *testing.T) {
+	jwtService, err := auth.NewJWTService()
+	if err != nil {
+		t.Fatalf("NewJWTService failed: %v", err)
+	}
+	cache := utils.NewCache()
+
+	before := runtime.NumGoroutine()
+
+	manager := NewManager()
+	manager.Go("cache janitor", cache.RunJanitor)
+	manager.Go("login state janitor", jwtService.RunStateJanitor)
+	for range 3 {
+		manager.Go("worker", func(ctx context.Context) {
+			ticker := time.NewTicker(time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+	if got := runtime.NumGoroutine(); got < before+5 {
+		t.Fatalf("%d goroutines running, want at least %d", got, before+5)
+	}
+
+	if err := manager.Shutdown(time.Second); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if got := waitForGoroutines(before); got > before {
+		t.Errorf("%d goroutines running after shutdown, want %d", got, before)
+	}
+
+	// Components started late see the canceled context at once
+	done := make(chan struct{})
+	manager.Go("late", func(ctx context.Context) {
+		<-ctx.Done()
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("component started after shutdown kept running")
+	}
+}
+
+func TestShutdownTimeoutNamesStuckComponents(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	manager := NewManager()
+	manager.Go("stopping", func(ctx context.Context) { <-ctx.Done() })
+	manager.Go("stuck", func(ctx context.Context) { <-release })
+
+	err := manager.Shutdown(50 * time.Millisecond)
+	if err == nil {
+		t.Fatal("Shutdown succeeded with a component ignoring its context")
+	}
+	if !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "stopping") {
+		t.Errorf("error = %q, want only the stuck component named", err)
+	}
+}
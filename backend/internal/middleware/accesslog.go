@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// accessLogKey is the locals key under which the in-flight access log entry is stored
+const accessLogKey = "accessLogEntry"
+
+// accessLogEntry holds the fields of a single access log line. Fields are copied out of
+// the Fiber context because streamed responses are written after the context is released.
+type accessLogEntry struct {
+	start         time.Time
+	method        string
+	path          string
+	status        int
+	ip            string
+	userAgent     string
+	username      string
+	authMethod    string
+	bucket        string
+	key           string
+	requestBytes  int64
+	responseBytes atomic.Int64
+	streaming     bool
+	skip          bool // Left out by the exclusions or sampling
+	once          sync.Once
+}
+
+// accessLogFilter decides which requests are logged from logging.access_log_exclude and
+// logging.access_log_sample_rate
+type accessLogFilter struct {
+	exclude    []string
+	sampleRate float64
+	seen       atomic.Uint64 // Requests considered for sampling
+}
+
+// keep reports whether a request is logged. Errors are always logged. Sampling is
+// deterministic: with a rate of 1/N, every Nth request is logged.
+func (f *accessLogFilter) keep(path string, status int) bool {
+	if status >= fiber.StatusBadRequest {
+		return true
+	}
+
+	for _, prefix := range f.exclude {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+
+	if f.sampleRate >= 1 {
+		return true
+	}
+	n := f.seen.Add(1)
+	return uint64(float64(n)*f.sampleRate) != uint64(float64(n-1)*f.sampleRate)
+}
+
+// AccessLogMiddleware logs one structured line per request with user attribution and byte counts
+func AccessLogMiddleware(cfg *config.LoggingConfig) fiber.Handler {
+	// If the access log is disabled, return a no-op middleware
+	if !cfg.AccessLog {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	filter := &accessLogFilter{exclude: cfg.AccessLogExclude, sampleRate: cfg.AccessLogSampleRate}
+
+	return func(c fiber.Ctx) error {
+		entry := &accessLogEntry{start: time.Now()}
+		c.Locals(accessLogKey, entry)
+
+		// Run the error handler here so the logged status matches what the client receives
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		entry.capture(c)
+		entry.skip = !filter.keep(entry.path, entry.status)
+
+		// Streamed bodies are counted as they are written and logged once the stream is closed
+		if entry.streaming && c.Response().IsBodyStream() {
+			return nil
+		}
+
+		entry.responseBytes.Store(int64(len(c.Response().Body())))
+		entry.emit()
+		return nil
+	}
+}
+
+// CountResponseBody wraps a response body stream so the access log records the bytes
+// actually sent to the client rather than the advertised Content-Length
+func CountResponseBody(c fiber.Ctx, body io.Reader) io.Reader {
+	entry, ok := c.Locals(accessLogKey).(*accessLogEntry)
+	if !ok {
+		return body
+	}
+
+	entry.streaming = true
+	return &countingReader{reader: body, entry: entry}
+}
+
+// capture copies the request attributes out of the Fiber context
+func (e *accessLogEntry) capture(c fiber.Ctx) {
+	path := c.Path()
+	if decoded, err := url.PathUnescape(path); err == nil {
+		path = decoded
+	}
+
+	e.method = strings.Clone(c.Method())
+	e.path = strings.Clone(path)
+	e.status = c.Response().StatusCode()
+	e.ip = strings.Clone(c.IP())
+	e.userAgent = strings.Clone(c.Get("User-Agent"))
+	e.bucket = strings.Clone(c.Params("bucket"))
+
+	if username, ok := c.Locals("username").(string); ok {
+		e.username = strings.Clone(username)
+	}
+	if authMethod, ok := c.Locals("authMethod").(string); ok {
+		e.authMethod = authMethod
+	}
+	if key, ok := c.Locals("objectKey").(string); ok {
+		e.key = strings.Clone(key)
+	}
+
+	e.requestBytes = int64(c.Request().Header.ContentLength())
+	// Reading a streamed body here would buffer whatever the handler left unread
+	if e.requestBytes < 0 && !c.Request().IsBodyStream() {
+		e.requestBytes = int64(len(c.Request().Body()))
+	}
+}
+
+// emit writes the access log line unless the request was left out; it is safe to call
+// more than once
+func (e *accessLogEntry) emit() {
+	if e.skip {
+		return
+	}
+	e.once.Do(func() {
+		event := logger.Info().
+			Str("method", e.method).
+			Str("path", e.path).
+			Int("status", e.status).
+			Dur("latency", time.Since(e.start)).
+			Int64("request_bytes", e.requestBytes).
+			Int64("response_bytes", e.responseBytes.Load()).
+			Str("ip", e.ip).
+			Str("user_agent", e.userAgent)
+
+		if e.username != "" {
+			event = event.Str("username", e.username)
+		}
+		if e.authMethod != "" {
+			event = event.Str("auth_method", e.authMethod)
+		}
+		if e.bucket != "" {
+			event = event.Str("bucket", e.bucket)
+		}
+		if e.key != "" {
+			event = event.Str("key", e.key)
+		}
+
+		event.Msg("Access")
+	})
+}
+
+// countingReader counts bytes read from a response stream and emits the access log on close
+type countingReader struct {
+	reader io.Reader
+	entry  *accessLogEntry
+}
+
+// Read implements io.Reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.entry.responseBytes.Add(int64(n))
+	return n, err
+}
+
+// Close implements io.Closer; fasthttp closes body streams once the response is written
+// or the client goes away, so this is where partial transfers get logged too
+func (r *countingReader) Close() error {
+	var err error
+	if closer, ok := r.reader.(io.Closer); ok {
+		err = closer.Close()
+	}
+	r.entry.emit()
+	return err
+}
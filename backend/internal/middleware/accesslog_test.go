@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestAccessLogFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		exclude    []string
+		sampleRate float64
+		path       string
+		status     int
+		want       []bool // For consecutive requests
+	}{
+		{name: "every request", sampleRate: 1, path: "/api/v1/buckets", status: fiber.StatusOK, want: []bool{true, true, true}},
+		{name: "excluded prefix", exclude: []string{"/health"}, sampleRate: 1, path: "/health/ready", status: fiber.StatusOK, want: []bool{false, false}},
+		{name: "other path", exclude: []string{"/health"}, sampleRate: 1, path: "/api/v1/health", status: fiber.StatusOK, want: []bool{true}},
+		{name: "sampled", sampleRate: 0.25, path: "/api/v1/monitoring/metrics", status: fiber.StatusOK, want: []bool{false, false, false, true, false, false, false, true}},
+		{name: "excluded error", exclude: []string{"/health"}, sampleRate: 1, path: "/health", status: fiber.StatusServiceUnavailable, want: []bool{true, true}},
+		{name: "sampled errors", sampleRate: 0.25, path: "/api/v1/buckets", status: fiber.StatusNotFound, want: []bool{true, true, true, true}},
+		{name: "nothing sampled", sampleRate: 0, path: "/api/v1/buckets", status: fiber.StatusOK, want: []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sampling is deterministic, so every filter keeps the same requests
+			for range 2 {
+				filter := &accessLogFilter{exclude: tt.exclude, sampleRate: tt.sampleRate}
+				got := make([]bool, len(tt.want))
+				for i := range got {
+					got[i] = filter.keep(tt.path, tt.status)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("kept %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessLogMiddlewareSkipsExcludedAndSampled(t *testing.T) {
+	app := fiber.New()
+	app.Use(AccessLogMiddleware(&config.LoggingConfig{
+		AccessLog:           true,
+		AccessLogExclude:    []string{"/accesslog-test/health"},
+		AccessLogSampleRate: 0.5,
+	}))
+	app.Get("/accesslog-test/health", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/accesslog-test/metrics", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/accesslog-test/fail", func(c fiber.Ctx) error { return fiber.ErrBadGateway })
+
+	logged := func(path string) int {
+		count := 0
+		for _, line := range logger.Recent() {
+			var entry struct {
+				Message string `json:"message"`
+				Path    string `json:"path"`
+			}
+			if json.Unmarshal([]byte(line), &entry) == nil && entry.Message == "Access" && entry.Path == path {
+				count++
+			}
+		}
+		return count
+	}
+
+	for _, path := range []string{"/accesslog-test/health", "/accesslog-test/metrics", "/accesslog-test/fail"} {
+		for range 4 {
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+			if err != nil {
+				t.Fatalf("GET %s failed: %v", path, err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	for path, want := range map[string]int{
+		"/accesslog-test/health":  0, // Excluded
+		"/accesslog-test/metrics": 2, // Every other request
+		"/accesslog-test/fail":    4, // Errors are always logged
+	} {
+		if got := logged(path); got != want {
+			t.Errorf("%s logged %d times, want %d", strings.TrimPrefix(path, "/accesslog-test"), got, want)
+		}
+	}
+}
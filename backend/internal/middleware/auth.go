@@ -9,31 +9,41 @@ import (
 )
 
 // AuthMiddleware supports admin and OIDC authentication
-func AuthMiddleware(cfg *config.AuthConfig, authService *auth.Service) fiber.Handler {
+func AuthMiddleware(cfg *config.AuthConfig, corsCfg *config.CORSConfig, authService *auth.Service) fiber.Handler {
 	return func(c fiber.Ctx) error {
+		// Preflight requests carry no credentials; they are answered by the CORS middleware
+		// and must not be rejected here. Any other OPTIONS request is authenticated.
+		if corsCfg.Enabled && isPreflight(c) {
+			return c.Next()
+		}
+
 		// If no auth is enabled, allow all requests
 		if !cfg.Admin.Enabled && !cfg.OIDC.Enabled {
+			c.Locals("authMethod", auth.AuthMethodNone)
+			c.Locals("isAdmin", true)
 			return c.Next()
 		}
 
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
 
-		// Try admin auth if enabled and header is present
-		if cfg.Admin.Enabled && authHeader != "" {
-			// Check if it's a Bearer token (JWT from admin login)
+		// Try admin auth or API tokens if enabled and header is present
+		if (cfg.Admin.Enabled || cfg.APITokens.Enabled) && authHeader != "" {
+			// Check if it's a Bearer token (JWT from admin login or an API token)
 			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
 				token := authHeader[7:]
 
-				// Validate JWT session token
+				// Validate JWT session token; without admin auth only API tokens are accepted
 				userInfo, err := authService.ValidateSessionToken(token)
-				if err == nil {
-					// Valid admin token
+				if err == nil && (cfg.Admin.Enabled || userInfo.TokenID != "") {
+					// Valid admin or API token
 					c.Locals("userInfo", userInfo)
 					c.Locals("username", userInfo.Username)
 					if userInfo.Email != "" {
 						c.Locals("email", userInfo.Email)
 					}
+					c.Locals("authMethod", userInfo.AuthMethod)
+					c.Locals("isAdmin", authService.IsAdminUser(userInfo))
 					return c.Next()
 				}
 			}
@@ -50,6 +60,8 @@ func AuthMiddleware(cfg *config.AuthConfig, authService *auth.Service) fiber.Han
 					c.Locals("userInfo", userInfo)
 					c.Locals("username", userInfo.Username)
 					c.Locals("email", userInfo.Email)
+					c.Locals("authMethod", userInfo.AuthMethod)
+					c.Locals("isAdmin", authService.IsAdminUser(userInfo))
 					return c.Next()
 				}
 			}
@@ -61,3 +73,16 @@ func AuthMiddleware(cfg *config.AuthConfig, authService *auth.Service) fiber.Han
 		)
 	}
 }
+
+// RequireAdmin restricts a route to administrators. It must run after AuthMiddleware,
+// which decides whether the authenticated user is an admin.
+func RequireAdmin() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if isAdmin, _ := c.Locals("isAdmin").(bool); !isAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(
+				models.ErrorResponse(models.ErrCodeForbidden, "Administrator privileges required"),
+			)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/objectroute"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// BodyLimitMiddleware rejects request bodies larger than limit with 413. The server streams
+// bodies larger than its body limit instead of refusing them, so that streamed uploads can
+// exceed it; every other route is held to the limit here, before anything reads the body.
+func BodyLimitMiddleware(limit int64) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if isStreamedUpload(c) {
+			return c.Next()
+		}
+
+		length := int64(c.Request().Header.ContentLength())
+		if length > limit {
+			return bodyTooLarge(c, limit)
+		}
+
+		// Chunked bodies announce no length: read them here, one byte past the limit at most
+		if length == -1 && c.Request().IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(c.Request().BodyStream(), limit+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(
+					models.ErrorResponse(models.ErrCodeBadRequest, "Failed to read request body: "+err.Error()),
+				)
+			}
+			if int64(len(body)) > limit {
+				return bodyTooLarge(c, limit)
+			}
+			c.Request().SetBody(body)
+		}
+		return c.Next()
+	}
+}
+
+// bodyTooLarge answers 413 and closes the connection, as the unread rest of the body would
+// otherwise be taken for the next request
+func bodyTooLarge(c fiber.Ctx, limit int64) error {
+	c.Response().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(
+		models.ErrorResponse(models.ErrCodeBadRequest, fmt.Sprintf("Request body exceeds the limit of %d bytes", limit)),
+	)
+}
+
+// isStreamedUpload reports whether the request is a raw object upload,
+// PUT /api/v1/buckets/:bucket/objects/<key> without a trailing action
+func isStreamedUpload(c fiber.Ctx) bool {
+	if c.Method() != fiber.MethodPut || !isObjectRoute(c.Path()) {
+		return false
+	}
+
+	_, raw, _ := strings.Cut(strings.TrimPrefix(c.Path(), "/api/v1/buckets/"), "/objects/")
+	if raw == "" || strings.HasPrefix(raw, "multipart/") {
+		return false
+	}
+	_, action, err := objectroute.Parse(raw, objectroute.ActionTags)
+	return err == nil && action == ""
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	const limit = 64
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "small JSON body", method: fiber.MethodPost, path: "/api/v1/buckets", body: `{"name":"photos"}`, wantStatus: fiber.StatusOK},
+		{name: "body at the limit", method: fiber.MethodPost, path: "/api/v1/buckets", body: strings.Repeat("a", limit), wantStatus: fiber.StatusOK},
+		{name: "oversized body", method: fiber.MethodPost, path: "/api/v1/buckets", body: strings.Repeat("a", limit+1), wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "small chunked body", method: fiber.MethodPost, path: "/api/v1/buckets", body: `{"name":"photos"}`, chunked: true, wantStatus: fiber.StatusOK},
+		{name: "oversized chunked body", method: fiber.MethodPost, path: "/api/v1/buckets", body: strings.Repeat("a", 4*limit), chunked: true, wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "oversized streamed upload", method: fiber.MethodPut, path: "/api/v1/buckets/photos/objects/cat.jpg", body: strings.Repeat("a", 4*limit), wantStatus: fiber.StatusOK},
+		{name: "oversized object action", method: fiber.MethodPut, path: "/api/v1/buckets/photos/objects/cat.jpg/tags", body: strings.Repeat("a", 4*limit), wantStatus: fiber.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				BodyLimit:                    limit,
+				StreamRequestBody:            true,
+				DisablePreParseMultipartForm: true,
+			})
+			app.Use(BodyLimitMiddleware(limit))
+			app.All("/*", func(c fiber.Ctx) error {
+				if c.Request().IsBodyStream() && c.Method() == fiber.MethodPut {
+					n, err := io.Copy(io.Discard, c.Request().BodyStream())
+					if err != nil {
+						return err
+					}
+					return c.SendString(strconv.FormatInt(n, 10))
+				}
+				return c.SendString(strconv.Itoa(len(c.Body())))
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			got, _ := io.ReadAll(resp.Body)
+			if string(got) != strconv.Itoa(len(tt.body)) {
+				t.Errorf("handler read %s bytes, want %d", got, len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/url"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DecodeBucketParam percent-decodes the :bucket path parameter the same way object keys
+// are decoded, validates it against the bucket naming rules and stores it in the
+// "bucketName" local for the handlers
+func DecodeBucketParam() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		// The objects group middleware also matches the wildcard object routes
+		if _, ok := c.Locals("bucketName").(string); ok {
+			return c.Next()
+		}
+
+		bucketName, err := url.PathUnescape(c.Params("bucket"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidBucketName, "Invalid bucket name encoding: "+err.Error()),
+			)
+		}
+
+		if err := utils.ValidateBucketName(bucketName); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidBucketName, "Invalid bucket name: "+err.Error()),
+			)
+		}
+
+		c.Locals("bucketName", bucketName)
+		return c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"Noooste/garage-ui/internal/objectroute"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestDecodeBucketParamOnWildcardRoutes(t *testing.T) {
+	// The handler echoes the bucket and key it was routed, as the object handlers read them
+	echo := func(c fiber.Ctx) error {
+		bucketName, _ := c.Locals("bucketName").(string)
+		key, _ := c.Locals("objectKey").(string)
+		c.Set("X-Bucket", bucketName)
+		c.Set("X-Key", key)
+		return c.SendString(bucketName + "|" + key)
+	}
+
+	app := fiber.New()
+	objects := app.Group("/api/v1/buckets/:bucket/objects", DecodeBucketParam())
+	objects.Get("/*", objectroute.Handler(echo, nil))
+	objects.Head("/*", objectroute.Handler(echo, nil))
+	objects.Delete("/*", objectroute.Handler(echo, nil))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBucket string
+		wantKey    string
+	}{
+		{name: "plain", path: "my.bucket-test/objects/a.txt", wantStatus: fiber.StatusOK, wantBucket: "my.bucket-test", wantKey: "a.txt"},
+		{name: "encoded dash", path: "my.bucket%2Dtest/objects/a.txt", wantStatus: fiber.StatusOK, wantBucket: "my.bucket-test", wantKey: "a.txt"},
+		{name: "encoded dot and key", path: "my%2Ebucket%2dtest/objects/dir%2Fa%20b+c.txt", wantStatus: fiber.StatusOK, wantBucket: "my.bucket-test", wantKey: "dir/a b+c.txt"},
+		{name: "encoded bucket with nested key", path: "photos%2D2024/objects/2024/summer/%C3%A9t%C3%A9.jpg", wantStatus: fiber.StatusOK, wantBucket: "photos-2024", wantKey: "2024/summer/été.jpg"},
+		{name: "invalid encoding", path: "my.bucket%zz/objects/a.txt", wantStatus: fiber.StatusBadRequest},
+		{name: "encoded slash", path: "my%2Fbucket/objects/a.txt", wantStatus: fiber.StatusBadRequest},
+		{name: "uppercase", path: "My.Bucket/objects/a.txt", wantStatus: fiber.StatusBadRequest},
+		{name: "encoded uppercase", path: "my%42ucket/objects/a.txt", wantStatus: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{fiber.MethodGet, fiber.MethodHead, fiber.MethodDelete} {
+			t.Run(tt.name+" "+method, func(t *testing.T) {
+				req := httptest.NewRequest(method, "/", nil)
+				// The request line is sent as written, even where it is not valid encoding
+				req.RequestURI = "/api/v1/buckets/" + tt.path
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				defer resp.Body.Close()
+				_, _ = io.Copy(io.Discard, resp.Body)
+
+				if resp.StatusCode != tt.wantStatus {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+				if tt.wantStatus != fiber.StatusOK {
+					return
+				}
+				if got := resp.Header.Get("X-Bucket"); got != tt.wantBucket {
+					t.Errorf("bucket = %q, want %q", got, tt.wantBucket)
+				}
+				if got := resp.Header.Get("X-Key"); got != tt.wantKey {
+					t.Errorf("key = %q, want %q", got, tt.wantKey)
+				}
+			})
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"net/url"
+	"strconv"
 	"strings"
 
 	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -19,9 +22,15 @@ func CORSMiddleware(cfg *config.CORSConfig) fiber.Handler {
 
 	return func(c fiber.Ctx) error {
 		origin := c.Get("Origin")
+		allowed := origin != "" && isAllowedOrigin(origin, cfg.AllowedOrigins)
+
+		// The response depends on the Origin header whenever it is echoed back
+		if origin != "" {
+			c.Vary("Origin")
+		}
 
 		// Check if origin is allowed
-		if origin != "" && isAllowedOrigin(origin, cfg.AllowedOrigins) {
+		if allowed {
 			// Set CORS headers
 			c.Set("Access-Control-Allow-Origin", origin)
 
@@ -39,14 +48,25 @@ func CORSMiddleware(cfg *config.CORSConfig) fiber.Handler {
 				c.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
 			}
 
+			// Let browser code read these response headers (e.g. ETag after an upload)
+			if len(cfg.ExposedHeaders) > 0 {
+				c.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+			}
+
 			// Set max age for preflight cache
 			if cfg.MaxAge > 0 {
-				c.Set("Access-Control-Max-Age", string(rune(cfg.MaxAge)))
+				c.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
 			}
 		}
 
-		// Handle preflight requests
-		if c.Method() == "OPTIONS" {
+		// Handle preflight requests here, before any route group's auth middleware runs:
+		// browsers never send credentials with a preflight
+		if isPreflight(c) {
+			if !allowed {
+				return c.Status(fiber.StatusForbidden).JSON(
+					models.ErrorResponse(models.ErrCodeForbidden, "Origin not allowed"),
+				)
+			}
 			return c.SendStatus(fiber.StatusNoContent)
 		}
 
@@ -54,12 +74,44 @@ func CORSMiddleware(cfg *config.CORSConfig) fiber.Handler {
 	}
 }
 
-// isAllowedOrigin checks if an origin is in the allowed list
+// isPreflight reports whether the request is a CORS preflight: an OPTIONS request with an
+// Origin and the Access-Control-Request-Method of the request it announces
+func isPreflight(c fiber.Ctx) bool {
+	return c.Method() == fiber.MethodOptions &&
+		c.Get("Origin") != "" &&
+		c.Get("Access-Control-Request-Method") != ""
+}
+
+// isAllowedOrigin checks if an origin is in the allowed list. Entries are exact origins,
+// "*", or wildcard subdomain patterns such as "https://*.example.com".
 func isAllowedOrigin(origin string, allowedOrigins []string) bool {
 	for _, allowed := range allowedOrigins {
 		if allowed == "*" || allowed == origin {
 			return true
 		}
+		if strings.Contains(allowed, "://*.") && matchesWildcardOrigin(origin, allowed) {
+			return true
+		}
 	}
 	return false
 }
+
+// matchesWildcardOrigin reports whether origin matches a "scheme://*.domain[:port]" pattern.
+// The scheme and port must match exactly and at least one subdomain label is required, so
+// "https://*.example.com" matches "https://app.example.com" but not "https://example.com".
+func matchesWildcardOrigin(origin, pattern string) bool {
+	scheme, hostPattern, _ := strings.Cut(pattern, "://")
+	suffix := strings.ToLower(strings.TrimPrefix(hostPattern, "*"))
+
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Scheme != scheme || originURL.User != nil {
+		return false
+	}
+	if originURL.Path != "" || originURL.RawQuery != "" || originURL.Fragment != "" {
+		return false
+	}
+
+	host := strings.ToLower(originURL.Host)
+	subdomain, found := strings.CutSuffix(host, suffix)
+	return found && subdomain != "" && !strings.HasSuffix(subdomain, ".")
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"Noooste/garage-ui/internal/config"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// newPreflightTestApp mirrors the route layout: CORS on the app, auth on /api/v1, and the
+// object routes in their own group
+func newPreflightTestApp(corsEnabled bool) *fiber.App {
+	corsCfg := &config.CORSConfig{
+		Enabled:        corsEnabled,
+		AllowedOrigins: []string{"https://ui.example.com"},
+		AllowedMethods: []string{"GET", "PUT", "DELETE"},
+	}
+	authCfg := &config.AuthConfig{Admin: config.AdminAuthConfig{Enabled: true}}
+
+	app := fiber.New()
+	app.Use(CORSMiddleware(corsCfg))
+	api := app.Group("/api/v1", AuthMiddleware(authCfg, corsCfg, nil))
+	api.Get("/buckets", func(c fiber.Ctx) error { return c.SendString("buckets") })
+	objects := api.Group("/buckets/:bucket/objects", DecodeBucketParam())
+	objects.Get("/*", func(c fiber.Ctx) error { return c.SendString("object") })
+	return app
+}
+
+func TestPreflightBeforeAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		corsDisabled  bool
+		path          string
+		origin        string
+		requestMethod string
+		wantStatus    int
+		wantAllow     string
+	}{
+		{name: "buckets from allowed origin", path: "/api/v1/buckets", origin: "https://ui.example.com", requestMethod: "GET", wantStatus: fiber.StatusNoContent, wantAllow: "https://ui.example.com"},
+		{name: "object from allowed origin", path: "/api/v1/buckets/photos/objects/a/b.jpg", origin: "https://ui.example.com", requestMethod: "PUT", wantStatus: fiber.StatusNoContent, wantAllow: "https://ui.example.com"},
+		{name: "buckets from disallowed origin", path: "/api/v1/buckets", origin: "https://evil.example.com", requestMethod: "GET", wantStatus: fiber.StatusForbidden},
+		{name: "object from disallowed origin", path: "/api/v1/buckets/photos/objects/a/b.jpg", origin: "https://evil.example.com", requestMethod: "DELETE", wantStatus: fiber.StatusForbidden},
+		{name: "OPTIONS without request method", path: "/api/v1/buckets", origin: "https://ui.example.com", wantStatus: fiber.StatusUnauthorized, wantAllow: "https://ui.example.com"},
+		{name: "OPTIONS without origin", path: "/api/v1/buckets", requestMethod: "GET", wantStatus: fiber.StatusUnauthorized},
+		{name: "preflight with CORS disabled", corsDisabled: true, path: "/api/v1/buckets", origin: "https://ui.example.com", requestMethod: "GET", wantStatus: fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newPreflightTestApp(!tt.corsDisabled)
+
+			req := httptest.NewRequest(fiber.MethodOptions, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestIsAllowedOrigin(t *testing.T) {
+	allowed := []string{"https://ui.example.com", "https://*.apps.example.com", "http://*.local:8080"}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://ui.example.com", want: true},
+		{origin: "https://other.example.com", want: false},
+		{origin: "https://team.apps.example.com", want: true},
+		{origin: "https://a.b.apps.example.com", want: true},
+		{origin: "https://TEAM.Apps.Example.com", want: true},
+		{origin: "https://apps.example.com", want: false},
+		{origin: "https://.apps.example.com", want: false},
+		{origin: "https://evilapps.example.com", want: false},
+		{origin: "https://team.apps.example.com.evil.com", want: false},
+		{origin: "http://team.apps.example.com", want: false},
+		{origin: "https://team.apps.example.com:8443", want: false},
+		{origin: "https://user@team.apps.example.com", want: false},
+		{origin: "https://team.apps.example.com/path", want: false},
+		{origin: "http://box.local:8080", want: true},
+		{origin: "http://box.local", want: false},
+		{origin: "http://box.local:9090", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isAllowedOrigin(tt.origin, allowed); got != tt.want {
+			t.Errorf("isAllowedOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if !isAllowedOrigin("https://anything.example.org", []string{"*"}) {
+		t.Error("isAllowedOrigin with \"*\" = false, want true")
+	}
+}
+
+func TestExposedHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		exposed []string
+		origin  string
+		want    string
+	}{
+		{name: "configured", exposed: []string{"ETag", "Content-Range", "X-Request-ID"}, origin: "https://ui.example.com", want: "ETag, Content-Range, X-Request-ID"},
+		{name: "none configured", origin: "https://ui.example.com"},
+		{name: "disallowed origin", exposed: []string{"ETag"}, origin: "https://evil.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(CORSMiddleware(&config.CORSConfig{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://ui.example.com"},
+				ExposedHeaders: tt.exposed,
+			}))
+			app.Get("/", func(c fiber.Ctx) error { return c.SendString("ok") })
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get("Access-Control-Expose-Headers"); got != tt.want {
+				t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
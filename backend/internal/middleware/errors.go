@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/logger"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// genericErrorMessage replaces the message of server errors in production, where error
+// strings may carry upstream URLs or credentials
+const genericErrorMessage = "Internal server error"
+
+// errorCodeForStatus returns the API error code matching an HTTP status
+func errorCodeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return models.ErrCodeBadRequest
+	case fiber.StatusUnauthorized:
+		return models.ErrCodeUnauthorized
+	case fiber.StatusForbidden:
+		return models.ErrCodeForbidden
+	case fiber.StatusNotFound:
+		return models.ErrCodeNotFound
+	case fiber.StatusConflict:
+		return models.ErrCodeConflict
+	case fiber.StatusTooManyRequests:
+		return models.ErrCodeThrottled
+	case fiber.StatusBadGateway:
+		return models.ErrCodeUpstream
+	case fiber.StatusGatewayTimeout:
+		return models.ErrCodeTimeout
+	}
+	if status >= fiber.StatusInternalServerError {
+		return models.ErrCodeInternalError
+	}
+	return models.ErrCodeBadRequest
+}
+
+// classifyError returns the status and API error code for an error reaching the error handler
+func classifyError(err error) (int, string) {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code, errorCodeForStatus(fiberErr.Code)
+	}
+
+	if errors.Is(err, utils.ErrThrottled) {
+		return fiber.StatusTooManyRequests, models.ErrCodeThrottled
+	}
+
+	// A route ran out of its time budget
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fiber.StatusGatewayTimeout, models.ErrCodeTimeout
+	}
+
+	// Missing and conflicting resources keep their meaning; any other Admin API failure
+	// is the upstream's fault, not ours
+	var statusErr *services.APIStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case fiber.StatusNotFound, fiber.StatusConflict:
+			return statusErr.StatusCode, errorCodeForStatus(statusErr.StatusCode)
+		}
+		return fiber.StatusBadGateway, models.ErrCodeUpstream
+	}
+
+	return fiber.StatusInternalServerError, models.ErrCodeInternalError
+}
+
+// ErrorHandler answers errors returned by handlers, including panics turned into errors by
+// the recover middleware, with the standard API response. The full error is always logged;
+// in production the message of server errors is replaced by a generic one.
+func ErrorHandler(production bool) fiber.ErrorHandler {
+	return func(c fiber.Ctx, err error) error {
+		status, code := classifyError(err)
+		requestID := RequestIDFromContext(c)
+
+		logger.Error().
+			Err(err).
+			Int("status_code", status).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Str("request_id", requestID).
+			Msg("Request error")
+
+		message := err.Error()
+		if production && status >= fiber.StatusInternalServerError {
+			message = genericErrorMessage
+		}
+
+		response := models.ErrorResponse(code, message)
+		response.Error.RequestID = requestID
+		return c.Status(status).JSON(response)
+	}
+}
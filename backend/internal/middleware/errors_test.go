@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/recover"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		production  bool
+		handler     fiber.Handler
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "recovered panic",
+			handler:     func(c fiber.Ctx) error { panic("boom") },
+			wantStatus:  fiber.StatusInternalServerError,
+			wantCode:    models.ErrCodeInternalError,
+			wantMessage: "boom",
+		},
+		{
+			name:        "recovered panic in production",
+			production:  true,
+			handler:     func(c fiber.Ctx) error { panic("secret") },
+			wantStatus:  fiber.StatusInternalServerError,
+			wantCode:    models.ErrCodeInternalError,
+			wantMessage: genericErrorMessage,
+		},
+		{
+			name:        "fiber not found",
+			handler:     func(c fiber.Ctx) error { return fiber.ErrNotFound },
+			wantStatus:  fiber.StatusNotFound,
+			wantCode:    models.ErrCodeNotFound,
+			wantMessage: fiber.ErrNotFound.Message,
+		},
+		{
+			name: "wrapped admin API not found",
+			handler: func(c fiber.Ctx) error {
+				return fmt.Errorf("failed to get bucket: %w", &services.APIStatusError{StatusCode: 404, Body: "no such bucket"})
+			},
+			wantStatus: fiber.StatusNotFound,
+			wantCode:   models.ErrCodeNotFound,
+		},
+		{
+			name: "wrapped admin API server error",
+			handler: func(c fiber.Ctx) error {
+				return fmt.Errorf("failed to get bucket: %w", &services.APIStatusError{StatusCode: 500, Body: "internal"})
+			},
+			wantStatus: fiber.StatusBadGateway,
+			wantCode:   models.ErrCodeUpstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(tt.production)})
+			app.Use(RequestID())
+			app.Use(recover.New())
+			app.Get("/", tt.handler)
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set(fiber.HeaderXRequestID, "req-123")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			var body models.APIResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Success || body.Error == nil {
+				t.Fatalf("response = %+v, want an error envelope", body)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.wantCode)
+			}
+			if tt.wantMessage != "" && body.Error.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", body.Error.Message, tt.wantMessage)
+			}
+			if body.Error.RequestID != "req-123" {
+				t.Errorf("requestId = %q, want %q", body.Error.RequestID, "req-123")
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "client ID kept", header: "abc-123", keep: true},
+		{name: "missing ID generated", header: ""},
+		{name: "ID with spaces replaced", header: "abc 123"},
+		{name: "over-long ID replaced", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(RequestID())
+			app.Get("/", func(c fiber.Ctx) error {
+				return c.SendString(RequestIDFromContext(c))
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			got := resp.Header.Get(fiber.HeaderXRequestID)
+			if tt.keep && got != tt.header {
+				t.Errorf("request ID = %q, want %q", got, tt.header)
+			}
+			if !tt.keep && (got == tt.header || len(got) != 36) {
+				t.Errorf("request ID = %q, want a generated UUID", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// defaultMaintenanceMessage is used when maintenance mode was enabled without a message
+const defaultMaintenanceMessage = "Garage UI is in maintenance mode, changes are disabled for now"
+
+// maintenanceExempt lists the API routes that take a POST body but keep working during
+// maintenance: ending maintenance itself, and calls that only read
+var maintenanceExempt = []string{
+	"/api/v1/admin/maintenance",
+	"/api/v1/admin/raw",
+	"/api/v1/monitoring/diagnostics/run",
+}
+
+// isMutatingAPICall reports whether the request is an API call that may change something
+func isMutatingAPICall(c fiber.Ctx) bool {
+	path := strings.TrimSuffix(c.Path(), "/")
+	if !strings.HasPrefix(path, "/api/v1/") {
+		return false
+	}
+
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	case fiber.MethodPost:
+		return !isReadOnlyPost(path) && !slices.Contains(maintenanceExempt, path)
+	}
+	return true
+}
+
+// MaintenanceMiddleware rejects every API call that may change something with 503 while
+// maintenance mode is in effect, so users can keep browsing during Garage upgrades. The
+// response carries the maintenance message and, when maintenance has an end, Retry-After
+// counted from clk.
+func MaintenanceMiddleware(store *services.SettingsStore, clk clock.Clock) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !isMutatingAPICall(c) {
+			return c.Next()
+		}
+
+		maintenance, active := store.Maintenance()
+		if !active {
+			return c.Next()
+		}
+
+		if maintenance.Until != nil {
+			seconds := math.Ceil(maintenance.Until.Sub(clk.Now()).Seconds())
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(seconds)))
+		}
+
+		message := maintenance.Message
+		if message == "" {
+			message = defaultMaintenanceMessage
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.MaintenanceResponse(message, maintenance))
+	}
+}
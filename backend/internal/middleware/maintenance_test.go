@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestMaintenanceRetryAfterCountsFromClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	store, err := services.NewSettingsStore("", nil, clk)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+	until := start.Add(90 * time.Second)
+	if err := store.SetMaintenance(models.Maintenance{Enabled: true, Until: &until}); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(MaintenanceMiddleware(store, clk))
+	app.Delete("/api/v1/buckets/:name", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name           string
+		elapsed        time.Duration
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "maintenance starts", wantStatus: fiber.StatusServiceUnavailable, wantRetryAfter: "90"},
+		{name: "part of a second left", elapsed: 89*time.Second + time.Millisecond, wantStatus: fiber.StatusServiceUnavailable, wantRetryAfter: "1"},
+		{name: "maintenance ends", elapsed: 90 * time.Second, wantStatus: fiber.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Set(start.Add(tt.elapsed))
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodDelete, "/api/v1/buckets/photos", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get(fiber.HeaderRetryAfter); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
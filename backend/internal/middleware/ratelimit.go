@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/limiter"
+)
+
+// PublicRateLimit limits unauthenticated requests to perMinute per client IP; the limiter
+// sets Retry-After on rejected requests. The client IP honors server.trusted_proxies.
+func PublicRateLimit(perMinute int) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        perMinute,
+		Expiration: time.Minute,
+		KeyGenerator: func(c fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(
+				models.ErrorResponse(models.ErrCodeThrottled, "Too many requests, retry later"),
+			)
+		},
+	})
+}
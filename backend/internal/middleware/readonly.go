@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// RejectReadOnlyBuckets refuses changes to read-only buckets with 403, whatever the keys
+// allow. Reads, including readOnlyPosts, go through. The bucket name is taken from
+// DecodeBucketParam on object routes and from the :name parameter on bucket routes.
+func RejectReadOnlyBuckets(store *services.SettingsStore) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		case fiber.MethodPost:
+			if isReadOnlyPost(c.Path()) {
+				return c.Next()
+			}
+		}
+
+		bucketName, ok := c.Locals("bucketName").(string)
+		if !ok {
+			bucketName = c.Params("name")
+		}
+		if bucketName != "" && store.IsReadOnly(bucketName) {
+			return c.Status(fiber.StatusForbidden).JSON(
+				models.ErrorResponse(models.ErrCodeBucketReadOnly, "Bucket "+bucketName+" is read-only"),
+			)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// requestIDKey holds the ID of the request in its locals
+const requestIDKey = "requestID"
+
+// maxRequestIDLength bounds request IDs taken from clients, which end up in every log line
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, echoed in the X-Request-ID response header and in
+// error responses to correlate them with the server logs. An X-Request-ID sent by the client
+// or a proxy is kept when it is short printable ASCII; otherwise a random UUID is used.
+func RequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(fiber.HeaderXRequestID, requestID)
+		c.Locals(requestIDKey, requestID)
+		return c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID RequestID gave the request, empty when it did not run
+func RequestIDFromContext(c fiber.Ctx) string {
+	requestID, _ := c.Locals(requestIDKey).(string)
+	return requestID
+}
+
+// validRequestID reports whether a client-supplied request ID can be logged as is
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
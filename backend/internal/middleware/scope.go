@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"Noooste/garage-ui/internal/auth"
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// tokenScope returns the scope of the API token the request authenticated with, if any
+func tokenScope(c fiber.Ctx) *auth.TokenScope {
+	userInfo, ok := c.Locals("userInfo").(*auth.UserInfo)
+	if !ok {
+		return nil
+	}
+	return userInfo.Scope
+}
+
+// bucketRoute returns what follows /api/v1/buckets/:bucket/ in path
+func bucketRoute(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/v1/buckets/")
+	if !ok {
+		return "", false
+	}
+	_, route, ok := strings.Cut(rest, "/")
+	return route, ok
+}
+
+// isObjectRoute reports whether path is one of the /api/v1/buckets/:bucket/objects routes
+func isObjectRoute(path string) bool {
+	route, ok := bucketRoute(path)
+	return ok && (route == "objects" || strings.HasPrefix(route, "objects/"))
+}
+
+// isSearchRoute reports whether path is the /api/v1/buckets/:bucket/search route
+func isSearchRoute(path string) bool {
+	route, ok := bucketRoute(path)
+	return ok && route == "search"
+}
+
+// RestrictScopedTokens keeps API tokens with a scope on the object routes and the object
+// search, where RequireTokenScope checks their bucket and verb, and on the progress of their
+// own uploads. It must run after AuthMiddleware.
+func RestrictScopedTokens() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		path := c.Path()
+		if tokenScope(c) != nil && !isObjectRoute(path) && !isSearchRoute(path) && !strings.HasPrefix(path, "/api/v1/uploads/") {
+			return c.Status(fiber.StatusForbidden).JSON(
+				models.ErrorResponse(models.ErrCodeForbidden, "Scoped API tokens may only access bucket objects"),
+			)
+		}
+		return c.Next()
+	}
+}
+
+// readOnlyPosts lists the object routes that take a POST body but only read
+var readOnlyPosts = []string{"/metadata-batch", "/manifest", "/download-zip/prepare", "/download-archive"}
+
+// isReadOnlyPost reports whether path is one of the object readOnlyPosts
+func isReadOnlyPost(path string) bool {
+	return slices.ContainsFunc(readOnlyPosts, func(suffix string) bool {
+		return strings.HasSuffix(path, "/objects"+suffix)
+	})
+}
+
+// RequireTokenScope rejects requests made with a scoped API token when the token does not
+// cover the bucket or the verb (read for GET, HEAD and readOnlyPosts, write otherwise). It
+// must run after DecodeBucketParam, which provides the bucket name.
+func RequireTokenScope() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		scope := tokenScope(c)
+		if scope == nil {
+			return c.Next()
+		}
+
+		verb := auth.VerbWrite
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead:
+			verb = auth.VerbRead
+		case fiber.MethodPost:
+			if isReadOnlyPost(c.Path()) {
+				verb = auth.VerbRead
+			}
+		}
+
+		bucketName, _ := c.Locals("bucketName").(string)
+		if !scope.AllowsBucket(bucketName) || !scope.AllowsVerb(verb) {
+			return c.Status(fiber.StatusForbidden).JSON(
+				models.ErrorResponse(models.ErrCodeForbidden, fmt.Sprintf(
+					"Token scope does not allow %s access to bucket %s (buckets: %s; verbs: %s)",
+					verb, bucketName, strings.Join(scope.Buckets, ", "), strings.Join(scope.Verbs, ", "),
+				)),
+			)
+		}
+		return c.Next()
+	}
+}
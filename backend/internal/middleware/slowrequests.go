@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// SlowRequestMiddleware records requests slower than logging.slow_request_threshold for
+// support bundles. It is installed before the access log, which runs the error handler, so
+// the recorded status is the one sent. Streamed responses are timed until the handler returns, not until the
+// last byte is sent.
+func SlowRequestMiddleware(slowRequests *services.SlowRequestLog) fiber.Handler {
+	if !slowRequests.Enabled() {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		duration := time.Since(start)
+		if !slowRequests.IsSlow(duration) {
+			return err
+		}
+
+		path := c.Path()
+		if decoded, decodeErr := url.PathUnescape(path); decodeErr == nil {
+			path = decoded
+		}
+
+		request := models.SlowRequest{
+			Time:   start.UTC(),
+			Method: strings.Clone(c.Method()),
+			Path:   strings.Clone(path),
+			Status: c.Response().StatusCode(),
+		}
+		if username, ok := c.Locals("username").(string); ok {
+			request.Username = strings.Clone(username)
+		}
+
+		slowRequests.Observe(request, duration)
+		return err
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ThrottleMiddleware turns failures caused by Garage throttling (S3 SlowDown, Admin API
+// 429/503) into 429 responses with a Retry-After hint, so that clients back off instead
+// of retrying a generic 500 right away
+func ThrottleMiddleware(stats *services.ThrottleStats) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.SetContext(services.WithThrottleMarker(c.Context()))
+
+		err := c.Next()
+
+		source := services.ThrottledBy(c.Context())
+		if source == "" || (err == nil && c.Response().StatusCode() < fiber.StatusInternalServerError) {
+			return err
+		}
+
+		// Authenticated users are told apart by name, everyone else by address
+		client := c.IP()
+		if username, ok := c.Locals("username").(string); ok && username != "" {
+			client = username
+		}
+
+		seconds := int(stats.RetryAfter(client, source).Seconds())
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return c.Status(fiber.StatusTooManyRequests).JSON(
+			models.ErrorResponse(models.ErrCodeThrottled, fmt.Sprintf("Garage is busy, retry in %d seconds", seconds)),
+		)
+	}
+}
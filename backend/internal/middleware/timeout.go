@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// timeoutBaseKey holds the request context from before any time budget was applied
+const timeoutBaseKey = "timeoutBaseContext"
+
+// Timeout applies the time budget of a route group to the routes it is installed on. The
+// budget replaces any budget applied further out rather than nesting in it, so a route
+// group can be given more time than the default installed on the whole API.
+func Timeout(timeouts config.TimeoutsConfig, group string) fiber.Handler {
+	return WithTimeout(timeouts, group, func(c fiber.Ctx) error {
+		return c.Next()
+	})
+}
+
+// WithTimeout wraps a handler with the time budget of a route group, for handlers picked
+// at runtime such as the actions of the wildcard object routes. See Timeout.
+func WithTimeout(timeouts config.TimeoutsConfig, group string, handler fiber.Handler) fiber.Handler {
+	budget := timeouts.Budget(group)
+
+	return func(c fiber.Ctx) error {
+		base, ok := c.Locals(timeoutBaseKey).(context.Context)
+		if !ok {
+			base = c.Context()
+			c.Locals(timeoutBaseKey, base)
+		}
+
+		ctx, cancel := base, context.CancelFunc(func() {})
+		if budget > 0 {
+			ctx, cancel = context.WithTimeout(base, budget)
+		}
+		defer cancel()
+		c.SetContext(ctx)
+
+		err := handler(c)
+
+		// Only the budget in effect reports, not the ones it replaced
+		if budget <= 0 || c.Context() != ctx {
+			return err
+		}
+
+		// Handlers answer upstream failures themselves, so a request that ran out of time
+		// usually comes back as a 5xx response rather than an error
+		timedOut := errors.Is(err, context.DeadlineExceeded) ||
+			(errors.Is(ctx.Err(), context.DeadlineExceeded) && (err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError))
+		if !timedOut {
+			return err
+		}
+
+		response := models.ErrorResponse(models.ErrCodeTimeout, fmt.Sprintf("Request exceeded the %s time budget of %s", group, budget))
+		response.Error.RequestID = RequestIDFromContext(c)
+		return c.Status(fiber.StatusGatewayTimeout).JSON(response)
+	}
+}
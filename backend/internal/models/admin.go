@@ -180,6 +180,11 @@ type ClusterHealth struct {
 	PartitionsAllOk  int    `json:"partitionsAllOk"`
 }
 
+// LacksWriteQuorum reports whether some partitions have too few nodes up to accept writes
+func (h *ClusterHealth) LacksWriteQuorum() bool {
+	return h.PartitionsQuorum < h.Partitions
+}
+
 // ClusterStatus represents the current status of the cluster
 type ClusterStatus struct {
 	LayoutVersion int        `json:"layoutVersion"`
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldSelection is the set of item fields a listing was asked to return with ?fields=.
+// It holds both the current and the legacy name of each field, so a projection works
+// whichever names the response was encoded with.
+type FieldSelection map[string]bool
+
+// ParseFieldSelection validates a comma-separated list of field names against the JSON
+// fields of the item type t. Fields may be given by their current or legacy name. An
+// empty list selects nothing, which callers treat as every field.
+func ParseFieldSelection(list string, t reflect.Type) (FieldSelection, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	// Map every accepted name to the names the field may be encoded with
+	names := make(map[string][]string)
+	jsonFieldNames(t, func(name, legacy string) {
+		encoded := []string{name}
+		if legacy != "" {
+			encoded = append(encoded, legacy)
+			names[legacy] = encoded
+		}
+		names[name] = encoded
+	})
+
+	selection := make(FieldSelection)
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		encoded, ok := names[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		for _, name := range encoded {
+			selection[name] = true
+		}
+	}
+	return selection, nil
+}
+
+// Project removes the unselected fields from every item of the list field of an encoded
+// APIResponse. The fields are removed rather than set to null; everything outside the
+// list is left as it is.
+func (s FieldSelection) Project(body []byte, listField string) ([]byte, error) {
+	if len(s) == 0 {
+		return body, nil
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(envelope["data"], &data); err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data[listField], &items); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		for name := range item {
+			if !s[name] {
+				delete(item, name)
+			}
+		}
+	}
+
+	var err error
+	if data[listField], err = json.Marshal(items); err != nil {
+		return nil, err
+	}
+	if envelope["data"], err = json.Marshal(data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+// jsonFieldNames calls fn with the JSON and legacy names of the exported fields of struct
+// type t, following embedded structs the way encoding/json does
+func jsonFieldNames(t reflect.Type, fn func(name, legacy string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				jsonFieldNames(embedded, fn)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fn(name, field.Tag.Get(legacyTag))
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldSelection(t *testing.T) {
+	objectInfo := reflect.TypeFor[ObjectInfo]()
+
+	for _, tt := range []struct {
+		name    string
+		list    string
+		want    FieldSelection
+		wantErr bool
+	}{
+		{name: "empty", list: " ", want: nil},
+		{name: "current names", list: "key, size", want: FieldSelection{"key": true, "size": true}},
+		{name: "legacy name", list: "last_modified", want: FieldSelection{"lastModified": true, "last_modified": true}},
+		{name: "current name of a legacy field", list: "lastModified,", want: FieldSelection{"lastModified": true, "last_modified": true}},
+		{name: "unknown", list: "key,owner", wantErr: true},
+		{name: "Go field name", list: "LastModified", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFieldSelection(tt.list, objectInfo)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseFieldSelection(%q) = %v, want an error", tt.list, got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFieldSelection(%q) = %v, %v; want %v", tt.list, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldSelectionProject(t *testing.T) {
+	listing := ObjectListResponse{
+		Objects: []ObjectInfo{{Key: "a.txt", Size: 1, ETag: "e1", StorageClass: "STANDARD"}},
+		Bucket:  "docs",
+	}
+	selection, err := ParseFieldSelection("key,lastModified", reflect.TypeFor[ObjectInfo]())
+	if err != nil {
+		t.Fatalf("ParseFieldSelection failed: %v", err)
+	}
+
+	for name, marshal := range map[string]func(interface{}) ([]byte, error){
+		"current": json.Marshal,
+		"legacy":  MarshalLegacyJSON,
+	} {
+		t.Run(name, func(t *testing.T) {
+			body, err := marshal(SuccessResponse(listing))
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			projected, err := selection.Project(body, "objects")
+			if err != nil {
+				t.Fatalf("Project failed: %v", err)
+			}
+
+			var response struct {
+				Success bool `json:"success"`
+				Data    struct {
+					Objects []map[string]interface{} `json:"objects"`
+					Bucket  string                   `json:"bucket"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(projected, &response); err != nil {
+				t.Fatalf("decoding %s: %v", projected, err)
+			}
+			if !response.Success || response.Data.Bucket != "docs" {
+				t.Errorf("fields outside the list changed: %s", projected)
+			}
+			if len(response.Data.Objects) != 1 || len(response.Data.Objects[0]) != 2 || response.Data.Objects[0]["key"] != "a.txt" {
+				t.Errorf("objects = %v, want only key and the modification time", response.Data.Objects)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Response fields used to mix snake_case and camelCase. They are all camelCase now; fields
+// that were renamed carry their previous name in a legacy struct tag, which MarshalLegacyJSON
+// emits instead while server.legacy_field_names is set, and which requests may still use.
+
+// legacyTag is the struct tag holding the snake_case name a field had before
+const legacyTag = "legacy"
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// MarshalLegacyJSON encodes v like json.Marshal, but with the snake_case names response
+// fields had before they were renamed. It is installed as the Fiber JSON encoder while
+// server.legacy_field_names is set.
+func MarshalLegacyJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(legacyValue(reflect.ValueOf(v)))
+}
+
+// legacyValue converts v into maps, slices and leaf values that json.Marshal encodes with
+// the legacy field names. Values with their own JSON encoding, such as time.Time and
+// json.RawMessage, are kept as they are.
+func legacyValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return legacyValue(v.Elem())
+
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		legacyFields(v, fields)
+		return fields
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		entries := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[iter.Key().String()] = legacyValue(iter.Value())
+		}
+		return entries
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = legacyValue(v.Index(i))
+		}
+		return items
+	}
+
+	return v.Interface()
+}
+
+// legacyFields adds the exported fields of struct v to fields under their legacy or JSON
+// names, following the json tag rules for omitted, omitempty and embedded fields
+func legacyFields(v reflect.Value, fields map[string]interface{}) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				legacyFields(value, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if strings.Contains(options, "omitempty") && value.IsZero() && value.Kind() != reflect.Struct {
+			continue
+		}
+
+		if legacy := field.Tag.Get(legacyTag); legacy != "" {
+			name = legacy
+		} else if name == "" {
+			name = field.Name
+		}
+		fields[name] = legacyValue(value)
+	}
+}
+
+// renameLegacyKeys rewrites the top-level legacy field names of t found in a JSON object to
+// their current names, unless the current name is present too
+func renameLegacyKeys(data []byte, t reflect.Type) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	renamed := false
+	for i := range t.NumField() {
+		field := t.Field(i)
+		legacy := field.Tag.Get(legacyTag)
+		value, ok := object[legacy]
+		if legacy == "" || !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if _, ok := object[name]; !ok {
+			object[name] = value
+		}
+		delete(object, legacy)
+		renamed = true
+	}
+
+	if !renamed {
+		return data, nil
+	}
+	return json.Marshal(object)
+}
+
+// bucketSettingsJSON has the fields of BucketSettings without its UnmarshalJSON method
+type bucketSettingsJSON BucketSettings
+
+// UnmarshalJSON decodes bucket settings, also accepting the legacy snake_case field names
+// still found in settings files and sent by older clients
+func (s *BucketSettings) UnmarshalJSON(data []byte) error {
+	data, err := renameLegacyKeys(data, reflect.TypeFor[BucketSettings]())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, (*bucketSettingsJSON)(s))
+}
@@ -1,9 +1,12 @@
 package models
 
+import "time"
+
 // CreateBucketRequest represents a request to create a new bucket
 type CreateBucketRequest struct {
-	Name   string `json:"name" validate:"required"`
-	Region string `json:"region,omitempty"`
+	Name   string                         `json:"name" validate:"required"`
+	Region string                         `json:"region,omitempty"`
+	Grants []GrantBucketPermissionRequest `json:"grants,omitempty"` // Keys granted access right after creation
 }
 
 // GrantBucketPermissionRequest represents a request to grant permissions on a bucket
@@ -54,8 +57,147 @@ type DeleteUserRequest struct {
 	AccessKey string `json:"access_key" validate:"required"`
 }
 
+// DeleteMultipleUsersRequest represents a request to delete several users/keys at once
+type DeleteMultipleUsersRequest struct {
+	AccessKeys []string `json:"access_keys" validate:"required"`
+}
+
+// CreateSelfServiceKeyRequest represents a request by an OIDC user to issue themselves a key
+type CreateSelfServiceKeyRequest struct {
+	ExpiresIn string `json:"expires_in,omitempty"` // Go duration such as "720h"; defaults to self_service.default_ttl
+}
+
+// CreateAPITokenRequest represents a request to issue a personal API token. Buckets and
+// Verbs are optional; when either is set the token is scoped to them.
+type CreateAPITokenRequest struct {
+	Name      string   `json:"name" validate:"required"`
+	Buckets   []string `json:"buckets,omitempty"`    // Bucket name patterns such as "artifacts-*"
+	Verbs     []string `json:"verbs,omitempty"`      // "read" and/or "write"
+	ExpiresIn string   `json:"expires_in,omitempty"` // Go duration such as "720h"; defaults to auth.api_tokens.max_ttl
+}
+
+// RestoreTrashRequest represents a request to restore an object from a bucket's trash
+type RestoreTrashRequest struct {
+	TrashKey  string `json:"trash_key" validate:"required"`
+	Overwrite bool   `json:"overwrite,omitempty"` // Replace an object that now exists at the original key
+}
+
+// TestUserKeyRequest represents a request to test a key's S3 connectivity
+type TestUserKeyRequest struct {
+	Bucket string `json:"bucket,omitempty"` // Optional bucket to run HeadBucket against
+}
+
 // UpdateUserRequest represents a request to update user permissions
 type UpdateUserRequest struct {
 	Status     *string `json:"status,omitempty"`     // "active" or "inactive"
-	Expiration *string `json:"expiration,omitempty"` // ISO 8601 date string
+	Expiration *string `json:"expiration,omitempty"` // RFC3339, with "Z" or a numeric offset
+}
+
+// AdminRawRequest represents a debugging call to a read-only Admin API endpoint
+type AdminRawRequest struct {
+	Endpoint string            `json:"endpoint" validate:"required"` // e.g. GetClusterHealth
+	Params   map[string]string `json:"params,omitempty"`             // Query parameters
+}
+
+// DownloadManifestRequest represents a request for presigned URLs of several objects, given
+// either as keys or as a prefix
+type DownloadManifestRequest struct {
+	Keys   []string `json:"keys,omitempty"`
+	Prefix *string  `json:"prefix,omitempty"` // Every object under the prefix; "" for the whole bucket
+}
+
+// ZipDownloadRequest represents a request to prepare a ZIP download fetched in parts, with
+// its objects given either as keys or as a prefix
+type ZipDownloadRequest struct {
+	Keys     []string `json:"keys,omitempty"`
+	Prefix   *string  `json:"prefix,omitempty"`    // Every object under the prefix; "" for the whole bucket
+	PartSize int64    `json:"part_size,omitempty"` // Bytes per part (default: 64 MiB, min: 1 MiB, max: 1 GiB)
+}
+
+// ZipStreamRequest represents a request to download several objects as one ZIP archive
+type ZipStreamRequest struct {
+	Keys   []string `json:"keys,omitempty"`
+	Prefix *string  `json:"prefix,omitempty"` // Every object under the prefix; "" for the whole bucket
+}
+
+// MaintenanceRequest represents a request to enable or end the maintenance mode
+type MaintenanceRequest struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"` // Shown to users while maintenance is in effect
+	Until   *time.Time `json:"until,omitempty"`   // End maintenance on its own at this time (optional)
+}
+
+// ObjectTagsRequest represents a request to replace the tags of an object
+type ObjectTagsRequest struct {
+	Tags map[string]string `json:"tags"` // At most 10; keys up to 128 characters, values up to 256
+}
+
+// ObjectCopyRequest represents a request to copy an object into the bucket of the route
+type ObjectCopyRequest struct {
+	SourceBucket string `json:"source_bucket,omitempty"` // Defaults to the bucket of the route
+	SourceKey    string `json:"source_key"`
+	Key          string `json:"key"`       // Key of the copy
+	Overwrite    bool   `json:"overwrite"` // Replace an existing object at key
+}
+
+// ObjectMoveRequest represents a request to move or rename an object, or a folder when the
+// key of the route ends with /
+type ObjectMoveRequest struct {
+	TargetKey string `json:"target_key"` // Must end with / when moving a folder
+	Overwrite bool   `json:"overwrite"`  // Replace existing objects at the target
+}
+
+// MultipartInitRequest represents a request to start a multipart upload
+type MultipartInitRequest struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// MultipartCompleteRequest represents a request to assemble the uploaded parts into the object
+type MultipartCompleteRequest struct {
+	Key   string                  `json:"key"`
+	Parts []MultipartCompletePart `json:"parts"`
+}
+
+// MultipartCompletePart identifies an uploaded part by its number and the ETag returned when
+// it was uploaded
+type MultipartCompletePart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// SelfTestRequest represents the options of an end-to-end self-test
+type SelfTestRequest struct {
+	DeleteBucket bool `json:"delete_bucket"` // Also delete the test bucket afterwards, if it is empty
+}
+
+// BulkBucketPermissionRequest represents a request to grant or revoke one key's
+// permissions on several buckets at once
+type BulkBucketPermissionRequest struct {
+	Buckets     []string            `json:"buckets" validate:"required"` // Global aliases, or ["*"] for every bucket
+	Permissions BucketKeyPermission `json:"permissions"`
+	Action      string              `json:"action" validate:"required"` // "grant" or "revoke"
+	ConfirmAll  bool                `json:"confirm_all,omitempty"`      // Required with buckets ["*"]
+}
+
+// BucketAliasRequest represents a request to add a global alias to a bucket
+type BucketAliasRequest struct {
+	Alias string `json:"alias" validate:"required"` // New global alias, following the bucket naming rules
+}
+
+// BucketCORSRequest represents a request to replace the CORS rules of a bucket
+type BucketCORSRequest struct {
+	Rules []CORSRule `json:"rules"` // At most 100; no rules removes the CORS configuration
+}
+
+// BucketLifecycleRequest represents a request to replace the lifecycle rules of a bucket
+type BucketLifecycleRequest struct {
+	Rules []LifecycleRule `json:"rules"` // At most 100; no rules removes every rule
+}
+
+// UpdateBucketWebsiteRequest represents a request to configure website hosting for a bucket
+type UpdateBucketWebsiteRequest struct {
+	Enabled       bool   `json:"enabled"`
+	IndexDocument string `json:"index_document,omitempty"` // Required when enabled, e.g. index.html
+	ErrorDocument string `json:"error_document,omitempty"` // Served for missing keys (optional)
 }
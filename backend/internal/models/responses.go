@@ -1,6 +1,12 @@
+// Package models holds the API request and response types. Every timestamp the API emits
+// is in UTC and serialized as RFC3339 with a "Z" suffix; timestamps accepted as input may
+// use either "Z" or a numeric offset and are converted to UTC.
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // DashboardMetrics represents aggregated metrics for the dashboard
 type DashboardMetrics struct {
@@ -8,14 +14,122 @@ type DashboardMetrics struct {
 	ObjectCount   int64         `json:"objectCount"`
 	BucketCount   int           `json:"bucketCount"`
 	UsageByBucket []BucketUsage `json:"usageByBucket"`
+	Truncated     bool          `json:"truncated,omitempty"` // The Admin API bucket list hit garage.admin_list_limit
+
+	// GeneratedAt is when the metrics were computed. Stale is set when the Admin API failed
+	// and the last metrics computed are served instead.
+	GeneratedAt time.Time `json:"generatedAt"`
+	Stale       bool      `json:"stale,omitempty"`
+}
+
+// ClusterStatisticsResponse represents global cluster statistics. Garage only reports them
+// as preformatted text, so the capacity is summed from the node partitions as raw numbers.
+type ClusterStatisticsResponse struct {
+	FreeformText string           `json:"freeformText" legacy:"freeform"` // Garage's human-readable report, for display only
+	Capacity     *CapacitySummary `json:"capacity,omitempty"`             // Omitted when the cluster status is unavailable
+}
+
+// CapacitySummary represents the disk space of the nodes reporting it. Sizes are in bytes and
+// percentages are unrounded, so clients format them in their own locale.
+type CapacitySummary struct {
+	Nodes               int     `json:"nodes"` // Nodes that reported their partitions
+	DataAvailable       int64   `json:"dataAvailable"`
+	DataTotal           int64   `json:"dataTotal"`
+	DataUsedPercent     float64 `json:"dataUsedPercent"`
+	MetadataAvailable   int64   `json:"metadataAvailable"`
+	MetadataTotal       int64   `json:"metadataTotal"`
+	MetadataUsedPercent float64 `json:"metadataUsedPercent"`
+}
+
+// UserTransfer represents the object bytes a user transferred through the proxy
+type UserTransfer struct {
+	Username      string `json:"username"`
+	UploadBytes   int64  `json:"uploadBytes" legacy:"upload_bytes"`
+	DownloadBytes int64  `json:"downloadBytes" legacy:"download_bytes"`
+}
+
+// TransferStatsResponse represents per-user transfer totals over a time window
+type TransferStatsResponse struct {
+	Window             string         `json:"window"`
+	Since              time.Time      `json:"since"`
+	Users              []UserTransfer `json:"users"`
+	TotalUploadBytes   int64          `json:"totalUploadBytes" legacy:"total_upload_bytes"`
+	TotalDownloadBytes int64          `json:"totalDownloadBytes" legacy:"total_download_bytes"`
+	Note               string         `json:"note"` // What the counters do not cover
+}
+
+// KeyActivityResponse represents the requests an access key made over a time window, as
+// counted from Garage's per-key request metrics
+type KeyActivityResponse struct {
+	AccessKeyID string              `json:"accessKeyId"`
+	Window      string              `json:"window"`
+	Since       time.Time           `json:"since"`
+	SampledAt   time.Time           `json:"sampledAt"`          // When the metrics were last read
+	LastSeen    *time.Time          `json:"lastSeen,omitempty"` // Last sample in which the key had made requests
+	Requests    int64               `json:"requests"`
+	Buckets     []KeyBucketActivity `json:"buckets"` // Busiest first
+}
+
+// KeyBucketActivity represents the requests of a key on one bucket, by S3 API endpoint
+type KeyBucketActivity struct {
+	Bucket   string           `json:"bucket"` // Empty for requests not tied to a bucket
+	Requests int64            `json:"requests"`
+	Verbs    map[string]int64 `json:"verbs"`
 }
 
 // BucketUsage represents storage usage for a single bucket
 type BucketUsage struct {
 	BucketName  string  `json:"bucketName"`
-	Size        int64   `json:"size"`
+	Size        int64   `json:"size"` // Bytes
 	ObjectCount int64   `json:"objectCount"`
-	Percentage  float64 `json:"percentage"`
+	Percentage  float64 `json:"percentage"` // Share of the total size, unrounded
+}
+
+// Diagnostic step statuses
+const (
+	DiagnosticStatusOK      = "ok"
+	DiagnosticStatusFailed  = "failed"
+	DiagnosticStatusSkipped = "skipped"
+)
+
+// DiagnosticReport represents the result of a connection self-test against Garage
+type DiagnosticReport struct {
+	StartedAt   time.Time          `json:"startedAt"`
+	DurationMs  int64              `json:"durationMs"`
+	Success     bool               `json:"success"`
+	Steps       []DiagnosticStep   `json:"steps"`
+	CacheWarmup *CacheWarmupStatus `json:"cacheWarmup,omitempty"` // Set when monitoring.warm_cache is enabled
+}
+
+// SelfTestReport represents the result of an end-to-end self-test of the deployment
+type SelfTestReport struct {
+	StartedAt  time.Time        `json:"startedAt"`
+	DurationMs int64            `json:"durationMs"`
+	Success    bool             `json:"success"`
+	Bucket     string           `json:"bucket"`
+	Steps      []DiagnosticStep `json:"steps"`
+}
+
+// CacheWarmupStatus represents the progress of the bucket statistics cache warm-up
+type CacheWarmupStatus struct {
+	State          string     `json:"state"`   // pending, warming, ready or failed
+	Buckets        int        `json:"buckets"` // Buckets in the current or last run
+	Warmed         int        `json:"warmed"`
+	Failed         int        `json:"failed"`
+	Runs           int        `json:"runs"` // Completed runs, the initial warm-up included
+	LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+// DiagnosticStep represents a single step of a diagnostic run
+type DiagnosticStep struct {
+	Name      string `json:"name"`   // e.g. "s3_dns", "admin_auth"
+	Target    string `json:"target"` // Host or endpoint the step ran against
+	Status    string `json:"status"` // ok, failed or skipped
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+	Hint      string `json:"hint,omitempty"` // Actionable suggestion when the step failed
 }
 
 // APIResponse is the standard response structure for all API endpoints
@@ -27,15 +141,48 @@ type APIResponse struct {
 
 // APIError represents an error in the API response
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty" legacy:"request_id"` // Set by the global error handler to correlate with server logs
 }
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
+	Status     string    `json:"status"`
+	Timestamp  time.Time `json:"timestamp"`
+	ServerTime time.Time `json:"serverTime" legacy:"server_time"` // Server clock in UTC, for clients to measure their own skew
+	Version    string    `json:"version"`
+	Upstream   Upstream  `json:"upstream"` // Last contact with Garage, as observed on regular traffic
+
+	Maintenance *Maintenance `json:"maintenance,omitempty"` // Set while maintenance mode is in effect
+}
+
+// Maintenance represents the maintenance mode, during which every API call that would change
+// something is rejected while browsing keeps working
+type Maintenance struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"` // Shown to users in the banner and in rejected calls
+	Until   *time.Time `json:"until,omitempty"`   // Maintenance ends on its own at this time (optional)
+}
+
+// Active reports whether the maintenance mode is in effect at now
+func (m Maintenance) Active(now time.Time) bool {
+	return m.Enabled && (m.Until == nil || now.Before(*m.Until))
+}
+
+// Upstream represents the last observed contact with each Garage API
+type Upstream struct {
+	S3    UpstreamContact `json:"s3"`
+	Admin UpstreamContact `json:"admin"`
+}
+
+// UpstreamContact represents when a Garage API last answered and last failed to. Both times
+// are nil until the first call of that kind; a failure more recent than the last success
+// means the API is currently unreachable.
+type UpstreamContact struct {
+	LastSuccess         *time.Time `json:"lastSuccess" legacy:"last_success"`
+	LastFailure         *time.Time `json:"lastFailure" legacy:"last_failure"`
+	ConsecutiveFailures int64      `json:"consecutiveFailures" legacy:"consecutive_failures"`
 }
 
 // BucketInfo represents information about a bucket
@@ -45,33 +192,239 @@ type BucketInfo struct {
 	ObjectCount  *int64    `json:"objectCount,omitempty"`
 	Size         *int64    `json:"size,omitempty"`
 	Region       string    `json:"region,omitempty"`
+	ReadOnly     bool      `json:"readOnly"` // Changes through the UI are refused, by setting or by garage.read_only_buckets
+
+	// Table columns taken from the same Admin API lookup as the statistics, and omitted
+	// with them
+	KeyCount      *int  `json:"keyCount,omitempty"`      // Keys with any permission on the bucket
+	WebsiteAccess *bool `json:"websiteAccess,omitempty"` // Website access is enabled
+	HasQuota      *bool `json:"hasQuota,omitempty"`      // A size or object count quota is set
+
+	// Set when the Admin API failed and the last statistics fetched are served instead,
+	// with when they were fetched
+	Stale       bool       `json:"stale,omitempty"`
+	GeneratedAt *time.Time `json:"generatedAt,omitempty"`
+}
+
+// BucketDetails represents a bucket as known to Garage, along with its UI-side flags
+type BucketDetails struct {
+	GarageBucketInfo
+	ReadOnly bool `json:"readOnly"` // Changes through the UI are refused, by setting or by garage.read_only_buckets
+}
+
+// BucketWebsiteResponse represents the website hosting configuration of a bucket
+type BucketWebsiteResponse struct {
+	Bucket        string `json:"bucket"`
+	Enabled       bool   `json:"enabled"`
+	IndexDocument string `json:"indexDocument,omitempty"`
+	ErrorDocument string `json:"errorDocument,omitempty"`
+	PublicURL     string `json:"publicUrl,omitempty"` // Set when garage.website_root_domain is configured
+}
+
+// BucketDeleteSummary represents the result of deleting a bucket. The counts and lists are
+// only set by force deletions.
+type BucketDeleteSummary struct {
+	Bucket         string   `json:"bucket"`
+	Message        string   `json:"message"`
+	DeletedObjects int      `json:"deletedObjects,omitempty"`
+	AbortedUploads int      `json:"abortedUploads,omitempty"`
+	RemovedAliases []string `json:"removedAliases,omitempty"` // Global aliases, and local ones as accessKeyId:alias
+	RevokedKeys    []string `json:"revokedKeys,omitempty"`    // Access key IDs whose grants were revoked
+}
+
+// BucketAliasesResponse represents the global aliases of a bucket
+type BucketAliasesResponse struct {
+	Bucket        string   `json:"bucket"` // ID of the bucket
+	GlobalAliases []string `json:"globalAliases"`
+}
+
+// CORSRule represents an S3 CORS rule of a bucket. Responses use the same shape, so the
+// rules of a bucket can be sent back as they were read.
+type CORSRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins"`           // Origins such as https://example.com; * allows any, one wildcard per origin
+	AllowedMethods []string `json:"allowedMethods"`           // GET, PUT, POST, DELETE or HEAD
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"` // Headers preflight requests may ask for
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`  // Response headers readable by browser scripts, such as ETag
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty"`  // How long browsers may cache preflight responses
+}
+
+// BucketCORSResponse represents the CORS rules of a bucket
+type BucketCORSResponse struct {
+	Bucket string     `json:"bucket"`
+	Rules  []CORSRule `json:"rules"`
+}
+
+// LifecycleRule represents an expiration rule of a bucket. Garage has no lifecycle policies,
+// so garage-ui enforces the rules itself. Responses use the same shape, so the rules of a
+// bucket can be sent back as they were read.
+type LifecycleRule struct {
+	ID                        string `json:"id"`                                  // Unique within the bucket
+	Prefix                    string `json:"prefix,omitempty"`                    // Keys the rule applies to (default: every key)
+	Enabled                   bool   `json:"enabled"`                             // Disabled rules are kept but not enforced
+	ExpirationDays            int    `json:"expirationDays,omitempty"`            // Delete objects last modified this many days ago (0: never)
+	AbortIncompleteUploadDays int    `json:"abortIncompleteUploadDays,omitempty"` // Abort multipart uploads started this many days ago (0: never)
+}
+
+// LifecycleRun represents one enforcement of the lifecycle rules of a bucket
+type LifecycleRun struct {
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	ExpiredObjects int       `json:"expiredObjects"`
+	AbortedUploads int       `json:"abortedUploads"`
+	Error          string    `json:"error,omitempty"` // Why the run stopped early, if it did
+}
+
+// BucketLifecycleResponse represents the lifecycle rules of a bucket
+type BucketLifecycleResponse struct {
+	Bucket   string          `json:"bucket"`
+	Rules    []LifecycleRule `json:"rules"`
+	Enforced bool            `json:"enforced"`          // lifecycle.enabled is set, so the rules are enforced periodically
+	LastRun  *LifecycleRun   `json:"lastRun,omitempty"` // Last enforcement since garage-ui started
+}
+
+// MissingWebsiteDocuments describes a website configuration refused because the documents
+// it names do not exist in the bucket
+type MissingWebsiteDocuments struct {
+	Missing   []string `json:"missing"` // Object keys of the missing index and error documents
+	PublicURL string   `json:"publicUrl,omitempty"`
 }
 
 // BucketListResponse represents a list of buckets
 type BucketListResponse struct {
-	Buckets []BucketInfo `json:"buckets"`
-	Count   int          `json:"count"`
+	Buckets    []BucketInfo `json:"buckets"`
+	Count      int          `json:"count"`
+	Truncated  bool         `json:"truncated,omitempty"` // The Admin API list hit garage.admin_list_limit
+	Degraded   bool         `json:"degraded,omitempty"`  // The Admin API is unavailable: listed through S3, without statistics
+	Pagination Pagination   `json:"pagination"`
+}
+
+// BucketSettings represents UI-only preferences for a bucket; they are never sent to Garage
+type BucketSettings struct {
+	MaxKeys   int    `json:"maxKeys,omitempty" legacy:"max_keys"`     // Default page size when listing objects (0: server default)
+	SortBy    string `json:"sortBy,omitempty" legacy:"sort_by"`       // Default sort field: name, size or last_modified
+	SortOrder string `json:"sortOrder,omitempty" legacy:"sort_order"` // Default sort order: asc or desc
+	FlatView  bool   `json:"flatView" legacy:"flat_view"`             // List keys flat instead of browsing by folder
+
+	// TrashEnabled moves objects deleted through the UI to the trash prefix instead of
+	// deleting them. This is enforced by the backend, unlike the display preferences above.
+	TrashEnabled bool `json:"trashEnabled" legacy:"trash_enabled"`
+
+	// PublicBrowsing serves the bucket read-only and without authentication under /public
+	// when public.enabled is set and the bucket has website access
+	PublicBrowsing bool `json:"publicBrowsing" legacy:"public_browsing"`
+
+	// ReadOnly refuses uploads, deletions and write grants on the bucket through the UI,
+	// whatever its keys allow. Only admins may change it.
+	ReadOnly bool `json:"readOnly"`
+
+	// File type rules enforced on uploads, as case-insensitive glob lists. Uploads are
+	// refused when their declared or sniffed type is outside AllowedContentTypes (when set),
+	// or when their name or sniffed type matches BlockedExtensions, e.g. "*.exe".
+	AllowedContentTypes []string `json:"allowedContentTypes,omitempty"`
+	BlockedExtensions   []string `json:"blockedExtensions,omitempty"`
+
+	// DedupeHints points uploads whose content was recently uploaded under another key at
+	// that key: "after" stores the upload and reports it, "before" refuses the upload with
+	// 409 unless allow_duplicate is set. Empty disables the hints.
+	DedupeHints string `json:"dedupeHints,omitempty"`
+}
+
+// TrashItem represents an object moved to a bucket's trash
+type TrashItem struct {
+	TrashKey    string    `json:"trashKey" legacy:"trash_key"`
+	OriginalKey string    `json:"originalKey" legacy:"original_key"`
+	DeletedAt   time.Time `json:"deletedAt" legacy:"deleted_at"`
+	Size        int64     `json:"size"`
+}
+
+// TrashListResponse represents the trashed objects of a bucket
+type TrashListResponse struct {
+	Bucket      string      `json:"bucket"`
+	Items       []TrashItem `json:"items"`
+	Count       int         `json:"count"`
+	IsTruncated bool        `json:"isTruncated" legacy:"is_truncated"`
+}
+
+// TrashRestoreResponse represents the response after restoring an object from the trash
+type TrashRestoreResponse struct {
+	Bucket   string `json:"bucket"`
+	TrashKey string `json:"trashKey" legacy:"trash_key"`
+	Key      string `json:"key"`
 }
 
 // ObjectInfo represents information about an object
 type ObjectInfo struct {
 	Key          string            `json:"key"`
 	Size         int64             `json:"size"`
-	LastModified time.Time         `json:"last_modified"`
+	LastModified time.Time         `json:"lastModified" legacy:"last_modified"`
 	ETag         string            `json:"etag"`
-	ContentType  string            `json:"content_type,omitempty"`
-	StorageClass string            `json:"storage_class,omitempty"`
+	ContentType  string            `json:"contentType,omitempty" legacy:"content_type"`
+	StorageClass string            `json:"storageClass,omitempty" legacy:"storage_class"` // Omitted when Garage does not report one
 	Metadata     map[string]string `json:"metadata,omitempty"`
+	PublicURL    string            `json:"publicUrl,omitempty" legacy:"public_url"` // Set when the bucket is served publicly via website access
+
+	ReplicationStatus string `json:"replicationStatus,omitempty" legacy:"replication_status"` // x-amz-replication-status, when Garage sends it
+	ContentEncoding   string `json:"contentEncoding,omitempty"`                               // gzip for objects uploaded with compress=gzip
+
+	Tags map[string]string `json:"tags,omitempty"` // Object tags, only filled in by the object metadata route
 }
 
 // ObjectListResponse represents a list of objects in a bucket
 type ObjectListResponse struct {
 	Bucket                string       `json:"bucket"`
+	Prefixes              []string     `json:"prefixes"` // Sorted, listed before the objects
 	Objects               []ObjectInfo `json:"objects"`
-	Prefixes              []string     `json:"prefixes"`
 	Count                 int          `json:"count"`
-	IsTruncated           bool         `json:"is_truncated"`
-	NextContinuationToken string       `json:"next_continuation_token,omitempty"`
+	Scanned               int          `json:"scanned,omitempty"` // Keys scanned to fill a filtered page
+	IsTruncated           bool         `json:"isTruncated" legacy:"is_truncated"`
+	NextContinuationToken string       `json:"nextContinuationToken,omitempty" legacy:"next_continuation_token"`
+	Public                bool         `json:"public"` // Bucket has website access enabled, so every object is publicly reachable
+	Pagination            Pagination   `json:"pagination"`
+
+	// SortWindowExceeded is set on sorted listings of folders holding more keys than are
+	// sorted; the keys after the window are missing from the listing
+	SortWindowExceeded bool `json:"sortWindowExceeded,omitempty"`
+}
+
+// ObjectStreamBatch is one line of a streamed object listing, holding one Garage page
+type ObjectStreamBatch struct {
+	Type     string       `json:"type"`     // Always "batch"
+	Prefixes []string     `json:"prefixes"` // Sorted, listed before the objects
+	Objects  []ObjectInfo `json:"objects"`
+}
+
+// ObjectStreamSummary is the last line of a streamed object listing
+type ObjectStreamSummary struct {
+	Type        string `json:"type"` // Always "summary"
+	Bucket      string `json:"bucket"`
+	Prefix      string `json:"prefix"`
+	Count       int    `json:"count"`
+	PrefixCount int    `json:"prefixCount" legacy:"prefix_count"`
+	Scanned     int    `json:"scanned,omitempty"`                 // Keys scanned by a filtered listing
+	IsTruncated bool   `json:"isTruncated" legacy:"is_truncated"` // The listing stopped at the object or scan limit
+	Error       string `json:"error,omitempty"`                   // Set when the listing failed part way
+}
+
+// EmptyBucketProgress is one line of the progress of a bucket being emptied
+type EmptyBucketProgress struct {
+	Type           string `json:"type"` // "progress" after each deleted page, then "summary"
+	Bucket         string `json:"bucket"`
+	Deleted        int    `json:"deleted"`                  // Objects deleted so far
+	Total          int64  `json:"total"`                    // Objects Garage counted before emptying started
+	AbortedUploads int    `json:"abortedUploads,omitempty"` // Incomplete multipart uploads aborted, in the summary
+	Error          string `json:"error,omitempty"`          // Set in the summary when emptying failed part way
+}
+
+// Pagination is the paging metadata included in every list response. Offset-based listings
+// set Offset, Total and NextOffset; object listings page with NextContinuationToken instead.
+type Pagination struct {
+	Limit                 int    `json:"limit"`
+	Offset                int    `json:"offset"`
+	Total                 int    `json:"total,omitempty"`
+	HasMore               bool   `json:"hasMore" legacy:"has_more"`
+	NextOffset            *int   `json:"nextOffset,omitempty" legacy:"next_offset"`
+	NextContinuationToken string `json:"nextContinuationToken,omitempty" legacy:"next_continuation_token"`
 }
 
 // ObjectUploadResponse represents the response after uploading an object
@@ -79,40 +432,139 @@ type ObjectUploadResponse struct {
 	Bucket      string `json:"bucket"`
 	Key         string `json:"key"`
 	ETag        string `json:"etag"`
-	Size        int64  `json:"size"`
-	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`       // Size of the uploaded file
+	StoredSize  int64  `json:"storedSize"` // Size of the object in Garage, smaller than size when compressed
+	ContentType string `json:"contentType" legacy:"content_type"`
+
+	ContentEncoding string `json:"contentEncoding,omitempty"` // gzip when the object is stored compressed
+	DuplicateOf     string `json:"duplicateOf,omitempty"`     // Key recently uploaded with the same content (dedupe hints)
+}
+
+// ObjectTagsResponse represents the tags of an object
+type ObjectTagsResponse struct {
+	Bucket string            `json:"bucket"`
+	Key    string            `json:"key"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// ObjectCopyResponse represents a copied object
+type ObjectCopyResponse struct {
+	SourceBucket string `json:"sourceBucket"`
+	SourceKey    string `json:"sourceKey"`
+	Bucket       string `json:"bucket"`
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+}
+
+// ObjectMoveResponse represents a moved object or folder
+type ObjectMoveResponse struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	TargetKey string `json:"targetKey"`
+	Folder    bool   `json:"folder"` // Whether every object under key was moved
+	Moved     int    `json:"moved"`  // Number of objects moved
+}
+
+// UploadProgress represents how far an upload proxied to Garage has got
+type UploadProgress struct {
+	UploadID    string `json:"uploadId"`
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	Total       int64  `json:"total"`           // Size of the file, in bytes
+	Transferred int64  `json:"transferred"`     // Bytes relayed to Garage so far
+	Done        bool   `json:"done"`            // Whether the upload ended, successfully unless error is set
+	Error       string `json:"error,omitempty"` // Why the upload failed
+}
+
+// Kinds of object previews
+const (
+	PreviewKindText = "text"
+	PreviewKindPDF  = "pdf"
+	PreviewKindNone = "none" // The object cannot be previewed; download it instead
+)
+
+// ObjectPreview represents a bounded preview of an object for the preview pane
+type ObjectPreview struct {
+	Bucket      string   `json:"bucket"`
+	Key         string   `json:"key"`
+	Size        int64    `json:"size"`
+	ContentType string   `json:"contentType"`
+	Kind        string   `json:"kind"`
+	Text        string   `json:"text,omitempty"`      // Start of text objects, as UTF-8
+	Truncated   bool     `json:"truncated,omitempty"` // Text holds only the start of the object
+	PDF         *PDFInfo `json:"pdf,omitempty"`
+}
+
+// PDFInfo represents what is known about a PDF document without reading it whole
+type PDFInfo struct {
+	Version   string `json:"version,omitempty"`
+	PageCount int    `json:"pageCount,omitempty"` // Omitted when it could not be determined
+	Encrypted bool   `json:"encrypted,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Creator   string `json:"creator,omitempty"`
+	Producer  string `json:"producer,omitempty"`
+}
+
+// MultipartUploadResponse represents a multipart upload that has been started
+type MultipartUploadResponse struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	UploadID    string `json:"uploadId"`
+	MinPartSize int64  `json:"minPartSize"` // Smallest size of every part but the last
+	MaxPartSize int64  `json:"maxPartSize"` // Largest part a request may carry (server.max_body_size)
+	MaxParts    int    `json:"maxParts"`
+}
+
+// MultipartPart represents an uploaded part of a multipart upload
+type MultipartPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// DuplicateUpload describes an upload refused because its content was recently uploaded
+// under another key
+type DuplicateUpload struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	DuplicateOf string `json:"duplicateOf"`
+	MD5         string `json:"md5"`
 }
 
 // ObjectUploadMultipleResponse represents the response after uploading multiple objects
 type ObjectUploadMultipleResponse struct {
 	Bucket       string                     `json:"bucket"`
-	TotalFiles   int                        `json:"total_files"`
-	SuccessCount int                        `json:"success_count"`
-	FailureCount int                        `json:"failure_count"`
-	SuccessFiles []ObjectUploadResult       `json:"success_files"`
-	FailedFiles  []ObjectUploadFailedResult `json:"failed_files,omitempty"`
+	TotalFiles   int                        `json:"totalFiles" legacy:"total_files"`
+	SuccessCount int                        `json:"successCount" legacy:"success_count"`
+	FailureCount int                        `json:"failureCount" legacy:"failure_count"`
+	SuccessFiles []ObjectUploadResult       `json:"successFiles" legacy:"success_files"`
+	FailedFiles  []ObjectUploadFailedResult `json:"failedFiles,omitempty" legacy:"failed_files"`
 }
 
 // ObjectUploadResult represents a successful upload result
 type ObjectUploadResult struct {
 	Key         string `json:"key"`
 	ETag        string `json:"etag"`
-	Size        int64  `json:"size"`
-	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`       // Size of the uploaded file
+	StoredSize  int64  `json:"storedSize"` // Size of the object in Garage, smaller than size when compressed
+	ContentType string `json:"contentType,omitempty" legacy:"content_type"`
 }
 
 // ObjectUploadFailedResult represents a failed upload result
 type ObjectUploadFailedResult struct {
 	Key         string `json:"key"`
 	Error       string `json:"error"`
-	ContentType string `json:"content_type,omitempty"`
+	ContentType string `json:"contentType,omitempty" legacy:"content_type"`
 }
 
 // ObjectDeleteResponse represents the response after deleting an object
 type ObjectDeleteResponse struct {
-	Bucket  string `json:"bucket"`
-	Key     string `json:"key"`
-	Deleted bool   `json:"deleted"`
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	Deleted  bool   `json:"deleted"`
+	TrashKey string `json:"trashKey,omitempty" legacy:"trash_key"` // Set when the object was moved to the trash
 }
 
 // UserInfo represents information about a Garage user (key pair)
@@ -125,6 +577,19 @@ type UserInfo struct {
 	BucketPermissions []BucketPermission `json:"permissions"` // Array of bucket permissions
 	Expiration        *time.Time         `json:"expiration,omitempty"`
 	Expired           bool               `json:"expired"`
+	ETag              string             `json:"etag,omitempty"` // Send back as If-Match when updating the key
+
+	// LastSeenActivity is when requests of the key were last noticed in Garage's metrics, to
+	// the sampling interval. Omitted when unknown or when Garage has no per-key metrics.
+	LastSeenActivity *time.Time `json:"lastSeenActivity,omitempty"`
+}
+
+// EditConflict describes an update rejected because the state changed since it was loaded
+type EditConflict struct {
+	ProvidedETag string      `json:"providedEtag" legacy:"provided_etag"` // From the If-Match header
+	CurrentETag  string      `json:"currentEtag" legacy:"current_etag"`
+	Current      interface{} `json:"current"`   // The state as it is now
+	Requested    interface{} `json:"requested"` // The rejected update
 }
 
 // BucketPermission represents permissions for a specific bucket
@@ -143,23 +608,360 @@ type Permission struct {
 	Effect   string   `json:"effect"` // "Allow" or "Deny"
 }
 
+// LimitsResponse lists the limits that apply to the current user's requests, so clients
+// can check them before sending a request. Durations are in seconds. Garage UI applies no
+// request rate limit of its own; throttling by Garage is answered with 429 and Retry-After.
+type LimitsResponse struct {
+	MaxBodySize           int64             `json:"maxBodySize" legacy:"max_body_size"`             // Bytes per request, multipart overhead included
+	MaxObjectSize         int64             `json:"maxObjectSize" legacy:"max_object_size"`         // Bytes per object uploaded with a form; uploads are proxied, so this is the body limit
+	MaxStreamedObjectSize int64             `json:"maxStreamedObjectSize"`                          // Bytes per object uploaded with PUT /buckets/{bucket}/objects/{key}, which the body limit does not apply to
+	MaxUploadFiles        int               `json:"maxUploadFiles" legacy:"max_upload_files"`       // Files per multi-upload; 0 means only the body limit applies
+	PresignDefaultTTL     int64             `json:"presignDefaultTtl" legacy:"presign_default_ttl"` // Presigned URL expiry when none is requested
+	PresignMaxTTL         int64             `json:"presignMaxTtl" legacy:"presign_max_ttl"`         // Longest presigned URL expiry
+	DefaultPageSize       int               `json:"defaultPageSize" legacy:"default_page_size"`
+	MaxPageSize           int               `json:"maxPageSize" legacy:"max_page_size"`
+	MaxKeyLength          int               `json:"maxKeyLength"`                                // Bytes per object key
+	MaxRequestHeader      int               `json:"maxRequestHeader"`                            // Bytes for the request line and headers, percent-encoded keys included
+	SelfService           *SelfServiceLimit `json:"selfService,omitempty" legacy:"self_service"` // Only for OIDC users when self-service keys are enabled
+	Bulk                  *BulkLimits       `json:"bulk,omitempty"`                              // Only for administrators
+	Timeouts              TimeoutLimits     `json:"timeouts"`
+}
+
+// TimeoutLimits lists the time budget in seconds of each group of API routes, 0 meaning
+// unlimited. Requests running past their budget are answered with 504.
+type TimeoutLimits struct {
+	Default      int64 `json:"default"`
+	Listing      int64 `json:"listing"`
+	Presign      int64 `json:"presign"`
+	Transfer     int64 `json:"transfer"`
+	TransferIdle int64 `json:"transferIdle"` // ZIP exports making no progress for this long are aborted
+}
+
+// SelfServiceLimit describes the self-service keys the current user may create
+type SelfServiceLimit struct {
+	MaxKeys    int      `json:"maxKeys" legacy:"max_keys"`
+	DefaultTTL int64    `json:"defaultTtl" legacy:"default_ttl"`
+	MaxTTL     int64    `json:"maxTtl" legacy:"max_ttl"`
+	Buckets    []string `json:"buckets"` // Bucket names mapped to the user's roles; "*" means every bucket
+}
+
+// BulkLimits lists the item caps of the administrative bulk endpoints
+type BulkLimits struct {
+	MaxUsers   int `json:"maxUsers" legacy:"max_users"`     // Keys per bulk user deletion
+	MaxBuckets int `json:"maxBuckets" legacy:"max_buckets"` // Buckets per bulk permission change
+}
+
 type PresignedURLResponse struct {
-	URL       string `json:"url"`
-	ExpiresIn int64  `json:"expires_in"` // in seconds
-	Bucket    string `json:"bucket"`
-	Key       string `json:"key"`
+	URL          string    `json:"url"`
+	Method       string    `json:"method"`                        // HTTP method the URL is signed for
+	ExpiresIn    int64     `json:"expiresIn" legacy:"expires_in"` // Applied expiry, in seconds
+	ExpiresAt    time.Time `json:"expiresAt" legacy:"expires_at"`
+	MaxExpiresIn int64     `json:"maxExpiresIn" legacy:"max_expires_in"` // Configured maximum expiry, in seconds
+	Clamped      bool      `json:"clamped,omitempty"`                    // The requested expiry exceeded the maximum and was lowered
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
 }
 
 type ObjectDeleteMultipleResponse struct {
 	Bucket  string   `json:"bucket"`
 	Deleted int      `json:"deleted"`
 	Keys    []string `json:"keys"`
+	Trashed bool     `json:"trashed,omitempty"` // Set when the objects were moved to the trash
+}
+
+// ObjectMetadataResult represents the metadata of one object in a batch lookup
+type ObjectMetadataResult struct {
+	Key    string      `json:"key"`
+	Object *ObjectInfo `json:"object,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ObjectMetadataBatchResponse represents the result of a batch metadata lookup. Results
+// are in the order the keys were requested.
+type ObjectMetadataBatchResponse struct {
+	Bucket       string                 `json:"bucket"`
+	Total        int                    `json:"total"`
+	SuccessCount int                    `json:"successCount" legacy:"success_count"`
+	FailureCount int                    `json:"failureCount" legacy:"failure_count"`
+	Partial      bool                   `json:"partial"` // Some keys failed while others succeeded
+	Results      []ObjectMetadataResult `json:"results"`
+}
+
+// ObjectDeletePrefixResponse represents the result of deleting every object under a prefix
+type ObjectDeletePrefixResponse struct {
+	Bucket  string `json:"bucket"`
+	Prefix  string `json:"prefix"`
+	Deleted int    `json:"deleted"`
+	Trashed bool   `json:"trashed,omitempty"` // Set when the objects were moved to the trash
+}
+
+// DeleteConfirmation is returned with 428 Precondition Required by destructive operations.
+// Repeating the request with confirm_token set to ConfirmToken before ExpiresAt executes it.
+type DeleteConfirmation struct {
+	ConfirmToken string    `json:"confirmToken" legacy:"confirm_token"`
+	Objects      int64     `json:"objects"` // Objects the operation would delete
+	Bytes        int64     `json:"bytes"`   // Bytes the operation would delete
+	ExpiresAt    time.Time `json:"expiresAt" legacy:"expires_at"`
+}
+
+// ZipManifestEntry is one object of a prepared ZIP download
+type ZipManifestEntry struct {
+	Key  string `json:"key"`
+	Name string `json:"name"` // Path in the archive; differs from key when the key is not a safe relative path, is over 255 bytes or cleans into the path of another entry
+	Size int64  `json:"size"`
+}
+
+// ZipDownloadManifest describes a ZIP download prepared to be fetched in parts. Part N holds
+// the archive bytes [N*PartSize, (N+1)*PartSize), so concatenating the parts in order gives
+// the whole archive.
+type ZipDownloadManifest struct {
+	Token     string             `json:"token"`
+	Bucket    string             `json:"bucket"`
+	Entries   []ZipManifestEntry `json:"entries"` // In archive order
+	Size      int64              `json:"size"`    // Bytes of the whole archive
+	PartSize  int64              `json:"partSize"`
+	Parts     int                `json:"parts"`
+	ExpiresAt time.Time          `json:"expiresAt"`
+}
+
+// AuditEvent represents a security-relevant action performed through the UI
+type AuditEvent struct {
+	Time       time.Time         `json:"time"`
+	Actor      string            `json:"actor"`
+	AuthMethod string            `json:"authMethod,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	Action     string            `json:"action"`
+	Target     string            `json:"target,omitempty"`
+	Success    bool              `json:"success"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// AdminRawResponse represents the raw answer of an Admin API endpoint, with secrets removed
+type AdminRawResponse struct {
+	Endpoint   string          `json:"endpoint"`
+	Path       string          `json:"path"`
+	Status     int             `json:"status"`
+	DurationMs int64           `json:"durationMs" legacy:"duration_ms"`
+	Body       json.RawMessage `json:"body" swaggertype:"object"`
+}
+
+// SlowRequest represents a request that took longer than logging.slow_request_threshold
+type SlowRequest struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	DurationMs int64     `json:"durationMs"`
+	Username   string    `json:"username,omitempty"`
+}
+
+// KeyNotificationTarget is where changes to a key's access are announced
+type KeyNotificationTarget struct {
+	WebhookURL string `json:"webhookUrl,omitempty"` // Receives each notification as a JSON POST
+	Contact    string `json:"contact,omitempty"`    // Email-style contact of the key's owners, passed along as metadata
+}
+
+// Key notification events
+const (
+	KeyEventPermissionGrant  = "permission.grant"
+	KeyEventPermissionRevoke = "permission.revoke"
+	KeyEventKeyDelete        = "key.delete"
+)
+
+// KeyNotification describes a change to a key's access, as sent to its webhook
+type KeyNotification struct {
+	Event       string    `json:"event"`
+	AccessKeyID string    `json:"accessKeyId"`
+	Bucket      string    `json:"bucket,omitempty"`
+	Permissions string    `json:"permissions,omitempty"` // Granted or revoked, e.g. "read,write"
+	Actor       string    `json:"actor,omitempty"`       // Who made the change
+	Contact     string    `json:"contact,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Notification delivery statuses
+const (
+	NotificationDelivered = "delivered"
+	NotificationFailed    = "failed"  // Every attempt failed
+	NotificationDropped   = "dropped" // The queue was full or the server shut down
+)
+
+// NotificationDelivery records the outcome of delivering a key notification
+type NotificationDelivery struct {
+	Notification KeyNotification `json:"notification"`
+	Target       string          `json:"target"` // Webhook URL
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	Error        string          `json:"error,omitempty"` // Last failure
+	CompletedAt  time.Time       `json:"completedAt"`
+}
+
+// NotificationListResponse represents a page of recent notification deliveries, newest first
+type NotificationListResponse struct {
+	Deliveries []NotificationDelivery `json:"deliveries"`
+	Count      int                    `json:"count"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// AuditListResponse represents a page of recent audit events, newest first
+type AuditListResponse struct {
+	Events     []AuditEvent `json:"events"`
+	Count      int          `json:"count"`
+	Pagination Pagination   `json:"pagination"`
+}
+
+// AuthConfigResponse lists the login methods the frontend should offer
+type AuthConfigResponse struct {
+	Admin AuthMethodConfig `json:"admin"`
+	OIDC  AuthMethodConfig `json:"oidc"`
+
+	Maintenance *Maintenance `json:"maintenance,omitempty"` // Set while maintenance mode is in effect, for the banner
+}
+
+// AuthMethodConfig describes one login method
+type AuthMethodConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Provider string `json:"provider,omitempty"` // Display name of the OIDC provider
+	Status   string `json:"status,omitempty"`   // OIDC only: initializing, degraded (discovery failing, retried) or ready
+}
+
+// AuthUser identifies the authenticated user
+type AuthUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// LoginResponse is returned by a successful admin login
+type LoginResponse struct {
+	Token string   `json:"token"`
+	User  AuthUser `json:"user"`
+}
+
+// CurrentUserResponse is returned by /auth/me
+type CurrentUserResponse struct {
+	User    AuthUser    `json:"user"`
+	Summary UserSummary `json:"summary"`
+}
+
+// UserSummary represents the personalized summary returned by /auth/me
+type UserSummary struct {
+	Role            string `json:"role"` // "admin" or "user"
+	AuthMethod      string `json:"authMethod" legacy:"auth_method"`
+	ReadableBuckets int    `json:"readableBuckets" legacy:"readable_buckets"`
+	WritableBuckets int    `json:"writableBuckets" legacy:"writable_buckets"`
+	TotalBytes      int64  `json:"totalBytes" legacy:"total_bytes"`
+	StatsAvailable  bool   `json:"statsAvailable" legacy:"stats_available"` // False when no bucket stats are cached yet
+}
+
+// BucketPermissionResult represents the outcome of a bulk grant or revoke on one bucket
+type BucketPermissionResult struct {
+	Bucket  string `json:"bucket"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkBucketPermissionResponse represents the outcome of a bulk grant or revoke
+type BulkBucketPermissionResponse struct {
+	AccessKey    string                   `json:"accessKey" legacy:"access_key"`
+	Action       string                   `json:"action"`
+	Total        int                      `json:"total"`
+	SuccessCount int                      `json:"successCount" legacy:"success_count"`
+	FailureCount int                      `json:"failureCount" legacy:"failure_count"`
+	Results      []BucketPermissionResult `json:"results"`
+}
+
+// UserDeleteResult represents the outcome of deleting one key in a bulk deletion
+type UserDeleteResult struct {
+	AccessKey   string   `json:"accessKey" legacy:"access_key"`
+	Deleted     bool     `json:"deleted"`
+	Error       string   `json:"error,omitempty"`
+	SoleOwnerOf []string `json:"soleOwnerOf,omitempty" legacy:"sole_owner_of"` // Buckets that would be left without an owner
+}
+
+// UserDeleteMultipleResponse represents the result of a bulk user/key deletion
+type UserDeleteMultipleResponse struct {
+	Total        int                `json:"total"`
+	SuccessCount int                `json:"successCount" legacy:"success_count"`
+	FailureCount int                `json:"failureCount" legacy:"failure_count"`
+	Results      []UserDeleteResult `json:"results"`
+}
+
+// APITokenInfo represents a personal API token and its scope
+type APITokenInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Buckets   []string  `json:"buckets,omitempty"` // Bucket name patterns of a scoped token
+	Verbs     []string  `json:"verbs,omitempty"`   // Verbs of a scoped token
+	Scoped    bool      `json:"scoped"`
+	CreatedAt time.Time `json:"createdAt" legacy:"created_at"`
+	ExpiresAt time.Time `json:"expiresAt" legacy:"expires_at"`
+	Token     string    `json:"token,omitempty"` // Only returned when the token is created
+}
+
+// APITokenListResponse represents the API tokens of the current user
+type APITokenListResponse struct {
+	Tokens []APITokenInfo `json:"tokens"`
+	Count  int            `json:"count"`
 }
 
 // UserListResponse represents a list of users/keys
 type UserListResponse struct {
-	Users []UserInfo `json:"users"`
-	Count int        `json:"count"`
+	Users      []UserInfo `json:"users"`
+	Count      int        `json:"count"`
+	Truncated  bool       `json:"truncated,omitempty"` // The Admin API list hit garage.admin_list_limit
+	Pagination Pagination `json:"pagination"`
+}
+
+// KeyTestCheck represents the outcome of a single connectivity check run with a key
+type KeyTestCheck struct {
+	Name      string `json:"name"` // "list_buckets" or "head_bucket"
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"` // Upstream error text when the check failed
+}
+
+// KeyTestResponse represents the result of testing a key's S3 connectivity
+type KeyTestResponse struct {
+	AccessKeyID string         `json:"accessKeyId"`
+	Bucket      string         `json:"bucket,omitempty"`
+	Success     bool           `json:"success"`
+	Checks      []KeyTestCheck `json:"checks"`
+}
+
+// PermissionMatrix represents which keys can access which buckets, for one page of buckets
+type PermissionMatrix struct {
+	Buckets      []PermissionMatrixBucket `json:"buckets"` // Columns
+	Keys         []PermissionMatrixRow    `json:"keys"`    // Rows, one per key with access to a bucket on this page
+	Totals       PermissionMatrixTotals   `json:"totals"`
+	Offset       int                      `json:"offset"`
+	Limit        int                      `json:"limit"`
+	TotalBuckets int                      `json:"totalBuckets"`
+	NextOffset   *int                     `json:"nextOffset,omitempty"` // Set when more bucket pages remain
+	Truncated    bool                     `json:"truncated,omitempty"`  // The Admin API bucket or key list hit garage.admin_list_limit
+}
+
+// PermissionMatrixBucket represents a bucket column of the permission matrix
+type PermissionMatrixBucket struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PermissionMatrixRow represents a key row of the permission matrix
+type PermissionMatrixRow struct {
+	AccessKeyID string   `json:"accessKeyId"`
+	Name        string   `json:"name"`
+	Cells       []string `json:"cells"` // One per bucket column: "r", "w" and "o" flags, e.g. "rw-", or "" for no access
+}
+
+// PermissionMatrixTotals summarizes the permission matrix page
+type PermissionMatrixTotals struct {
+	Keys                  int `json:"keys"`
+	Grants                int `json:"grants"`
+	OwnerThreshold        int `json:"ownerThreshold"`
+	KeysOwningManyBuckets int `json:"keysOwningManyBuckets"` // Keys with owner on more than OwnerThreshold buckets
+	BucketsWithoutOwner   int `json:"bucketsWithoutOwner"`
+	BucketsWithoutKeys    int `json:"bucketsWithoutKeys"`
 }
 
 // Helper functions to create standard responses
@@ -173,6 +975,83 @@ func SuccessResponse(data interface{}) APIResponse {
 	}
 }
 
+// ConflictResponse creates an edit conflict API response carrying both versions of the state
+func ConflictResponse(message string, conflict EditConflict) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    conflict,
+		Error: &APIError{
+			Code:    ErrCodeConflict,
+			Message: message,
+		},
+	}
+}
+
+// ConfirmationRequiredResponse creates an API response asking the client to confirm a
+// destructive operation with the token it carries
+func ConfirmationRequiredResponse(message string, confirmation DeleteConfirmation) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    confirmation,
+		Error: &APIError{
+			Code:    ErrCodeConfirmationRequired,
+			Message: message,
+		},
+	}
+}
+
+// ClusterDegradedResponse creates an API response refusing a write because the cluster
+// lacks write quorum, carrying the cluster health the decision was based on
+func ClusterDegradedResponse(message string, health *ClusterHealth) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    health,
+		Error: &APIError{
+			Code:    ErrCodeClusterDegraded,
+			Message: message,
+		},
+	}
+}
+
+// MaintenanceResponse creates an API response refusing a change because maintenance mode is
+// in effect, carrying the maintenance state
+func MaintenanceResponse(message string, maintenance Maintenance) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    maintenance,
+		Error: &APIError{
+			Code:    ErrCodeMaintenance,
+			Message: message,
+		},
+	}
+}
+
+// WebsiteDocumentsMissingResponse creates an API response refusing a website configuration
+// whose documents do not exist, listing them
+func WebsiteDocumentsMissingResponse(message string, missing MissingWebsiteDocuments) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    missing,
+		Error: &APIError{
+			Code:    ErrCodeWebsiteDocumentsMissing,
+			Message: message,
+		},
+	}
+}
+
+// DuplicateUploadResponse creates an API response refusing an upload of content already
+// stored under another key, pointing at that key
+func DuplicateUploadResponse(message string, duplicate DuplicateUpload) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    duplicate,
+		Error: &APIError{
+			Code:    ErrCodeDuplicateUpload,
+			Message: message,
+		},
+	}
+}
+
 // ErrorResponse creates an error API response
 func ErrorResponse(code, message string) APIResponse {
 	return APIResponse{
@@ -187,18 +1066,29 @@ func ErrorResponse(code, message string) APIResponse {
 
 // Common error codes
 const (
-	ErrCodeBadRequest        = "BAD_REQUEST"
-	ErrCodeUnauthorized      = "UNAUTHORIZED"
-	ErrCodeForbidden         = "FORBIDDEN"
-	ErrCodeNotFound          = "NOT_FOUND"
-	ErrCodeConflict          = "CONFLICT"
-	ErrCodeInternalError     = "INTERNAL_ERROR"
-	ErrCodeBucketExists      = "BUCKET_ALREADY_EXISTS"
-	ErrCodeBucketNotFound    = "BUCKET_NOT_FOUND"
-	ErrCodeObjectNotFound    = "OBJECT_NOT_FOUND"
-	ErrCodeInvalidBucketName = "INVALID_BUCKET_NAME"
-	ErrCodeInvalidObjectKey  = "INVALID_OBJECT_KEY"
-	ErrCodeUploadFailed      = "UPLOAD_FAILED"
-	ErrCodeDeleteFailed      = "DELETE_FAILED"
-	ErrCodeListFailed        = "LIST_FAILED"
+	ErrCodeBadRequest              = "BAD_REQUEST"
+	ErrCodeUnauthorized            = "UNAUTHORIZED"
+	ErrCodeForbidden               = "FORBIDDEN"
+	ErrCodeNotFound                = "NOT_FOUND"
+	ErrCodeConflict                = "CONFLICT"
+	ErrCodeInternalError           = "INTERNAL_ERROR"
+	ErrCodeBucketExists            = "BUCKET_ALREADY_EXISTS"
+	ErrCodeBucketNotFound          = "BUCKET_NOT_FOUND"
+	ErrCodeObjectNotFound          = "OBJECT_NOT_FOUND"
+	ErrCodeInvalidBucketName       = "INVALID_BUCKET_NAME"
+	ErrCodeInvalidObjectKey        = "INVALID_OBJECT_KEY"
+	ErrCodeUploadFailed            = "UPLOAD_FAILED"
+	ErrCodeDeleteFailed            = "DELETE_FAILED"
+	ErrCodeListFailed              = "LIST_FAILED"
+	ErrCodeThrottled               = "THROTTLED"
+	ErrCodeUpstream                = "UPSTREAM_ERROR"
+	ErrCodeConfirmationRequired    = "CONFIRMATION_REQUIRED"
+	ErrCodeClusterDegraded         = "CLUSTER_DEGRADED"
+	ErrCodeMaintenance             = "MAINTENANCE"
+	ErrCodeBucketReadOnly          = "BUCKET_READ_ONLY"
+	ErrCodeFileTypeBlocked         = "FILE_TYPE_BLOCKED"
+	ErrCodeWebsiteDocumentsMissing = "WEBSITE_DOCUMENTS_MISSING"
+	ErrCodeDuplicateUpload         = "DUPLICATE_UPLOAD"
+	ErrCodeUnsupported             = "UNSUPPORTED"
+	ErrCodeTimeout                 = "TIMEOUT"
 )
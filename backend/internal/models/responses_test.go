@@ -0,0 +1,238 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the serialization snapshots in testdata")
+
+// responseModels are the models serialized into API responses
+var responseModels = []reflect.Type{
+	reflect.TypeFor[DashboardMetrics](),
+	reflect.TypeFor[ClusterStatisticsResponse](),
+	reflect.TypeFor[CapacitySummary](),
+	reflect.TypeFor[UserTransfer](),
+	reflect.TypeFor[TransferStatsResponse](),
+	reflect.TypeFor[KeyActivityResponse](),
+	reflect.TypeFor[KeyBucketActivity](),
+	reflect.TypeFor[BucketUsage](),
+	reflect.TypeFor[DiagnosticReport](),
+	reflect.TypeFor[SelfTestReport](),
+	reflect.TypeFor[CacheWarmupStatus](),
+	reflect.TypeFor[DiagnosticStep](),
+	reflect.TypeFor[APIResponse](),
+	reflect.TypeFor[APIError](),
+	reflect.TypeFor[HealthResponse](),
+	reflect.TypeFor[Maintenance](),
+	reflect.TypeFor[Upstream](),
+	reflect.TypeFor[UpstreamContact](),
+	reflect.TypeFor[BucketInfo](),
+	reflect.TypeFor[BucketDetails](),
+	reflect.TypeFor[BucketWebsiteResponse](),
+	reflect.TypeFor[BucketDeleteSummary](),
+	reflect.TypeFor[BucketAliasesResponse](),
+	reflect.TypeFor[CORSRule](),
+	reflect.TypeFor[BucketCORSResponse](),
+	reflect.TypeFor[LifecycleRule](),
+	reflect.TypeFor[LifecycleRun](),
+	reflect.TypeFor[BucketLifecycleResponse](),
+	reflect.TypeFor[MissingWebsiteDocuments](),
+	reflect.TypeFor[BucketListResponse](),
+	reflect.TypeFor[BucketSettings](),
+	reflect.TypeFor[TrashItem](),
+	reflect.TypeFor[TrashListResponse](),
+	reflect.TypeFor[TrashRestoreResponse](),
+	reflect.TypeFor[ObjectInfo](),
+	reflect.TypeFor[ObjectListResponse](),
+	reflect.TypeFor[ObjectStreamBatch](),
+	reflect.TypeFor[ObjectStreamSummary](),
+	reflect.TypeFor[EmptyBucketProgress](),
+	reflect.TypeFor[Pagination](),
+	reflect.TypeFor[ObjectUploadResponse](),
+	reflect.TypeFor[ObjectTagsResponse](),
+	reflect.TypeFor[ObjectCopyResponse](),
+	reflect.TypeFor[ObjectMoveResponse](),
+	reflect.TypeFor[UploadProgress](),
+	reflect.TypeFor[ObjectPreview](),
+	reflect.TypeFor[PDFInfo](),
+	reflect.TypeFor[MultipartUploadResponse](),
+	reflect.TypeFor[MultipartPart](),
+	reflect.TypeFor[DuplicateUpload](),
+	reflect.TypeFor[ObjectUploadMultipleResponse](),
+	reflect.TypeFor[ObjectUploadResult](),
+	reflect.TypeFor[ObjectUploadFailedResult](),
+	reflect.TypeFor[ObjectDeleteResponse](),
+	reflect.TypeFor[UserInfo](),
+	reflect.TypeFor[EditConflict](),
+	reflect.TypeFor[BucketPermission](),
+	reflect.TypeFor[Permission](),
+	reflect.TypeFor[LimitsResponse](),
+	reflect.TypeFor[TimeoutLimits](),
+	reflect.TypeFor[SelfServiceLimit](),
+	reflect.TypeFor[BulkLimits](),
+	reflect.TypeFor[PresignedURLResponse](),
+	reflect.TypeFor[ObjectDeleteMultipleResponse](),
+	reflect.TypeFor[ObjectMetadataResult](),
+	reflect.TypeFor[ObjectMetadataBatchResponse](),
+	reflect.TypeFor[ObjectDeletePrefixResponse](),
+	reflect.TypeFor[DeleteConfirmation](),
+	reflect.TypeFor[ZipManifestEntry](),
+	reflect.TypeFor[ZipDownloadManifest](),
+	reflect.TypeFor[AuditEvent](),
+	reflect.TypeFor[AdminRawResponse](),
+	reflect.TypeFor[SlowRequest](),
+	reflect.TypeFor[KeyNotificationTarget](),
+	reflect.TypeFor[KeyNotification](),
+	reflect.TypeFor[NotificationDelivery](),
+	reflect.TypeFor[NotificationListResponse](),
+	reflect.TypeFor[AuditListResponse](),
+	reflect.TypeFor[AuthConfigResponse](),
+	reflect.TypeFor[AuthMethodConfig](),
+	reflect.TypeFor[AuthUser](),
+	reflect.TypeFor[LoginResponse](),
+	reflect.TypeFor[CurrentUserResponse](),
+	reflect.TypeFor[UserSummary](),
+	reflect.TypeFor[BucketPermissionResult](),
+	reflect.TypeFor[BulkBucketPermissionResponse](),
+	reflect.TypeFor[UserDeleteResult](),
+	reflect.TypeFor[UserDeleteMultipleResponse](),
+	reflect.TypeFor[APITokenInfo](),
+	reflect.TypeFor[APITokenListResponse](),
+	reflect.TypeFor[UserListResponse](),
+	reflect.TypeFor[KeyTestCheck](),
+	reflect.TypeFor[KeyTestResponse](),
+	reflect.TypeFor[PermissionMatrix](),
+	reflect.TypeFor[PermissionMatrixBucket](),
+	reflect.TypeFor[PermissionMatrixRow](),
+	reflect.TypeFor[PermissionMatrixTotals](),
+}
+
+// sampleValue returns a value of type t with every field set, so that omitempty fields are
+// serialized too
+func sampleValue(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		v.Set(reflect.ValueOf(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+		return v
+	case reflect.TypeFor[json.RawMessage]():
+		v.Set(reflect.ValueOf(json.RawMessage(`{"raw":true}`)))
+		return v
+	}
+	if depth > 4 {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		v.Set(sampleValue(t.Elem(), depth+1).Addr())
+	case reflect.Slice:
+		v.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), sampleValue(t.Elem(), depth+1)))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		v.SetMapIndex(sampleValue(t.Key(), depth+1), sampleValue(t.Elem(), depth+1))
+	case reflect.Interface:
+		v.Set(reflect.ValueOf("x"))
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() {
+				v.Field(i).Set(sampleValue(t.Field(i).Type, depth+1))
+			}
+		}
+	}
+	return v
+}
+
+// jsonKeys returns the object keys found anywhere in a JSON document
+func jsonKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	var keys []string
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, field := range v {
+				keys = append(keys, key)
+				walk(field)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(document)
+	return keys
+}
+
+func TestResponseModelSerialization(t *testing.T) {
+	var snapshot bytes.Buffer
+	for _, model := range responseModels {
+		value := sampleValue(model, 0).Interface()
+
+		current, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("encoding %s: %v", model.Name(), err)
+		}
+		legacy, err := MarshalLegacyJSON(value)
+		if err != nil {
+			t.Fatalf("encoding %s with legacy names: %v", model.Name(), err)
+		}
+
+		for _, key := range jsonKeys(t, current) {
+			if strings.Contains(key, "_") {
+				t.Errorf("%s serializes field %q, which is not camelCase", model.Name(), key)
+			}
+		}
+		fmt.Fprintf(&snapshot, "%s\n  %s\n  %s\n", model.Name(), current, legacy)
+	}
+
+	golden := filepath.Join("testdata", "responses.golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, snapshot.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading snapshot (run with -update to create it): %v", err)
+	}
+	if got := snapshot.String(); got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := range min(len(gotLines), len(wantLines)) {
+			if gotLines[i] != wantLines[i] {
+				t.Fatalf("serialization differs from %s at line %d:\n got: %s\nwant: %s\n(run with -update if the change is intended)", golden, i+1, gotLines[i], wantLines[i])
+			}
+		}
+		t.Fatalf("serialization differs from %s in length (run with -update if the change is intended)", golden)
+	}
+}
@@ -0,0 +1,91 @@
+// Package objectroute parses the wildcard part of the object routes,
+// /api/v1/buckets/:bucket/objects/*, into an object key and an optional trailing action
+// such as /metadata.
+//
+// Actions are recognized on the raw path, before decoding, and only from an explicit list.
+// A key whose last segment is an action name can therefore still be addressed by encoding
+// its slashes as %2F, which is what the frontend does for every key.
+package objectroute
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Actions that may follow an object key
+const (
+	ActionMetadata  = "metadata"
+	ActionPresign   = "presign"
+	ActionMove      = "move"
+	ActionTags      = "tags"
+	ActionPreview   = "preview"
+	ActionThumbnail = "thumbnail"
+)
+
+// ErrInvalidEncoding is returned for paths that are not validly percent-encoded
+var ErrInvalidEncoding = errors.New("invalid path encoding")
+
+// Parse splits a raw wildcard path into the decoded object key and the trailing action, if
+// its last segment is one of actions. The key is decoded with path rules, so "+" stays a
+// plus sign. An empty key is not an error here; handlers report missing keys themselves.
+func Parse(raw string, actions ...string) (key, action string, err error) {
+	encodedKey := raw
+	if i := strings.LastIndexByte(raw, '/'); i >= 0 && slices.Contains(actions, raw[i+1:]) {
+		encodedKey, action = raw[:i], raw[i+1:]
+	}
+
+	key, err = url.PathUnescape(encodedKey)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return key, action, nil
+}
+
+// Handler returns a handler for a wildcard object route. It stores the decoded key in the
+// objectKey local and runs the handler of the trailing action, or fallback when there is
+// none. Trailing slashes are kept in the key. Badly encoded paths are rejected with 400.
+func Handler(fallback fiber.Handler, actions map[string]fiber.Handler) fiber.Handler {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+
+	return func(c fiber.Ctx) error {
+		key, action, err := Parse(wildcard(c), names...)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				models.ErrorResponse(models.ErrCodeInvalidObjectKey, "Invalid object key: "+err.Error()),
+			)
+		}
+
+		c.Locals("objectKey", key)
+		if handler, ok := actions[action]; ok {
+			return handler(c)
+		}
+		return fallback(c)
+	}
+}
+
+// wildcard returns the raw wildcard path of a request. Without strict routing Fiber drops
+// trailing slashes from it, but they are part of the key of folder markers, so they are
+// taken back from the request path.
+func wildcard(c fiber.Ctx) string {
+	raw := c.Params("*")
+	if raw == "" {
+		return raw
+	}
+
+	path := c.Path()
+	trimmed := strings.TrimRight(path, "/")
+	if len(trimmed) < len(path) && strings.HasSuffix(trimmed, raw) {
+		raw += path[len(trimmed):]
+	}
+	return raw
+}
@@ -0,0 +1,127 @@
+package objectroute
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestParse(t *testing.T) {
+	actions := []string{ActionMetadata, ActionPresign}
+
+	tests := []struct {
+		name       string
+		raw        string
+		actions    []string
+		wantKey    string
+		wantAction string
+		wantErr    error
+	}{
+		{name: "plain key", raw: "a.txt", actions: actions, wantKey: "a.txt"},
+		{name: "nested key", raw: "dir/a.txt", actions: actions, wantKey: "dir/a.txt"},
+		{name: "encoded slash", raw: "dir%2Fa.txt", actions: actions, wantKey: "dir/a.txt"},
+		{name: "plus stays a plus", raw: "a+b.txt", actions: actions, wantKey: "a+b.txt"},
+		{name: "encoded plus", raw: "a%2Bb.txt", actions: actions, wantKey: "a+b.txt"},
+		{name: "encoded space", raw: "a%20b.txt", actions: actions, wantKey: "a b.txt"},
+		{name: "double slash", raw: "dir//a.txt", actions: actions, wantKey: "dir//a.txt"},
+		{name: "trailing slash", raw: "dir/", actions: actions, wantKey: "dir/"},
+		{name: "trailing slashes", raw: "dir//", actions: actions, wantKey: "dir//"},
+		{name: "action", raw: "foo/metadata", actions: actions, wantKey: "foo", wantAction: ActionMetadata},
+		{name: "action after nested key", raw: "dir/foo.txt/presign", actions: actions, wantKey: "dir/foo.txt", wantAction: ActionPresign},
+		{name: "action after trailing slash", raw: "dir//metadata", actions: actions, wantKey: "dir/", wantAction: ActionMetadata},
+		{name: "action name as encoded key", raw: "foo%2Fmetadata", actions: actions, wantKey: "foo/metadata"},
+		{name: "action name as key", raw: "metadata", actions: actions, wantKey: "metadata"},
+		{name: "action name as folder", raw: "foo/metadata/", actions: actions, wantKey: "foo/metadata/"},
+		{name: "action after action name", raw: "foo%2Fmetadata/metadata", actions: actions, wantKey: "foo/metadata", wantAction: ActionMetadata},
+		{name: "action without key", raw: "/metadata", actions: actions, wantKey: "", wantAction: ActionMetadata},
+		{name: "action not allowed", raw: "foo/move", actions: actions, wantKey: "foo/move"},
+		{name: "no actions", raw: "foo/metadata", wantKey: "foo/metadata"},
+		{name: "action is case sensitive", raw: "foo/Metadata", actions: actions, wantKey: "foo/Metadata"},
+		{name: "empty", raw: "", actions: actions, wantKey: ""},
+		{name: "invalid encoding", raw: "a%zz.txt", actions: actions, wantErr: ErrInvalidEncoding},
+		{name: "truncated encoding", raw: "a%2", actions: actions, wantErr: ErrInvalidEncoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, action, err := Parse(tt.raw, tt.actions...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.raw, err, tt.wantErr)
+			}
+			if key != tt.wantKey || action != tt.wantAction {
+				t.Errorf("Parse(%q) = %q, %q, want %q, %q", tt.raw, key, action, tt.wantKey, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	// Every handler answers with its name and the key it was given
+	respond := func(name string) fiber.Handler {
+		return func(c fiber.Ctx) error {
+			key, _ := c.Locals("objectKey").(string)
+			c.Set("X-Handler", name)
+			return c.SendString(key)
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/objects/*", Handler(respond("get"), map[string]fiber.Handler{
+		ActionMetadata: respond(ActionMetadata),
+		ActionPresign:  respond(ActionPresign),
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantHandler string
+		wantKey     string
+	}{
+		{name: "plain key", path: "a.txt", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "a.txt"},
+		{name: "encoded slash", path: "dir%2Fa.txt", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir/a.txt"},
+		{name: "plus", path: "a+b.txt", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "a+b.txt"},
+		{name: "double slash", path: "dir//a.txt", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir//a.txt"},
+		{name: "trailing slash", path: "dir/", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir/"},
+		{name: "trailing slashes", path: "dir//", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir//"},
+		{name: "encoded trailing slash", path: "dir%2F", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir/"},
+		{name: "trailing slash with query", path: "dir/?download=true", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "dir/"},
+		{name: "action name as folder", path: "foo/metadata/", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "foo/metadata/"},
+		{name: "action", path: "foo/metadata", wantStatus: http.StatusOK, wantHandler: ActionMetadata, wantKey: "foo"},
+		{name: "action with encoded key", path: "dir%2Ffoo/presign", wantStatus: http.StatusOK, wantHandler: ActionPresign, wantKey: "dir/foo"},
+		{name: "key named like an action", path: "foo%2Fmetadata", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "foo/metadata"},
+		{name: "unknown action", path: "foo/move", wantStatus: http.StatusOK, wantHandler: "get", wantKey: "foo/move"},
+		{name: "invalid encoding", path: "a%zz.txt", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			// The request line is sent as written, even where it is not valid encoding
+			req.RequestURI = "/objects/" + tt.path
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("X-Handler"); got != tt.wantHandler {
+				t.Errorf("handler = %q, want %q", got, tt.wantHandler)
+			}
+			body := make([]byte, len(tt.wantKey)+1)
+			n, _ := resp.Body.Read(body)
+			if got := string(body[:n]); got != tt.wantKey {
+				t.Errorf("key = %q, want %q", got, tt.wantKey)
+			}
+		})
+	}
+}
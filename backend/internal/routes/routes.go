@@ -5,30 +5,60 @@ import (
 	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/handlers"
 	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/objectroute"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
 	"Noooste/garage-ui/pkg/logger"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/recover"
 	// Swagger imports
 	_ "Noooste/garage-ui/docs"
 
 	"github.com/Noooste/swagger"
 )
 
+// SetupMiddleware applies the middleware every request goes through, before any route.
+// SetupRoutes must be called after it.
+func SetupMiddleware(
+	app *fiber.App,
+	cfg *config.Config,
+	settingsStore *services.SettingsStore,
+	slowRequests *services.SlowRequestLog,
+	throttleStats *services.ThrottleStats,
+	clk clock.Clock,
+) {
+	app.Use(middleware.RequestID())                                 // X-Request-ID, echoed in error responses
+	app.Use(middleware.BodyLimitMiddleware(cfg.Server.BodyLimit())) // 413 for bodies over server.max_body_size, except streamed uploads
+	app.Use(middleware.SlowRequestMiddleware(slowRequests))         // Slow-request list for support bundles
+	app.Use(middleware.AccessLogMiddleware(&cfg.Logging))           // Access log (before recover so panics are logged too)
+	app.Use(recover.New())                                          // Panic recovery
+	app.Use(middleware.ThrottleMiddleware(throttleStats))           // 429 with Retry-After while Garage is throttling
+	app.Use(middleware.MaintenanceMiddleware(settingsStore, clk))   // 503 for changes while maintenance mode is in effect
+}
+
 // SetupRoutes configures all API routes
 func SetupRoutes(
 	app *fiber.App,
 	cfg *config.Config,
 	authService *auth.Service,
+	settingsStore *services.SettingsStore,
 	healthHandler *handlers.HealthHandler,
 	bucketHandler *handlers.BucketHandler,
 	objectHandler *handlers.ObjectHandler,
 	userHandler *handlers.UserHandler,
 	clusterHandler *handlers.ClusterHandler,
 	monitoringHandler *handlers.MonitoringHandler,
+	adminHandler *handlers.AdminHandler,
+	trashHandler *handlers.TrashHandler,
+	lifecycleHandler *handlers.LifecycleHandler,
+	meHandler *handlers.MeHandler,
+	limitsHandler *handlers.LimitsHandler,
+	tokenHandler *handlers.TokenHandler,
+	publicHandler *handlers.PublicHandler,
 ) {
 	// Apply CORS middleware globally
 	app.Use(middleware.CORSMiddleware(&cfg.CORS))
@@ -41,7 +71,7 @@ func SetupRoutes(
 	app.Get("/docs/*", swagger.HandlerDefault)
 
 	// Create auth handler
-	authHandler := handlers.NewAuthHandler(cfg, authService)
+	authHandler := handlers.NewAuthHandler(cfg, authService, settingsStore)
 
 	// Auth configuration endpoint (always accessible, no auth required)
 	app.Get("/auth/config", authHandler.GetAuthConfig)
@@ -50,102 +80,155 @@ func SetupRoutes(
 	api := app.Group("/api/v1")
 
 	// Apply authentication middleware to all API routes
-	api.Use(middleware.AuthMiddleware(&cfg.Auth, authService))
+	api.Use(middleware.AuthMiddleware(&cfg.Auth, &cfg.CORS, authService), middleware.RestrictScopedTokens())
+
+	// Changes to read-only buckets are refused before reaching the handlers
+	readOnly := middleware.RejectReadOnlyBuckets(settingsStore)
+
+	// Every API route gets the default time budget; the routes of the other groups replace
+	// it with theirs. The budgets are published in /api/v1/limits.
+	timeouts := cfg.Server.Timeouts
+	api.Use(middleware.Timeout(timeouts, config.TimeoutGroupDefault))
+	listing := middleware.Timeout(timeouts, config.TimeoutGroupListing)
+	presign := middleware.Timeout(timeouts, config.TimeoutGroupPresign)
+	transfer := middleware.Timeout(timeouts, config.TimeoutGroupTransfer)
 
 	// Bucket routes
 	buckets := api.Group("/buckets")
 	{
-		buckets.Get("/", bucketHandler.ListBuckets)                             // List all buckets
-		buckets.Post("/", bucketHandler.CreateBucket)                           // Create a new bucket
-		buckets.Get("/:name", bucketHandler.GetBucketInfo)                      // Get bucket info
-		buckets.Delete("/:name", bucketHandler.DeleteBucket)                    // Delete a bucket
-		buckets.Post("/:name/permissions", bucketHandler.GrantBucketPermission) // Grant bucket permissions
+		buckets.Get("/", listing, bucketHandler.ListBuckets)                                         // List all buckets
+		buckets.Post("/", bucketHandler.CreateBucket)                                                // Create a new bucket
+		buckets.Get("/:name", bucketHandler.GetBucketInfo)                                           // Get bucket info
+		buckets.Delete("/:name", readOnly, bucketHandler.DeleteBucket)                               // Delete a bucket
+		buckets.Post("/:name/empty", middleware.RequireAdmin(), readOnly, bucketHandler.EmptyBucket) // Delete every object of a bucket (admin only)
+		buckets.Post("/:name/permissions", bucketHandler.GrantBucketPermission)                      // Grant bucket permissions
+		buckets.Get("/:name/settings", bucketHandler.GetBucketSettings)                              // Get bucket UI settings
+		buckets.Patch("/:name", bucketHandler.UpdateBucketSettings)                                  // Update bucket UI settings
+		buckets.Put("/:name/settings", bucketHandler.UpdateBucketSettings)                           // Update bucket UI settings
+		buckets.Get("/:name/website", bucketHandler.GetBucketWebsite)                                // Get website hosting configuration
+		buckets.Put("/:name/website", bucketHandler.UpdateBucketWebsite)                             // Configure website hosting
+		buckets.Delete("/:name/website", bucketHandler.DeleteBucketWebsite)                          // Disable website hosting
+		buckets.Get("/:name/cors", bucketHandler.GetBucketCORS)                                      // Get CORS rules
+		buckets.Put("/:name/cors", bucketHandler.PutBucketCORS)                                      // Replace CORS rules
+		buckets.Post("/:name/aliases", bucketHandler.AddBucketAlias)                                 // Add a global alias
+		buckets.Delete("/:name/aliases", bucketHandler.RemoveBucketAlias)                            // Remove a global alias
+		buckets.Get("/:name/trash", listing, trashHandler.ListTrash)                                 // List trashed objects
+		buckets.Post("/:name/trash/restore", readOnly, trashHandler.RestoreFromTrash)                // Restore a trashed object
+
+		// Lifecycle rules are enforced by garage-ui itself, as Garage has none
+		buckets.Get("/:name/lifecycle", lifecycleHandler.GetLifecycle)                                                     // Get lifecycle rules
+		buckets.Put("/:name/lifecycle", middleware.RequireAdmin(), readOnly, lifecycleHandler.PutLifecycle)                // Replace lifecycle rules (admin only)
+		buckets.Delete("/:name/lifecycle", middleware.RequireAdmin(), readOnly, lifecycleHandler.DeleteLifecycle)          // Remove lifecycle rules (admin only)
+		buckets.Post("/:name/lifecycle/run", middleware.RequireAdmin(), readOnly, transfer, lifecycleHandler.RunLifecycle) // Enforce lifecycle rules now (admin only)
 	}
 
-	// Object routes
-	objects := api.Group("/buckets/:bucket/objects")
+	// Object-specific routes take the key as a wildcard (supporting paths with slashes)
+	objectWildcardHandler := objectroute.Handler(middleware.WithTimeout(timeouts, config.TimeoutGroupTransfer, objectHandler.GetObject), map[string]fiber.Handler{
+		objectroute.ActionMetadata:  objectHandler.GetObjectMetadata,
+		objectroute.ActionPresign:   middleware.WithTimeout(timeouts, config.TimeoutGroupPresign, objectHandler.GetPresignedURL),
+		objectroute.ActionTags:      objectHandler.GetObjectTags,
+		objectroute.ActionPreview:   objectHandler.GetObjectPreview,
+		objectroute.ActionThumbnail: middleware.WithTimeout(timeouts, config.TimeoutGroupTransfer, objectHandler.GetObjectThumbnail),
+	})
+	objectDeleteHandler := objectroute.Handler(objectHandler.DeleteObject, map[string]fiber.Handler{
+		objectroute.ActionTags: objectHandler.DeleteObjectTags,
+	})
+	objectHeadHandler := objectroute.Handler(objectHandler.GetObjectMetadata, nil)
+	objectPostHandler := objectroute.Handler(objectHandler.UnknownObjectAction, map[string]fiber.Handler{
+		objectroute.ActionMove: objectHandler.MoveObject,
+	})
+	objectPutHandler := objectroute.Handler(middleware.WithTimeout(timeouts, config.TimeoutGroupTransfer, objectHandler.UploadObjectStream), map[string]fiber.Handler{
+		objectroute.ActionTags: objectHandler.PutObjectTags,
+	})
+
+	// Object routes. Every route, wildcard ones included, lives in this group so they all go
+	// through the same middlewares exactly once; never register object routes on app.
+	objects := api.Group("/buckets/:bucket/objects", middleware.DecodeBucketParam(), middleware.RequireTokenScope(), readOnly)
 	{
-		objects.Get("/", objectHandler.ListObjects)                           // List objects in bucket
-		objects.Post("/", objectHandler.UploadObject)                         // Upload object (multipart)
-		objects.Post("/upload-multiple", objectHandler.UploadMultipleObjects) // Upload multiple objects
-		objects.Post("/delete-multiple", objectHandler.DeleteMultipleObjects) // Delete multiple objects
+		objects.Get("/", listing, objectHandler.ListObjects)                                          // List objects in bucket
+		objects.Get("/stream", transfer, objectHandler.StreamObjects)                                 // Stream a listing as NDJSON
+		objects.Post("/", transfer, objectHandler.UploadObject)                                       // Upload object (multipart)
+		objects.Post("/upload-multiple", transfer, objectHandler.UploadMultipleObjects)               // Upload multiple objects
+		objects.Post("/delete-multiple", objectHandler.DeleteMultipleObjects)                         // Delete multiple objects
+		objects.Post("/delete-prefix", objectHandler.DeletePrefix)                                    // Delete every object under a prefix
+		objects.Post("/metadata-batch", objectHandler.GetObjectsMetadata)                             // Get the metadata of several objects
+		objects.Post("/manifest", presign, objectHandler.GetDownloadManifest)                         // Presigned URLs of several objects for download managers
+		objects.Post("/download-zip/prepare", objectHandler.PrepareZipDownload)                       // Prepare a ZIP download fetched in parts
+		objects.Get("/download-zip/:token", transfer, objectHandler.DownloadZipPart)                  // Get one part of a prepared ZIP download
+		objects.Post("/download-archive", transfer, objectHandler.DownloadArchive)                    // Stream several objects as one ZIP archive
+		objects.Post("/copy", objectHandler.CopyObject)                                               // Copy an object, possibly from another bucket
+		objects.Post("/multipart/init", objectHandler.InitMultipartUpload)                            // Start a multipart upload
+		objects.Put("/multipart/:upload_id/parts/:part", transfer, objectHandler.UploadMultipartPart) // Upload one part of a multipart upload
+		objects.Post("/multipart/:upload_id/complete", objectHandler.CompleteMultipartUpload)         // Assemble the parts into the object
+		objects.Delete("/multipart/:upload_id/abort", objectHandler.AbortMultipartUpload)             // Cancel a multipart upload
+
+		// Wildcard routes come last so the fixed paths above take precedence. Fiber registers
+		// HEAD alongside every GET route; here HEAD is registered explicitly so it serves
+		// metadata without opening the object.
+		objects.Head("/*", objectHeadHandler)     // Get object metadata
+		objects.Get("/*", objectWildcardHandler)  // Get object, or its /metadata, /presign, /tags, /preview or /thumbnail
+		objects.Post("/*", objectPostHandler)     // Move object or folder with /move
+		objects.Put("/*", objectPutHandler)       // Upload the raw body as the object, or set its /tags
+		objects.Delete("/*", objectDeleteHandler) // Delete object, or its /tags
 	}
 
-	// Object-specific routes with wildcard key parameter (supports paths with slashes)
-	// These need to be registered on the main app with auth middleware applied
-	objectWildcardHandler := func(c fiber.Ctx) error {
-		// Get the full path from wildcard parameter
-		// Note: Fiber v3 does NOT automatically decode params, we need to do it manually
-		path := c.Params("*")
-
-		// Decode the full path using QueryUnescape (handles %20, %2F, etc.)
-		decodedPath, err := url.QueryUnescape(path)
-		if err != nil {
-			// If decoding fails, use the original path
-			decodedPath = path
-		}
+	// Object search lives beside the object routes rather than under them, where it would
+	// shadow an object named "search"
+	api.Get("/buckets/:bucket/search", middleware.DecodeBucketParam(), middleware.RequireTokenScope(), listing, objectHandler.SearchObjects)
 
-		// Check if it's a metadata request
-		if strings.HasSuffix(decodedPath, "/metadata") {
-			// Remove /metadata suffix to get the actual key
-			key := strings.TrimSuffix(decodedPath, "/metadata")
-			c.Locals("objectKey", key)
-			return objectHandler.GetObjectMetadata(c)
-		}
-		// Check if it's a presign request
-		if strings.HasSuffix(decodedPath, "/presign") {
-			// Remove /presign suffix to get the actual key
-			key := strings.TrimSuffix(decodedPath, "/presign")
-			c.Locals("objectKey", key)
-			return objectHandler.GetPresignedURL(c)
-		}
-		// Otherwise, it's a regular object download
-		c.Locals("objectKey", decodedPath)
-		return objectHandler.GetObject(c)
+	// User/Key management routes
+	users := api.Group("/users")
+	{
+		users.Get("/", listing, userHandler.ListUsers)                                                              // List all users/keys
+		users.Post("/", userHandler.CreateUser)                                                                     // Create new user/key
+		users.Post("/delete-multiple", middleware.RequireAdmin(), userHandler.DeleteMultipleUsers)                  // Delete several users/keys (admin only)
+		users.Get("/:access_key", userHandler.GetUser)                                                              // Get user info
+		users.Get("/:access_key/secret", userHandler.GetUserSecretKey)                                              // Get user secret key
+		users.Delete("/:access_key", userHandler.DeleteUser)                                                        // Delete user/key
+		users.Patch("/:access_key", userHandler.UpdateUserPermissions)                                              // Update user permissions
+		users.Post("/:access_key/test", middleware.RequireAdmin(), userHandler.TestUserKey)                         // Test key connectivity (admin only)
+		users.Post("/:access_key/buckets/bulk", middleware.RequireAdmin(), userHandler.UpdateBucketPermissionsBulk) // Grant/revoke on many buckets (admin only)
+		users.Get("/:access_key/notifications", middleware.RequireAdmin(), userHandler.GetUserNotifications)        // Get the key's notification target (admin only)
+		users.Get("/:access_key/activity", middleware.RequireAdmin(), userHandler.GetUserActivity)                  // Recent requests of the key (admin only)
+		users.Put("/:access_key/notifications", middleware.RequireAdmin(), userHandler.UpdateUserNotifications)     // Set the key's notification target (admin only)
 	}
 
-	objectDeleteHandler := func(c fiber.Ctx) error {
-		path := c.Params("*")
+	// Progress of uploads proxied to Garage, as server-sent events
+	api.Get("/uploads/:upload_id/progress", transfer, objectHandler.StreamUploadProgress)
 
-		// Decode the full path using QueryUnescape
-		key, err := url.QueryUnescape(path)
-		if err != nil {
-			// If decoding fails, use the original path
-			key = path
-		}
+	// Effective request limits for the current user
+	api.Get("/limits", limitsHandler.GetLimits)
 
-		c.Locals("objectKey", key)
-		return objectHandler.DeleteObject(c)
+	// Self-service key routes for OIDC users (only if enabled)
+	if cfg.SelfService.Enabled {
+		me := api.Group("/me")
+		{
+			me.Get("/keys", meHandler.ListMyKeys)         // List my keys
+			me.Post("/keys", meHandler.CreateMyKey)       // Issue a key for my buckets
+			me.Delete("/keys/:id", meHandler.DeleteMyKey) // Delete one of my keys
+		}
 	}
 
-	objectHeadHandler := func(c fiber.Ctx) error {
-		path := c.Params("*")
-
-		// Decode the full path using QueryUnescape
-		key, err := url.QueryUnescape(path)
-		if err != nil {
-			// If decoding fails, use the original path
-			key = path
+	// Personal API token routes (only if enabled)
+	if cfg.Auth.APITokens.Enabled {
+		tokens := api.Group("/me/tokens")
+		{
+			tokens.Get("/", tokenHandler.ListTokens)        // List my API tokens and their scopes
+			tokens.Post("/", tokenHandler.CreateToken)      // Issue an API token, optionally scoped
+			tokens.Delete("/:id", tokenHandler.DeleteToken) // Revoke one of my API tokens
 		}
-
-		c.Locals("objectKey", key)
-		return objectHandler.GetObjectMetadata(c)
 	}
 
-	// Register with auth middleware
-	app.Get("/api/v1/buckets/:bucket/objects/*", middleware.AuthMiddleware(&cfg.Auth, authService), objectWildcardHandler)
-	app.Delete("/api/v1/buckets/:bucket/objects/*", middleware.AuthMiddleware(&cfg.Auth, authService), objectDeleteHandler)
-	app.Head("/api/v1/buckets/:bucket/objects/*", middleware.AuthMiddleware(&cfg.Auth, authService), objectHeadHandler)
-
-	// User/Key management routes
-	users := api.Group("/users")
+	// Administrator-only overview routes
+	admin := api.Group("/admin", middleware.RequireAdmin())
 	{
-		users.Get("/", userHandler.ListUsers)                          // List all users/keys
-		users.Post("/", userHandler.CreateUser)                        // Create new user/key
-		users.Get("/:access_key", userHandler.GetUser)                 // Get user info
-		users.Get("/:access_key/secret", userHandler.GetUserSecretKey) // Get user secret key
-		users.Delete("/:access_key", userHandler.DeleteUser)           // Delete user/key
-		users.Patch("/:access_key", userHandler.UpdateUserPermissions) // Update user permissions
+		admin.Get("/permission-matrix", adminHandler.GetPermissionMatrix) // Key/bucket permission matrix
+		admin.Get("/audit", adminHandler.ListAuditEvents)                 // Recent audit events
+		admin.Get("/notifications", adminHandler.ListNotifications)       // Recent key notification deliveries
+		admin.Post("/raw", adminHandler.RawAdminRequest)                  // Read-only Admin API passthrough for debugging
+		admin.Post("/maintenance", adminHandler.SetMaintenance)           // Enable or end maintenance mode
+		admin.Get("/support-bundle", adminHandler.DownloadSupportBundle)  // Zip of config, logs and diagnostics for bug reports
+		admin.Post("/selftest", adminHandler.RunSelfTest)                 // End-to-end exercise in a dedicated test bucket
 	}
 
 	// Cluster management routes
@@ -161,9 +244,12 @@ func SetupRoutes(
 	// Monitoring routes
 	monitoring := api.Group("/monitoring")
 	{
-		monitoring.Get("/metrics", monitoringHandler.GetMetrics)            // Get Prometheus metrics
-		monitoring.Get("/admin-health", monitoringHandler.CheckAdminHealth) // Check Admin API health
-		monitoring.Get("/dashboard", monitoringHandler.GetDashboardMetrics) // Get dashboard metrics
+		monitoring.Get("/metrics", monitoringHandler.GetMetrics)                                         // Get Prometheus metrics
+		monitoring.Get("/admin-health", monitoringHandler.CheckAdminHealth)                              // Check Admin API health
+		monitoring.Get("/dashboard", monitoringHandler.GetDashboardMetrics)                              // Get dashboard metrics
+		monitoring.Get("/diagnostics", monitoringHandler.GetDiagnostics)                                 // Get last connection diagnostics
+		monitoring.Post("/diagnostics/run", middleware.RequireAdmin(), monitoringHandler.RunDiagnostics) // Re-run connection diagnostics (admin only)
+		monitoring.Get("/transfer", middleware.RequireAdmin(), monitoringHandler.GetTransferStats)       // Per-user transfer totals (admin only)
 	}
 
 	// Admin auth login endpoint (only if admin is enabled)
@@ -173,113 +259,24 @@ func SetupRoutes(
 
 	// Auth "me" endpoint (if any auth is enabled)
 	if cfg.Auth.Admin.Enabled || cfg.Auth.OIDC.Enabled {
-		app.Get("/auth/me", middleware.AuthMiddleware(&cfg.Auth, authService), authHandler.GetMe)
+		app.Get("/auth/me", middleware.AuthMiddleware(&cfg.Auth, &cfg.CORS, authService), authHandler.GetMe)
 	}
 
 	// OIDC authentication routes (only if OIDC is enabled)
 	if cfg.Auth.OIDC.Enabled {
 		oidcRoutes := app.Group("/auth/oidc")
 		{
-			// Login endpoint - redirects to OIDC provider
-			oidcRoutes.Get("/login", func(c fiber.Ctx) error {
-				state, err := authService.GenerateStateToken()
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to generate state token",
-					})
-				}
-
-				authURL, err := authService.GetAuthorizationURL(state)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to generate login URL",
-					})
-				}
-				return c.Redirect().To(authURL)
-			})
-
-			// Callback endpoint - handles OIDC redirect after login
-			oidcRoutes.Get("/callback", func(c fiber.Ctx) error {
-				// Get and validate state token
-				state := c.Query("state")
-				if !authService.ValidateAndConsumeState(state) {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"error": "Invalid or expired state token",
-					})
-				}
-
-				// Get authorization code from query
-				code := c.Query("code")
-				if code == "" {
-					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-						"error": "Authorization code is required",
-					})
-				}
-
-				// Exchange code for tokens
-				ctx := c.Context()
-				token, err := authService.ExchangeCode(ctx, code)
-				if err != nil {
-					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-						"error": "Failed to exchange authorization code",
-					})
-				}
-
-				// Extract ID token from OAuth2 token
-				rawIDToken, ok := token.Extra("id_token").(string)
-				if !ok {
-					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-						"error": "No ID token in response",
-					})
-				}
-
-				// Verify ID token and get user info
-				userInfo, err := authService.VerifyIDToken(ctx, rawIDToken)
-				if err != nil {
-					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-						"error": "Invalid ID token",
-					})
-				}
-
-				// Generate JWT session token
-				sessionToken, err := authService.GenerateSessionToken(userInfo)
-				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to create session",
-					})
-				}
-
-				// Set JWT session token as secure cookie
-				c.Cookie(&fiber.Cookie{
-					Name:     cfg.Auth.OIDC.CookieName,
-					Value:    sessionToken,
-					MaxAge:   cfg.Auth.OIDC.SessionMaxAge,
-					Secure:   cfg.Auth.OIDC.CookieSecure,
-					HTTPOnly: cfg.Auth.OIDC.CookieHTTPOnly,
-					SameSite: cfg.Auth.OIDC.CookieSameSite,
-				})
-
-				// Redirect to frontend with success indicator
-				return c.Redirect().To("/login?login=success")
-			})
-
-			// Logout endpoint
-			oidcRoutes.Post("/logout", func(c fiber.Ctx) error {
-				// Clear session cookie
-				c.Cookie(&fiber.Cookie{
-					Name:   cfg.Auth.OIDC.CookieName,
-					Value:  "",
-					MaxAge: -1,
-				})
-
-				return c.JSON(fiber.Map{
-					"success": true,
-					"message": "Logged out successfully",
-				})
-			})
+			oidcRoutes.Get("/login", authHandler.OIDCLogin)       // Redirect to the OIDC provider
+			oidcRoutes.Get("/callback", authHandler.OIDCCallback) // Complete login and set the session cookie
+			oidcRoutes.Post("/logout", authHandler.OIDCLogout)    // Clear the session cookie
 		}
 	}
 
+	// Anonymous read-only browsing of public buckets (only if enabled); never add mutation routes here
+	if cfg.Public.Enabled {
+		app.Get("/public/:bucket/*", middleware.PublicRateLimit(cfg.Public.RateLimit), middleware.DecodeBucketParam(), publicHandler.Browse)
+	}
+
 	cfg.Server.FrontendPath = "./frontend/dist"
 
 	// Check if frontend path exists
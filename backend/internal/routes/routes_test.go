@@ -0,0 +1,141 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// publicAPIRoutes are the /api/v1 routes served without authentication
+var publicAPIRoutes = []string{"GET /api/v1/health", "HEAD /api/v1/health"}
+
+// TestAPIRoutesPassMiddlewaresOnce serves every /api/v1 route as main does and checks that a
+// request to it goes through the auth, throttling and maintenance middlewares exactly once
+// before reaching the route's handler
+func TestAPIRoutesPassMiddlewaresOnce(t *testing.T) {
+	t.Setenv("GARAGE_UI_GARAGE_ENDPOINT", "http://127.0.0.1:3900")
+	t.Setenv("GARAGE_UI_GARAGE_ADMIN_ENDPOINT", "http://127.0.0.1:3903")
+	t.Setenv("GARAGE_UI_GARAGE_ADMIN_TOKEN", "test")
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("config.Load failed: %v", err)
+	}
+	// Every user is an admin, so that only the routing decides which handler is reached
+	cfg.Auth.Admin.Enabled = false
+	cfg.Auth.OIDC.Enabled = false
+	cfg.SelfService.Enabled = true
+	cfg.Auth.APITokens.Enabled = true
+
+	settingsStore, err := services.NewSettingsStore("", nil, clock.Real)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+
+	app := fiber.New()
+	SetupMiddleware(app, cfg, settingsStore, services.NewSlowRequestLog(0), services.NewThrottleStats(), clock.Real)
+	SetupRoutes(app, cfg, nil, settingsStore, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	// Middlewares are told apart by the function their handlers are made from
+	watched := map[string]uintptr{
+		"auth":        handlerPointer(middleware.AuthMiddleware(&cfg.Auth, &cfg.CORS, nil)),
+		"throttle":    handlerPointer(middleware.ThrottleMiddleware(services.NewThrottleStats())),
+		"maintenance": handlerPointer(middleware.MaintenanceMiddleware(settingsStore, clock.Real)),
+	}
+	passes := make(map[string]int)
+	var reached string
+
+	// Route handlers are registered on nil receivers; the last handler of each route is
+	// replaced by one recording which route was reached
+	endpoints := make(map[*fiber.Handler]string)
+	for _, route := range app.GetRoutes(true) {
+		endpoints[&route.Handlers[len(route.Handlers)-1]] = route.Method + " " + route.Path
+	}
+	for _, routes := range app.Stack() {
+		for _, route := range routes {
+			wrapped := make([]fiber.Handler, len(route.Handlers))
+			for i, handler := range route.Handlers {
+				if endpoint, ok := endpoints[&route.Handlers[i]]; ok {
+					wrapped[i] = func(c fiber.Ctx) error {
+						reached = endpoint
+						return c.SendStatus(fiber.StatusNoContent)
+					}
+					continue
+				}
+				wrapped[i] = handler
+				for name, pointer := range watched {
+					if handlerPointer(handler) == pointer {
+						wrapped[i] = func(c fiber.Ctx) error {
+							passes[name]++
+							return handler(c)
+						}
+					}
+				}
+			}
+			route.Handlers = wrapped
+		}
+	}
+
+	checked := 0
+	for _, route := range app.GetRoutes(true) {
+		endpoint := route.Method + " " + route.Path
+		if !strings.HasPrefix(route.Path, "/api/v1/") || slices.Contains(publicAPIRoutes, endpoint) {
+			continue
+		}
+		checked++
+
+		t.Run(endpoint, func(t *testing.T) {
+			clear(passes)
+			reached = ""
+
+			resp, err := app.Test(httptest.NewRequest(route.Method, samplePath(route.Path), nil), fiber.TestConfig{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if reached != endpoint {
+				t.Fatalf("request reached %q (status %d), want %q", reached, resp.StatusCode, endpoint)
+			}
+			for name := range watched {
+				if passes[name] != 1 {
+					t.Errorf("%s middleware ran %d times, want once", name, passes[name])
+				}
+			}
+		})
+	}
+	if checked == 0 {
+		t.Fatal("no /api/v1 route registered")
+	}
+}
+
+// handlerPointer identifies the function a handler was made from
+func handlerPointer(handler fiber.Handler) uintptr {
+	return reflect.ValueOf(handler).Pointer()
+}
+
+// samplePath fills the parameters of a route path with valid values
+func samplePath(routePath string) string {
+	segments := strings.Split(routePath, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "*":
+			segments[i] = "dir/file.txt"
+		case segment == ":part":
+			segments[i] = "1"
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "docs"
+		}
+	}
+	return strings.Join(segments, "/")
+}
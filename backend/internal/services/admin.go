@@ -3,14 +3,20 @@ package services
 import (
 	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
 	"Noooste/garage-ui/pkg/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/Noooste/azuretls-client"
+	"golang.org/x/sync/singleflight"
 )
 
 // GarageAdminService handles interactions with the Garage Admin API
@@ -18,30 +24,125 @@ type GarageAdminService struct {
 	baseURL    string
 	token      string
 	httpClient *azuretls.Session
+	listLimit  int
+
+	// lookups coalesces concurrent identical bucket info requests into one upstream call
+	lookups singleflight.Group
+
+	// contact tracks the last successful and failed Admin API calls
+	contact contactTracker
+
+	// metrics counts Admin API calls by operation and response status
+	metrics *BackendMetrics
+
+	// lastKnown holds the last info fetched for each global alias beyond its cache TTL, to
+	// be served while the Admin API fails (global alias → knownBucketInfo)
+	lastKnown sync.Map
+
+	// logBodies logs every call with its bodies, secrets scrubbed (logging.log_admin_bodies)
+	logBodies bool
+
+	// slots bounds the calls in flight (garage.admin_max_concurrent_requests); a call holds
+	// a slot for each attempt until its response body is closed, not while backing off
+	// between attempts
+	slots chan struct{}
+}
+
+// defaultAdminListLimit caps ListKeys and ListBuckets when garage.admin_list_limit is unset
+const defaultAdminListLimit = 10000
+
+// adminTimeout bounds an Admin API lookup shared by concurrent callers, which none of them
+// can cancel on its own
+const adminTimeout = 30 * time.Second
+
+// sharedLookup runs fn once for the concurrent callers using the same key in group. fn gets
+// its own context, detached from the first caller's cancellation so one aborted request does
+// not fail the others and bounded by adminTimeout; each caller stops waiting when its own ctx
+// is done.
+func sharedLookup(ctx context.Context, group *singleflight.Group, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	results := group.DoChan(key, func() (interface{}, error) {
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), adminTimeout)
+		defer cancel()
+		return fn(lookupCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		return result.Val, result.Err
+	}
 }
 
+// defaultAdminMaxConcurrent caps the Admin API calls in flight when
+// garage.admin_max_concurrent_requests is unset
+const defaultAdminMaxConcurrent = 8
+
 // NewGarageAdminService creates a new Garage Admin API service
-func NewGarageAdminService(cfg *config.GarageConfig, logLevel string) *GarageAdminService {
+func NewGarageAdminService(cfg *config.GarageConfig, logging *config.LoggingConfig, metrics *BackendMetrics) *GarageAdminService {
+	// The session's own logging would print the Authorization header and unscrubbed
+	// bodies; logAdminExchange logs the exchanges instead
 	session := azuretls.NewSession()
+	debug := logging.Level == "debug"
+
+	listLimit := cfg.AdminListLimit
+	if listLimit <= 0 {
+		listLimit = defaultAdminListLimit
+	}
 
-	if logLevel == "debug" {
-		session.Log()
+	maxConcurrent := cfg.AdminMaxConcurrentRequests
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultAdminMaxConcurrent
 	}
 
 	return &GarageAdminService{
 		baseURL:    cfg.AdminEndpoint,
 		token:      cfg.AdminToken,
 		httpClient: session,
+		listLimit:  listLimit,
+		metrics:    metrics,
+		logBodies:  debug && logging.LogAdminBodies,
+		slots:      make(chan struct{}, maxConcurrent),
 	}
 }
 
-// doRequest performs an HTTP request to the Admin API with retry logic for connection refused errors
-func (s *GarageAdminService) doRequest(ctx context.Context, method, path string, body interface{}) (*azuretls.Response, error) {
+// retryPolicy tells doRequest whether an Admin API call may be repeated after a failure
+type retryPolicy int
+
+const (
+	// retrySafe marks reads and updates that converge to the same state when repeated
+	retrySafe retryPolicy = iota
+
+	// retryUnsafe marks creations, which are only retried when the request provably never
+	// reached the Admin API; a repeated CreateKey would otherwise issue a second key
+	retryUnsafe
+)
+
+// doRequest sends an Admin API request, retrying failures as far as policy allows
+func (s *GarageAdminService) doRequest(ctx context.Context, method, path string, body interface{}, policy retryPolicy) (*azuretls.Response, error) {
 	var resp *azuretls.Response
 
 	retryConfig := utils.DefaultRetryConfig()
+	switch {
+	case policy == retryUnsafe:
+		retryConfig = utils.NonIdempotentRetryConfig()
+	case method == http.MethodGet:
+		retryConfig = utils.ReadRetryConfig()
+	}
 	err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+		if err := s.acquireSlot(ctx); err != nil {
+			return err
+		}
+		// The slot is held until the caller closes the body of a successful response
+		handedOver := false
+		defer func() {
+			if !handedOver {
+				s.releaseSlot()
+			}
+		}()
+
 		var reqErr error
+		start := time.Now()
 		resp, reqErr = s.httpClient.Do(&azuretls.Request{
 			Method:     method,
 			Url:        s.baseURL + path,
@@ -51,9 +152,32 @@ func (s *GarageAdminService) doRequest(ctx context.Context, method, path string,
 				{"Authorization", fmt.Sprintf("Bearer %s", s.token)},
 			},
 		}, ctx)
-		return reqErr
+		if s.logBodies {
+			s.logAdminExchange(method, path, body, resp, reqErr, time.Since(start))
+		}
+		if reqErr != nil {
+			s.metrics.adminCallDone(path, 0)
+			return reqErr
+		}
+		s.metrics.adminCallDone(path, resp.StatusCode)
+
+		if isThrottleStatus(resp.StatusCode) {
+			resp.RawBody.Close()
+			markThrottled(ctx, ThrottleSourceAdmin)
+			return fmt.Errorf("%w: Admin API returned status %d", utils.ErrThrottled, resp.StatusCode)
+		}
+
+		resp.RawBody = &slotBody{ReadCloser: resp.RawBody, release: s.releaseSlot}
+		handedOver = true
+		return nil
 	})
 
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+	s.contact.observe(ctx, reachedAPI(err, statusCode))
+
 	if err != nil {
 		return nil, err
 	}
@@ -61,13 +185,74 @@ func (s *GarageAdminService) doRequest(ctx context.Context, method, path string,
 	return resp, nil
 }
 
+// acquireSlot waits for one of the slots bounding the calls in flight, or for ctx to end
+func (s *GarageAdminService) acquireSlot(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	s.metrics.adminQueued.Add(1)
+	defer s.metrics.adminQueued.Add(-1)
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for an Admin API slot: %w", ctx.Err())
+	}
+}
+
+// releaseSlot frees the slot taken by acquireSlot
+func (s *GarageAdminService) releaseSlot() {
+	<-s.slots
+}
+
+// slotBody is the body of an Admin API response, releasing the slot of its call once closed
+type slotBody struct {
+	io.ReadCloser
+	release   func()
+	closeOnce sync.Once
+}
+
+// Close closes the body and releases the slot
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.closeOnce.Do(b.release)
+	return err
+}
+
+// ContactStatus returns when the Admin API last answered and last failed to, as observed
+// on regular traffic
+func (s *GarageAdminService) ContactStatus() models.UpstreamContact {
+	return s.contact.status()
+}
+
+// APIStatusError is returned when the Admin API answers with a non-2xx status
+type APIStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *APIStatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsAdminNotFound reports whether the Admin API answered that the requested resource does not exist
+func IsAdminNotFound(err error) bool {
+	var statusErr *APIStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
 // decodeResponse decodes a JSON response into the target structure
 func decodeResponse(resp *azuretls.Response, target interface{}) error {
 	defer resp.RawBody.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.RawBody)
-		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return &APIStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	if target != nil {
@@ -79,24 +264,62 @@ func decodeResponse(resp *azuretls.Response, target interface{}) error {
 	return nil
 }
 
-// ListKeys returns all access keys in the cluster
-func (s *GarageAdminService) ListKeys(ctx context.Context) ([]models.ListKeysResponseItem, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/ListKeys", nil)
+// decodeListResponse stream-decodes a JSON array response, keeping at most limit items.
+// The v2 Admin API returns complete lists without pagination, so the limit only guards
+// memory on very large clusters; the returned flag reports whether items were dropped.
+func decodeListResponse[T any](resp *azuretls.Response, limit int) ([]T, bool, error) {
+	defer resp.RawBody.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.RawBody)
+		return nil, false, &APIStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	decoder := json.NewDecoder(resp.RawBody)
+	if token, err := decoder.Token(); err != nil {
+		return nil, false, fmt.Errorf("failed to decode response: %w", err)
+	} else if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, false, fmt.Errorf("failed to decode response: expected a JSON array")
+	}
+
+	result := make([]T, 0)
+	for decoder.More() {
+		if len(result) >= limit {
+			return result, true, nil
+		}
+
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return nil, false, fmt.Errorf("failed to decode response: %w", err)
+		}
+		result = append(result, item)
+	}
+
+	return result, false, nil
+}
+
+// ListKeys returns the access keys in the cluster, up to the configured list limit.
+// The returned flag reports whether the list was truncated at that limit.
+func (s *GarageAdminService) ListKeys(ctx context.Context) ([]models.ListKeysResponseItem, bool, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/ListKeys", nil, retrySafe)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, false, fmt.Errorf("request failed: %w", err)
 	}
 
-	var result []models.ListKeysResponseItem
-	if err := decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	result, truncated, err := decodeListResponse[models.ListKeysResponseItem](resp, s.listLimit)
+	if err != nil {
+		return nil, false, err
+	}
+	if truncated {
+		logger.Warn().Int("limit", s.listLimit).Msg("ListKeys truncated at garage.admin_list_limit")
 	}
 
-	return result, nil
+	return result, truncated, nil
 }
 
 // CreateKey creates a new API access key
 func (s *GarageAdminService) CreateKey(ctx context.Context, req models.CreateKeyRequest) (*models.GarageKeyInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/CreateKey", req)
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/CreateKey", req, retryUnsafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -116,7 +339,7 @@ func (s *GarageAdminService) GetKeyInfo(ctx context.Context, keyID string, showS
 		path += "&showSecretKey=true"
 	}
 
-	resp, err := s.doRequest(ctx, http.MethodGet, path, nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, path, nil, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -131,9 +354,12 @@ func (s *GarageAdminService) GetKeyInfo(ctx context.Context, keyID string, showS
 
 // UpdateKey updates information about an access key
 func (s *GarageAdminService) UpdateKey(ctx context.Context, keyID string, req models.UpdateKeyRequest) (*models.GarageKeyInfo, error) {
+	// Bucket info embeds key names and permissions
+	defer utils.GlobalCache.DeletePrefix(bucketInfoCachePrefix)
+
 	path := fmt.Sprintf("/v2/UpdateKey?id=%s", keyID)
 
-	resp, err := s.doRequest(ctx, http.MethodPost, path, req)
+	resp, err := s.doRequest(ctx, http.MethodPost, path, req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -148,9 +374,12 @@ func (s *GarageAdminService) UpdateKey(ctx context.Context, keyID string, req mo
 
 // DeleteKey deletes an access key from the cluster
 func (s *GarageAdminService) DeleteKey(ctx context.Context, keyID string) error {
+	// Bucket info embeds key names and permissions
+	defer utils.GlobalCache.DeletePrefix(bucketInfoCachePrefix)
+
 	path := fmt.Sprintf("/v2/DeleteKey?id=%s", keyID)
 
-	resp, err := s.doRequest(ctx, http.MethodPost, path, nil)
+	resp, err := s.doRequest(ctx, http.MethodPost, path, nil, retrySafe)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -162,9 +391,43 @@ func (s *GarageAdminService) DeleteKey(ctx context.Context, keyID string) error
 	return nil
 }
 
+// SoleOwnedBuckets returns the names of the buckets on which the key is the only owner.
+// Deleting such a key would leave the bucket without anyone able to manage it.
+func (s *GarageAdminService) SoleOwnedBuckets(ctx context.Context, key *models.GarageKeyInfo) ([]string, error) {
+	var names []string
+	for _, bucket := range key.Buckets {
+		if !bucket.Permissions.Owner {
+			continue
+		}
+
+		info, err := s.GetBucketInfo(ctx, bucket.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info for bucket %s: %w", bucket.ID, err)
+		}
+
+		owners := 0
+		for _, bucketKey := range info.Keys {
+			if bucketKey.Permissions.Owner {
+				owners++
+			}
+		}
+		if owners > 1 {
+			continue
+		}
+
+		name := bucket.ID
+		if len(bucket.GlobalAliases) > 0 {
+			name = bucket.GlobalAliases[0]
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
 // ImportKey imports an existing API access key
 func (s *GarageAdminService) ImportKey(ctx context.Context, req models.ImportKeyRequest) (*models.GarageKeyInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/ImportKey", req)
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/ImportKey", req, retryUnsafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -177,60 +440,200 @@ func (s *GarageAdminService) ImportKey(ctx context.Context, req models.ImportKey
 	return &result, nil
 }
 
-// ListBuckets returns all buckets in the cluster
-func (s *GarageAdminService) ListBuckets(ctx context.Context) ([]models.ListBucketsResponseItem, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/ListBuckets", nil)
+// ListBuckets returns the buckets in the cluster, up to the configured list limit.
+// The returned flag reports whether the list was truncated at that limit.
+func (s *GarageAdminService) ListBuckets(ctx context.Context) ([]models.ListBucketsResponseItem, bool, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/ListBuckets", nil, retrySafe)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, false, fmt.Errorf("request failed: %w", err)
 	}
 
-	var result []models.ListBucketsResponseItem
-	if err := decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	result, truncated, err := decodeListResponse[models.ListBucketsResponseItem](resp, s.listLimit)
+	if err != nil {
+		return nil, false, err
+	}
+	if truncated {
+		logger.Warn().Int("limit", s.listLimit).Msg("ListBuckets truncated at garage.admin_list_limit")
 	}
 
-	return result, nil
+	return result, truncated, nil
+}
+
+// bucketInfoCacheTTL bounds how stale cached bucket info may get when Garage is changed
+// behind our back; changes made through this service invalidate the cache immediately
+const bucketInfoCacheTTL = 30 * time.Second
+
+// bucketInfoCachePrefix prefixes all bucket info cache keys
+const bucketInfoCachePrefix = "bucketinfo:"
+
+// bucketInfoCacheKey returns the cache key for a bucket's info
+func bucketInfoCacheKey(bucketID string) string {
+	return bucketInfoCachePrefix + bucketID
+}
+
+// InvalidateBucketInfo drops the cached info for a bucket, along with all alias mappings
+// since aliases may have moved
+func (s *GarageAdminService) InvalidateBucketInfo(bucketID string) {
+	// Callers arriving after a change must not join a lookup started before it
+	s.lookups.Forget("id:" + bucketID)
+	utils.GlobalCache.Delete(bucketInfoCacheKey(bucketID))
+	utils.GlobalCache.DeletePrefix(bucketAliasCachePrefix)
+}
+
+// CachedBuckets returns the bucket info currently held in the cache. It never calls the
+// Admin API, so the result only covers buckets fetched within the last bucketInfoCacheTTL.
+func CachedBuckets() []*models.GarageBucketInfo {
+	var buckets []*models.GarageBucketInfo
+	for _, value := range utils.GlobalCache.Values(bucketInfoCachePrefix) {
+		if info, ok := value.(*models.GarageBucketInfo); ok {
+			buckets = append(buckets, info)
+		}
+	}
+	return buckets
 }
 
 // GetBucketInfo returns detailed information about a bucket by ID
 func (s *GarageAdminService) GetBucketInfo(ctx context.Context, bucketID string) (*models.GarageBucketInfo, error) {
-	path := fmt.Sprintf("/v2/GetBucketInfo?id=%s", bucketID)
+	// Check cache first
+	if cached := utils.GlobalCache.Get(bucketInfoCacheKey(bucketID)); cached != nil {
+		if info, ok := cached.(*models.GarageBucketInfo); ok {
+			return info, nil
+		}
+	}
 
-	resp, err := s.doRequest(ctx, http.MethodGet, path, nil)
+	return s.fetchBucketInfo(ctx, bucketID)
+}
+
+// RefreshBucketInfo fetches a bucket's info from the Admin API and caches it under its ID
+// and global aliases. The cached copy keeps being served until the new one replaces it.
+func (s *GarageAdminService) RefreshBucketInfo(ctx context.Context, bucketID string) (*models.GarageBucketInfo, error) {
+	info, err := s.fetchBucketInfo(ctx, bucketID)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 
-	var result models.GarageBucketInfo
-	if err := decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	for _, alias := range info.GlobalAliases {
+		utils.GlobalCache.Set(bucketAliasCachePrefix+alias, info.ID, bucketInfoCacheTTL)
 	}
+	return info, nil
+}
 
-	return &result, nil
+// fetchBucketInfo requests a bucket's info from the Admin API and caches it by ID.
+// Concurrent callers share one request, see sharedLookup.
+func (s *GarageAdminService) fetchBucketInfo(ctx context.Context, bucketID string) (*models.GarageBucketInfo, error) {
+	value, err := sharedLookup(ctx, &s.lookups, "id:"+bucketID, func(ctx context.Context) (interface{}, error) {
+		path := fmt.Sprintf("/v2/GetBucketInfo?id=%s", bucketID)
+
+		resp, err := s.doRequest(ctx, http.MethodGet, path, nil, retrySafe)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		var result models.GarageBucketInfo
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		utils.GlobalCache.Set(bucketInfoCacheKey(bucketID), &result, bucketInfoCacheTTL)
+		return &result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.GarageBucketInfo), nil
 }
 
-// GetBucketInfoByAlias returns detailed information about a bucket by its global alias
-func (s *GarageAdminService) GetBucketInfoByAlias(ctx context.Context, globalAlias string) (*models.GarageBucketInfo, error) {
-	path := fmt.Sprintf("/v2/GetBucketInfo?globalAlias=%s", globalAlias)
+// bucketAliasCachePrefix prefixes the cached global alias to bucket ID mappings
+const bucketAliasCachePrefix = "bucketalias:"
+
+// GetCachedBucketInfoByAlias returns bucket info by global alias, served from the bucket info
+// cache when possible. Use it where data up to bucketInfoCacheTTL old is acceptable, e.g. for
+// display purposes on every listing page.
+func (s *GarageAdminService) GetCachedBucketInfoByAlias(ctx context.Context, globalAlias string) (*models.GarageBucketInfo, error) {
+	if cached := utils.GlobalCache.Get(bucketAliasCachePrefix + globalAlias); cached != nil {
+		if bucketID, ok := cached.(string); ok {
+			if info, err := s.GetBucketInfo(ctx, bucketID); err == nil {
+				return info, nil
+			}
+		}
+	}
 
-	resp, err := s.doRequest(ctx, http.MethodGet, path, nil)
+	info, err := s.GetBucketInfoByAlias(ctx, globalAlias)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 
-	var result models.GarageBucketInfo
-	if err = decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	utils.GlobalCache.Set(bucketAliasCachePrefix+globalAlias, info.ID, bucketInfoCacheTTL)
+	utils.GlobalCache.Set(bucketInfoCacheKey(info.ID), info, bucketInfoCacheTTL)
+
+	return info, nil
+}
+
+// knownBucketInfo is bucket info kept past its TTL, with when it was fetched
+type knownBucketInfo struct {
+	info      *models.GarageBucketInfo
+	fetchedAt time.Time
+}
+
+// GetBucketInfoOrStale returns bucket info by global alias like GetCachedBucketInfoByAlias.
+// When the Admin API fails, the last info fetched for the alias is returned instead, however
+// old, along with when it was fetched; the error is only returned when there is none.
+// staleSince is nil for fresh info.
+func (s *GarageAdminService) GetBucketInfoOrStale(ctx context.Context, globalAlias string) (info *models.GarageBucketInfo, staleSince *time.Time, err error) {
+	info, err = s.GetCachedBucketInfoByAlias(ctx, globalAlias)
+	if err == nil {
+		s.lastKnown.Store(globalAlias, knownBucketInfo{info: info, fetchedAt: time.Now().UTC()})
+		return info, nil, nil
 	}
 
-	return &result, nil
+	value, ok := s.lastKnown.Load(globalAlias)
+	if !ok {
+		return nil, nil, err
+	}
+	known := value.(knownBucketInfo)
+	return known.info, &known.fetchedAt, nil
 }
 
-// CreateBucket creates a new bucket via the Admin API
+// GetBucketInfoByAlias returns detailed information about a bucket by its global alias.
+// Concurrent lookups of the same alias share one upstream request.
+func (s *GarageAdminService) GetBucketInfoByAlias(ctx context.Context, globalAlias string) (*models.GarageBucketInfo, error) {
+	value, err := sharedLookup(ctx, &s.lookups, "alias:"+globalAlias, func(ctx context.Context) (interface{}, error) {
+		path := fmt.Sprintf("/v2/GetBucketInfo?globalAlias=%s", globalAlias)
+
+		resp, err := s.doRequest(ctx, http.MethodGet, path, nil, retrySafe)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		var result models.GarageBucketInfo
+		if err = decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		return &result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.GarageBucketInfo), nil
+}
+
+// ErrCreateOutcomeUnknown is returned when a bucket creation failed without an answer and
+// the bucket found afterwards cannot be told apart from one created by someone else
+var ErrCreateOutcomeUnknown = errors.New("bucket creation outcome is unknown")
+
+// CreateBucket creates a new bucket via the Admin API. The request is not retried; when it
+// fails without an answer, the bucket is looked up to find out whether it was created.
 func (s *GarageAdminService) CreateBucket(ctx context.Context, req models.CreateBucketAdminRequest) (*models.GarageBucketInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/CreateBucket", req)
+	start := time.Now()
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/CreateBucket", req, retryUnsafe)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		if utils.IsDialRefused(err) || utils.IsThrottled(err) {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		return s.findCreatedBucket(ctx, req, start, err)
 	}
 
 	var result models.GarageBucketInfo
@@ -241,11 +644,51 @@ func (s *GarageAdminService) CreateBucket(ctx context.Context, req models.Create
 	return &result, nil
 }
 
+// findCreatedBucket looks up the bucket of a creation that failed without an answer. The
+// bucket is only claimed when it carries the local alias requested for the key, which a
+// bucket created by another client under the same global alias would not.
+func (s *GarageAdminService) findCreatedBucket(ctx context.Context, req models.CreateBucketAdminRequest, start time.Time, createErr error) (*models.GarageBucketInfo, error) {
+	if req.GlobalAlias == nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreateOutcomeUnknown, createErr)
+	}
+
+	// A bucket that is not found yet may still be created by the request in flight, and a bucket
+	// created well before this call already existed and is not ours
+	info, err := s.GetBucketInfoByAlias(ctx, *req.GlobalAlias)
+	if err != nil || info == nil || !info.Created.After(start.Add(-time.Minute)) || !hasRequestedLocalAlias(info, req.LocalAlias) {
+		return nil, fmt.Errorf("%w: %w", ErrCreateOutcomeUnknown, createErr)
+	}
+
+	logger.Warn().Err(createErr).Str("bucket", *req.GlobalAlias).Msg("CreateBucket failed without an answer, but the bucket was created")
+	return info, nil
+}
+
+// hasRequestedLocalAlias reports whether the bucket carries the requested local alias with at
+// least the requested permissions
+func hasRequestedLocalAlias(info *models.GarageBucketInfo, alias *models.CreateBucketLocalAlias) bool {
+	if alias == nil {
+		return false
+	}
+	for _, key := range info.Keys {
+		if key.AccessKeyID != alias.AccessKeyID || !slices.Contains(key.BucketLocalAliases, alias.Alias) {
+			continue
+		}
+		allow := alias.Allow
+		return allow == nil ||
+			(key.Permissions.Read || !allow.Read) &&
+				(key.Permissions.Write || !allow.Write) &&
+				(key.Permissions.Owner || !allow.Owner)
+	}
+	return false
+}
+
 // UpdateBucket updates bucket settings
 func (s *GarageAdminService) UpdateBucket(ctx context.Context, bucketID string, req models.UpdateBucketRequest) (*models.GarageBucketInfo, error) {
+	defer s.InvalidateBucketInfo(bucketID)
+
 	path := fmt.Sprintf("/v2/UpdateBucket?id=%s", bucketID)
 
-	resp, err := s.doRequest(ctx, http.MethodPost, path, req)
+	resp, err := s.doRequest(ctx, http.MethodPost, path, req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -260,9 +703,11 @@ func (s *GarageAdminService) UpdateBucket(ctx context.Context, bucketID string,
 
 // DeleteBucket deletes a bucket
 func (s *GarageAdminService) DeleteBucket(ctx context.Context, bucketID string) error {
+	defer s.InvalidateBucketInfo(bucketID)
+
 	path := fmt.Sprintf("/v2/DeleteBucket?id=%s", bucketID)
 
-	resp, err := s.doRequest(ctx, http.MethodPost, path, nil)
+	resp, err := s.doRequest(ctx, http.MethodPost, path, nil, retrySafe)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -271,12 +716,65 @@ func (s *GarageAdminService) DeleteBucket(ctx context.Context, bucketID string)
 		return fmt.Errorf("failed to process response: %w", err)
 	}
 
+	// A deleted bucket must not come back from the stale info
+	s.lastKnown.Range(func(alias, value interface{}) bool {
+		if value.(knownBucketInfo).info.ID == bucketID {
+			s.lastKnown.Delete(alias)
+		}
+		return true
+	})
+
 	return nil
 }
 
+// DetachBucket revokes every key grant of a bucket and removes its aliases, except the global
+// alias keep, which Garage drops along with the bucket. It returns the aliases removed, local
+// ones as "accessKeyId:alias", and the keys revoked, up to the first failure.
+func (s *GarageAdminService) DetachBucket(ctx context.Context, info *models.GarageBucketInfo, keep string) (removedAliases, revokedKeys []string, err error) {
+	removedAliases, revokedKeys = []string{}, []string{}
+
+	for _, key := range info.Keys {
+		for _, alias := range key.BucketLocalAliases {
+			_, err := s.RemoveBucketAlias(ctx, models.RemoveBucketAliasRequest{
+				BucketID:    info.ID,
+				LocalAlias:  &alias,
+				AccessKeyID: &key.AccessKeyID,
+			})
+			if err != nil {
+				return removedAliases, revokedKeys, fmt.Errorf("failed to remove local alias %s of key %s: %w", alias, key.AccessKeyID, err)
+			}
+			removedAliases = append(removedAliases, key.AccessKeyID+":"+alias)
+		}
+
+		_, err := s.DenyBucketKey(ctx, models.BucketKeyPermRequest{
+			BucketID:    info.ID,
+			AccessKeyID: key.AccessKeyID,
+			Permissions: models.BucketKeyPermission{Read: true, Write: true, Owner: true},
+		})
+		if err != nil {
+			return removedAliases, revokedKeys, fmt.Errorf("failed to revoke key %s: %w", key.AccessKeyID, err)
+		}
+		revokedKeys = append(revokedKeys, key.AccessKeyID)
+	}
+
+	for _, alias := range info.GlobalAliases {
+		if alias == keep {
+			continue
+		}
+		if _, err := s.RemoveBucketAlias(ctx, models.RemoveBucketAliasRequest{BucketID: info.ID, GlobalAlias: &alias}); err != nil {
+			return removedAliases, revokedKeys, fmt.Errorf("failed to remove global alias %s: %w", alias, err)
+		}
+		removedAliases = append(removedAliases, alias)
+	}
+
+	return removedAliases, revokedKeys, nil
+}
+
 // AddBucketAlias adds an alias to a bucket
 func (s *GarageAdminService) AddBucketAlias(ctx context.Context, req models.AddBucketAliasRequest) (*models.GarageBucketInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/AddBucketAlias", req)
+	defer s.InvalidateBucketInfo(req.BucketID)
+
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/AddBucketAlias", req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -291,7 +789,9 @@ func (s *GarageAdminService) AddBucketAlias(ctx context.Context, req models.AddB
 
 // RemoveBucketAlias removes an alias from a bucket
 func (s *GarageAdminService) RemoveBucketAlias(ctx context.Context, req models.RemoveBucketAliasRequest) (*models.GarageBucketInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/RemoveBucketAlias", req)
+	defer s.InvalidateBucketInfo(req.BucketID)
+
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/RemoveBucketAlias", req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -306,7 +806,9 @@ func (s *GarageAdminService) RemoveBucketAlias(ctx context.Context, req models.R
 
 // AllowBucketKey grants permissions for a key on a bucket
 func (s *GarageAdminService) AllowBucketKey(ctx context.Context, req models.BucketKeyPermRequest) (*models.GarageBucketInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/AllowBucketKey", req)
+	defer s.InvalidateBucketInfo(req.BucketID)
+
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/AllowBucketKey", req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -321,7 +823,9 @@ func (s *GarageAdminService) AllowBucketKey(ctx context.Context, req models.Buck
 
 // DenyBucketKey revokes permissions for a key on a bucket
 func (s *GarageAdminService) DenyBucketKey(ctx context.Context, req models.BucketKeyPermRequest) (*models.GarageBucketInfo, error) {
-	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/DenyBucketKey", req)
+	defer s.InvalidateBucketInfo(req.BucketID)
+
+	resp, err := s.doRequest(ctx, http.MethodPost, "/v2/DenyBucketKey", req, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -334,9 +838,16 @@ func (s *GarageAdminService) DenyBucketKey(ctx context.Context, req models.Bucke
 	return &result, nil
 }
 
-// GetClusterHealth returns the health status of the cluster
+// clusterHealthCacheKey is the cache key of the last cluster health answer
+const clusterHealthCacheKey = "clusterhealth"
+
+// clusterHealthCacheTTL bounds how long a cluster health answer is reused
+const clusterHealthCacheTTL = 15 * time.Second
+
+// GetClusterHealth returns the health status of the cluster and caches it for
+// GetCachedClusterHealth
 func (s *GarageAdminService) GetClusterHealth(ctx context.Context) (*models.ClusterHealth, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterHealth", nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterHealth", nil, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -346,12 +857,32 @@ func (s *GarageAdminService) GetClusterHealth(ctx context.Context) (*models.Clus
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	utils.GlobalCache.Set(clusterHealthCacheKey, &result, clusterHealthCacheTTL)
 	return &result, nil
 }
 
+// GetCachedClusterHealth returns the cluster health, served from the cache when it is at
+// most clusterHealthCacheTTL old. Concurrent misses share one upstream request.
+func (s *GarageAdminService) GetCachedClusterHealth(ctx context.Context) (*models.ClusterHealth, error) {
+	if cached := utils.GlobalCache.Get(clusterHealthCacheKey); cached != nil {
+		if health, ok := cached.(*models.ClusterHealth); ok {
+			return health, nil
+		}
+	}
+
+	value, err := sharedLookup(ctx, &s.lookups, "health", func(ctx context.Context) (interface{}, error) {
+		return s.GetClusterHealth(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.ClusterHealth), nil
+}
+
 // GetClusterStatus returns the current status of the cluster
 func (s *GarageAdminService) GetClusterStatus(ctx context.Context) (*models.ClusterStatus, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterStatus", nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterStatus", nil, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -366,7 +897,7 @@ func (s *GarageAdminService) GetClusterStatus(ctx context.Context) (*models.Clus
 
 // GetClusterStatistics returns global cluster statistics
 func (s *GarageAdminService) GetClusterStatistics(ctx context.Context) (*models.ClusterStatistics, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterStatistics", nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, "/v2/GetClusterStatistics", nil, retrySafe)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -379,43 +910,19 @@ func (s *GarageAdminService) GetClusterStatistics(ctx context.Context) (*models.
 	return &result, nil
 }
 
-// GetNodeInfo returns information about a specific node
+// GetNodeInfo returns information about a specific node, or about every node for "*"
 func (s *GarageAdminService) GetNodeInfo(ctx context.Context, nodeID string) (*models.MultiNodeResponse, error) {
-	path := fmt.Sprintf("/v2/GetNodeInfo?node=%s", nodeID)
-
-	resp, err := s.doRequest(ctx, http.MethodGet, path, nil)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-
-	var result models.MultiNodeResponse
-	if err := decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	return s.queryNodes(ctx, "GetNodeInfo", nodeID)
 }
 
-// GetNodeStatistics returns statistics for a specific node
+// GetNodeStatistics returns statistics for a specific node, or for every node for "*"
 func (s *GarageAdminService) GetNodeStatistics(ctx context.Context, nodeID string) (*models.MultiNodeResponse, error) {
-	path := fmt.Sprintf("/v2/GetNodeStatistics?node=%s", nodeID)
-
-	resp, err := s.doRequest(ctx, http.MethodGet, path, nil)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-
-	var result models.MultiNodeResponse
-	if err := decodeResponse(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	return s.queryNodes(ctx, "GetNodeStatistics", nodeID)
 }
 
 // HealthCheck checks if the Admin API is reachable
 func (s *GarageAdminService) HealthCheck(ctx context.Context) error {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/health", nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, "/health", nil, retrySafe)
 	if err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
@@ -429,7 +936,7 @@ func (s *GarageAdminService) HealthCheck(ctx context.Context) error {
 
 // GetMetrics returns Prometheus metrics from the Admin API
 func (s *GarageAdminService) GetMetrics(ctx context.Context) (string, error) {
-	resp, err := s.doRequest(ctx, http.MethodGet, "/metrics", nil)
+	resp, err := s.doRequest(ctx, http.MethodGet, "/metrics", nil, retrySafe)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
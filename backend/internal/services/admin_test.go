@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/garagetest"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/Noooste/azuretls-client"
+	"golang.org/x/sync/singleflight"
+)
+
+// newTestAdminService returns an admin service talking to a fake Admin API served by handler
+func newTestAdminService(t *testing.T, handler http.HandlerFunc, maxConcurrent int) *GarageAdminService {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewGarageAdminService(
+		&config.GarageConfig{AdminEndpoint: server.URL, AdminToken: "test", AdminMaxConcurrentRequests: maxConcurrent},
+		&config.LoggingConfig{},
+		NewBackendMetrics(),
+	)
+}
+
+func TestGetBucketInfoByAliasCoalescesLookups(t *testing.T) {
+	const callers = 20
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{ID: "b1", GlobalAliases: []string{r.URL.Query().Get("globalAlias")}})
+	}, 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, err := admin.GetBucketInfoByAlias(context.Background(), "photos")
+			if err == nil && info.ID != "b1" {
+				err = errors.New("unexpected bucket " + info.ID)
+			}
+			errs <- err
+		}()
+	}
+
+	// Let every caller join the lookup before the fake answers
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+}
+
+func TestSharedLookup(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelFirst bool
+		wantFirst   error
+	}{
+		{name: "callers share the result"},
+		{name: "cancelled caller stops waiting without failing the others", cancelFirst: true, wantFirst: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var group singleflight.Group
+			var calls atomic.Int32
+			started := make(chan struct{})
+			release := make(chan struct{})
+			lookup := func(ctx context.Context) (interface{}, error) {
+				calls.Add(1)
+				close(started)
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("shared lookup has no deadline")
+				}
+				select {
+				case <-release:
+					return "value", ctx.Err()
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+
+			firstCtx, cancelFirst := context.WithCancel(context.Background())
+			defer cancelFirst()
+			firstErr := make(chan error, 1)
+			go func() {
+				_, err := sharedLookup(firstCtx, &group, "key", lookup)
+				firstErr <- err
+			}()
+			<-started
+
+			secondValue := make(chan interface{}, 1)
+			go func() {
+				value, err := sharedLookup(context.Background(), &group, "key", lookup)
+				if err != nil {
+					value = err
+				}
+				secondValue <- value
+			}()
+
+			if tt.cancelFirst {
+				cancelFirst()
+				select {
+				case err := <-firstErr:
+					if !errors.Is(err, tt.wantFirst) {
+						t.Errorf("first caller error = %v, want %v", err, tt.wantFirst)
+					}
+				case <-time.After(time.Second):
+					t.Fatal("cancelled caller is still waiting")
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+
+			if value := <-secondValue; value != "value" {
+				t.Errorf("second caller got %v, want the shared value", value)
+			}
+			if !tt.cancelFirst {
+				if err := <-firstErr; err != nil {
+					t.Errorf("first caller error = %v", err)
+				}
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("lookups = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestDoRequestHoldsSlotUntilBodyClosed(t *testing.T) {
+	const maxConcurrent = 2
+
+	t.Run("open bodies keep their slots", func(t *testing.T) {
+		admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}, maxConcurrent)
+
+		var open []io.ReadCloser
+		for range maxConcurrent {
+			resp, err := admin.doRequest(context.Background(), http.MethodGet, "/v2/GetClusterStatus", nil, retrySafe)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			open = append(open, resp.RawBody)
+		}
+
+		if got := len(admin.slots); got != maxConcurrent {
+			t.Fatalf("slots held with %d bodies open = %d, want %d", maxConcurrent, got, maxConcurrent)
+		}
+
+		// One call more than the slots waits, and gives up with its ctx
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := admin.doRequest(ctx, http.MethodGet, "/v2/GetClusterStatus", nil, retrySafe); !errors.Is(err, context.Canceled) {
+			t.Fatalf("call beyond the slots: err = %v, want %v", err, context.Canceled)
+		}
+
+		// Closing a body frees its slot, once however often it is closed
+		_ = open[0].Close()
+		_ = open[0].Close()
+		resp, err := admin.doRequest(context.Background(), http.MethodGet, "/v2/GetClusterStatus", nil, retrySafe)
+		if err != nil {
+			t.Fatalf("request after a close failed: %v", err)
+		}
+		_ = resp.RawBody.Close()
+		_ = open[1].Close()
+
+		if got := len(admin.slots); got != 0 {
+			t.Errorf("slots held after every body was closed = %d, want 0", got)
+		}
+	})
+
+	t.Run("concurrent calls never exceed the slots", func(t *testing.T) {
+		const callers = 50
+
+		// Every call is held by the server until the test releases it
+		var inFlight, maxInFlight atomic.Int32
+		arrived := make(chan struct{}, callers)
+		release := make(chan struct{})
+		admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			arrived <- struct{}{}
+			<-release
+			inFlight.Add(-1)
+			_, _ = w.Write([]byte(`{}`))
+		}, maxConcurrent)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, callers)
+		for range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var info models.ClusterStatus
+				resp, err := admin.doRequest(context.Background(), http.MethodGet, "/v2/GetClusterStatus", nil, retrySafe)
+				if err == nil {
+					err = decodeResponse(resp, &info)
+				}
+				errs <- err
+			}()
+		}
+
+		// Once the slots are taken every other call queues for one, unless the server sees
+		// more calls than the slots
+		for range maxConcurrent {
+			<-arrived
+		}
+		for admin.metrics.adminQueued.Load() < callers-maxConcurrent && maxInFlight.Load() <= maxConcurrent {
+			runtime.Gosched()
+		}
+
+		// Each call the server answers lets one queued call through
+		for range callers - maxConcurrent {
+			release <- struct{}{}
+			<-arrived
+		}
+		for range maxConcurrent {
+			release <- struct{}{}
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+		}
+		if got := maxInFlight.Load(); got != maxConcurrent {
+			t.Errorf("calls in flight at once = %d, want %d", got, maxConcurrent)
+		}
+	})
+}
+
+func TestDecodeListResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		limit         int
+		wantIDs       []string
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{name: "empty", status: http.StatusOK, body: `[]`, limit: 3, wantIDs: []string{}},
+		{name: "below limit", status: http.StatusOK, body: `[{"id":"a"},{"id":"b"}]`, limit: 3, wantIDs: []string{"a", "b"}},
+		{name: "at limit", status: http.StatusOK, body: `[{"id":"a"},{"id":"b"},{"id":"c"}]`, limit: 3, wantIDs: []string{"a", "b", "c"}},
+		{name: "over limit", status: http.StatusOK, body: `[{"id":"a"},{"id":"b"},{"id":"c"},{"id":"d"}]`, limit: 3, wantIDs: []string{"a", "b", "c"}, wantTruncated: true},
+		{name: "not an array", status: http.StatusOK, body: `{"items":[]}`, limit: 3, wantErr: true},
+		{name: "malformed item", status: http.StatusOK, body: `[{"id":"a"},{"id":`, limit: 3, wantErr: true},
+		{name: "error status", status: http.StatusInternalServerError, body: `{"code":"InternalError"}`, limit: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &azuretls.Response{StatusCode: tt.status, RawBody: io.NopCloser(strings.NewReader(tt.body))}
+
+			items, truncated, err := decodeListResponse[models.ListKeysResponseItem](resp, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			ids := make([]string, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestListKeysAndBucketsTruncation(t *testing.T) {
+	g := garagetest.New(t, clock.Real)
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddBucket(name)
+		g.GrantKey(name, "GK-"+name, true, nil)
+	}
+
+	for _, tt := range []struct {
+		limit         int
+		wantCount     int
+		wantTruncated bool
+	}{
+		{limit: 2, wantCount: 2, wantTruncated: true},
+		{limit: 3, wantCount: 3},
+		{limit: 0, wantCount: 3}, // The default limit
+	} {
+		admin := NewGarageAdminService(
+			&config.GarageConfig{AdminEndpoint: g.AdminURL, AdminToken: "test", AdminListLimit: tt.limit},
+			&config.LoggingConfig{},
+			NewBackendMetrics(),
+		)
+
+		keys, truncated, err := admin.ListKeys(context.Background())
+		if err != nil {
+			t.Fatalf("ListKeys failed: %v", err)
+		}
+		if len(keys) != tt.wantCount || truncated != tt.wantTruncated {
+			t.Errorf("limit %d: ListKeys = %d keys, truncated %v; want %d, %v", tt.limit, len(keys), truncated, tt.wantCount, tt.wantTruncated)
+		}
+
+		buckets, truncated, err := admin.ListBuckets(context.Background())
+		if err != nil {
+			t.Fatalf("ListBuckets failed: %v", err)
+		}
+		if len(buckets) != tt.wantCount || truncated != tt.wantTruncated {
+			t.Errorf("limit %d: ListBuckets = %d buckets, truncated %v; want %d, %v", tt.limit, len(buckets), truncated, tt.wantCount, tt.wantTruncated)
+		}
+	}
+}
+
+func TestCreationsAreNotRepeatedAfterSuccess(t *testing.T) {
+	// How the fake fails the creation request once it has processed it
+	failures := map[string]func(w http.ResponseWriter, r *http.Request){
+		"client timeout": func(w http.ResponseWriter, r *http.Request) {
+			// The server notices the client leaving once the request body was read
+			_, _ = io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		},
+		"dropped connection": func(w http.ResponseWriter, r *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		},
+		"throttled": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		create  func(ctx context.Context, admin *GarageAdminService) error
+		failure string
+		// recovered is set when the creation is found by a lookup despite the failure
+		recovered bool
+		// unknown is set when the failure must be reported as an unknown outcome
+		unknown bool
+	}{
+		{name: "CreateKey after a timeout", path: "/v2/CreateKey", create: createTestKey, failure: "client timeout"},
+		{name: "CreateKey after a dropped connection", path: "/v2/CreateKey", create: createTestKey, failure: "dropped connection"},
+		{name: "CreateKey when throttled", path: "/v2/CreateKey", create: createTestKey, failure: "throttled"},
+		{name: "CreateBucket after a timeout", path: "/v2/CreateBucket", create: createTestBucket("GK1"), failure: "client timeout", unknown: true},
+		{name: "CreateBucket after a dropped connection", path: "/v2/CreateBucket", create: createTestBucket("GK1"), failure: "dropped connection", recovered: true},
+		{name: "CreateBucket when throttled", path: "/v2/CreateBucket", create: createTestBucket("GK1"), failure: "throttled"},
+		// The bucket found under the alias lacks the local alias requested for this key
+		{name: "CreateBucket of another client", path: "/v2/CreateBucket", create: createTestBucket("GK2"), failure: "dropped connection", unknown: true},
+		{name: "CreateBucket without a local alias", path: "/v2/CreateBucket", create: createTestBucket(""), failure: "dropped connection", unknown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var creations atomic.Int32
+			admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case tt.path:
+					creations.Add(1)
+					failures[tt.failure](w, r)
+				case "/v2/GetBucketInfo":
+					if creations.Load() == 0 {
+						http.Error(w, `{"code":"NoSuchBucket"}`, http.StatusNotFound)
+						return
+					}
+					_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{
+						ID:            "b1",
+						Created:       time.Now(),
+						GlobalAliases: []string{"photos"},
+						Keys: []models.BucketKeyInfo{{
+							AccessKeyID:        "GK1",
+							Permissions:        models.BucketKeyPermission{Read: true, Write: true},
+							BucketLocalAliases: []string{"photos"},
+						}},
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}, 0)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			err := tt.create(ctx, admin)
+
+			if tt.recovered && err != nil {
+				t.Errorf("creation failed: %v, want it found by the lookup", err)
+			}
+			if !tt.recovered && err == nil {
+				t.Error("creation succeeded, want the failure reported")
+			}
+			if got := errors.Is(err, ErrCreateOutcomeUnknown); got != tt.unknown {
+				t.Errorf("outcome unknown = %v, want %v (err = %v)", got, tt.unknown, err)
+			}
+			if got := creations.Load(); got != 1 {
+				t.Errorf("creation requests = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func createTestKey(ctx context.Context, admin *GarageAdminService) error {
+	name := "app"
+	_, err := admin.CreateKey(ctx, models.CreateKeyRequest{Name: &name})
+	return err
+}
+
+// createTestBucket creates the bucket with a local alias for the given key, or with a global
+// alias only when the key is empty
+func createTestBucket(accessKeyID string) func(ctx context.Context, admin *GarageAdminService) error {
+	return func(ctx context.Context, admin *GarageAdminService) error {
+		alias := "photos"
+		req := models.CreateBucketAdminRequest{GlobalAlias: &alias}
+		if accessKeyID != "" {
+			req.LocalAlias = &models.CreateBucketLocalAlias{
+				AccessKeyID: accessKeyID,
+				Alias:       alias,
+				Allow:       &models.BucketKeyPermission{Read: true, Write: true},
+			}
+		}
+		_, err := admin.CreateBucket(ctx, req)
+		return err
+	}
+}
+
+func TestGetBucketInfoOrStale(t *testing.T) {
+	g := newFakeGarage(t)
+	g.AddBucket("docs")
+	ctx := context.Background()
+
+	// Nothing was ever fetched, so there is nothing to fall back on
+	g.FailAdmin("GetBucketInfo")
+	if info, staleSince, err := g.admin.GetBucketInfoOrStale(ctx, "docs"); err == nil || info != nil || staleSince != nil {
+		t.Fatalf("never fetched: info = %v, stale since %v, err = %v; want an error", info, staleSince, err)
+	}
+
+	g.FailAdmin()
+	before := time.Now().UTC()
+	fresh, staleSince, err := g.admin.GetBucketInfoOrStale(ctx, "docs")
+	after := time.Now().UTC()
+	if err != nil || staleSince != nil || fresh.Objects != 0 {
+		t.Fatalf("fresh: info = %+v, stale since %v, err = %v; want fresh info", fresh, staleSince, err)
+	}
+
+	// Past the cache TTL the Admin API is asked again, and fails
+	g.PutObject("docs", "a.txt", "text/plain", []byte("a"))
+	g.clock.Advance(bucketInfoCacheTTL + time.Second)
+	g.FailAdmin("GetBucketInfo")
+	stale, staleSince, err := g.admin.GetBucketInfoOrStale(ctx, "docs")
+	if err != nil || stale != fresh {
+		t.Fatalf("stale: info = %+v, err = %v; want the info fetched first", stale, err)
+	}
+	if staleSince == nil || staleSince.Before(before) || staleSince.After(after) {
+		t.Errorf("stale since %v, want between %v and %v", staleSince, before, after)
+	}
+
+	g.FailAdmin()
+	recovered, staleSince, err := g.admin.GetBucketInfoOrStale(ctx, "docs")
+	if err != nil || staleSince != nil || recovered.Objects != 1 {
+		t.Errorf("recovered: info = %+v, stale since %v, err = %v; want fresh info with 1 object", recovered, staleSince, err)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/Noooste/azuretls-client"
+)
+
+// maxLoggedAdminBody is the number of bytes of a request or response body logged by
+// logging.log_admin_bodies; longer bodies are cut
+const maxLoggedAdminBody = 4096
+
+// logAdminExchange logs an Admin API call with its request and response bodies at debug
+// level. The response body is read to be logged and put back for the caller to decode.
+func (s *GarageAdminService) logAdminExchange(method, path string, body interface{}, resp *azuretls.Response, reqErr error, duration time.Duration) {
+	event := logger.Debug().
+		Str("method", method).
+		Str("path", path).
+		Dur("duration", duration).
+		Str("request_body", s.scrubAdminBody(requestBodyBytes(body)))
+
+	if reqErr != nil {
+		event.Err(reqErr).Msg("Admin API request failed")
+		return
+	}
+
+	responseBody, err := io.ReadAll(resp.RawBody)
+	resp.RawBody.Close()
+	resp.RawBody = io.NopCloser(bytes.NewReader(responseBody))
+	if err != nil {
+		event = event.AnErr("read_error", err)
+	}
+
+	event.Int("status", resp.StatusCode).
+		Str("response_body", s.scrubAdminBody(responseBody)).
+		Msg("Admin API request")
+}
+
+// requestBodyBytes renders a request body the way the HTTP client sends it
+func requestBodyBytes(body interface{}) []byte {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return b
+	case string:
+		return []byte(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return []byte(fmt.Sprintf("<unencodable body: %v>", err))
+		}
+		return encoded
+	}
+}
+
+// scrubAdminBody makes a body safe to log: JSON fields naming a secret are removed as in
+// raw requests, the admin token and bearer credentials are masked, and the result is cut
+// at maxLoggedAdminBody. Secrets are removed before cutting, so none can be left half-masked.
+func (s *GarageAdminService) scrubAdminBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var scrubbed string
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		redacted, _ := json.Marshal(redactSecrets(value))
+		scrubbed = string(redacted)
+	} else {
+		scrubbed = string(body)
+	}
+
+	scrubbed = redactText(scrubbed, s.token)
+
+	if len(scrubbed) > maxLoggedAdminBody {
+		return fmt.Sprintf("%s... (truncated, %d bytes)", scrubbed[:maxLoggedAdminBody], len(scrubbed))
+	}
+	return scrubbed
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrubAdminBody(t *testing.T) {
+	s := &GarageAdminService{token: "admin-token"}
+
+	for _, tt := range []struct {
+		name     string
+		body     string
+		want     string
+		wantGone []string
+	}{
+		{
+			name:     "secret access key",
+			body:     `{"accessKeyId":"GK1","secretAccessKey":"s3cr3t","name":"app"}`,
+			want:     `{"accessKeyId":"GK1","name":"app"}`,
+			wantGone: []string{"s3cr3t"},
+		},
+		{
+			name:     "nested arrays",
+			body:     `{"keys":[{"id":"GK1","secretAccessKey":"one"},[{"SecretKey":"two","id":"GK2"}]]}`,
+			want:     `{"keys":[{"id":"GK1"},[{"id":"GK2"}]]}`,
+			wantGone: []string{"one", "two"},
+		},
+		{
+			name:     "authorization header in JSON",
+			body:     `{"headers":{"Authorization":"Bearer abc.def-ghi"}}`,
+			want:     `{"headers":{"Authorization":"Bearer [REDACTED]"}}`,
+			wantGone: []string{"abc.def-ghi"},
+		},
+		{
+			name:     "authorization header in text",
+			body:     "Authorization: Bearer abc.def-ghi\nX-Other: 1",
+			want:     "Authorization: Bearer [REDACTED]\nX-Other: 1",
+			wantGone: []string{"abc.def-ghi"},
+		},
+		{
+			name:     "admin token",
+			body:     `{"error":"bad token admin-token"}`,
+			want:     `{"error":"bad token [REDACTED]"}`,
+			wantGone: []string{"admin-token"},
+		},
+		{
+			name: "empty",
+			body: "",
+			want: "",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.scrubAdminBody([]byte(tt.body))
+			if got != tt.want {
+				t.Errorf("scrubAdminBody(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+			for _, secret := range tt.wantGone {
+				if strings.Contains(got, secret) {
+					t.Errorf("scrubbed body %q still holds %q", got, secret)
+				}
+			}
+		})
+	}
+
+	t.Run("long body", func(t *testing.T) {
+		body := `{"secretAccessKey":"s3cr3t","data":"` + strings.Repeat("x", 2*maxLoggedAdminBody) + `"}`
+		got := s.scrubAdminBody([]byte(body))
+		if strings.Contains(got, "s3cr3t") || strings.Contains(got, "secretAccessKey") {
+			t.Error("truncated body still holds the secret")
+		}
+		if !strings.Contains(got, "(truncated, ") || len(got) > maxLoggedAdminBody+64 {
+			t.Errorf("body of %d bytes not cut at %d", len(got), maxLoggedAdminBody)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// maxRawResponseSize caps the Admin API response body returned by RawRequest
+const maxRawResponseSize = 10 << 20
+
+// rawEndpoint describes an Admin API endpoint that may be called through RawRequest
+type rawEndpoint struct {
+	method string
+	params []string // Query parameters callers may set
+	body   interface{}
+}
+
+// rawEndpoints is the hard-coded list of read-only Admin API endpoints available for
+// debugging. Anything else, and every mutation, is refused.
+var rawEndpoints = map[string]rawEndpoint{
+	"GetClusterHealth": {method: http.MethodGet},
+	"GetClusterStatus": {method: http.MethodGet},
+	"GetBucketInfo":    {method: http.MethodGet, params: []string{"id", "globalAlias", "search"}},
+	"GetKeyInfo":       {method: http.MethodGet, params: []string{"id", "search"}}, // never showSecretKey
+	"ListWorkers":      {method: http.MethodPost, params: []string{"node"}, body: map[string]bool{}},
+}
+
+var (
+	// ErrRawEndpointNotAllowed is returned when RawRequest is asked for an endpoint outside the allowlist
+	ErrRawEndpointNotAllowed = errors.New("endpoint is not available for raw requests")
+
+	// ErrRawParamNotAllowed is returned when RawRequest is given a query parameter the endpoint does not allow
+	ErrRawParamNotAllowed = errors.New("parameter is not allowed")
+)
+
+// RawEndpoints returns the names of the endpoints available through RawRequest
+func RawEndpoints() []string {
+	names := make([]string, 0, len(rawEndpoints))
+	for name := range rawEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RawRequest calls an allowlisted read-only Admin API endpoint and returns its JSON answer
+// verbatim, except for secrets, which are removed wherever they appear.
+// Error statuses are returned as responses rather than errors, since that is what callers debug.
+func (s *GarageAdminService) RawRequest(ctx context.Context, endpoint string, params map[string]string) (*models.AdminRawResponse, error) {
+	spec, ok := rawEndpoints[endpoint]
+	if !ok {
+		return nil, ErrRawEndpointNotAllowed
+	}
+
+	query := url.Values{}
+	for name, value := range params {
+		if !slices.Contains(spec.params, name) {
+			return nil, fmt.Errorf("%w for %s: %s", ErrRawParamNotAllowed, endpoint, name)
+		}
+		query.Set(name, value)
+	}
+
+	path := "/v2/" + endpoint
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	start := time.Now()
+	resp, err := s.doRequest(ctx, spec.method, path, spec.body, retrySafe)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.RawBody.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.RawBody, maxRawResponseSize))
+	duration := time.Since(start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return &models.AdminRawResponse{
+		Endpoint:   endpoint,
+		Path:       path,
+		Status:     resp.StatusCode,
+		DurationMs: duration.Milliseconds(),
+		Body:       redactRawBody(bodyBytes),
+	}, nil
+}
+
+// redactRawBody removes every secret field from a JSON body. Bodies that are not JSON,
+// such as plain-text errors, are returned as a JSON string.
+func redactRawBody(body []byte) json.RawMessage {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+
+	redacted, err := json.Marshal(redactSecrets(value))
+	if err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	return redacted
+}
+
+// bearerPattern matches bearer credentials quoted in plain text
+var bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+
+// redactText masks the given secret values and any bearer credentials in free text
+func redactText(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, "[REDACTED]")
+		}
+	}
+	return bearerPattern.ReplaceAllString(text, "Bearer [REDACTED]")
+}
+
+// redactSecrets drops the fields whose name mentions a secret from a decoded JSON value
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if strings.Contains(strings.ToLower(name), "secret") {
+				delete(v, name)
+				continue
+			}
+			v[name] = redactSecrets(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactSecrets(v[i])
+		}
+	}
+	return value
+}
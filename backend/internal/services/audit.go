@@ -0,0 +1,74 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// defaultAuditCapacity is the number of audit events kept in memory
+const defaultAuditCapacity = 1000
+
+// AuditLog records audit events to the structured log and keeps the most recent ones in memory
+type AuditLog struct {
+	mu       sync.Mutex
+	events   []models.AuditEvent
+	next     int
+	capacity int
+}
+
+// NewAuditLog creates a new audit log
+func NewAuditLog() *AuditLog {
+	return &AuditLog{
+		events:   make([]models.AuditEvent, 0, defaultAuditCapacity),
+		capacity: defaultAuditCapacity,
+	}
+}
+
+// Record logs an audit event and stores it in the in-memory ring buffer
+func (a *AuditLog) Record(event models.AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	logger.Info().
+		Bool("audit", true).
+		Str("actor", event.Actor).
+		Str("auth_method", event.AuthMethod).
+		Str("ip", event.IP).
+		Str("action", event.Action).
+		Str("target", event.Target).
+		Bool("success", event.Success).
+		Interface("details", event.Details).
+		Msg("Audit event")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.events) < a.capacity {
+		a.events = append(a.events, event)
+		return
+	}
+	a.events[a.next] = event
+	a.next = (a.next + 1) % a.capacity
+}
+
+// Recent returns up to limit audit events, newest first
+func (a *AuditLog) Recent(limit int) []models.AuditEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if limit <= 0 || limit > len(a.events) {
+		limit = len(a.events)
+	}
+
+	result := make([]models.AuditEvent, 0, limit)
+	for i := 0; i < limit; i++ {
+		// The newest event sits just before next once the buffer has wrapped
+		idx := (a.next - 1 - i + len(a.events)) % len(a.events)
+		result = append(result, a.events[idx])
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ContentEncodingGzip marks objects stored compressed with gzip
+const ContentEncodingGzip = "gzip"
+
+// gzipReader streams a body compressed with gzip. Garage does not compress, so large text
+// exports are compressed on the way in instead.
+type gzipReader struct {
+	*io.PipeReader
+	rawSize int64 // Bytes read from the body; final once the reader returned EOF
+}
+
+// newGzipReader starts compressing body. The reader must be closed, which stops the
+// compression if the upload gave up before reading everything.
+func newGzipReader(body io.Reader) *gzipReader {
+	pr, pw := io.Pipe()
+	r := &gzipReader{PipeReader: pr}
+
+	go func() {
+		gz := gzip.NewWriter(pw)
+		n, err := io.Copy(gz, body)
+		if err == nil {
+			err = gz.Close()
+		}
+		// Set before the pipe is closed, so it is visible once the reader sees EOF
+		r.rawSize = n
+		pw.CloseWithError(err)
+	}()
+
+	return r
+}
+
+// putObject uploads body, compressed with gzip when compress is set, and returns the upload
+// info along with the size of the body before compression
+func putObject(ctx context.Context, client *minio.Client, bucketName, key string, body io.Reader, opts minio.PutObjectOptions, compress bool) (minio.UploadInfo, int64, error) {
+	if !compress {
+		info, err := client.PutObject(ctx, bucketName, key, body, -1, opts)
+		return info, info.Size, err
+	}
+
+	// Retries compress the body afresh, so it is rewound when possible
+	if seeker, ok := body.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return minio.UploadInfo{}, 0, err
+		}
+	}
+
+	compressed := newGzipReader(body)
+	defer compressed.Close()
+
+	opts.ContentEncoding = ContentEncodingGzip
+	info, err := client.PutObject(ctx, bucketName, key, compressed, -1, opts)
+	if err != nil {
+		return info, 0, err
+	}
+	return info, compressed.rawSize, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// ConfirmationTTL is how long a confirmation token of a destructive operation stays valid
+const ConfirmationTTL = 5 * time.Minute
+
+// confirmationCachePrefix namespaces confirmation tokens in the global cache
+const confirmationCachePrefix = "confirm:"
+
+// Destructive operations that must be confirmed with a server-issued token
+const (
+	ConfirmForceDeleteBucket = "bucket.force_delete"
+	ConfirmEmptyBucket       = "bucket.empty"
+	ConfirmDeletePrefix      = "objects.delete_prefix"
+)
+
+// pendingConfirmation is what a confirmation token was issued for
+type pendingConfirmation struct {
+	action   string
+	target   string
+	username string
+}
+
+// IssueConfirmation creates a single-use token confirming action on target by username.
+// objects and bytes describe what the operation would delete and are echoed to the client.
+func IssueConfirmation(action, target, username string, objects, bytes int64) (models.DeleteConfirmation, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return models.DeleteConfirmation{}, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	utils.GlobalCache.Set(confirmationCachePrefix+token, pendingConfirmation{
+		action:   action,
+		target:   target,
+		username: username,
+	}, ConfirmationTTL)
+
+	return models.DeleteConfirmation{
+		ConfirmToken: token,
+		Objects:      objects,
+		Bytes:        bytes,
+		ExpiresAt:    utils.GlobalCache.Now().UTC().Add(ConfirmationTTL),
+	}, nil
+}
+
+// ConsumeConfirmation reports whether token was issued for the same action, target and
+// user and has not expired. A token is used up by the first attempt, matching or not.
+func ConsumeConfirmation(token, action, target, username string) bool {
+	if token == "" {
+		return false
+	}
+
+	pending, ok := utils.GlobalCache.Take(confirmationCachePrefix + token).(pendingConfirmation)
+	return ok && pending == pendingConfirmation{action: action, target: target, username: username}
+}
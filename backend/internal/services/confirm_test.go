@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// useManualCache replaces the global cache, for the duration of the test, with one whose
+// items expire by a manual clock stopped at now
+func useManualCache(t *testing.T, now time.Time) *clock.Manual {
+	t.Helper()
+
+	clk := clock.NewManual(now)
+	previous := utils.GlobalCache
+	utils.GlobalCache = utils.NewCacheWithClock(clk)
+	t.Cleanup(func() { utils.GlobalCache = previous })
+	return clk
+}
+
+func TestConfirmationExpiry(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "right after issue", elapsed: 0, want: true},
+		{name: "just before expiry", elapsed: ConfirmationTTL - time.Nanosecond, want: true},
+		{name: "at expiry", elapsed: ConfirmationTTL, want: false},
+		{name: "after expiry", elapsed: ConfirmationTTL + time.Second, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := useManualCache(t, start)
+
+			confirmation, err := IssueConfirmation(ConfirmEmptyBucket, "photos", "alice", 3, 42)
+			if err != nil {
+				t.Fatalf("IssueConfirmation failed: %v", err)
+			}
+			if want := start.Add(ConfirmationTTL); !confirmation.ExpiresAt.Equal(want) {
+				t.Errorf("ExpiresAt = %v, want %v", confirmation.ExpiresAt, want)
+			}
+
+			clk.Advance(tt.elapsed)
+			if got := ConsumeConfirmation(confirmation.ConfirmToken, ConfirmEmptyBucket, "photos", "alice"); got != tt.want {
+				t.Errorf("ConsumeConfirmation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsumeConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		target   string
+		username string
+		want     bool
+	}{
+		{name: "same action, target and user", action: ConfirmEmptyBucket, target: "photos", username: "alice", want: true},
+		{name: "other action", action: ConfirmForceDeleteBucket, target: "photos", username: "alice"},
+		{name: "other target", action: ConfirmEmptyBucket, target: "videos", username: "alice"},
+		{name: "other user", action: ConfirmEmptyBucket, target: "photos", username: "bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useManualCache(t, time.Now())
+
+			confirmation, err := IssueConfirmation(ConfirmEmptyBucket, "photos", "alice", 0, 0)
+			if err != nil {
+				t.Fatalf("IssueConfirmation failed: %v", err)
+			}
+			if got := ConsumeConfirmation(confirmation.ConfirmToken, tt.action, tt.target, tt.username); got != tt.want {
+				t.Errorf("ConsumeConfirmation = %v, want %v", got, tt.want)
+			}
+			// A token is used up by the first attempt
+			if ConsumeConfirmation(confirmation.ConfirmToken, ConfirmEmptyBucket, "photos", "alice") {
+				t.Error("token was accepted twice")
+			}
+		})
+	}
+}
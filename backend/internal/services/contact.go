@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// contactTracker records when a Garage API last answered and last failed to. It only
+// observes the calls the services make anyway, so reading it never costs an upstream call.
+type contactTracker struct {
+	lastSuccess         atomic.Int64 // Unix nanoseconds, 0 if never
+	lastFailure         atomic.Int64 // Unix nanoseconds, 0 if never
+	consecutiveFailures atomic.Int64
+}
+
+// observe records the outcome of a call. reached reports whether the API answered, even
+// with an error status; calls abandoned because ctx was cancelled say nothing about the
+// API and are ignored.
+func (t *contactTracker) observe(ctx context.Context, reached bool) {
+	now := time.Now().UnixNano()
+	if reached {
+		t.lastSuccess.Store(now)
+		t.consecutiveFailures.Store(0)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	t.lastFailure.Store(now)
+	t.consecutiveFailures.Add(1)
+}
+
+// status returns a snapshot of the tracker
+func (t *contactTracker) status() models.UpstreamContact {
+	return models.UpstreamContact{
+		LastSuccess:         unixNanoTime(t.lastSuccess.Load()),
+		LastFailure:         unixNanoTime(t.lastFailure.Load()),
+		ConsecutiveFailures: t.consecutiveFailures.Load(),
+	}
+}
+
+// unixNanoTime converts a stored timestamp to UTC, or nil if it was never set
+func unixNanoTime(nanos int64) *time.Time {
+	if nanos == 0 {
+		return nil
+	}
+	t := time.Unix(0, nanos).UTC()
+	return &t
+}
+
+// reachedAPI reports whether a call that returned err and, if the API answered, statusCode
+// got a usable answer. Server errors count as failures; throttling and client errors show
+// the API is up.
+func reachedAPI(err error, statusCode int) bool {
+	if errors.Is(err, utils.ErrThrottled) {
+		return true
+	}
+	if statusCode > 0 {
+		return statusCode < 500
+	}
+	return err == nil
+}
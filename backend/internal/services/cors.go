@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/cors"
+)
+
+// maxCORSRules is the most rules S3 accepts in the CORS configuration of a bucket
+const maxCORSRules = 100
+
+// corsMethods lists the methods CORS rules may allow
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// GetBucketCORS returns the CORS rules of a bucket, empty when it has none
+func (s *S3Service) GetBucketCORS(ctx context.Context, bucketName string) ([]models.CORSRule, error) {
+	var config *cors.Config
+
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetBucketCORS", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var corsErr error
+			config, corsErr = client.GetBucketCors(ctx, bucketName)
+			return throttleError(ctx, corsErr)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CORS configuration of bucket %s: %w", bucketName, err)
+	}
+
+	rules := make([]models.CORSRule, 0)
+	if config == nil {
+		return rules, nil
+	}
+	for _, rule := range config.CORSRules {
+		rules = append(rules, models.CORSRule{
+			ID:             rule.ID,
+			AllowedOrigins: rule.AllowedOrigin,
+			AllowedMethods: rule.AllowedMethod,
+			AllowedHeaders: rule.AllowedHeader,
+			ExposeHeaders:  rule.ExposeHeader,
+			MaxAgeSeconds:  rule.MaxAgeSeconds,
+		})
+	}
+	return rules, nil
+}
+
+// PutBucketCORS replaces the CORS rules of a bucket; no rules removes the configuration. The
+// rules are checked against the S3 rules before calling Garage.
+func (s *S3Service) PutBucketCORS(ctx context.Context, bucketName string, rules []models.CORSRule) error {
+	if err := validateCORSRules(rules); err != nil {
+		return &InvalidCORSError{Err: err}
+	}
+
+	// A nil configuration makes minio delete the bucket's CORS configuration
+	var config *cors.Config
+	if len(rules) > 0 {
+		corsRules := make([]cors.Rule, 0, len(rules))
+		for _, rule := range rules {
+			corsRules = append(corsRules, cors.Rule{
+				ID:            rule.ID,
+				AllowedOrigin: rule.AllowedOrigins,
+				AllowedMethod: rule.AllowedMethods,
+				AllowedHeader: rule.AllowedHeaders,
+				ExposeHeader:  rule.ExposeHeaders,
+				MaxAgeSeconds: rule.MaxAgeSeconds,
+			})
+		}
+		config = cors.NewConfig(corsRules)
+	}
+
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "PutBucketCORS", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.SetBucketCors(ctx, bucketName, config))
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set CORS configuration of bucket %s: %w", bucketName, err)
+	}
+
+	return nil
+}
+
+// validateCORSRules checks CORS rules against the limits S3 puts on them
+func validateCORSRules(rules []models.CORSRule) error {
+	if len(rules) > maxCORSRules {
+		return fmt.Errorf("at most %d rules are allowed", maxCORSRules)
+	}
+
+	for i, rule := range rules {
+		if len(rule.AllowedOrigins) == 0 {
+			return fmt.Errorf("rule %d: allowedOrigins is required", i+1)
+		}
+		for _, origin := range rule.AllowedOrigins {
+			if strings.Count(origin, "*") > 1 {
+				return fmt.Errorf("rule %d: origin %q may contain at most one wildcard", i+1, origin)
+			}
+		}
+		if len(rule.AllowedMethods) == 0 {
+			return fmt.Errorf("rule %d: allowedMethods is required", i+1)
+		}
+		for _, method := range rule.AllowedMethods {
+			if !slices.Contains(corsMethods, method) {
+				return fmt.Errorf("rule %d: method %q is not one of %s", i+1, method, strings.Join(corsMethods, ", "))
+			}
+		}
+		for _, header := range rule.AllowedHeaders {
+			if strings.Count(header, "*") > 1 {
+				return fmt.Errorf("rule %d: header %q may contain at most one wildcard", i+1, header)
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			return fmt.Errorf("rule %d: maxAgeSeconds must not be negative", i+1)
+		}
+		if len(rule.ID) > 255 {
+			return fmt.Errorf("rule %d: id must be at most 255 characters", i+1)
+		}
+	}
+	return nil
+}
+
+// InvalidCORSError is returned when CORS rules break the S3 rules
+type InvalidCORSError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *InvalidCORSError) Error() string {
+	return "invalid CORS rules: " + e.Err.Error()
+}
+
+// Unwrap returns the validation error
+func (e *InvalidCORSError) Unwrap() error {
+	return e.Err
+}
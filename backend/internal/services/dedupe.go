@@ -0,0 +1,98 @@
+package services
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Dedupe hint modes of a bucket's dedupeHints setting
+const (
+	DedupeHintsAfter  = "after"  // Store the upload, then report the object it duplicates
+	DedupeHintsBefore = "before" // Refuse a duplicate upload unless the client insists
+)
+
+// maxDedupeEntries is the number of checksums remembered per bucket
+const maxDedupeEntries = 1000
+
+// DedupeIndex remembers the MD5 checksums of recent uploads per bucket, so an upload of
+// content already stored under another key can be pointed at it. It only gives hints:
+// entries are not updated when objects are deleted or overwritten outside of uploads.
+type DedupeIndex struct {
+	mu      sync.Mutex
+	buckets map[string]*dedupeBucket
+}
+
+// dedupeBucket maps checksums to keys, evicting the oldest entry once full
+type dedupeBucket struct {
+	keys  map[string]string
+	order []string
+}
+
+// NewDedupeIndex creates an empty dedupe index
+func NewDedupeIndex() *DedupeIndex {
+	return &DedupeIndex{buckets: make(map[string]*dedupeBucket)}
+}
+
+// Lookup returns the key last uploaded with the checksum in a bucket
+func (d *DedupeIndex) Lookup(bucketName, checksum string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bucket, ok := d.buckets[bucketName]
+	if !ok {
+		return "", false
+	}
+	key, ok := bucket.keys[checksum]
+	return key, ok
+}
+
+// Remember records that key holds content with the checksum
+func (d *DedupeIndex) Remember(bucketName, checksum, key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bucket, ok := d.buckets[bucketName]
+	if !ok {
+		bucket = &dedupeBucket{keys: make(map[string]string)}
+		d.buckets[bucketName] = bucket
+	}
+
+	if _, exists := bucket.keys[checksum]; !exists {
+		if len(bucket.order) >= maxDedupeEntries {
+			delete(bucket.keys, bucket.order[0])
+			bucket.order = bucket.order[1:]
+		}
+		bucket.order = append(bucket.order, checksum)
+	}
+	bucket.keys[checksum] = key
+}
+
+// Forget drops a checksum whose key no longer holds the content
+func (d *DedupeIndex) Forget(bucketName, checksum string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bucket, ok := d.buckets[bucketName]
+	if !ok {
+		return
+	}
+	if _, exists := bucket.keys[checksum]; exists {
+		delete(bucket.keys, checksum)
+		bucket.order = slices.DeleteFunc(bucket.order, func(entry string) bool { return entry == checksum })
+	}
+}
+
+// ChecksumUpload returns the hex MD5 checksum of an uploaded file and rewinds it
+func ChecksumUpload(file io.ReadSeeker) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// diagnosticsTimeout bounds a whole diagnostic run
+const diagnosticsTimeout = 30 * time.Second
+
+// DiagnosticsService runs connection self-tests against the S3 and Admin endpoints and keeps
+// the last report, so misconfiguration is reported with a hint instead of as a generic 500
+type DiagnosticsService struct {
+	config       *config.GarageConfig
+	adminService *GarageAdminService
+
+	mu   sync.RWMutex
+	last *models.DiagnosticReport
+}
+
+// NewDiagnosticsService creates a new diagnostics service
+func NewDiagnosticsService(cfg *config.GarageConfig, adminService *GarageAdminService) *DiagnosticsService {
+	return &DiagnosticsService{
+		config:       cfg,
+		adminService: adminService,
+	}
+}
+
+// LastReport returns the report of the most recent run, or nil if none has completed yet
+func (d *DiagnosticsService) LastReport() *models.DiagnosticReport {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.last
+}
+
+// Run performs the full diagnostic sequence, logs the report and stores it as the last report.
+// Steps that depend on a failed step are skipped.
+func (d *DiagnosticsService) Run(ctx context.Context) *models.DiagnosticReport {
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+	defer cancel()
+
+	report := &models.DiagnosticReport{StartedAt: time.Now().UTC()}
+
+	// The S3 endpoint has its scheme stripped at startup; UseSSL carries it instead
+	s3Host, s3Port := splitHostPort(d.config.Endpoint, d.config.UseSSL)
+	report.Steps = append(report.Steps, d.checkConnection(ctx, "s3", s3Host, s3Port, d.config.UseSSL, "garage.endpoint")...)
+
+	adminOK := false
+	adminURL, err := url.Parse(d.config.AdminEndpoint)
+	if err != nil || adminURL.Host == "" {
+		report.Steps = append(report.Steps, models.DiagnosticStep{
+			Name:   "admin_dns",
+			Target: d.config.AdminEndpoint,
+			Status: models.DiagnosticStatusFailed,
+			Error:  "invalid admin endpoint URL",
+			Hint:   "garage.admin_endpoint must be a URL such as http://garage:3903",
+		})
+	} else {
+		secure := adminURL.Scheme == "https"
+		adminHost, adminPort := splitHostPort(adminURL.Host, secure)
+		steps := d.checkConnection(ctx, "admin", adminHost, adminPort, secure, "garage.admin_endpoint")
+		report.Steps = append(report.Steps, steps...)
+		adminOK = steps[len(steps)-1].Status == models.DiagnosticStatusOK
+	}
+
+	// Admin token: the health endpoint is unauthenticated, so use an authenticated call
+	authStep := d.runStep(adminOK, "admin_auth", d.config.AdminEndpoint, func() (string, error) {
+		_, err := d.adminService.GetClusterHealth(ctx)
+		var statusErr *APIStatusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+			return "admin_token rejected: check garage.admin_token", err
+		}
+		return "Admin API call failed: check that garage.admin_endpoint points at Garage's admin API port", err
+	})
+	report.Steps = append(report.Steps, authStep)
+
+	listStep := d.runStep(authStep.Status == models.DiagnosticStatusOK, "list_buckets", d.config.AdminEndpoint, func() (string, error) {
+		_, _, err := d.adminService.ListBuckets(ctx)
+		return "listing buckets failed: check that the admin token has bucket permissions", err
+	})
+	report.Steps = append(report.Steps, listStep)
+
+	report.Success = true
+	for _, step := range report.Steps {
+		if step.Status != models.DiagnosticStatusOK {
+			report.Success = false
+			break
+		}
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	d.logReport(report)
+
+	d.mu.Lock()
+	d.last = report
+	d.mu.Unlock()
+
+	return report
+}
+
+// checkConnection runs the DNS, TCP and (when secure) TLS steps for one endpoint
+func (d *DiagnosticsService) checkConnection(ctx context.Context, prefix, host, port string, secure bool, configKey string) []models.DiagnosticStep {
+	address := net.JoinHostPort(host, port)
+
+	dnsStep := d.runStep(true, prefix+"_dns", host, func() (string, error) {
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		return fmt.Sprintf("cannot resolve %s: check the host in %s and the DNS configuration", host, configKey), err
+	})
+
+	tcpStep := d.runStep(dnsStep.Status == models.DiagnosticStatusOK, prefix+"_tcp", address, func() (string, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			conn.Close()
+		}
+		return fmt.Sprintf("cannot connect to %s: check the port in %s and that Garage is running and reachable", address, configKey), err
+	})
+
+	steps := []models.DiagnosticStep{dnsStep, tcpStep}
+	if !secure {
+		return steps
+	}
+
+	tlsStep := d.runStep(tcpStep.Status == models.DiagnosticStatusOK, prefix+"_tls", address, func() (string, error) {
+		dialer := tls.Dialer{Config: &tls.Config{ServerName: host}}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			conn.Close()
+		}
+		return fmt.Sprintf("TLS handshake with %s failed: check the certificate, or use http:// in %s if TLS is not enabled", address, configKey), err
+	})
+
+	return append(steps, tlsStep)
+}
+
+// runStep times a diagnostic step. fn returns the hint to report should it fail.
+// When the step's prerequisites failed it is reported as skipped without running.
+func (d *DiagnosticsService) runStep(prerequisitesOK bool, name, target string, fn func() (string, error)) models.DiagnosticStep {
+	step := models.DiagnosticStep{Name: name, Target: target}
+	if !prerequisitesOK {
+		step.Status = models.DiagnosticStatusSkipped
+		return step
+	}
+
+	start := time.Now()
+	hint, err := fn()
+	step.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		step.Status = models.DiagnosticStatusFailed
+		step.Error = err.Error()
+		step.Hint = hint
+		return step
+	}
+
+	step.Status = models.DiagnosticStatusOK
+	return step
+}
+
+// logReport writes a structured summary of the report, with one warning per failed step
+func (d *DiagnosticsService) logReport(report *models.DiagnosticReport) {
+	for _, step := range report.Steps {
+		if step.Status != models.DiagnosticStatusFailed {
+			continue
+		}
+		logger.Warn().
+			Str("step", step.Name).
+			Str("target", step.Target).
+			Str("error", step.Error).
+			Str("hint", step.Hint).
+			Msg("Connection diagnostic failed")
+	}
+
+	event := logger.Info()
+	if !report.Success {
+		event = logger.Warn()
+	}
+
+	statuses := make([]string, 0, len(report.Steps))
+	for _, step := range report.Steps {
+		statuses = append(statuses, step.Name+"="+step.Status)
+	}
+
+	event.
+		Bool("success", report.Success).
+		Int64("duration_ms", report.DurationMs).
+		Str("steps", strings.Join(statuses, " ")).
+		Msg("Connection diagnostics completed")
+}
+
+// splitHostPort splits host[:port], defaulting the port from the scheme
+func splitHostPort(hostPort string, secure bool) (string, string) {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err == nil {
+		return host, port
+	}
+
+	if secure {
+		return hostPort, "443"
+	}
+	return hostPort, "80"
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/garagetest"
+	"Noooste/garage-ui/pkg/clock"
+)
+
+// fakeGarage is a fake Garage cluster with the admin and S3 services under test in front of it
+type fakeGarage struct {
+	*garagetest.Server
+
+	admin *GarageAdminService
+	s3    *S3Service
+	clock *clock.Manual
+}
+
+// newFakeGarage starts a fake cluster, with the global cache and the services running on a
+// manual clock stopped at the current time
+func newFakeGarage(t *testing.T) *fakeGarage {
+	t.Helper()
+
+	clk := useManualCache(t, time.Now().UTC().Truncate(time.Second))
+	g := &fakeGarage{Server: garagetest.New(t, clk), clock: clk}
+
+	g.admin = NewGarageAdminService(
+		&config.GarageConfig{AdminEndpoint: g.AdminURL, AdminToken: "test"},
+		&config.LoggingConfig{},
+		NewBackendMetrics(),
+	)
+	s3, err := NewS3Service(&config.GarageConfig{
+		Endpoint:       g.S3URL,
+		Region:         "garage",
+		ForcePathStyle: true,
+	}, g.admin, NewBackendMetrics(), g.clock)
+	if err != nil {
+		t.Fatalf("NewS3Service failed: %v", err)
+	}
+	g.s3 = s3
+	return g
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// KeyActivityRetention is how far back per-key request counts are kept
+const KeyActivityRetention = 24 * time.Hour
+
+// keyActivityMetric is the Garage counter of S3 API requests
+const keyActivityMetric = "api_s3_request_counter"
+
+// Labels Garage may attribute a request with. The access key label is only present on
+// builds exposing per-key metrics; without it activity cannot be attributed at all.
+var (
+	keyActivityKeyLabels    = []string{"access_key_id", "access_key", "api_key", "key_id"}
+	keyActivityBucketLabels = []string{"bucket", "bucket_name"}
+	keyActivityVerbLabels   = []string{"api_endpoint", "endpoint", "method"}
+)
+
+// activitySeries identifies the requests of one key on one bucket with one verb
+type activitySeries struct {
+	accessKey string
+	bucket    string
+	verb      string
+}
+
+// KeyActivity samples the Garage request counters periodically and keeps how many requests
+// each access key made, by bucket and verb, in hourly buckets. Counts are kept in memory
+// and start over on restart; the requests made before the first sample are never counted.
+type KeyActivity struct {
+	adminService *GarageAdminService
+	interval     time.Duration
+
+	mu        sync.Mutex
+	sampledAt time.Time // Zero until a sample succeeds
+	supported bool      // Whether the last sample held per-key series
+	previous  map[activitySeries]float64
+	hours     map[time.Time]map[activitySeries]int64
+	lastSeen  map[string]time.Time
+}
+
+// NewKeyActivity creates a sampler reading the metrics every interval; 0 disables it
+func NewKeyActivity(adminService *GarageAdminService, interval time.Duration) *KeyActivity {
+	return &KeyActivity{
+		adminService: adminService,
+		interval:     interval,
+		hours:        make(map[time.Time]map[activitySeries]int64),
+		lastSeen:     make(map[string]time.Time),
+	}
+}
+
+// Run samples the metrics every interval until ctx is done
+func (k *KeyActivity) Run(ctx context.Context) {
+	if k.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		k.sample(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample reads the counters and adds what they grew by since the previous sample
+func (k *KeyActivity) sample(ctx context.Context) {
+	text, err := k.adminService.GetMetrics(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to sample key activity metrics")
+		return
+	}
+
+	current := make(map[activitySeries]float64)
+	for _, sample := range parsePrometheusText(text) {
+		if sample.name != keyActivityMetric {
+			continue
+		}
+		accessKey := firstLabel(sample.labels, keyActivityKeyLabels)
+		if accessKey == "" {
+			continue
+		}
+		series := activitySeries{
+			accessKey: accessKey,
+			bucket:    firstLabel(sample.labels, keyActivityBucketLabels),
+			verb:      firstLabel(sample.labels, keyActivityVerbLabels),
+		}
+		current[series] += sample.value
+	}
+
+	now := time.Now().UTC()
+	hour := now.Truncate(time.Hour)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	first := k.sampledAt.IsZero()
+	k.sampledAt = now
+	k.supported = len(current) > 0
+
+	for series, value := range current {
+		previous, known := k.previous[series]
+		var delta float64
+		switch {
+		case known && value >= previous:
+			delta = value - previous
+		case known:
+			delta = value // Garage restarted and its counters started over
+		case !first:
+			delta = value // First requests of this series since the previous sample
+		}
+		if delta <= 0 {
+			continue
+		}
+
+		counts, ok := k.hours[hour]
+		if !ok {
+			counts = make(map[activitySeries]int64)
+			k.hours[hour] = counts
+		}
+		counts[series] += int64(delta)
+		k.lastSeen[series.accessKey] = now
+	}
+	k.previous = current
+
+	cutoff := now.Add(-KeyActivityRetention)
+	for h := range k.hours {
+		if h.Add(time.Hour).Before(cutoff) {
+			delete(k.hours, h)
+		}
+	}
+}
+
+// Supported reports whether sampling is enabled and the last sample attributed requests
+// to keys. Before the first sample it reports false.
+func (k *KeyActivity) Supported() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.supported
+}
+
+// Enabled reports whether the metrics are sampled at all
+func (k *KeyActivity) Enabled() bool {
+	return k.interval > 0
+}
+
+// SampledAt returns when the metrics were last sampled, or the zero time if never
+func (k *KeyActivity) SampledAt() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.sampledAt
+}
+
+// LastSeen returns when requests of the key were last noticed. It is only as precise as the
+// sampling interval, and unknown for keys not used since the server started.
+func (k *KeyActivity) LastSeen(accessKey string) (time.Time, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	seen, ok := k.lastSeen[accessKey]
+	return seen, ok
+}
+
+// Activity returns the requests of a key in the hourly buckets overlapping the window,
+// by bucket with the busiest first
+func (k *KeyActivity) Activity(accessKey string, window time.Duration) models.KeyActivityResponse {
+	now := time.Now().UTC()
+	since := now.Add(-window)
+
+	response := models.KeyActivityResponse{
+		AccessKeyID: accessKey,
+		Window:      window.String(),
+		Since:       since,
+		Buckets:     []models.KeyBucketActivity{},
+	}
+
+	k.mu.Lock()
+	byBucket := make(map[string]*models.KeyBucketActivity)
+	for hour, counts := range k.hours {
+		if !hour.Add(time.Hour).After(since) {
+			continue
+		}
+		for series, count := range counts {
+			if series.accessKey != accessKey {
+				continue
+			}
+			bucket, ok := byBucket[series.bucket]
+			if !ok {
+				bucket = &models.KeyBucketActivity{Bucket: series.bucket, Verbs: make(map[string]int64)}
+				byBucket[series.bucket] = bucket
+			}
+			bucket.Requests += count
+			bucket.Verbs[series.verb] += count
+		}
+	}
+	if seen, ok := k.lastSeen[accessKey]; ok {
+		response.LastSeen = &seen
+	}
+	response.SampledAt = k.sampledAt
+	k.mu.Unlock()
+
+	for _, bucket := range byBucket {
+		response.Requests += bucket.Requests
+		response.Buckets = append(response.Buckets, *bucket)
+	}
+	sort.Slice(response.Buckets, func(i, j int) bool {
+		if response.Buckets[i].Requests != response.Buckets[j].Requests {
+			return response.Buckets[i].Requests > response.Buckets[j].Requests
+		}
+		return response.Buckets[i].Bucket < response.Buckets[j].Bucket
+	})
+
+	return response
+}
+
+// firstLabel returns the value of the first of names set in labels
+func firstLabel(labels map[string]string, names []string) string {
+	for _, name := range names {
+		if value := labels[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
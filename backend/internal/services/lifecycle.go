@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// maxLifecycleRules is the most lifecycle rules a bucket may have
+const maxLifecycleRules = 100
+
+// ErrLifecycleRunning is returned when the rules of a bucket are already being enforced
+var ErrLifecycleRunning = errors.New("lifecycle rules of the bucket are already being enforced")
+
+// LifecycleService enforces the per-bucket lifecycle rules kept in the settings store. Garage
+// has no lifecycle policies, so expired objects are listed and deleted like any others, and
+// stale multipart uploads are aborted.
+type LifecycleService struct {
+	s3Service     *S3Service
+	settingsStore *SettingsStore
+	config        *config.LifecycleConfig
+	clock         clock.Clock
+
+	mu       sync.Mutex
+	running  map[string]bool
+	lastRuns map[string]models.LifecycleRun
+}
+
+// NewLifecycleService creates a new lifecycle service
+func NewLifecycleService(s3Service *S3Service, settingsStore *SettingsStore, cfg *config.LifecycleConfig, clk clock.Clock) *LifecycleService {
+	return &LifecycleService{
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
+		config:        cfg,
+		clock:         clk,
+		running:       make(map[string]bool),
+		lastRuns:      make(map[string]models.LifecycleRun),
+	}
+}
+
+// Enforced reports whether the rules are enforced periodically
+func (l *LifecycleService) Enforced() bool {
+	return l.config.Enabled
+}
+
+// Rules returns the lifecycle rules of a bucket, empty when it has none
+func (l *LifecycleService) Rules(bucketName string) []models.LifecycleRule {
+	return l.settingsStore.LifecycleRules(bucketName)
+}
+
+// SetRules checks the lifecycle rules of a bucket and replaces the stored ones; no rules
+// removes them
+func (l *LifecycleService) SetRules(bucketName string, rules []models.LifecycleRule) error {
+	if err := validateLifecycleRules(rules); err != nil {
+		return &InvalidLifecycleError{Err: err}
+	}
+	return l.settingsStore.SetLifecycleRules(bucketName, rules)
+}
+
+// LastRun returns the last enforcement of the rules of a bucket since startup, if any
+func (l *LifecycleService) LastRun(bucketName string) (models.LifecycleRun, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	run, ok := l.lastRuns[bucketName]
+	return run, ok
+}
+
+// Apply enforces the enabled rules of a bucket now. The run is recorded as the bucket's last
+// one, errors included; ErrLifecycleRunning is returned without a run when another
+// enforcement of the bucket is in progress.
+func (l *LifecycleService) Apply(ctx context.Context, bucketName string) (models.LifecycleRun, error) {
+	l.mu.Lock()
+	if l.running[bucketName] {
+		l.mu.Unlock()
+		return models.LifecycleRun{}, ErrLifecycleRunning
+	}
+	l.running[bucketName] = true
+	l.mu.Unlock()
+
+	run := models.LifecycleRun{StartedAt: l.clock.Now().UTC()}
+	err := l.apply(ctx, bucketName, &run)
+	run.FinishedAt = l.clock.Now().UTC()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	delete(l.running, bucketName)
+	l.lastRuns[bucketName] = run
+	l.mu.Unlock()
+
+	return run, err
+}
+
+// apply enforces the enabled rules of a bucket one after the other, counting into run
+func (l *LifecycleService) apply(ctx context.Context, bucketName string, run *models.LifecycleRun) error {
+	now := l.clock.Now()
+
+	for _, rule := range l.settingsStore.LifecycleRules(bucketName) {
+		if !rule.Enabled {
+			continue
+		}
+
+		if rule.ExpirationDays > 0 {
+			cutoff := now.AddDate(0, 0, -rule.ExpirationDays)
+			err := l.s3Service.ListObjectsPages(ctx, bucketName, rule.Prefix, true, func(page []models.ObjectInfo, _ []string) error {
+				var expired []string
+				for _, obj := range page {
+					if obj.LastModified.Before(cutoff) {
+						expired = append(expired, obj.Key)
+					}
+				}
+				err := l.s3Service.DeleteMultipleObjects(ctx, bucketName, expired)
+				var deleteErr *DeleteObjectsError
+				switch {
+				case errors.As(err, &deleteErr):
+					run.ExpiredObjects += deleteErr.Total - deleteErr.Failed
+					return err
+				case err != nil:
+					return fmt.Errorf("%d expired objects not deleted: %w", len(expired), err)
+				}
+				run.ExpiredObjects += len(expired)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("rule %s: failed to expire objects: %w", rule.ID, err)
+			}
+		}
+
+		if rule.AbortIncompleteUploadDays > 0 {
+			cutoff := now.AddDate(0, 0, -rule.AbortIncompleteUploadDays)
+			aborted, err := l.s3Service.AbortIncompleteUploads(ctx, bucketName, rule.Prefix, cutoff)
+			run.AbortedUploads += aborted
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunScheduler periodically enforces the rules of every bucket until ctx is done
+func (l *LifecycleService) RunScheduler(ctx context.Context) {
+	ticker := l.clock.NewTicker(l.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			l.sweep(ctx)
+		}
+	}
+}
+
+// sweep enforces the rules of every bucket that has any, skipping read-only buckets
+func (l *LifecycleService) sweep(ctx context.Context) {
+	for bucketName := range l.settingsStore.ListLifecycleRules() {
+		if ctx.Err() != nil {
+			return
+		}
+		if l.settingsStore.IsReadOnly(bucketName) {
+			logger.Debug().Str("bucket", bucketName).Msg("Skipping lifecycle rules of read-only bucket")
+			continue
+		}
+
+		run, err := l.Apply(ctx, bucketName)
+		if errors.Is(err, ErrLifecycleRunning) {
+			continue
+		}
+		if err != nil {
+			logger.Warn().Err(err).Str("bucket", bucketName).Msg("Failed to enforce lifecycle rules")
+		}
+		if run.ExpiredObjects > 0 || run.AbortedUploads > 0 {
+			logger.Info().
+				Str("bucket", bucketName).
+				Int("expired", run.ExpiredObjects).
+				Int("aborted_uploads", run.AbortedUploads).
+				Msg("Enforced lifecycle rules")
+		}
+	}
+}
+
+// validateLifecycleRules checks that every rule has a unique ID and does something
+func validateLifecycleRules(rules []models.LifecycleRule) error {
+	if len(rules) > maxLifecycleRules {
+		return fmt.Errorf("at most %d rules are allowed", maxLifecycleRules)
+	}
+
+	ids := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		switch {
+		case rule.ID == "":
+			return fmt.Errorf("rule %d: id is required", i+1)
+		case len(rule.ID) > 255:
+			return fmt.Errorf("rule %d: id must be at most 255 characters", i+1)
+		case ids[rule.ID]:
+			return fmt.Errorf("rule %d: id %q is used by another rule", i+1, rule.ID)
+		case strings.HasPrefix(rule.Prefix, "/"):
+			return fmt.Errorf("rule %s: prefix must not start with '/'", rule.ID)
+		case rule.ExpirationDays < 0 || rule.AbortIncompleteUploadDays < 0:
+			return fmt.Errorf("rule %s: days must not be negative", rule.ID)
+		case rule.ExpirationDays == 0 && rule.AbortIncompleteUploadDays == 0:
+			return fmt.Errorf("rule %s: expirationDays or abortIncompleteUploadDays is required", rule.ID)
+		}
+		ids[rule.ID] = true
+	}
+	return nil
+}
+
+// InvalidLifecycleError is returned when lifecycle rules are malformed
+type InvalidLifecycleError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *InvalidLifecycleError) Error() string {
+	return "invalid lifecycle rules: " + e.Err.Error()
+}
+
+// Unwrap returns the validation error
+func (e *InvalidLifecycleError) Unwrap() error {
+	return e.Err
+}
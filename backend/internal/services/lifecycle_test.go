@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+)
+
+func TestValidateLifecycleRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []models.LifecycleRule
+		wantErr bool
+	}{
+		{name: "no rules"},
+		{name: "expiration rule", rules: []models.LifecycleRule{{ID: "logs", Prefix: "logs/", ExpirationDays: 30}}},
+		{name: "abort rule", rules: []models.LifecycleRule{{ID: "uploads", AbortIncompleteUploadDays: 7}}},
+		{name: "missing id", rules: []models.LifecycleRule{{ExpirationDays: 30}}, wantErr: true},
+		{name: "duplicate id", rules: []models.LifecycleRule{{ID: "a", ExpirationDays: 1}, {ID: "a", ExpirationDays: 2}}, wantErr: true},
+		{name: "prefix with leading slash", rules: []models.LifecycleRule{{ID: "a", Prefix: "/logs", ExpirationDays: 1}}, wantErr: true},
+		{name: "negative days", rules: []models.LifecycleRule{{ID: "a", ExpirationDays: -1}}, wantErr: true},
+		{name: "rule doing nothing", rules: []models.LifecycleRule{{ID: "a"}}, wantErr: true},
+		{name: "too many rules", rules: make([]models.LifecycleRule, maxLifecycleRules+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateLifecycleRules(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("validateLifecycleRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLifecycleRunTimestamps(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+
+	store, err := NewSettingsStore("", nil, clk)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+	lifecycle := NewLifecycleService(nil, store, &config.LifecycleConfig{Interval: time.Hour}, clk)
+
+	// Disabled rules are not enforced, so the run never reaches S3
+	if err := lifecycle.SetRules("photos", []models.LifecycleRule{{ID: "old", ExpirationDays: 30}}); err != nil {
+		t.Fatalf("SetRules failed: %v", err)
+	}
+
+	run, err := lifecycle.Apply(context.Background(), "photos")
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !run.StartedAt.Equal(start) || !run.FinishedAt.Equal(start) {
+		t.Errorf("run = %v to %v, want both at %v", run.StartedAt, run.FinishedAt, start)
+	}
+	if last, ok := lifecycle.LastRun("photos"); !ok || !last.StartedAt.Equal(start) {
+		t.Errorf("LastRun = %+v, %v, want the run started at %v", last, ok, start)
+	}
+}
+
+func TestDeleteObjectsError(t *testing.T) {
+	cause := errors.New("access denied")
+	err := fmt.Errorf("rule old: failed to expire objects: %w", &DeleteObjectsError{Failed: 3, Total: 10, Err: cause})
+
+	var deleteErr *DeleteObjectsError
+	if !errors.As(err, &deleteErr) || deleteErr.Failed != 3 || deleteErr.Total != 10 {
+		t.Fatalf("errors.As = %+v, want 3 of 10 failed", deleteErr)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("the first failure is not unwrapped")
+	}
+	if want := "rule old: failed to expire objects: 3 of 10 objects not deleted: access denied"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
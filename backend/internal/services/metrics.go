@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// s3LatencyBuckets are the upper bounds, in seconds, of the S3 operation latency histogram
+var s3LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// BackendMetrics counts what the proxy asks of Garage: bucket credential cache lookups,
+// Admin API calls and S3 operation latencies. The Admin and S3 services record into it
+// from the helpers all their calls go through, so every operation is covered.
+type BackendMetrics struct {
+	credentialHits          atomic.Int64
+	credentialMisses        atomic.Int64
+	credentialInvalidations atomic.Int64
+
+	// adminQueued is the number of Admin API calls waiting for a concurrency slot
+	adminQueued atomic.Int64
+
+	mu         sync.Mutex
+	adminCalls map[adminCall]int64          // Admin API calls by operation and status
+	s3Ops      map[string]*latencyHistogram // S3 operation latencies by operation
+}
+
+// adminCall labels one Admin API counter
+type adminCall struct {
+	operation string
+	status    string
+}
+
+// latencyHistogram is a cumulative histogram over s3LatencyBuckets
+type latencyHistogram struct {
+	counts []int64 // Observations at or below each bucket bound
+	count  int64
+	sum    float64
+}
+
+// NewBackendMetrics creates an empty set of backend metrics
+func NewBackendMetrics() *BackendMetrics {
+	return &BackendMetrics{
+		adminCalls: make(map[adminCall]int64),
+		s3Ops:      make(map[string]*latencyHistogram),
+	}
+}
+
+// credentialLookup records a bucket credential cache hit or miss
+func (m *BackendMetrics) credentialLookup(hit bool) {
+	if hit {
+		m.credentialHits.Add(1)
+	} else {
+		m.credentialMisses.Add(1)
+	}
+}
+
+// credentialInvalidated records cached bucket credentials being dropped
+func (m *BackendMetrics) credentialInvalidated() {
+	m.credentialInvalidations.Add(1)
+}
+
+// adminCallDone records an Admin API call to requestPath. statusCode is zero when no
+// response was received.
+func (m *BackendMetrics) adminCallDone(requestPath string, statusCode int) {
+	// Operations are the last path segment, without the query that holds IDs
+	requestPath, _, _ = strings.Cut(requestPath, "?")
+	key := adminCall{operation: path.Base(requestPath), status: "error"}
+	if statusCode > 0 {
+		key.status = strconv.Itoa(statusCode)
+	}
+
+	m.mu.Lock()
+	m.adminCalls[key]++
+	m.mu.Unlock()
+}
+
+// s3OperationDone records the latency of an S3 operation
+func (m *BackendMetrics) s3OperationDone(operation string, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histogram, ok := m.s3Ops[operation]
+	if !ok {
+		histogram = &latencyHistogram{counts: make([]int64, len(s3LatencyBuckets))}
+		m.s3Ops[operation] = histogram
+	}
+	for i, bound := range s3LatencyBuckets {
+		if seconds <= bound {
+			histogram.counts[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// PrometheusMetrics renders the backend metrics in the Prometheus text format
+func (m *BackendMetrics) PrometheusMetrics() string {
+	var b strings.Builder
+
+	b.WriteString("# HELP garage_ui_credential_cache_lookups_total Bucket credential cache lookups by result.\n")
+	b.WriteString("# TYPE garage_ui_credential_cache_lookups_total counter\n")
+	fmt.Fprintf(&b, "garage_ui_credential_cache_lookups_total{result=\"hit\"} %d\n", m.credentialHits.Load())
+	fmt.Fprintf(&b, "garage_ui_credential_cache_lookups_total{result=\"miss\"} %d\n", m.credentialMisses.Load())
+
+	b.WriteString("# HELP garage_ui_credential_cache_invalidations_total Bucket credentials dropped from the cache before expiring.\n")
+	b.WriteString("# TYPE garage_ui_credential_cache_invalidations_total counter\n")
+	fmt.Fprintf(&b, "garage_ui_credential_cache_invalidations_total %d\n", m.credentialInvalidations.Load())
+
+	b.WriteString("# HELP garage_ui_backend_retries_total Garage calls repeated after a transient failure.\n")
+	b.WriteString("# TYPE garage_ui_backend_retries_total counter\n")
+	fmt.Fprintf(&b, "garage_ui_backend_retries_total %d\n", utils.RetryAttempts())
+
+	b.WriteString("# HELP garage_ui_admin_api_queued_requests Admin API calls waiting for garage.admin_max_concurrent_requests.\n")
+	b.WriteString("# TYPE garage_ui_admin_api_queued_requests gauge\n")
+	fmt.Fprintf(&b, "garage_ui_admin_api_queued_requests %d\n", m.adminQueued.Load())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]adminCall, 0, len(m.adminCalls))
+	for call := range m.adminCalls {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].operation != calls[j].operation {
+			return calls[i].operation < calls[j].operation
+		}
+		return calls[i].status < calls[j].status
+	})
+
+	b.WriteString("# HELP garage_ui_admin_api_calls_total Garage Admin API calls by operation and response status.\n")
+	b.WriteString("# TYPE garage_ui_admin_api_calls_total counter\n")
+	for _, call := range calls {
+		fmt.Fprintf(&b, "garage_ui_admin_api_calls_total{operation=%q,status=%q} %d\n", call.operation, call.status, m.adminCalls[call])
+	}
+
+	operations := make([]string, 0, len(m.s3Ops))
+	for operation := range m.s3Ops {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	b.WriteString("# HELP garage_ui_s3_operation_duration_seconds Latency of S3 operations against Garage.\n")
+	b.WriteString("# TYPE garage_ui_s3_operation_duration_seconds histogram\n")
+	for _, operation := range operations {
+		histogram := m.s3Ops[operation]
+		for i, bound := range s3LatencyBuckets {
+			fmt.Fprintf(&b, "garage_ui_s3_operation_duration_seconds_bucket{operation=%q,le=%q} %d\n",
+				operation, strconv.FormatFloat(bound, 'g', -1, 64), histogram.counts[i])
+		}
+		fmt.Fprintf(&b, "garage_ui_s3_operation_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", operation, histogram.count)
+		fmt.Fprintf(&b, "garage_ui_s3_operation_duration_seconds_sum{operation=%q} %g\n", operation, histogram.sum)
+		fmt.Fprintf(&b, "garage_ui_s3_operation_duration_seconds_count{operation=%q} %d\n", operation, histogram.count)
+	}
+
+	return b.String()
+}
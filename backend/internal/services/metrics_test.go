@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/garagetest"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// metricValue returns the value of the sample of a rendered metric with the given name and
+// labels, or -1 when there is none
+func metricValue(t *testing.T, metrics, sample string) float64 {
+	t.Helper()
+
+	for _, line := range strings.Split(metrics, "\n") {
+		if value, ok := strings.CutPrefix(line, sample+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("sample %q has value %q: %v", sample, value, err)
+			}
+			return v
+		}
+	}
+	return -1
+}
+
+func TestBackendMetricsCountFakeBackend(t *testing.T) {
+	clk := useManualCache(t, time.Now().UTC().Truncate(time.Second))
+	g := garagetest.New(t, clk)
+	g.AddBucket("photos")
+	g.GrantKey("photos", "GK1", true, nil)
+	g.PutObject("photos", "cat.jpg", "image/jpeg", []byte("meow"))
+
+	// The admin and S3 services share one set of metrics, as they do in main
+	metrics := NewBackendMetrics()
+	admin := NewGarageAdminService(&config.GarageConfig{AdminEndpoint: g.AdminURL, AdminToken: "test"}, &config.LoggingConfig{}, metrics)
+	s3, err := NewS3Service(&config.GarageConfig{Endpoint: g.S3URL, Region: "garage", ForcePathStyle: true}, admin, metrics, clk)
+	if err != nil {
+		t.Fatalf("NewS3Service failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for range 3 {
+		if _, err := s3.GetObjectMetadata(ctx, "photos", "cat.jpg"); err != nil {
+			t.Fatalf("GetObjectMetadata failed: %v", err)
+		}
+	}
+	// Only cached credentials count as invalidated
+	s3.InvalidateBucketCredentials("photos")
+	s3.InvalidateBucketCredentials("photos")
+	if _, err := s3.GetObjectMetadata(ctx, "photos", "missing.jpg"); err == nil {
+		t.Fatal("GetObjectMetadata of a missing object succeeded")
+	}
+
+	rendered := metrics.PrometheusMetrics()
+	tests := []struct {
+		sample string
+		want   float64
+	}{
+		{sample: `garage_ui_credential_cache_lookups_total{result="miss"}`, want: 2},
+		{sample: `garage_ui_credential_cache_lookups_total{result="hit"}`, want: 2},
+		{sample: `garage_ui_credential_cache_invalidations_total`, want: 1},
+		{sample: `garage_ui_admin_api_calls_total{operation="GetBucketInfo",status="200"}`, want: 2},
+		{sample: `garage_ui_admin_api_calls_total{operation="GetKeyInfo",status="200"}`, want: 2},
+		{sample: `garage_ui_s3_operation_duration_seconds_count{operation="GetObjectMetadata"}`, want: 4},
+		{sample: `garage_ui_s3_operation_duration_seconds_bucket{operation="GetObjectMetadata",le="+Inf"}`, want: 4},
+		{sample: `garage_ui_admin_api_queued_requests`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sample, func(t *testing.T) {
+			if got := metricValue(t, rendered, tt.sample); got != tt.want {
+				t.Errorf("%s = %v, want %v in\n%s", tt.sample, got, tt.want, rendered)
+			}
+		})
+	}
+}
+
+func TestBackendMetricsCountRetries(t *testing.T) {
+	var calls atomic.Int32
+	admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+		// The first call is throttled, and retried
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{ID: "b1"})
+	}, 0)
+
+	retriesBefore := utils.RetryAttempts()
+	if _, err := admin.GetBucketInfo(context.Background(), "b1"); err != nil {
+		t.Fatalf("GetBucketInfo failed: %v", err)
+	}
+
+	if got := utils.RetryAttempts() - retriesBefore; got < 1 {
+		t.Errorf("retries grew by %d, want at least 1", got)
+	}
+	rendered := admin.metrics.PrometheusMetrics()
+	for status, want := range map[int]float64{http.StatusTooManyRequests: 1, http.StatusOK: 1} {
+		sample := fmt.Sprintf(`garage_ui_admin_api_calls_total{operation="GetBucketInfo",status="%d"}`, status)
+		if got := metricValue(t, rendered, sample); got != want {
+			t.Errorf("%s = %v, want %v", sample, got, want)
+		}
+	}
+	if got := metricValue(t, rendered, "garage_ui_backend_retries_total"); got < 1 {
+		t.Errorf("garage_ui_backend_retries_total = %v, want at least 1", got)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// nodeQueryTimeout bounds the answer of each node when all nodes are queried, so a single
+// slow node cannot make the whole call time out
+const nodeQueryTimeout = 5 * time.Second
+
+// NodeTimeoutReason is reported in the error map for nodes that did not answer within
+// nodeQueryTimeout
+const NodeTimeoutReason = "timeout"
+
+// queryNodes calls a multi-node Admin API endpoint. For "*", Garage would wait for every node
+// before answering, so each node of the cluster is queried separately and concurrently, within
+// nodeQueryTimeout, and the answers are merged. Nodes that fail or time out are reported in
+// the error map instead of failing the call.
+func (s *GarageAdminService) queryNodes(ctx context.Context, endpoint, nodeID string) (*models.MultiNodeResponse, error) {
+	if nodeID != "*" {
+		return s.queryNode(ctx, endpoint, nodeID)
+	}
+
+	status, err := s.GetClusterStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	results := make([]*models.MultiNodeResponse, len(status.Nodes))
+	errs := make([]error, len(status.Nodes))
+	var wg sync.WaitGroup
+	for i, node := range status.Nodes {
+		wg.Add(1)
+		go func(i int, nodeID string) {
+			defer wg.Done()
+			nodeCtx, cancel := context.WithTimeout(ctx, nodeQueryTimeout)
+			defer cancel()
+			results[i], errs[i] = s.queryNode(nodeCtx, endpoint, nodeID)
+			// The HTTP client does not always wrap the context error
+			if errs[i] != nil && nodeCtx.Err() != nil {
+				errs[i] = nodeCtx.Err()
+			}
+		}(i, node.ID)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	merged := &models.MultiNodeResponse{
+		Success: make(map[string]interface{}),
+		Error:   make(map[string]string),
+	}
+	for i, node := range status.Nodes {
+		switch {
+		case errors.Is(errs[i], context.DeadlineExceeded):
+			merged.Error[node.ID] = NodeTimeoutReason
+		case errs[i] != nil:
+			merged.Error[node.ID] = errs[i].Error()
+		default:
+			for id, value := range results[i].Success {
+				merged.Success[id] = value
+			}
+			for id, reason := range results[i].Error {
+				merged.Error[id] = reason
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// queryNode calls a multi-node Admin API endpoint for a single node, or for a node selector
+func (s *GarageAdminService) queryNode(ctx context.Context, endpoint, nodeID string) (*models.MultiNodeResponse, error) {
+	path := fmt.Sprintf("/v2/%s?node=%s", endpoint, url.QueryEscape(nodeID))
+
+	resp, err := s.doRequest(ctx, http.MethodGet, path, nil, retrySafe)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	var result models.MultiNodeResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
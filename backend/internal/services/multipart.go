@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Limits of the S3 multipart protocol. Every part but the last must be at least
+// MultipartMinPartSize bytes; Garage refuses to complete an upload otherwise.
+const (
+	MultipartMaxParts    = 10000
+	MultipartMinPartSize = 5 * 1024 * 1024
+)
+
+// InitMultipartUpload starts a multipart upload of key and returns its upload ID. The object
+// only appears once the upload is completed.
+func (s *S3Service) InitMultipartUpload(ctx context.Context, bucketName, key, contentType string) (string, error) {
+	var uploadID string
+
+	// Call MinIO NewMultipartUpload API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "InitMultipartUpload", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var initErr error
+			uploadID, initErr = minio.Core{Client: client}.NewMultipartUpload(ctx, bucketName, key, minio.PutObjectOptions{
+				ContentType: contentType,
+			})
+			return throttleError(ctx, initErr)
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload of %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	return uploadID, nil
+}
+
+// UploadPart uploads one part of a multipart upload. Uploading a part number again replaces
+// the part, so a failed part can simply be sent again. body must hold exactly size bytes.
+func (s *S3Service) UploadPart(ctx context.Context, bucketName, key, uploadID string, partNumber int, body io.Reader, size int64) (*models.MultipartPart, error) {
+	var part minio.ObjectPart
+
+	// Call MinIO PutObjectPart API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withReplayableBody(ctx, "UploadPart", bucketName, body, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var uploadErr error
+			part, uploadErr = minio.Core{Client: client}.PutObjectPart(ctx, bucketName, key, uploadID, partNumber, body, size, minio.PutObjectPartOptions{})
+			return throttleError(ctx, uploadErr)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload part %d of %s in bucket %s: %w", partNumber, key, bucketName, err)
+	}
+
+	return &models.MultipartPart{
+		PartNumber: part.PartNumber,
+		ETag:       part.ETag,
+		Size:       part.Size,
+	}, nil
+}
+
+// CompleteMultipartUpload assembles the given parts into the object. Parts are assembled in
+// part number order whatever order they are given in.
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, bucketName, key, uploadID string, parts []models.MultipartCompletePart) (*models.ObjectUploadResponse, error) {
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	sort.Slice(completeParts, func(i, j int) bool {
+		return completeParts[i].PartNumber < completeParts[j].PartNumber
+	})
+
+	var info minio.UploadInfo
+
+	// Call MinIO CompleteMultipartUpload API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "CompleteMultipartUpload", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var completeErr error
+			info, completeErr = minio.Core{Client: client}.CompleteMultipartUpload(ctx, bucketName, key, uploadID, completeParts, minio.PutObjectOptions{})
+			return throttleError(ctx, completeErr)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload of %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	// The completion does not report the size, so it is read back
+	response := &models.ObjectUploadResponse{
+		Bucket: bucketName,
+		Key:    key,
+		ETag:   info.ETag,
+	}
+	if object, err := s.GetObjectMetadata(ctx, bucketName, key); err == nil {
+		response.Size = object.Size
+		response.StoredSize = object.Size
+		response.ContentType = object.ContentType
+	}
+	return response, nil
+}
+
+// AbortMultipartUpload cancels a multipart upload and frees the parts uploaded so far
+func (s *S3Service) AbortMultipartUpload(ctx context.Context, bucketName, key, uploadID string) error {
+	// Call MinIO AbortMultipartUpload API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "AbortMultipartUpload", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, minio.Core{Client: client}.AbortMultipartUpload(ctx, bucketName, key, uploadID))
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload of %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	return nil
+}
+
+// AbortIncompleteUploads aborts every multipart upload in progress under prefix and returns
+// how many were aborted. With initiatedBefore set, uploads started at or after it are kept.
+// Uploads that end while this runs are skipped.
+func (s *S3Service) AbortIncompleteUploads(ctx context.Context, bucketName, prefix string, initiatedBefore time.Time) (int, error) {
+	aborted := 0
+
+	// Kept outside the closure so a credential retry resumes instead of starting over
+	keyMarker, uploadIDMarker := "", ""
+
+	retryConfig := utils.ReadRetryConfig()
+	abortRetryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "AbortIncompleteUploads", bucketName, func(client *minio.Client) error {
+		core := minio.Core{Client: client}
+
+		for {
+			var result minio.ListMultipartUploadsResult
+			err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+				var listErr error
+				result, listErr = core.ListMultipartUploads(ctx, bucketName, prefix, keyMarker, uploadIDMarker, "", s3MaxKeys)
+				return throttleError(ctx, listErr)
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, upload := range result.Uploads {
+				if !initiatedBefore.IsZero() && !upload.Initiated.Before(initiatedBefore) {
+					continue
+				}
+				err := utils.RetryWithBackoff(ctx, abortRetryConfig, func() error {
+					return throttleError(ctx, core.AbortMultipartUpload(ctx, bucketName, upload.Key, upload.UploadID))
+				})
+				if err != nil && !IsNoSuchUpload(err) {
+					return err
+				}
+				if err == nil {
+					aborted++
+				}
+			}
+
+			if !result.IsTruncated {
+				return nil
+			}
+			keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+		}
+	})
+	if err != nil {
+		return aborted, fmt.Errorf("failed to abort incomplete uploads in bucket %s: %w", bucketName, err)
+	}
+
+	return aborted, nil
+}
+
+// IsNoSuchUpload reports whether Garage does not know the upload ID of a multipart upload:
+// it never existed, or was completed or aborted
+func IsNoSuchUpload(err error) bool {
+	var errResponse minio.ErrorResponse
+	return errors.As(err, &errResponse) && errResponse.Code == "NoSuchUpload"
+}
+
+// IsInvalidPart reports whether Garage refused the parts listed to complete a multipart
+// upload: unknown parts, wrong ETags, or parts but the last smaller than MultipartMinPartSize
+func IsInvalidPart(err error) bool {
+	var errResponse minio.ErrorResponse
+	if !errors.As(err, &errResponse) {
+		return false
+	}
+
+	switch errResponse.Code {
+	case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// Bounds of key notification delivery
+const (
+	notificationQueueSize   = 256
+	notificationAttempts    = 3
+	notificationTimeout     = 10 * time.Second
+	notificationBackoff     = 2 * time.Second // Doubled after each failed attempt
+	notificationLogCapacity = 500
+)
+
+// notificationJob is a notification waiting to be delivered to a webhook
+type notificationJob struct {
+	notification models.KeyNotification
+	target       string
+}
+
+// Notifier announces changes to a key's access to the webhook registered for the key.
+// Notifications are queued and delivered in the background with retries, so delivery never
+// delays or fails the change itself; the outcome of each is kept in a delivery log.
+type Notifier struct {
+	settingsStore *SettingsStore
+	client        *http.Client
+	queue         chan notificationJob
+
+	mu         sync.Mutex
+	deliveries []models.NotificationDelivery
+	next       int
+}
+
+// NewNotifier creates a notifier reading the key targets from the settings store
+func NewNotifier(settingsStore *SettingsStore) *Notifier {
+	return &Notifier{
+		settingsStore: settingsStore,
+		client:        &http.Client{Timeout: notificationTimeout},
+		queue:         make(chan notificationJob, notificationQueueSize),
+	}
+}
+
+// Notify queues a notification for the key's webhook, if it has one. It never blocks:
+// when the queue is full the notification is dropped and logged as such.
+func (n *Notifier) Notify(notification models.KeyNotification) {
+	target, ok := n.settingsStore.KeyNotificationTarget(notification.AccessKeyID)
+	if !ok || target.WebhookURL == "" {
+		return
+	}
+
+	if notification.Time.IsZero() {
+		notification.Time = time.Now().UTC()
+	}
+	notification.Contact = target.Contact
+
+	job := notificationJob{notification: notification, target: target.WebhookURL}
+	select {
+	case n.queue <- job:
+	default:
+		n.record(job, models.NotificationDropped, 0, "notification queue is full")
+	}
+}
+
+// Run delivers queued notifications until ctx is done. Notifications still queued then
+// are dropped.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case job := <-n.queue:
+					n.record(job, models.NotificationDropped, 0, "server shutting down")
+				default:
+					return
+				}
+			}
+		case job := <-n.queue:
+			n.deliver(ctx, job)
+		}
+	}
+}
+
+// Recent returns up to limit deliveries, newest first
+func (n *Notifier) Recent(limit int) []models.NotificationDelivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if limit <= 0 || limit > len(n.deliveries) {
+		limit = len(n.deliveries)
+	}
+
+	result := make([]models.NotificationDelivery, 0, limit)
+	for i := 0; i < limit; i++ {
+		// The newest delivery sits just before next once the buffer has wrapped
+		idx := (n.next - 1 - i + len(n.deliveries)) % len(n.deliveries)
+		result = append(result, n.deliveries[idx])
+	}
+	return result
+}
+
+// deliver posts a notification to its webhook, retrying with backoff
+func (n *Notifier) deliver(ctx context.Context, job notificationJob) {
+	body, err := json.Marshal(job.notification)
+	if err != nil {
+		n.record(job, models.NotificationFailed, 0, err.Error())
+		return
+	}
+
+	backoff := notificationBackoff
+	for attempt := 1; ; attempt++ {
+		err = n.post(ctx, job.target, body)
+		if err == nil {
+			n.record(job, models.NotificationDelivered, attempt, "")
+			return
+		}
+		if attempt == notificationAttempts {
+			n.record(job, models.NotificationFailed, attempt, err.Error())
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			n.record(job, models.NotificationDropped, attempt, err.Error())
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one delivery attempt; any status other than 2xx is a failure
+func (n *Notifier) post(ctx context.Context, target string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// record adds the outcome of a notification to the delivery log
+func (n *Notifier) record(job notificationJob, status string, attempts int, errText string) {
+	delivery := models.NotificationDelivery{
+		Notification: job.notification,
+		Target:       job.target,
+		Status:       status,
+		Attempts:     attempts,
+		Error:        errText,
+		CompletedAt:  time.Now().UTC(),
+	}
+
+	if status != models.NotificationDelivered {
+		logger.Warn().
+			Str("event", job.notification.Event).
+			Str("access_key", job.notification.AccessKeyID).
+			Str("status", status).
+			Int("attempts", attempts).
+			Str("error", errText).
+			Msg("Key notification not delivered")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.deliveries) < notificationLogCapacity {
+		n.deliveries = append(n.deliveries, delivery)
+		return
+	}
+	n.deliveries[n.next] = delivery
+	n.next = (n.next + 1) % notificationLogCapacity
+}
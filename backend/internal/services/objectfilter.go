@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// FilterScanBudget is the most keys a filtered listing page scans. When it is used up the
+// page is returned with fewer matches than requested, or none, and a continuation token.
+const FilterScanBudget = 10 * s3MaxKeys
+
+// filterTokenPrefix marks continuation tokens of filtered listings, which hold the last
+// scanned key rather than an S3 continuation token
+const filterTokenPrefix = "filter:"
+
+// ErrInvalidFilterToken is returned when a filtered listing is given a continuation token
+// that did not come from a filtered listing
+var ErrInvalidFilterToken = errors.New("continuation token does not belong to a filtered listing")
+
+// ObjectFilter selects objects by size and modification time. Unset bounds are ignored;
+// set bounds are inclusive.
+type ObjectFilter struct {
+	MinSize        *int64
+	MaxSize        *int64
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+}
+
+// Active reports whether any bound is set
+func (f ObjectFilter) Active() bool {
+	return f.MinSize != nil || f.MaxSize != nil || f.ModifiedAfter != nil || f.ModifiedBefore != nil
+}
+
+// Matches reports whether an object is within every set bound
+func (f ObjectFilter) Matches(obj models.ObjectInfo) bool {
+	if f.MinSize != nil && obj.Size < *f.MinSize {
+		return false
+	}
+	if f.MaxSize != nil && obj.Size > *f.MaxSize {
+		return false
+	}
+	if f.ModifiedAfter != nil && obj.LastModified.Before(*f.ModifiedAfter) {
+		return false
+	}
+	if f.ModifiedBefore != nil && obj.LastModified.After(*f.ModifiedBefore) {
+		return false
+	}
+	return true
+}
+
+// encodeFilterToken returns the continuation token resuming a filtered listing after key
+func encodeFilterToken(key string) string {
+	return filterTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeFilterToken returns the key a filtered listing resumes after
+func decodeFilterToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	encoded, ok := strings.CutPrefix(token, filterTokenPrefix)
+	if !ok {
+		return "", ErrInvalidFilterToken
+	}
+	key, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidFilterToken
+	}
+	return string(key), nil
+}
+
+// ListObjectsFiltered lists one folder level like ListObjects but only returns the objects
+// matching filter; folders are always returned. Garage pages are scanned until maxKeys
+// objects match or FilterScanBudget keys have been scanned, so a page may hold fewer
+// matches than requested while more follow. The continuation token holds the last scanned
+// key, so the next page resumes exactly where this one stopped. With recursive set, every
+// key under the prefix is scanned and no folders are returned.
+func (s *S3Service) ListObjectsFiltered(ctx context.Context, bucketName, prefix string, recursive bool, maxKeys int, continuationToken string, filter ObjectFilter) (*models.ObjectListResponse, error) {
+	if maxKeys <= 0 || maxKeys > s3MaxKeys {
+		maxKeys = s3MaxKeys
+	}
+
+	startAfter, err := decodeFilterToken(continuationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
+
+	var client *minio.Client
+	objects := make([]models.ObjectInfo, 0, maxKeys)
+	prefixes := make([]string, 0)
+	scanned := 0
+	lastKey := ""
+	truncated := false
+
+	retryConfig := utils.ReadRetryConfig()
+	err = s.withBucketClient(ctx, "ListObjectsFiltered", bucketName, func(c *minio.Client) error {
+		client = c
+		core := &minio.Core{Client: c}
+
+		objects, prefixes = objects[:0], prefixes[:0]
+		scanned, lastKey, truncated = 0, startAfter, false
+		pageToken := ""
+
+		for {
+			var result minio.ListBucketV2Result
+			err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+				var listErr error
+				// S3 ignores startAfter once a continuation token is given
+				result, listErr = core.ListObjectsV2(bucketName, prefix, startAfter, pageToken, delimiter, s3MaxKeys)
+				return throttleError(ctx, listErr)
+			})
+			if err != nil {
+				return err
+			}
+
+			// Folders and objects come back separately; walk them in key order so the
+			// last scanned key is a valid resume point
+			folders := commonPrefixes(result.CommonPrefixes)
+			contents := result.Contents
+			for len(folders) > 0 || len(contents) > 0 {
+				if len(objects) == maxKeys || scanned == FilterScanBudget {
+					truncated = true
+					return nil
+				}
+
+				if len(contents) == 0 || (len(folders) > 0 && folders[0] < contents[0].Key) {
+					// Resuming after a folder lists it again, as its keys follow startAfter
+					if folders[0] > startAfter {
+						prefixes = append(prefixes, folders[0])
+					}
+					lastKey = folders[0]
+					folders = folders[1:]
+					continue
+				}
+
+				obj := objectInfoFromListing(contents[0])
+				contents = contents[1:]
+				scanned++
+				lastKey = obj.Key
+				if filter.Matches(obj) {
+					objects = append(objects, obj)
+				}
+			}
+
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return nil
+			}
+			pageToken = result.NextContinuationToken
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
+	}
+
+	fillContentTypes(ctx, client, bucketName, objects)
+
+	nextToken := ""
+	if truncated {
+		nextToken = encodeFilterToken(lastKey)
+	}
+
+	response := &models.ObjectListResponse{
+		Bucket:                bucketName,
+		Objects:               objects,
+		Prefixes:              prefixes,
+		Count:                 len(objects),
+		Scanned:               scanned,
+		IsTruncated:           truncated,
+		NextContinuationToken: nextToken,
+		Pagination: models.Pagination{
+			Limit:                 maxKeys,
+			HasMore:               truncated,
+			NextContinuationToken: nextToken,
+		},
+	}
+	s.markPublicObjects(ctx, response)
+
+	return response, nil
+}
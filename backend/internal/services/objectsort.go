@@ -0,0 +1,204 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// SortWindow is the most keys of a folder a sorted listing reads. Garage only lists in
+// ascending key order, so any other order needs the whole folder in memory; in larger
+// folders only the first SortWindow keys are sorted and the listing says so.
+const SortWindow = 10 * s3MaxKeys
+
+// Fields object listings can be sorted by
+const (
+	SortByName         = "name"
+	SortBySize         = "size"
+	SortByLastModified = "last_modified"
+)
+
+// sortTokenPrefix marks continuation tokens of sorted listings, which hold an offset into
+// the sorted folder rather than an S3 continuation token
+const sortTokenPrefix = "sort:"
+
+// ErrInvalidSortToken is returned when a sorted listing is given a continuation token that
+// did not come from a sorted listing
+var ErrInvalidSortToken = errors.New("continuation token does not belong to a sorted listing")
+
+// ObjectSort is the order of an object listing
+type ObjectSort struct {
+	By         string // One of the SortBy constants
+	Descending bool
+}
+
+// Native reports whether Garage lists in this order by itself
+func (o ObjectSort) Native() bool {
+	return (o.By == "" || o.By == SortByName) && !o.Descending
+}
+
+// compare orders two objects, by key when the sort field is equal
+func (o ObjectSort) compare(a, b models.ObjectInfo) int {
+	result := 0
+	switch o.By {
+	case SortBySize:
+		result = cmp.Compare(a.Size, b.Size)
+	case SortByLastModified:
+		result = a.LastModified.Compare(b.LastModified)
+	}
+	if result == 0 {
+		result = strings.Compare(a.Key, b.Key)
+	}
+	if o.Descending {
+		return -result
+	}
+	return result
+}
+
+// encodeSortToken returns the continuation token of the page of a sorted listing at offset
+func encodeSortToken(offset int) string {
+	return sortTokenPrefix + strconv.Itoa(offset)
+}
+
+// decodeSortToken returns the offset a sorted listing resumes at
+func decodeSortToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	encoded, ok := strings.CutPrefix(token, sortTokenPrefix)
+	if !ok {
+		return 0, ErrInvalidSortToken
+	}
+	offset, err := strconv.Atoi(encoded)
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidSortToken
+	}
+	return offset, nil
+}
+
+// ListObjectsSorted lists one folder level like ListObjects, in the given order, keeping the
+// objects matching filter. Up to SortWindow keys of the folder are read and sorted on each
+// call, then the page at the offset held by the continuation token is returned; folders come
+// first, ordered by name (reversed for a descending name sort), then objects. When the
+// folder holds more keys, SortWindowExceeded is set and the keys after the window are left
+// out. Pages are read again on each call, so a folder changing between pages may shift them.
+// With recursive set, the window covers every key under the prefix and no folders are returned.
+func (s *S3Service) ListObjectsSorted(ctx context.Context, bucketName, prefix string, recursive bool, maxKeys int, continuationToken string, order ObjectSort, filter ObjectFilter) (*models.ObjectListResponse, error) {
+	if maxKeys <= 0 || maxKeys > s3MaxKeys {
+		maxKeys = s3MaxKeys
+	}
+
+	offset, err := decodeSortToken(continuationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
+
+	var client *minio.Client
+	objects := make([]models.ObjectInfo, 0)
+	prefixes := make([]string, 0)
+	scanned := 0
+	windowExceeded := false
+
+	retryConfig := utils.ReadRetryConfig()
+	err = s.withBucketClient(ctx, "ListObjectsSorted", bucketName, func(c *minio.Client) error {
+		client = c
+		core := &minio.Core{Client: c}
+
+		objects, prefixes = objects[:0], prefixes[:0]
+		scanned, windowExceeded = 0, false
+		pageToken := ""
+
+		for {
+			var result minio.ListBucketV2Result
+			err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+				var listErr error
+				result, listErr = core.ListObjectsV2(bucketName, prefix, "", pageToken, delimiter, s3MaxKeys)
+				return throttleError(ctx, listErr)
+			})
+			if err != nil {
+				return err
+			}
+
+			prefixes = append(prefixes, commonPrefixes(result.CommonPrefixes)...)
+			for _, content := range result.Contents {
+				obj := objectInfoFromListing(content)
+				scanned++
+				if filter.Matches(obj) {
+					objects = append(objects, obj)
+				}
+			}
+
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return nil
+			}
+			if scanned+len(prefixes) >= SortWindow {
+				windowExceeded = true
+				return nil
+			}
+			pageToken = result.NextContinuationToken
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
+	}
+
+	slices.Sort(prefixes)
+	prefixes = slices.Compact(prefixes)
+	if order.By == SortByName && order.Descending {
+		slices.Reverse(prefixes)
+	}
+	slices.SortFunc(objects, order.compare)
+
+	// Folders and objects form one sequence the pages are cut from
+	total := len(prefixes) + len(objects)
+	start := min(offset, total)
+	end := min(start+maxKeys, total)
+	pagePrefixes := prefixes[min(start, len(prefixes)):min(end, len(prefixes))]
+	pageObjects := objects[max(start-len(prefixes), 0):max(end-len(prefixes), 0)]
+	fillContentTypes(ctx, client, bucketName, pageObjects)
+
+	truncated := end < total
+	nextToken := ""
+	var nextOffset *int
+	if truncated {
+		nextToken = encodeSortToken(end)
+		nextOffset = &end
+	}
+
+	response := &models.ObjectListResponse{
+		Bucket:                bucketName,
+		Objects:               pageObjects,
+		Prefixes:              slices.Clip(pagePrefixes),
+		Count:                 len(pageObjects),
+		Scanned:               scanned,
+		IsTruncated:           truncated,
+		NextContinuationToken: nextToken,
+		SortWindowExceeded:    windowExceeded,
+		Pagination: models.Pagination{
+			Limit:                 maxKeys,
+			Offset:                start,
+			Total:                 total,
+			HasMore:               truncated,
+			NextOffset:            nextOffset,
+			NextContinuationToken: nextToken,
+		},
+	}
+	s.markPublicObjects(ctx, response)
+
+	return response, nil
+}
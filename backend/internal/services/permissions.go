@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// permissionMatrixConcurrency bounds concurrent GetBucketInfo calls while building the matrix
+const permissionMatrixConcurrency = 8
+
+// BuildPermissionMatrix computes which keys can access which buckets for one page of buckets.
+// Buckets are ordered by name and paged with offset/limit so the work stays bounded on large
+// clusters; totals describe the returned page only.
+func (s *GarageAdminService) BuildPermissionMatrix(ctx context.Context, offset, limit, ownerThreshold int) (*models.PermissionMatrix, error) {
+	buckets, bucketsTruncated, err := s.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	keys, keysTruncated, err := s.ListKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	// Build the bucket columns in a stable order
+	columns := make([]models.PermissionMatrixBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		name := bucket.ID
+		if len(bucket.GlobalAliases) > 0 {
+			name = bucket.GlobalAliases[0]
+		}
+		columns = append(columns, models.PermissionMatrixBucket{ID: bucket.ID, Name: name})
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].Name < columns[j].Name
+	})
+
+	matrix := &models.PermissionMatrix{
+		Offset:       offset,
+		Limit:        limit,
+		TotalBuckets: len(columns),
+		Truncated:    bucketsTruncated || keysTruncated,
+	}
+
+	if offset > len(columns) {
+		offset = len(columns)
+	}
+	end := min(offset+limit, len(columns))
+	if end < len(columns) {
+		matrix.NextOffset = &end
+	}
+	columns = columns[offset:end]
+	matrix.Buckets = columns
+
+	// Fetch bucket details concurrently; results go through the bucket info cache
+	infos := make([]*models.GarageBucketInfo, len(columns))
+	errs := make([]error, len(columns))
+	sem := make(chan struct{}, permissionMatrixConcurrency)
+	var wg sync.WaitGroup
+	for i, column := range columns {
+		wg.Add(1)
+		go func(i int, bucketID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			infos[i], errs[i] = s.GetBucketInfo(ctx, bucketID)
+		}(i, column.ID)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info for bucket %s: %w", columns[i].Name, err)
+		}
+	}
+
+	keyNames := make(map[string]string, len(keys))
+	for _, key := range keys {
+		keyNames[key.ID] = key.Name
+	}
+
+	// Fill in the cells, creating rows lazily for keys that appear on this page
+	rows := make(map[string]*models.PermissionMatrixRow)
+	ownerCounts := make(map[string]int)
+	for i, info := range infos {
+		hasOwner := false
+		for _, key := range info.Keys {
+			cell := permissionFlags(key.Permissions)
+			if cell == "" {
+				continue
+			}
+
+			row, ok := rows[key.AccessKeyID]
+			if !ok {
+				name, known := keyNames[key.AccessKeyID]
+				if !known {
+					name = key.Name
+				}
+				row = &models.PermissionMatrixRow{
+					AccessKeyID: key.AccessKeyID,
+					Name:        name,
+					Cells:       make([]string, len(columns)),
+				}
+				rows[key.AccessKeyID] = row
+			}
+			row.Cells[i] = cell
+			matrix.Totals.Grants++
+
+			if key.Permissions.Owner {
+				hasOwner = true
+				ownerCounts[key.AccessKeyID]++
+			}
+		}
+
+		if len(info.Keys) == 0 {
+			matrix.Totals.BucketsWithoutKeys++
+		}
+		if !hasOwner {
+			matrix.Totals.BucketsWithoutOwner++
+		}
+	}
+
+	matrix.Keys = make([]models.PermissionMatrixRow, 0, len(rows))
+	for _, row := range rows {
+		matrix.Keys = append(matrix.Keys, *row)
+	}
+	sort.Slice(matrix.Keys, func(i, j int) bool {
+		if matrix.Keys[i].Name != matrix.Keys[j].Name {
+			return matrix.Keys[i].Name < matrix.Keys[j].Name
+		}
+		return matrix.Keys[i].AccessKeyID < matrix.Keys[j].AccessKeyID
+	})
+
+	matrix.Totals.Keys = len(matrix.Keys)
+	matrix.Totals.OwnerThreshold = ownerThreshold
+	for _, count := range ownerCounts {
+		if count > ownerThreshold {
+			matrix.Totals.KeysOwningManyBuckets++
+		}
+	}
+
+	return matrix, nil
+}
+
+// permissionFlags renders permissions as "rwo" flags, or "" when the key has no access
+func permissionFlags(perm models.BucketKeyPermission) string {
+	if !perm.Read && !perm.Write && !perm.Owner {
+		return ""
+	}
+
+	flags := []byte("---")
+	if perm.Read {
+		flags[0] = 'r'
+	}
+	if perm.Write {
+		flags[1] = 'w'
+	}
+	if perm.Owner {
+		flags[2] = 'o'
+	}
+	return string(flags)
+}
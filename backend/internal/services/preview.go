@@ -0,0 +1,253 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// Bytes of a PDF scanned for its metadata: the start holds the header and, in linearized
+// files, the first trailer; the end holds the trailer of every other file
+const (
+	pdfScanHead = 1 << 20
+	pdfScanTail = 256 << 10
+)
+
+// ReadObjectHead returns up to limit bytes from the start of the content of an object, and
+// whether the object holds more. Objects stored with gzip are decompressed, so the bytes are
+// the content as downloaded.
+func (s *S3Service) ReadObjectHead(ctx context.Context, bucketName string, info *models.ObjectInfo, limit int64) ([]byte, bool, error) {
+	if info.ContentEncoding != ContentEncodingGzip {
+		if info.Size == 0 {
+			return []byte{}, false, nil
+		}
+		body, err := s.GetObjectRange(ctx, bucketName, info.Key, 0, min(limit, info.Size))
+		if err != nil {
+			return nil, false, err
+		}
+		defer body.Close()
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read object %s: %w", info.Key, err)
+		}
+		return data, info.Size > limit, nil
+	}
+
+	// The decompressed size is unknown, so read one byte more to tell whether there is more
+	body, _, err := s.GetObject(ctx, bucketName, info.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	defer body.Close()
+
+	decompressed, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decompress object %s: %w", info.Key, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(decompressed, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read object %s: %w", info.Key, err)
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
+// GetPDFInfo reads the page count and document information of a PDF from its first and last
+// bytes, without downloading the whole file. The page count is left at 0 when the page tree
+// is stored in a compressed object stream, and the title and author when the information
+// dictionary is outside the scanned bytes or the document is encrypted.
+func (s *S3Service) GetPDFInfo(ctx context.Context, bucketName string, info *models.ObjectInfo) (*models.PDFInfo, error) {
+	data, truncated, err := s.ReadObjectHead(ctx, bucketName, info, pdfScanHead)
+	if err != nil {
+		return nil, err
+	}
+
+	// The end of compressed objects cannot be reached without decompressing everything
+	if truncated && info.ContentEncoding != ContentEncodingGzip {
+		offset := max(info.Size-pdfScanTail, int64(len(data)))
+		tail, err := s.GetObjectRange(ctx, bucketName, info.Key, offset, info.Size-offset)
+		if err != nil {
+			return nil, err
+		}
+		defer tail.Close()
+
+		tailData, err := io.ReadAll(tail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object %s: %w", info.Key, err)
+		}
+		data = append(data, tailData...)
+	}
+
+	return parsePDFInfo(data), nil
+}
+
+var (
+	pdfVersionPattern   = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+	pdfPagesPattern     = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfInfoRefPattern   = regexp.MustCompile(`/Info\s+(\d+)\s+(\d+)\s+R`)
+	pdfEncryptPattern   = regexp.MustCompile(`/Encrypt\s*(\d+\s+\d+\s+R|<<)`)
+	pdfInfoFieldPattern = regexp.MustCompile(`/(Title|Author|Subject|Creator|Producer)\s*([(<])`)
+)
+
+// parsePDFInfo extracts what it can from the raw bytes of a PDF
+func parsePDFInfo(data []byte) *models.PDFInfo {
+	pdf := &models.PDFInfo{}
+	if match := pdfVersionPattern.FindSubmatch(data); match != nil {
+		pdf.Version = string(match[1])
+	}
+	pdf.Encrypted = pdfEncryptPattern.Match(data)
+
+	// Every node of the page tree has a count; the root has the largest
+	for _, match := range pdfPagesPattern.FindAllSubmatch(data, -1) {
+		count := match[1]
+		if count == nil {
+			count = match[2]
+		}
+		if n, err := strconv.Atoi(string(count)); err == nil && n > pdf.PageCount {
+			pdf.PageCount = n
+		}
+	}
+
+	// Strings of encrypted documents are encrypted too
+	if pdf.Encrypted {
+		return pdf
+	}
+
+	// Incremental updates append trailers, so the last reference is the current one
+	refs := pdfInfoRefPattern.FindAllSubmatch(data, -1)
+	if len(refs) == 0 {
+		return pdf
+	}
+	ref := refs[len(refs)-1]
+	objectPattern, err := regexp.Compile(`(?:^|\s)` + string(ref[1]) + `\s+` + string(ref[2]) + `\s+obj\s*<<`)
+	if err != nil {
+		return pdf
+	}
+	locations := objectPattern.FindAllIndex(data, -1)
+	if len(locations) == 0 {
+		return pdf
+	}
+	dict := data[locations[len(locations)-1][1]:]
+	if end := bytes.Index(dict, []byte("endobj")); end >= 0 {
+		dict = dict[:end]
+	}
+
+	for _, match := range pdfInfoFieldPattern.FindAllSubmatchIndex(dict, -1) {
+		value := decodePDFString(dict[match[4]:])
+		switch string(dict[match[2]:match[3]]) {
+		case "Title":
+			pdf.Title = value
+		case "Author":
+			pdf.Author = value
+		case "Subject":
+			pdf.Subject = value
+		case "Creator":
+			pdf.Creator = value
+		case "Producer":
+			pdf.Producer = value
+		}
+	}
+	return pdf
+}
+
+// decodePDFString decodes the literal (...) or hexadecimal <...> string at the start of data
+func decodePDFString(data []byte) string {
+	var raw []byte
+	if data[0] == '<' {
+		var digits []byte
+		for _, b := range data[1:] {
+			if b == '>' {
+				break
+			}
+			if _, err := strconv.ParseUint(string(b), 16, 8); err == nil {
+				digits = append(digits, b)
+			}
+		}
+		if len(digits)%2 == 1 {
+			digits = append(digits, '0')
+		}
+		for i := 0; i < len(digits); i += 2 {
+			b, _ := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+			raw = append(raw, byte(b))
+		}
+	} else {
+		raw = readPDFLiteral(data[1:])
+	}
+
+	// Text strings are UTF-16BE with a byte order mark, or PDFDocEncoding, which matches
+	// Latin-1 for the printable characters
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// readPDFLiteral reads the body of a literal string up to its closing parenthesis, resolving
+// escapes. Balanced parentheses may appear unescaped inside.
+func readPDFLiteral(data []byte) []byte {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b == '\\' && i+1 < len(data):
+			i++
+			switch escaped := data[i]; escaped {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// Escaped line breaks continue the string on the next line
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					end := i + 1
+					for end < len(data) && end < i+3 && data[end] >= '0' && data[end] <= '7' {
+						end++
+					}
+					value, _ := strconv.ParseUint(string(data[i:end]), 8, 8)
+					out = append(out, byte(value))
+					i = end - 1
+				} else {
+					out = append(out, escaped)
+				}
+			}
+		case b == '(':
+			depth++
+			out = append(out, b)
+		case b == ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+			out = append(out, b)
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
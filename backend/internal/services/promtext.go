@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// promSample is one sample of the Prometheus text exposition format
+type promSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parsePrometheusText parses the samples of a Prometheus text exposition. Comments and
+// timestamps are ignored, and lines that cannot be parsed are skipped so that one odd
+// metric does not hide the others.
+func parsePrometheusText(text string) []promSample {
+	var samples []promSample
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if sample, err := parsePrometheusLine(line); err == nil {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// parsePrometheusLine parses a line of the form name{label="value",...} value [timestamp]
+func parsePrometheusLine(line string) (promSample, error) {
+	sample := promSample{labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, fmt.Errorf("missing value")
+	}
+	sample.name = line[:end]
+	rest := line[end:]
+
+	if rest[0] == '{' {
+		var err error
+		if rest, err = parsePrometheusLabels(rest[1:], sample.labels); err != nil {
+			return sample, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, fmt.Errorf("missing value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("invalid value: %w", err)
+	}
+	sample.value = value
+	return sample, nil
+}
+
+// parsePrometheusLabels reads label pairs up to the closing brace into labels and returns
+// what follows the brace
+func parsePrometheusLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return "", fmt.Errorf("unterminated labels")
+		}
+		if s[0] == '}' {
+			return s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return "", fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		// Values escape backslashes, double quotes and line feeds
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			switch {
+			case s[i] == '\\' && i+1 < len(s):
+				i++
+				if s[i] == 'n' {
+					value.WriteByte('\n')
+				} else {
+					value.WriteByte(s[i])
+				}
+			case s[i] == '"':
+				s = s[i+1:]
+				closed = true
+			default:
+				value.WriteByte(s[i])
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated label value")
+		}
+		labels[name] = value.String()
+	}
+}
@@ -2,29 +2,61 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
 	"Noooste/garage-ui/pkg/utils"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 // S3Service handles all S3 operations with Garage using MinIO SDK
 type S3Service struct {
-	client       *minio.Client
 	config       *config.GarageConfig
 	adminService *GarageAdminService
+
+	// client uses the default key. It is built lazily and dropped after network failures,
+	// so an outage at startup does not outlive the outage.
+	clientMu sync.Mutex
+	client   *minio.Client
+
+	// credentialLookups coalesces concurrent credential resolutions for the same bucket
+	credentialLookups singleflight.Group
+
+	// presignEndpoint and presignSecure describe the host presigned URLs are signed for.
+	// They default to the internal endpoint unless garage.public_endpoint is set.
+	presignEndpoint string
+	presignSecure   bool
+
+	// contact tracks the last successful and failed S3 calls
+	contact contactTracker
+
+	// metrics records credential cache lookups and S3 operation latencies
+	metrics *BackendMetrics
+
+	// clock tells whether bucket keys have expired
+	clock clock.Clock
 }
 
-// NewS3Service creates a new S3 service instance using MinIO SDK
-func NewS3Service(cfg *config.GarageConfig, adminService *GarageAdminService) *S3Service {
+// NewS3Service creates a new S3 service instance using MinIO SDK. It fails when the endpoint
+// cannot be used to build a client.
+func NewS3Service(cfg *config.GarageConfig, adminService *GarageAdminService, metrics *BackendMetrics, clk clock.Clock) (*S3Service, error) {
 	// Create MinIO client for Garage
 	// trim http or https from endpoint
 	if strings.HasPrefix(cfg.Endpoint, "http://") {
@@ -36,69 +68,345 @@ func NewS3Service(cfg *config.GarageConfig, adminService *GarageAdminService) *S
 		cfg.UseSSL = true
 	}
 
-	client, err := minio.New(cfg.Endpoint, &minio.Options{
-		//Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-		Secure: cfg.UseSSL,
-		Region: cfg.Region,
+	presignEndpoint, presignSecure := cfg.Endpoint, cfg.UseSSL
+	if cfg.PublicEndpoint != "" {
+		// The URL has already been validated when the configuration was loaded
+		publicURL, err := url.Parse(cfg.PublicEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public endpoint: %w", err)
+		}
+		presignEndpoint = publicURL.Host
+		presignSecure = publicURL.Scheme == "https"
+	}
+
+	s := &S3Service{
+		config:          cfg,
+		adminService:    adminService,
+		presignEndpoint: presignEndpoint,
+		presignSecure:   presignSecure,
+		metrics:         metrics,
+		clock:           clk,
+	}
+
+	// Build the default client now so that a malformed endpoint is reported at startup
+	if _, err := s.defaultClient(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// defaultClient returns the client using the default key, building it if there is none.
+// The default client is anonymous unless a default key is configured.
+func (s *S3Service) defaultClient() (*minio.Client, error) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	var defaultCreds *credentials.Credentials
+	if s.config.DefaultAccessKey != "" {
+		defaultCreds = credentials.NewStaticV4(s.config.DefaultAccessKey, s.config.DefaultSecretKey, "")
+	}
+
+	client, err := minio.New(s.config.Endpoint, &minio.Options{
+		Creds:        defaultCreds,
+		Secure:       s.config.UseSSL,
+		Region:       s.config.Region,
+		BucketLookup: bucketLookupType(s.config),
 	})
 	if err != nil {
-		panic(fmt.Errorf("failed to create MinIO client: %w", err))
+		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
 	}
 
-	return &S3Service{
-		client:       client,
-		config:       cfg,
-		adminService: adminService,
+	s.client = client
+	return client, nil
+}
+
+// resetDefaultClient drops the default client after a network failure, so the next call
+// builds a fresh one instead of reusing connections and addresses from before an outage
+func (s *S3Service) resetDefaultClient(client *minio.Client) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	// Another caller may already have replaced it
+	if s.client == client {
+		s.client = nil
+	}
+}
+
+// isNetworkError reports whether err means Garage could not be reached at all, such as a
+// failed DNS lookup or a refused connection. The MinIO client retries such failures until
+// the deadline of the request, so a deadline reached also counts.
+func isNetworkError(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// bucketLookupType maps force_path_style to the MinIO addressing mode. It is always explicit:
+// MinIO's auto mode only uses virtual-hosted-style for AWS hosts and would ignore the setting.
+func bucketLookupType(cfg *config.GarageConfig) minio.BucketLookupType {
+	if cfg.ForcePathStyle {
+		return minio.BucketLookupPath
+	}
+	return minio.BucketLookupDNS
+}
+
+// credentialCacheKey returns the cache key under which a bucket's resolved credentials are stored
+func credentialCacheKey(bucketName string) string {
+	return fmt.Sprintf("key:%s", bucketName)
+}
+
+// InvalidateBucketCredentials drops the cached credentials for a bucket so the next
+// operation resolves a fresh key from the Admin API
+func (s *S3Service) InvalidateBucketCredentials(bucketName string) {
+	s.credentialLookups.Forget(bucketName)
+	if utils.GlobalCache.Take(credentialCacheKey(bucketName)) != nil {
+		s.metrics.credentialInvalidated()
 	}
 }
 
+// WarmBucketCredentials resolves and caches the credentials of a bucket ahead of its first
+// S3 operation, dropping whatever was cached for an earlier bucket of the same name
+func (s *S3Service) WarmBucketCredentials(ctx context.Context, bucketName string) error {
+	s.InvalidateBucketCredentials(bucketName)
+	_, err := s.getBucketCredentials(ctx, bucketName)
+	return err
+}
+
 func (s *S3Service) getBucketCredentials(ctx context.Context, bucketName string) (*credentials.Credentials, error) {
-	cacheKey := fmt.Sprintf("key:%s", bucketName)
-	cacheData := utils.GlobalCache.Get(cacheKey)
+	cacheData := utils.GlobalCache.Get(credentialCacheKey(bucketName))
+	s.metrics.credentialLookup(cacheData != nil)
 
 	if cacheData != nil {
 		return cacheData.(*credentials.Credentials), nil
 	}
 
+	// Concurrent callers for the same bucket share one resolution, see sharedLookup
+	value, err := sharedLookup(ctx, &s.credentialLookups, bucketName, func(ctx context.Context) (interface{}, error) {
+		return s.resolveBucketCredentials(ctx, bucketName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*credentials.Credentials), nil
+}
+
+// resolveBucketCredentials picks a usable key for the bucket through the Admin API and caches it
+func (s *S3Service) resolveBucketCredentials(ctx context.Context, bucketName string) (*credentials.Credentials, error) {
 	// Get bucket info from Garage Admin API
 	bucketInfo, err := s.adminService.GetBucketInfoByAlias(ctx, bucketName)
+	var statusErr *APIStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketName)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bucket info: %w", err)
 	}
 
-	// Find a key with read and write permissions
-	var accessKeyID, secretAccessKey string
+	// Collect keys with read and write permissions, preferring owners
+	candidates := make([]models.BucketKeyInfo, 0, len(bucketInfo.Keys))
 	for _, keyInfo := range bucketInfo.Keys {
-		if !keyInfo.Permissions.Read || !keyInfo.Permissions.Write {
-			continue
+		if keyInfo.Permissions.Read && keyInfo.Permissions.Write {
+			candidates = append(candidates, keyInfo)
 		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Permissions.Owner && !candidates[j].Permissions.Owner
+	})
 
+	// Pick the first candidate that still exists and has not expired
+	var accessKeyID, secretAccessKey string
+	var expiration *time.Time
+	var lastErr error
+	for _, keyInfo := range candidates {
 		// Get key details with secret
 		keyDetails, err := s.adminService.GetKeyInfo(ctx, keyInfo.AccessKeyID, true)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get key info: %w", err)
+			// The key may have been deleted since the bucket info was fetched
+			lastErr = err
+			continue
+		}
+
+		if keyDetails.Expired || (keyDetails.Expiration != nil && clock.Expired(s.clock.Now(), *keyDetails.Expiration)) {
+			continue
 		}
 
 		if keyDetails.SecretAccessKey != nil {
 			accessKeyID = keyDetails.AccessKeyID
 			secretAccessKey = *keyDetails.SecretAccessKey
+			expiration = keyDetails.Expiration
 			break
 		}
 	}
 
 	if accessKeyID == "" || secretAccessKey == "" {
-		return nil, fmt.Errorf("no valid credentials found for bucket %s", bucketName)
+		if lastErr != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrNoBucketCredentials, bucketName, lastErr)
+		}
+		return nil, fmt.Errorf("%w %s", ErrNoBucketCredentials, bucketName)
 	}
 
 	// Create credentials
 	creds := credentials.NewStaticV4(accessKeyID, secretAccessKey, "")
 
-	// Cache credentials for 1 hour
-	utils.GlobalCache.Set(cacheKey, creds, time.Hour)
+	utils.GlobalCache.Set(credentialCacheKey(bucketName), creds, credentialTTL(s.clock.Now(), expiration))
 
 	return creds, nil
 }
 
+// credentialTTL is how long the credentials of a key expiring at expiration (nil: never) are
+// cached at now: 1 hour, or until the key expires if that is sooner
+func credentialTTL(now time.Time, expiration *time.Time) time.Duration {
+	ttl := time.Hour
+	if expiration != nil {
+		if untilExpiry := expiration.Sub(now); untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	return ttl
+}
+
+var (
+	// ErrBucketNotFound is returned when a bucket does not exist
+	ErrBucketNotFound = errors.New("bucket does not exist")
+
+	// ErrNoBucketCredentials is returned when no key with read and write access to a bucket
+	// is usable, so the proxy cannot reach it
+	ErrNoBucketCredentials = errors.New("no valid credentials found for bucket")
+)
+
+// IsBucketNotFound reports whether err means the bucket cannot be found: it does not exist,
+// or no key gives the proxy access to it
+func IsBucketNotFound(err error) bool {
+	if errors.Is(err, ErrBucketNotFound) || errors.Is(err, ErrNoBucketCredentials) {
+		return true
+	}
+	var errResponse minio.ErrorResponse
+	return errors.As(err, &errResponse) && errResponse.Code == "NoSuchBucket"
+}
+
+// IsObjectNotFound reports whether err means the object does not exist
+func IsObjectNotFound(err error) bool {
+	var errResponse minio.ErrorResponse
+	return errors.As(err, &errResponse) && errResponse.Code == "NoSuchKey"
+}
+
+// IsAccessDenied reports whether Garage refused an S3 request on a bucket that exists
+func IsAccessDenied(err error) bool {
+	var errResponse minio.ErrorResponse
+	return errors.As(err, &errResponse) && errResponse.Code == "AccessDenied"
+}
+
+// isCredentialError reports whether an S3 error indicates that the key used for the request
+// was rejected (deleted, expired or stripped of its permissions)
+func isCredentialError(err error) bool {
+	var errResponse minio.ErrorResponse
+	if !errors.As(err, &errResponse) {
+		return false
+	}
+
+	switch errResponse.Code {
+	case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "InvalidToken":
+		return true
+	}
+
+	return errResponse.StatusCode == http.StatusUnauthorized || errResponse.StatusCode == http.StatusForbidden
+}
+
+// throttleError marks S3 errors asking us to slow down (SlowDown, 429 or 503) with
+// utils.ErrThrottled and records them on ctx. Other errors are returned unchanged.
+func throttleError(ctx context.Context, err error) error {
+	var errResponse minio.ErrorResponse
+	if !errors.As(err, &errResponse) {
+		return err
+	}
+	if errResponse.Code != "SlowDown" && !isThrottleStatus(errResponse.StatusCode) {
+		return err
+	}
+
+	markThrottled(ctx, ThrottleSourceS3)
+	return fmt.Errorf("%w: %w", utils.ErrThrottled, err)
+}
+
+// observeS3 records the outcome of an S3 call for ContactStatus
+func (s *S3Service) observeS3(ctx context.Context, err error) {
+	statusCode := 0
+	var errResponse minio.ErrorResponse
+	if errors.As(err, &errResponse) {
+		statusCode = errResponse.StatusCode
+	}
+	s.contact.observe(ctx, reachedAPI(err, statusCode))
+}
+
+// ContactStatus returns when the S3 API last answered and last failed to, as observed on
+// regular traffic
+func (s *S3Service) ContactStatus() models.UpstreamContact {
+	return s.contact.status()
+}
+
+// withBucketClient runs fn with a bucket-specific client. If Garage rejects the cached
+// credentials, they are invalidated and fn is retried once with freshly resolved ones.
+func (s *S3Service) withBucketClient(ctx context.Context, operation, bucketName string, fn func(client *minio.Client) error) error {
+	client, err := s.getMinioClient(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() { s.metrics.s3OperationDone(operation, time.Since(start)) }()
+
+	err = fn(client)
+	s.observeS3(ctx, err)
+	if !isCredentialError(err) {
+		return err
+	}
+
+	logger.Warn().
+		Err(err).
+		Str("bucket", bucketName).
+		Msg("Bucket credentials rejected, resolving a fresh key")
+
+	s.InvalidateBucketCredentials(bucketName)
+
+	client, refreshErr := s.getMinioClient(ctx, bucketName)
+	if refreshErr != nil {
+		return fmt.Errorf("%w (credential refresh failed: %v)", err, refreshErr)
+	}
+
+	err = fn(client)
+	s.observeS3(ctx, err)
+	return err
+}
+
+// withReplayableBody behaves like withBucketClient for uploads. The credential retry is only
+// attempted when the body can be rewound, since a rejected upload may have consumed part of it.
+func (s *S3Service) withReplayableBody(ctx context.Context, operation, bucketName string, body io.Reader, fn func(client *minio.Client) error) error {
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		client, err := s.getMinioClient(ctx, bucketName)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		err = fn(client)
+		s.metrics.s3OperationDone(operation, time.Since(start))
+		s.observeS3(ctx, err)
+		return err
+	}
+
+	return s.withBucketClient(ctx, operation, bucketName, func(client *minio.Client) error {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind upload body: %w", err)
+		}
+		return fn(client)
+	})
+}
+
 // getMinioClient creates a MinIO client for a specific bucket with dynamic credentials
 func (s *S3Service) getMinioClient(ctx context.Context, bucketName string) (*minio.Client, error) {
 	creds, err := s.getBucketCredentials(ctx, bucketName)
@@ -108,9 +416,10 @@ func (s *S3Service) getMinioClient(ctx context.Context, bucketName string) (*min
 
 	// Create MinIO client with bucket-specific credentials
 	client, err := minio.New(s.config.Endpoint, &minio.Options{
-		Creds:  creds,
-		Secure: s.config.UseSSL,
-		Region: s.config.Region,
+		Creds:        creds,
+		Secure:       s.config.UseSSL,
+		Region:       s.config.Region,
+		BucketLookup: bucketLookupType(s.config),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MinIO client for bucket %s: %w", bucketName, err)
@@ -119,17 +428,108 @@ func (s *S3Service) getMinioClient(ctx context.Context, bucketName string) (*min
 	return client, nil
 }
 
-// ListBuckets retrieves all buckets from Garage
+// getPresignClient creates a MinIO client for a bucket that signs against the public endpoint.
+// The host is part of the SigV4 signature, so it has to be the one clients will connect to.
+func (s *S3Service) getPresignClient(ctx context.Context, bucketName string) (*minio.Client, error) {
+	creds, err := s.getBucketCredentials(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get credentials for bucket %s: %w", bucketName, err)
+	}
+
+	// The region must be set explicitly, otherwise MinIO would look up the bucket location
+	// through the public endpoint, which may not be reachable from here
+	client, err := minio.New(s.presignEndpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       s.presignSecure,
+		Region:       s.config.Region,
+		BucketLookup: bucketLookupType(s.config),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create presign client for bucket %s: %w", bucketName, err)
+	}
+
+	return client, nil
+}
+
+// TestKeyConnectivity checks a key end-to-end against the S3 API: ListBuckets and, when a
+// bucket is given, HeadBucket. The client is built for this call only and never cached,
+// so the secret does not outlive the request.
+func (s *S3Service) TestKeyConnectivity(ctx context.Context, accessKeyID, secretKey, bucketName string) ([]models.KeyTestCheck, error) {
+	client, err := minio.New(s.config.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKeyID, secretKey, ""),
+		Secure:       s.config.UseSSL,
+		Region:       s.config.Region,
+		BucketLookup: bucketLookupType(s.config),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client for key %s: %w", accessKeyID, err)
+	}
+
+	checks := []models.KeyTestCheck{
+		runKeyCheck("list_buckets", func() error {
+			_, err := client.ListBuckets(ctx)
+			return err
+		}),
+	}
+
+	if bucketName != "" {
+		checks = append(checks, runKeyCheck("head_bucket", func() error {
+			exists, err := client.BucketExists(ctx, bucketName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("bucket %s does not exist", bucketName)
+			}
+			return nil
+		}))
+	}
+
+	return checks, nil
+}
+
+// runKeyCheck times a single connectivity check and records its outcome
+func runKeyCheck(name string, fn func() error) models.KeyTestCheck {
+	start := time.Now()
+	err := fn()
+
+	check := models.KeyTestCheck{
+		Name:      name,
+		Success:   err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// ListBuckets retrieves the buckets the default S3 key can access, without statistics
 func (s *S3Service) ListBuckets(ctx context.Context) (*models.BucketListResponse, error) {
+	if !s.HasDefaultCredentials() {
+		return nil, errors.New("failed to list buckets: no default S3 key is configured")
+	}
+
+	client, err := s.defaultClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
 	var bucketInfos []minio.BucketInfo
 
 	// Call MinIO ListBuckets API with retry logic
-	retryConfig := utils.DefaultRetryConfig()
-	err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+	retryConfig := utils.ReadRetryConfig()
+	start := time.Now()
+	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
 		var listErr error
-		bucketInfos, listErr = s.client.ListBuckets(ctx)
-		return listErr
+		bucketInfos, listErr = client.ListBuckets(ctx)
+		return throttleError(ctx, listErr)
 	})
+	s.metrics.s3OperationDone("ListBuckets", time.Since(start))
+	s.observeS3(ctx, err)
+	if isNetworkError(err) {
+		s.resetDefaultClient(client)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list buckets: %w", err)
 	}
@@ -139,7 +539,7 @@ func (s *S3Service) ListBuckets(ctx context.Context) (*models.BucketListResponse
 	for _, bucket := range bucketInfos {
 		buckets = append(buckets, models.BucketInfo{
 			Name:         bucket.Name,
-			CreationDate: bucket.CreationDate,
+			CreationDate: utils.UTC(bucket.CreationDate),
 		})
 	}
 
@@ -149,18 +549,21 @@ func (s *S3Service) ListBuckets(ctx context.Context) (*models.BucketListResponse
 	}, nil
 }
 
+// HasDefaultCredentials reports whether a default S3 key is configured, without which
+// ListBuckets cannot serve as a fallback for the Admin API
+func (s *S3Service) HasDefaultCredentials() bool {
+	return s.config.DefaultAccessKey != ""
+}
+
 // CreateBucket creates a new bucket in Garage
 func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
-
 	// Call MinIO MakeBucket API with retry logic
 	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		return client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{
-			Region: s.config.Region,
+	err := s.withBucketClient(ctx, "CreateBucket", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{
+				Region: s.config.Region,
+			}))
 		})
 	})
 	if err != nil {
@@ -172,15 +575,12 @@ func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
 
 // DeleteBucket deletes a bucket from Garage
 func (s *S3Service) DeleteBucket(ctx context.Context, bucketName string) error {
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
-
 	// Call MinIO RemoveBucket API with retry logic
 	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		return client.RemoveBucket(ctx, bucketName)
+	err := s.withBucketClient(ctx, "DeleteBucket", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.RemoveBucket(ctx, bucketName))
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete bucket %s: %w", bucketName, err)
@@ -189,205 +589,475 @@ func (s *S3Service) DeleteBucket(ctx context.Context, bucketName string) error {
 	return nil
 }
 
-// ListObjects lists objects in a bucket with optional prefix filter and pagination
-func (s *S3Service) ListObjects(ctx context.Context, bucketName, prefix string, maxKeys int, continuationToken string) (*models.ObjectListResponse, error) {
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
+// s3MaxKeys is the largest page an S3 ListObjectsV2 call returns
+const s3MaxKeys = 1000
 
-	// Set default max keys if not specified
-	if maxKeys <= 0 {
-		maxKeys = 1000
+// ListObjects lists objects in a bucket with optional prefix filter and pagination. With
+// recursive set, every key under the prefix is listed instead of one folder level, and no
+// prefixes are returned.
+func (s *S3Service) ListObjects(ctx context.Context, bucketName, prefix string, recursive bool, maxKeys int, continuationToken string) (*models.ObjectListResponse, error) {
+	// S3 returns at most s3MaxKeys per page; callers resolve the page size from the pagination config
+	if maxKeys <= 0 || maxKeys > s3MaxKeys {
+		maxKeys = s3MaxKeys
 	}
 
-	// Create Core client for low-level API access
-	core := &minio.Core{Client: client}
-
-	// Use Core.ListObjectsV2 for proper pagination with continuation tokens
-	result, err := core.ListObjectsV2(
-		bucketName,
-		prefix,            // objectPrefix
-		"",                // startAfter (empty when using continuationToken)
-		continuationToken, // continuationToken (proper S3 token)
-		"/",               // delimiter (for folder listing)
-		maxKeys,           // maxkeys
-	)
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
 
+	var client *minio.Client
+	var result minio.ListBucketV2Result
+
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "ListObjects", bucketName, func(c *minio.Client) error {
+		client = c
+
+		// Create Core client for low-level API access
+		core := &minio.Core{Client: c}
+
+		// Use Core.ListObjectsV2 for proper pagination with continuation tokens
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var listErr error
+			result, listErr = core.ListObjectsV2(
+				bucketName,
+				prefix,            // objectPrefix
+				"",                // startAfter (empty when using continuationToken)
+				continuationToken, // continuationToken (proper S3 token)
+				delimiter,         // delimiter ("/" for folder listing)
+				maxKeys,           // maxkeys
+			)
+			return throttleError(ctx, listErr)
+		})
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
 	}
 
 	// Process objects from result.Contents
-	// Note: ListObjectsV2 doesn't return ContentType, so we need to fetch it separately
 	objects := make([]models.ObjectInfo, len(result.Contents))
+	for i, obj := range result.Contents {
+		objects[i] = objectInfoFromListing(obj)
+	}
+	fillContentTypes(ctx, client, bucketName, objects)
 
-	// Use goroutines to fetch ContentType concurrently for better performance
+	// Process folders from result.CommonPrefixes. S3 rolls each common prefix up once, on the
+	// page where its first key falls, so a folder is never split across pages.
+	prefixList := commonPrefixes(result.CommonPrefixes)
+
+	response := &models.ObjectListResponse{
+		Bucket:                bucketName,
+		Objects:               objects,
+		Prefixes:              prefixList,
+		Count:                 len(objects),
+		IsTruncated:           result.IsTruncated,
+		NextContinuationToken: result.NextContinuationToken,
+		Pagination: models.Pagination{
+			Limit:                 maxKeys,
+			HasMore:               result.IsTruncated,
+			NextContinuationToken: result.NextContinuationToken,
+		},
+	}
+	s.markPublicObjects(ctx, response)
+
+	return response, nil
+}
+
+// objectInfoFromListing converts an object as returned by a listing, which carries no
+// content type
+func objectInfoFromListing(obj minio.ObjectInfo) models.ObjectInfo {
+	return models.ObjectInfo{
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: utils.UTC(obj.LastModified),
+		ETag:         obj.ETag,
+		StorageClass: obj.StorageClass,
+	}
+}
+
+// fillContentTypes sets the content type of listed objects, which ListObjectsV2 does not
+// return, by stat'ing them concurrently. Objects whose stat fails keep an empty content type.
+func fillContentTypes(ctx context.Context, client *minio.Client, bucketName string, objects []models.ObjectInfo) {
 	type statResult struct {
 		index       int
 		contentType string
 		err         error
 	}
 
-	statChan := make(chan statResult, len(result.Contents))
-
-	for i, obj := range result.Contents {
+	statChan := make(chan statResult, len(objects))
+	for i := range objects {
 		go func(idx int, objKey string) {
-			// Fetch object metadata to get ContentType
 			stat, err := client.StatObject(ctx, bucketName, objKey, minio.StatObjectOptions{})
-			if err != nil {
-				// If StatObject fails, we still include the object but without ContentType
-				statChan <- statResult{index: idx, contentType: "", err: err}
-				return
-			}
-			statChan <- statResult{index: idx, contentType: stat.ContentType, err: nil}
-		}(i, obj.Key)
-
-		// Initialize the object with basic info from ListObjectsV2
-		objects[i] = models.ObjectInfo{
-			Key:          obj.Key,
-			Size:         obj.Size,
-			LastModified: obj.LastModified,
-			ETag:         obj.ETag,
-			StorageClass: obj.StorageClass,
-		}
+			statChan <- statResult{index: idx, contentType: stat.ContentType, err: err}
+		}(i, objects[i].Key)
 	}
 
-	// Collect results from goroutines
-	for range result.Contents {
+	for range objects {
 		res := <-statChan
 		if res.err == nil {
 			objects[res.index].ContentType = res.contentType
 		}
-		// If there was an error, ContentType remains empty, which is acceptable
 	}
 	close(statChan)
+}
 
-	// Process folders from result.CommonPrefixes
-	prefixList := make([]string, 0, len(result.CommonPrefixes))
-	for _, p := range result.CommonPrefixes {
-		prefixList = append(prefixList, p.Prefix)
+// ListObjectsPages lists objects page by page as Garage returns them, calling fn with each
+// page of objects and common prefixes. With recursive set, no delimiter is used and no
+// prefixes are returned. Listing stops at the first error from fn or when ctx is canceled.
+func (s *S3Service) ListObjectsPages(ctx context.Context, bucketName, prefix string, recursive bool, fn func(objects []models.ObjectInfo, prefixes []string) error) error {
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
 	}
 
-	return &models.ObjectListResponse{
-		Bucket:                bucketName,
-		Objects:               objects,
-		Prefixes:              prefixList,
-		Count:                 len(objects),
-		IsTruncated:           result.IsTruncated,
-		NextContinuationToken: result.NextContinuationToken,
-	}, nil
+	// Kept outside the closure so a credential retry resumes instead of replaying pages
+	continuationToken := ""
+
+	retryConfig := utils.ReadRetryConfig()
+	return s.withBucketClient(ctx, "ListObjectsPages", bucketName, func(client *minio.Client) error {
+		core := &minio.Core{Client: client}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var result minio.ListBucketV2Result
+			err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+				var listErr error
+				result, listErr = core.ListObjectsV2(bucketName, prefix, "", continuationToken, delimiter, s3MaxKeys)
+				return throttleError(ctx, listErr)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
+			}
+
+			objects := make([]models.ObjectInfo, 0, len(result.Contents))
+			for _, obj := range result.Contents {
+				objects = append(objects, objectInfoFromListing(obj))
+			}
+
+			prefixes := commonPrefixes(result.CommonPrefixes)
+
+			if err := fn(objects, prefixes); err != nil {
+				return err
+			}
+
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return nil
+			}
+			continuationToken = result.NextContinuationToken
+		}
+	})
 }
 
-// UploadObject uploads an object to a bucket
-func (s *S3Service) UploadObject(ctx context.Context, bucketName, key string, body io.Reader, contentType string) (*models.ObjectUploadResponse, error) {
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
+// commonPrefixes returns the folder prefixes of a listing page in lexicographic order, so
+// folders keep their place across refreshes whatever order the gateway returns them in
+func commonPrefixes(common []minio.CommonPrefix) []string {
+	prefixes := make([]string, 0, len(common))
+	for _, p := range common {
+		prefixes = append(prefixes, p.Prefix)
+	}
+	sort.Strings(prefixes)
+	return slices.Compact(prefixes)
+}
+
+// markPublicObjects flags listings of buckets with website access enabled and gives each
+// object its public URL. Bucket info comes from the cache, so this rarely costs an Admin API call.
+func (s *S3Service) markPublicObjects(ctx context.Context, response *models.ObjectListResponse) {
+	bucketInfo, err := s.adminService.GetCachedBucketInfoByAlias(ctx, response.Bucket)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
+		// The listing itself succeeded; only the public indicator is missing
+		logger.Debug().Err(err).Str("bucket", response.Bucket).Msg("Failed to get bucket info for website access")
+		return
 	}
+	if !bucketInfo.WebsiteAccess {
+		return
+	}
+
+	response.Public = true
+	for i := range response.Objects {
+		response.Objects[i].PublicURL = s.config.WebsiteURL(response.Bucket, response.Objects[i].Key)
+	}
+}
 
+// UploadObject uploads an object to a bucket, stored compressed with gzip when compress is set
+func (s *S3Service) UploadObject(ctx context.Context, bucketName, key string, body io.Reader, contentType string, compress bool) (*models.ObjectUploadResponse, error) {
 	// Upload options
 	opts := minio.PutObjectOptions{
 		ContentType: contentType,
 	}
 
 	var info minio.UploadInfo
+	var rawSize int64
 
 	// Call MinIO PutObject API with retry logic
 	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		var uploadErr error
-		info, uploadErr = client.PutObject(ctx, bucketName, key, body, -1, opts)
-		return uploadErr
+	err := s.withReplayableBody(ctx, "UploadObject", bucketName, body, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var uploadErr error
+			info, rawSize, uploadErr = putObject(ctx, client, bucketName, key, body, opts, compress)
+			return throttleError(ctx, uploadErr)
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload object %s to bucket %s: %w", key, bucketName, err)
 	}
 
-	return &models.ObjectUploadResponse{
+	response := &models.ObjectUploadResponse{
 		Bucket:      bucketName,
 		Key:         key,
 		ETag:        info.ETag,
-		Size:        info.Size,
+		Size:        rawSize,
+		StoredSize:  info.Size,
 		ContentType: contentType,
-	}, nil
+	}
+	if compress {
+		response.ContentEncoding = ContentEncodingGzip
+	}
+	return response, nil
 }
 
 // GetObject retrieves an object from a bucket
 func (s *S3Service) GetObject(ctx context.Context, bucketName, key string) (io.ReadCloser, *models.ObjectInfo, error) {
 	var object *minio.Object
+	var stat minio.ObjectInfo
 
 	// Call MinIO GetObject API with retry logic
-	retryConfig := utils.DefaultRetryConfig()
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetObject", bucketName, func(client *minio.Client) error {
+		err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var getErr error
+			object, getErr = client.GetObject(ctx, bucketName, key, minio.GetObjectOptions{})
+			return throttleError(ctx, getErr)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get object %s from bucket %s: %w", key, bucketName, err)
+		}
 
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		var getErr error
-		object, getErr = client.GetObject(ctx, bucketName, key, minio.GetObjectOptions{})
-		return getErr
+		// GetObject is lazy, so credential errors only surface once the object is stat'ed
+		stat, err = object.Stat()
+		if err != nil {
+			object.Close()
+			return fmt.Errorf("failed to get object info for %s in bucket %s: %w", key, bucketName, throttleError(ctx, err))
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get object %s from bucket %s: %w", key, bucketName, err)
+		return nil, nil, err
 	}
 
-	// Get object info
-	stat, err := object.Stat()
-	if err != nil {
-		object.Close()
-		return nil, nil, fmt.Errorf("failed to get object info for %s in bucket %s: %w", key, bucketName, err)
+	return object, objectInfoFromStat(key, stat), nil
+}
+
+// objectInfoFromStat converts a stat result, so downloads and metadata requests report the
+// same fields. Storage class and replication status are only set when Garage sends them.
+func objectInfoFromStat(key string, stat minio.ObjectInfo) *models.ObjectInfo {
+	return &models.ObjectInfo{
+		Key:               key,
+		Size:              stat.Size,
+		LastModified:      utils.UTC(stat.LastModified),
+		ETag:              stat.ETag,
+		ContentType:       stat.ContentType,
+		ContentEncoding:   stat.Metadata.Get("Content-Encoding"),
+		StorageClass:      stat.StorageClass,
+		ReplicationStatus: stat.ReplicationStatus,
+		Metadata:          stat.UserMetadata,
+	}
+}
+
+// GetObjectRange retrieves length bytes of an object starting at offset, or everything from
+// offset when length is negative. Callers resolve the range against GetObjectMetadata first,
+// so unlike GetObject no object info is returned.
+func (s *S3Service) GetObjectRange(ctx context.Context, bucketName, key string, offset, length int64) (io.ReadCloser, error) {
+	// MinIO reads "start to end of object" from an end of 0
+	end := int64(0)
+	if length >= 0 {
+		end = offset + length - 1
 	}
 
-	// Create object info
-	objectInfo := &models.ObjectInfo{
-		Key:          key,
-		Size:         stat.Size,
-		LastModified: stat.LastModified,
-		ETag:         stat.ETag,
-		ContentType:  stat.ContentType,
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, end); err != nil {
+		return nil, fmt.Errorf("invalid range for object %s: %w", key, err)
 	}
 
-	return object, objectInfo, nil
+	var object io.ReadCloser
+
+	// The low-level GetObject sends the range with the first request; the lazy
+	// minio.Object drops it when it is stat'ed before being read
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetObjectRange", bucketName, func(client *minio.Client) error {
+		err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var getErr error
+			object, _, _, getErr = minio.Core{Client: client}.GetObject(ctx, bucketName, key, opts)
+			return throttleError(ctx, getErr)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get range of object %s in bucket %s: %w", key, bucketName, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
 }
 
 // DeleteObject deletes an object from a bucket
 func (s *S3Service) DeleteObject(ctx context.Context, bucketName, key string) error {
 	// Call MinIO RemoveObject API with retry logic
-	client, err := s.getMinioClient(ctx, bucketName)
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "DeleteObject", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.RemoveObject(ctx, bucketName, key, minio.RemoveObjectOptions{}))
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
+		return fmt.Errorf("failed to delete object %s from bucket %s: %w", key, bucketName, err)
 	}
 
+	return nil
+}
+
+// CopyObject copies an object, within a bucket or to another one, using a server-side copy.
+// When the key of the destination bucket cannot read the source bucket, the object is
+// streamed through the proxy instead, keeping its content type, encoding and metadata.
+func (s *S3Service) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	// Call MinIO CopyObject API with retry logic
 	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		return client.RemoveObject(ctx, bucketName, key, minio.RemoveObjectOptions{})
+	err := s.withBucketClient(ctx, "CopyObject", dstBucket, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			_, copyErr := client.CopyObject(ctx,
+				minio.CopyDestOptions{Bucket: dstBucket, Object: dstKey},
+				minio.CopySrcOptions{Bucket: srcBucket, Object: srcKey},
+			)
+			return throttleError(ctx, copyErr)
+		})
 	})
+	if err != nil && srcBucket != dstBucket && IsAccessDenied(err) {
+		err = s.streamCopyObject(ctx, srcBucket, srcKey, dstBucket, dstKey)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete object %s from bucket %s: %w", key, bucketName, err)
+		return fmt.Errorf("failed to copy object %s/%s to %s/%s: %w", srcBucket, srcKey, dstBucket, dstKey, err)
 	}
 
 	return nil
 }
 
-// ObjectExists checks if an object exists in a bucket
-func (s *S3Service) ObjectExists(ctx context.Context, bucketName, key string) (bool, error) {
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
+// streamCopyObject copies an object by reading it with the key of its bucket and writing it
+// with the key of the destination bucket
+func (s *S3Service) streamCopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	object, info, err := s.GetObject(ctx, srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	opts := minio.PutObjectOptions{
+		ContentType:     info.ContentType,
+		ContentEncoding: info.ContentEncoding,
+		UserMetadata:    info.Metadata,
+	}
+	return s.withReplayableBody(ctx, "CopyObject", dstBucket, object, func(client *minio.Client) error {
+		_, putErr := client.PutObject(ctx, dstBucket, dstKey, object, info.Size, opts)
+		return throttleError(ctx, putErr)
+	})
+}
+
+// MoveObject moves an object to another key of its bucket with a server-side copy followed
+// by a deletion of the original
+func (s *S3Service) MoveObject(ctx context.Context, bucketName, srcKey, dstKey string) error {
+	if err := s.CopyObject(ctx, bucketName, srcKey, bucketName, dstKey); err != nil {
+		return err
+	}
+
+	if err := s.DeleteObject(ctx, bucketName, srcKey); err != nil {
+		return fmt.Errorf("object copied to %s but original not deleted: %w", dstKey, err)
+	}
+
+	return nil
+}
+
+// MovePrefix moves every object under srcPrefix to the same key under dstPrefix, one listing
+// page at a time, and returns how many were moved. dstPrefix must not lie under srcPrefix,
+// or moved objects would be listed again. A move that fails midway can be resumed by calling
+// it again, since only the objects left under srcPrefix are listed.
+func (s *S3Service) MovePrefix(ctx context.Context, bucketName, srcPrefix, dstPrefix string) (int, error) {
+	moved := 0
+	err := s.ListObjectsPages(ctx, bucketName, srcPrefix, true, func(page []models.ObjectInfo, _ []string) error {
+		for _, obj := range page {
+			dstKey := dstPrefix + strings.TrimPrefix(obj.Key, srcPrefix)
+			if err := s.CopyObject(ctx, bucketName, obj.Key, bucketName, dstKey); err != nil {
+				return err
+			}
+		}
+
+		// The originals of a page are deleted once all of them have been copied
+		keys := make([]string, 0, len(page))
+		for _, obj := range page {
+			keys = append(keys, obj.Key)
+		}
+		if err := s.DeleteMultipleObjects(ctx, bucketName, keys); err != nil {
+			return fmt.Errorf("objects copied under %s but originals not deleted: %w", dstPrefix, err)
+		}
+		moved += len(keys)
+		return nil
+	})
+	return moved, err
+}
+
+// ListObjectsRecursive lists up to limit objects under a prefix without grouping by folder.
+// The returned flag reports whether more objects were left unlisted.
+func (s *S3Service) ListObjectsRecursive(ctx context.Context, bucketName, prefix string, limit int) ([]models.ObjectInfo, bool, error) {
+	var objects []models.ObjectInfo
+	truncated := false
+
+	err := s.withBucketClient(ctx, "ListObjectsRecursive", bucketName, func(client *minio.Client) error {
+		objects = objects[:0]
+		truncated = false
+
+		// Cancel the listing goroutine when we stop reading early
+		listCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		for obj := range client.ListObjects(listCtx, bucketName, minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}) {
+			if obj.Err != nil {
+				return throttleError(ctx, obj.Err)
+			}
+			if len(objects) >= limit {
+				truncated = true
+				return nil
+			}
+			objects = append(objects, models.ObjectInfo{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				LastModified: utils.UTC(obj.LastModified),
+				ETag:         obj.ETag,
+				StorageClass: obj.StorageClass,
+			})
+		}
+		return nil
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
+		return nil, false, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
 	}
 
-	var statErr error
+	return objects, truncated, nil
+}
 
+// ObjectExists checks if an object exists in a bucket
+func (s *S3Service) ObjectExists(ctx context.Context, bucketName, key string) (bool, error) {
 	// Call MinIO StatObject API with retry logic
-	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		_, statErr = client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
-		return statErr
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "ObjectExists", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			_, statErr := client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+			return throttleError(ctx, statErr)
+		})
 	})
 
 	if err != nil {
@@ -401,121 +1071,282 @@ func (s *S3Service) ObjectExists(ctx context.Context, bucketName, key string) (b
 	return true, nil
 }
 
+// WebsiteURL returns the public website URL of an object, or "" if no website root domain is configured
+func (s *S3Service) WebsiteURL(bucketName, key string) string {
+	return s.config.WebsiteURL(bucketName, key)
+}
+
 // GetObjectMetadata retrieves metadata for an object without downloading it
 func (s *S3Service) GetObjectMetadata(ctx context.Context, bucketName, key string) (*models.ObjectInfo, error) {
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
-
 	var stat minio.ObjectInfo
 
 	// Call MinIO StatObject API with retry logic
-	retryConfig := utils.DefaultRetryConfig()
-	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
-		var statErr error
-		stat, statErr = client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
-		return statErr
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetObjectMetadata", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var statErr error
+			stat, statErr = client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+			return throttleError(ctx, statErr)
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metadata for object %s in bucket %s: %w", key, bucketName, err)
 	}
 
-	return &models.ObjectInfo{
-		Key:          key,
-		Size:         stat.Size,
-		LastModified: stat.LastModified,
-		ETag:         stat.ETag,
-		ContentType:  stat.ContentType,
-		StorageClass: stat.StorageClass,
-		Metadata:     stat.UserMetadata,
-	}, nil
+	info := objectInfoFromStat(key, stat)
+
+	// Tags take a request of their own, only made when the object reports having some
+	if stat.UserTagCount > 0 {
+		if objectTags, err := s.GetObjectTags(ctx, bucketName, key); err == nil {
+			info.Tags = objectTags
+		}
+	}
+
+	return info, nil
 }
 
-// DeleteMultipleObjects deletes multiple objects from a bucket
+// metadataBatchWorkers bounds the concurrent StatObject calls of GetObjectsMetadata
+const metadataBatchWorkers = 16
+
+// GetObjectsMetadata returns the metadata of several objects, one result per key in the
+// order given. The bucket credentials are resolved once for the whole batch; a missing or
+// unreadable object only fails its own result.
+func (s *S3Service) GetObjectsMetadata(ctx context.Context, bucketName string, keys []string) ([]models.ObjectMetadataResult, error) {
+	results := make([]models.ObjectMetadataResult, len(keys))
+
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetObjectsMetadata", bucketName, func(client *minio.Client) error {
+		// Each result has its own slot, so workers never share state
+		var group errgroup.Group
+		group.SetLimit(metadataBatchWorkers)
+		for i, key := range keys {
+			group.Go(func() error {
+				var stat minio.ObjectInfo
+				err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+					var statErr error
+					stat, statErr = client.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+					return throttleError(ctx, statErr)
+				})
+
+				results[i] = models.ObjectMetadataResult{Key: key}
+				switch {
+				case isCredentialError(err):
+					// Rejected credentials fail every key; let withBucketClient refresh them
+					return err
+				case err != nil:
+					results[i].Error = err.Error()
+				default:
+					results[i].Object = objectInfoFromStat(key, stat)
+				}
+				return nil
+			})
+		}
+		return group.Wait()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object metadata in bucket %s: %w", bucketName, err)
+	}
+
+	return results, nil
+}
+
+// DeleteObjectsError is returned when some objects of a batch delete were not deleted
+type DeleteObjectsError struct {
+	Failed int   // Objects not deleted
+	Total  int   // Objects in the batch
+	Err    error // The first failure
+}
+
+// Error implements the error interface
+func (e *DeleteObjectsError) Error() string {
+	return fmt.Sprintf("%d of %d objects not deleted: %v", e.Failed, e.Total, e.Err)
+}
+
+// Unwrap returns the first failure
+func (e *DeleteObjectsError) Unwrap() error {
+	return e.Err
+}
+
+// DeleteMultipleObjects deletes multiple objects from a bucket. When some of them are not
+// deleted, the error is a *DeleteObjectsError counting them.
 func (s *S3Service) DeleteMultipleObjects(ctx context.Context, bucketName string, keys []string) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
-		return fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
-	}
+	return s.withBucketClient(ctx, "DeleteMultipleObjects", bucketName, func(client *minio.Client) error {
+		// Create channel for objects to delete
+		objectsCh := make(chan minio.ObjectInfo)
+
+		// Send objects to delete in a goroutine
+		go func() {
+			defer close(objectsCh)
+			for _, key := range keys {
+				select {
+				case objectsCh <- minio.ObjectInfo{Key: key}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
 
-	// Create channel for objects to delete
-	objectsCh := make(chan minio.ObjectInfo)
+		// Call MinIO RemoveObjects API (batch delete)
+		errorCh := client.RemoveObjects(ctx, bucketName, objectsCh, minio.RemoveObjectsOptions{})
 
-	// Send objects to delete in a goroutine
-	go func() {
-		defer close(objectsCh)
-		for _, key := range keys {
-			objectsCh <- minio.ObjectInfo{
-				Key: key,
+		// Check for errors, draining the channel so the sender goroutines can finish
+		var firstErr error
+		failed := 0
+		for err := range errorCh {
+			if err.Err == nil {
+				continue
+			}
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to delete object %s from bucket %s: %w", err.ObjectName, bucketName, throttleError(ctx, err.Err))
 			}
 		}
-	}()
 
-	// Call MinIO RemoveObjects API (batch delete)
-	errorCh := client.RemoveObjects(ctx, bucketName, objectsCh, minio.RemoveObjectsOptions{})
+		if firstErr != nil {
+			return &DeleteObjectsError{Failed: failed, Total: len(keys), Err: firstErr}
+		}
+		return nil
+	})
+}
+
+// PrefixUsage counts the objects under a prefix and their total size by listing them all.
+// An empty prefix covers the whole bucket.
+func (s *S3Service) PrefixUsage(ctx context.Context, bucketName, prefix string) (objects, bytes int64, err error) {
+	err = s.ListObjectsPages(ctx, bucketName, prefix, true, func(page []models.ObjectInfo, _ []string) error {
+		for _, obj := range page {
+			objects++
+			bytes += obj.Size
+		}
+		return nil
+	})
+	return objects, bytes, err
+}
+
+// DeletePrefix permanently deletes every object under a prefix, one listing page at a time,
+// and returns how many were deleted. An empty prefix empties the whole bucket. Continuation
+// tokens hold the last listed key, so deleting a page does not disturb the listing.
+func (s *S3Service) DeletePrefix(ctx context.Context, bucketName, prefix string) (int, error) {
+	deleted := 0
+	err := s.ListObjectsPages(ctx, bucketName, prefix, true, func(page []models.ObjectInfo, _ []string) error {
+		keys := make([]string, 0, len(page))
+		for _, obj := range page {
+			keys = append(keys, obj.Key)
+		}
+		if err := s.DeleteMultipleObjects(ctx, bucketName, keys); err != nil {
+			return err
+		}
+		deleted += len(keys)
+		return nil
+	})
+	return deleted, err
+}
 
-	// Check for errors
-	for err := range errorCh {
-		if err.Err != nil {
-			return fmt.Errorf("failed to delete object %s from bucket %s: %w", err.ObjectName, bucketName, err.Err)
+// EmptyBucket deletes every object of a bucket, then aborts its incomplete multipart uploads,
+// whose parts would otherwise keep Garage from deleting the bucket. progress, when set, is
+// called with the number of objects deleted so far after each listing page; an error from it
+// stops the emptying.
+func (s *S3Service) EmptyBucket(ctx context.Context, bucketName string, progress func(deleted int) error) (deleted, aborted int, err error) {
+	err = s.ListObjectsPages(ctx, bucketName, "", true, func(page []models.ObjectInfo, _ []string) error {
+		keys := make([]string, 0, len(page))
+		for _, obj := range page {
+			keys = append(keys, obj.Key)
+		}
+		if err := s.DeleteMultipleObjects(ctx, bucketName, keys); err != nil {
+			return err
 		}
+		deleted += len(keys)
+		if progress != nil {
+			return progress(deleted)
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, 0, err
 	}
 
-	return nil
+	aborted, err = s.AbortIncompleteUploads(ctx, bucketName, "", time.Time{})
+	return deleted, aborted, err
 }
 
-// GetPresignedURL generates a pre-signed URL for temporary access to an object
+// HTTP methods presigned URLs can be generated for
+const (
+	PresignMethodGet    = "GET"
+	PresignMethodPut    = "PUT"
+	PresignMethodDelete = "DELETE"
+)
+
+// GetPresignedURL generates a pre-signed URL for temporary access to an object with the
+// given method: GET to download it, PUT to upload it or DELETE to delete it.
 // This is useful for sharing files without exposing credentials
-func (s *S3Service) GetPresignedURL(ctx context.Context, bucketName, key string, expiresIn time.Duration) (string, error) {
-	// Get bucket-specific MinIO client
-	client, err := s.getMinioClient(ctx, bucketName)
+func (s *S3Service) GetPresignedURL(ctx context.Context, bucketName, key, method string, expiresIn time.Duration) (string, error) {
+	// Get bucket-specific client signing against the public endpoint
+	client, err := s.getPresignClient(ctx, bucketName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get MinIO client for bucket %s: %w", bucketName, err)
+		return "", fmt.Errorf("failed to get presign client for bucket %s: %w", bucketName, err)
 	}
 
 	var presignedURL *url.URL
 
-	// Generate presigned GET URL with retry logic
+	// Generate presigned URL with retry logic
 	retryConfig := utils.DefaultRetryConfig()
 	err = utils.RetryWithBackoff(ctx, retryConfig, func() error {
 		var presignErr error
-		presignedURL, presignErr = client.PresignedGetObject(ctx, bucketName, key, expiresIn, nil)
+		presignedURL, presignErr = client.Presign(ctx, method, bucketName, key, expiresIn, nil)
 		return presignErr
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL for %s/%s: %w", bucketName, key, err)
+		return "", fmt.Errorf("failed to generate presigned %s URL for %s/%s: %w", method, bucketName, key, err)
 	}
 
 	return presignedURL.String(), nil
 }
 
+// GetPresignedURLs generates presigned GET URLs for several objects of a bucket, resolving
+// the bucket credentials once. Presigning happens locally, so Garage is not called.
+func (s *S3Service) GetPresignedURLs(ctx context.Context, bucketName string, keys []string, expiresIn time.Duration) ([]string, error) {
+	client, err := s.getPresignClient(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presign client for bucket %s: %w", bucketName, err)
+	}
+
+	urls := make([]string, len(keys))
+	for i, key := range keys {
+		presignedURL, err := client.PresignedGetObject(ctx, bucketName, key, expiresIn, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate presigned URL for %s/%s: %w", bucketName, key, err)
+		}
+		urls[i] = presignedURL.String()
+	}
+
+	return urls, nil
+}
+
 // UploadResult represents the result of a single file upload
 type UploadResult struct {
 	Key         string
 	Success     bool
 	Error       error
 	ETag        string
-	Size        int64
+	Size        int64 // Size of the file as uploaded
+	StoredSize  int64 // Size of the object, smaller than Size when compressed
 	ContentType string
 }
 
+// UploadMultipleObjects uploads files one after the other, stored compressed with gzip when
+// compress is set
 func (s *S3Service) UploadMultipleObjects(ctx context.Context, bucketName string, files []struct {
 	Key         string
 	Body        io.Reader
 	ContentType string
-}) []UploadResult {
+}, compress bool) []UploadResult {
 	results := make([]UploadResult, len(files))
 
-	// Get bucket-specific MinIO client once for all uploads
-	client, err := s.getMinioClient(ctx, bucketName)
-	if err != nil {
+	// Resolve the bucket credentials once up front for all uploads
+	if _, err := s.getMinioClient(ctx, bucketName); err != nil {
 		// If we can't get the client, all uploads fail
 		for i := range files {
 			results[i] = UploadResult{
@@ -535,7 +1366,13 @@ func (s *S3Service) UploadMultipleObjects(ctx context.Context, bucketName string
 		}
 
 		// Attempt upload
-		info, err := client.PutObject(ctx, bucketName, file.Key, file.Body, -1, opts)
+		var info minio.UploadInfo
+		var rawSize int64
+		err := s.withReplayableBody(ctx, "UploadMultipleObjects", bucketName, file.Body, func(client *minio.Client) error {
+			var uploadErr error
+			info, rawSize, uploadErr = putObject(ctx, client, bucketName, file.Key, file.Body, opts, compress)
+			return uploadErr
+		})
 		if err != nil {
 			results[i] = UploadResult{
 				Key:         file.Key,
@@ -551,7 +1388,8 @@ func (s *S3Service) UploadMultipleObjects(ctx context.Context, bucketName string
 			Success:     true,
 			Error:       nil,
 			ETag:        info.ETag,
-			Size:        info.Size,
+			Size:        rawSize,
+			StoredSize:  info.Size,
 			ContentType: file.ContentType,
 		}
 	}
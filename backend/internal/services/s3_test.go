@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestIsCredentialError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "deleted key", err: minio.ErrorResponse{Code: "InvalidAccessKeyId", StatusCode: http.StatusForbidden}, want: true},
+		{name: "revoked permission", err: minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, want: true},
+		{name: "wrong secret", err: minio.ErrorResponse{Code: "SignatureDoesNotMatch", StatusCode: http.StatusForbidden}, want: true},
+		{name: "unauthorized without code", err: minio.ErrorResponse{StatusCode: http.StatusUnauthorized}, want: true},
+		{name: "wrapped", err: fmt.Errorf("stat: %w", minio.ErrorResponse{Code: "AccessDenied"}), want: true},
+		{name: "missing object", err: minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound}},
+		{name: "throttled", err: minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}},
+		{name: "not an S3 error", err: errors.New("connection reset")},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCredentialError(tt.err); got != tt.want {
+				t.Errorf("isCredentialError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBucketCredentialsPrefersUsableOwnerKeys(t *testing.T) {
+	g := newFakeGarage(t)
+	g.AddBucket("photos")
+	expired := g.clock.Now().Add(-time.Minute)
+	g.GrantKey("photos", "GKplain", false, nil)
+	g.GrantKey("photos", "GKexpired", true, &expired)
+	g.GrantKey("photos", "GKowner", true, nil)
+
+	creds, err := g.s3.resolveBucketCredentials(context.Background(), "photos")
+	if err != nil {
+		t.Fatalf("resolveBucketCredentials failed: %v", err)
+	}
+	value, err := creds.GetWithContext(nil)
+	if err != nil || value.AccessKeyID != "GKowner" {
+		t.Errorf("credentials = %+v, %v, want the unexpired owner key GKowner", value, err)
+	}
+}
+
+func TestWithBucketClientRevokedKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		revoke   func(g *fakeGarage)
+		wantKeys []string // Access keys of the S3 requests made after the revocation
+		wantErr  bool
+	}{
+		{
+			name:     "deleted key is replaced by the next candidate",
+			revoke:   func(g *fakeGarage) { g.DeleteKey("GK1") },
+			wantKeys: []string{"GK1", "GK2"},
+		},
+		{
+			name: "retried only once when the fresh key is rejected too",
+			revoke: func(g *fakeGarage) {
+				g.DenyKey("GK1")
+				g.DenyKey("GK2")
+			},
+			wantKeys: []string{"GK1", "GK1"},
+			wantErr:  true,
+		},
+		{
+			name: "no key left",
+			revoke: func(g *fakeGarage) {
+				g.DeleteKey("GK1")
+				g.DeleteKey("GK2")
+			},
+			wantKeys: []string{"GK1"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newFakeGarage(t)
+			g.AddBucket("photos")
+			g.GrantKey("photos", "GK1", true, nil)
+			g.GrantKey("photos", "GK2", false, nil)
+			g.PutObject("photos", "cat.jpg", "image/jpeg", []byte("meow"))
+
+			// The first call resolves and caches the owner key
+			if _, err := g.s3.GetObjectMetadata(context.Background(), "photos", "cat.jpg"); err != nil {
+				t.Fatalf("GetObjectMetadata failed: %v", err)
+			}
+			before := len(g.S3KeysUsed())
+
+			tt.revoke(g)
+			_, err := g.s3.GetObjectMetadata(context.Background(), "photos", "cat.jpg")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetObjectMetadata after revocation: err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := g.S3KeysUsed()[before:]; !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("S3 requests after revocation signed with %v, want %v", got, tt.wantKeys)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// The fresh key is cached for the calls that follow
+			before = len(g.S3KeysUsed())
+			if _, err := g.s3.GetObjectMetadata(context.Background(), "photos", "cat.jpg"); err != nil {
+				t.Fatalf("GetObjectMetadata with the fresh key failed: %v", err)
+			}
+			if got := g.S3KeysUsed()[before:]; !slices.Equal(got, []string{"GK2"}) {
+				t.Errorf("S3 requests with the fresh key signed with %v, want [GK2]", got)
+			}
+		})
+	}
+}
+
+func TestAddressingStyle(t *testing.T) {
+	tests := []struct {
+		name          string
+		pathStyle     bool
+		wantHost      string
+		wantPublicURL string
+		wantPath      string
+	}{
+		{
+			name:          "path style",
+			pathStyle:     true,
+			wantHost:      "s3.garage.internal:3900",
+			wantPublicURL: "https://s3.example.com/photos/albums/cat.jpg",
+			wantPath:      "/photos/albums/cat.jpg",
+		},
+		{
+			name:          "virtual-hosted style",
+			wantHost:      "photos.s3.garage.internal:3900",
+			wantPublicURL: "https://photos.s3.example.com/albums/cat.jpg",
+			wantPath:      "/albums/cat.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newFakeGarage(t)
+			g.AddBucket("photos")
+			g.GrantKey("photos", "GK1", true, nil)
+
+			s3, err := NewS3Service(&config.GarageConfig{
+				Endpoint:         "http://s3.garage.internal:3900",
+				PublicEndpoint:   "https://s3.example.com",
+				Region:           "garage",
+				ForcePathStyle:   tt.pathStyle,
+				DefaultAccessKey: "GKdefault",
+				DefaultSecretKey: "secret",
+			}, g.admin, NewBackendMetrics(), g.clock)
+			if err != nil {
+				t.Fatalf("NewS3Service failed: %v", err)
+			}
+
+			// Requests on the internal endpoint, whether made with the default key or a bucket key
+			bucketClient, err := s3.getMinioClient(context.Background(), "photos")
+			if err != nil {
+				t.Fatalf("getMinioClient failed: %v", err)
+			}
+			defaultClient, err := s3.defaultClient()
+			if err != nil {
+				t.Fatalf("defaultClient failed: %v", err)
+			}
+			for name, client := range map[string]*minio.Client{"bucket": bucketClient, "default": defaultClient} {
+				requestURL, err := client.PresignedGetObject(context.Background(), "photos", "albums/cat.jpg", time.Minute, nil)
+				if err != nil {
+					t.Fatalf("%s client: presign failed: %v", name, err)
+				}
+				if requestURL.Host != tt.wantHost || requestURL.Path != tt.wantPath {
+					t.Errorf("%s client: request to %s%s, want %s%s", name, requestURL.Host, requestURL.Path, tt.wantHost, tt.wantPath)
+				}
+			}
+
+			// Presigned URLs handed out, on the public endpoint
+			presigned, err := s3.GetPresignedURL(context.Background(), "photos", "albums/cat.jpg", http.MethodGet, time.Minute)
+			if err != nil {
+				t.Fatalf("GetPresignedURL failed: %v", err)
+			}
+			if base, _, _ := strings.Cut(presigned, "?"); base != tt.wantPublicURL {
+				t.Errorf("presigned URL = %s, want %s", base, tt.wantPublicURL)
+			}
+		})
+	}
+}
+
+func TestListObjectsPrefixesAreStable(t *testing.T) {
+	g := newFakeGarage(t)
+	g.AddBucket("docs")
+	g.GrantKey("docs", "GKowner", true, nil)
+	for _, key := range []string{
+		"a.txt", "zeta/1.txt", "alpha/x/1.txt", "alpha/y.txt", "beta/1.txt", "beta/2.txt",
+		"Gamma/1.txt", "m.txt", "delta/deep/er/1.txt", "epsilon/1.txt",
+	} {
+		g.PutObject("docs", key, "text/plain", []byte(key))
+	}
+	// Answer with prefixes out of order, as some gateways do
+	g.ReversePrefixes()
+	wantPrefixes := []string{"Gamma/", "alpha/", "beta/", "delta/", "epsilon/", "zeta/"}
+
+	t.Run("ListObjects", func(t *testing.T) {
+		for range 3 {
+			list, err := g.s3.ListObjects(context.Background(), "docs", "", false, 1000, "")
+			if err != nil {
+				t.Fatalf("ListObjects failed: %v", err)
+			}
+			if !slices.Equal(list.Prefixes, wantPrefixes) {
+				t.Fatalf("prefixes = %v, want %v", list.Prefixes, wantPrefixes)
+			}
+		}
+	})
+
+	t.Run("ListObjects pages", func(t *testing.T) {
+		var prefixes []string
+		token := ""
+		for page := 0; ; page++ {
+			list, err := g.s3.ListObjects(context.Background(), "docs", "", false, 3, token)
+			if err != nil {
+				t.Fatalf("ListObjects failed: %v", err)
+			}
+			if !slices.IsSorted(list.Prefixes) {
+				t.Errorf("page %d prefixes %v are not sorted", page, list.Prefixes)
+			}
+			prefixes = append(prefixes, list.Prefixes...)
+			if !list.IsTruncated {
+				break
+			}
+			token = list.NextContinuationToken
+		}
+		// Every folder is listed once, on a single page
+		slices.Sort(prefixes)
+		if !slices.Equal(prefixes, wantPrefixes) {
+			t.Errorf("prefixes over all pages = %v, want %v", prefixes, wantPrefixes)
+		}
+	})
+
+	t.Run("ListObjectsPages", func(t *testing.T) {
+		for range 3 {
+			var prefixes []string
+			err := g.s3.ListObjectsPages(context.Background(), "docs", "", false, func(_ []models.ObjectInfo, page []string) error {
+				if !slices.IsSorted(page) {
+					t.Errorf("page prefixes %v are not sorted", page)
+				}
+				prefixes = append(prefixes, page...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ListObjectsPages failed: %v", err)
+			}
+			if !slices.Equal(prefixes, wantPrefixes) {
+				t.Fatalf("prefixes = %v, want %v", prefixes, wantPrefixes)
+			}
+		}
+	})
+
+	t.Run("nested prefix", func(t *testing.T) {
+		list, err := g.s3.ListObjects(context.Background(), "docs", "alpha/", false, 1000, "")
+		if err != nil {
+			t.Fatalf("ListObjects failed: %v", err)
+		}
+		if want := []string{"alpha/x/"}; !slices.Equal(list.Prefixes, want) {
+			t.Errorf("prefixes = %v, want %v", list.Prefixes, want)
+		}
+		if len(list.Objects) != 1 || list.Objects[0].Key != "alpha/y.txt" {
+			t.Errorf("objects = %v, want alpha/y.txt", list.Objects)
+		}
+	})
+}
+
+func TestNewS3ServiceRejectsBadEndpoints(t *testing.T) {
+	for _, endpoint := range []string{
+		"localhost:3900/s3",
+		"http://localhost:3900/s3",
+		"local host:3900",
+		"localhost:port",
+	} {
+		t.Run(endpoint, func(t *testing.T) {
+			if _, err := NewS3Service(&config.GarageConfig{Endpoint: endpoint, Region: "garage"}, nil, NewBackendMetrics(), clock.Real); err == nil {
+				t.Errorf("NewS3Service(%q) succeeded", endpoint)
+			}
+		})
+	}
+}
+
+// listBucketsResult is what Garage answers to ListBuckets with one bucket
+const listBucketsResult = `<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+<Owner><ID>GKdefault</ID></Owner>
+<Buckets><Bucket><Name>photos</Name><CreationDate>2026-01-01T00:00:00.000Z</CreationDate></Bucket></Buckets>
+</ListAllMyBucketsResult>`
+
+func TestDefaultClientRecoversFromOutage(t *testing.T) {
+	// Reserve an address nothing listens on until the outage ends
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	s3, err := NewS3Service(&config.GarageConfig{
+		Endpoint:         "http://" + addr,
+		Region:           "garage",
+		ForcePathStyle:   true,
+		DefaultAccessKey: "GKdefault",
+		DefaultSecretKey: "secret",
+	}, nil, NewBackendMetrics(), clock.Real)
+	if err != nil {
+		t.Fatalf("NewS3Service failed for an unreachable endpoint: %v", err)
+	}
+	unreachableClient, err := s3.defaultClient()
+	if err != nil {
+		t.Fatalf("defaultClient failed: %v", err)
+	}
+
+	// The MinIO client keeps retrying until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := s3.ListBuckets(ctx); err == nil {
+		t.Fatal("ListBuckets succeeded while Garage is unreachable")
+	}
+	s3.clientMu.Lock()
+	dropped := s3.client == nil
+	s3.clientMu.Unlock()
+	if !dropped {
+		t.Error("default client was kept after a network failure")
+	}
+
+	// Garage comes back on the same address
+	listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("address %s was taken meanwhile: %v", addr, err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(listBucketsResult))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { server.Close() })
+
+	buckets, err := s3.ListBuckets(context.Background())
+	if err != nil {
+		t.Fatalf("ListBuckets failed after Garage came back: %v", err)
+	}
+	if len(buckets.Buckets) != 1 || buckets.Buckets[0].Name != "photos" {
+		t.Errorf("buckets = %+v, want photos", buckets.Buckets)
+	}
+	if client, _ := s3.defaultClient(); client == unreachableClient {
+		t.Error("default client was not rebuilt after the outage")
+	}
+}
+
+func TestCredentialTTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		expiration := now.Add(d)
+		return &expiration
+	}
+
+	tests := []struct {
+		name       string
+		expiration *time.Time
+		want       time.Duration
+	}{
+		{name: "key without expiration", expiration: nil, want: time.Hour},
+		{name: "key expiring after the hour", expiration: at(2 * time.Hour), want: time.Hour},
+		{name: "key expiring within the hour", expiration: at(10 * time.Minute), want: 10 * time.Minute},
+		{name: "key expiring in a nanosecond", expiration: at(time.Nanosecond), want: time.Nanosecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credentialTTL(now, tt.expiration); got != tt.want {
+				t.Errorf("credentialTTL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBucketCredentialsKeyExpiry(t *testing.T) {
+	expiration := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "just before expiry", now: expiration.Add(-time.Nanosecond)},
+		{name: "at expiry", now: expiration, wantErr: ErrNoBucketCredentials},
+		{name: "after expiry", now: expiration.Add(time.Second), wantErr: ErrNoBucketCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useManualCache(t, tt.now)
+
+			secret := "secret"
+			admin := newTestAdminService(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/GetBucketInfo":
+					_ = json.NewEncoder(w).Encode(models.GarageBucketInfo{ID: "b1", Keys: []models.BucketKeyInfo{{
+						AccessKeyID: "GK1",
+						Permissions: models.BucketKeyPermission{Read: true, Write: true},
+					}}})
+				case "/v2/GetKeyInfo":
+					_ = json.NewEncoder(w).Encode(models.GarageKeyInfo{AccessKeyID: "GK1", SecretAccessKey: &secret, Expiration: &expiration})
+				default:
+					http.NotFound(w, r)
+				}
+			}, 0)
+			s3, err := NewS3Service(&config.GarageConfig{Endpoint: "localhost:3900"}, admin, NewBackendMetrics(), clock.NewManual(tt.now))
+			if err != nil {
+				t.Fatalf("NewS3Service failed: %v", err)
+			}
+
+			creds, err := s3.resolveBucketCredentials(context.Background(), "photos")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			value, err := creds.GetWithContext(nil)
+			if err != nil || value.AccessKeyID != "GK1" {
+				t.Errorf("credentials = %+v, %v, want key GK1", value, err)
+			}
+		})
+	}
+}
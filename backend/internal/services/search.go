@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// SearchObjects lists the objects under prefix, at any depth, whose key satisfies match and
+// that are within filter. Like ListObjectsFiltered, Garage pages are scanned until maxKeys
+// objects match or FilterScanBudget keys have been scanned, so a page may hold fewer
+// matches than requested, or none, while more follow; the continuation token holds the last
+// scanned key. No folders are returned.
+func (s *S3Service) SearchObjects(ctx context.Context, bucketName, prefix string, maxKeys int, continuationToken string, match func(key string) bool, filter ObjectFilter) (*models.ObjectListResponse, error) {
+	if maxKeys <= 0 || maxKeys > s3MaxKeys {
+		maxKeys = s3MaxKeys
+	}
+
+	startAfter, err := decodeFilterToken(continuationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *minio.Client
+	objects := make([]models.ObjectInfo, 0, maxKeys)
+	scanned := 0
+	lastKey := ""
+	truncated := false
+
+	retryConfig := utils.ReadRetryConfig()
+	err = s.withBucketClient(ctx, "SearchObjects", bucketName, func(c *minio.Client) error {
+		client = c
+		core := &minio.Core{Client: c}
+
+		objects = objects[:0]
+		scanned, lastKey, truncated = 0, startAfter, false
+		pageToken := ""
+
+		for {
+			var result minio.ListBucketV2Result
+			err := utils.RetryWithBackoff(ctx, retryConfig, func() error {
+				var listErr error
+				// Without a delimiter every key under the prefix is listed, in key order
+				result, listErr = core.ListObjectsV2(bucketName, prefix, startAfter, pageToken, "", s3MaxKeys)
+				return throttleError(ctx, listErr)
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, content := range result.Contents {
+				if len(objects) == maxKeys || scanned == FilterScanBudget {
+					truncated = true
+					return nil
+				}
+
+				obj := objectInfoFromListing(content)
+				scanned++
+				lastKey = obj.Key
+				if match(obj.Key) && filter.Matches(obj) {
+					objects = append(objects, obj)
+				}
+			}
+
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return nil
+			}
+			pageToken = result.NextContinuationToken
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search objects in bucket %s: %w", bucketName, err)
+	}
+
+	fillContentTypes(ctx, client, bucketName, objects)
+
+	nextToken := ""
+	if truncated {
+		nextToken = encodeFilterToken(lastKey)
+	}
+
+	response := &models.ObjectListResponse{
+		Bucket:                bucketName,
+		Objects:               objects,
+		Prefixes:              []string{},
+		Count:                 len(objects),
+		Scanned:               scanned,
+		IsTruncated:           truncated,
+		NextContinuationToken: nextToken,
+		Pagination: models.Pagination{
+			Limit:                 maxKeys,
+			HasMore:               truncated,
+			NextContinuationToken: nextToken,
+		},
+	}
+	s.markPublicObjects(ctx, response)
+
+	return response, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+var (
+	// ErrSelfServiceKeyLimit is returned when the user already holds max_keys_per_user keys
+	ErrSelfServiceKeyLimit = errors.New("self-service key limit reached")
+
+	// ErrNoEntitledBuckets is returned when none of the user's roles is mapped to a bucket
+	ErrNoEntitledBuckets = errors.New("no buckets are mapped to the user's roles")
+
+	// ErrKeyNotOwned is returned when a user tries to manage a key that was not issued to them
+	ErrKeyNotOwned = errors.New("key was not issued to this user")
+)
+
+// SelfServiceKeys issues S3 keys to OIDC users for the buckets their roles are mapped to.
+// Issued keys are tracked by name: every key of a user is named <key_name_prefix><username>.
+type SelfServiceKeys struct {
+	config       *config.SelfServiceConfig
+	adminService *GarageAdminService
+}
+
+// NewSelfServiceKeys creates a new self-service key service
+func NewSelfServiceKeys(cfg *config.SelfServiceConfig, adminService *GarageAdminService) *SelfServiceKeys {
+	return &SelfServiceKeys{
+		config:       cfg,
+		adminService: adminService,
+	}
+}
+
+// keyName returns the name given to all keys issued to a user
+func (s *SelfServiceKeys) keyName(username string) string {
+	return s.config.KeyNamePrefix + username
+}
+
+// ListKeys returns the keys issued to a user
+func (s *SelfServiceKeys) ListKeys(ctx context.Context, username string) ([]models.ListKeysResponseItem, error) {
+	keys, _, err := s.adminService.ListKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	name := s.keyName(username)
+	owned := make([]models.ListKeysResponseItem, 0)
+	for _, key := range keys {
+		if key.Name == name {
+			owned = append(owned, key)
+		}
+	}
+
+	return owned, nil
+}
+
+// EntitledBuckets resolves the buckets mapped to any of the given roles.
+// Mapped buckets that do not exist are logged and skipped.
+func (s *SelfServiceKeys) EntitledBuckets(ctx context.Context, roles []string) ([]models.KeyBucketInfo, error) {
+	names := s.config.BucketsForRoles(roles)
+
+	if slices.Contains(names, "*") {
+		buckets, _, err := s.adminService.ListBuckets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+
+		entitled := make([]models.KeyBucketInfo, 0, len(buckets))
+		for _, bucket := range buckets {
+			entitled = append(entitled, models.KeyBucketInfo{ID: bucket.ID, GlobalAliases: bucket.GlobalAliases})
+		}
+		return entitled, nil
+	}
+
+	entitled := make([]models.KeyBucketInfo, 0, len(names))
+	for _, name := range names {
+		info, err := s.adminService.GetCachedBucketInfoByAlias(ctx, name)
+		if err != nil {
+			logger.Warn().Err(err).Str("bucket", name).Msg("Skipping self-service bucket that cannot be resolved")
+			continue
+		}
+		entitled = append(entitled, models.KeyBucketInfo{ID: info.ID, GlobalAliases: []string{name}})
+	}
+
+	return entitled, nil
+}
+
+// CreateKey issues a key to the user, expiring after ttl, with read/write access to exactly
+// the buckets the user's roles are mapped to. The returned key includes its secret.
+func (s *SelfServiceKeys) CreateKey(ctx context.Context, username string, roles []string, ttl time.Duration) (*models.GarageKeyInfo, error) {
+	existing, err := s.ListKeys(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= s.config.MaxKeysPerUser {
+		return nil, ErrSelfServiceKeyLimit
+	}
+
+	buckets, err := s.EntitledBuckets(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(buckets) == 0 {
+		return nil, ErrNoEntitledBuckets
+	}
+
+	name := s.keyName(username)
+	expiration := time.Now().Add(ttl)
+	key, err := s.adminService.CreateKey(ctx, models.CreateKeyRequest{
+		Name:       &name,
+		Expiration: &expiration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key: %w", err)
+	}
+
+	permissions := models.BucketKeyPermission{Read: true, Write: true}
+	for i := range buckets {
+		_, err := s.adminService.AllowBucketKey(ctx, models.BucketKeyPermRequest{
+			BucketID:    buckets[i].ID,
+			AccessKeyID: key.AccessKeyID,
+			Permissions: permissions,
+		})
+		if err != nil {
+			// Never hand out a key with partial grants
+			if deleteErr := s.adminService.DeleteKey(ctx, key.AccessKeyID); deleteErr != nil {
+				logger.Error().Err(deleteErr).Str("access_key", key.AccessKeyID).Msg("Failed to remove partially granted self-service key")
+			}
+			return nil, fmt.Errorf("failed to grant bucket %s: %w", buckets[i].ID, err)
+		}
+		buckets[i].Permissions = permissions
+	}
+
+	key.Buckets = buckets
+	return key, nil
+}
+
+// DeleteKey deletes a key previously issued to the user
+func (s *SelfServiceKeys) DeleteKey(ctx context.Context, username, keyID string) error {
+	key, err := s.adminService.GetKeyInfo(ctx, keyID, false)
+	if err != nil {
+		return fmt.Errorf("failed to get key info: %w", err)
+	}
+	if key.Name != s.keyName(username) {
+		return ErrKeyNotOwned
+	}
+
+	return s.adminService.DeleteKey(ctx, keyID)
+}
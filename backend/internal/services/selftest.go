@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// The test runs within the time budget of its request; the cleanup gets its own so that it
+// still runs when the test used all of it
+const (
+	selfTestCleanupTimeout = 30 * time.Second
+	selfTestObjectSize     = 1024
+)
+
+// Names of the resources a self-test creates. Leftovers of an interrupted run are found by
+// these prefixes and removed by the next run.
+const (
+	selfTestKeyPrefix    = "garage-ui-selftest-"
+	selfTestObjectPrefix = ".garage-ui-selftest/"
+)
+
+// ErrSelfTestRunning is returned when a self-test is started while another one runs
+var ErrSelfTestRunning = errors.New("a self-test is already running")
+
+// SelfTest exercises a deployment end to end against a dedicated bucket: it creates the
+// bucket if missing, grants a temporary key on it, then uploads, inspects, downloads through
+// a presigned URL and deletes an object with that key before revoking and deleting it.
+// Whatever was created is removed afterwards, whether the run passed or not.
+type SelfTest struct {
+	adminService *GarageAdminService
+	s3Service    *S3Service
+	bucket       string
+
+	running sync.Mutex
+}
+
+// NewSelfTest creates a self-test runner using the given test bucket
+func NewSelfTest(adminService *GarageAdminService, s3Service *S3Service, bucket string) *SelfTest {
+	return &SelfTest{
+		adminService: adminService,
+		s3Service:    s3Service,
+		bucket:       bucket,
+	}
+}
+
+// selfTestRun is the state of one run, shared by its steps and its cleanup
+type selfTestRun struct {
+	report    *models.SelfTestReport
+	bucketID  string
+	keyID     string
+	client    *minio.Client
+	objectKey string
+	payload   []byte
+	uploaded  bool
+}
+
+// Run performs a self-test and returns its report. Steps that depend on a failed step are
+// skipped; the cleanup steps always run. deleteBucket also removes the test bucket at the
+// end, if it is empty.
+func (t *SelfTest) Run(ctx context.Context, deleteBucket bool) (*models.SelfTestReport, error) {
+	if !t.running.TryLock() {
+		return nil, ErrSelfTestRunning
+	}
+	defer t.running.Unlock()
+
+	run := &selfTestRun{
+		report: &models.SelfTestReport{
+			StartedAt: time.Now().UTC(),
+			Bucket:    t.bucket,
+		},
+		objectKey: selfTestObjectPrefix + time.Now().UTC().Format("20060102-150405"),
+		payload:   make([]byte, selfTestObjectSize),
+	}
+	if _, err := rand.Read(run.payload); err != nil {
+		return nil, fmt.Errorf("failed to generate the test object: %w", err)
+	}
+
+	t.exercise(ctx, run)
+
+	// Clean up even when the request was canceled, but not forever
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.WithoutCancel(ctx), selfTestCleanupTimeout)
+	defer cancelCleanup()
+	t.cleanup(cleanupCtx, run, deleteBucket)
+
+	report := run.report
+	report.Success = true
+	for _, step := range report.Steps {
+		if step.Status != models.DiagnosticStatusOK {
+			report.Success = false
+			break
+		}
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	t.logReport(report)
+	return report, nil
+}
+
+// exercise runs the test steps, each only when the one before it passed
+func (t *SelfTest) exercise(ctx context.Context, run *selfTestRun) {
+	ok := t.step(run, true, "cleanup_leftovers", t.bucket, func() (string, error) {
+		return "leftovers of an earlier run could not be removed: check the Admin API", t.removeLeftovers(ctx)
+	})
+
+	ok = t.step(run, ok, "ensure_bucket", t.bucket, func() (string, error) {
+		info, err := t.adminService.GetBucketInfoByAlias(ctx, t.bucket)
+		var statusErr *APIStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			info, err = t.adminService.CreateBucket(ctx, models.CreateBucketAdminRequest{GlobalAlias: &t.bucket})
+		}
+		if err != nil {
+			return "the test bucket could not be found or created: check monitoring.selftest_bucket", err
+		}
+		run.bucketID = info.ID
+		return "", nil
+	})
+
+	ok = t.step(run, ok, "create_key", t.bucket, func() (string, error) {
+		// The key expires on its own should the cleanup not get to it
+		name := selfTestKeyPrefix + hex.EncodeToString(run.payload[:4])
+		expiration := time.Now().UTC().Add(time.Hour)
+		key, err := t.adminService.CreateKey(ctx, models.CreateKeyRequest{Name: &name, Expiration: &expiration})
+		if err != nil {
+			return "creating an access key failed: check the Admin API token permissions", err
+		}
+		run.keyID = key.AccessKeyID
+
+		secret := key.SecretAccessKey
+		if secret == nil {
+			info, err := t.adminService.GetKeyInfo(ctx, key.AccessKeyID, true)
+			if err != nil {
+				return "the secret of the new key could not be read", err
+			}
+			secret = info.SecretAccessKey
+		}
+		if secret == nil {
+			return "the Admin API did not return the secret of the new key", fmt.Errorf("no secret key returned for %s", key.AccessKeyID)
+		}
+
+		run.client, err = minio.New(t.s3Service.config.Endpoint, &minio.Options{
+			Creds:        credentials.NewStaticV4(key.AccessKeyID, *secret, ""),
+			Secure:       t.s3Service.config.UseSSL,
+			Region:       t.s3Service.config.Region,
+			BucketLookup: bucketLookupType(t.s3Service.config),
+		})
+		return "the S3 client could not be created: check garage.endpoint", err
+	})
+
+	ok = t.step(run, ok, "grant_key", t.bucket, func() (string, error) {
+		_, err := t.adminService.AllowBucketKey(ctx, models.BucketKeyPermRequest{
+			BucketID:    run.bucketID,
+			AccessKeyID: run.keyID,
+			Permissions: models.BucketKeyPermission{Read: true, Write: true},
+		})
+		return "granting the key on the test bucket failed", err
+	})
+
+	ok = t.step(run, ok, "upload_object", run.objectKey, func() (string, error) {
+		_, err := run.client.PutObject(ctx, t.bucket, run.objectKey, bytes.NewReader(run.payload), int64(len(run.payload)), minio.PutObjectOptions{
+			ContentType: "application/octet-stream",
+		})
+		run.uploaded = err == nil
+		return "uploading with the granted key failed: check garage.endpoint, garage.region and the S3 API", err
+	})
+
+	ok = t.step(run, ok, "head_object", run.objectKey, func() (string, error) {
+		stat, err := run.client.StatObject(ctx, t.bucket, run.objectKey, minio.StatObjectOptions{})
+		if err == nil && stat.Size != int64(len(run.payload)) {
+			err = fmt.Errorf("object size is %d bytes, %d were uploaded", stat.Size, len(run.payload))
+		}
+		return "the uploaded object could not be inspected", err
+	})
+
+	ok = t.step(run, ok, "presign_fetch", run.objectKey, func() (string, error) {
+		presigned, err := run.client.PresignedGetObject(ctx, t.bucket, run.objectKey, 5*time.Minute, nil)
+		if err != nil {
+			return "presigning a download URL failed", err
+		}
+		return "downloading through the presigned URL failed: check that garage.endpoint is reachable and clocks are in sync", fetchPresigned(ctx, presigned.String(), run.payload)
+	})
+
+	t.step(run, ok, "delete_object", run.objectKey, func() (string, error) {
+		err := run.client.RemoveObject(ctx, t.bucket, run.objectKey, minio.RemoveObjectOptions{})
+		run.uploaded = err != nil
+		return "deleting with the granted key failed", err
+	})
+}
+
+// cleanup removes what the run created, reporting each removal as a step. Steps with
+// nothing to remove are reported as skipped.
+func (t *SelfTest) cleanup(ctx context.Context, run *selfTestRun, deleteBucket bool) {
+	if run.uploaded {
+		// The test key may be what failed, so the object is removed with the bucket credentials
+		t.step(run, true, "cleanup_object", run.objectKey, func() (string, error) {
+			return "the test object was left behind and will be removed by the next run", t.s3Service.DeleteObject(ctx, t.bucket, run.objectKey)
+		})
+	}
+
+	t.step(run, run.keyID != "" && run.bucketID != "", "revoke_key", t.bucket, func() (string, error) {
+		_, err := t.adminService.DenyBucketKey(ctx, models.BucketKeyPermRequest{
+			BucketID:    run.bucketID,
+			AccessKeyID: run.keyID,
+			Permissions: models.BucketKeyPermission{Read: true, Write: true, Owner: true},
+		})
+		return "revoking the test key failed; it is deleted next and expires within the hour anyway", err
+	})
+
+	t.step(run, run.keyID != "", "delete_key", run.keyID, func() (string, error) {
+		return "the test key was left behind; it expires within the hour and is removed by the next run", t.adminService.DeleteKey(ctx, run.keyID)
+	})
+
+	if deleteBucket {
+		t.step(run, run.bucketID != "", "delete_bucket", t.bucket, func() (string, error) {
+			return "the test bucket could not be deleted: it may hold objects other than the test ones", t.adminService.DeleteBucket(ctx, run.bucketID)
+		})
+	}
+}
+
+// removeLeftovers deletes the keys and objects an interrupted run left behind, so that
+// every run starts from the same state
+func (t *SelfTest) removeLeftovers(ctx context.Context) error {
+	keys, _, err := t.adminService.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if strings.HasPrefix(key.Name, selfTestKeyPrefix) {
+			if err := t.adminService.DeleteKey(ctx, key.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The bucket may not exist yet, in which case it holds nothing to remove
+	if _, err := t.s3Service.DeletePrefix(ctx, t.bucket, selfTestObjectPrefix); err != nil && !IsBucketNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// step runs and times one step of the run and appends it to the report. fn returns the
+// hint to report should it fail. The step is skipped when its prerequisites failed.
+func (t *SelfTest) step(run *selfTestRun, prerequisitesOK bool, name, target string, fn func() (string, error)) bool {
+	step := models.DiagnosticStep{Name: name, Target: target}
+	if !prerequisitesOK {
+		step.Status = models.DiagnosticStatusSkipped
+		run.report.Steps = append(run.report.Steps, step)
+		return false
+	}
+
+	start := time.Now()
+	hint, err := fn()
+	step.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		step.Status = models.DiagnosticStatusFailed
+		step.Error = err.Error()
+		step.Hint = hint
+	} else {
+		step.Status = models.DiagnosticStatusOK
+	}
+	run.report.Steps = append(run.report.Steps, step)
+	return err == nil
+}
+
+// logReport writes a summary of the report, with one warning per failed step
+func (t *SelfTest) logReport(report *models.SelfTestReport) {
+	statuses := make([]string, 0, len(report.Steps))
+	for _, step := range report.Steps {
+		statuses = append(statuses, step.Name+"="+step.Status)
+		if step.Status == models.DiagnosticStatusFailed {
+			logger.Warn().
+				Str("step", step.Name).
+				Str("target", step.Target).
+				Str("error", step.Error).
+				Msg("Self-test step failed")
+		}
+	}
+
+	event := logger.Info()
+	if !report.Success {
+		event = logger.Warn()
+	}
+	event.
+		Bool("success", report.Success).
+		Str("bucket", report.Bucket).
+		Int64("duration_ms", report.DurationMs).
+		Str("steps", strings.Join(statuses, " ")).
+		Msg("Self-test completed")
+}
+
+// fetchPresigned downloads a presigned URL and checks that it returns want
+func fetchPresigned(ctx context.Context, url string, want []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("presigned URL answered %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(len(want))+1))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(body, want) {
+		return fmt.Errorf("downloaded content differs from the uploaded object")
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// SettingsStore keeps UI-only settings (e.g. per-bucket listing preferences and the
+// maintenance mode).
+// Nothing stored here is ever sent to Garage. When a path is configured the settings
+// are persisted as JSON so they survive restarts; otherwise they live in memory only.
+type SettingsStore struct {
+	mu          sync.RWMutex
+	path        string
+	buckets     map[string]models.BucketSettings
+	maintenance models.Maintenance
+
+	// keyTargets are where changes to each key's access are announced, by access key ID
+	keyTargets map[string]models.KeyNotificationTarget
+
+	// lifecycle holds the lifecycle rules of each bucket that has any
+	lifecycle map[string][]models.LifecycleRule
+
+	// readOnlyPatterns are the garage.read_only_buckets globs
+	readOnlyPatterns []string
+
+	// clock decides when the maintenance window ends
+	clock clock.Clock
+}
+
+// settingsFile is the on-disk representation of the settings store
+type settingsFile struct {
+	Buckets     map[string]models.BucketSettings `json:"buckets"`
+	Maintenance *models.Maintenance              `json:"maintenance,omitempty"`
+
+	KeyNotifications map[string]models.KeyNotificationTarget `json:"keyNotifications,omitempty"`
+	Lifecycle        map[string][]models.LifecycleRule       `json:"lifecycle,omitempty"`
+}
+
+// NewSettingsStore creates a settings store, loading previously saved settings from path if
+// set. Buckets matching readOnlyPatterns are read-only whatever their settings say, and the
+// maintenance window is measured against clk.
+func NewSettingsStore(path string, readOnlyPatterns []string, clk clock.Clock) (*SettingsStore, error) {
+	store := &SettingsStore{
+		path:             path,
+		buckets:          make(map[string]models.BucketSettings),
+		keyTargets:       make(map[string]models.KeyNotificationTarget),
+		lifecycle:        make(map[string][]models.LifecycleRule),
+		readOnlyPatterns: readOnlyPatterns,
+		clock:            clk,
+	}
+
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	var file settingsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	}
+	for bucket, settings := range file.Buckets {
+		store.buckets[bucket] = settings
+	}
+	if file.Maintenance != nil {
+		store.maintenance = *file.Maintenance
+	}
+	for accessKey, target := range file.KeyNotifications {
+		store.keyTargets[accessKey] = target
+	}
+	for bucket, rules := range file.Lifecycle {
+		store.lifecycle[bucket] = rules
+	}
+
+	return store, nil
+}
+
+// GetBucketSettings returns the stored settings for a bucket and whether any were set
+func (s *SettingsStore) GetBucketSettings(bucketName string) (models.BucketSettings, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, ok := s.buckets[bucketName]
+	return settings, ok
+}
+
+// ListBucketSettings returns a copy of the settings of every bucket that has any
+func (s *SettingsStore) ListBucketSettings() map[string]models.BucketSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]models.BucketSettings, len(s.buckets))
+	for bucket, settings := range s.buckets {
+		result[bucket] = settings
+	}
+	return result
+}
+
+// PublicBuckets returns the sorted names of the buckets whose settings enable public browsing
+func (s *SettingsStore) PublicBuckets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	buckets := make([]string, 0)
+	for bucket, settings := range s.buckets {
+		if settings.PublicBrowsing {
+			buckets = append(buckets, bucket)
+		}
+	}
+	slices.Sort(buckets)
+	return buckets
+}
+
+// ErrSettingsConflict is returned when bucket settings changed since the caller loaded them
+var ErrSettingsConflict = errors.New("bucket settings were modified concurrently")
+
+// BucketSettingsETag returns the entity tag of a bucket's settings as GetBucketSettings returns them
+func BucketSettingsETag(settings models.BucketSettings) string {
+	return utils.StateETag(settings)
+}
+
+// IsReadOnly reports whether changes to the bucket are refused, by its settings or by a
+// garage.read_only_buckets pattern
+func (s *SettingsStore) IsReadOnly(bucketName string) bool {
+	if slices.ContainsFunc(s.readOnlyPatterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, bucketName)
+		return matched
+	}) {
+		return true
+	}
+
+	settings, _ := s.GetBucketSettings(bucketName)
+	return settings.ReadOnly
+}
+
+// SetBucketSettings replaces the stored settings for a bucket
+func (s *SettingsStore) SetBucketSettings(bucketName string, settings models.BucketSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.setLocked(bucketName, settings)
+}
+
+// ErrReadOnlyChangeForbidden is returned when bucket settings would change whether the bucket
+// is read-only and the caller may not
+var ErrReadOnlyChangeForbidden = errors.New("only admins can change whether a bucket is read-only")
+
+// UpdateBucketSettings replaces the stored settings for a bucket, checking under the same lock
+// that the update is allowed. Unless allowReadOnlyChange is set, changing ReadOnly returns
+// ErrReadOnlyChangeForbidden. When ifMatch is set, a mismatching entity tag returns
+// ErrSettingsConflict. It returns the settings held before the update and whether ReadOnly
+// changed.
+func (s *SettingsStore) UpdateBucketSettings(bucketName string, settings models.BucketSettings, ifMatch string, allowReadOnlyChange bool) (models.BucketSettings, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.buckets[bucketName]
+	readOnlyChanged := settings.ReadOnly != current.ReadOnly
+	if readOnlyChanged && !allowReadOnlyChange {
+		return current, false, ErrReadOnlyChangeForbidden
+	}
+	if ifMatch != "" && !utils.ETagMatches(ifMatch, BucketSettingsETag(current)) {
+		return current, false, ErrSettingsConflict
+	}
+	return current, readOnlyChanged, s.setLocked(bucketName, settings)
+}
+
+// setLocked stores the settings of a bucket and saves them; s.mu must be held
+func (s *SettingsStore) setLocked(bucketName string, settings models.BucketSettings) error {
+	previous, existed := s.buckets[bucketName]
+	s.buckets[bucketName] = settings
+
+	if err := s.save(); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			s.buckets[bucketName] = previous
+		} else {
+			delete(s.buckets, bucketName)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteBucketSettings removes the stored settings and lifecycle rules for a bucket
+func (s *SettingsStore) DeleteBucketSettings(bucketName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, hasSettings := s.buckets[bucketName]
+	_, hasRules := s.lifecycle[bucketName]
+	if !hasSettings && !hasRules {
+		return nil
+	}
+	delete(s.buckets, bucketName)
+	delete(s.lifecycle, bucketName)
+	return s.save()
+}
+
+// Maintenance returns the maintenance mode and whether it is in effect now. It ends on its
+// own once its Until time has passed.
+func (s *SettingsStore) Maintenance() (models.Maintenance, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.maintenance, s.maintenance.Active(s.clock.Now())
+}
+
+// SetMaintenance replaces the maintenance mode and saves it
+func (s *SettingsStore) SetMaintenance(maintenance models.Maintenance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.maintenance
+	s.maintenance = maintenance
+	if err := s.save(); err != nil {
+		// Keep memory consistent with what is on disk
+		s.maintenance = previous
+		return err
+	}
+	return nil
+}
+
+// KeyNotificationTarget returns where changes to a key's access are announced, if anywhere
+func (s *SettingsStore) KeyNotificationTarget(accessKey string) (models.KeyNotificationTarget, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	target, ok := s.keyTargets[accessKey]
+	return target, ok
+}
+
+// SetKeyNotificationTarget replaces where changes to a key's access are announced and saves
+// it; an empty target removes it
+func (s *SettingsStore) SetKeyNotificationTarget(accessKey string, target models.KeyNotificationTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.keyTargets[accessKey]
+	if target == (models.KeyNotificationTarget{}) {
+		delete(s.keyTargets, accessKey)
+	} else {
+		s.keyTargets[accessKey] = target
+	}
+
+	if err := s.save(); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			s.keyTargets[accessKey] = previous
+		} else {
+			delete(s.keyTargets, accessKey)
+		}
+		return err
+	}
+	return nil
+}
+
+// LifecycleRules returns a copy of the lifecycle rules of a bucket, empty when it has none
+func (s *SettingsStore) LifecycleRules(bucketName string) []models.LifecycleRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.LifecycleRule{}, s.lifecycle[bucketName]...)
+}
+
+// ListLifecycleRules returns a copy of the lifecycle rules of every bucket that has any
+func (s *SettingsStore) ListLifecycleRules() map[string][]models.LifecycleRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string][]models.LifecycleRule, len(s.lifecycle))
+	for bucket, rules := range s.lifecycle {
+		result[bucket] = slices.Clone(rules)
+	}
+	return result
+}
+
+// SetLifecycleRules replaces the lifecycle rules of a bucket and saves them; no rules
+// removes them
+func (s *SettingsStore) SetLifecycleRules(bucketName string, rules []models.LifecycleRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.lifecycle[bucketName]
+	if len(rules) == 0 {
+		delete(s.lifecycle, bucketName)
+	} else {
+		s.lifecycle[bucketName] = slices.Clone(rules)
+	}
+
+	if err := s.save(); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			s.lifecycle[bucketName] = previous
+		} else {
+			delete(s.lifecycle, bucketName)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the settings to disk atomically; callers must hold the write lock
+func (s *SettingsStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := settingsFile{Buckets: s.buckets, KeyNotifications: s.keyTargets, Lifecycle: s.lifecycle}
+	if s.maintenance.Enabled {
+		file.Maintenance = &s.maintenance
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create settings file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace settings file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// slowRequestCapacity is the number of slow requests kept in memory
+const slowRequestCapacity = 200
+
+// SlowRequestLog keeps the most recent requests slower than a threshold in a ring buffer
+type SlowRequestLog struct {
+	threshold time.Duration
+
+	mu       sync.Mutex
+	requests []models.SlowRequest
+	next     int
+}
+
+// NewSlowRequestLog creates a slow-request log; a threshold of 0 disables it
+func NewSlowRequestLog(threshold time.Duration) *SlowRequestLog {
+	return &SlowRequestLog{threshold: threshold}
+}
+
+// Enabled reports whether slow requests are recorded
+func (l *SlowRequestLog) Enabled() bool {
+	return l.threshold > 0
+}
+
+// IsSlow reports whether a request taking duration is recorded
+func (l *SlowRequestLog) IsSlow(duration time.Duration) bool {
+	return l.Enabled() && duration >= l.threshold
+}
+
+// Observe records a request if it took at least the threshold
+func (l *SlowRequestLog) Observe(request models.SlowRequest, duration time.Duration) {
+	if !l.IsSlow(duration) {
+		return
+	}
+	request.DurationMs = duration.Milliseconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.requests) < slowRequestCapacity {
+		l.requests = append(l.requests, request)
+		return
+	}
+	l.requests[l.next] = request
+	l.next = (l.next + 1) % slowRequestCapacity
+}
+
+// Recent returns the slow requests kept in memory, newest first
+func (l *SlowRequestLog) Recent() []models.SlowRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]models.SlowRequest, 0, len(l.requests))
+	for i := range l.requests {
+		// The newest request sits just before next once the buffer has wrapped
+		idx := (l.next - 1 - i + len(l.requests)) % len(l.requests)
+		result = append(result, l.requests[idx])
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"Noooste/garage-ui/internal/models"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// StreamUploadPartSize is the size of the parts streamed uploads are sent to Garage in. Only
+// one part is held in memory at a time, whatever the size of the object.
+const StreamUploadPartSize = 16 << 20
+
+// MaxStreamedObjectSize is the largest object a streamed upload can create
+const MaxStreamedObjectSize = StreamUploadPartSize * MultipartMaxParts
+
+// UploadObjectStream uploads an object from a body that is read once, as it arrives, rather
+// than from a file received in full. size is -1 when unknown. Bodies larger than one part are
+// sent as a multipart upload, which Garage cleans up when the upload fails midway. The upload
+// as a whole is only retried when body can be rewound; each part is retried on its own.
+func (s *S3Service) UploadObjectStream(ctx context.Context, bucketName, key string, body io.Reader, size int64, contentType string) (*models.ObjectUploadResponse, error) {
+	if size > MaxStreamedObjectSize {
+		return nil, fmt.Errorf("object of %d bytes exceeds the streamed upload limit of %d bytes", size, int64(MaxStreamedObjectSize))
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    StreamUploadPartSize,
+	}
+
+	var info minio.UploadInfo
+	err := s.withReplayableBody(ctx, "UploadObjectStream", bucketName, body, func(client *minio.Client) error {
+		var uploadErr error
+		info, uploadErr = client.PutObject(ctx, bucketName, key, body, size, opts)
+		return throttleError(ctx, uploadErr)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s to bucket %s: %w", key, bucketName, err)
+	}
+
+	return &models.ObjectUploadResponse{
+		Bucket:      bucketName,
+		Key:         key,
+		ETag:        info.ETag,
+		Size:        info.Size,
+		StoredSize:  info.Size,
+		ContentType: contentType,
+	}, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// Bounds of a support bundle. Files are cut at maxSupportBundleFile, and once the bundle
+// holds maxSupportBundleSize bytes the remaining files are cut to nothing.
+const (
+	maxSupportBundleFile = 4 << 20
+	maxSupportBundleSize = 16 << 20
+)
+
+// supportBundleTimeout bounds the cluster snapshots taken for a support bundle
+const supportBundleTimeout = 15 * time.Second
+
+// SupportBundleFile is one file of a support bundle
+type SupportBundleFile struct {
+	Name      string
+	Data      []byte
+	Truncated bool
+}
+
+// SupportBundle collects the information asked for in bug reports: the effective
+// configuration, version, recent logs, last diagnostics, cluster snapshots and slow
+// requests, with secrets removed
+type SupportBundle struct {
+	config       *config.Config
+	version      string
+	adminService *GarageAdminService
+	diagnostics  *DiagnosticsService
+	slowRequests *SlowRequestLog
+}
+
+// NewSupportBundle creates a support bundle builder
+func NewSupportBundle(cfg *config.Config, version string, adminService *GarageAdminService, diagnostics *DiagnosticsService, slowRequests *SlowRequestLog) *SupportBundle {
+	return &SupportBundle{
+		config:       cfg,
+		version:      version,
+		adminService: adminService,
+		diagnostics:  diagnostics,
+		slowRequests: slowRequests,
+	}
+}
+
+// Collect gathers the files of a bundle. Upstream failures are recorded in the bundle
+// rather than returned, since a bundle is most useful when something is broken.
+func (b *SupportBundle) Collect(ctx context.Context) []SupportBundleFile {
+	ctx, cancel := context.WithTimeout(ctx, supportBundleTimeout)
+	defer cancel()
+
+	health, healthErr := b.adminService.GetClusterHealth(ctx)
+	status, statusErr := b.adminService.GetClusterStatus(ctx)
+
+	entries := []struct {
+		name  string
+		value interface{}
+		err   error
+	}{
+		{"version.json", b.versionInfo(), nil},
+		{"config.json", b.config.Redacted(), nil},
+		{"diagnostics.json", b.diagnostics.LastReport(), nil},
+		{"cluster-health.json", health, healthErr},
+		{"cluster-status.json", status, statusErr},
+		{"slow-requests.json", b.slowRequests.Recent(), nil},
+	}
+
+	files := make([]SupportBundleFile, 0, len(entries)+1)
+	for _, entry := range entries {
+		files = append(files, SupportBundleFile{Name: entry.name, Data: b.encode(entry.value, entry.err)})
+	}
+	files = append(files, SupportBundleFile{Name: "logs.jsonl", Data: b.recentLogs()})
+
+	// Keep the bundle bounded; the logs come last so they are the ones cut first
+	remaining := maxSupportBundleSize
+	for i := range files {
+		limit := min(maxSupportBundleFile, remaining)
+		if len(files[i].Data) > limit {
+			files[i].Data = files[i].Data[:limit]
+			files[i].Truncated = true
+		}
+		remaining -= len(files[i].Data)
+	}
+
+	return files
+}
+
+// WriteSupportBundle writes the files of a bundle to w as a zip archive, along with a
+// README listing the files that were cut
+func WriteSupportBundle(w io.Writer, files []SupportBundleFile, generatedAt time.Time) error {
+	archive := zip.NewWriter(w)
+
+	var readme strings.Builder
+	fmt.Fprintf(&readme, "Garage UI support bundle generated at %s.\nSecrets are removed from every file.\n", generatedAt.UTC().Format(time.RFC3339))
+	for _, file := range files {
+		if file.Truncated {
+			fmt.Fprintf(&readme, "%s was cut to %d bytes.\n", file.Name, len(file.Data))
+		}
+	}
+	files = append([]SupportBundleFile{{Name: "README.txt", Data: []byte(readme.String())}}, files...)
+
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   zip.Deflate,
+			Modified: generatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(file.Data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// versionInfo describes the running build
+func (b *SupportBundle) versionInfo() map[string]string {
+	info := map[string]string{
+		"version":   b.version,
+		"goVersion": runtime.Version(),
+		"platform":  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				info[setting.Key] = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// encode renders a value as indented JSON with secrets removed, or the error that kept it
+// from being fetched
+func (b *SupportBundle) encode(value interface{}, fetchErr error) []byte {
+	if fetchErr != nil {
+		value = map[string]string{"error": fetchErr.Error()}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		data, _ = json.MarshalIndent(redactSecrets(decoded), "", "  ")
+	}
+	return []byte(b.redact(string(data)))
+}
+
+// recentLogs returns the log lines kept in memory with secrets removed
+func (b *SupportBundle) recentLogs() []byte {
+	var logs strings.Builder
+	for _, line := range logger.Recent() {
+		line = strings.TrimRight(line, "\n")
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err == nil {
+			redacted, _ := json.Marshal(redactSecrets(decoded))
+			line = string(redacted)
+		}
+
+		logs.WriteString(b.redact(line))
+		logs.WriteByte('\n')
+	}
+	return []byte(logs.String())
+}
+
+// redact masks the configured secrets wherever they appear
+func (b *SupportBundle) redact(text string) string {
+	return redactText(text,
+		b.config.Garage.AdminToken,
+		b.config.Garage.DefaultSecretKey,
+		b.config.Auth.JWTPrivKey,
+		b.config.Auth.Admin.Password,
+		b.config.Auth.OIDC.ClientSecret,
+	)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"Noooste/garage-ui/pkg/utils"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// IsNotImplemented reports whether Garage does not implement the S3 call that failed
+func IsNotImplemented(err error) bool {
+	var errResponse minio.ErrorResponse
+	return errors.As(err, &errResponse) && (errResponse.Code == "NotImplemented" || errResponse.StatusCode == 501)
+}
+
+// GetObjectTags returns the tags of an object, empty when it has none
+func (s *S3Service) GetObjectTags(ctx context.Context, bucketName, key string) (map[string]string, error) {
+	var objectTags *tags.Tags
+
+	// Call MinIO GetObjectTagging API with retry logic
+	retryConfig := utils.ReadRetryConfig()
+	err := s.withBucketClient(ctx, "GetObjectTags", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			var tagErr error
+			objectTags, tagErr = client.GetObjectTagging(ctx, bucketName, key, minio.GetObjectTaggingOptions{})
+			return throttleError(ctx, tagErr)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of object %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	return objectTags.ToMap(), nil
+}
+
+// PutObjectTags replaces the tags of an object. The tags must follow the S3 rules, which are
+// checked before calling Garage: at most 10 tags, keys of up to 128 characters and values
+// of up to 256.
+func (s *S3Service) PutObjectTags(ctx context.Context, bucketName, key string, objectTags map[string]string) error {
+	parsed, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return &InvalidTagsError{Err: err}
+	}
+
+	// Call MinIO PutObjectTagging API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err = s.withBucketClient(ctx, "PutObjectTags", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.PutObjectTagging(ctx, bucketName, key, parsed, minio.PutObjectTaggingOptions{}))
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tags of object %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	return nil
+}
+
+// DeleteObjectTags removes every tag of an object
+func (s *S3Service) DeleteObjectTags(ctx context.Context, bucketName, key string) error {
+	// Call MinIO RemoveObjectTagging API with retry logic
+	retryConfig := utils.DefaultRetryConfig()
+	err := s.withBucketClient(ctx, "DeleteObjectTags", bucketName, func(client *minio.Client) error {
+		return utils.RetryWithBackoff(ctx, retryConfig, func() error {
+			return throttleError(ctx, client.RemoveObjectTagging(ctx, bucketName, key, minio.RemoveObjectTaggingOptions{}))
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete tags of object %s in bucket %s: %w", key, bucketName, err)
+	}
+
+	return nil
+}
+
+// InvalidTagsError is returned when tags break the S3 tagging rules
+type InvalidTagsError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *InvalidTagsError) Error() string {
+	return "invalid tags: " + e.Err.Error()
+}
+
+// Unwrap returns the validation error
+func (e *InvalidTagsError) Unwrap() error {
+	return e.Err
+}
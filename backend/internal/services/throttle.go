@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Throttle sources, i.e. which Garage API asked us to slow down
+const (
+	ThrottleSourceS3    = "s3"
+	ThrottleSourceAdmin = "admin"
+)
+
+const (
+	// throttleBaseDelay is the first Retry-After hint handed to a client
+	throttleBaseDelay = time.Second
+
+	// throttleMaxDelay caps the Retry-After hint however long throttling lasts
+	throttleMaxDelay = 30 * time.Second
+
+	// throttleQuietPeriod is how long a client must go without being throttled before its
+	// schedule starts over at throttleBaseDelay
+	throttleQuietPeriod = time.Minute
+)
+
+// isThrottleStatus reports whether an HTTP status means the backend is shedding load
+func isThrottleStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// throttleMarkerKey is the context key of the throttleMarker of an API request
+type throttleMarkerKey struct{}
+
+// throttleMarker records the source of the last throttled backend call made on behalf of
+// an API request. Calls may run concurrently, so the source is stored atomically.
+type throttleMarker struct {
+	source atomic.Value
+}
+
+// WithThrottleMarker returns a context in which throttled Garage calls are recorded, so
+// that ThrottledBy can tell afterwards whether a failure was caused by throttling
+func WithThrottleMarker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, throttleMarkerKey{}, &throttleMarker{})
+}
+
+// ThrottledBy returns the source of the last throttled Garage call made with ctx, or an
+// empty string if none was throttled
+func ThrottledBy(ctx context.Context) string {
+	marker, ok := ctx.Value(throttleMarkerKey{}).(*throttleMarker)
+	if !ok {
+		return ""
+	}
+	source, _ := marker.source.Load().(string)
+	return source
+}
+
+// markThrottled records on ctx that Garage throttled a call
+func markThrottled(ctx context.Context, source string) {
+	if marker, ok := ctx.Value(throttleMarkerKey{}).(*throttleMarker); ok {
+		marker.source.Store(source)
+	}
+}
+
+// ThrottleStats hands out Retry-After hints to clients whose requests failed because
+// Garage was throttling, and counts those responses. Each client gets its own
+// exponential schedule so that the busiest clients back off the most.
+type ThrottleStats struct {
+	mu      sync.Mutex
+	clients map[string]*throttleBackoff
+	counts  map[string]int64 // Throttled responses by source
+}
+
+// throttleBackoff is the position of one client in the Retry-After schedule
+type throttleBackoff struct {
+	streak int
+	last   time.Time
+}
+
+// NewThrottleStats creates an empty throttle tracker
+func NewThrottleStats() *ThrottleStats {
+	return &ThrottleStats{
+		clients: make(map[string]*throttleBackoff),
+		counts: map[string]int64{
+			ThrottleSourceS3:    0,
+			ThrottleSourceAdmin: 0,
+		},
+	}
+}
+
+// RetryAfter records a throttled response to client and returns how long the client
+// should wait before retrying: 1s, 2s, 4s and so on up to 30s while throttling goes on
+func (t *ThrottleStats) RetryAfter(client, source string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.counts[source]++
+
+	// Forget the clients that have been left alone long enough
+	for name, backoff := range t.clients {
+		if now.Sub(backoff.last) > throttleQuietPeriod {
+			delete(t.clients, name)
+		}
+	}
+
+	backoff, ok := t.clients[client]
+	if !ok {
+		backoff = &throttleBackoff{}
+		t.clients[client] = backoff
+	}
+	backoff.last = now
+
+	delay := throttleBaseDelay << backoff.streak
+	if delay >= throttleMaxDelay {
+		return throttleMaxDelay
+	}
+	backoff.streak++
+	return delay
+}
+
+// PrometheusMetrics renders the throttled response counters in the Prometheus text format
+func (t *ThrottleStats) PrometheusMetrics() string {
+	t.mu.Lock()
+	s3, admin := t.counts[ThrottleSourceS3], t.counts[ThrottleSourceAdmin]
+	t.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP garage_ui_throttled_responses_total Requests answered with 429 because Garage was throttling.\n")
+	b.WriteString("# TYPE garage_ui_throttled_responses_total counter\n")
+	fmt.Fprintf(&b, "garage_ui_throttled_responses_total{source=%q} %d\n", ThrottleSourceS3, s3)
+	fmt.Fprintf(&b, "garage_ui_throttled_responses_total{source=%q} %d\n", ThrottleSourceAdmin, admin)
+	return b.String()
+}
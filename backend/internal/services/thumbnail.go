@@ -0,0 +1,316 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	// Registered for image.Decode next to the JPEG and PNG decoders imported above
+	_ "image/gif"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxThumbnailSourcePixels bounds the images thumbnails are generated from, since a small
+// file can declare a huge canvas that would take gigabytes once decoded
+const maxThumbnailSourcePixels = 40_000_000
+
+// thumbnailJPEGQuality is the quality thumbnails of photos are encoded with
+const thumbnailJPEGQuality = 80
+
+// Errors returned when no thumbnail can be generated for an object
+var (
+	ErrThumbnailUnsupported = errors.New("object is not an image thumbnails can be generated from")
+	ErrThumbnailTooLarge    = errors.New("object is too large to generate a thumbnail from")
+)
+
+// Thumbnail is a generated image preview
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	ETag        string // Changes with the source object and the requested size
+}
+
+// ThumbnailService generates downscaled previews of JPEG, PNG and GIF objects and caches
+// them on local disk. Cache entries are keyed by the ETag of the source object, so an
+// overwritten object gets a new thumbnail, and removed by a janitor once unused.
+type ThumbnailService struct {
+	s3Service *S3Service
+	config    *config.ThumbnailsConfig
+	cacheDir  string
+	group     singleflight.Group // Requests for the same thumbnail share one generation
+	clock     clock.Clock        // Marks thumbnails in use and decides when they are unused
+}
+
+// NewThumbnailService creates a thumbnail service and its cache directory. Thumbnails unused
+// for the cache TTL by clk are removed.
+func NewThumbnailService(s3Service *S3Service, cfg *config.ThumbnailsConfig, clk clock.Clock) (*ThumbnailService, error) {
+	cacheDir := cfg.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "garage-ui-thumbnails")
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail cache directory: %w", err)
+	}
+
+	return &ThumbnailService{s3Service: s3Service, config: cfg, cacheDir: cacheDir, clock: clk}, nil
+}
+
+// Get returns a thumbnail of an object fitting within width x height, keeping its aspect
+// ratio. Images are never enlarged. Thumbnails of PNG and GIF images are PNGs, to keep
+// their transparency; those of JPEG images are JPEGs.
+func (t *ThumbnailService) Get(ctx context.Context, bucketName, key string, width, height int) (*Thumbnail, error) {
+	info, err := t.s3Service.GetObjectMetadata(ctx, bucketName, key)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size > t.config.MaxSourceSize {
+		return nil, ErrThumbnailTooLarge
+	}
+
+	sum := sha256.Sum256([]byte(bucketName + "\x00" + key + "\x00" + info.ETag + "\x00" + strconv.Itoa(width) + "x" + strconv.Itoa(height)))
+	id := hex.EncodeToString(sum[:])
+	etag := `"` + id[:32] + `"`
+
+	if thumbnail, ok := t.readCache(id, etag); ok {
+		return thumbnail, nil
+	}
+
+	result, err, _ := t.group.Do(id, func() (interface{}, error) {
+		thumbnail, err := t.generate(ctx, bucketName, key, info.ContentEncoding, width, height)
+		if err != nil {
+			return nil, err
+		}
+		thumbnail.ETag = etag
+		t.writeCache(id, thumbnail)
+		return thumbnail, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Thumbnail), nil
+}
+
+// generate downloads an object and encodes a downscaled copy of it
+func (t *ThumbnailService) generate(ctx context.Context, bucketName, key, contentEncoding string, width, height int) (*Thumbnail, error) {
+	object, _, err := t.s3Service.GetObject(ctx, bucketName, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	var body io.Reader = object
+	if contentEncoding == ContentEncodingGzip {
+		decompressed, err := gzip.NewReader(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+		}
+		body = decompressed
+	}
+
+	// Compressed objects may inflate beyond their stored size, so the limit applies again
+	data, err := io.ReadAll(io.LimitReader(body, t.config.MaxSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if int64(len(data)) > t.config.MaxSourceSize {
+		return nil, ErrThumbnailTooLarge
+	}
+
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrThumbnailUnsupported
+	}
+	if imageConfig.Width <= 0 || imageConfig.Height <= 0 {
+		return nil, ErrThumbnailUnsupported
+	}
+	if int64(imageConfig.Width)*int64(imageConfig.Height) > maxThumbnailSourcePixels {
+		return nil, ErrThumbnailTooLarge
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	scaled := downscale(source, width, height)
+
+	var out bytes.Buffer
+	thumbnail := &Thumbnail{}
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, scaled, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		thumbnail.ContentType = "image/jpeg"
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&out, scaled)
+		thumbnail.ContentType = "image/png"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail of %s: %w", key, err)
+	}
+	thumbnail.Data = out.Bytes()
+	return thumbnail, nil
+}
+
+// downscale shrinks an image to fit within width x height, averaging the source pixels
+// covered by each target pixel. Images already small enough are returned as they are.
+func downscale(source image.Image, width, height int) image.Image {
+	bounds := source.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw <= width && sh <= height {
+		return source
+	}
+
+	// Fit the longer side and round the other, keeping at least one pixel
+	dw, dh := width, sh*width/sw
+	if sh*width > sw*height {
+		dw, dh = sw*height/sh, height
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// Work on premultiplied RGBA so that transparent pixels do not bleed their color
+	rgba, ok := source.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, sw, sh))
+		draw.Draw(rgba, rgba.Bounds(), source, bounds.Min, draw.Src)
+	}
+
+	target := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[y*rgba.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := dy*target.Stride + dx*4
+			target.Pix[i] = uint8(r / n)
+			target.Pix[i+1] = uint8(g / n)
+			target.Pix[i+2] = uint8(b / n)
+			target.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return target
+}
+
+// cachePath returns where the thumbnail with the given ID is cached. Entries are spread over
+// subdirectories so that no single directory grows too large.
+func (t *ThumbnailService) cachePath(id string) string {
+	return filepath.Join(t.cacheDir, id[:2], id)
+}
+
+// readCache returns a cached thumbnail, refreshing its modification time so that the
+// janitor keeps thumbnails in use
+func (t *ThumbnailService) readCache(id, etag string) (*Thumbnail, bool) {
+	path := t.cachePath(id)
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	now := t.clock.Now()
+	_ = os.Chtimes(path, now, now)
+
+	contentType := "image/png"
+	if bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		contentType = "image/jpeg"
+	}
+	return &Thumbnail{Data: data, ContentType: contentType, ETag: etag}, true
+}
+
+// writeCache stores a thumbnail; failures only cost a regeneration and are logged. The file
+// is written under a temporary name and renamed so readers never see a partial thumbnail.
+func (t *ThumbnailService) writeCache(id string, thumbnail *Thumbnail) {
+	path := t.cachePath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		logger.Warn().Err(err).Msg("Failed to create thumbnail cache directory")
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), id+".*.tmp")
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to cache thumbnail")
+		return
+	}
+	_, err = tmp.Write(thumbnail.Data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		logger.Warn().Err(err).Msg("Failed to cache thumbnail")
+	}
+}
+
+// RunJanitor removes cached thumbnails unused for longer than the cache TTL until ctx is
+// cancelled
+func (t *ThumbnailService) RunJanitor(ctx context.Context) {
+	ticker := t.clock.NewTicker(min(t.config.CacheTTL, time.Hour))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			t.prune()
+		}
+	}
+}
+
+// prune removes expired cache entries and leftover temporary files
+func (t *ThumbnailService) prune() {
+	cutoff := t.clock.Now().Add(-t.config.CacheTTL)
+	removed := 0
+
+	err := filepath.WalkDir(t.cacheDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to prune thumbnail cache")
+		return
+	}
+	if removed > 0 {
+		logger.Debug().Int("removed", removed).Msg("Pruned thumbnail cache")
+	}
+}
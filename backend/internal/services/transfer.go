@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// TransferRetention is how far back per-user transfer counters are kept
+const TransferRetention = 7 * 24 * time.Hour
+
+// Transfer directions, as seen from the client
+const (
+	TransferUpload   = "upload"
+	TransferDownload = "download"
+)
+
+// TransferStats attributes the bytes proxied through object uploads and downloads to the
+// authenticated user. Counters are kept in memory in hourly buckets and reset on restart.
+// Presigned URL transfers go straight to Garage and are never counted.
+type TransferStats struct {
+	mu    sync.Mutex
+	hours map[time.Time]map[string]*models.UserTransfer
+
+	// Lifetime totals exported as Prometheus counters
+	uploadBytes   atomic.Int64
+	downloadBytes atomic.Int64
+}
+
+// NewTransferStats creates an empty transfer counter
+func NewTransferStats() *TransferStats {
+	return &TransferStats{
+		hours: make(map[time.Time]map[string]*models.UserTransfer),
+	}
+}
+
+// Add attributes n bytes transferred in the given direction to username
+func (t *TransferStats) Add(username, direction string, n int64) {
+	if n <= 0 {
+		return
+	}
+
+	switch direction {
+	case TransferUpload:
+		t.uploadBytes.Add(n)
+	case TransferDownload:
+		t.downloadBytes.Add(n)
+	default:
+		return
+	}
+
+	now := time.Now()
+	hour := now.Truncate(time.Hour)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	users, ok := t.hours[hour]
+	if !ok {
+		users = make(map[string]*models.UserTransfer)
+		t.hours[hour] = users
+		t.pruneLocked(now)
+	}
+
+	totals, ok := users[username]
+	if !ok {
+		totals = &models.UserTransfer{Username: username}
+		users[username] = totals
+	}
+
+	if direction == TransferUpload {
+		totals.UploadBytes += n
+	} else {
+		totals.DownloadBytes += n
+	}
+}
+
+// pruneLocked drops the hourly buckets older than the retention period
+func (t *TransferStats) pruneLocked(now time.Time) {
+	cutoff := now.Add(-TransferRetention)
+	for hour := range t.hours {
+		if hour.Add(time.Hour).Before(cutoff) {
+			delete(t.hours, hour)
+		}
+	}
+}
+
+// Totals returns the per-user totals of every hourly bucket overlapping the window,
+// sorted by total bytes transferred, highest first
+func (t *TransferStats) Totals(window time.Duration) []models.UserTransfer {
+	since := time.Now().Add(-window)
+
+	t.mu.Lock()
+	byUser := make(map[string]*models.UserTransfer)
+	for hour, users := range t.hours {
+		if !hour.Add(time.Hour).After(since) {
+			continue
+		}
+		for username, totals := range users {
+			sum, ok := byUser[username]
+			if !ok {
+				sum = &models.UserTransfer{Username: username}
+				byUser[username] = sum
+			}
+			sum.UploadBytes += totals.UploadBytes
+			sum.DownloadBytes += totals.DownloadBytes
+		}
+	}
+	t.mu.Unlock()
+
+	result := make([]models.UserTransfer, 0, len(byUser))
+	for _, totals := range byUser {
+		result = append(result, *totals)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a := result[i].UploadBytes + result[i].DownloadBytes
+		b := result[j].UploadBytes + result[j].DownloadBytes
+		if a != b {
+			return a > b
+		}
+		return result[i].Username < result[j].Username
+	})
+
+	return result
+}
+
+// PrometheusMetrics renders the lifetime transfer counters in the Prometheus text format
+func (t *TransferStats) PrometheusMetrics() string {
+	var b strings.Builder
+	b.WriteString("# HELP garage_ui_transfer_bytes_total Object bytes proxied through Garage UI.\n")
+	b.WriteString("# TYPE garage_ui_transfer_bytes_total counter\n")
+	fmt.Fprintf(&b, "garage_ui_transfer_bytes_total{direction=%q} %d\n", TransferUpload, t.uploadBytes.Load())
+	fmt.Fprintf(&b, "garage_ui_transfer_bytes_total{direction=%q} %d\n", TransferDownload, t.downloadBytes.Load())
+	return b.String()
+}
+
+// CountReader wraps r so the bytes read from it are attributed to username once it is
+// closed. Partial transfers are counted up to the point where the stream was closed.
+func (t *TransferStats) CountReader(username, direction string, r io.Reader) io.ReadCloser {
+	return &transferReader{reader: r, stats: t, username: username, direction: direction}
+}
+
+// transferReader counts the bytes read through it and records them on close
+type transferReader struct {
+	reader    io.Reader
+	stats     *TransferStats
+	username  string
+	direction string
+	n         int64
+	once      sync.Once
+}
+
+// Read implements io.Reader
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer and closes the wrapped reader if it is closable
+func (r *transferReader) Close() error {
+	var err error
+	if closer, ok := r.reader.(io.Closer); ok {
+		err = closer.Close()
+	}
+	r.once.Do(func() {
+		r.stats.Add(r.username, r.direction, r.n)
+	})
+	return err
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+	"Noooste/garage-ui/pkg/logger"
+)
+
+// trashTimestampFormat is the sortable timestamp folder trashed objects are stored under
+const trashTimestampFormat = "20060102T150405.000000000Z"
+
+// trashListLimit bounds how many trashed objects a single listing or sweep walks
+const trashListLimit = 1000
+
+// ErrRestoreConflict is returned when restoring would overwrite an existing object
+var ErrRestoreConflict = errors.New("an object already exists at the original key")
+
+// TrashService implements soft-delete for buckets that enable it in their settings.
+// Trashed objects are server-side copied to <prefix><timestamp>/<original-key> in the
+// same bucket, so Garage needs no versioning support.
+type TrashService struct {
+	s3Service     *S3Service
+	settingsStore *SettingsStore
+	config        *config.TrashConfig
+
+	// clock stamps trashed objects and decides when they are past the retention
+	clock clock.Clock
+}
+
+// NewTrashService creates a new trash service whose retention is measured against clk
+func NewTrashService(s3Service *S3Service, settingsStore *SettingsStore, cfg *config.TrashConfig, clk clock.Clock) *TrashService {
+	return &TrashService{
+		s3Service:     s3Service,
+		settingsStore: settingsStore,
+		config:        cfg,
+		clock:         clk,
+	}
+}
+
+// Enabled reports whether deletions in the bucket should go to the trash
+func (t *TrashService) Enabled(bucketName string) bool {
+	settings, ok := t.settingsStore.GetBucketSettings(bucketName)
+	return ok && settings.TrashEnabled
+}
+
+// IsTrashKey reports whether a key lives inside the trash prefix
+func (t *TrashService) IsTrashKey(key string) bool {
+	return strings.HasPrefix(key, t.config.Prefix)
+}
+
+// CoversTrash reports whether deleting every key under the prefix would reach into the trash
+func (t *TrashService) CoversTrash(prefix string) bool {
+	return t.IsTrashKey(prefix) || strings.HasPrefix(t.config.Prefix, prefix)
+}
+
+// MoveToTrash copies an object into the trash and deletes the original.
+// Objects already in the trash are deleted permanently; handlers only let admins do so.
+func (t *TrashService) MoveToTrash(ctx context.Context, bucketName, key string) (string, error) {
+	if t.IsTrashKey(key) {
+		return "", t.s3Service.DeleteObject(ctx, bucketName, key)
+	}
+
+	trashKey := t.config.Prefix + t.clock.Now().UTC().Format(trashTimestampFormat) + "/" + key
+	if err := t.s3Service.CopyObject(ctx, bucketName, key, bucketName, trashKey); err != nil {
+		return "", fmt.Errorf("failed to move object to trash: %w", err)
+	}
+
+	if err := t.s3Service.DeleteObject(ctx, bucketName, key); err != nil {
+		return "", fmt.Errorf("object copied to trash but original not deleted: %w", err)
+	}
+
+	return trashKey, nil
+}
+
+// ListTrash lists the trashed objects of a bucket, oldest first
+func (t *TrashService) ListTrash(ctx context.Context, bucketName string) (*models.TrashListResponse, error) {
+	objects, truncated, err := t.s3Service.ListObjectsRecursive(ctx, bucketName, t.config.Prefix, trashListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.TrashItem, 0, len(objects))
+	for _, obj := range objects {
+		item, ok := t.parseTrashKey(obj.Key)
+		if !ok {
+			continue
+		}
+		item.Size = obj.Size
+		items = append(items, item)
+	}
+
+	return &models.TrashListResponse{
+		Bucket:      bucketName,
+		Items:       items,
+		Count:       len(items),
+		IsTruncated: truncated,
+	}, nil
+}
+
+// Restore moves a trashed object back to its original key and returns that key
+func (t *TrashService) Restore(ctx context.Context, bucketName, trashKey string, overwrite bool) (string, error) {
+	item, ok := t.parseTrashKey(trashKey)
+	if !ok {
+		return "", fmt.Errorf("%s is not a trash key", trashKey)
+	}
+
+	if !overwrite {
+		exists, err := t.s3Service.ObjectExists(ctx, bucketName, item.OriginalKey)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", ErrRestoreConflict
+		}
+	}
+
+	if err := t.s3Service.CopyObject(ctx, bucketName, trashKey, bucketName, item.OriginalKey); err != nil {
+		return "", fmt.Errorf("failed to restore object: %w", err)
+	}
+
+	if err := t.s3Service.DeleteObject(ctx, bucketName, trashKey); err != nil {
+		return "", fmt.Errorf("object restored but trash entry not deleted: %w", err)
+	}
+
+	return item.OriginalKey, nil
+}
+
+// RunSweeper periodically purges trashed objects older than the retention until ctx is done
+func (t *TrashService) RunSweeper(ctx context.Context) {
+	ticker := t.clock.NewTicker(t.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			t.sweep(ctx)
+		}
+	}
+}
+
+// sweep purges expired trash in every bucket that has trash enabled
+func (t *TrashService) sweep(ctx context.Context) {
+	cutoff := t.clock.Now().Add(-t.config.Retention)
+
+	for bucketName, settings := range t.settingsStore.ListBucketSettings() {
+		if !settings.TrashEnabled {
+			continue
+		}
+
+		trash, err := t.ListTrash(ctx, bucketName)
+		if err != nil {
+			logger.Warn().Err(err).Str("bucket", bucketName).Msg("Failed to list trash for sweeping")
+			continue
+		}
+
+		// Entries are listed oldest first, so stop at the first one still within retention
+		var expired []string
+		for _, item := range trash.Items {
+			if item.DeletedAt.After(cutoff) {
+				break
+			}
+			expired = append(expired, item.TrashKey)
+		}
+		if len(expired) == 0 {
+			continue
+		}
+
+		if err := t.s3Service.DeleteMultipleObjects(ctx, bucketName, expired); err != nil {
+			logger.Warn().Err(err).Str("bucket", bucketName).Msg("Failed to purge expired trash")
+			continue
+		}
+
+		logger.Info().Str("bucket", bucketName).Int("purged", len(expired)).Msg("Purged expired trash")
+	}
+}
+
+// parseTrashKey extracts the original key and deletion time from a trash key
+func (t *TrashService) parseTrashKey(trashKey string) (models.TrashItem, bool) {
+	rest, ok := strings.CutPrefix(trashKey, t.config.Prefix)
+	if !ok {
+		return models.TrashItem{}, false
+	}
+
+	timestamp, originalKey, ok := strings.Cut(rest, "/")
+	if !ok || originalKey == "" {
+		return models.TrashItem{}, false
+	}
+
+	deletedAt, err := time.Parse(trashTimestampFormat, timestamp)
+	if err != nil {
+		return models.TrashItem{}, false
+	}
+
+	return models.TrashItem{
+		TrashKey:    trashKey,
+		OriginalKey: originalKey,
+		DeletedAt:   deletedAt,
+	}, true
+}
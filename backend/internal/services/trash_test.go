@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+)
+
+func TestTrashCoversTrash(t *testing.T) {
+	trash := NewTrashService(nil, nil, &config.TrashConfig{Prefix: ".trash/"}, clock.Real)
+
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{prefix: ".trash/", want: true},
+		{prefix: ".trash/20260101T000000.000000000Z/", want: true},
+		{prefix: ".trash", want: true},
+		{prefix: ".", want: true},
+		{prefix: "docs/", want: false},
+		{prefix: ".trashed/", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := trash.CoversTrash(tt.prefix); got != tt.want {
+			t.Errorf("CoversTrash(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestTrashSweepRetention(t *testing.T) {
+	g := newFakeGarage(t)
+	g.AddBucket("docs")
+	g.GrantKey("docs", "GKowner", true, nil)
+	g.PutObject("docs", "old.txt", "text/plain", []byte("old"))
+	g.PutObject("docs", "new.txt", "text/plain", []byte("new"))
+
+	store, err := NewSettingsStore("", nil, g.clock)
+	if err != nil {
+		t.Fatalf("NewSettingsStore failed: %v", err)
+	}
+	if err := store.SetBucketSettings("docs", models.BucketSettings{TrashEnabled: true}); err != nil {
+		t.Fatalf("SetBucketSettings failed: %v", err)
+	}
+	trash := NewTrashService(g.s3, store, &config.TrashConfig{Prefix: ".trash/", Retention: 24 * time.Hour}, g.clock)
+
+	ctx := context.Background()
+	oldKey, err := trash.MoveToTrash(ctx, "docs", "old.txt")
+	if err != nil {
+		t.Fatalf("MoveToTrash failed: %v", err)
+	}
+	g.clock.Advance(time.Second)
+	newKey, err := trash.MoveToTrash(ctx, "docs", "new.txt")
+	if err != nil {
+		t.Fatalf("MoveToTrash failed: %v", err)
+	}
+
+	// The older object reaches the retention, the newer one is a second short of it
+	g.clock.Advance(24*time.Hour - time.Second)
+	trash.sweep(ctx)
+
+	keys := g.ObjectKeys("docs")
+	if slices.Contains(keys, oldKey) {
+		t.Errorf("%s was kept past the retention", oldKey)
+	}
+	if !slices.Contains(keys, newKey) {
+		t.Errorf("%s was swept within the retention", newKey)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// SniffLength is how much of an upload SniffContentType looks at, as http.DetectContentType
+const SniffLength = 512
+
+// executableSignatures are the magic numbers of executables, which http.DetectContentType
+// reports as plain binary data. Each comes with the extension blocked_extensions rules match.
+var executableSignatures = []struct {
+	magic       []byte
+	contentType string
+	extension   string
+}{
+	{[]byte("MZ"), "application/x-msdownload", ".exe"},
+	{[]byte("\x7fELF"), "application/x-executable", ".elf"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary", ".macho"},
+	{[]byte("\xca\xfe\xba\xbe"), "application/x-mach-binary", ".macho"},
+	{[]byte("#!"), "text/x-shellscript", ".sh"},
+}
+
+// genericContentTypes are sniffed types that say nothing about a file; the declared type is
+// trusted over them
+var genericContentTypes = []string{"application/octet-stream", "text/plain"}
+
+// UploadBlockedError is returned for uploads refused by a bucket's file type rules
+type UploadBlockedError struct {
+	Rule string // The rule that refused the upload, e.g. blockedExtensions "*.exe"
+}
+
+// Error implements the error interface
+func (e *UploadBlockedError) Error() string {
+	return "upload refused by " + e.Rule
+}
+
+// SniffContentType detects the content type of a file from its first bytes, without
+// parameters such as the charset
+func SniffContentType(head []byte) string {
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(head, signature.magic) {
+			return signature.contentType
+		}
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType
+}
+
+// CheckUpload applies the allowedContentTypes and blockedExtensions settings of a bucket to
+// an upload. Both the declared and the sniffed content type are checked, so renaming a file
+// does not get it through: a sniffed executable is refused as if it had its usual extension.
+func (s *SettingsStore) CheckUpload(bucketName, key, declaredType, sniffedType string) error {
+	settings, _ := s.GetBucketSettings(bucketName)
+	if len(settings.AllowedContentTypes) == 0 && len(settings.BlockedExtensions) == 0 {
+		return nil
+	}
+
+	declaredType = strings.ToLower(strings.TrimSpace(strings.Split(declaredType, ";")[0]))
+	sniffedType = strings.ToLower(sniffedType)
+
+	// The key's name, plus names carrying the extensions of the sniffed type
+	names := []string{strings.ToLower(path.Base(key))}
+	for _, extension := range sniffedExtensions(sniffedType) {
+		names = append(names, "file"+extension)
+	}
+	for _, pattern := range settings.BlockedExtensions {
+		for _, name := range names {
+			if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+				return &UploadBlockedError{Rule: fmt.Sprintf("blockedExtensions %q", pattern)}
+			}
+		}
+	}
+
+	if len(settings.AllowedContentTypes) == 0 {
+		return nil
+	}
+	types := []string{declaredType}
+	if !slices.Contains(genericContentTypes, sniffedType) {
+		types = append(types, sniffedType)
+	}
+	for _, contentType := range types {
+		if contentType == "" {
+			continue
+		}
+		if !matchesAny(settings.AllowedContentTypes, contentType) {
+			return &UploadBlockedError{Rule: fmt.Sprintf("allowedContentTypes (%s is not allowed)", contentType)}
+		}
+	}
+	return nil
+}
+
+// sniffedExtensions returns the extensions files of a sniffed type usually have
+func sniffedExtensions(contentType string) []string {
+	if slices.Contains(genericContentTypes, contentType) {
+		return nil
+	}
+	for _, signature := range executableSignatures {
+		if signature.contentType == contentType {
+			return []string{signature.extension}
+		}
+	}
+	extensions, _ := mime.ExtensionsByType(contentType)
+	return extensions
+}
+
+// matchesAny reports whether value matches one of the glob patterns, ignoring case
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/clock"
+)
+
+// UploadProgressRetention is how long the outcome of an upload can still be read after it
+// finished, so that a watcher connecting late sees how it ended
+const UploadProgressRetention = time.Minute
+
+// uploadTracker is the progress of one upload
+type uploadTracker struct {
+	username    string
+	bucket      string
+	key         string
+	total       int64
+	transferred atomic.Int64
+
+	mu         sync.Mutex
+	finishedAt time.Time // Zero while the upload runs
+	err        string
+}
+
+// UploadProgress tracks how far uploads proxied to Garage have got, by an ID the client
+// chooses. Only the bytes relayed to Garage are counted: the request body has been
+// received in full before the upload starts.
+type UploadProgress struct {
+	mu      sync.Mutex
+	uploads map[string]*uploadTracker
+
+	// clock stamps finished uploads and decides when they are forgotten
+	clock clock.Clock
+}
+
+// NewUploadProgress creates an empty upload progress tracker, retaining finished uploads
+// by clk
+func NewUploadProgress(clk clock.Clock) *UploadProgress {
+	return &UploadProgress{uploads: make(map[string]*uploadTracker), clock: clk}
+}
+
+// Track registers an upload of total bytes and returns body wrapped so that reading it
+// advances the progress. Rewinding the body, as retries do, moves the progress back. An
+// earlier upload with the same ID is replaced. Finish must be called once the upload ends.
+func (p *UploadProgress) Track(id, username, bucketName, key string, total int64, body io.ReadSeeker) io.ReadSeeker {
+	tracker := &uploadTracker{username: username, bucket: bucketName, key: key, total: total}
+
+	p.mu.Lock()
+	p.prune()
+	p.uploads[id] = tracker
+	p.mu.Unlock()
+
+	return &progressReader{body: body, tracker: tracker}
+}
+
+// Finish records the outcome of an upload; err is nil when it succeeded
+func (p *UploadProgress) Finish(id string, err error) {
+	p.mu.Lock()
+	tracker, ok := p.uploads[id]
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.finishedAt = p.clock.Now()
+	if err != nil {
+		tracker.err = err.Error()
+	}
+}
+
+// Get returns the progress of an upload of the user, and whether there is one
+func (p *UploadProgress) Get(id, username string) (models.UploadProgress, bool) {
+	p.mu.Lock()
+	tracker, ok := p.uploads[id]
+	p.mu.Unlock()
+	if !ok || tracker.username != username {
+		return models.UploadProgress{}, false
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return models.UploadProgress{
+		UploadID:    id,
+		Bucket:      tracker.bucket,
+		Key:         tracker.key,
+		Total:       tracker.total,
+		Transferred: tracker.transferred.Load(),
+		Done:        !tracker.finishedAt.IsZero(),
+		Error:       tracker.err,
+	}, true
+}
+
+// prune forgets uploads that finished more than UploadProgressRetention ago. p.mu must be held.
+func (p *UploadProgress) prune() {
+	cutoff := p.clock.Now().Add(-UploadProgressRetention)
+	for id, tracker := range p.uploads {
+		tracker.mu.Lock()
+		expired := !tracker.finishedAt.IsZero() && tracker.finishedAt.Before(cutoff)
+		tracker.mu.Unlock()
+		if expired {
+			delete(p.uploads, id)
+		}
+	}
+}
+
+// progressReader advances the progress of an upload as its body is read
+type progressReader struct {
+	body    io.ReadSeeker
+	tracker *uploadTracker
+}
+
+// Read implements io.Reader
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.body.Read(b)
+	r.tracker.transferred.Add(int64(n))
+	return n, err
+}
+
+// Seek implements io.Seeker
+func (r *progressReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.body.Seek(offset, whence)
+	if err == nil {
+		r.tracker.transferred.Store(position)
+	}
+	return position, err
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/pkg/clock"
+)
+
+func TestUploadProgressRetention(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	progress := NewUploadProgress(clk)
+
+	progress.Track("done", "alice", "docs", "a.txt", 1, strings.NewReader("a"))
+	progress.Finish("done", nil)
+
+	// Finished uploads are pruned when another upload starts
+	clk.Advance(UploadProgressRetention)
+	progress.Track("next", "alice", "docs", "b.txt", 1, strings.NewReader("b"))
+	if upload, ok := progress.Get("done", "alice"); !ok || !upload.Done {
+		t.Errorf("Get = %+v, %v at the end of the retention, want the finished upload", upload, ok)
+	}
+
+	clk.Advance(time.Nanosecond)
+	progress.Track("last", "alice", "docs", "c.txt", 1, strings.NewReader("c"))
+	if _, ok := progress.Get("done", "alice"); ok {
+		t.Error("finished upload kept past the retention")
+	}
+	if _, ok := progress.Get("next", "alice"); !ok {
+		t.Error("running upload was pruned")
+	}
+}
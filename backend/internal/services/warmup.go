@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/config"
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/logger"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// cacheRefreshMargin is how long before the bucket info cache expires it gets refreshed
+const cacheRefreshMargin = 5 * time.Second
+
+// CacheWarmer pre-populates the bucket info cache, which holds the statistics shown on the
+// dashboard, shortly after startup and then refreshes it just before it expires, so users
+// are served from the cache instead of waiting for the Admin API
+type CacheWarmer struct {
+	config       *config.MonitoringConfig
+	adminService *GarageAdminService
+
+	mu     sync.RWMutex
+	status models.CacheWarmupStatus
+}
+
+// NewCacheWarmer creates a new cache warmer
+func NewCacheWarmer(cfg *config.MonitoringConfig, adminService *GarageAdminService) *CacheWarmer {
+	return &CacheWarmer{
+		config:       cfg,
+		adminService: adminService,
+		status:       models.CacheWarmupStatus{State: "pending"},
+	}
+}
+
+// Status returns the progress of the warm-up, or nil if it is disabled
+func (w *CacheWarmer) Status() *models.CacheWarmupStatus {
+	if !w.config.WarmCache {
+		return nil
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	status := w.status
+	return &status
+}
+
+// Run warms the cache after the configured delay and keeps it fresh until ctx is done.
+// It returns at once unless monitoring.warm_cache is enabled.
+func (w *CacheWarmer) Run(ctx context.Context) {
+	if !w.config.WarmCache {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(w.config.WarmDelay):
+	}
+
+	w.warm(ctx)
+
+	ticker := time.NewTicker(bucketInfoCacheTTL - cacheRefreshMargin)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.warm(ctx)
+		}
+	}
+}
+
+// warm refreshes the info of every bucket with bounded concurrency
+func (w *CacheWarmer) warm(ctx context.Context) {
+	start := time.Now().UTC()
+
+	w.mu.RLock()
+	initial := w.status.Runs == 0
+	w.mu.RUnlock()
+
+	buckets, _, err := w.adminService.ListBuckets(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Cache warm-up failed to list buckets")
+		w.finish(start, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.status.State = "warming"
+	w.status.Buckets = len(buckets)
+	w.status.Warmed = 0
+	w.status.Failed = 0
+	w.mu.Unlock()
+
+	if initial {
+		logger.Info().Int("buckets", len(buckets)).Msg("Warming bucket statistics cache")
+	}
+
+	var lastErr error
+	var group errgroup.Group
+	group.SetLimit(w.config.WarmConcurrency)
+	for _, bucket := range buckets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		group.Go(func() error {
+			_, err := w.adminService.RefreshBucketInfo(ctx, bucket.ID)
+
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if err != nil {
+				w.status.Failed++
+				lastErr = err
+			} else {
+				w.status.Warmed++
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	status := w.finish(start, lastErr)
+	if initial {
+		event := logger.Info()
+		if status.Failed > 0 {
+			event = logger.Warn().Err(lastErr)
+		}
+		event.Int("warmed", status.Warmed).Int("failed", status.Failed).Dur("duration", time.Since(start)).Msg("Bucket statistics cache warmed")
+	}
+}
+
+// finish records the outcome of a run and returns the resulting status
+func (w *CacheWarmer) finish(start time.Time, err error) models.CacheWarmupStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status.Runs++
+	w.status.LastRunAt = &start
+	w.status.LastDurationMs = time.Since(start).Milliseconds()
+	w.status.LastError = ""
+	if err != nil {
+		w.status.LastError = err.Error()
+	}
+
+	// A run where nothing could be refreshed leaves users on the cold path again
+	if err != nil && w.status.Warmed == 0 {
+		w.status.State = "failed"
+	} else {
+		w.status.State = "ready"
+	}
+	return w.status
+}
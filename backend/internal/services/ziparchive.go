@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+	"Noooste/garage-ui/pkg/utils"
+)
+
+// Bounds of a resumable ZIP download. ZIP64 is not written, so an archive holds at most
+// 65535 entries and every offset in it, hence the whole archive, stays below 4 GiB.
+const (
+	ZipDefaultPartSize = 64 << 20
+	ZipMinPartSize     = 1 << 20
+	ZipMaxPartSize     = 1 << 30
+	ZipMaxEntries      = math.MaxUint16
+	zipMaxArchiveSize  = math.MaxUint32
+)
+
+// zipArchiveCachePrefix namespaces prepared ZIP downloads in the global cache
+const zipArchiveCachePrefix = "zip:"
+
+// Sizes of the fixed parts of the ZIP records written, before the entry name
+const (
+	zipLocalHeaderLen   = 30
+	zipDescriptorLen    = 16
+	zipCentralHeaderLen = 46
+	zipEndRecordLen     = 22
+)
+
+// Every entry is stored (not compressed) with a data descriptor and a UTF-8 name
+const (
+	zipVersion = 20
+	zipFlags   = 0x0008 | 0x0800
+)
+
+var (
+	// ErrZipArchiveTooLarge is returned when the selected objects do not fit in an archive
+	// written without ZIP64
+	ErrZipArchiveTooLarge = errors.New("the archive would exceed 4 GiB or 65535 entries")
+
+	// ErrZipObjectChanged is returned when an object of a prepared archive was modified or
+	// removed, since the parts served before would no longer match
+	ErrZipObjectChanged = errors.New("an object of the archive changed since it was prepared")
+)
+
+// zipEntry is one object of a prepared archive
+type zipEntry struct {
+	key      string
+	name     string // Path of the entry in the archive, see zipEntryName
+	size     int64
+	etag     string
+	modified time.Time
+	offset   int64 // Offset of the local header in the archive
+}
+
+// zipMaxEntryName is the longest entry name written, the usual limit of a file name
+const zipMaxEntryName = 255
+
+// zipEntryName returns the path an object is stored under in an archive: its key cleaned
+// into a relative path, without a leading "/" or ".." segments, so that extracting the
+// archive cannot write outside the target folder. Keys whose path is empty or longer than
+// zipMaxEntryName bytes are stored under zipHashedName of the key; the manifest of a
+// prepared archive maps them back to their key.
+func zipEntryName(key string) string {
+	name := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if name != "" && len(name) <= zipMaxEntryName {
+		return name
+	}
+	return zipHashedName(key, 1)
+}
+
+// zipHashedName returns the SHA-256 of key, keeping a short extension. The nth name of a
+// key (n > 1) has "-n" before the extension.
+func zipHashedName(key string, n int) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	if n > 1 {
+		name += "-" + strconv.Itoa(n)
+	}
+	if ext := path.Ext(key); len(ext) > 1 && len(ext) <= 16 && !strings.Contains(ext, "/") {
+		name += ext
+	}
+	return name
+}
+
+// zipEntryNames hands out the entry names of one archive. Keys cleaned into the same path,
+// such as "a//b" and "a/b" or "x/../y" and "y", would otherwise be stored under the same
+// name and overwrite each other when extracted.
+type zipEntryNames map[string]bool
+
+// name returns zipEntryName of key, or when an earlier entry of the archive took that name,
+// zipHashedName of the key, counting up until the name is free
+func (names zipEntryNames) name(key string) string {
+	name := zipEntryName(key)
+	for n := 1; names[name]; n++ {
+		name = zipHashedName(key, n)
+	}
+	names[name] = true
+	return name
+}
+
+// ZipArchive is a ZIP download prepared from a fixed list of objects. Its layout depends
+// only on the ordered keys, sizes and modification times, so any byte range of it can be
+// regenerated on its own: entries are stored uncompressed in key order, and the CRC-32 of
+// an entry, which goes in its data descriptor after the data and in the central directory,
+// is computed from the object when first needed and kept with the archive.
+type ZipArchive struct {
+	Bucket    string
+	PartSize  int64
+	Size      int64
+	ExpiresAt time.Time
+
+	username      string
+	entries       []zipEntry
+	centralOffset int64
+	centralSize   int64
+
+	mu   sync.Mutex
+	crcs map[int]uint32
+}
+
+// PrepareZipArchive lays out an archive of objects and stores it under a new token for ttl.
+// Folder markers are left out; the remaining objects are ordered by key and stored under
+// the names zipEntryNames hands out.
+func PrepareZipArchive(bucketName, username string, objects []models.ObjectInfo, partSize int64, ttl time.Duration) (string, *ZipArchive, error) {
+	objects = slices.DeleteFunc(slices.Clone(objects), func(obj models.ObjectInfo) bool {
+		return strings.HasSuffix(obj.Key, "/")
+	})
+	slices.SortFunc(objects, func(a, b models.ObjectInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	if len(objects) > ZipMaxEntries {
+		return "", nil, ErrZipArchiveTooLarge
+	}
+
+	archive := &ZipArchive{
+		Bucket:    bucketName,
+		PartSize:  partSize,
+		ExpiresAt: utils.GlobalCache.Now().UTC().Add(ttl),
+		username:  username,
+		entries:   make([]zipEntry, len(objects)),
+		crcs:      make(map[int]uint32),
+	}
+
+	var offset int64
+	names := make(zipEntryNames)
+	for i, obj := range objects {
+		name := names.name(obj.Key)
+		archive.entries[i] = zipEntry{
+			key:      obj.Key,
+			name:     name,
+			size:     obj.Size,
+			etag:     obj.ETag,
+			modified: obj.LastModified.UTC(),
+			offset:   offset,
+		}
+		offset += zipLocalHeaderLen + int64(len(name)) + obj.Size + zipDescriptorLen
+		archive.centralSize += zipCentralHeaderLen + int64(len(name))
+	}
+	archive.centralOffset = offset
+	archive.Size = offset + archive.centralSize + zipEndRecordLen
+	if archive.Size > zipMaxArchiveSize {
+		return "", nil, ErrZipArchiveTooLarge
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to generate archive token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	utils.GlobalCache.Set(zipArchiveCachePrefix+token, archive, ttl)
+	return token, archive, nil
+}
+
+// LookupZipArchive returns the archive prepared under token by username, if it has not expired
+func LookupZipArchive(token, username string) (*ZipArchive, bool) {
+	archive, ok := utils.GlobalCache.Get(zipArchiveCachePrefix + token).(*ZipArchive)
+	if !ok || archive.username != username {
+		return nil, false
+	}
+	return archive, true
+}
+
+// Manifest describes the archive for the client that prepared it
+func (a *ZipArchive) Manifest(token string) models.ZipDownloadManifest {
+	entries := make([]models.ZipManifestEntry, len(a.entries))
+	for i, entry := range a.entries {
+		entries[i] = models.ZipManifestEntry{Key: entry.key, Name: entry.name, Size: entry.size}
+	}
+	return models.ZipDownloadManifest{
+		Token:     token,
+		Bucket:    a.Bucket,
+		Entries:   entries,
+		Size:      a.Size,
+		PartSize:  a.PartSize,
+		Parts:     a.Parts(),
+		ExpiresAt: a.ExpiresAt,
+	}
+}
+
+// Parts returns the number of parts of the archive; only the last one may be shorter
+func (a *ZipArchive) Parts() int {
+	return int((a.Size + a.PartSize - 1) / a.PartSize)
+}
+
+// PartRange returns the archive bytes [start, end) covered by part, counted from 0
+func (a *ZipArchive) PartRange(part int) (start, end int64, err error) {
+	if part < 0 || part >= a.Parts() {
+		return 0, 0, fmt.Errorf("part must be between 0 and %d", a.Parts()-1)
+	}
+	start = int64(part) * a.PartSize
+	return start, min(start+a.PartSize, a.Size), nil
+}
+
+// PreparePart checks that the objects a part is built from are unchanged and computes the
+// CRC-32s it needs but cannot get from its own data, so that WritePart can stream without
+// failing halfway on a stale archive
+func (a *ZipArchive) PreparePart(ctx context.Context, s3Service *S3Service, part int) error {
+	start, end, err := a.PartRange(part)
+	if err != nil {
+		return err
+	}
+
+	var touched, missing []int
+	centralInRange := overlaps(a.centralOffset, a.centralSize, start, end)
+	for i, entry := range a.entries {
+		dataStart := a.dataOffset(i)
+		dataInRange := overlaps(dataStart, entry.size, start, end)
+		descriptorInRange := overlaps(dataStart+entry.size, zipDescriptorLen, start, end)
+		if dataInRange {
+			touched = append(touched, i)
+		}
+
+		// An entry whose data is sent whole computes its CRC before its descriptor is written
+		dataWhole := entry.size == 0 || (dataStart >= start && dataStart+entry.size <= end)
+		if (descriptorInRange || centralInRange) && !dataWhole {
+			if _, known := a.crc(i); !known {
+				missing = append(missing, i)
+				if !dataInRange {
+					touched = append(touched, i)
+				}
+			}
+		}
+	}
+
+	if err := a.checkUnchanged(ctx, s3Service, touched); err != nil {
+		return err
+	}
+	for _, i := range missing {
+		if err := a.computeCRC(ctx, s3Service, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePart writes a part to w. PreparePart must have been called for it first.
+func (a *ZipArchive) WritePart(ctx context.Context, s3Service *S3Service, w io.Writer, part int) error {
+	start, end, err := a.PartRange(part)
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range a.entries {
+		if err := writeOverlap(w, a.localHeader(entry), entry.offset, start, end); err != nil {
+			return err
+		}
+		if err := a.writeData(ctx, s3Service, w, i, start, end); err != nil {
+			return err
+		}
+
+		descriptorOffset := a.dataOffset(i) + entry.size
+		if overlaps(descriptorOffset, zipDescriptorLen, start, end) {
+			if err := writeOverlap(w, a.descriptor(i), descriptorOffset, start, end); err != nil {
+				return err
+			}
+		}
+	}
+
+	if overlaps(a.centralOffset, a.centralSize, start, end) {
+		offset := a.centralOffset
+		for i, entry := range a.entries {
+			header := a.centralHeader(i, entry)
+			if err := writeOverlap(w, header, offset, start, end); err != nil {
+				return err
+			}
+			offset += int64(len(header))
+		}
+	}
+	return writeOverlap(w, a.endRecord(), a.centralOffset+a.centralSize, start, end)
+}
+
+// writeData writes the part of an entry's data that falls in [start, end). Data sent whole
+// has its CRC-32 computed on the way.
+func (a *ZipArchive) writeData(ctx context.Context, s3Service *S3Service, w io.Writer, i int, start, end int64) error {
+	entry := a.entries[i]
+	dataStart := a.dataOffset(i)
+	if !overlaps(dataStart, entry.size, start, end) {
+		return nil
+	}
+
+	from := max(start, dataStart) - dataStart
+	to := min(end, dataStart+entry.size) - dataStart
+	body, err := s3Service.GetObjectRange(ctx, a.Bucket, entry.key, from, to-from)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if from > 0 || to < entry.size {
+		_, err = io.CopyN(w, body, to-from)
+		return err
+	}
+
+	hash := crc32.NewIEEE()
+	if _, err := io.CopyN(io.MultiWriter(w, hash), body, entry.size); err != nil {
+		return err
+	}
+	a.setCRC(i, hash.Sum32())
+	return nil
+}
+
+// checkUnchanged fails with ErrZipObjectChanged if any of the entries was modified or removed
+func (a *ZipArchive) checkUnchanged(ctx context.Context, s3Service *S3Service, indexes []int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(indexes))
+	for j, i := range indexes {
+		keys[j] = a.entries[i].key
+	}
+	results, err := s3Service.GetObjectsMetadata(ctx, a.Bucket, keys)
+	if err != nil {
+		return err
+	}
+
+	for j, result := range results {
+		entry := a.entries[indexes[j]]
+		if result.Object == nil || result.Object.ETag != entry.etag || result.Object.Size != entry.size {
+			return fmt.Errorf("%w: %s", ErrZipObjectChanged, entry.key)
+		}
+	}
+	return nil
+}
+
+// computeCRC reads an entry's object whole to compute its CRC-32
+func (a *ZipArchive) computeCRC(ctx context.Context, s3Service *S3Service, i int) error {
+	entry := a.entries[i]
+	body, info, err := s3Service.GetObject(ctx, a.Bucket, entry.key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if info.ETag != entry.etag || info.Size != entry.size {
+		return fmt.Errorf("%w: %s", ErrZipObjectChanged, entry.key)
+	}
+
+	hash := crc32.NewIEEE()
+	if _, err := io.CopyN(hash, body, entry.size); err != nil {
+		return fmt.Errorf("failed to read object %s: %w", entry.key, err)
+	}
+	a.setCRC(i, hash.Sum32())
+	return nil
+}
+
+// crc returns the CRC-32 of an entry if it is known. Empty entries always have a CRC of 0.
+func (a *ZipArchive) crc(i int) (uint32, bool) {
+	if a.entries[i].size == 0 {
+		return 0, true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	crc, ok := a.crcs[i]
+	return crc, ok
+}
+
+// setCRC keeps the CRC-32 of an entry for the parts fetched later
+func (a *ZipArchive) setCRC(i int, crc uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.crcs[i] = crc
+}
+
+// dataOffset returns the offset of an entry's data in the archive
+func (a *ZipArchive) dataOffset(i int) int64 {
+	return a.entries[i].offset + zipLocalHeaderLen + int64(len(a.entries[i].name))
+}
+
+// localHeader encodes the local file header of an entry. The CRC and sizes are left at 0
+// as they follow the data in its descriptor.
+func (a *ZipArchive) localHeader(entry zipEntry) []byte {
+	modTime, modDate := dosTime(entry.modified)
+
+	b := make([]byte, 0, zipLocalHeaderLen+len(entry.name))
+	b = binary.LittleEndian.AppendUint32(b, 0x04034b50)
+	b = binary.LittleEndian.AppendUint16(b, zipVersion)
+	b = binary.LittleEndian.AppendUint16(b, zipFlags)
+	b = binary.LittleEndian.AppendUint16(b, 0) // Stored
+	b = binary.LittleEndian.AppendUint16(b, modTime)
+	b = binary.LittleEndian.AppendUint16(b, modDate)
+	b = binary.LittleEndian.AppendUint32(b, 0) // CRC-32
+	b = binary.LittleEndian.AppendUint32(b, 0) // Compressed size
+	b = binary.LittleEndian.AppendUint32(b, 0) // Uncompressed size
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(entry.name)))
+	b = binary.LittleEndian.AppendUint16(b, 0) // Extra field length
+	return append(b, entry.name...)
+}
+
+// descriptor encodes the data descriptor following an entry's data
+func (a *ZipArchive) descriptor(i int) []byte {
+	crc, _ := a.crc(i)
+	size := uint32(a.entries[i].size)
+
+	b := make([]byte, 0, zipDescriptorLen)
+	b = binary.LittleEndian.AppendUint32(b, 0x08074b50)
+	b = binary.LittleEndian.AppendUint32(b, crc)
+	b = binary.LittleEndian.AppendUint32(b, size)
+	return binary.LittleEndian.AppendUint32(b, size)
+}
+
+// centralHeader encodes the central directory header of an entry
+func (a *ZipArchive) centralHeader(i int, entry zipEntry) []byte {
+	modTime, modDate := dosTime(entry.modified)
+	crc, _ := a.crc(i)
+
+	b := make([]byte, 0, zipCentralHeaderLen+len(entry.name))
+	b = binary.LittleEndian.AppendUint32(b, 0x02014b50)
+	b = binary.LittleEndian.AppendUint16(b, zipVersion) // Version made by
+	b = binary.LittleEndian.AppendUint16(b, zipVersion) // Version needed
+	b = binary.LittleEndian.AppendUint16(b, zipFlags)
+	b = binary.LittleEndian.AppendUint16(b, 0) // Stored
+	b = binary.LittleEndian.AppendUint16(b, modTime)
+	b = binary.LittleEndian.AppendUint16(b, modDate)
+	b = binary.LittleEndian.AppendUint32(b, crc)
+	b = binary.LittleEndian.AppendUint32(b, uint32(entry.size))
+	b = binary.LittleEndian.AppendUint32(b, uint32(entry.size))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(entry.name)))
+	b = binary.LittleEndian.AppendUint16(b, 0) // Extra field length
+	b = binary.LittleEndian.AppendUint16(b, 0) // Comment length
+	b = binary.LittleEndian.AppendUint16(b, 0) // Disk number
+	b = binary.LittleEndian.AppendUint16(b, 0) // Internal attributes
+	b = binary.LittleEndian.AppendUint32(b, 0) // External attributes
+	b = binary.LittleEndian.AppendUint32(b, uint32(entry.offset))
+	return append(b, entry.name...)
+}
+
+// endRecord encodes the end of central directory record
+func (a *ZipArchive) endRecord() []byte {
+	b := make([]byte, 0, zipEndRecordLen)
+	b = binary.LittleEndian.AppendUint32(b, 0x06054b50)
+	b = binary.LittleEndian.AppendUint16(b, 0) // Disk number
+	b = binary.LittleEndian.AppendUint16(b, 0) // Disk holding the central directory
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(a.entries)))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(a.entries)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(a.centralSize))
+	b = binary.LittleEndian.AppendUint32(b, uint32(a.centralOffset))
+	return binary.LittleEndian.AppendUint16(b, 0) // Comment length
+}
+
+// dosTime converts a UTC time to the MS-DOS time and date of ZIP headers, which cannot
+// represent anything before 1980
+func dosTime(t time.Time) (uint16, uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	modTime := uint16(t.Hour()<<11 | t.Minute()<<5 | t.Second()/2)
+	modDate := uint16((t.Year()-1980)<<9 | int(t.Month())<<5 | t.Day())
+	return modTime, modDate
+}
+
+// overlaps reports whether the length bytes at offset intersect [start, end)
+func overlaps(offset, length, start, end int64) bool {
+	return length > 0 && offset < end && offset+length > start
+}
+
+// writeOverlap writes the bytes of a record at offset that fall in [start, end)
+func writeOverlap(w io.Writer, record []byte, offset, start, end int64) error {
+	if !overlaps(offset, int64(len(record)), start, end) {
+		return nil
+	}
+	from := max(start, offset) - offset
+	to := min(end, offset+int64(len(record))) - offset
+	_, err := w.Write(record[from:to])
+	return err
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+func TestZipArchiveExpiry(t *testing.T) {
+	const ttl = time.Hour
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	objects := []models.ObjectInfo{{Key: "a.txt", Size: 3, LastModified: start}}
+
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		username string
+		want     bool
+	}{
+		{name: "just before expiry", elapsed: ttl - time.Nanosecond, username: "alice", want: true},
+		{name: "at expiry", elapsed: ttl, username: "alice", want: false},
+		{name: "other user", elapsed: 0, username: "bob", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := useManualCache(t, start)
+
+			token, archive, err := PrepareZipArchive("photos", "alice", objects, ZipDefaultPartSize, ttl)
+			if err != nil {
+				t.Fatalf("PrepareZipArchive failed: %v", err)
+			}
+			if want := start.Add(ttl); !archive.ExpiresAt.Equal(want) {
+				t.Errorf("ExpiresAt = %v, want %v", archive.ExpiresAt, want)
+			}
+
+			clk.Advance(tt.elapsed)
+			if _, ok := LookupZipArchive(token, tt.username); ok != tt.want {
+				t.Errorf("LookupZipArchive found = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestZipEntryName(t *testing.T) {
+	longKey := strings.Repeat("a/", 200) + "report.pdf"
+	longSum := sha256.Sum256([]byte(longKey))
+	dotsSum := sha256.Sum256([]byte("../.."))
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "plain key", key: "photos/2026/cat.jpg", want: "photos/2026/cat.jpg"},
+		{name: "leading slash", key: "/etc/passwd", want: "etc/passwd"},
+		{name: "parent segments", key: "../../etc/passwd", want: "etc/passwd"},
+		{name: "parent segments inside", key: "a/../../b/./c.txt", want: "b/c.txt"},
+		{name: "repeated slashes", key: "a//b", want: "a/b"},
+		{name: "nothing left", key: "../..", want: hex.EncodeToString(dotsSum[:])},
+		{name: "over-long key keeps its extension", key: longKey, want: hex.EncodeToString(longSum[:]) + ".pdf"},
+		{name: "key at the limit", key: strings.Repeat("b", zipMaxEntryName), want: strings.Repeat("b", zipMaxEntryName)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zipEntryName(tt.key); got != tt.want {
+				t.Errorf("zipEntryName(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZipEntryNamesDisambiguate(t *testing.T) {
+	hashed := func(key string, n int) string {
+		sum := sha256.Sum256([]byte(key))
+		name := hex.EncodeToString(sum[:])
+		if n > 1 {
+			name += fmt.Sprintf("-%d", n)
+		}
+		return name + path.Ext(key)
+	}
+	longKey := strings.Repeat("a/", 200) + "report.pdf"
+
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "distinct paths", keys: []string{"a/b.txt", "a/c.txt"}, want: []string{"a/b.txt", "a/c.txt"}},
+		{name: "repeated slashes", keys: []string{"a//b.txt", "a/b.txt"}, want: []string{"a/b.txt", hashed("a/b.txt", 1)}},
+		{name: "parent segments", keys: []string{"x/../y.txt", "y.txt"}, want: []string{"y.txt", hashed("y.txt", 1)}},
+		{name: "three keys for one path", keys: []string{"/y.txt", "x/../y.txt", "y.txt"}, want: []string{"y.txt", hashed("x/../y.txt", 1), hashed("y.txt", 1)}},
+		{
+			name: "key named after the hash of another",
+			keys: []string{longKey, hashed(longKey, 1)},
+			want: []string{hashed(longKey, 1), hashed(hashed(longKey, 1), 1)},
+		},
+		{
+			name: "counter once the hash is taken",
+			keys: []string{hashed("y.txt", 1), "/y.txt", "y.txt"},
+			want: []string{hashed("y.txt", 1), "y.txt", hashed("y.txt", 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make(zipEntryNames)
+			for i, key := range tt.keys {
+				if got := names.name(key); got != tt.want[i] {
+					t.Errorf("name(%q) = %q, want %q", key, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPrepareZipArchiveEntryNames(t *testing.T) {
+	useManualCache(t, time.Now())
+
+	longKey := strings.Repeat("deep/", 100) + "file.txt"
+	modified := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	objects := []models.ObjectInfo{
+		{Key: "../escape.txt", LastModified: modified},
+		{Key: "/absolute.txt", LastModified: modified},
+		{Key: "folder/", LastModified: modified},
+		{Key: longKey, LastModified: modified},
+		{Key: "plain.txt", LastModified: modified},
+		{Key: "docs//plain.txt", LastModified: modified},
+		{Key: "docs/plain.txt", LastModified: modified},
+	}
+
+	token, archive, err := PrepareZipArchive("photos", "alice", objects, ZipDefaultPartSize, time.Hour)
+	if err != nil {
+		t.Fatalf("PrepareZipArchive failed: %v", err)
+	}
+
+	// The manifest maps every entry back to its key
+	want := map[string]string{
+		"../escape.txt":   "escape.txt",
+		"/absolute.txt":   "absolute.txt",
+		longKey:           zipEntryName(longKey),
+		"plain.txt":       "plain.txt",
+		"docs//plain.txt": "docs/plain.txt",
+		"docs/plain.txt":  zipHashedName("docs/plain.txt", 1),
+	}
+	manifest := archive.Manifest(token)
+	if len(manifest.Entries) != len(want) {
+		t.Fatalf("manifest entries = %+v, want %d", manifest.Entries, len(want))
+	}
+	for _, entry := range manifest.Entries {
+		if entry.Name != want[entry.Key] {
+			t.Errorf("entry %q named %q, want %q", entry.Key, entry.Name, want[entry.Key])
+		}
+	}
+
+	// Empty objects are never read, so the whole archive can be written without S3
+	var buf bytes.Buffer
+	if err := archive.WritePart(context.Background(), nil, &buf, 0); err != nil {
+		t.Fatalf("WritePart failed: %v", err)
+	}
+	if int64(buf.Len()) != archive.Size {
+		t.Fatalf("archive is %d bytes, layout says %d", buf.Len(), archive.Size)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("archive does not parse: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i, file := range reader.File {
+		if seen[file.Name] {
+			t.Errorf("archive entry %q is stored twice", file.Name)
+		}
+		seen[file.Name] = true
+		if file.Name != manifest.Entries[i].Name {
+			t.Errorf("archive entry %d = %q, want %q", i, file.Name, manifest.Entries[i].Name)
+		}
+		if strings.HasPrefix(file.Name, "/") || strings.Contains(file.Name, "..") || len(file.Name) > zipMaxEntryName {
+			t.Errorf("unsafe archive entry %q", file.Name)
+		}
+	}
+}
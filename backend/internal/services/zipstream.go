@@ -0,0 +1,78 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"Noooste/garage-ui/internal/models"
+)
+
+// WriteZipStream writes a ZIP archive of the given keys, or of every object under prefix
+// when prefix is set, to w and returns how many objects it holds. Objects are read as they
+// are written, so nothing is buffered, and ZIP64 records are written when needed, so unlike
+// prepared archives there are no size or entry limits; but the archive cannot be resumed.
+// Entries are stored uncompressed under the names zipEntryNames hands out; unlike the
+// manifest of a prepared archive, nothing maps renamed entries back to their key. Keys that
+// cannot be found and folder markers are left out.
+func (s *S3Service) WriteZipStream(ctx context.Context, w io.Writer, bucketName string, keys []string, prefix *string) (int, error) {
+	archive := zip.NewWriter(w)
+	names := make(zipEntryNames)
+	written := 0
+
+	add := func(key string) error {
+		if strings.HasSuffix(key, "/") {
+			return nil
+		}
+
+		object, info, err := s.GetObject(ctx, bucketName, key)
+		if err != nil {
+			// Objects removed since they were selected or listed are skipped
+			if IsObjectNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		defer object.Close()
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     names.name(key),
+			Method:   zip.Store,
+			Modified: info.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(entry, object); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", key, err)
+		}
+		written++
+		return nil
+	}
+
+	var err error
+	if prefix != nil {
+		err = s.ListObjectsPages(ctx, bucketName, *prefix, true, func(page []models.ObjectInfo, _ []string) error {
+			for _, obj := range page {
+				if err := add(obj.Key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else {
+		for _, key := range slices.Compact(slices.Sorted(slices.Values(keys))) {
+			if err = add(key); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return written, err
+	}
+
+	return written, archive.Close()
+}
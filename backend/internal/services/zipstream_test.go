@@ -0,0 +1,76 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestWriteZipStreamDisambiguatesEntryNames(t *testing.T) {
+	g := newFakeGarage(t)
+	g.AddBucket("docs")
+	g.GrantKey("docs", "GKowner", true, nil)
+	contents := map[string]string{
+		"a//b.txt":   "cleaned into a/b.txt",
+		"a/b.txt":    "a/b.txt itself",
+		"x/../y.txt": "cleaned into y.txt",
+		"y.txt":      "y.txt itself",
+	}
+	for key, content := range contents {
+		g.PutObject("docs", key, "text/plain", []byte(content))
+	}
+
+	for _, tt := range []struct {
+		name   string
+		keys   []string
+		prefix *string
+	}{
+		{name: "selected keys", keys: []string{"y.txt", "a/b.txt", "x/../y.txt", "a//b.txt"}},
+		{name: "prefix", prefix: new(string)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			written, err := g.s3.WriteZipStream(context.Background(), &buf, "docs", tt.keys, tt.prefix)
+			if err != nil {
+				t.Fatalf("WriteZipStream failed: %v", err)
+			}
+			if written != len(contents) {
+				t.Errorf("written = %d, want %d", written, len(contents))
+			}
+
+			reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("archive does not parse: %v", err)
+			}
+			// Every object is extracted to a name of its own
+			stored := make(map[string]string)
+			for _, file := range reader.File {
+				if _, ok := stored[file.Name]; ok {
+					t.Errorf("archive entry %q is stored twice", file.Name)
+				}
+				entry, err := file.Open()
+				if err != nil {
+					t.Fatalf("opening %q failed: %v", file.Name, err)
+				}
+				data, err := io.ReadAll(entry)
+				entry.Close()
+				if err != nil {
+					t.Fatalf("reading %q failed: %v", file.Name, err)
+				}
+				stored[file.Name] = string(data)
+			}
+			for name, want := range map[string]string{
+				"a/b.txt":                   contents["a//b.txt"],
+				zipHashedName("a/b.txt", 1): contents["a/b.txt"],
+				"y.txt":                     contents["x/../y.txt"],
+				zipHashedName("y.txt", 1):   contents["y.txt"],
+			} {
+				if got, ok := stored[name]; !ok || got != want {
+					t.Errorf("entry %q = %q, %v, want %q", name, got, ok, want)
+				}
+			}
+		})
+	}
+}
@@ -1,27 +1,35 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"Noooste/garage-ui/internal/auth"
 	"Noooste/garage-ui/internal/config"
 	"Noooste/garage-ui/internal/handlers"
+	"Noooste/garage-ui/internal/lifecycle"
+	"Noooste/garage-ui/internal/middleware"
+	"Noooste/garage-ui/internal/models"
 	"Noooste/garage-ui/internal/routes"
 	"Noooste/garage-ui/internal/services"
+	"Noooste/garage-ui/pkg/clock"
 	"Noooste/garage-ui/pkg/logger"
+	"Noooste/garage-ui/pkg/utils"
 
 	"github.com/gofiber/fiber/v3"
-	"github.com/gofiber/fiber/v3/middleware/recover"
 )
 
 //	@title			Garage UI API
 //	@version		0.1.0
 //	@description	REST API for managing Garage distributed object storage system
 //	@description	This API provides endpoints for managing buckets, objects, users, and cluster operations.
+//	@description	Every /api/v1 route runs within the time budget of its group (server.timeouts: listing 10s, presign 5s, transfer unlimited, default 30s for everything else, unless configured otherwise; the effective values are in /api/v1/limits). Requests exceeding their budget are answered with 504 TIMEOUT and the request ID.
 //	@termsOfService	http://swagger.io/terms/
 
 //	@license.name	MIT
@@ -49,6 +57,9 @@ import (
 //	@tag.name			Monitoring
 //	@tag.description	Monitoring and metrics endpoints
 
+//	@tag.name			Admin
+//	@tag.description	Administrator-only overview endpoints
+
 //	@securityDefinitions.apikey	BearerAuth
 //	@in							header
 //	@name						Authorization
@@ -56,6 +67,9 @@ import (
 
 const version = "0.1.0"
 
+// backgroundShutdownTimeout bounds how long shutdown waits for background components
+const backgroundShutdownTimeout = 10 * time.Second
+
 func main() {
 	// Parse command-line flags
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
@@ -81,12 +95,68 @@ func main() {
 		Str("environment", cfg.Server.Environment).
 		Msg("Starting Garage UI Backend")
 
+	logger.Debug().Interface("config", cfg.Redacted()).Msg("Effective configuration")
+
 	// Initialize services
 	logger.Info().Msg("Initializing Garage Admin service")
-	adminService := services.NewGarageAdminService(&cfg.Garage, cfg.Logging.Level)
+	backendMetrics := services.NewBackendMetrics()
+	adminService := services.NewGarageAdminService(&cfg.Garage, &cfg.Logging, backendMetrics)
 
 	logger.Info().Msg("Initializing S3 service")
-	s3Service := services.NewS3Service(&cfg.Garage, adminService)
+	s3Service, err := services.NewS3Service(&cfg.Garage, adminService, backendMetrics, clock.Real)
+	if err != nil {
+		logger.Fatal().Err(err).Str("endpoint", cfg.Garage.Endpoint).Msg("Invalid Garage S3 endpoint configuration")
+	}
+
+	auditLog := services.NewAuditLog()
+	slowRequests := services.NewSlowRequestLog(cfg.Logging.SlowRequestThreshold)
+	transferStats := services.NewTransferStats()
+	throttleStats := services.NewThrottleStats()
+
+	// Background components stop when the server shuts down
+	background := lifecycle.NewManager()
+	background.Go("cache janitor", utils.GlobalCache.RunJanitor)
+
+	logger.Info().Str("settings_path", cfg.Server.SettingsPath).Msg("Initializing settings store")
+	settingsStore, err := services.NewSettingsStore(cfg.Server.SettingsPath, cfg.Garage.ReadOnlyBuckets, clock.Real)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize settings store")
+	}
+
+	// Everything served without authentication is announced at startup
+	if cfg.Public.Enabled {
+		for _, bucket := range settingsStore.PublicBuckets() {
+			logger.Info().Str("bucket", bucket).Msg("Public browsing enabled (served while the bucket has website access)")
+		}
+	}
+
+	// Diagnose the Garage connection in the background; failures are logged, not fatal
+	diagnosticsService := services.NewDiagnosticsService(&cfg.Garage, adminService)
+	background.Go("connection diagnostics", func(ctx context.Context) {
+		diagnosticsService.Run(ctx)
+	})
+
+	trashService := services.NewTrashService(s3Service, settingsStore, &cfg.Trash, clock.Real)
+	background.Go("trash sweeper", trashService.RunSweeper)
+
+	lifecycleService := services.NewLifecycleService(s3Service, settingsStore, &cfg.Lifecycle, clock.Real)
+	if cfg.Lifecycle.Enabled {
+		background.Go("lifecycle scheduler", lifecycleService.RunScheduler)
+	}
+
+	var thumbnailService *services.ThumbnailService
+	if cfg.Thumbnails.Enabled {
+		thumbnailService, err = services.NewThumbnailService(s3Service, &cfg.Thumbnails, clock.Real)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to initialize thumbnail service")
+		}
+		background.Go("thumbnail cache janitor", thumbnailService.RunJanitor)
+	}
+
+	cacheWarmer := services.NewCacheWarmer(&cfg.Monitoring, adminService)
+	background.Go("cache warmer", cacheWarmer.Run)
+
+	selfServiceKeys := services.NewSelfServiceKeys(&cfg.SelfService, adminService)
 
 	// Determine enabled auth methods for logging
 	authMethods := []string{}
@@ -100,31 +170,48 @@ func main() {
 		authMethods = append(authMethods, "none")
 	}
 	logger.Info().Strs("enabled_methods", authMethods).Msg("Initializing authentication service")
-	authService, err := auth.NewAuthService(&cfg.Auth, &cfg.Server)
+	authService, err := auth.NewAuthService(&cfg.Auth, &cfg.Server, clock.Real)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to initialize auth service")
 	}
+	background.Go("login state janitor", authService.RunStateJanitor)
+	background.Go("oidc discovery", authService.RunOIDCDiscovery)
 
 	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler(version)
-	bucketHandler := handlers.NewBucketHandler(adminService, s3Service)
-	objectHandler := handlers.NewObjectHandler(s3Service)
-	userHandler := handlers.NewUserHandler(adminService)
+	healthHandler := handlers.NewHealthHandler(version, adminService, s3Service, settingsStore)
+	notifier := services.NewNotifier(settingsStore)
+	background.Go("key notifier", notifier.Run)
+
+	keyActivity := services.NewKeyActivity(adminService, cfg.Monitoring.KeyActivityInterval)
+	background.Go("key activity sampler", keyActivity.Run)
+
+	bucketHandler := handlers.NewBucketHandler(adminService, s3Service, settingsStore, auditLog, notifier, &cfg.Server.Pagination)
+	objectHandler := handlers.NewObjectHandler(s3Service, adminService, settingsStore, trashService, transferStats, auditLog, thumbnailService, cfg, clock.Real)
+	userHandler := handlers.NewUserHandler(adminService, s3Service, settingsStore, auditLog, notifier, keyActivity, &cfg.Server.Pagination)
 	clusterHandler := handlers.NewClusterHandler(adminService)
-	monitoringHandler := handlers.NewMonitoringHandler(adminService, s3Service)
+	monitoringHandler := handlers.NewMonitoringHandler(adminService, s3Service, diagnosticsService, transferStats, throttleStats, backendMetrics, cacheWarmer)
+	supportBundle := services.NewSupportBundle(cfg, version, adminService, diagnosticsService, slowRequests)
+	selfTest := services.NewSelfTest(adminService, s3Service, cfg.Monitoring.SelfTestBucket)
+	adminHandler := handlers.NewAdminHandler(adminService, settingsStore, auditLog, notifier, supportBundle, selfTest, &cfg.Server.Pagination, clock.Real)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	lifecycleHandler := handlers.NewLifecycleHandler(lifecycleService)
+	meHandler := handlers.NewMeHandler(selfServiceKeys, &cfg.SelfService, auditLog)
+	limitsHandler := handlers.NewLimitsHandler(cfg)
+	tokenHandler := handlers.NewTokenHandler(authService, &cfg.Auth.APITokens, auditLog)
+	publicHandler := handlers.NewPublicHandler(adminService, s3Service, settingsStore, trashService, objectHandler)
 
 	// Set default values for buffer sizes if not configured
-	maxBodySize := cfg.Server.MaxBodySize
-	if maxBodySize == 0 {
-		maxBodySize = 300 * 1024 * 1024 // 300MB default
-	}
+	maxBodySize := cfg.Server.BodyLimit()
 	maxHeaderSize := cfg.Server.MaxHeaderSize
 	if maxHeaderSize == 0 {
 		maxHeaderSize = 1 * 1024 * 1024 // 1MB default
 	}
-	readBufferSize := cfg.Server.ReadBufferSize
-	if readBufferSize == 0 {
-		readBufferSize = 4096 // 4KB default
+	readBufferSize := cfg.Server.ReadBufferLimit()
+	if cfg.Server.ReadBufferSize != 0 && cfg.Server.ReadBufferSize < readBufferSize {
+		logger.Warn().
+			Int("configured", cfg.Server.ReadBufferSize).
+			Int("effective", readBufferSize).
+			Msg("server.read_buffer_size is too small for requests on long object keys, raising it")
 	}
 	writeBufferSize := cfg.Server.WriteBufferSize
 	if writeBufferSize == 0 {
@@ -136,19 +223,49 @@ func main() {
 		Float64("max_body_mb", float64(maxBodySize)/(1024*1024)).
 		Int("max_header_bytes", maxHeaderSize).
 		Float64("max_header_kb", float64(maxHeaderSize)/1024).
+		Int("read_buffer_bytes", readBufferSize).
 		Msg("Server request limits configured")
 
+	// Only honor the proxy header when the request comes from a trusted proxy
+	proxyHeader := ""
+	if len(cfg.Server.TrustedProxies) > 0 {
+		proxyHeader = cfg.Server.ProxyHeader
+		if proxyHeader == "" {
+			proxyHeader = fiber.HeaderXForwardedFor
+		}
+	}
+
+	// Clients not yet updated to the camelCase field names can have the old ones back
+	jsonEncoder := json.Marshal
+	if cfg.Server.LegacyFieldNames {
+		logger.Warn().Msg("server.legacy_field_names is set: responses use the deprecated snake_case field names")
+		jsonEncoder = models.MarshalLegacyJSON
+	}
+
 	// Create Fiber app with configuration
 	app := fiber.New(fiber.Config{
 		AppName:         "Garage UI Backend v" + version,
 		BodyLimit:       int(maxBodySize),
 		ReadBufferSize:  readBufferSize,
 		WriteBufferSize: writeBufferSize,
-		ErrorHandler:    customErrorHandler,
+		ErrorHandler:    middleware.ErrorHandler(cfg.IsProduction()),
+		JSONEncoder:     jsonEncoder,
+		TrustProxy:      len(cfg.Server.TrustedProxies) > 0,
+		TrustProxyConfig: fiber.TrustProxyConfig{
+			Proxies: cfg.Server.TrustedProxies,
+		},
+		ProxyHeader: proxyHeader,
+		// Bodies over BodyLimit are handed to the handler as a stream instead of being
+		// refused, so that streamed uploads can exceed it; BodyLimitMiddleware holds every
+		// other route to the limit
+		StreamRequestBody: true,
+		// Multipart bodies are parsed when a handler asks for them, after BodyLimitMiddleware,
+		// rather than spooled to disk whatever their size while the request is read
+		DisablePreParseMultipartForm: true,
 	})
 
 	// Apply global middleware
-	app.Use(recover.New()) // Panic recovery
+	routes.SetupMiddleware(app, cfg, settingsStore, slowRequests, throttleStats, clock.Real)
 
 	// Setup routes
 	logger.Info().Msg("Setting up routes")
@@ -156,12 +273,20 @@ func main() {
 		app,
 		cfg,
 		authService,
+		settingsStore,
 		healthHandler,
 		bucketHandler,
 		objectHandler,
 		userHandler,
 		clusterHandler,
 		monitoringHandler,
+		adminHandler,
+		trashHandler,
+		lifecycleHandler,
+		meHandler,
+		limitsHandler,
+		tokenHandler,
+		publicHandler,
 	)
 
 	// Start server in a goroutine
@@ -187,34 +312,9 @@ func main() {
 	if err := app.Shutdown(); err != nil {
 		logger.Fatal().Err(err).Msg("Server shutdown failed")
 	}
+	if err := background.Shutdown(backgroundShutdownTimeout); err != nil {
+		logger.Warn().Err(err).Msg("Background components did not stop in time")
+	}
 
 	logger.Info().Msg("Server stopped gracefully")
 }
-
-// customErrorHandler handles errors globally
-func customErrorHandler(c fiber.Ctx, err error) error {
-	// Default to 500 Internal Server Error
-	code := fiber.StatusInternalServerError
-
-	// Check if it's a Fiber error
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
-
-	// Log the error
-	logger.Error().
-		Err(err).
-		Int("status_code", code).
-		Str("method", c.Method()).
-		Str("path", c.Path()).
-		Msg("Request error")
-
-	// Return JSON error response
-	return c.Status(code).JSON(fiber.Map{
-		"success": false,
-		"error": fiber.Map{
-			"code":    fmt.Sprintf("ERROR_%d", code),
-			"message": err.Error(),
-		},
-	})
-}
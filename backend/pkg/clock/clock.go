@@ -0,0 +1,138 @@
+// Package clock abstracts the current time, so that expiry logic (sessions, login states,
+// cached credentials, maintenance windows) can be run against any instant.
+//
+// Everything expires at its expiry instant: a value expiring at t is valid strictly
+// before t, which is what Expired implements.
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+
+	// NewTicker returns a ticker ticking every d, like time.NewTicker
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, dropping ticks for slow receivers like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock, used everywhere outside tests
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+// Now returns the current system time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a time.Ticker
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+// C returns the channel the ticks are delivered on
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// Expired reports whether something expiring at expiresAt has expired at now
+func Expired(now, expiresAt time.Time) bool {
+	return !now.Before(expiresAt)
+}
+
+// Manual is a clock that only moves when told to. Its tickers tick as it is moved past
+// their next tick.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*manualTicker
+}
+
+// NewManual creates a clock stopped at now
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time the clock is stopped at
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Set moves the clock to now
+func (m *Manual) Set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+	m.tick()
+}
+
+// Advance moves the clock forward by d
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	m.tick()
+}
+
+// NewTicker returns a ticker ticking every d of the clock's time
+func (m *Manual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := &manualTicker{clock: m, c: make(chan time.Time, 1), every: d, next: m.now.Add(d)}
+	m.tickers = append(m.tickers, t)
+	return t
+}
+
+// tick delivers the ticks the clock was moved past; m.mu must be held
+func (m *Manual) tick() {
+	for _, t := range m.tickers {
+		for !m.now.Before(t.next) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.every)
+		}
+	}
+}
+
+type manualTicker struct {
+	clock *Manual
+	c     chan time.Time
+	every time.Duration
+	next  time.Time
+}
+
+// C returns the channel the ticks are delivered on
+func (t *manualTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop stops the ticker; no tick is delivered afterwards
+func (t *manualTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.tickers = slices.DeleteFunc(t.clock.tickers, func(other *manualTicker) bool {
+		return other == t
+	})
+}
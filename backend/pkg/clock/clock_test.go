@@ -0,0 +1,76 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "just before expiry", now: expiresAt.Add(-time.Nanosecond), want: false},
+		{name: "at expiry", now: expiresAt, want: true},
+		{name: "after expiry", now: expiresAt.Add(time.Nanosecond), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expired(tt.now, expiresAt); got != tt.want {
+				t.Errorf("Expired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManualTicker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		advances []time.Duration
+		stop     bool
+		want     []time.Time // Ticks received after the last advance
+	}{
+		{name: "before the first tick", advances: []time.Duration{time.Minute - time.Nanosecond}},
+		{name: "at the first tick", advances: []time.Duration{time.Minute}, want: []time.Time{start.Add(time.Minute)}},
+		{name: "ticks across advances", advances: []time.Duration{30 * time.Second, 30 * time.Second}, want: []time.Time{start.Add(time.Minute)}},
+		{name: "missed ticks are dropped", advances: []time.Duration{3 * time.Minute}, want: []time.Time{start.Add(time.Minute)}},
+		{name: "stopped ticker", advances: []time.Duration{time.Minute}, stop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := NewManual(start)
+			ticker := clk.NewTicker(time.Minute)
+			if tt.stop {
+				ticker.Stop()
+			}
+			for _, d := range tt.advances {
+				clk.Advance(d)
+			}
+
+			var got []time.Time
+			for done := false; !done; {
+				select {
+				case tick := <-ticker.C():
+					got = append(got, tick)
+				default:
+					done = true
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ticks = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("tick %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -51,8 +51,8 @@ func Init(cfg Config) {
 		level = zerolog.ErrorLevel
 	}
 
-	// Create logger
-	logger := zerolog.New(output).
+	// Create logger; lines are also kept in memory, as JSON, for support bundles
+	logger := zerolog.New(zerolog.MultiLevelWriter(output, recentLines)).
 		Level(level).
 		With().
 		Timestamp().
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"sync"
+)
+
+// recentCapacity is the number of log lines kept in memory for support bundles
+const recentCapacity = 1000
+
+// recentLines keeps the most recent log lines, as JSON, in a ring buffer
+var recentLines = &ringWriter{capacity: recentCapacity}
+
+// ringWriter is an io.Writer keeping the last lines written to it. zerolog writes one
+// event per call and reuses its buffer afterwards, so every line is copied.
+type ringWriter struct {
+	mu       sync.Mutex
+	lines    []string
+	next     int
+	capacity int
+}
+
+// Write implements io.Writer
+func (r *ringWriter) Write(p []byte) (int, error) {
+	line := string(p)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.lines) < r.capacity {
+		r.lines = append(r.lines, line)
+		return len(p), nil
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % r.capacity
+	return len(p), nil
+}
+
+// Recent returns the log lines kept in memory as JSON, oldest first. Only lines at or
+// above the configured level are kept.
+func Recent() []string {
+	r := recentLines
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]string, 0, len(r.lines))
+	for i := range r.lines {
+		// The oldest line sits at next once the buffer has wrapped
+		result = append(result, r.lines[(r.next+i)%len(r.lines)])
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// ValidateBucketName checks a bucket name against the rules Garage applies to global aliases
+func ValidateBucketName(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return errors.New("bucket name must be between 3 and 63 characters")
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '.' && r != '-' {
+			return errors.New("bucket name may only contain lowercase letters, digits, dots and dashes")
+		}
+	}
+
+	if strings.ContainsAny(name[:1], ".-") || strings.ContainsAny(name[len(name)-1:], ".-") {
+		return errors.New("bucket name must start and end with a letter or a digit")
+	}
+	if net.ParseIP(name) != nil {
+		return errors.New("bucket name must not be formatted as an IP address")
+	}
+	if strings.HasPrefix(name, "xn--") || strings.HasSuffix(name, "-s3alias") {
+		return errors.New("bucket name must not start with \"xn--\" or end with \"-s3alias\"")
+	}
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"context"
+	"strings"
 	"sync"
 	"time"
+
+	"Noooste/garage-ui/pkg/clock"
 )
 
 // CacheItem represents a cached item with expiration
@@ -15,18 +19,27 @@ type CacheItem struct {
 type Cache struct {
 	mu    sync.RWMutex
 	items map[string]CacheItem
+	clock clock.Clock
 }
 
-// NewCache creates a new cache instance
+// NewCache creates a new cache instance. Expired items are only removed from memory while
+// RunJanitor runs.
 func NewCache() *Cache {
-	c := &Cache{
+	return NewCacheWithClock(clock.Real)
+}
+
+// NewCacheWithClock creates a cache whose items expire according to clk
+func NewCacheWithClock(clk clock.Clock) *Cache {
+	return &Cache{
 		items: make(map[string]CacheItem),
+		clock: clk,
 	}
+}
 
-	// Start cleanup goroutine
-	go c.cleanupExpired()
-
-	return c
+// Now returns the current time of the clock items expire by, for expiry times announced
+// alongside cached values
+func (c *Cache) Now() time.Time {
+	return c.clock.Now()
 }
 
 // Get retrieves a value from the cache
@@ -40,7 +53,7 @@ func (c *Cache) Get(key string) interface{} {
 	}
 
 	// Check if item has expired
-	if time.Now().After(item.Expiration) {
+	if clock.Expired(c.clock.Now(), item.Expiration) {
 		return nil
 	}
 
@@ -54,7 +67,7 @@ func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
 
 	c.items[key] = CacheItem{
 		Value:      value,
-		Expiration: time.Now().Add(duration),
+		Expiration: c.clock.Now().Add(duration),
 	}
 }
 
@@ -66,6 +79,51 @@ func (c *Cache) Delete(key string) {
 	delete(c.items, key)
 }
 
+// Take removes a value from the cache and returns it, or nil if it was missing or expired.
+// Concurrent callers never receive the same value twice.
+func (c *Cache) Take(key string) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[key]
+	if !exists {
+		return nil
+	}
+	delete(c.items, key)
+
+	if clock.Expired(c.clock.Now(), item.Expiration) {
+		return nil
+	}
+	return item.Value
+}
+
+// DeletePrefix removes all values whose key starts with prefix
+func (c *Cache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+}
+
+// Values returns the unexpired values whose key starts with prefix
+func (c *Cache) Values(prefix string) []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	var values []interface{}
+	for key, item := range c.items {
+		if strings.HasPrefix(key, prefix) && !clock.Expired(now, item.Expiration) {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
+
 // Clear removes all items from the cache
 func (c *Cache) Clear() {
 	c.mu.Lock()
@@ -74,20 +132,30 @@ func (c *Cache) Clear() {
 	c.items = make(map[string]CacheItem)
 }
 
-// cleanupExpired periodically removes expired items
-func (c *Cache) cleanupExpired() {
+// RunJanitor removes expired items every five minutes until ctx is canceled
+func (c *Cache) RunJanitor(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mu.Lock()
-		now := time.Now()
-		for key, item := range c.items {
-			if now.After(item.Expiration) {
-				delete(c.items, key)
-			}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.removeExpired(c.clock.Now())
+		}
+	}
+}
+
+// removeExpired removes the items expired at now
+func (c *Cache) removeExpired(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, item := range c.items {
+		if clock.Expired(now, item.Expiration) {
+			delete(c.items, key)
 		}
-		c.mu.Unlock()
 	}
 }
 
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"mime"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// SanitizeHeaderValue strips CR, LF and every other non-printable character from a
+// user-derived value so that it cannot split or forge response headers
+func SanitizeHeaderValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// ContainsControlChars reports whether value contains a control character such as CR or LF
+func ContainsControlChars(value string) bool {
+	return strings.ContainsFunc(value, unicode.IsControl)
+}
+
+// ContentDisposition builds a Content-Disposition header value for the base name of key.
+// The filename is sanitized first and non-ASCII names are encoded as per RFC 2231.
+func ContentDisposition(disposition, key string) string {
+	filename := SanitizeHeaderValue(path.Base(key))
+	if filename == "" || filename == "." || filename == "/" {
+		return disposition
+	}
+
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); value != "" {
+		return value
+	}
+	return disposition
+}
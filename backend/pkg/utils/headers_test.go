@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHeaderValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "report.pdf", want: "report.pdf"},
+		{value: "evil\r\nSet-Cookie: a=b", want: "evilSet-Cookie: a=b"},
+		{value: "tab\there", want: "tabhere"},
+		{value: "nul\x00byte", want: "nulbyte"},
+		{value: "bad\xffutf8", want: "badutf8"},
+		{value: "résumé 2024.pdf", want: "résumé 2024.pdf"},
+		{value: `"etag-123"`, want: `"etag-123"`},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeHeaderValue(tt.value); got != tt.want {
+			t.Errorf("SanitizeHeaderValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "docs/report.pdf", want: `attachment; filename=report.pdf`},
+		{key: "docs/my report.pdf", want: `attachment; filename="my report.pdf"`},
+		{key: "docs/evil\r\nSet-Cookie: a=b.txt", want: `attachment; filename="evilSet-Cookie: a=b.txt"`},
+		{key: "docs/résumé.pdf", want: `attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf`},
+		{key: "\r\n", want: "attachment"},
+		{key: "docs/", want: "attachment; filename=docs"},
+	}
+
+	for _, tt := range tests {
+		got := ContentDisposition("attachment", tt.key)
+		if got != tt.want {
+			t.Errorf("ContentDisposition(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+		if strings.ContainsAny(got, "\r\n") {
+			t.Errorf("ContentDisposition(%q) = %q contains CR or LF", tt.key, got)
+		}
+	}
+}
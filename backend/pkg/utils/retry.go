@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -15,8 +16,27 @@ type RetryConfig struct {
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
 	BackoffFactor  float64
+
+	// Idempotent marks operations that can be repeated safely. Other operations are only
+	// retried when the connection could not be established, so the request never left.
+	Idempotent bool
+
+	// ThrottleRetries caps the retries of an idempotent operation that failed with
+	// ErrThrottled. It is zero by default, so throttling is only retried for reads.
+	ThrottleRetries int
+}
+
+// retryAttempts counts the attempts RetryWithBackoff repeated, across all callers
+var retryAttempts atomic.Int64
+
+// RetryAttempts returns how many failed attempts RetryWithBackoff has repeated so far
+func RetryAttempts() int64 {
+	return retryAttempts.Load()
 }
 
+// ErrThrottled marks failures caused by the backend asking us to slow down
+var ErrThrottled = errors.New("backend is throttling requests")
+
 // DefaultRetryConfig returns default retry configuration
 func DefaultRetryConfig() RetryConfig {
 	return RetryConfig{
@@ -24,9 +44,31 @@ func DefaultRetryConfig() RetryConfig {
 		InitialBackoff: 100 * time.Millisecond,
 		MaxBackoff:     5 * time.Second,
 		BackoffFactor:  2.0,
+		Idempotent:     true,
 	}
 }
 
+// NonIdempotentRetryConfig returns the default retry configuration for operations that
+// must not run twice, such as creations
+func NonIdempotentRetryConfig() RetryConfig {
+	config := DefaultRetryConfig()
+	config.Idempotent = false
+	return config
+}
+
+// ReadRetryConfig returns the default retry configuration for reads, which may also be
+// retried a couple of times when the backend throttles them
+func ReadRetryConfig() RetryConfig {
+	config := DefaultRetryConfig()
+	config.ThrottleRetries = 2
+	return config
+}
+
+// IsThrottled checks if the error is caused by the backend throttling requests
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
 // IsConnectionRefused checks if the error is a connection refused error
 func IsConnectionRefused(err error) bool {
 	if err == nil {
@@ -53,9 +95,30 @@ func IsConnectionRefused(err error) bool {
 	return false
 }
 
-// RetryWithBackoff executes a function with exponential backoff on connection refused errors
+// IsDialRefused checks if the error is a connection refused while dialing, which proves
+// that the request was never sent
+func IsDialRefused(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return false
+	}
+	return errors.Is(opErr.Err, syscall.ECONNREFUSED)
+}
+
+// isRetryable reports whether a failed attempt may be repeated under the given configuration
+func isRetryable(config RetryConfig, err error) bool {
+	if config.Idempotent {
+		return IsConnectionRefused(err)
+	}
+	return IsDialRefused(err)
+}
+
+// RetryWithBackoff executes a function with exponential backoff on connection refused errors.
+// Non-idempotent operations are only retried when the connection was refused while dialing,
+// and throttled operations at most config.ThrottleRetries times.
 func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error) error {
 	var lastErr error
+	throttled := 0
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		// Execute the function
@@ -66,8 +129,14 @@ func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error)
 
 		lastErr = err
 
-		// If it's not a connection refused error, don't retry
-		if !IsConnectionRefused(err) {
+		// Don't retry errors after which the request may already have been processed, nor
+		// keep pushing a throttling backend
+		if IsThrottled(err) {
+			if !config.Idempotent || throttled >= config.ThrottleRetries {
+				return err
+			}
+			throttled++
+		} else if !isRetryable(config, err) {
 			return err
 		}
 
@@ -88,6 +157,7 @@ func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error)
 			return fmt.Errorf("context cancelled during retry: %w", ctx.Err())
 		case <-time.After(backoff):
 			// Continue to next attempt
+			retryAttempts.Add(1)
 		}
 	}
 
@@ -0,0 +1,31 @@
+package utils
+
+import "time"
+
+// UTC returns t in UTC so that it is serialized as RFC3339 with a "Z" suffix.
+// Zero times are returned unchanged.
+func UTC(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC()
+}
+
+// UTCPtr is UTC for optional timestamps; nil stays nil
+func UTCPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := UTC(*t)
+	return &utc
+}
+
+// ParseTimestamp parses an RFC3339 timestamp given either with a "Z" suffix or with a
+// numeric offset (e.g. "+02:00"), with optional fractional seconds, and returns it in UTC
+func ParseTimestamp(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2030-01-01T12:00:00Z", want: want},
+		{value: "2030-01-01T14:00:00+02:00", want: want},
+		{value: "2030-01-01T07:00:00-05:00", want: want},
+		{value: "2030-01-01T12:00:00.250Z", want: want.Add(250 * time.Millisecond)},
+		{value: "2030-01-01 12:00:00", wantErr: true},
+		{value: "2030-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimestamp(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v in UTC", tt.value, got, tt.want)
+		}
+	}
+}
@@ -124,14 +124,17 @@ api.interceptors.response.use(
 export const authApi = {
   getConfig: async () => {
     const response = await authApiClient.get<{
-      admin: { enabled: boolean };
-      oidc: { enabled: boolean; provider?: string };
+      success: boolean;
+      data: {
+        admin: { enabled: boolean };
+        oidc: { enabled: boolean; provider?: string };
+      };
     }>('/config');
     return response;
   },
 
   loginAdmin: async (username: string, password: string) => {
-    const response = await authApiClient.post<{ success: boolean; token: string; user: AuthUser }>('/login', {
+    const response = await authApiClient.post<{ success: boolean; data: { token: string; user: AuthUser } }>('/login', {
       username,
       password,
     });
@@ -139,7 +142,7 @@ export const authApi = {
   },
 
   me: async () => {
-    const response = await authApiClient.get<{ success: boolean; user: AuthUser }>('/me');
+    const response = await authApiClient.get<{ success: boolean; data: { user: AuthUser } }>('/me');
     return response;
   },
 
@@ -211,10 +214,10 @@ export const objectsApi = {
     const objects: S3Object[] = data.objects?.map((obj: any) => ({
       key: obj.key,
       size: obj.size,
-      lastModified: obj.last_modified,
+      lastModified: obj.lastModified,
       etag: obj.etag,
-      contentType: obj.content_type,
-      storageClass: obj.storage_class,
+      contentType: obj.contentType,
+      storageClass: obj.storageClass,
       isFolder: false,
     })) || [];
 
@@ -230,8 +233,8 @@ export const objectsApi = {
       objects: [...folders, ...objects],
       prefixes: data.prefixes || [],
       count: data.count,
-      isTruncated: data.is_truncated || false,
-      nextContinuationToken: data.next_continuation_token,
+      isTruncated: data.isTruncated || false,
+      nextContinuationToken: data.nextContinuationToken,
     };
   },
 
@@ -248,10 +251,10 @@ export const objectsApi = {
     return {
       key: data.key,
       size: data.size,
-      lastModified: data.last_modified,
-      contentType: data.content_type,
+      lastModified: data.lastModified,
+      contentType: data.contentType,
       etag: data.etag,
-      storageClass: data.storage_class,
+      storageClass: data.storageClass,
       metadata: data.metadata,
     };
   },
@@ -323,7 +323,7 @@ export function Cluster() {
                   <div className="space-y-4">
                     <div className="rounded-lg bg-muted p-4">
                       <pre className="text-xs overflow-x-auto whitespace-pre-wrap font-mono">
-                        {statistics.freeform}
+                        {statistics.freeformText}
                       </pre>
                     </div>
                   </div>
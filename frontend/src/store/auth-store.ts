@@ -41,7 +41,7 @@ export const useAuthStore = create<AuthStore>()(
 
           // Fetch auth configuration
           const configResponse = await authApi.getConfig();
-          const config = configResponse.data as AuthConfig;
+          const config = configResponse.data.data as AuthConfig;
           set({ config });
 
           // If no auth is enabled, mark as authenticated immediately
@@ -57,7 +57,7 @@ export const useAuthStore = create<AuthStore>()(
           // Try to get current user (check if already authenticated)
           try {
             const userResponse = await authApi.me();
-            const user = userResponse.data.user;
+            const user = userResponse.data.data.user;
             set({
               user,
               isAuthenticated: true,
@@ -86,7 +86,7 @@ export const useAuthStore = create<AuthStore>()(
           set({ isLoading: true, error: null });
 
           const response = await authApi.loginAdmin(username, password);
-          const { token, user } = response.data;
+          const { token, user } = response.data.data;
 
           // Store token in localStorage
           localStorage.setItem('auth-token', token);
@@ -5,6 +5,10 @@ export interface Bucket {
   objectCount?: number;
   size?: number;
   region?: string;
+  keyCount?: number;
+  websiteAccess?: boolean;
+  hasQuota?: boolean;
+  readOnly?: boolean;
 }
 
 export interface BucketDetails extends Bucket {
@@ -170,10 +174,18 @@ export interface ClusterHealth {
 }
 
 export interface ClusterStatistics {
-  timestamp: number;
-  uptime: number;
-  freeform: string;
-  [key: string]: any;
+  freeformText: string;
+  capacity?: CapacitySummary;
+}
+
+export interface CapacitySummary {
+  nodes: number;
+  dataAvailable: number;
+  dataTotal: number;
+  dataUsedPercent: number;
+  metadataAvailable: number;
+  metadataTotal: number;
+  metadataUsedPercent: number;
 }
 
 export interface ClusterStatus {